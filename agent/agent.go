@@ -0,0 +1,142 @@
+// Package agent provides a builder for configuring agents that run on an
+// engine.
+//
+// An agent bundles a system prompt, model, tool subset and limits under a
+// name, so several agents can share one engine and tool registry:
+//
+//	support, err := agent.New("support").
+//		WithSystemPrompt("You answer account questions.").
+//		WithTools("get_balance", "get_transactions").
+//		WithMaxTurns(5).
+//		WithEngine(eng).
+//		Build()
+//	output, err := support.Run(ctx, input)
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// Builder provides a fluent interface for creating agents.
+type Builder struct {
+	name   string
+	caps   core.Capabilities
+	engine *engine.Engine
+}
+
+// New creates a new agent builder. Unset values default to
+// core.DefaultCapabilities.
+func New(name string) *Builder {
+	return &Builder{
+		name: name,
+		caps: *core.DefaultCapabilities(),
+	}
+}
+
+// WithSystemPrompt sets the agent's system prompt.
+func (b *Builder) WithSystemPrompt(prompt string) *Builder {
+	b.caps.SystemPrompt = prompt
+	return b
+}
+
+// WithModel sets the Claude model the agent uses.
+func (b *Builder) WithModel(model string) *Builder {
+	b.caps.Model = model
+	return b
+}
+
+// WithTools restricts the agent to the named tools from the engine's
+// registry. Without it, the agent can use every registered tool.
+func (b *Builder) WithTools(names ...string) *Builder {
+	b.caps.AvailableTools = append(b.caps.AvailableTools, names...)
+	return b
+}
+
+// WithMaxTurns sets the maximum number of agentic turns.
+func (b *Builder) WithMaxTurns(turns int) *Builder {
+	b.caps.MaxTurns = turns
+	return b
+}
+
+// WithMaxTokens sets the maximum response tokens per turn.
+func (b *Builder) WithMaxTokens(tokens int64) *Builder {
+	b.caps.MaxTokens = tokens
+	return b
+}
+
+// WithoutConfirmation prevents the agent from pausing for user
+// confirmation, as for sub-agents.
+func (b *Builder) WithoutConfirmation() *Builder {
+	b.caps.CanRequestConfirmation = false
+	return b
+}
+
+// WithEngine sets the engine the agent runs on. Agents built without an
+// engine can still be passed to Engine.RunAgent directly.
+func (b *Builder) WithEngine(eng *engine.Engine) *Builder {
+	b.engine = eng
+	return b
+}
+
+// Build validates the configuration and returns the agent.
+func (b *Builder) Build() (core.Agent, error) {
+	var errs []error
+	if b.name == "" {
+		errs = append(errs, errors.New("agent name is required"))
+	}
+	if b.caps.MaxTurns <= 0 {
+		errs = append(errs, fmt.Errorf("max turns must be positive, got %d", b.caps.MaxTurns))
+	}
+	if b.caps.MaxTokens <= 0 {
+		errs = append(errs, fmt.Errorf("max tokens must be positive, got %d", b.caps.MaxTokens))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	caps := b.caps
+	caps.AvailableTools = append([]string(nil), b.caps.AvailableTools...)
+	return &Agent{name: b.name, caps: caps, engine: b.engine}, nil
+}
+
+// Agent is a configured agent. It implements core.Agent.
+type Agent struct {
+	name   string
+	caps   core.Capabilities
+	engine *engine.Engine
+}
+
+// Name returns the agent's unique identifier.
+func (a *Agent) Name() string {
+	return a.name
+}
+
+// Capabilities returns a copy of the agent's configuration.
+func (a *Agent) Capabilities() *core.Capabilities {
+	caps := a.caps
+	caps.AvailableTools = append([]string(nil), a.caps.AvailableTools...)
+	return &caps
+}
+
+// Run executes the agent on its engine.
+func (a *Agent) Run(ctx context.Context, input *core.Input) (*core.Output, error) {
+	if a.engine == nil {
+		return nil, fmt.Errorf("agent %q has no engine (use WithEngine or Engine.RunAgent)", a.name)
+	}
+	if !a.caps.CanRequestConfirmation && input.Context != nil && input.Context.Limits != nil {
+		// Override on copies, leaving the caller's input as it was
+		limits := *input.Context.Limits
+		limits.CanConfirm = false
+		agentCtx := *input.Context
+		agentCtx.Limits = &limits
+		agentInput := *input
+		agentInput.Context = &agentCtx
+		input = &agentInput
+	}
+	return a.engine.RunAgent(ctx, a, input)
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/engine/enginetest"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestBuild_Capabilities(t *testing.T) {
+	a, err := New("support").
+		WithSystemPrompt("You answer account questions.").
+		WithModel("claude-test").
+		WithTools("get_balance", "get_transactions").
+		WithMaxTurns(5).
+		WithMaxTokens(1024).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if a.Name() != "support" {
+		t.Errorf("Name() = %q, want %q", a.Name(), "support")
+	}
+	want := &core.Capabilities{
+		CanRequestConfirmation: true,
+		AvailableTools:         []string{"get_balance", "get_transactions"},
+		Model:                  "claude-test",
+		MaxTokens:              1024,
+		MaxTurns:               5,
+		SystemPrompt:           "You answer account questions.",
+	}
+	if got := a.Capabilities(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Capabilities() = %+v, want %+v", got, want)
+	}
+
+	// Callers can't mutate the agent through the returned capabilities.
+	a.Capabilities().AvailableTools[0] = "send_money"
+	if got := a.Capabilities().AvailableTools[0]; got != "get_balance" {
+		t.Errorf("AvailableTools[0] = %q after mutation, want %q", got, "get_balance")
+	}
+}
+
+func TestBuild_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr bool
+	}{
+		{"defaults", New("agent"), false},
+		{"empty name", New(""), true},
+		{"zero max turns", New("agent").WithMaxTurns(0), true},
+		{"negative max turns", New("agent").WithMaxTurns(-1), true},
+		{"zero max tokens", New("agent").WithMaxTokens(0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgent_RunWithoutEngine(t *testing.T) {
+	a, err := New("agent").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if _, err := a.Run(context.Background(), &core.Input{UserMessage: "hi"}); err == nil {
+		t.Error("Run() without engine error = nil, want error")
+	}
+}
+
+func TestAgent_RunFiltersTools(t *testing.T) {
+	var request struct {
+		Model  string `json:"model"`
+		System []struct {
+			Text string `json:"text"`
+		} `json:"system"`
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-test",
+			"stop_reason": "end_turn",
+			"content":     []map[string]interface{}{{"type": "text", "text": "Done"}},
+			"usage":       map[string]interface{}{"input_tokens": 10, "output_tokens": 5},
+		})
+	}))
+	defer srv.Close()
+	client := anthropic.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test-key"), option.WithMaxRetries(0))
+
+	registry := engine.NewToolRegistry()
+	for _, name := range []string{"get_balance", "get_transactions", "send_money"} {
+		registry.Register(tools.New(name).Description(name).HandlerFunc(
+			func(ctx context.Context, input json.RawMessage) (interface{}, error) { return nil, nil },
+		).Build())
+	}
+	eng := engine.NewEngine(&client, registry)
+
+	a, err := New("analyst").
+		WithSystemPrompt("You analyze spending.").
+		WithModel("claude-test").
+		WithTools("get_transactions").
+		WithEngine(eng).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	output, err := a.Run(context.Background(), &core.Input{
+		UserMessage: "How much did I spend?",
+		Context:     core.NewContext("user-1", "session-1", "conv-1", "req-1"),
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Text != "Done" {
+		t.Errorf("Text = %q, want %q", output.Text, "Done")
+	}
+	if request.Model != "claude-test" {
+		t.Errorf("model = %q, want %q", request.Model, "claude-test")
+	}
+	if len(request.Tools) != 1 || request.Tools[0].Name != "get_transactions" {
+		t.Errorf("tools = %+v, want only get_transactions", request.Tools)
+	}
+}
+
+func TestAgent_RunWithoutConfirmationKeepsCallerLimits(t *testing.T) {
+	h := enginetest.New(t)
+	h.Claude.Queue(enginetest.Text("Done"))
+	a, err := New("analyst").WithModel("claude-test").WithoutConfirmation().WithEngine(h.Engine).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	agentCtx := core.NewContext("user-1", "session-1", "conv-1", "req-1")
+	agentCtx.Limits = &core.ExecutionLimits{MaxTurns: 5, MaxTokens: 1024, CanConfirm: true}
+	if _, err := a.Run(context.Background(), &core.Input{UserMessage: "hi", Context: agentCtx}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !agentCtx.Limits.CanConfirm {
+		t.Error("Run() cleared CanConfirm on the caller's limits")
+	}
+}
@@ -0,0 +1,11 @@
+// Package analytics provides reusable analysis of transaction history, such
+// as detecting recurring payments and subscriptions.
+//
+// Functions take the package's own Transaction type so they work with any
+// ledger; FromLiminal converts Liminal get_transactions results.
+//
+//	series := analytics.DetectRecurring(analytics.FromLiminal(resp.Transactions))
+//	for _, s := range series {
+//		fmt.Printf("%s: %.2f %s %s, next %s\n", s.Counterparty, s.Amount, s.Currency, s.Period, s.NextExpected.Format("Jan 2"))
+//	}
+package analytics
@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// FromLiminal converts Liminal transactions (from get_transactions) to
+// Transactions. Failed and cancelled transactions, and ones without a valid
+// createdAt timestamp, are skipped. Amounts are signed from the
+// transaction's direction, or its type if no direction is set.
+func FromLiminal(txns []executor.Transaction) []Transaction {
+	out := make([]Transaction, 0, len(txns))
+	for _, tx := range txns {
+		if status := strings.ToLower(tx.Status); status == "failed" || status == "cancelled" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, tx.CreatedAt)
+		if err != nil {
+			continue
+		}
+		amount, _ := strconv.ParseFloat(strings.TrimSpace(tx.Amount), 64)
+		usd, _ := strconv.ParseFloat(strings.TrimSpace(tx.USDValue), 64)
+		amount, usd = math.Abs(amount), math.Abs(usd)
+		if isOutflow(tx) {
+			amount, usd = -amount, -usd
+		}
+		out = append(out, Transaction{
+			ID:           tx.ID,
+			Counterparty: tx.Counterparty,
+			Amount:       amount,
+			Currency:     tx.Currency,
+			USDValue:     usd,
+			Time:         at,
+		})
+	}
+	return out
+}
+
+// isOutflow reports whether money left the user's wallet.
+func isOutflow(tx executor.Transaction) bool {
+	switch strings.ToLower(tx.Direction) {
+	case "outgoing", "out", "debit", "sent":
+		return true
+	case "incoming", "in", "credit", "received":
+		return false
+	}
+	switch strings.ToLower(tx.Type) {
+	case "send", "payment", "withdrawal", "transfer_out", "deposit_savings":
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(tx.Amount), "-")
+}
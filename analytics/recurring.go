@@ -0,0 +1,216 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Transaction is a single ledger entry.
+type Transaction struct {
+	ID           string
+	Counterparty string    // Who was paid or paid the user, e.g. "@netflix"
+	Amount       float64   // Signed: negative for money out, positive for money in
+	Currency     string    // e.g. "USDC"
+	USDValue     float64   // Signed USD value, or 0 if unknown
+	Time         time.Time // When the transaction happened
+}
+
+// Period is the cadence of a recurring series.
+type Period string
+
+const (
+	Weekly   Period = "weekly"
+	Biweekly Period = "biweekly"
+	Monthly  Period = "monthly"
+)
+
+// RecurringSeries is a payment repeated to (or from) the same counterparty
+// for a similar amount on a regular cadence.
+type RecurringSeries struct {
+	Counterparty string
+	Currency     string
+	Amount       float64 // Typical (median) amount, signed like Transaction.Amount
+	Period       Period
+
+	// Occurrences are the series' transactions, oldest first.
+	Occurrences []Transaction
+
+	LastSeen     time.Time
+	NextExpected time.Time // LastSeen plus one period
+
+	// Confidence is in [0, 1]: higher for more occurrences, more regular
+	// spacing and more consistent amounts.
+	Confidence float64
+}
+
+const (
+	// amountTolerance is how far (as a fraction of the typical amount) a
+	// transaction's amount may be from the series' to belong to it.
+	amountTolerance = 0.10
+
+	// minRegularity is the fraction of gaps between occurrences that must
+	// fit the period for a series to be reported.
+	minRegularity = 0.5
+)
+
+// periodWindows are the accepted gaps, in days, for each period.
+var periodWindows = []struct {
+	period   Period
+	min, max float64
+}{
+	{Weekly, 5, 9},
+	{Biweekly, 11, 17},
+	{Monthly, 26, 35},
+}
+
+// DetectRecurring finds recurring series in txns. Transactions are grouped
+// by counterparty and currency, then clustered by amount (within 10% of each
+// other, and with the same sign). A cluster is reported if it has at least
+// two occurrences whose median spacing fits a weekly, biweekly or monthly
+// period, and at least half of its gaps fit that period, so an occasional
+// late or skipped payment is tolerated but irregular spacing is not.
+// Transactions without a counterparty are ignored. Results are sorted by
+// confidence, highest first.
+func DetectRecurring(txns []Transaction) []RecurringSeries {
+	type groupKey struct{ counterparty, currency string }
+	groups := make(map[groupKey][]Transaction)
+	for _, tx := range txns {
+		if tx.Counterparty == "" || tx.Amount == 0 {
+			continue
+		}
+		key := groupKey{tx.Counterparty, tx.Currency}
+		groups[key] = append(groups[key], tx)
+	}
+
+	var series []RecurringSeries
+	for _, group := range groups {
+		for _, cluster := range clusterByAmount(group) {
+			if s, ok := detectSeries(cluster); ok {
+				series = append(series, s)
+			}
+		}
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Confidence != series[j].Confidence {
+			return series[i].Confidence > series[j].Confidence
+		}
+		if series[i].Counterparty != series[j].Counterparty {
+			return series[i].Counterparty < series[j].Counterparty
+		}
+		return series[i].Amount < series[j].Amount
+	})
+	return series
+}
+
+// clusterByAmount splits transactions into clusters of similar amounts.
+func clusterByAmount(txns []Transaction) [][]Transaction {
+	sorted := append([]Transaction(nil), txns...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount < sorted[j].Amount })
+
+	var clusters [][]Transaction
+	var current []Transaction
+	for _, tx := range sorted {
+		if len(current) > 0 {
+			first := current[0].Amount
+			if (first < 0) != (tx.Amount < 0) || math.Abs(tx.Amount-first) > math.Abs(first)*amountTolerance {
+				clusters = append(clusters, current)
+				current = nil
+			}
+		}
+		current = append(current, tx)
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+	return clusters
+}
+
+// detectSeries reports whether a cluster of similar-amount transactions
+// recurs on a regular period.
+func detectSeries(cluster []Transaction) (RecurringSeries, bool) {
+	if len(cluster) < 2 {
+		return RecurringSeries{}, false
+	}
+	occurrences := append([]Transaction(nil), cluster...)
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Time.Before(occurrences[j].Time) })
+
+	gaps := make([]float64, 0, len(occurrences)-1)
+	for i := 1; i < len(occurrences); i++ {
+		gaps = append(gaps, occurrences[i].Time.Sub(occurrences[i-1].Time).Hours()/24)
+	}
+
+	window := -1
+	medianGap := median(gaps)
+	for i, w := range periodWindows {
+		if medianGap >= w.min && medianGap <= w.max {
+			window = i
+			break
+		}
+	}
+	if window < 0 {
+		return RecurringSeries{}, false
+	}
+
+	fitting := 0
+	for _, gap := range gaps {
+		if gap >= periodWindows[window].min && gap <= periodWindows[window].max {
+			fitting++
+		}
+	}
+	regularity := float64(fitting) / float64(len(gaps))
+	if regularity < minRegularity {
+		return RecurringSeries{}, false
+	}
+
+	amounts := make([]float64, len(occurrences))
+	for i, tx := range occurrences {
+		amounts[i] = tx.Amount
+	}
+	typical := median(amounts)
+	var deviation float64
+	for _, a := range amounts {
+		deviation += math.Abs(a - typical)
+	}
+	consistency := 1 - deviation/float64(len(amounts))/math.Abs(typical)/amountTolerance
+
+	// Two occurrences are weak evidence; four or more are strong.
+	count := math.Min(1, float64(len(occurrences)-1)/3)
+	confidence := 0.4*count + 0.4*regularity + 0.2*math.Max(0, consistency)
+
+	last := occurrences[len(occurrences)-1]
+	period := periodWindows[window].period
+	return RecurringSeries{
+		Counterparty: last.Counterparty,
+		Currency:     last.Currency,
+		Amount:       typical,
+		Period:       period,
+		Occurrences:  occurrences,
+		LastSeen:     last.Time,
+		NextExpected: nextAfter(last.Time, period),
+		Confidence:   math.Round(confidence*100) / 100,
+	}, true
+}
+
+// nextAfter returns t advanced by one period.
+func nextAfter(t time.Time, period Period) time.Time {
+	switch period {
+	case Weekly:
+		return t.AddDate(0, 0, 7)
+	case Biweekly:
+		return t.AddDate(0, 0, 14)
+	default:
+		return t.AddDate(0, 1, 0)
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,103 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+var start = time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)
+
+// payments returns one payment to counterparty per offset (in days from start).
+func payments(counterparty string, amount float64, offsets ...int) []Transaction {
+	txns := make([]Transaction, len(offsets))
+	for i, days := range offsets {
+		txns[i] = Transaction{Counterparty: counterparty, Amount: amount, Currency: "USDC", Time: start.AddDate(0, 0, days)}
+	}
+	return txns
+}
+
+func TestDetectRecurringMonthly(t *testing.T) {
+	txns := payments("@netflix", -15.99, 0, 31, 59, 90)
+	txns = append(txns, payments("@cafe", -4.50, 2, 3, 11, 40)...)
+
+	series := DetectRecurring(txns)
+	if len(series) != 1 {
+		t.Fatalf("got %d series, want 1: %+v", len(series), series)
+	}
+	s := series[0]
+	if s.Counterparty != "@netflix" || s.Period != Monthly || s.Amount != -15.99 || len(s.Occurrences) != 4 {
+		t.Errorf("series = %+v", s)
+	}
+	if want := start.AddDate(0, 4, 0); !s.NextExpected.Equal(want) {
+		t.Errorf("NextExpected = %v, want %v", s.NextExpected, want)
+	}
+	if s.Confidence < 0.9 {
+		t.Errorf("Confidence = %v, want >= 0.9 for four regular payments", s.Confidence)
+	}
+}
+
+func TestDetectRecurringWeeklyTolerance(t *testing.T) {
+	// One payment a day late and one skipped week still read as weekly
+	series := DetectRecurring(payments("@gym", -10, 0, 7, 15, 22, 36))
+	if len(series) != 1 || series[0].Period != Weekly {
+		t.Fatalf("series = %+v, want one weekly", series)
+	}
+	if series[0].Confidence >= 1 {
+		t.Errorf("Confidence = %v, want < 1 with a skipped week", series[0].Confidence)
+	}
+	if want := start.AddDate(0, 0, 43); !series[0].NextExpected.Equal(want) {
+		t.Errorf("NextExpected = %v, want %v", series[0].NextExpected, want)
+	}
+}
+
+func TestDetectRecurringIrregularSpacing(t *testing.T) {
+	if series := DetectRecurring(payments("@alice", -20, 0, 3, 20, 24, 60)); len(series) != 0 {
+		t.Errorf("irregular payments detected as recurring: %+v", series)
+	}
+}
+
+func TestDetectRecurringSingleOccurrence(t *testing.T) {
+	if series := DetectRecurring(payments("@spotify", -9.99, 0)); len(series) != 0 {
+		t.Errorf("single payment detected as recurring: %+v", series)
+	}
+	if series := DetectRecurring(nil); len(series) != 0 {
+		t.Errorf("DetectRecurring(nil) = %+v", series)
+	}
+}
+
+func TestDetectRecurringSplitsByAmount(t *testing.T) {
+	// Rent and a weekly allowance to the same person are separate series;
+	// money received from them is never merged with money sent.
+	txns := payments("@landlord", -1200, 0, 30, 61)
+	txns = append(txns, payments("@landlord", -50, 1, 8, 15, 22)...)
+	txns = append(txns, payments("@landlord", 1200, 5)...)
+
+	series := DetectRecurring(txns)
+	if len(series) != 2 {
+		t.Fatalf("got %d series, want 2: %+v", len(series), series)
+	}
+	periods := map[float64]Period{}
+	for _, s := range series {
+		periods[s.Amount] = s.Period
+	}
+	if periods[-1200] != Monthly || periods[-50] != Weekly {
+		t.Errorf("periods by amount = %v", periods)
+	}
+}
+
+func TestFromLiminal(t *testing.T) {
+	txns := FromLiminal([]executor.Transaction{
+		{ID: "1", Type: "send", Amount: "15.99", Currency: "USDC", Counterparty: "@netflix", CreatedAt: "2026-01-15T09:00:00Z"},
+		{ID: "2", Type: "receive", Amount: "100", USDValue: "100", Counterparty: "@bob", CreatedAt: "2026-01-16T09:00:00Z"},
+		{ID: "3", Type: "send", Amount: "5", Status: "failed", CreatedAt: "2026-01-17T09:00:00Z"},
+		{ID: "4", Type: "send", Amount: "5", CreatedAt: "yesterday"},
+	})
+	if len(txns) != 2 {
+		t.Fatalf("got %d transactions, want 2: %+v", len(txns), txns)
+	}
+	if txns[0].Amount != -15.99 || txns[1].Amount != 100 || txns[1].USDValue != 100 {
+		t.Errorf("amounts = %v, %v", txns[0].Amount, txns[1].Amount)
+	}
+}
@@ -0,0 +1,349 @@
+// Package audit provides persistent engine.AuditLogger implementations.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver, no cgo required
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+const (
+	// bufferSize is how many entries can be queued before Log starts dropping.
+	bufferSize = 1024
+
+	// maxBatch is the most entries written in one transaction.
+	maxBatch = 100
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS audit_entries (
+	seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+	id          TEXT NOT NULL UNIQUE,
+	user_id     TEXT NOT NULL,
+	session_id  TEXT NOT NULL,
+	request_id  TEXT NOT NULL,
+	parent_id   TEXT,
+	agent_name  TEXT NOT NULL,
+	tool_name   TEXT NOT NULL,
+	tool_input  TEXT,
+	tool_output TEXT,
+	error       TEXT,
+	duration_ms INTEGER NOT NULL,
+	is_write_op INTEGER NOT NULL,
+	timestamp   INTEGER NOT NULL,
+	response_id TEXT,
+	input_tokens  INTEGER NOT NULL DEFAULT 0,
+	output_tokens INTEGER NOT NULL DEFAULT 0,
+	cache_creation_input_tokens INTEGER NOT NULL DEFAULT 0,
+	cache_read_input_tokens     INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_user ON audit_entries(user_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_parent ON audit_entries(parent_id);
+`
+
+// addedColumns were added to audit_entries after it was first released;
+// migrate adds them to databases created before.
+var addedColumns = []struct{ name, definition string }{
+	{"response_id", "TEXT"},
+	{"input_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"output_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"cache_creation_input_tokens", "INTEGER NOT NULL DEFAULT 0"},
+	{"cache_read_input_tokens", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// SQLiteLogger is an engine.AuditLogger that stores entries in SQLite.
+//
+// Writes are buffered and performed by a background goroutine, so Log never
+// blocks the request path. If the buffer is full the entry is dropped and
+// logged. Call Close to flush pending entries on shutdown.
+type SQLiteLogger struct {
+	db    *sql.DB
+	queue chan queued
+	done  chan struct{}
+
+	closeOnce sync.Once
+	mu        sync.RWMutex // Guards closed against concurrent Log/Close
+	closed    bool
+}
+
+// queued is an entry waiting to be written, or a flush marker if flushed is set.
+type queued struct {
+	entry   *engine.AuditEntry
+	flushed chan struct{}
+}
+
+// NewSQLiteLogger opens (or creates) the SQLite database at path and starts
+// the background writer. The database uses WAL mode so queries don't block
+// writes.
+func NewSQLiteLogger(path string) (*SQLiteLogger, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	// SQLite allows one writer at a time
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure audit database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create audit schema: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate audit schema: %w", err)
+	}
+
+	l := &SQLiteLogger{
+		db:    db,
+		queue: make(chan queued, bufferSize),
+		done:  make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// migrate adds any of addedColumns that audit_entries lacks.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('audit_entries')")
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range addedColumns {
+		if existing[c.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE audit_entries ADD COLUMN " + c.name + " " + c.definition); err != nil {
+			return fmt.Errorf("add column %s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// Log queues the entry for writing and returns immediately.
+func (l *SQLiteLogger) Log(ctx context.Context, entry *engine.AuditEntry) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return fmt.Errorf("audit logger is closed")
+	}
+
+	select {
+	case l.queue <- queued{entry: entry}:
+		return nil
+	default:
+		log.Printf("[AUDIT] Buffer full, dropping entry %s (%s)", entry.ID, entry.ToolName)
+		return fmt.Errorf("audit buffer full")
+	}
+}
+
+// Flush blocks until all entries logged so far have been written.
+func (l *SQLiteLogger) Flush(ctx context.Context) error {
+	l.mu.RLock()
+	if l.closed {
+		l.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case l.queue <- queued{flushed: flushed}:
+	case <-ctx.Done():
+		l.mu.RUnlock()
+		return ctx.Err()
+	}
+	l.mu.RUnlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes any pending entries and closes the database.
+func (l *SQLiteLogger) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		l.mu.Lock()
+		l.closed = true
+		close(l.queue)
+		l.mu.Unlock()
+
+		<-l.done
+		err = l.db.Close()
+	})
+	return err
+}
+
+// run writes queued entries in batches until the queue is closed.
+func (l *SQLiteLogger) run() {
+	defer close(l.done)
+
+	batch := make([]*engine.AuditEntry, 0, maxBatch)
+	var flushes []chan struct{}
+
+	for item := range l.queue {
+		batch, flushes = collect(batch[:0], flushes[:0], item)
+
+		// Drain whatever else is already queued, up to a batch
+	drain:
+		for len(batch) < maxBatch {
+			select {
+			case item, ok := <-l.queue:
+				if !ok {
+					break drain
+				}
+				batch, flushes = collect(batch, flushes, item)
+			default:
+				break drain
+			}
+		}
+
+		if len(batch) > 0 {
+			if err := l.write(batch); err != nil {
+				log.Printf("[AUDIT] Failed to write %d entries: %v", len(batch), err)
+			}
+		}
+		for _, f := range flushes {
+			close(f)
+		}
+	}
+}
+
+func collect(batch []*engine.AuditEntry, flushes []chan struct{}, item queued) ([]*engine.AuditEntry, []chan struct{}) {
+	if item.flushed != nil {
+		return batch, append(flushes, item.flushed)
+	}
+	return append(batch, item.entry), flushes
+}
+
+// write inserts a batch of entries in one transaction.
+func (l *SQLiteLogger) write(entries []*engine.AuditEntry) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO audit_entries
+			(id, user_id, session_id, request_id, parent_id, agent_name, tool_name,
+			 tool_input, tool_output, error, duration_ms, is_write_op, timestamp,
+			 response_id, input_tokens, output_tokens, cache_creation_input_tokens, cache_read_input_tokens)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range entries {
+		_, err := stmt.Exec(
+			e.ID, e.UserID, e.SessionID, e.RequestID, e.ParentID, e.AgentName, e.ToolName,
+			nullableJSON(e.ToolInput), nullableJSON(e.ToolOutput), e.Error,
+			e.DurationMs, e.IsWriteOp, e.Timestamp,
+			nullableString(e.ResponseID), e.InputTokens, e.OutputTokens, e.CacheCreationInputTokens, e.CacheReadInputTokens,
+		)
+		if err != nil {
+			return fmt.Errorf("insert entry %s: %w", e.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Query returns a user's audit trail, oldest first. If limit > 0, only the
+// most recent limit entries are returned. Pending entries are flushed first.
+func (l *SQLiteLogger) Query(ctx context.Context, userID string, limit int) ([]*engine.AuditEntry, error) {
+	if err := l.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = -1 // No limit
+	}
+	rows, err := l.db.QueryContext(ctx, `
+		SELECT id, user_id, session_id, request_id, parent_id, agent_name, tool_name,
+		       tool_input, tool_output, error, duration_ms, is_write_op, timestamp,
+		       response_id, input_tokens, output_tokens, cache_creation_input_tokens, cache_read_input_tokens
+		FROM (
+			SELECT * FROM audit_entries WHERE user_id = ? ORDER BY seq DESC LIMIT ?
+		) ORDER BY seq ASC`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*engine.AuditEntry
+	for rows.Next() {
+		var e engine.AuditEntry
+		var parentID, toolInput, toolOutput, errStr, responseID sql.NullString
+		if err := rows.Scan(
+			&e.ID, &e.UserID, &e.SessionID, &e.RequestID, &parentID, &e.AgentName, &e.ToolName,
+			&toolInput, &toolOutput, &errStr, &e.DurationMs, &e.IsWriteOp, &e.Timestamp,
+			&responseID, &e.InputTokens, &e.OutputTokens, &e.CacheCreationInputTokens, &e.CacheReadInputTokens,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if parentID.Valid {
+			e.ParentID = &parentID.String
+		}
+		if errStr.Valid {
+			e.Error = &errStr.String
+		}
+		if toolInput.Valid {
+			e.ToolInput = []byte(toolInput.String)
+		}
+		if toolOutput.Valid {
+			e.ToolOutput = []byte(toolOutput.String)
+		}
+		e.ResponseID = responseID.String
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// QueryTree returns a user's audit trail arranged as an agent call tree.
+// See Tree.
+func (l *SQLiteLogger) QueryTree(ctx context.Context, userID string, limit int) ([]*Node, error) {
+	entries, err := l.Query(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return Tree(entries), nil
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullableJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+func TestSQLiteLoggerQueryTree(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewSQLiteLogger(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteLogger() error = %v", err)
+	}
+	defer l.Close()
+
+	parent := "delegate-1"
+	failure := "insufficient funds"
+	entries := []*engine.AuditEntry{
+		{ID: "delegate-1", UserID: "alice", AgentName: "nim", ToolName: "delegate_to_analyst", ToolInput: json.RawMessage(`{"task":"analyze"}`), Timestamp: 100},
+		{ID: "balance-1", UserID: "alice", ParentID: &parent, AgentName: "analyst", ToolName: "get_balance", ToolOutput: json.RawMessage(`{"balance":"10"}`), Timestamp: 101},
+		{ID: "send-1", UserID: "alice", AgentName: "nim", ToolName: "send_money", Error: &failure, IsWriteOp: true, Timestamp: 102},
+		{ID: "other", UserID: "bob", AgentName: "nim", ToolName: "get_balance", Timestamp: 103},
+	}
+	for _, e := range entries {
+		if err := l.Log(ctx, e); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+
+	got, err := l.Query(ctx, "alice", 0)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Query() returned %d entries, want 3", len(got))
+	}
+	if got[1].ParentID == nil || *got[1].ParentID != parent {
+		t.Errorf("entry %s ParentID = %v, want %q", got[1].ID, got[1].ParentID, parent)
+	}
+	if got[2].Error == nil || *got[2].Error != failure || !got[2].IsWriteOp {
+		t.Errorf("entry %s = (error %v, write %v), want (%q, true)", got[2].ID, got[2].Error, got[2].IsWriteOp, failure)
+	}
+	if string(got[0].ToolInput) != `{"task":"analyze"}` {
+		t.Errorf("ToolInput = %s, want %s", got[0].ToolInput, `{"task":"analyze"}`)
+	}
+
+	roots, err := l.QueryTree(ctx, "alice", 0)
+	if err != nil {
+		t.Fatalf("QueryTree() error = %v", err)
+	}
+	if len(roots) != 2 || roots[0].Entry.ID != "delegate-1" || roots[1].Entry.ID != "send-1" {
+		t.Fatalf("QueryTree() roots = %v, want [delegate-1 send-1]", roots)
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].Entry.ID != "balance-1" {
+		t.Errorf("delegate-1 children = %v, want [balance-1]", roots[0].Children)
+	}
+
+	// limit keeps the most recent entries
+	got, _ = l.Query(ctx, "alice", 1)
+	if len(got) != 1 || got[0].ID != "send-1" {
+		t.Errorf("Query(limit 1) = %v, want [send-1]", got)
+	}
+}
+
+func TestSQLiteLoggerCloseFlushes(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.db")
+	l, err := NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteLogger() error = %v", err)
+	}
+	l.Log(ctx, &engine.AuditEntry{ID: "a", UserID: "alice", ToolName: "get_balance"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := l.Log(ctx, &engine.AuditEntry{ID: "b"}); err == nil {
+		t.Error("Log() after Close() error = nil, want error")
+	}
+
+	l, err = NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer l.Close()
+	got, _ := l.Query(ctx, "alice", 0)
+	if len(got) != 1 {
+		t.Errorf("Query() after reopen returned %d entries, want 1", len(got))
+	}
+}
+
+func TestSQLiteLoggerTokenUsage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.db")
+
+	// A database created before token usage was recorded
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE audit_entries (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT, id TEXT NOT NULL UNIQUE, user_id TEXT NOT NULL,
+		session_id TEXT NOT NULL, request_id TEXT NOT NULL, parent_id TEXT, agent_name TEXT NOT NULL,
+		tool_name TEXT NOT NULL, tool_input TEXT, tool_output TEXT, error TEXT,
+		duration_ms INTEGER NOT NULL, is_write_op INTEGER NOT NULL, timestamp INTEGER NOT NULL
+	);
+	INSERT INTO audit_entries (id, user_id, session_id, request_id, agent_name, tool_name, duration_ms, is_write_op, timestamp)
+	VALUES ('old', 'alice', 's', 'r', 'nim', 'get_balance', 1, 0, 99);`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+
+	l, err := NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteLogger() error = %v", err)
+	}
+	defer l.Close()
+
+	entry := &engine.AuditEntry{
+		ID: "reply-1", UserID: "alice", AgentName: "nim", Timestamp: 100,
+		ResponseID: "msg_1", InputTokens: 1200, OutputTokens: 80,
+		CacheCreationInputTokens: 300, CacheReadInputTokens: 900,
+	}
+	if err := l.Log(ctx, entry); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+
+	got, err := l.Query(ctx, "alice", 0)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Query() returned %d entries, want 2", len(got))
+	}
+	if got[0].ID != "old" || got[0].InputTokens != 0 || got[0].ResponseID != "" {
+		t.Errorf("old entry = %+v, want no token usage", got[0])
+	}
+	e := got[1]
+	if e.ResponseID != "msg_1" || e.InputTokens != 1200 || e.OutputTokens != 80 ||
+		e.CacheCreationInputTokens != 300 || e.CacheReadInputTokens != 900 {
+		t.Errorf("entry = %+v, want the logged token usage", e)
+	}
+}
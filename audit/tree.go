@@ -0,0 +1,32 @@
+package audit
+
+import "github.com/becomeliminal/nim-go-sdk/engine"
+
+// Node is an audit entry with the sub-agent tool calls it spawned.
+type Node struct {
+	Entry    *engine.AuditEntry
+	Children []*Node
+}
+
+// Tree arranges entries into agent call trees using their ParentID links.
+// Entries without a parent, or whose parent isn't in entries, are roots.
+// Roots and children keep the order of entries.
+func Tree(entries []*engine.AuditEntry) []*Node {
+	nodes := make(map[string]*Node, len(entries))
+	for _, e := range entries {
+		nodes[e.ID] = &Node{Entry: e}
+	}
+
+	var roots []*Node
+	for _, e := range entries {
+		node := nodes[e.ID]
+		if e.ParentID != nil {
+			if parent, ok := nodes[*e.ParentID]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
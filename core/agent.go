@@ -23,7 +23,8 @@ type Capabilities struct {
 	// execution to request user confirmation for write operations.
 	CanRequestConfirmation bool
 
-	// AvailableTools lists the tool names this agent can use.
+	// AvailableTools lists the tools this agent can use, by name or by
+	// engine.FilterByNames selector (e.g. "liminal_*", "-send_money").
 	AvailableTools []string
 
 	// Model is the Claude model to use (e.g., "claude-sonnet-4-20250514").
@@ -65,6 +66,9 @@ type Output struct {
 	// PendingAction is set when Type is OutputConfirmationNeeded.
 	PendingAction *PendingAction
 
+	// PendingBatch is set when Type is OutputBatchConfirmationNeeded.
+	PendingBatch *PendingActionBatch
+
 	// ToolsUsed records all tools invoked during this run.
 	ToolsUsed []ToolExecution
 
@@ -90,6 +94,10 @@ const (
 
 	// OutputError indicates an error occurred.
 	OutputError
+
+	// OutputBatchConfirmationNeeded indicates several write operations need
+	// user confirmation together.
+	OutputBatchConfirmationNeeded
 )
 
 // DefaultCapabilities returns sensible default capabilities.
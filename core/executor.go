@@ -1,10 +1,8 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"text/template"
 )
 
 // ToolExecutor executes Liminal tools (get_balance, send_money, etc.).
@@ -47,6 +45,16 @@ type ExecuteRequest struct {
 
 	// RequestID for tracing/logging.
 	RequestID string `json:"request_id,omitempty"`
+
+	// IdempotencyKey is set for confirmed writes to the pending action's
+	// ID, so the backend can reject a write it has already applied (e.g.
+	// when a confirmation is retried). Executors should forward it.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// Metadata carries request-scoped values (core.Context.Values, e.g. a
+	// trace ID from the server's AuthFunc) so executors can forward them.
+	// ExecutorTool fills it from the context.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // ExecuteResponse contains the result of tool execution.
@@ -117,10 +125,12 @@ func (t *ExecutorTool) RequiresConfirmation() bool {
 // Execute runs the tool via the ToolExecutor.
 func (t *ExecutorTool) Execute(ctx context.Context, params *ToolParams) (*ToolResult, error) {
 	req := &ExecuteRequest{
-		UserID:    params.UserID,
-		Tool:      t.definition.ToolName,
-		Input:     params.Input,
-		RequestID: params.RequestID,
+		UserID:         params.UserID,
+		Tool:           t.definition.ToolName,
+		Input:          params.Input,
+		RequestID:      params.RequestID,
+		IdempotencyKey: params.IdempotencyKey,
+		Metadata:       copyValues(ContextValues(ctx)),
 	}
 
 	var resp *ExecuteResponse
@@ -158,32 +168,23 @@ func (t *ExecutorTool) Execute(ctx context.Context, params *ToolParams) (*ToolRe
 	}, nil
 }
 
-// GetSummary returns a formatted summary using the template.
+// GetSummary returns a formatted summary using SummaryFunc if set,
+// otherwise the template (see SummaryFuncs).
 func (t *ExecutorTool) GetSummary(input json.RawMessage) string {
-	// If no template, return empty string
-	if t.definition.SummaryTemplate == "" {
-		return ""
+	if t.definition.SummaryFunc != nil {
+		return t.definition.SummaryFunc(input)
 	}
 
-	// Parse input JSON into a map for templating
-	var data map[string]interface{}
-	if err := json.Unmarshal(input, &data); err != nil {
-		// If parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
-
-	// Create and execute template
-	tmpl, err := template.New("summary").Parse(t.definition.SummaryTemplate)
-	if err != nil {
-		// If template parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+	return renderSummary(t.definition.SummaryTemplate, input)
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// If template execution fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// SummaryKey returns the localized summary's message key, if any.
+func (t *ExecutorTool) SummaryKey() string {
+	return t.definition.SummaryKey
+}
 
-	return buf.String()
+// IdempotencyInput returns the input's IdempotencyFields, or the whole
+// input if none are set.
+func (t *ExecutorTool) IdempotencyInput(input json.RawMessage) json.RawMessage {
+	return selectFields(input, t.definition.IdempotencyFields)
 }
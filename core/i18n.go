@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Localizer translates the SDK's user-facing messages: confirmation
+// summaries, the engine's built-in errors and the prevention advice it
+// records for failed actions. Messages are identified by key (see the Msg
+// constants and PreventionKey); args are the values the message refers to.
+// Localize returns the key itself for messages it doesn't translate, so
+// the English default is used instead.
+type Localizer interface {
+	Localize(key string, args map[string]interface{}) string
+}
+
+// Message keys for the SDK's built-in messages.
+const (
+	// MsgConfirmationRequired is returned to Claude when a write tool is
+	// called where confirmation isn't allowed.
+	MsgConfirmationRequired = "confirmation_required"
+
+	// MsgUnknownTool is returned to Claude for a call to an unregistered
+	// tool. Args: tool.
+	MsgUnknownTool = "unknown_tool"
+
+	// MsgThoughtRequired is returned to Claude for a write without a
+	// thought.
+	MsgThoughtRequired = "thought_required"
+
+	// MsgThoughtRequiredRead is returned to Claude for a call without a
+	// thought to a read tool that requires one. Args: tool.
+	MsgThoughtRequiredRead = "thought_required_read"
+
+	// MsgThoughtRejected is returned to Claude for a call whose thought the
+	// engine's thought validator rejected. Args: tool, reason.
+	MsgThoughtRejected = "thought_rejected"
+
+	// MsgPreventionDefault is the prevention advice for unclassified errors.
+	MsgPreventionDefault = "prevention.default"
+
+	// MsgFirstTimeRecipient warns in a send_money summary that the user
+	// hasn't paid the recipient before. Args: recipient.
+	MsgFirstTimeRecipient = "warning.first_time_recipient"
+
+	// MsgRecipientCheckFailed warns in a send_money summary that the user's
+	// payment history couldn't be checked. Args: recipient.
+	MsgRecipientCheckFailed = "warning.recipient_check_failed"
+)
+
+// PreventionKey returns the message key for advice on avoiding errorType
+// (e.g. "insufficient_balance"), specific to tool if tool is non-empty:
+// "prevention.send_money.insufficient_balance" or
+// "prevention.insufficient_balance".
+func PreventionKey(tool, errorType string) string {
+	if tool == "" {
+		return "prevention." + errorType
+	}
+	return "prevention." + tool + "." + errorType
+}
+
+// Catalog is a Localizer backed by message templates, executed against the
+// args with SummaryFuncs available: "Send {{money .amount}} to {{.recipient}}".
+type Catalog map[string]string
+
+// Localize implements Localizer.
+func (c Catalog) Localize(key string, args map[string]interface{}) string {
+	text, ok := c[key]
+	if !ok {
+		return key
+	}
+	return renderTemplate(text, args)
+}
+
+// English is the default catalog of the SDK's built-in messages.
+var English = Catalog{
+	MsgConfirmationRequired: "error: this operation requires user confirmation",
+	MsgUnknownTool:          "unknown tool: {{.tool}}",
+	MsgThoughtRequired: `Error: Missing or empty "thought" field. Write operations require explicit reasoning.
+Please explain:
+1. What you've verified (e.g., "Balance is $500, sufficient for $100 transfer")
+2. Why you're taking this action (e.g., "User requested transfer to Alice")
+3. What you expect to happen (e.g., "This will complete the payment")`,
+	MsgThoughtRequiredRead: `Error: Missing or empty "thought" field. The {{.tool}} tool requires explicit reasoning.
+Please explain what you're trying to find out and why, then call it again.`,
+	MsgThoughtRejected: `Error: The "thought" field was rejected: {{.reason}}.
+Please explain what you've verified, why you're taking this action and what you expect to happen, then call {{.tool}} again.`,
+
+	PreventionKey("send_money", "insufficient_balance"):       "Check balance with get_balance before attempting transfer",
+	PreventionKey("send_money", "not_found"):                  "Verify recipient exists with search_users before transfer",
+	PreventionKey("send_money", "invalid_input"):              "Validate amount is positive and recipient ID format is correct",
+	PreventionKey("deposit_savings", "insufficient_balance"):  "Check wallet balance before depositing to savings",
+	PreventionKey("withdraw_savings", "insufficient_balance"): "Check savings balance with get_savings_balance before withdrawal",
+	PreventionKey("", "insufficient_balance"):                 "Check balance before attempting operation",
+	PreventionKey("", "not_found"):                            "Verify the entity exists before referencing it",
+	PreventionKey("", "invalid_input"):                        "Validate input parameters before submission",
+	PreventionKey("", "rate_limit"):                           "Implement retry with backoff",
+	PreventionKey("", "timeout"):                              "Retry operation with timeout handling",
+	MsgPreventionDefault:                                      "Review error message and adjust approach accordingly",
+
+	MsgFirstTimeRecipient:   "⚠️ You've never sent money to {{.recipient}} before — double-check the tag",
+	MsgRecipientCheckFailed: "⚠️ Couldn't check whether you've sent money to {{.recipient}} before — double-check the tag",
+}
+
+type localizerKey struct{}
+
+// WithLocalizer returns a copy of ctx carrying l, which Localize and
+// LocalizedSummary use. The engine sets it for each run (see
+// engine.WithLocalizer).
+func WithLocalizer(ctx context.Context, l Localizer) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// LocalizerFromContext returns the Localizer carried by ctx, or nil.
+func LocalizerFromContext(ctx context.Context) Localizer {
+	l, _ := ctx.Value(localizerKey{}).(Localizer)
+	return l
+}
+
+// Localize translates key with ctx's Localizer, falling back to English for
+// messages it doesn't translate. Returns key if neither does.
+func Localize(ctx context.Context, key string, args map[string]interface{}) string {
+	if s, ok := localize(LocalizerFromContext(ctx), key, args); ok {
+		return s
+	}
+	return English.Localize(key, args)
+}
+
+// localize translates key with l, reporting false if l is nil or doesn't
+// translate it.
+func localize(l Localizer, key string, args map[string]interface{}) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	s := l.Localize(key, args)
+	return s, s != "" && s != key
+}
+
+// SummaryKeyer is an optional interface for tools whose confirmation
+// summary can be localized. The summary key is translated with the tool
+// input as args; tools fall back to GetSummary when it isn't translated.
+type SummaryKeyer interface {
+	SummaryKey() string
+}
+
+// LocalizedSummary returns tool's confirmation summary translated by ctx's
+// Localizer if the tool has a summary key the Localizer knows, otherwise
+// GetSummary.
+func LocalizedSummary(ctx context.Context, tool Tool, input json.RawMessage) string {
+	if sk, ok := tool.(SummaryKeyer); ok && sk.SummaryKey() != "" {
+		var args map[string]interface{}
+		if err := json.Unmarshal(input, &args); err == nil {
+			if s, ok := localize(LocalizerFromContext(ctx), sk.SummaryKey(), args); ok {
+				return s
+			}
+		}
+	}
+	return tool.GetSummary(input)
+}
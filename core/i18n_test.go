@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+var spanish = Catalog{
+	MsgUnknownTool:       "herramienta desconocida: {{.tool}}",
+	"summary.send_money": "Enviar {{money .amount}} {{.currency}} a {{.recipient}}",
+}
+
+func TestLocalize(t *testing.T) {
+	ctx := WithLocalizer(context.Background(), spanish)
+	args := map[string]interface{}{"tool": "get_weather"}
+
+	if got := Localize(ctx, MsgUnknownTool, args); got != "herramienta desconocida: get_weather" {
+		t.Errorf("Localize(translated) = %q", got)
+	}
+	// Untranslated messages fall back to English
+	if got := Localize(ctx, MsgConfirmationRequired, nil); got != English[MsgConfirmationRequired] {
+		t.Errorf("Localize(untranslated) = %q, want the English message", got)
+	}
+	if got := Localize(context.Background(), MsgUnknownTool, args); got != "unknown tool: get_weather" {
+		t.Errorf("Localize(no localizer) = %q", got)
+	}
+	if got := Localize(ctx, "no.such.key", nil); got != "no.such.key" {
+		t.Errorf("Localize(unknown key) = %q, want the key", got)
+	}
+}
+
+func TestLocalizedSummary(t *testing.T) {
+	tool := NewBaseTool(ToolDefinition{
+		ToolName:        "send_money",
+		SummaryTemplate: "Send {{money .amount}} {{.currency}} to {{.recipient}}",
+		SummaryKey:      "summary.send_money",
+	}, nil)
+	input := json.RawMessage(`{"amount": "1500", "currency": "USDC", "recipient": "@ana"}`)
+
+	ctx := WithLocalizer(context.Background(), spanish)
+	if got := LocalizedSummary(ctx, tool, input); got != "Enviar 1,500.00 USDC a @ana" {
+		t.Errorf("LocalizedSummary() = %q", got)
+	}
+	if got := LocalizedSummary(context.Background(), tool, input); got != "Send 1,500.00 USDC to @ana" {
+		t.Errorf("LocalizedSummary(no localizer) = %q, want the template summary", got)
+	}
+	if got := LocalizedSummary(WithLocalizer(context.Background(), Catalog{}), tool, input); got != "Send 1,500.00 USDC to @ana" {
+		t.Errorf("LocalizedSummary(untranslated) = %q, want the template summary", got)
+	}
+}
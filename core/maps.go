@@ -0,0 +1,18 @@
+package core
+
+import (
+	"maps"
+	"slices"
+)
+
+// SortedKeys returns m's keys in ascending order. Range over them instead
+// of the map whenever the result reaches Claude or the user (tool lists,
+// per-currency totals, summaries), since map iteration order is random and
+// would make responses, prompt caching and tests nondeterministic:
+//
+//	for _, currency := range core.SortedKeys(totals) {
+//		fmt.Fprintf(&b, "%s: %.2f\n", currency, totals[currency])
+//	}
+func SortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SummaryFuncs are the functions available in summary templates:
+//
+//	money    formats a number or numeric string with two decimals and
+//	         thousands separators: {{money .amount}} → 1,234.50
+//	default  returns a fallback when a field is missing or empty:
+//	         {{default "USD" .currency}}
+//	title    capitalizes each word: {{title .category}} → Dining Out
+//
+// Missing fields are safe to pass to any of them.
+var SummaryFuncs = template.FuncMap{
+	"money":   summaryMoney,
+	"default": summaryDefault,
+	"title":   summaryTitle,
+}
+
+// renderSummary executes a summary template against the tool input.
+// The template is returned as-is if the input isn't a JSON object or the
+// template fails to parse or execute.
+func renderSummary(text string, input json.RawMessage) string {
+	// If no template, return empty string
+	if text == "" {
+		return ""
+	}
+
+	// Parse input JSON into a map for templating
+	var data map[string]interface{}
+	if err := json.Unmarshal(input, &data); err != nil {
+		return text
+	}
+
+	return renderTemplate(text, data)
+}
+
+// renderTemplate executes text as a template against data with SummaryFuncs
+// available, returning text as-is if it fails to parse or execute.
+func renderTemplate(text string, data map[string]interface{}) string {
+	tmpl, err := template.New("summary").Funcs(SummaryFuncs).Parse(text)
+	if err != nil {
+		return text
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return text
+	}
+
+	return buf.String()
+}
+
+// summaryMoney formats v with two decimals and thousands separators.
+// Non-numeric values are returned unformatted; missing values are empty.
+func summaryMoney(v interface{}) string {
+	var f float64
+	switch n := normalizeNumber(v).(type) {
+	case nil:
+		return ""
+	case float64:
+		f = n
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return n
+		}
+		f = parsed
+	default:
+		return fmt.Sprint(v)
+	}
+
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + "." + frac
+}
+
+// summaryDefault returns v, or def if v is missing or an empty string.
+func summaryDefault(def, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return def
+	}
+	return v
+}
+
+// summaryTitle capitalizes the first letter of each word in v.
+func summaryTitle(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	words := strings.Fields(fmt.Sprint(v))
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRenderSummary_Funcs(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		input    string
+		want     string
+	}{
+		{
+			name:     "money from string",
+			template: "Send {{money .amount}} {{.currency}}",
+			input:    `{"amount": "1234.5", "currency": "USD"}`,
+			want:     "Send 1,234.50 USD",
+		},
+		{
+			name:     "money from number",
+			template: "{{money .amount}}",
+			input:    `{"amount": 1000000}`,
+			want:     "1,000,000.00",
+		},
+		{
+			name:     "money negative",
+			template: "{{money .amount}}",
+			input:    `{"amount": -999.999}`,
+			want:     "-1,000.00",
+		},
+		{
+			name:     "money non-numeric",
+			template: "{{money .amount}}",
+			input:    `{"amount": "all of it"}`,
+			want:     "all of it",
+		},
+		{
+			name:     "money missing",
+			template: "Send {{money .amount}}",
+			input:    `{}`,
+			want:     "Send ",
+		},
+		{
+			name:     "default used when missing",
+			template: "{{default \"USD\" .currency}}",
+			input:    `{}`,
+			want:     "USD",
+		},
+		{
+			name:     "default used when empty",
+			template: "{{default \"USD\" .currency}}",
+			input:    `{"currency": ""}`,
+			want:     "USD",
+		},
+		{
+			name:     "default not used when set",
+			template: "{{default \"USD\" .currency}}",
+			input:    `{"currency": "EUR"}`,
+			want:     "EUR",
+		},
+		{
+			name:     "title",
+			template: "{{title .category}}",
+			input:    `{"category": "dining out"}`,
+			want:     "Dining Out",
+		},
+		{
+			name:     "title missing",
+			template: "[{{title .category}}]",
+			input:    `{}`,
+			want:     "[]",
+		},
+		{
+			name:     "optional note present",
+			template: "Send {{money .amount}} {{.currency}} to {{.recipient}}{{with .note}} ({{.}}){{end}}",
+			input:    `{"amount": "50", "currency": "USD", "recipient": "@alice", "note": "dinner"}`,
+			want:     "Send 50.00 USD to @alice (dinner)",
+		},
+		{
+			name:     "optional note missing",
+			template: "Send {{money .amount}} {{.currency}} to {{.recipient}}{{with .note}} ({{.}}){{end}}",
+			input:    `{"amount": "50", "currency": "USD", "recipient": "@alice"}`,
+			want:     "Send 50.00 USD to @alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderSummary(tt.template, json.RawMessage(tt.input))
+			if got != tt.want {
+				t.Errorf("renderSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
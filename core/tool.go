@@ -1,10 +1,12 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"text/template"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
 // Tool is the interface for all tools available to agents.
@@ -28,6 +30,46 @@ type Tool interface {
 	GetSummary(input json.RawMessage) string
 }
 
+// ThoughtRequirer is an optional interface for tools that require a thought
+// in their input even though they don't need confirmation. The engine
+// always requires a thought for tools that need confirmation.
+type ThoughtRequirer interface {
+	// RequiresThought returns true if calls without a non-empty thought
+	// should be rejected.
+	RequiresThought() bool
+}
+
+// ContextSummarizer is an optional interface for tools whose confirmation
+// summary depends on the user or external state, e.g. a warning based on
+// their transaction history. The engine uses it instead of GetSummary when
+// creating a pending action.
+type ContextSummarizer interface {
+	// GetSummaryContext returns the confirmation summary for userID's call.
+	GetSummaryContext(ctx context.Context, userID string, input json.RawMessage) string
+}
+
+// ClaudeResultFormatter is an optional interface for tools that send Claude
+// a different view of a successful result than its full data, e.g. a compact
+// summary of a verbose response. The engine uses FormatForModel for the
+// tool_result content and falls back to the JSON-encoded data if it fails.
+// The full data is still returned to the caller in ToolExecution.Result.
+type ClaudeResultFormatter interface {
+	// FormatForModel returns the content of the tool_result sent to Claude.
+	FormatForModel(result *ToolResult) (string, error)
+}
+
+// IdempotencyKeyer is an optional interface for write tools that choose
+// which parts of their input identify a call, e.g. to ignore volatile fields
+// such as timestamps or client request IDs. The engine hashes the returned
+// input instead of the full input when generating a pending action's
+// idempotency key, so calls with the same idempotency input within the
+// deduplication window share a key. BaseTool and ExecutorTool implement it
+// using ToolDefinition.IdempotencyFields.
+type IdempotencyKeyer interface {
+	// IdempotencyInput returns the input to hash for the idempotency key.
+	IdempotencyInput(input json.RawMessage) json.RawMessage
+}
+
 // ToolParams contains all parameters needed for tool execution.
 type ToolParams struct {
 	// UserID is the authenticated user making the request.
@@ -39,6 +81,11 @@ type ToolParams struct {
 	// ConfirmationID is set for confirmed write operations.
 	ConfirmationID string
 
+	// IdempotencyKey is set for confirmed write operations to the pending
+	// action's ID. Tools that call external APIs should pass it on so a
+	// retried write isn't applied twice.
+	IdempotencyKey string
+
 	// RequestID for tracing/logging.
 	RequestID string
 
@@ -57,6 +104,11 @@ type ToolResult struct {
 	// Data is the result payload to send back to Claude.
 	Data interface{} `json:"data,omitempty"`
 
+	// Blocks is optional rich content for Claude and the UI, such as a
+	// chart image or a table as text. Claude gets Data as JSON (if set)
+	// followed by the blocks. Only text and image blocks are supported.
+	Blocks []ContentBlock `json:"blocks,omitempty"`
+
 	// Error is set on failure.
 	Error string `json:"error,omitempty"`
 
@@ -64,6 +116,94 @@ type ToolResult struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// UnmarshalData decodes Data into v by round-tripping it through JSON,
+// so v can be a struct regardless of how the tool built Data.
+func (r *ToolResult) UnmarshalData(v interface{}) error {
+	if r == nil || r.Data == nil {
+		return errors.New("tool result has no data")
+	}
+
+	var raw []byte
+	switch d := r.Data.(type) {
+	case json.RawMessage:
+		raw = d
+	case []byte:
+		raw = d
+	default:
+		var err error
+		if raw, err = json.Marshal(d); err != nil {
+			return fmt.Errorf("failed to marshal tool result data: %w", err)
+		}
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// DataString returns the top-level Data field key as a string.
+// Numbers are formatted without trailing zeros. Returns false if the field
+// is missing or isn't a string or number.
+func (r *ToolResult) DataString(key string) (string, bool) {
+	switch v := r.dataField(key).(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// DataFloat returns the top-level Data field key as a float64.
+// Numeric strings (e.g. "12.50", as APIs often return amounts) are parsed.
+// Returns false if the field is missing or isn't numeric.
+func (r *ToolResult) DataFloat(key string) (float64, bool) {
+	switch v := r.dataField(key).(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// dataField returns a top-level field of Data, normalizing Data to a
+// JSON object first. Returns nil if Data isn't an object or lacks key.
+func (r *ToolResult) dataField(key string) interface{} {
+	if r == nil {
+		return nil
+	}
+	fields, ok := r.Data.(map[string]interface{})
+	if !ok {
+		if err := r.UnmarshalData(&fields); err != nil {
+			return nil
+		}
+	}
+	return normalizeNumber(fields[key])
+}
+
+// normalizeNumber converts Go integer types (from tool-built maps) to float64,
+// matching what JSON decoding produces.
+func normalizeNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	default:
+		return v
+	}
+}
+
 // ToolDefinition contains static tool metadata.
 type ToolDefinition struct {
 	// Name is the tool's unique identifier.
@@ -73,11 +213,33 @@ type ToolDefinition struct {
 	ToolDescription string
 
 	// RequiresUserConfirmation indicates if user approval is needed.
+	// Confirmation implies RequireThought.
 	RequiresUserConfirmation bool
 
-	// SummaryTemplate is a Go template for generating summaries.
+	// RequireThought makes the engine reject calls without a non-empty
+	// "thought", even though the tool doesn't need confirmation. Use it for
+	// read tools whose reasoning should be captured for memory and audit.
+	RequireThought bool
+
+	// SummaryTemplate is a Go template for generating summaries, executed
+	// against the tool input with SummaryFuncs available.
 	SummaryTemplate string
 
+	// SummaryFunc generates the summary from the tool input in Go code, for
+	// summaries that need formatting, lookups or conditional warnings.
+	// Takes precedence over SummaryTemplate when set.
+	SummaryFunc func(input json.RawMessage) string
+
+	// SummaryKey is the message key of a localized summary, translated with
+	// the run's Localizer against the tool input. Falls back to SummaryFunc
+	// or SummaryTemplate when the Localizer doesn't translate it.
+	SummaryKey string
+
+	// IdempotencyFields lists the input fields that identify a write, e.g.
+	// "recipient", "amount" and "currency". Only these fields are hashed
+	// for its idempotency key. Empty means the whole input.
+	IdempotencyFields []string
+
 	// InputSchema is the JSON Schema for parameters.
 	InputSchema map[string]interface{}
 }
@@ -119,6 +281,12 @@ func (t *BaseTool) RequiresConfirmation() bool {
 	return t.definition.RequiresUserConfirmation
 }
 
+// RequiresThought returns whether calls must include a thought.
+// Always true for tools that require confirmation.
+func (t *BaseTool) RequiresThought() bool {
+	return t.definition.RequireThought || t.definition.RequiresUserConfirmation
+}
+
 // Execute runs the tool handler.
 func (t *BaseTool) Execute(ctx context.Context, params *ToolParams) (*ToolResult, error) {
 	if t.handler == nil {
@@ -127,34 +295,49 @@ func (t *BaseTool) Execute(ctx context.Context, params *ToolParams) (*ToolResult
 	return t.handler(ctx, params)
 }
 
-// GetSummary returns a formatted summary using the template.
+// GetSummary returns a formatted summary using SummaryFunc if set,
+// otherwise the template (see SummaryFuncs).
 func (t *BaseTool) GetSummary(input json.RawMessage) string {
-	// If no template, return empty string
-	if t.definition.SummaryTemplate == "" {
-		return ""
+	if t.definition.SummaryFunc != nil {
+		return t.definition.SummaryFunc(input)
 	}
 
-	// Parse input JSON into a map for templating
-	var data map[string]interface{}
-	if err := json.Unmarshal(input, &data); err != nil {
-		// If parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+	return renderSummary(t.definition.SummaryTemplate, input)
+}
 
-	// Create and execute template
-	tmpl, err := template.New("summary").Parse(t.definition.SummaryTemplate)
-	if err != nil {
-		// If template parsing fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// SummaryKey returns the localized summary's message key, if any.
+func (t *BaseTool) SummaryKey() string {
+	return t.definition.SummaryKey
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// If template execution fails, return the template as-is
-		return t.definition.SummaryTemplate
-	}
+// IdempotencyInput returns the input's IdempotencyFields, or the whole
+// input if none are set.
+func (t *BaseTool) IdempotencyInput(input json.RawMessage) json.RawMessage {
+	return selectFields(input, t.definition.IdempotencyFields)
+}
 
-	return buf.String()
+// selectFields returns a JSON object with only the given fields of input,
+// or input unchanged if fields is empty or input isn't an object. Missing
+// fields are omitted.
+func selectFields(input json.RawMessage, fields []string) json.RawMessage {
+	if len(fields) == 0 {
+		return input
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(input, &all); err != nil {
+		return input
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if v, ok := all[field]; ok {
+			selected[field] = v
+		}
+	}
+	out, err := json.Marshal(selected)
+	if err != nil {
+		return input
+	}
+	return out
 }
 
 // Definition returns the underlying ToolDefinition.
@@ -161,3 +161,101 @@ func TestBaseTool_GetSummary_InvalidTemplate(t *testing.T) {
 		t.Errorf("GetSummary() with invalid template = %q, want %q", got, want)
 	}
 }
+
+func TestToolResult_DataAccessors(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       interface{}
+		wantString string
+		wantFloat  float64
+		wantOK     bool
+	}{
+		{"string amount", map[string]interface{}{"amount": "12.50"}, "12.50", 12.5, true},
+		{"float amount", map[string]interface{}{"amount": 12.5}, "12.5", 12.5, true},
+		{"int amount", map[string]interface{}{"amount": 12}, "12", 12, true},
+		{"struct data", struct {
+			Amount string `json:"amount"`
+		}{"7"}, "7", 7, true},
+		{"raw JSON", json.RawMessage(`{"amount":3.25}`), "3.25", 3.25, true},
+		{"missing field", map[string]interface{}{"other": "x"}, "", 0, false},
+		{"non-object data", []string{"a"}, "", 0, false},
+		{"nil data", nil, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ToolResult{Success: true, Data: tt.data}
+			if got, ok := r.DataString("amount"); got != tt.wantString || ok != tt.wantOK {
+				t.Errorf("DataString() = (%q, %v), want (%q, %v)", got, ok, tt.wantString, tt.wantOK)
+			}
+			if got, ok := r.DataFloat("amount"); got != tt.wantFloat || ok != tt.wantOK {
+				t.Errorf("DataFloat() = (%v, %v), want (%v, %v)", got, ok, tt.wantFloat, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestToolResult_UnmarshalData(t *testing.T) {
+	r := &ToolResult{Success: true, Data: map[string]interface{}{
+		"balances": []interface{}{map[string]interface{}{"currency": "USD", "amount": "100"}},
+	}}
+
+	var got struct {
+		Balances []struct {
+			Currency string `json:"currency"`
+			Amount   string `json:"amount"`
+		} `json:"balances"`
+	}
+	if err := r.UnmarshalData(&got); err != nil {
+		t.Fatalf("UnmarshalData() error = %v", err)
+	}
+	if len(got.Balances) != 1 || got.Balances[0].Amount != "100" {
+		t.Errorf("UnmarshalData() = %+v, want one USD balance of 100", got)
+	}
+
+	if err := (&ToolResult{}).UnmarshalData(&got); err == nil {
+		t.Error("UnmarshalData() with nil Data error = nil, want error")
+	}
+}
+
+func TestBaseTool_GetSummary_SummaryFunc(t *testing.T) {
+	tool := NewBaseTool(ToolDefinition{
+		ToolName:        "send_money",
+		SummaryTemplate: "Send {{.amount}}",
+		SummaryFunc: func(input json.RawMessage) string {
+			var p struct {
+				Amount string `json:"amount"`
+			}
+			json.Unmarshal(input, &p)
+			return "Send $" + p.Amount + " (first payment to this recipient)"
+		},
+	}, nil)
+
+	got := tool.GetSummary(json.RawMessage(`{"amount": "50.00"}`))
+	want := "Send $50.00 (first payment to this recipient)"
+	if got != want {
+		t.Errorf("GetSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestBaseTool_IdempotencyInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		input  string
+		want   string
+	}{
+		{"selected fields", []string{"recipient", "amount"}, `{"amount":"10","recipient":"@alice","requested_at":"2026-01-01T10:00:00Z"}`, `{"amount":"10","recipient":"@alice"}`},
+		{"missing field", []string{"recipient", "note"}, `{"recipient":"@alice","amount":"10"}`, `{"recipient":"@alice"}`},
+		{"no fields", nil, `{"amount":"10","thought":"x"}`, `{"amount":"10","thought":"x"}`},
+		{"not an object", []string{"amount"}, `"10"`, `"10"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewBaseTool(ToolDefinition{ToolName: "send_money", IdempotencyFields: tt.fields}, nil)
+			if got := string(tool.IdempotencyInput(json.RawMessage(tt.input))); got != tt.want {
+				t.Errorf("IdempotencyInput() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
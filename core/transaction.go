@@ -0,0 +1,23 @@
+package core
+
+// TxStatus is the state of a submitted transaction.
+type TxStatus string
+
+const (
+	// TxPending means the transaction hasn't settled yet.
+	TxPending TxStatus = "pending"
+
+	// TxConfirmed means the transaction landed successfully.
+	TxConfirmed TxStatus = "confirmed"
+
+	// TxFailed means the transaction was rejected or failed to settle.
+	TxFailed TxStatus = "failed"
+
+	// TxReverted means the transaction was mined but reverted on-chain.
+	TxReverted TxStatus = "reverted"
+)
+
+// Final reports whether the status will no longer change.
+func (s TxStatus) Final() bool {
+	return s == TxConfirmed || s == TxFailed || s == TxReverted
+}
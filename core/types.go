@@ -2,8 +2,10 @@
 package core
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -43,6 +45,9 @@ type ContentBlock struct {
 
 	// ToolResult contains tool execution result (for ToolResultBlock type).
 	ToolResult *ToolResultContent `json:"tool_result,omitempty"`
+
+	// Image contains the image (for ImageBlock type).
+	Image *ImageContent `json:"image,omitempty"`
 }
 
 // ContentBlockType indicates the type of content block.
@@ -57,6 +62,9 @@ const (
 
 	// ToolResultBlockType contains the result of a tool execution.
 	ToolResultBlockType ContentBlockType = "tool_result"
+
+	// ImageBlockType contains an image, e.g. a chart returned by a tool.
+	ImageBlockType ContentBlockType = "image"
 )
 
 // ToolUseContent contains details about a tool invocation.
@@ -81,6 +89,19 @@ type ToolResultContent struct {
 
 	// IsError indicates if the tool execution failed.
 	IsError bool `json:"is_error,omitempty"`
+
+	// Blocks is rich content sent after Content, e.g. images (see
+	// ToolResult.Blocks).
+	Blocks []ContentBlock `json:"blocks,omitempty"`
+}
+
+// ImageContent is a base64-encoded image.
+type ImageContent struct {
+	// MediaType is "image/png", "image/jpeg", "image/gif" or "image/webp".
+	MediaType string `json:"media_type"`
+
+	// Data is the base64-encoded image.
+	Data string `json:"data"`
 }
 
 // Trace represents a single ReAct reasoning-action-observation cycle
@@ -146,6 +167,18 @@ func NewTextBlock(text string) ContentBlock {
 	return ContentBlock{Type: TextBlockType, Text: text}
 }
 
+// NewImageBlock creates an image content block from raw image bytes, e.g.
+// NewImageBlock("image/png", png).
+func NewImageBlock(mediaType string, data []byte) ContentBlock {
+	return ContentBlock{
+		Type: ImageBlockType,
+		Image: &ImageContent{
+			MediaType: mediaType,
+			Data:      base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
 // NewToolUseBlock creates a tool_use content block.
 func NewToolUseBlock(id, name string, input json.RawMessage) ContentBlock {
 	return ContentBlock{
@@ -215,6 +248,11 @@ type Context struct {
 
 	// StartTime is when this execution started.
 	StartTime time.Time
+
+	// Values are request-scoped values resolved during authentication
+	// (e.g., tenant ID, scopes). The engine makes them available to tools
+	// through the context.Context; read them with ContextValue.
+	Values map[string]string
 }
 
 // NewContext creates a new Context with default values.
@@ -292,6 +330,11 @@ type ExecutionLimits struct {
 	// MaxTokens is the maximum response tokens per turn.
 	MaxTokens int64
 
+	// MaxTotalTokens caps the input plus output tokens used across all turns
+	// of a run. The engine checks it before each Claude call, so the current
+	// turn always finishes. Zero means no cap.
+	MaxTotalTokens int
+
 	// Timeout is the maximum execution time.
 	Timeout time.Duration
 
@@ -414,6 +457,33 @@ type PendingAction struct {
 	ExpiresAt int64 `json:"expires_at"`
 }
 
+// PendingActionBatch groups the write actions from a single turn so the user
+// can confirm them together. The actions execute in order.
+type PendingActionBatch struct {
+	// ID is the unique identifier for this batch.
+	ID string `json:"id"`
+
+	// Actions are the batched actions, in execution order.
+	Actions []*PendingAction `json:"actions"`
+
+	// CreatedAt is when the batch was created (unix timestamp).
+	CreatedAt int64 `json:"created_at"`
+
+	// ExpiresAt is when the batch expires: when its first action expires
+	// (unix timestamp).
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// Summary returns a human-readable description of the batch: its actions'
+// summaries as a numbered list.
+func (b *PendingActionBatch) Summary() string {
+	lines := make([]string, len(b.Actions))
+	for i, action := range b.Actions {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, action.Summary)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ToolExecution records a single tool invocation.
 type ToolExecution struct {
 	// Tool is the name of the tool.
@@ -425,6 +495,10 @@ type ToolExecution struct {
 	// Result is the tool output.
 	Result interface{} `json:"result,omitempty"`
 
+	// Blocks is the tool's rich content, e.g. a chart (see
+	// ToolResult.Blocks), for the UI to render.
+	Blocks []ContentBlock `json:"blocks,omitempty"`
+
 	// Error is any error message.
 	Error string `json:"error,omitempty"`
 
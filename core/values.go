@@ -0,0 +1,46 @@
+package core
+
+import "context"
+
+type valuesKey struct{}
+
+// WithValues returns a copy of ctx carrying the given request-scoped values,
+// merged over any values already present.
+func WithValues(ctx context.Context, values map[string]string) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+	merged := make(map[string]string, len(values))
+	for k, v := range ContextValues(ctx) {
+		merged[k] = v
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, valuesKey{}, merged)
+}
+
+// ContextValues returns the request-scoped values carried by ctx, or nil.
+// The returned map must not be modified.
+func ContextValues(ctx context.Context) map[string]string {
+	values, _ := ctx.Value(valuesKey{}).(map[string]string)
+	return values
+}
+
+// copyValues returns a copy of values, or nil if it is empty.
+func copyValues(values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return copied
+}
+
+// ContextValue returns a single request-scoped value carried by ctx.
+func ContextValue(ctx context.Context, key string) (string, bool) {
+	v, ok := ContextValues(ctx)[key]
+	return v, ok
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContractCallTool is the Liminal tool that sends arbitrary calldata to a
+// contract.
+const ContractCallTool = "execute_contract_call"
+
+// ContractTarget is a contract execute_contract_call may call.
+type ContractTarget struct {
+	ChainID int64
+	Address string // 0x-prefixed; compared case-insensitively
+}
+
+// WithContractAllowlist restricts execute_contract_call to the given
+// contracts. Calls to any other chain and address are rejected before the
+// user is asked to confirm, with a reason Claude can relay. Repeated options
+// add to the allowlist; with no targets at all, every contract call is
+// blocked.
+//
+// Strongly recommended for any agent with execute_contract_call: without it,
+// a prompt-injected message can get the agent to propose a call (such as a
+// token approval) to a malicious contract, leaving the user's confirmation
+// as the only defence.
+func WithContractAllowlist(targets ...ContractTarget) Option {
+	return func(e *Engine) {
+		if e.contractAllowlist == nil {
+			e.contractAllowlist = make(map[ContractTarget]bool, len(targets))
+		}
+		for _, t := range targets {
+			e.contractAllowlist[ContractTarget{ChainID: t.ChainID, Address: strings.ToLower(t.Address)}] = true
+		}
+	}
+}
+
+// checkContractCall enforces the contract allowlist, if configured. It
+// returns a non-empty reason if the call is blocked. A call whose target
+// can't be read is blocked, since it can't be shown to be allowed.
+func (e *Engine) checkContractCall(tool string, input json.RawMessage) string {
+	if e.contractAllowlist == nil || tool != ContractCallTool {
+		return ""
+	}
+
+	var call struct {
+		ChainID json.Number `json:"chain_id"`
+		To      string      `json:"to"`
+	}
+	if err := json.Unmarshal(input, &call); err != nil || call.To == "" {
+		return "The contract call's chain and address couldn't be read, so it was blocked."
+	}
+	chainID, err := call.ChainID.Int64()
+	if err != nil {
+		return fmt.Sprintf("The contract call's chain ID %q isn't valid, so it was blocked.", call.ChainID)
+	}
+
+	if !e.contractAllowlist[ContractTarget{ChainID: chainID, Address: strings.ToLower(call.To)}] {
+		return fmt.Sprintf("Contract %s on chain %d isn't on this agent's allowlist of known contracts, so the call was blocked. Don't retry it with another address; tell the user which contract was refused.", call.To, chainID)
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestRunEnforcesContractAllowlist(t *testing.T) {
+	const usdc = "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	call := func(chainID interface{}, to string) map[string]interface{} {
+		return map[string]interface{}{"chain_id": chainID, "to": to, "data": "0x095ea7b3", "thought": "User asked to approve"}
+	}
+
+	tests := []struct {
+		name    string
+		input   map[string]interface{}
+		allowed bool
+	}{
+		{"allowed", call(42161, usdc), true},
+		{"allowed with other address case", call(42161, strings.ToLower(usdc)), true},
+		{"allowed with string chain ID", call("42161", usdc), true},
+		{"unknown contract", call(42161, "0x000000000000000000000000000000000000dEaD"), false},
+		{"known contract on another chain", call(1, usdc), false},
+		{"missing target", map[string]interface{}{"chain_id": 42161, "data": "0x", "thought": "t"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := []map[string]interface{}{toolUseResponse("toolu_1", ContractCallTool, tt.input)}
+			if !tt.allowed {
+				responses = append(responses, textResponse("I can't call that contract."))
+			}
+			fake, client := newFakeClaude(t, responses...)
+
+			registry := NewToolRegistry()
+			registry.Register(testTool(ContractCallTool, true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+				return &core.ToolResult{Success: true}, nil
+			}))
+			eng := NewEngine(client, registry, WithContractAllowlist(ContractTarget{ChainID: 42161, Address: usdc}))
+
+			output, err := eng.Run(context.Background(), testInput("approve it"))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tt.allowed {
+				if output.Type != OutputConfirmationNeeded {
+					t.Errorf("Run() = %v, want OutputConfirmationNeeded", output.Type)
+				}
+				return
+			}
+
+			if output.Type != OutputComplete {
+				t.Fatalf("Run() = %v, want OutputComplete without confirmation", output.Type)
+			}
+			messages := fake.Requests()[1]["messages"].([]interface{})
+			result := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+			text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+			if result["is_error"] != true || !strings.Contains(text, "blocked") {
+				t.Errorf("tool_result = %v, want a blocked error", result)
+			}
+		})
+	}
+}
+
+func TestExecuteToolEnforcesContractAllowlist(t *testing.T) {
+	_, client := newFakeClaude(t)
+	executed := false
+	registry := NewToolRegistry()
+	registry.Register(testTool(ContractCallTool, true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executed = true
+		return &core.ToolResult{Success: true}, nil
+	}))
+	eng := NewEngine(client, registry, WithContractAllowlist())
+
+	input := json.RawMessage(`{"chain_id":42161,"to":"0x000000000000000000000000000000000000dEaD","data":"0x"}`)
+	result, err := eng.ExecuteTool(context.Background(), "user-1", ContractCallTool, input, "conf-1")
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if result.Success || executed {
+		t.Errorf("ExecuteTool() = %+v (executed %v), want blocked with an empty allowlist", result, executed)
+	}
+}
@@ -3,6 +3,10 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/google/uuid"
 )
 
 // AuditLogger logs tool executions for compliance and debugging.
@@ -54,6 +58,110 @@ type AuditEntry struct {
 
 	// Timestamp is when the tool execution started (Unix timestamp).
 	Timestamp int64 `json:"timestamp"`
+
+	// ResponseID is the ID of the Claude response (turn) that made this
+	// call. Entries from the same turn share it and its token usage, so
+	// count each ResponseID once when adding up cost.
+	ResponseID string `json:"response_id,omitempty"`
+
+	// InputTokens, OutputTokens and the cache token counts are the Claude
+	// token usage of the turn that made this call.
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// Redactor masks sensitive data in a tool's input before it's written to the
+// audit log. It receives the tool name so redaction can differ per tool, and
+// must not modify input in place: the original input is what executes.
+type Redactor func(toolName string, input json.RawMessage) json.RawMessage
+
+// RedactedValue replaces field values masked by RedactFields.
+const RedactedValue = "[REDACTED]"
+
+// WithAuditRedactor sets a Redactor applied to tool input before audit
+// entries are built. By default nothing is redacted.
+//
+//	engine.WithAuditRedactor(engine.RedactFields("note", "amount"))
+func WithAuditRedactor(r Redactor) Option {
+	return func(e *Engine) {
+		e.redactor = r
+	}
+}
+
+// RedactFields returns a Redactor that replaces the values of the named
+// fields with RedactedValue, at any depth in the input, for every tool.
+// Input that isn't valid JSON is logged unchanged.
+func RedactFields(fields ...string) Redactor {
+	redact := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		redact[f] = true
+	}
+	return func(toolName string, input json.RawMessage) json.RawMessage {
+		var v interface{}
+		if err := json.Unmarshal(input, &v); err != nil {
+			return input
+		}
+		out, err := json.Marshal(redactValue(v, redact))
+		if err != nil {
+			return input
+		}
+		return out
+	}
+}
+
+// redactValue masks the fields in redact throughout v.
+func redactValue(v interface{}, redact map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if redact[k] {
+				val[k] = RedactedValue
+			} else {
+				val[k] = redactValue(child, redact)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child, redact)
+		}
+	}
+	return v
+}
+
+// auditInput returns the tool input as it should appear in the audit log.
+func (e *Engine) auditInput(toolName string, input json.RawMessage) json.RawMessage {
+	if e.redactor == nil {
+		return input
+	}
+	// Give the redactor its own copy so the executed input can't be altered
+	return e.redactor(toolName, append(json.RawMessage(nil), input...))
+}
+
+// withTurnUsage sets entry's turn and token usage from resp.
+func withTurnUsage(entry *AuditEntry, resp *anthropic.Message) *AuditEntry {
+	entry.ResponseID = resp.ID
+	entry.InputTokens = int(resp.Usage.InputTokens)
+	entry.OutputTokens = int(resp.Usage.OutputTokens)
+	entry.CacheCreationInputTokens = int(resp.Usage.CacheCreationInputTokens)
+	entry.CacheReadInputTokens = int(resp.Usage.CacheReadInputTokens)
+	return entry
+}
+
+// auditTurn logs an entry with an empty ToolName for a turn that executed no
+// tools, such as the final text reply or a turn that only requested
+// confirmations, so every turn's token usage is in the audit log.
+func (e *Engine) auditTurn(ctx context.Context, session *Session, cfg *loopConfig, resp *anthropic.Message) {
+	e.audit.Log(ctx, withTurnUsage(&AuditEntry{
+		ID:        uuid.New().String(),
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		RequestID: session.ID,
+		ParentID:  cfg.auditParentID,
+		AgentName: cfg.agentName,
+		Timestamp: time.Now().Unix(),
+	}, resp))
 }
 
 // NoOpAuditLogger is an audit logger that discards all entries.
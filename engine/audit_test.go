@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestRedactFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "top-level fields",
+			input: `{"amount":"50.00","note":"rent","recipient":"@alice"}`,
+			want:  `{"amount":"[REDACTED]","note":"[REDACTED]","recipient":"@alice"}`,
+		},
+		{
+			name:  "nested fields",
+			input: `{"payments":[{"note":"rent","to":"@bob"}]}`,
+			want:  `{"payments":[{"note":"[REDACTED]","to":"@bob"}]}`,
+		},
+		{
+			name:  "no matching fields",
+			input: `{"query":"alice"}`,
+			want:  `{"query":"alice"}`,
+		},
+		{
+			name:  "invalid JSON unchanged",
+			input: `not json`,
+			want:  `not json`,
+		},
+	}
+
+	redact := RedactFields("note", "amount")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redact("send_money", json.RawMessage(tt.input))
+			if string(got) != tt.want {
+				t.Errorf("RedactFields() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditRedactorOnlyAffectsLog(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "lookup", map[string]interface{}{"note": "secret"}),
+		textResponse("Done."),
+	)
+
+	var executed string
+	registry := NewToolRegistry()
+	registry.Register(testTool("lookup", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		var p struct {
+			Note string `json:"note"`
+		}
+		json.Unmarshal(params.Input, &p)
+		executed = p.Note
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	audit := NewMemoryAuditLogger()
+	eng := NewEngine(client, registry, WithAudit(audit), WithAuditRedactor(RedactFields("note")))
+	if _, err := eng.Run(context.Background(), testInput("look it up")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if executed != "secret" {
+		t.Errorf("tool executed with note %q, want %q", executed, "secret")
+	}
+	// The tool call, then the final reply's turn
+	entries := audit.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	if got, want := string(entries[0].ToolInput), `{"note":"[REDACTED]"}`; got != want {
+		t.Errorf("ToolInput = %s, want %s", got, want)
+	}
+}
+
+func TestAuditTokenUsage(t *testing.T) {
+	cached := textResponse("Done.")
+	cached["usage"] = map[string]interface{}{
+		"input_tokens": 30, "output_tokens": 8,
+		"cache_creation_input_tokens": 100, "cache_read_input_tokens": 400,
+	}
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "lookup", map[string]interface{}{}),
+		cached,
+	)
+	registry := NewToolRegistry()
+	registry.Register(testTool("lookup", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	audit := NewMemoryAuditLogger()
+	eng := NewEngine(client, registry, WithAudit(audit))
+	if _, err := eng.Run(context.Background(), testInput("look it up")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []AuditEntry{
+		{ToolName: "lookup", ResponseID: "msg_toolu_1", InputTokens: 10, OutputTokens: 5},
+		{ToolName: "", ResponseID: "msg_text", InputTokens: 30, OutputTokens: 8, CacheCreationInputTokens: 100, CacheReadInputTokens: 400},
+	}
+	entries := audit.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d audit entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		got := entries[i]
+		if got.ToolName != w.ToolName || got.ResponseID != w.ResponseID ||
+			got.InputTokens != w.InputTokens || got.OutputTokens != w.OutputTokens ||
+			got.CacheCreationInputTokens != w.CacheCreationInputTokens || got.CacheReadInputTokens != w.CacheReadInputTokens {
+			t.Errorf("entry %d = %+v, want tool %q with %+v", i, got, w.ToolName, w)
+		}
+	}
+}
+
+func TestAuditTurnAwaitingConfirmation(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", map[string]interface{}{"thought": "User asked to send $10"}),
+	)
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	audit := NewMemoryAuditLogger()
+	eng := NewEngine(client, registry, WithAudit(audit))
+	output, err := eng.Run(context.Background(), testInput("send $10"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+
+	entries := audit.Entries()
+	if len(entries) != 1 || entries[0].ToolName != "" || entries[0].InputTokens != 10 {
+		t.Errorf("entries = %+v, want one turn entry with the turn's tokens", entries)
+	}
+}
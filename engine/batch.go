@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/google/uuid"
+)
+
+// WithBatchConfirmations collects all the writes Claude asks for in one turn
+// into a single PendingActionBatch, so the user confirms e.g. a rebalance's
+// withdraw and deposit once instead of once per write. Such runs return
+// OutputBatchConfirmationNeeded; execute the batch with RunConfirmedBatch.
+// A turn with a single write still returns a PendingAction.
+func WithBatchConfirmations() Option {
+	return func(e *Engine) {
+		e.batchConfirmations = true
+	}
+}
+
+// BatchActionStatus is the outcome of one action in a confirmed batch.
+type BatchActionStatus string
+
+const (
+	// BatchActionSucceeded means the action executed successfully.
+	BatchActionSucceeded BatchActionStatus = "succeeded"
+
+	// BatchActionFailed means the action executed and failed, which stops
+	// the batch.
+	BatchActionFailed BatchActionStatus = "failed"
+
+	// BatchActionSkipped means the action didn't execute because an earlier
+	// action failed or the run was cancelled.
+	BatchActionSkipped BatchActionStatus = "skipped"
+)
+
+// BatchActionResult reports what happened to one action of a batch.
+type BatchActionResult struct {
+	Action *core.PendingAction
+	Status BatchActionStatus
+	Result interface{}         // Tool result data, if the action succeeded
+	Blocks []core.ContentBlock // Rich tool result content, if any
+	Error  string              // Why the action failed or was skipped
+}
+
+// newPendingBatch groups a turn's pending actions into a batch.
+func newPendingBatch(actions []*core.PendingAction) *core.PendingActionBatch {
+	batch := &core.PendingActionBatch{
+		ID:        uuid.New().String(),
+		Actions:   actions,
+		CreatedAt: time.Now().Unix(),
+	}
+	for _, action := range actions {
+		if batch.ExpiresAt == 0 || action.ExpiresAt < batch.ExpiresAt {
+			batch.ExpiresAt = action.ExpiresAt
+		}
+	}
+	return batch
+}
+
+// RunConfirmedBatch executes a confirmed batch's actions in order and resumes
+// the ReAct loop, like RunConfirmedAction. The first action that fails stops
+// the batch: the remaining actions are skipped, and Claude gets a result for
+// every action so it can tell the user what happened. Output.BatchResults
+// reports each action's outcome, including when the resumed loop fails.
+func (e *Engine) RunConfirmedBatch(ctx context.Context, input *Input, batch *core.PendingActionBatch) (*Output, error) {
+	start := time.Now()
+	output, err := e.runConfirmedBatch(ctx, input, batch)
+	e.emitRunCompleted(ctx, input, start, output, err)
+	return output, err
+}
+
+func (e *Engine) runConfirmedBatch(ctx context.Context, input *Input, batch *core.PendingActionBatch) (*Output, error) {
+	ctx = e.requestContext(ctx, input.Context)
+	if batch == nil || len(batch.Actions) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	// Look up every tool first, so an unknown tool fails the batch before
+	// anything executes
+	tools := make([]core.Tool, len(batch.Actions))
+	for i, action := range batch.Actions {
+		tool, ok := e.registry.Get(action.Tool)
+		if !ok {
+			return nil, fmt.Errorf("unknown tool: %s", action.Tool)
+		}
+		tools[i] = tool
+	}
+
+	// Restore history - this includes the batch's tool_use blocks
+	session, restored, err := e.restoreSession(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchActionResult, len(batch.Actions))
+	toolResults := make([]anthropic.ContentBlockParamUnion, len(batch.Actions))
+	var executions []core.ToolExecution
+	stopped := ""
+	for i, action := range batch.Actions {
+		results[i].Action = action
+
+		if stopped == "" && ctx.Err() != nil {
+			stopped = "the run was cancelled"
+		}
+		if stopped != "" {
+			reason := fmt.Sprintf("skipped: %s", stopped)
+			results[i].Status = BatchActionSkipped
+			results[i].Error = reason
+			toolResults[i] = anthropic.NewToolResultBlock(action.BlockID, reason, true)
+			continue
+		}
+
+		confirmed, err := e.executeConfirmed(ctx, input, session, tools[i], action)
+		if err != nil {
+			// Earlier actions may have executed, so report this as the
+			// failure that stopped the batch rather than failing the run
+			confirmed.execution = core.ToolExecution{Tool: action.Tool, Error: err.Error()}
+			confirmed.block = anthropic.NewToolResultBlock(action.BlockID, err.Error(), true)
+		}
+		toolResults[i] = confirmed.block
+		executions = append(executions, confirmed.execution)
+
+		if confirmed.succeeded {
+			results[i].Status = BatchActionSucceeded
+			results[i].Result = confirmed.execution.Result
+			results[i].Blocks = confirmed.execution.Blocks
+		} else {
+			results[i].Status = BatchActionFailed
+			results[i].Error = confirmed.execution.Error
+			stopped = fmt.Sprintf("%s failed earlier in the batch", action.Tool)
+			e.logger.WarnContext(ctx, "batch stopped", "user_id", session.UserID, "batch_id", batch.ID, "tool", action.Tool, "succeeded", i)
+		}
+	}
+
+	// Add the tool results to session (the tool_use blocks are already in history from RestoreHistory)
+	session.AddToolResults(toolResults)
+	e.logger.DebugContext(ctx, "resuming loop after batch confirmation", "user_id", session.UserID, "batch_id", batch.ID)
+
+	output, err := e.resumeLoop(ctx, input, session, restored)
+	if output == nil {
+		output = &Output{Type: OutputError, Error: err}
+	}
+	output.BatchResults = results
+
+	// Prepend the batch's tool executions to ToolsUsed
+	output.ToolsUsed = append(executions, output.ToolsUsed...)
+
+	return output, err
+}
@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// rebalanceResponse asks for a withdraw and a deposit in the same turn.
+func rebalanceResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "msg_rebalance",
+		"type":        "message",
+		"role":        "assistant",
+		"model":       "claude-test",
+		"stop_reason": "tool_use",
+		"content": []map[string]interface{}{
+			{"type": "text", "text": "Moving your USDC to Morpho."},
+			{"type": "tool_use", "id": "toolu_1", "name": "withdraw", "input": map[string]interface{}{"amount": "100", "thought": "Aave yield dropped"}},
+			{"type": "tool_use", "id": "toolu_2", "name": "deposit", "input": map[string]interface{}{"amount": "100", "thought": "Morpho pays more"}},
+		},
+		"usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 5},
+	}
+}
+
+func TestRunBatchConfirmations(t *testing.T) {
+	var executed []string
+	handler := func(name string, success bool) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			executed = append(executed, name)
+			if !success {
+				return &core.ToolResult{Success: false, Error: "insufficient liquidity"}, nil
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{"tx": name}}, nil
+		}
+	}
+
+	tests := []struct {
+		name         string
+		withdrawOK   bool
+		wantExecuted []string
+		wantStatus   []BatchActionStatus
+	}{
+		{
+			name:         "all succeed",
+			withdrawOK:   true,
+			wantExecuted: []string{"withdraw", "deposit"},
+			wantStatus:   []BatchActionStatus{BatchActionSucceeded, BatchActionSucceeded},
+		},
+		{
+			name:         "failure stops the batch",
+			withdrawOK:   false,
+			wantExecuted: []string{"withdraw"},
+			wantStatus:   []BatchActionStatus{BatchActionFailed, BatchActionSkipped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executed = nil
+			fake, client := newFakeClaude(t, rebalanceResponse(), textResponse("Done."))
+
+			registry := NewToolRegistry()
+			registry.Register(testTool("withdraw", true, handler("withdraw", tt.withdrawOK)))
+			registry.Register(testTool("deposit", true, handler("deposit", true)))
+			eng := NewEngine(client, registry, WithBatchConfirmations())
+
+			output, err := eng.Run(context.Background(), testInput("rebalance"))
+			if err != nil || output.Type != OutputBatchConfirmationNeeded {
+				t.Fatalf("Run() = (%v, %v), want OutputBatchConfirmationNeeded", output.Type, err)
+			}
+			batch := output.PendingBatch
+			if len(batch.Actions) != 2 || batch.Actions[0].Tool != "withdraw" || batch.Actions[1].Tool != "deposit" {
+				t.Fatalf("batch actions = %+v, want withdraw then deposit", batch.Actions)
+			}
+			if len(executed) != 0 {
+				t.Fatalf("executed %v before confirmation", executed)
+			}
+
+			input := testInput("")
+			input.History = []core.Message{
+				core.NewUserMessage("rebalance"),
+				core.NewAssistantMessageWithBlocks(output.ResponseBlocks),
+			}
+			output, err = eng.RunConfirmedBatch(context.Background(), input, batch)
+			if err != nil || output.Type != OutputComplete {
+				t.Fatalf("RunConfirmedBatch() = (%v, %v), want OutputComplete", output.Type, err)
+			}
+
+			if len(executed) != len(tt.wantExecuted) {
+				t.Fatalf("executed %v, want %v", executed, tt.wantExecuted)
+			}
+			for i, name := range tt.wantExecuted {
+				if executed[i] != name {
+					t.Errorf("executed %v, want %v", executed, tt.wantExecuted)
+				}
+			}
+			for i, want := range tt.wantStatus {
+				if got := output.BatchResults[i].Status; got != want {
+					t.Errorf("BatchResults[%d].Status = %q, want %q", i, got, want)
+				}
+			}
+
+			// Claude got a tool_result for every action in the batch.
+			messages := fake.Requests()[1]["messages"].([]interface{})
+			results := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+			if len(results) != 2 {
+				t.Fatalf("follow-up request has %d tool results, want 2", len(results))
+			}
+			for i, want := range tt.wantStatus {
+				isError, _ := results[i].(map[string]interface{})["is_error"].(bool)
+				if isError != (want != BatchActionSucceeded) {
+					t.Errorf("tool result %d is_error = %v, want %v", i, isError, want != BatchActionSucceeded)
+				}
+			}
+		})
+	}
+}
+
+// rebalancer is a minimal core.Agent for RunAgent.
+type rebalancer struct{}
+
+func (rebalancer) Run(ctx context.Context, input *core.Input) (*core.Output, error) {
+	return nil, nil
+}
+
+func (rebalancer) Capabilities() *core.Capabilities {
+	return &core.Capabilities{CanRequestConfirmation: true, Model: "claude-test", MaxTurns: 5}
+}
+
+func (rebalancer) Name() string { return "rebalancer" }
+
+func TestRunAgentBatchConfirmations(t *testing.T) {
+	_, client := newFakeClaude(t, rebalanceResponse())
+
+	registry := NewToolRegistry()
+	for _, name := range []string{"withdraw", "deposit"} {
+		registry.Register(testTool(name, true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true}, nil
+		}))
+	}
+	eng := NewEngine(client, registry, WithBatchConfirmations())
+
+	input := testInput("rebalance")
+	output, err := eng.RunAgent(context.Background(), rebalancer{}, &core.Input{UserMessage: input.UserMessage, Context: input.Context})
+	if err != nil || output.Type != core.OutputBatchConfirmationNeeded {
+		t.Fatalf("RunAgent() = (%v, %v), want OutputBatchConfirmationNeeded", output.Type, err)
+	}
+	if output.PendingBatch == nil || len(output.PendingBatch.Actions) != 2 {
+		t.Errorf("PendingBatch = %+v, want withdraw and deposit", output.PendingBatch)
+	}
+}
+
+func TestRunWithoutBatchConfirmationsConfirmsFirstWrite(t *testing.T) {
+	_, client := newFakeClaude(t, rebalanceResponse())
+
+	registry := NewToolRegistry()
+	for _, name := range []string{"withdraw", "deposit"} {
+		registry.Register(testTool(name, true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			return &core.ToolResult{Success: true}, nil
+		}))
+	}
+	eng := NewEngine(client, registry)
+
+	output, err := eng.Run(context.Background(), testInput("rebalance"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+	if output.PendingAction.Tool != "withdraw" || output.PendingBatch != nil {
+		t.Errorf("PendingAction = %+v, PendingBatch = %+v, want only withdraw", output.PendingAction, output.PendingBatch)
+	}
+}
+
+func TestRunConfirmedBatchIdenticalActions(t *testing.T) {
+	send := map[string]interface{}{"recipient": "@alice", "amount": "20", "thought": "User asked for two $20 payments"}
+	response := toolUseResponse("toolu_1", "send_money", send)
+	response["content"] = append(response["content"].([]map[string]interface{}),
+		map[string]interface{}{"type": "tool_use", "id": "toolu_2", "name": "send_money", "input": send})
+	_, client := newFakeClaude(t, response, textResponse("Sent $20 twice."))
+
+	executions := 0
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executions++
+		return &core.ToolResult{Success: true}, nil
+	}))
+	eng := NewEngine(client, registry, WithBatchConfirmations(), WithIdempotency(store.NewMemoryIdempotency(), 0))
+
+	output, err := eng.Run(context.Background(), testInput("send alice $20 twice"))
+	if err != nil || output.Type != OutputBatchConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputBatchConfirmationNeeded", output.Type, err)
+	}
+	input := testInput("")
+	input.History = []core.Message{
+		core.NewUserMessage("send alice $20 twice"),
+		core.NewAssistantMessageWithBlocks(output.ResponseBlocks),
+	}
+	output, err = eng.RunConfirmedBatch(context.Background(), input, output.PendingBatch)
+	if err != nil || output.Type != OutputComplete {
+		t.Fatalf("RunConfirmedBatch() = (%v, %v), want OutputComplete", output.Type, err)
+	}
+	if executions != 2 {
+		t.Errorf("send_money executed %d times, want 2", executions)
+	}
+	for i, result := range output.BatchResults {
+		if result.Status != BatchActionSucceeded {
+			t.Errorf("BatchResults[%d] = %q (%s), want succeeded", i, result.Status, result.Error)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// successToolResult builds the tool_result for a successful call: the
+// result's data for Claude (see toolResultContent) with note appended,
+// followed by the result's content blocks. A result with blocks and no data
+// sends only the blocks.
+func (e *Engine) successToolResult(ctx context.Context, blockID string, tool core.Tool, result *core.ToolResult, trace *core.Trace, note string) anthropic.ContentBlockParamUnion {
+	var blocks []core.ContentBlock
+	if result != nil {
+		blocks = result.Blocks
+	}
+	text := note
+	if len(blocks) == 0 || result.Data != nil {
+		text = e.toolResultContent(ctx, tool, result, trace) + note
+	}
+	return toolResultBlock(blockID, text, blocks, false)
+}
+
+// toolResultBlock builds a tool_result with text followed by blocks. Text
+// and image blocks are sent; other block types are skipped. Empty text is
+// omitted when there are blocks.
+func toolResultBlock(toolUseID, text string, blocks []core.ContentBlock, isError bool) anthropic.ContentBlockParamUnion {
+	var content []anthropic.ToolResultBlockParamContentUnion
+	if text != "" {
+		content = append(content, anthropic.ToolResultBlockParamContentUnion{OfText: &anthropic.TextBlockParam{Text: text}})
+	}
+	for _, block := range blocks {
+		switch {
+		case block.Type == core.TextBlockType && block.Text != "":
+			content = append(content, anthropic.ToolResultBlockParamContentUnion{OfText: &anthropic.TextBlockParam{Text: block.Text}})
+		case block.Type == core.ImageBlockType && block.Image != nil:
+			content = append(content, anthropic.ToolResultBlockParamContentUnion{OfImage: &anthropic.ImageBlockParam{
+				Source: anthropic.ImageBlockParamSourceUnion{
+					OfBase64: &anthropic.Base64ImageSourceParam{
+						Data:      block.Image.Data,
+						MediaType: anthropic.Base64ImageSourceMediaType(block.Image.MediaType),
+					},
+				},
+			}})
+		}
+	}
+	if len(blocks) == 0 {
+		return anthropic.NewToolResultBlock(toolUseID, text, isError)
+	}
+	if len(content) == 0 {
+		return anthropic.NewToolResultBlock(toolUseID, "No output", isError)
+	}
+
+	return anthropic.ContentBlockParamUnion{OfToolResult: &anthropic.ToolResultBlockParam{
+		ToolUseID: toolUseID,
+		Content:   content,
+		IsError:   anthropic.Bool(isError),
+	}}
+}
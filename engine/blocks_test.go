@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestToolResultBlocks(t *testing.T) {
+	chart := core.NewImageBlock("image/png", []byte("\x89PNG fake chart"))
+
+	tests := []struct {
+		name      string
+		data      interface{}
+		wantTypes []string
+	}{
+		{"data and blocks", map[string]interface{}{"total": "120.00"}, []string{"text", "image", "text"}},
+		{"blocks only", nil, []string{"image", "text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeClaude(t,
+				toolUseResponse("toolu_1", "analyze_spending", map[string]interface{}{}),
+				textResponse("Here's your spending."),
+			)
+			registry := NewToolRegistry()
+			registry.Register(testTool("analyze_spending", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+				return &core.ToolResult{
+					Success: true,
+					Data:    tt.data,
+					Blocks:  []core.ContentBlock{chart, core.NewTextBlock("| Category | Total |\n| Food | 120.00 |")},
+				}, nil
+			}))
+			eng := NewEngine(client, registry)
+
+			output, err := eng.Run(context.Background(), testInput("chart my spending"))
+			if err != nil || output.Type != OutputComplete {
+				t.Fatalf("Run() = (%v, %v), want OutputComplete", output.Type, err)
+			}
+			if len(output.ToolsUsed) != 1 || len(output.ToolsUsed[0].Blocks) != 2 {
+				t.Fatalf("ToolsUsed = %+v, want the blocks for the UI", output.ToolsUsed)
+			}
+
+			messages := fake.Requests()[1]["messages"].([]interface{})
+			result := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+			content := result["content"].([]interface{})
+			if len(content) != len(tt.wantTypes) {
+				t.Fatalf("tool_result content = %v, want %v", content, tt.wantTypes)
+			}
+			for i, c := range content {
+				block := c.(map[string]interface{})
+				if block["type"] != tt.wantTypes[i] {
+					t.Errorf("content[%d] type = %v, want %s", i, block["type"], tt.wantTypes[i])
+				}
+				if block["type"] == "image" {
+					source := block["source"].(map[string]interface{})
+					if source["media_type"] != "image/png" || source["data"] != chart.Image.Data {
+						t.Errorf("image source = %v, want the chart", source)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestToolResultBlocksRoundTrip(t *testing.T) {
+	chart := core.NewImageBlock("image/png", []byte("chart"))
+	api := toolResultBlock("toolu_1", `{"total":"120.00"}`, []core.ContentBlock{chart}, false)
+
+	session := NewSession("user-1", "conv-1")
+	session.AddToolResults([]anthropic.ContentBlockParamUnion{api})
+	msg := session.MessagesSince(0)[0]
+
+	result := msg.ContentBlocks[0].ToolResult
+	if result.Content != `{"total":"120.00"}` || len(result.Blocks) != 1 || result.Blocks[0].Image.Data != chart.Image.Data {
+		t.Fatalf("round-tripped tool_result = %+v, want text and image", result)
+	}
+
+	restored := NewSession("user-1", "conv-1")
+	restored.RestoreHistory([]core.Message{msg})
+	content := restored.Messages()[0].Content[0].OfToolResult.Content
+	if len(content) != 2 || content[1].OfImage == nil {
+		t.Errorf("restored tool_result content = %+v, want text and image", content)
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// CapabilitiesToolName is the name of the tool added by WithCapabilitiesTool.
+const CapabilitiesToolName = "list_capabilities"
+
+// WithCapabilitiesTool registers a list_capabilities read tool that returns
+// the name and description of every tool available in the current run,
+// respecting Input.AvailableTools. It lets Claude check what it can do in
+// long conversations, where the tool list in the prompt drifts out of
+// attention, instead of guessing tool names. A tool already registered
+// under that name is kept.
+func WithCapabilitiesTool() Option {
+	return func(e *Engine) {
+		e.capabilitiesTool = true
+	}
+}
+
+// Capability describes a tool returned by list_capabilities.
+type Capability struct {
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	RequiresConfirmation bool   `json:"requires_confirmation"`
+}
+
+// availableToolsKey is the context key for the run's Input.AvailableTools.
+type availableToolsKey struct{}
+
+// withAvailableTools records the run's tool selectors for list_capabilities.
+func withAvailableTools(ctx context.Context, selectors []string) context.Context {
+	return context.WithValue(ctx, availableToolsKey{}, selectors)
+}
+
+// newCapabilitiesTool creates the list_capabilities tool over registry.
+func newCapabilitiesTool(registry *ToolRegistry) core.Tool {
+	return core.NewBaseTool(core.ToolDefinition{
+		ToolName:        CapabilitiesToolName,
+		ToolDescription: "List the tools available to you, with what each one does and whether it needs user confirmation. Use it when unsure whether a capability exists, rather than guessing a tool name.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		filter := func(core.Tool) bool { return true }
+		if selectors, _ := ctx.Value(availableToolsKey{}).([]string); len(selectors) > 0 {
+			filter = FilterByNames(selectors...)
+		}
+
+		names := registry.List()
+		capabilities := make([]Capability, 0, len(names))
+		for _, name := range names {
+			tool, ok := registry.Get(name)
+			if !ok || name == CapabilitiesToolName || !filter(tool) {
+				continue
+			}
+			capabilities = append(capabilities, Capability{
+				Name:                 name,
+				Description:          tool.Description(),
+				RequiresConfirmation: tool.RequiresConfirmation(),
+			})
+		}
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"tools": capabilities}}, nil
+	})
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestCapabilitiesToolRespectsAvailableTools(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", CapabilitiesToolName, map[string]interface{}{}),
+		textResponse("I can check your balance."),
+	)
+
+	registry := NewToolRegistry()
+	ok := func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	}
+	registry.Register(testTool("get_balance", false, ok))
+	registry.Register(testTool("send_money", true, ok))
+	eng := NewEngine(client, registry, WithCapabilitiesTool())
+
+	input := testInput("what can you do?")
+	input.AvailableTools = []string{"get_*", CapabilitiesToolName}
+	if _, err := eng.Run(context.Background(), input); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	results := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+	content := results[0].(map[string]interface{})["content"].([]interface{})
+	text := content[0].(map[string]interface{})["text"].(string)
+
+	var got struct {
+		Tools []Capability `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(text), &got); err != nil {
+		t.Fatalf("tool result %q is not JSON: %v", text, err)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Name != "get_balance" || got.Tools[0].Description != "test tool get_balance" {
+		t.Errorf("list_capabilities = %+v, want only get_balance", got.Tools)
+	}
+}
+
+func TestCapabilitiesToolOffByDefault(t *testing.T) {
+	_, client := newFakeClaude(t)
+	registry := NewToolRegistry()
+	NewEngine(client, registry)
+	if _, ok := registry.Get(CapabilitiesToolName); ok {
+		t.Error("list_capabilities registered without WithCapabilitiesTool")
+	}
+}
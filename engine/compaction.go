@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Summarizer condenses the older part of a conversation into a short note
+// that replaces it in the history sent to Claude.
+type Summarizer interface {
+	Summarize(ctx context.Context, messages []core.Message) (string, error)
+}
+
+// SummarizerFunc adapts a function to the Summarizer interface.
+type SummarizerFunc func(ctx context.Context, messages []core.Message) (string, error)
+
+// Summarize implements Summarizer.
+func (f SummarizerFunc) Summarize(ctx context.Context, messages []core.Message) (string, error) {
+	return f(ctx, messages)
+}
+
+// SummaryPrompt is the system prompt for ClaudeSummarizer.
+const SummaryPrompt = `You condense the earlier part of a conversation between a user and a financial assistant so it can continue without the full transcript.
+
+Write a short summary (under 200 words) in plain prose covering:
+- What the user asked for and any preferences or facts they shared
+- Actions taken, with amounts, recipients and outcomes
+- Anything still open or promised
+
+Keep exact amounts, names and identifiers. Don't add commentary.`
+
+// compactionSummaryPrefix starts the note that replaces compacted messages.
+const compactionSummaryPrefix = "Summary of the earlier conversation:\n"
+
+// ClaudeSummarizer summarizes with a small, fast Claude call.
+type ClaudeSummarizer struct {
+	Client *anthropic.Client
+
+	// Model defaults to Claude Haiku.
+	Model anthropic.Model
+}
+
+// NewClaudeSummarizer creates a Summarizer that uses Claude Haiku.
+func NewClaudeSummarizer(client *anthropic.Client) *ClaudeSummarizer {
+	return &ClaudeSummarizer{Client: client}
+}
+
+// Summarize implements Summarizer.
+func (s *ClaudeSummarizer) Summarize(ctx context.Context, messages []core.Message) (string, error) {
+	model := s.Model
+	if model == "" {
+		model = anthropic.ModelClaudeHaiku4_5_20251001
+	}
+
+	params := anthropic.MessageNewParams{
+		Model:     model,
+		MaxTokens: 400,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(transcript(messages))),
+		},
+		System: []anthropic.TextBlockParam{
+			{Text: SummaryPrompt},
+		},
+	}
+
+	resp, err := s.Client.Messages.New(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	var summary strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			summary.WriteString(block.Text)
+		}
+	}
+	return strings.TrimSpace(summary.String()), nil
+}
+
+// transcript renders messages as plain text for summarization, with tool
+// calls and results inline.
+func transcript(messages []core.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		if len(msg.ContentBlocks) == 0 {
+			fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.Content)
+			continue
+		}
+		for _, block := range msg.ContentBlocks {
+			switch block.Type {
+			case core.TextBlockType:
+				fmt.Fprintf(&b, "%s: %s\n", msg.Role, block.Text)
+			case core.ToolUseBlockType:
+				if block.ToolUse != nil {
+					fmt.Fprintf(&b, "%s called %s with %s\n", msg.Role, block.ToolUse.Name, block.ToolUse.Input)
+				}
+			case core.ToolResultBlockType:
+				if block.ToolResult != nil {
+					status := "result"
+					if block.ToolResult.IsError {
+						status = "error"
+					}
+					fmt.Fprintf(&b, "tool %s: %s\n", status, block.ToolResult.Content)
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// WithHistoryCompaction summarizes the oldest turns of a conversation when
+// its history exceeds threshold estimated tokens. The oldest turns are
+// replaced by a summary note (a user/assistant pair, so roles still
+// alternate) and the recent turns that fit in half the threshold are kept
+// verbatim; the latest turn, including any pending confirmation, is always
+// kept. Turns are only split at user messages, so tool_use and tool_result
+// blocks stay paired.
+//
+// Only the history sent to Claude is compacted; a ConversationStore keeps the
+// full conversation. Each conversation's summary is reused by later runs
+// until the turns kept after it exceed threshold again, when the summary and
+// the turns that no longer fit are summarized together. If summarizing
+// fails, the run continues with the full history. Zero or negative threshold
+// disables compaction.
+func WithHistoryCompaction(threshold int, summarizer Summarizer) Option {
+	return func(e *Engine) {
+		e.compactionThreshold = threshold
+		e.summarizer = summarizer
+	}
+}
+
+// maxCachedCompactions bounds the conversations whose summary is cached.
+const maxCachedCompactions = 1000
+
+// compaction is a summary of a conversation's messages before split.
+type compaction struct {
+	split   int
+	prefix  string // Hash of the summarized messages
+	summary string
+}
+
+// compactionCache holds each conversation's latest compaction, so later runs
+// don't summarize the same messages again.
+type compactionCache struct {
+	mu      sync.Mutex
+	entries map[string]*compaction // conversationID -> compaction
+}
+
+func (c *compactionCache) get(conversationID string) *compaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[conversationID]
+}
+
+// put caches a conversation's compaction, evicting another conversation's
+// if the cache is full.
+func (c *compactionCache) put(conversationID string, entry *compaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*compaction)
+	}
+	if _, ok := c.entries[conversationID]; !ok && len(c.entries) >= maxCachedCompactions {
+		for id := range c.entries {
+			delete(c.entries, id)
+			break
+		}
+	}
+	c.entries[conversationID] = entry
+}
+
+// compactHistory applies history compaction, if configured. A conversation's
+// cached summary is reused while history still starts with the messages it
+// summarizes and the rest fits in the threshold.
+func (e *Engine) compactHistory(ctx context.Context, conversationID string, history []core.Message) []core.Message {
+	if e.summarizer == nil || e.compactionThreshold <= 0 || estimateTokens(history) <= e.compactionThreshold {
+		return history
+	}
+
+	var cached *compaction
+	if conversationID != "" {
+		cached = e.compactions.get(conversationID)
+		if cached != nil && (cached.split >= len(history) || historyHash(history[:cached.split]) != cached.prefix) {
+			cached = nil
+		}
+	}
+	if cached != nil && estimateTokens(history[cached.split:]) <= e.compactionThreshold {
+		return compacted(cached.summary, history[cached.split:])
+	}
+
+	split := compactionSplit(history, e.compactionThreshold/2)
+	if split <= 0 || (cached != nil && split <= cached.split) {
+		if cached != nil {
+			return compacted(cached.summary, history[cached.split:])
+		}
+		return history
+	}
+
+	// Summarize only what the cached summary doesn't cover
+	toSummarize := history[:split]
+	if cached != nil {
+		toSummarize = compacted(cached.summary, history[cached.split:split])
+	}
+	summary, err := e.summarizer.Summarize(ctx, toSummarize)
+	if err != nil || summary == "" {
+		e.logger.WarnContext(ctx, "history compaction failed, using full history", "messages", len(history), "error", err)
+		return history
+	}
+	e.logger.DebugContext(ctx, "compacted history", "summarized", split, "kept", len(history)-split)
+
+	if conversationID != "" {
+		e.compactions.put(conversationID, &compaction{split: split, prefix: historyHash(history[:split]), summary: summary})
+	}
+	return compacted(summary, history[split:])
+}
+
+// compacted returns kept preceded by the summary note that replaces the
+// messages before it.
+func compacted(summary string, kept []core.Message) []core.Message {
+	messages := make([]core.Message, 0, len(kept)+2)
+	messages = append(messages,
+		core.Message{Role: core.RoleUser, Content: compactionSummaryPrefix + summary},
+		core.Message{Role: core.RoleAssistant, Content: "Understood, I'll continue from there."},
+	)
+	return append(messages, kept...)
+}
+
+// historyHash identifies a sequence of messages by its transcript.
+func historyHash(messages []core.Message) string {
+	hash := sha256.Sum256([]byte(transcript(messages)))
+	return hex.EncodeToString(hash[:])
+}
+
+// compactionSplit returns the index of the first message to keep: the start
+// of the oldest turn such that it and the turns after it fit in keep
+// estimated tokens, but never later than the start of the latest turn.
+// A turn starts at a user message that isn't tool results. Returns 0 if
+// there is nothing to compact.
+func compactionSplit(history []core.Message, keep int) int {
+	var starts []int
+	for i, msg := range history {
+		if isTurnStart(msg) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) < 2 {
+		return 0
+	}
+
+	split := starts[len(starts)-1]
+	for i := len(starts) - 2; i > 0; i-- {
+		if estimateTokens(history[starts[i]:]) > keep {
+			break
+		}
+		split = starts[i]
+	}
+	return split
+}
+
+// isTurnStart reports whether msg is a user message that begins a turn,
+// rather than one carrying tool results.
+func isTurnStart(msg core.Message) bool {
+	if msg.Role != core.RoleUser {
+		return false
+	}
+	for _, block := range msg.ContentBlocks {
+		if block.Type == core.ToolResultBlockType {
+			return false
+		}
+	}
+	return true
+}
+
+// estimateTokens roughly estimates the tokens in messages, at about four
+// characters per token.
+func estimateTokens(messages []core.Message) int {
+	chars := 0
+	for _, msg := range messages {
+		chars += len(msg.Content)
+		for _, block := range msg.ContentBlocks {
+			chars += len(block.Text)
+			if block.ToolUse != nil {
+				chars += len(block.ToolUse.Name) + len(block.ToolUse.Input)
+			}
+			if block.ToolResult != nil {
+				chars += len(block.ToolResult.Content)
+			}
+		}
+	}
+	return chars / 4
+}
@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// longHistory builds a history of turns, each a padded balance check:
+// user message, tool_use, tool_result and assistant reply.
+func longHistory(turns int) []core.Message {
+	var history []core.Message
+	padding := strings.Repeat("x", 400)
+	for i := 0; i < turns; i++ {
+		id := fmt.Sprintf("toolu_%d", i)
+		history = append(history,
+			core.Message{Role: core.RoleUser, Content: fmt.Sprintf("turn %d: what's my balance? %s", i, padding)},
+			core.Message{Role: core.RoleAssistant, ContentBlocks: []core.ContentBlock{{
+				Type:    core.ToolUseBlockType,
+				ToolUse: &core.ToolUseContent{ID: id, Name: "get_balance", Input: json.RawMessage(`{}`)},
+			}}},
+			core.Message{Role: core.RoleUser, ContentBlocks: []core.ContentBlock{{
+				Type:       core.ToolResultBlockType,
+				ToolResult: &core.ToolResultContent{ToolUseID: id, Content: `{"balance":"100.00"}` + padding},
+			}}},
+			core.Message{Role: core.RoleAssistant, Content: fmt.Sprintf("turn %d: you have $100", i)},
+		)
+	}
+	return history
+}
+
+func TestHistoryCompaction(t *testing.T) {
+	fake, client := newFakeClaude(t, textResponse("Your balance is still $100."))
+	registry := NewToolRegistry()
+
+	var summarized []core.Message
+	summarizer := SummarizerFunc(func(ctx context.Context, messages []core.Message) (string, error) {
+		summarized = messages
+		return "The user checked their balance several times; it was $100.", nil
+	})
+	eng := NewEngine(client, registry, WithHistoryCompaction(500, summarizer))
+
+	input := testInput("and now?")
+	input.History = longHistory(6)
+	if _, err := eng.Run(context.Background(), input); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(summarized) == 0 || len(summarized)%4 != 0 {
+		t.Fatalf("summarized %d messages, want whole turns", len(summarized))
+	}
+
+	messages := fake.Requests()[0]["messages"].([]interface{})
+	if len(messages) >= len(input.History) {
+		t.Fatalf("sent %d messages, want fewer than the %d in history", len(messages), len(input.History))
+	}
+	first := messages[0].(map[string]interface{})
+	text := first["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if first["role"] != "user" || !strings.Contains(text, "it was $100") {
+		t.Errorf("first message = %v, want the summary note", first)
+	}
+
+	// Roles alternate and every tool_result follows its tool_use.
+	toolUses := map[string]bool{}
+	for i, m := range messages {
+		msg := m.(map[string]interface{})
+		wantRole := "user"
+		if i%2 == 1 {
+			wantRole = "assistant"
+		}
+		if msg["role"] != wantRole {
+			t.Errorf("message %d role = %v, want %s", i, msg["role"], wantRole)
+		}
+		for _, b := range msg["content"].([]interface{}) {
+			block := b.(map[string]interface{})
+			switch block["type"] {
+			case "tool_use":
+				toolUses[block["id"].(string)] = true
+			case "tool_result":
+				if !toolUses[block["tool_use_id"].(string)] {
+					t.Errorf("message %d has tool_result for %v without its tool_use", i, block["tool_use_id"])
+				}
+			}
+		}
+	}
+
+	// The latest turn is kept verbatim, followed by the new message.
+	last := messages[len(messages)-1].(map[string]interface{})
+	lastText := last["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if lastText != "and now?" {
+		t.Errorf("last message = %q, want the new user message", lastText)
+	}
+	kept := messages[len(messages)-5].(map[string]interface{})
+	keptText := kept["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.HasPrefix(keptText, "turn 5:") {
+		t.Errorf("latest turn starts with %q, want turn 5 kept verbatim", keptText)
+	}
+}
+
+func TestHistoryCompactionReusesSummary(t *testing.T) {
+	_, client := newFakeClaude(t, textResponse("1"), textResponse("2"), textResponse("3"))
+
+	var calls [][]core.Message
+	summarizer := SummarizerFunc(func(ctx context.Context, messages []core.Message) (string, error) {
+		calls = append(calls, messages)
+		return fmt.Sprintf("summary %d", len(calls)), nil
+	})
+	eng := NewEngine(client, NewToolRegistry(), WithHistoryCompaction(500, summarizer))
+	run := func(turns int) {
+		t.Helper()
+		input := testInput("and now?")
+		input.History = longHistory(turns)
+		if _, err := eng.Run(context.Background(), input); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	run(6)
+	run(7)
+	if len(calls) != 1 {
+		t.Fatalf("summarizer called %d times, want the first summary reused while the kept turns fit", len(calls))
+	}
+
+	// Once the kept turns outgrow the threshold, only they are summarized,
+	// after the previous summary.
+	run(8)
+	if len(calls) != 2 {
+		t.Fatalf("summarizer called %d times, want 2", len(calls))
+	}
+	first := calls[1][0].Content
+	if first != compactionSummaryPrefix+"summary 1" {
+		t.Errorf("second summary starts with %q, want the first summary", first)
+	}
+	if got := len(calls[1]); got != 2+2*4 {
+		t.Errorf("second summary covers %d messages, want the note and turns 5 and 6", got)
+	}
+}
+
+func TestHistoryCompactionUnderThreshold(t *testing.T) {
+	fake, client := newFakeClaude(t, textResponse("Hi."))
+	summarizer := SummarizerFunc(func(ctx context.Context, messages []core.Message) (string, error) {
+		t.Error("summarizer called for a short history")
+		return "", nil
+	})
+	eng := NewEngine(client, NewToolRegistry(), WithHistoryCompaction(100000, summarizer))
+
+	input := testInput("hello")
+	input.History = longHistory(2)
+	if _, err := eng.Run(context.Background(), input); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := len(fake.Requests()[0]["messages"].([]interface{})); got != len(input.History)+1 {
+		t.Errorf("sent %d messages, want %d", got, len(input.History)+1)
+	}
+}
+
+func TestHistoryCompactionFailureKeepsHistory(t *testing.T) {
+	fake, client := newFakeClaude(t, textResponse("Hi."))
+	summarizer := SummarizerFunc(func(ctx context.Context, messages []core.Message) (string, error) {
+		return "", fmt.Errorf("summarizer unavailable")
+	})
+	eng := NewEngine(client, NewToolRegistry(), WithHistoryCompaction(500, summarizer))
+
+	input := testInput("hello")
+	input.History = longHistory(6)
+	if _, err := eng.Run(context.Background(), input); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := len(fake.Requests()[0]["messages"].([]interface{})); got != len(input.History)+1 {
+		t.Errorf("sent %d messages, want the full history (%d)", got, len(input.History)+1)
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ConversationStore persists conversation history for the engine.
+// With a store configured, callers only need to pass Context.ConversationID;
+// the engine loads the history before each run and appends the messages the
+// run produced (including tool_use and tool_result blocks) afterwards.
+// The store package provides in-memory and SQLite implementations.
+type ConversationStore interface {
+	// Load returns the conversation's messages in order.
+	// Returns an empty slice (not an error) for an unknown conversation.
+	Load(ctx context.Context, conversationID string) ([]core.Message, error)
+
+	// Append adds messages to the end of the conversation.
+	Append(ctx context.Context, conversationID string, messages []core.Message) error
+}
+
+// WithConversationStore makes the engine load and save history by
+// Context.ConversationID. Input.History, if set, is used instead of the
+// stored history for that run; new messages are still appended.
+func WithConversationStore(cs ConversationStore) Option {
+	return func(e *Engine) {
+		e.conversations = cs
+	}
+}
+
+// loadHistory returns the history for a run: the caller's history if given,
+// otherwise the stored conversation, compacted if configured.
+func (e *Engine) loadHistory(ctx context.Context, conversationID string, history []core.Message) ([]core.Message, error) {
+	if e.conversations == nil || conversationID == "" || len(history) > 0 {
+		return e.compactHistory(ctx, conversationID, history), nil
+	}
+	stored, err := e.conversations.Load(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation %s: %w", conversationID, err)
+	}
+	return e.compactHistory(ctx, conversationID, stored), nil
+}
+
+// saveHistory appends the session's messages after the first n to the
+// conversation store. Failed runs aren't saved, so a retry starts clean.
+func (e *Engine) saveHistory(ctx context.Context, session *Session, n int, output *Output) {
+	if e.conversations == nil || session.ConversationID == "" {
+		return
+	}
+	if output == nil || output.Type == OutputError {
+		return
+	}
+	messages := session.MessagesSince(n)
+	if len(messages) == 0 {
+		return
+	}
+	if err := e.conversations.Append(ctx, session.ConversationID, messages); err != nil {
+		e.logger.ErrorContext(ctx, "failed to save conversation", "user_id", session.UserID, "conversation_id", session.ConversationID, "messages", len(messages), "error", err)
+	}
+}
+
+// convertAPIMessageToCore converts an API message back to a core.Message,
+// preserving tool_use and tool_result blocks. A tool_result's text is
+// joined into Content and its images kept in Blocks.
+func convertAPIMessageToCore(msg anthropic.MessageParam) core.Message {
+	role := core.RoleUser
+	if msg.Role == anthropic.MessageParamRoleAssistant {
+		role = core.RoleAssistant
+	}
+
+	blocks := make([]core.ContentBlock, 0, len(msg.Content))
+	for _, block := range msg.Content {
+		switch {
+		case block.OfText != nil:
+			blocks = append(blocks, core.ContentBlock{Type: core.TextBlockType, Text: block.OfText.Text})
+		case block.OfToolUse != nil:
+			input, _ := json.Marshal(block.OfToolUse.Input)
+			blocks = append(blocks, core.ContentBlock{
+				Type: core.ToolUseBlockType,
+				ToolUse: &core.ToolUseContent{
+					ID:    block.OfToolUse.ID,
+					Name:  block.OfToolUse.Name,
+					Input: input,
+				},
+			})
+		case block.OfToolResult != nil:
+			var content string
+			var images []core.ContentBlock
+			for _, c := range block.OfToolResult.Content {
+				switch {
+				case c.OfText != nil && content != "":
+					content += "\n\n" + c.OfText.Text
+				case c.OfText != nil:
+					content = c.OfText.Text
+				case c.OfImage != nil && c.OfImage.Source.OfBase64 != nil:
+					images = append(images, core.ContentBlock{
+						Type: core.ImageBlockType,
+						Image: &core.ImageContent{
+							MediaType: string(c.OfImage.Source.OfBase64.MediaType),
+							Data:      c.OfImage.Source.OfBase64.Data,
+						},
+					})
+				}
+			}
+			blocks = append(blocks, core.ContentBlock{
+				Type: core.ToolResultBlockType,
+				ToolResult: &core.ToolResultContent{
+					ToolUseID: block.OfToolResult.ToolUseID,
+					Content:   content,
+					IsError:   block.OfToolResult.IsError.Value,
+					Blocks:    images,
+				},
+			})
+		}
+	}
+
+	// Plain text messages round-trip as Content, like NewUserMessage
+	if len(blocks) == 1 && blocks[0].Type == core.TextBlockType {
+		return core.Message{Role: role, Content: blocks[0].Text}
+	}
+	return core.Message{Role: role, ContentBlocks: blocks}
+}
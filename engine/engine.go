@@ -3,8 +3,9 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -14,13 +15,54 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrTokenBudgetExceeded is returned in Output.Error when a run stops because
+// it used up core.ExecutionLimits.MaxTotalTokens.
+var ErrTokenBudgetExceeded = errors.New("token budget exceeded")
+
 // Engine is the agent runner that executes tools and manages Claude API interactions.
 type Engine struct {
 	client     *anthropic.Client
 	registry   *ToolRegistry
 	guardrails Guardrails      // Optional: rate limiting and circuit breaker
+	spending   SpendingLimiter // Optional: money limits on write tools
 	audit      AuditLogger     // Optional: audit logging
+	redactor   Redactor        // Optional: masks tool input before audit logging
 	memory     memory.Manager  // Optional: memory system for trace retrieval/storage
+
+	idempotency    IdempotencyStore // Optional: deduplicates confirmed executions
+	idempotencyTTL time.Duration
+
+	memoryTimeout time.Duration // Max time for memory retrieval; 0 = no limit
+
+	conversations ConversationStore // Optional: loads and saves history by conversation ID
+
+	summarizer          Summarizer      // Optional: compacts long histories
+	compactionThreshold int             // Estimated history tokens that trigger compaction
+	compactions         compactionCache // Summaries reused across a conversation's runs
+
+	maxToolResultBytes int // Truncate larger tool results sent to Claude; 0 = no limit
+
+	planPreview bool // Ask Claude for a plan before the first turn
+
+	batchConfirmations bool // Confirm all of a turn's writes together
+
+	capabilitiesTool bool // Register the list_capabilities tool
+
+	contractAllowlist map[ContractTarget]bool // Optional: contracts execute_contract_call may call
+
+	thoughtValidator ThoughtValidator // Optional: checks thoughts beyond non-empty
+
+	traceRedactor TraceRedactor // Optional: sanitizes traces returned in Output.Traces
+
+	localizer  core.Localizer            // Optional: translates summaries and built-in messages
+	localizers map[string]core.Localizer // Optional: per-locale overrides of localizer
+
+	txPoller      TransactionPoller // Optional: waits for confirmed writes' transactions to settle
+	txPollTimeout time.Duration
+
+	events EventSink // Optional: receives run, tool and memory events
+
+	logger *slog.Logger
 }
 
 // Option configures the engine.
@@ -40,6 +82,19 @@ func WithAudit(a AuditLogger) Option {
 	}
 }
 
+// WithIdempotency makes RunConfirmedAction execute each confirmed action at
+// most once within ttl (DefaultIdempotencyTTL if zero). Repeated confirmations
+// of the same action return the recorded result without executing it again.
+func WithIdempotency(store IdempotencyStore, ttl time.Duration) Option {
+	return func(e *Engine) {
+		if ttl <= 0 {
+			ttl = DefaultIdempotencyTTL
+		}
+		e.idempotency = store
+		e.idempotencyTTL = ttl
+	}
+}
+
 // WithMemory configures the engine with a memory manager.
 func WithMemory(m memory.Manager) Option {
 	return func(e *Engine) {
@@ -47,6 +102,16 @@ func WithMemory(m memory.Manager) Option {
 	}
 }
 
+// WithMemoryTimeout limits how long memory retrieval may take before a run
+// continues without memories, so a slow memory backend (e.g. an embedding
+// model's cold start or a loaded database) doesn't hold up the user. Zero
+// means no limit.
+func WithMemoryTimeout(d time.Duration) Option {
+	return func(e *Engine) {
+		e.memoryTimeout = d
+	}
+}
+
 // NewEngine creates a new engine with the given Anthropic client and registry.
 func NewEngine(client *anthropic.Client, registry *ToolRegistry, opts ...Option) *Engine {
 	e := &Engine{
@@ -56,6 +121,14 @@ func NewEngine(client *anthropic.Client, registry *ToolRegistry, opts ...Option)
 	for _, opt := range opts {
 		opt(e)
 	}
+	if e.logger == nil {
+		e.logger = slog.Default()
+	}
+	if e.capabilitiesTool {
+		if err := registry.Register(newCapabilitiesTool(registry)); err != nil {
+			e.logger.Warn("capabilities tool not registered", "error", err)
+		}
+	}
 	return e
 }
 
@@ -75,9 +148,15 @@ type Input struct {
 	// History contains previous messages in the conversation.
 	History []core.Message
 
-	// SystemPrompt is the system prompt to use.
+	// SystemPrompt is the system prompt to use. Defaults to
+	// DefaultSystemPrompt unless SystemPromptSegments are set.
 	SystemPrompt string
 
+	// SystemPromptSegments layer further system prompt blocks after
+	// SystemPrompt, e.g. a tenant policy, and mark which can be cached.
+	// Retrieved memories are added as a final, uncached segment.
+	SystemPromptSegments []PromptSegment
+
 	// Model is the Claude model to use.
 	Model string
 
@@ -88,12 +167,30 @@ type Input struct {
 	// Defaults to "default" if not specified.
 	AgentName string
 
-	// AvailableTools filters which tools from the registry are available.
-	// If empty, all registered tools are available.
+	// AvailableTools filters which tools from the registry are available,
+	// using FilterByNames selectors (names, globs, ReadOnlyTools and
+	// "-" exclusions). If empty, all registered tools are available.
 	AvailableTools []string
 
 	// StreamCallback is an optional callback for streaming responses.
 	StreamCallback func(chunk string, done bool)
+
+	// ToolInputCallback is an optional callback for tool inputs as they
+	// stream in. Only used when StreamCallback is set.
+	ToolInputCallback func(delta ToolInputDelta)
+
+	// PlanCallback is called with the agent's plan before any tools run.
+	// Only used when the engine is created with WithPlanPreview.
+	PlanCallback func(plan *Plan)
+
+	// ToolCallback is an optional callback invoked when a tool starts executing.
+	// Write tools only start after confirmation, so it is not called for
+	// actions that end up pending.
+	ToolCallback func(tool string)
+
+	// IncludeTraces returns the run's ReAct traces in Output.Traces, so the
+	// client can show the agent's reasoning.
+	IncludeTraces bool
 }
 
 // Output represents the output from an agent run.
@@ -107,6 +204,13 @@ type Output struct {
 	// PendingAction is set when Type is OutputConfirmationNeeded.
 	PendingAction *core.PendingAction
 
+	// PendingBatch is set when Type is OutputBatchConfirmationNeeded.
+	PendingBatch *core.PendingActionBatch
+
+	// BatchResults reports each action of a batch run by RunConfirmedBatch,
+	// in batch order.
+	BatchResults []BatchActionResult
+
 	// ToolsUsed records all tools invoked during this run.
 	ToolsUsed []core.ToolExecution
 
@@ -116,6 +220,15 @@ type Output struct {
 	// TokensUsed tracks Claude API token consumption for this run.
 	TokensUsed core.TokenUsage
 
+	// Plan is the plan produced before execution when plan preview is enabled.
+	Plan *Plan
+
+	// Traces are the run's reasoning steps, in order, when
+	// Input.IncludeTraces is set. A write awaiting confirmation appears with
+	// Status "pending_confirmation"; the run that executes it after
+	// confirmation includes its final step. See WithTraceRedactor.
+	Traces []TraceStep
+
 	// Error is set when Type is OutputError.
 	Error error
 }
@@ -132,23 +245,50 @@ const (
 
 	// OutputError indicates an error occurred.
 	OutputError
+
+	// OutputBatchConfirmationNeeded indicates several write operations need
+	// user confirmation together (see WithBatchConfirmations).
+	OutputBatchConfirmationNeeded
 )
 
 // loopConfig holds the parameters for the ReAct loop.
 type loopConfig struct {
 	model          string
 	maxTokens      int64
-	systemPrompt   string
+	system         []PromptSegment
 	maxTurns       int
+	maxTotalTokens int             // 0 = no cap
+	tokensUsed     core.TokenUsage // Spent before the loop (e.g. on the plan), counted toward maxTotalTokens
 	canConfirm     bool
 	apiTools       []anthropic.ToolUnionParam
 	agentName      string
 	auditParentID  *string
 	streamCallback func(chunk string, done bool)
+	inputCallback  func(delta ToolInputDelta)
+	toolCallback   func(tool string)
 }
 
 // Run executes the agent loop until completion or confirmation is needed.
 func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
+	start := time.Now()
+	output, err := e.run(ctx, input)
+	e.emitRunCompleted(ctx, input, start, output, err)
+	return output, err
+}
+
+// requestContext returns ctx carrying the request-scoped values (e.g., from
+// server auth) and the localizer for c, so every entry point exposes the
+// same context to tools.
+func (e *Engine) requestContext(ctx context.Context, c *core.Context) context.Context {
+	if c != nil {
+		ctx = core.WithValues(ctx, c.Values)
+	}
+	return e.withLocalizer(ctx, c)
+}
+
+func (e *Engine) run(ctx context.Context, input *Input) (*Output, error) {
+	ctx = e.requestContext(ctx, input.Context)
+
 	// Check guardrails if configured
 	if e.guardrails != nil && input.Context != nil {
 		result, err := e.guardrails.Check(ctx, input.Context.UserID)
@@ -169,17 +309,26 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 	// === PHASE 0: RETRIEVE MEMORIES ===
 	var enrichment string
 	if e.memory != nil && input.UserMessage != "" && input.Context != nil {
-		log.Printf("[MEMORY] Retrieving memories for query: %s", input.UserMessage)
+		e.logger.DebugContext(ctx, "retrieving memories", "user_id", input.Context.UserID, "query", truncateLog(input.UserMessage, 200))
 
 		// Manager decides how to retrieve and format
 		var err error
-		enrichment, err = e.memory.Retrieve(ctx, input.Context.UserID, input.UserMessage)
-		if err != nil {
-			log.Printf("[MEMORY] Retrieval failed: %v", err)
+		enrichment, err = e.retrieveMemories(ctx, input.Context.UserID, input.UserMessage)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			e.logger.WarnContext(ctx, "memory retrieval timed out", "user_id", input.Context.UserID, "timeout", e.memoryTimeout)
+			enrichment = "" // Non-fatal, continue without memories
+		} else if err != nil {
+			e.logger.WarnContext(ctx, "memory retrieval failed", "user_id", input.Context.UserID, "error", err)
 			enrichment = "" // Non-fatal, continue without memories
 		} else if enrichment != "" {
-			log.Printf("[MEMORY] Retrieved memories successfully")
+			e.logger.DebugContext(ctx, "retrieved memories", "user_id", input.Context.UserID)
 		}
+		e.emit(ctx, Event{
+			Type:   EventMemoryRetrieved,
+			UserID: input.Context.UserID,
+			Hit:    enrichment != "",
+			Err:    err,
+		})
 	}
 
 	// Apply defaults
@@ -191,21 +340,20 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 	if maxTokens == 0 {
 		maxTokens = 4096
 	}
-	systemPrompt := input.SystemPrompt
-	if systemPrompt == "" {
-		systemPrompt = DefaultSystemPrompt
-	}
+	system := systemSegments(input)
 
 	// === PHASE 1: ENRICH SYSTEM PROMPT ===
 	if enrichment != "" {
-		systemPrompt += "\n\n" + enrichment
+		system = append(system, PromptSegment{Text: enrichment})
 	}
 
 	// Get limits from context
 	maxTurns := 20
+	maxTotalTokens := 0
 	canConfirm := true
 	if input.Context != nil && input.Context.Limits != nil {
 		maxTurns = input.Context.Limits.MaxTurns
+		maxTotalTokens = input.Context.Limits.MaxTotalTokens
 		canConfirm = input.Context.Limits.CanConfirm
 		if input.Context.Limits.Timeout > 0 {
 			var cancel context.CancelFunc
@@ -227,7 +375,12 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 	session.MessageID = messageID
 
 	// Restore history
-	session.RestoreHistory(input.History)
+	history, err := e.loadHistory(ctx, conversationID, input.History)
+	if err != nil {
+		return &Output{Type: OutputError, Error: err}, nil
+	}
+	session.RestoreHistory(history)
+	restored := len(session.Messages())
 
 	// Add user message
 	if input.UserMessage != "" {
@@ -257,32 +410,65 @@ func (e *Engine) Run(ctx context.Context, input *Input) (*Output, error) {
 	cfg := &loopConfig{
 		model:          model,
 		maxTokens:      maxTokens,
-		systemPrompt:   systemPrompt,
+		system:         system,
 		maxTurns:       maxTurns,
+		maxTotalTokens: maxTotalTokens,
 		canConfirm:     canConfirm,
 		apiTools:       apiTools,
 		agentName:      agentName,
 		auditParentID:  auditParentID,
 		streamCallback: input.StreamCallback,
+		inputCallback:  input.ToolInputCallback,
+		toolCallback:   input.ToolCallback,
 	}
 
-	return e.runLoop(ctx, input, session, cfg)
+	// Plan preview: capture the intended steps before any tools run
+	var plan *Plan
+	if e.planPreview && input.UserMessage != "" && len(apiTools) > 0 {
+		var err error
+		plan, cfg.tokensUsed, err = e.generatePlan(ctx, session, cfg)
+		if err != nil {
+			e.logger.WarnContext(ctx, "plan generation failed", "user_id", session.UserID, "error", err)
+		} else {
+			e.logger.InfoContext(ctx, "plan generated", "user_id", session.UserID, "summary", plan.Summary, "steps", len(plan.Steps))
+			if input.PlanCallback != nil {
+				input.PlanCallback(plan)
+			}
+		}
+	}
+
+	output, err := e.runLoop(ctx, input, session, cfg)
+	if output != nil {
+		output.Plan = plan
+	}
+	e.saveHistory(ctx, session, restored, output)
+	return output, err
 }
 
-// ExecuteTool executes a confirmed write operation.
+// ExecuteTool executes a confirmed write operation. It has no core.Context,
+// so callers attach request-scoped values to ctx with core.WithValues.
 func (e *Engine) ExecuteTool(ctx context.Context, userID, toolName string, input json.RawMessage, confirmationID string) (*core.ToolResult, error) {
 	tool, ok := e.registry.Get(toolName)
 	if !ok {
 		return nil, fmt.Errorf("unknown tool: %s", toolName)
 	}
+	if reason := e.checkContractCall(toolName, input); reason != "" {
+		return &core.ToolResult{Success: false, Error: reason}, nil
+	}
+	reason, settleSpending := e.reserveSpending(ctx, userID, toolName, input)
+	if reason != "" {
+		return &core.ToolResult{Success: false, Error: reason}, nil
+	}
 
-	return tool.Execute(ctx, &core.ToolParams{
+	result, err := e.executeTool(ctx, tool, &core.ToolParams{
 		UserID:         userID,
 		Input:          input,
 		ConfirmationID: confirmationID,
 		RequestID:      confirmationID,
 		// Note: ConversationID and MessageID not available in standalone ExecuteTool.
 	})
+	settleSpending(err == nil && result != nil && result.Success)
+	return result, err
 }
 
 // RunConfirmedAction resumes the ReAct loop for a confirmed write operation.
@@ -290,7 +476,50 @@ func (e *Engine) ExecuteTool(ctx context.Context, userID, toolName string, input
 // enters the full ReAct loop so Claude can issue follow-up tool calls
 // (e.g., sending to the next recipient in a multi-action sequence).
 func (e *Engine) RunConfirmedAction(ctx context.Context, input *Input, action *core.PendingAction) (*Output, error) {
-	// Create session from input
+	start := time.Now()
+	output, err := e.runConfirmedAction(ctx, input, action)
+	e.emitRunCompleted(ctx, input, start, output, err)
+	return output, err
+}
+
+func (e *Engine) runConfirmedAction(ctx context.Context, input *Input, action *core.PendingAction) (*Output, error) {
+	ctx = e.requestContext(ctx, input.Context)
+	// Restore history - this includes the original tool_use block
+	session, restored, err := e.restoreSession(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get tool from registry
+	tool, ok := e.registry.Get(action.Tool)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool: %s", action.Tool)
+	}
+
+	confirmed, err := e.executeConfirmed(ctx, input, session, tool, action)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add tool result to session (the tool_use block is already in history from RestoreHistory)
+	session.AddToolResults([]anthropic.ContentBlockParamUnion{confirmed.block})
+	e.logger.DebugContext(ctx, "resuming loop after confirmation", "user_id", session.UserID, "confirmation_id", action.ID)
+
+	output, err := e.resumeLoop(ctx, input, session, restored)
+	if err != nil {
+		return output, err
+	}
+
+	// Prepend the confirmed tool execution to ToolsUsed
+	output.ToolsUsed = append([]core.ToolExecution{confirmed.execution}, output.ToolsUsed...)
+
+	return output, nil
+}
+
+// restoreSession creates the session for resuming after a confirmation, with
+// the input's history restored. It also returns the number of restored
+// messages, so only the messages added afterwards are saved.
+func (e *Engine) restoreSession(ctx context.Context, input *Input) (*Session, int, error) {
 	userID := ""
 	conversationID := ""
 	messageID := ""
@@ -302,24 +531,32 @@ func (e *Engine) RunConfirmedAction(ctx context.Context, input *Input, action *c
 	session := NewSession(userID, conversationID)
 	session.MessageID = messageID
 
-	// Restore history - this includes the original tool_use block
-	session.RestoreHistory(input.History)
+	history, err := e.loadHistory(ctx, conversationID, input.History)
+	if err != nil {
+		return nil, 0, err
+	}
+	session.RestoreHistory(history)
+	return session, len(session.Messages()), nil
+}
 
-	// Extract thought (already stored in action)
-	thought := action.Thought
+// confirmedExecution is the outcome of executing one confirmed action.
+type confirmedExecution struct {
+	execution core.ToolExecution
+	block     anthropic.ContentBlockParamUnion // tool_result for the action's tool_use block
+	succeeded bool
+}
 
-	// Get tool from registry
-	tool, ok := e.registry.Get(action.Tool)
-	if !ok {
-		return nil, fmt.Errorf("unknown tool: %s", action.Tool)
-	}
+// executeConfirmed executes a confirmed action and records its trace in the
+// session. The caller adds the returned tool_result block to the session.
+func (e *Engine) executeConfirmed(ctx context.Context, input *Input, session *Session, tool core.Tool, action *core.PendingAction) (confirmedExecution, error) {
+	userID := session.UserID
 
 	// Create trace object (THINK phase already done)
 	trace := &core.Trace{
 		ID:          uuid.New().String(),
 		SessionID:   session.ID,
 		TurnNumber:  session.TurnCount,
-		Thought:     thought,
+		Thought:     action.Thought,
 		Action:      action.Tool,
 		ActionInput: action.Input,
 		Timestamp:   time.Now().Unix(),
@@ -333,21 +570,92 @@ func (e *Engine) RunConfirmedAction(ctx context.Context, input *Input, action *c
 	// retrieves the cached confirmed action and actually executes the operation.
 	// The confirmation store caches confirmed actions for 60s to support this
 	// double-call pattern (server.Confirm → executor.Confirm).
+	//
+	// With an idempotency store, an action that already executed returns its
+	// recorded result instead of executing again.
+	var result *core.ToolResult
+	var toolErr error
+	execute := true
+	executionKey := actionExecutionKey(action)
+	if e.idempotency != nil {
+		prior, started, err := e.idempotency.Begin(ctx, executionKey, e.idempotencyTTL)
+		if err != nil {
+			return confirmedExecution{}, fmt.Errorf("idempotency check failed: %w", err)
+		}
+		if !started {
+			execute = false
+			trace.Metadata["idempotent_replay"] = "true"
+			if prior != nil {
+				e.logger.InfoContext(ctx, "action already executed, returning recorded result", "user_id", action.UserID, "tool", action.Tool, "confirmation_id", action.ID)
+				result = prior
+			} else {
+				e.logger.InfoContext(ctx, "action already executing, skipping", "user_id", action.UserID, "tool", action.Tool, "confirmation_id", action.ID)
+				toolErr = fmt.Errorf("this action is already being executed")
+			}
+		}
+	}
+
+	// Re-check spending limits: other writes may have executed since the
+	// confirmation was requested
+	var settleSpending func(succeeded bool)
+	if execute {
+		var reason string
+		if reason, settleSpending = e.reserveSpending(ctx, action.UserID, action.Tool, action.Input); reason != "" {
+			execute = false
+			toolErr = errors.New(reason)
+			trace.Metadata["spending_limit"] = "blocked"
+			if e.idempotency != nil {
+				if err := e.idempotency.Release(ctx, executionKey); err != nil {
+					e.logger.ErrorContext(ctx, "failed to release idempotency key", "user_id", action.UserID, "confirmation_id", action.ID, "error", err)
+				}
+			}
+		}
+	}
+
 	startTime := time.Now()
-	result, toolErr := tool.Execute(ctx, &core.ToolParams{
-		UserID:         action.UserID,
-		Input:          action.Input,
-		ConfirmationID: action.ID,
-		RequestID:      session.ID,
-		ConversationID: session.ConversationID,
-		MessageID:      session.MessageID,
-	})
+	if execute {
+		if input.ToolCallback != nil {
+			input.ToolCallback(action.Tool)
+		}
+		result, toolErr = e.executeTool(ctx, tool, &core.ToolParams{
+			UserID:         action.UserID,
+			Input:          action.Input,
+			ConfirmationID: action.ID,
+			IdempotencyKey: actionIdempotencyKey(action),
+			RequestID:      session.ID,
+			ConversationID: session.ConversationID,
+			MessageID:      session.MessageID,
+		})
+		if toolErr == nil && result != nil && result.Success {
+			result = e.pollTransaction(ctx, action, result, trace)
+		}
+
+		settleSpending(toolErr == nil && result != nil && result.Success)
+
+		if e.idempotency != nil {
+			var err error
+			if toolErr == nil && result != nil && result.Success {
+				err = e.idempotency.Complete(ctx, executionKey, result)
+			} else {
+				err = e.idempotency.Release(ctx, executionKey)
+			}
+			if err != nil {
+				e.logger.ErrorContext(ctx, "failed to record idempotency key", "user_id", action.UserID, "confirmation_id", action.ID, "error", err)
+			}
+		}
+	}
 
 	durationMs := time.Since(startTime).Milliseconds()
+	if execute {
+		e.emitToolExecuted(ctx, userID, input.AgentName, action.Tool, time.Since(startTime), result, toolErr)
+	}
 
 	// PHASE 4: OBSERVE - Format observation and complete trace
 	trace.Success = (toolErr == nil && result != nil && result.Success)
 	trace.Observation = formatObservation(tool, result, toolErr)
+	if status := trace.Metadata["transaction_status"]; status != "" && trace.Success {
+		trace.Observation += " [transaction " + status + "]"
+	}
 
 	if !trace.Success {
 		if toolErr != nil {
@@ -356,33 +664,67 @@ func (e *Engine) RunConfirmedAction(ctx context.Context, input *Input, action *c
 			trace.Metadata["error"] = result.Error
 		}
 
-		errorType := categorizeError(trace.Metadata["error"])
+		errorType := failureType(trace, toolErr)
 		trace.Metadata["error_type"] = errorType
-		trace.Metadata["prevention"] = generatePrevention(action.Tool, errorType)
+		trace.Metadata["prevention"] = generatePrevention(ctx, action.Tool, errorType)
+	}
+
+	if trace.Success && execute {
+		e.markMemoryUsed(ctx, userID, action.Tool)
 	}
 
 	// Add trace to session
 	session.AddTrace(trace)
-	log.Printf("[REACT TRACE] %s", trace.String())
+	if execute {
+		e.logTrace(ctx, userID, trace, durationMs)
+	} else {
+		e.logTrace(ctx, userID, trace, -1)
+	}
 
 	// Build tool result block for Claude
 	var toolResult anthropic.ContentBlockParamUnion
 	if toolErr != nil {
-		log.Printf("[CONFIRMATION] Tool execution error, will send to Claude: %v", toolErr)
+		e.logger.WarnContext(ctx, "confirmed tool failed", "user_id", userID, "tool", action.Tool, "confirmation_id", action.ID, "error", toolErr)
 		toolResult = anthropic.NewToolResultBlock(action.BlockID, toolErr.Error(), true)
 	} else if result != nil && !result.Success {
-		log.Printf("[CONFIRMATION] Tool execution failed, will send to Claude: %s", result.Error)
+		e.logger.WarnContext(ctx, "confirmed tool failed", "user_id", userID, "tool", action.Tool, "confirmation_id", action.ID, "error", result.Error)
 		toolResult = anthropic.NewToolResultBlock(action.BlockID, result.Error, true)
 	} else {
-		log.Printf("[CONFIRMATION] Tool execution succeeded, sending result to Claude")
-		resultBytes, _ := json.Marshal(result.Data)
-		toolResult = anthropic.NewToolResultBlock(action.BlockID, string(resultBytes), false)
+		e.logger.DebugContext(ctx, "confirmed tool succeeded", "user_id", userID, "tool", action.Tool, "confirmation_id", action.ID)
+		toolResult = e.successToolResult(ctx, action.BlockID, tool, result, trace, transactionStatusNote(trace))
 	}
 
-	// Add tool result to session (the tool_use block is already in history from RestoreHistory)
-	session.AddToolResults([]anthropic.ContentBlockParamUnion{toolResult})
-	log.Printf("[CONFIRMATION] Entering ReAct loop for follow-up processing...")
+	// Record the execution for ToolsUsed
+	var toolInput interface{}
+	if err := json.Unmarshal(action.Input, &toolInput); err != nil {
+		e.logger.WarnContext(ctx, "failed to decode action input for execution record", "user_id", userID, "tool", action.Tool, "error", err)
+	}
+	execution := core.ToolExecution{
+		Tool:       action.Tool,
+		Input:      toolInput,
+		DurationMs: durationMs,
+	}
+	if toolErr != nil {
+		execution.Error = toolErr.Error()
+	} else if result != nil {
+		if !result.Success {
+			execution.Error = result.Error
+		} else {
+			execution.Result = result.Data
+			execution.Blocks = result.Blocks
+		}
+	}
+
+	return confirmedExecution{
+		execution: execution,
+		block:     toolResult,
+		succeeded: trace.Success,
+	}, nil
+}
 
+// resumeLoop re-enters the ReAct loop after confirmed actions' results were
+// added to the session, and saves the new history.
+func (e *Engine) resumeLoop(ctx context.Context, input *Input, session *Session, restored int) (*Output, error) {
 	// Apply defaults
 	model := input.Model
 	if model == "" {
@@ -392,16 +734,15 @@ func (e *Engine) RunConfirmedAction(ctx context.Context, input *Input, action *c
 	if maxTokens == 0 {
 		maxTokens = 4096
 	}
-	systemPrompt := input.SystemPrompt
-	if systemPrompt == "" {
-		systemPrompt = DefaultSystemPrompt
-	}
+	system := systemSegments(input)
 
 	// Get limits from context
 	maxTurns := 10
+	maxTotalTokens := 0
 	canConfirm := true
 	if input.Context != nil && input.Context.Limits != nil {
 		maxTurns = input.Context.Limits.MaxTurns
+		maxTotalTokens = input.Context.Limits.MaxTotalTokens
 		canConfirm = input.Context.Limits.CanConfirm
 	}
 
@@ -424,52 +765,98 @@ func (e *Engine) RunConfirmedAction(ctx context.Context, input *Input, action *c
 	}
 
 	cfg := &loopConfig{
-		model:         model,
-		maxTokens:     maxTokens,
-		systemPrompt:  systemPrompt,
-		maxTurns:      maxTurns,
-		canConfirm:    canConfirm,
-		apiTools:      apiTools,
-		agentName:     agentName,
-		auditParentID: auditParentID,
+		model:          model,
+		maxTokens:      maxTokens,
+		system:         system,
+		maxTurns:       maxTurns,
+		maxTotalTokens: maxTotalTokens,
+		canConfirm:     canConfirm,
+		apiTools:       apiTools,
+		agentName:      agentName,
+		auditParentID:  auditParentID,
+		toolCallback:   input.ToolCallback,
 	}
 
 	// Enter the ReAct loop - this handles follow-up tool calls, new confirmations, etc.
 	output, err := e.runLoop(ctx, input, session, cfg)
-	if err != nil {
-		return output, err
+	e.saveHistory(ctx, session, restored, output)
+	return output, err
+}
+
+// recordFailure reports a failed run to the guardrails, if configured.
+func (e *Engine) recordFailure(ctx context.Context, input *Input) {
+	if e.guardrails != nil && input.Context != nil {
+		e.guardrails.RecordFailure(ctx, input.Context.UserID)
 	}
+}
 
-	// Prepend the confirmed tool execution to ToolsUsed
-	var toolInput interface{}
-	if err := json.Unmarshal(action.Input, &toolInput); err != nil {
-		log.Printf("[CONFIRMATION] Failed to unmarshal action input for execution record: %v", err)
+// toolSummary returns the confirmation summary for a tool call, preferring
+// the tool's context-aware summary (see core.ContextSummarizer), then its
+// localized summary (see core.SummaryKeyer).
+func toolSummary(ctx context.Context, tool core.Tool, userID string, input json.RawMessage) string {
+	if cs, ok := tool.(core.ContextSummarizer); ok {
+		return cs.GetSummaryContext(ctx, userID, input)
 	}
-	execution := core.ToolExecution{
-		Tool:       action.Tool,
-		Input:      toolInput,
-		DurationMs: durationMs,
+	return core.LocalizedSummary(ctx, tool, input)
+}
+
+// retrieveMemories retrieves memories for the user's message, giving up
+// after the memory timeout if one is set. A retrieval that times out is
+// cancelled and its result discarded, even if the manager ignores ctx.
+func (e *Engine) retrieveMemories(ctx context.Context, userID, message string) (string, error) {
+	if e.memoryTimeout <= 0 {
+		return e.memory.Retrieve(ctx, userID, message)
 	}
-	if toolErr != nil {
-		execution.Error = toolErr.Error()
-	} else if result != nil {
-		if !result.Success {
-			execution.Error = result.Error
-		} else {
-			execution.Result = result.Data
-		}
+	ctx, cancel := context.WithTimeout(ctx, e.memoryTimeout)
+	defer cancel()
+
+	type retrieval struct {
+		enrichment string
+		err        error
+	}
+	done := make(chan retrieval, 1)
+	go func() {
+		enrichment, err := e.memory.Retrieve(ctx, userID, message)
+		done <- retrieval{enrichment, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.enrichment, r.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("memory retrieval: %w", ctx.Err())
 	}
-	output.ToolsUsed = append([]core.ToolExecution{execution}, output.ToolsUsed...)
+}
 
-	return output, nil
+// markMemoryUsed tells the memory manager that a tool succeeded, so it can
+// promote the retrieved memories that led to it (see memory.UsageRecorder).
+func (e *Engine) markMemoryUsed(ctx context.Context, userID, toolName string) {
+	recorder, ok := e.memory.(memory.UsageRecorder)
+	if !ok {
+		return
+	}
+	if err := recorder.MarkUsed(ctx, userID, toolName); err != nil {
+		e.logger.WarnContext(ctx, "failed to mark memories used", "user_id", userID, "tool", toolName, "error", err)
+	}
 }
 
 // runLoop is the core ReAct loop shared by Run() and RunConfirmedAction().
 // It calls Claude, processes tool_use blocks, executes read-only tools, and
 // returns when Claude responds with text only (OutputComplete) or when a
 // write operation needs user confirmation (OutputConfirmationNeeded).
-func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cfg *loopConfig) (*Output, error) {
-	var totalTokens core.TokenUsage
+func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cfg *loopConfig) (out *Output, err error) {
+	ctx = withAvailableTools(ctx, input.AvailableTools)
+	if input.IncludeTraces {
+		defer func() {
+			if out != nil {
+				out.Traces = e.traceSteps(session.Traces)
+			}
+		}()
+	}
+
+	totalTokens := cfg.tokensUsed
+	var partialText string // Text from earlier turns, returned if the token budget runs out
+	var toolsUsed []core.ToolExecution
 
 	for {
 		// Check context cancellation
@@ -483,6 +870,7 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 
 		// Check turn limit
 		if session.TurnCount >= cfg.maxTurns {
+			e.recordFailure(ctx, input)
 			return &Output{
 				Type:       OutputError,
 				Error:      fmt.Errorf("exceeded maximum turns (%d)", cfg.maxTurns),
@@ -490,6 +878,18 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 			}, nil
 		}
 
+		// Check token budget. Checked between turns so a turn's tools
+		// always finish.
+		if used := totalTokens.InputTokens + totalTokens.OutputTokens; cfg.maxTotalTokens > 0 && used >= cfg.maxTotalTokens {
+			e.recordFailure(ctx, input)
+			return &Output{
+				Type:       OutputError,
+				Text:       partialText,
+				Error:      fmt.Errorf("%w: used %d of %d tokens", ErrTokenBudgetExceeded, used, cfg.maxTotalTokens),
+				TokensUsed: totalTokens,
+			}, nil
+		}
+
 		session.IncrementTurnCount()
 
 		// Build the message request
@@ -497,9 +897,7 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 			Model:     anthropic.Model(cfg.model),
 			MaxTokens: cfg.maxTokens,
 			Messages:  session.Messages(),
-			System: []anthropic.TextBlockParam{
-				{Text: cfg.systemPrompt},
-			},
+			System:    systemBlocks(cfg.system),
 		}
 
 		if len(cfg.apiTools) > 0 {
@@ -511,12 +909,15 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 		var err error
 
 		if cfg.streamCallback != nil {
-			resp, err = e.createMessageStreaming(ctx, params, cfg.streamCallback)
+			resp, err = e.createMessageStreaming(ctx, params, session.UserID, cfg)
 		} else {
 			resp, err = e.client.Messages.New(ctx, params)
 		}
 
 		if err != nil {
+			if ctx.Err() == nil {
+				e.recordFailure(ctx, input)
+			}
 			return &Output{
 				Type:       OutputError,
 				Error:      fmt.Errorf("claude API error: %w", err),
@@ -531,8 +932,8 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 		// Process response blocks
 		var toolResults []anthropic.ContentBlockParamUnion
 		var textResponse string
-		var toolsUsed []core.ToolExecution
-		var confirmationNeeded *core.PendingAction
+		var pending []*core.PendingAction // Writes awaiting confirmation
+		audited := 0                      // Tool calls audit logged this turn
 
 		for _, block := range resp.Content {
 			switch block.Type {
@@ -540,6 +941,22 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 				textResponse += block.Text
 
 			case "tool_use":
+				// Stop between tools if the run was cancelled (e.g. the
+				// client disconnected), rather than at the next turn
+				if ctx.Err() != nil {
+					text := partialText
+					if text != "" && textResponse != "" {
+						text += "\n\n"
+					}
+					return &Output{
+						Type:       OutputError,
+						Text:       text + textResponse,
+						Error:      fmt.Errorf("stopped before %s: %w", block.Name, ctx.Err()),
+						ToolsUsed:  toolsUsed,
+						TokensUsed: totalTokens,
+					}, nil
+				}
+
 				toolName := block.Name
 				toolInput := block.Input
 
@@ -563,7 +980,7 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 				if !ok {
 					toolResults = append(toolResults, anthropic.NewToolResultBlock(
 						block.ID,
-						fmt.Sprintf("unknown tool: %s", toolName),
+						core.Localize(ctx, core.MsgUnknownTool, map[string]interface{}{"tool": toolName}),
 						true,
 					))
 					continue
@@ -573,16 +990,35 @@ func (e *Engine) runLoop(ctx context.Context, input *Input, session *Session, cf
 				if tool.RequiresConfirmation() && thought == "" {
 					toolResults = append(toolResults, anthropic.NewToolResultBlock(
 						block.ID,
-						`Error: Missing or empty "thought" field. Write operations require explicit reasoning.
-Please explain:
-1. What you've verified (e.g., "Balance is $500, sufficient for $100 transfer")
-2. Why you're taking this action (e.g., "User requested transfer to Alice")
-3. What you expect to happen (e.g., "This will complete the payment")`,
+						core.Localize(ctx, core.MsgThoughtRequired, nil),
 						true,
 					))
 					continue
 				}
 
+				// ...and for read tools that opt in
+				if tr, ok := tool.(core.ThoughtRequirer); ok && tr.RequiresThought() && thought == "" {
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(
+						block.ID,
+						core.Localize(ctx, core.MsgThoughtRequiredRead, map[string]interface{}{"tool": toolName}),
+						true,
+					))
+					continue
+				}
+
+				// ...and that required thoughts meet the configured quality bar
+				if e.thoughtValidator != nil && thoughtRequired(tool) {
+					if err := e.thoughtValidator(thought, toolName); err != nil {
+						e.logger.DebugContext(ctx, "thought rejected", "user_id", session.UserID, "tool", toolName, "error", err)
+						toolResults = append(toolResults, anthropic.NewToolResultBlock(
+							block.ID,
+							core.Localize(ctx, core.MsgThoughtRejected, map[string]interface{}{"tool": toolName, "reason": err.Error()}),
+							true,
+						))
+						continue
+					}
+				}
+
 				// Create trace object for this action
 				inputBytes, _ := json.Marshal(toolInput)
 				trace := &core.Trace{
@@ -604,26 +1040,50 @@ Please explain:
 						trace.Observation = "Operation blocked: confirmation not allowed in this context"
 						trace.Metadata["error"] = "confirmation_disabled"
 						session.AddTrace(trace)
-						log.Printf("[REACT TRACE] %s", trace.String())
+						e.logTrace(ctx, session.UserID, trace, -1)
 
 						toolResults = append(toolResults, anthropic.NewToolResultBlock(
 							block.ID,
-							"error: this operation requires user confirmation",
+							core.Localize(ctx, core.MsgConfirmationRequired, nil),
 							true,
 						))
 						continue
 					}
 
+					// Reject calls to unknown contracts before asking the user to confirm
+					if reason := e.checkContractCall(toolName, inputBytes); reason != "" {
+						trace.Success = false
+						trace.Observation = "Operation blocked: " + reason
+						trace.Metadata["error"] = "contract_not_allowed"
+						session.AddTrace(trace)
+						e.logTrace(ctx, session.UserID, trace, -1)
+
+						toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, "error: "+reason, true))
+						continue
+					}
+
+					// Enforce spending limits before asking the user to confirm
+					if reason := e.checkSpending(ctx, session.UserID, toolName, inputBytes); reason != "" {
+						trace.Success = false
+						trace.Observation = "Operation blocked: " + reason
+						trace.Metadata["error"] = "spending_limit"
+						session.AddTrace(trace)
+						e.logTrace(ctx, session.UserID, trace, -1)
+
+						toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, "error: "+reason, true))
+						continue
+					}
+
 					// Generate pending confirmation
-					confirmationNeeded = &core.PendingAction{
+					action := &core.PendingAction{
 						ID:             uuid.New().String(),
-						IdempotencyKey: GenerateIdempotencyKey(session.UserID, toolName, inputBytes),
+						IdempotencyKey: toolIdempotencyKey(session.UserID, tool, inputBytes),
 						SessionID:      session.ID,
 						UserID:         session.UserID,
 						Tool:           toolName,
 						Input:          inputBytes,
 						Thought:        thought, // Store thought for ReAct trace on confirmation
-						Summary:        tool.GetSummary(inputBytes),
+						Summary:        toolSummary(ctx, tool, session.UserID, inputBytes),
 						BlockID:        block.ID,
 						CreatedAt:      time.Now().Unix(),
 						ExpiresAt:      time.Now().Add(10 * time.Minute).Unix(),
@@ -632,16 +1092,26 @@ Please explain:
 					// Store trace with pending status
 					trace.Success = false
 					trace.Observation = "Awaiting user confirmation"
-					trace.Metadata["confirmation_id"] = confirmationNeeded.ID
+					trace.Metadata["confirmation_id"] = action.ID
 					trace.Metadata["status"] = "pending_confirmation"
 					session.AddTrace(trace)
-					log.Printf("[REACT TRACE] %s", trace.String())
+					e.logTrace(ctx, session.UserID, trace, -1)
+					e.emit(ctx, Event{
+						Type:      EventConfirmationRequested,
+						UserID:    session.UserID,
+						AgentName: cfg.agentName,
+						Tool:      toolName,
+					})
+					pending = append(pending, action)
 					break
 				}
 
 				// PHASE 3: ACT - Execute read-only tool
+				if cfg.toolCallback != nil {
+					cfg.toolCallback(toolName)
+				}
 				startTime := time.Now()
-				result, err := tool.Execute(ctx, &core.ToolParams{
+				result, err := e.executeTool(ctx, tool, &core.ToolParams{
 					UserID:         session.UserID,
 					Input:          inputBytes,
 					RequestID:      session.ID,
@@ -650,6 +1120,7 @@ Please explain:
 				})
 
 				durationMs := time.Since(startTime).Milliseconds()
+				e.emitToolExecuted(ctx, session.UserID, cfg.agentName, toolName, time.Since(startTime), result, err)
 				execution := core.ToolExecution{
 					Tool:       toolName,
 					Input:      toolInput,
@@ -671,16 +1142,16 @@ Please explain:
 					}
 
 					// Categorize error for reflexion
-					errorType := categorizeError(trace.Metadata["error"])
+					errorType := failureType(trace, err)
 					trace.Metadata["error_type"] = errorType
-					trace.Metadata["prevention"] = generatePrevention(toolName, errorType)
+					trace.Metadata["prevention"] = generatePrevention(ctx, toolName, errorType)
 				}
 
 				// Add trace to session
 				session.AddTrace(trace)
 
 				// Log the ReAct trace
-				log.Printf("[REACT TRACE] %s", trace.String())
+				e.logTrace(ctx, session.UserID, trace, durationMs)
 
 				// Log audit entry if configured
 				if e.audit != nil {
@@ -696,7 +1167,7 @@ Please explain:
 						errMsg := err.Error()
 						errStr = &errMsg
 					}
-					e.audit.Log(ctx, &AuditEntry{
+					e.audit.Log(ctx, withTurnUsage(&AuditEntry{
 						ID:         uuid.New().String(),
 						UserID:     session.UserID,
 						SessionID:  session.ID,
@@ -704,13 +1175,14 @@ Please explain:
 						ParentID:   cfg.auditParentID,
 						AgentName:  cfg.agentName,
 						ToolName:   toolName,
-						ToolInput:  inputBytes,
+						ToolInput:  e.auditInput(toolName, inputBytes),
 						ToolOutput: outputBytes,
 						Error:      errStr,
 						DurationMs: durationMs,
 						IsWriteOp:  tool.RequiresConfirmation(),
 						Timestamp:  startTime.Unix(),
-					})
+					}, resp))
+					audited++
 				}
 
 				// Build tool result for Claude
@@ -723,33 +1195,51 @@ Please explain:
 				} else {
 					if result != nil {
 						execution.Result = result.Data
+						execution.Blocks = result.Blocks
 					}
-					resultBytes, _ := json.Marshal(result.Data)
-					toolResults = append(toolResults, anthropic.NewToolResultBlock(
-						block.ID, string(resultBytes), false))
+					if trace.Success {
+						e.markMemoryUsed(ctx, session.UserID, toolName)
+					}
+					toolResults = append(toolResults, e.successToolResult(ctx, block.ID, tool, result, trace, ""))
 				}
 
 				toolsUsed = append(toolsUsed, execution)
 			}
 
-			if confirmationNeeded != nil {
+			// Without batching, stop at the first write; with it, collect
+			// the turn's remaining writes too
+			if len(pending) > 0 && !e.batchConfirmations {
 				break
 			}
 		}
 
+		if e.audit != nil && audited == 0 {
+			e.auditTurn(ctx, session, cfg, resp)
+		}
+
 		// If confirmation needed, filter blocks and return for user approval
-		if confirmationNeeded != nil {
-			filteredBlocks := filterBlocksForConfirmation(resp, confirmationNeeded.BlockID)
+		if len(pending) > 0 {
+			blockIDs := make([]string, len(pending))
+			for i, action := range pending {
+				blockIDs[i] = action.BlockID
+			}
+			filteredBlocks := filterBlocksForConfirmation(resp, blockIDs...)
 			session.AddAssistantBlocks(filteredBlocks)
 
-			return &Output{
+			output := &Output{
 				Type:           OutputConfirmationNeeded,
 				Text:           textResponse,
-				PendingAction:  confirmationNeeded,
 				ToolsUsed:      toolsUsed,
 				ResponseBlocks: filteredBlocks,
 				TokensUsed:     totalTokens,
-			}, nil
+			}
+			if len(pending) == 1 {
+				output.PendingAction = pending[0]
+			} else {
+				output.Type = OutputBatchConfirmationNeeded
+				output.PendingBatch = newPendingBatch(pending)
+			}
+			return output, nil
 		}
 
 		// If no tool calls, we're done
@@ -772,9 +1262,16 @@ Please explain:
 					AssistantResponse: textResponse,
 					Traces:            session.Traces,
 				}
-				if err := e.memory.Record(ctx, input.Context.UserID, interaction); err != nil {
-					log.Printf("[MEMORY] Failed to record interaction: %v", err)
+				err := e.memory.Record(ctx, input.Context.UserID, interaction)
+				if err != nil {
+					e.logger.WarnContext(ctx, "failed to record interaction", "user_id", input.Context.UserID, "error", err)
 				}
+				e.emit(ctx, Event{
+					Type:      EventMemoryRecorded,
+					UserID:    input.Context.UserID,
+					AgentName: cfg.agentName,
+					Err:       err,
+				})
 			}
 
 			return &Output{
@@ -786,33 +1283,53 @@ Please explain:
 		}
 
 		// Continue loop with tool results
+		if textResponse != "" {
+			if partialText != "" {
+				partialText += "\n\n"
+			}
+			partialText += textResponse
+		}
 		session.AddAssistantResponse(resp)
 		session.AddToolResults(toolResults)
 	}
 }
 
 // createMessageStreaming handles streaming API calls.
-func (e *Engine) createMessageStreaming(ctx context.Context, params anthropic.MessageNewParams, callback func(string, bool)) (*anthropic.Message, error) {
+// Tool inputs are accumulated separately (see toolInputStream) so they match
+// what the non-streaming API returns. Accumulation errors are logged and
+// emitted as EventStreamError; an incomplete tool_use block fails the call
+// rather than returning a message without it.
+func (e *Engine) createMessageStreaming(ctx context.Context, params anthropic.MessageNewParams, userID string, cfg *loopConfig) (*anthropic.Message, error) {
 	stream := e.client.Messages.NewStreaming(ctx, params)
 	defer stream.Close()
 
 	// Accumulate the message from events
 	message := anthropic.Message{}
+	toolInputs := newToolInputStream(cfg.inputCallback)
 
 	for stream.Next() {
 		event := stream.Current()
 
 		// Accumulate into the message
 		if err := message.Accumulate(event); err != nil {
-			// Log but continue - accumulation errors are non-fatal
+			// Non-fatal on its own: toolInputs.apply fails the call if a
+			// tool_use block was lost
+			e.logger.WarnContext(ctx, "failed to accumulate stream event", "user_id", userID, "event", string(event.Type), "error", err)
+			e.emit(ctx, Event{
+				Type:      EventStreamError,
+				UserID:    userID,
+				AgentName: cfg.agentName,
+				Err:       err,
+			})
 		}
+		toolInputs.handle(event)
 
 		// Handle different event types
 		switch evt := event.AsAny().(type) {
 		case anthropic.ContentBlockDeltaEvent:
 			switch delta := evt.Delta.AsAny().(type) {
 			case anthropic.TextDelta:
-				callback(delta.Text, false)
+				cfg.streamCallback(delta.Text, false)
 			}
 		case anthropic.MessageStopEvent:
 			// Stream complete
@@ -822,6 +1339,9 @@ func (e *Engine) createMessageStreaming(ctx context.Context, params anthropic.Me
 	if err := stream.Err(); err != nil {
 		return nil, err
 	}
+	if err := toolInputs.apply(&message); err != nil {
+		return nil, fmt.Errorf("incomplete streamed response: %w", err)
+	}
 
 	return &message, nil
 }
@@ -841,17 +1361,22 @@ func responseToBlocks(resp *anthropic.Message) []core.ContentBlock {
 	return blocks
 }
 
-// filterBlocksForConfirmation returns only text blocks and the single tool_use
-// block that requires confirmation. Other tool_use blocks are dropped to prevent
+// filterBlocksForConfirmation returns only text blocks and the tool_use blocks
+// that require confirmation. Other tool_use blocks are dropped to prevent
 // "tool_use without tool_result" errors when the history is restored later.
-func filterBlocksForConfirmation(resp *anthropic.Message, confirmedBlockID string) []core.ContentBlock {
+func filterBlocksForConfirmation(resp *anthropic.Message, confirmedBlockIDs ...string) []core.ContentBlock {
+	confirmed := make(map[string]bool, len(confirmedBlockIDs))
+	for _, id := range confirmedBlockIDs {
+		confirmed[id] = true
+	}
+
 	var blocks []core.ContentBlock
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
 			blocks = append(blocks, core.NewTextBlock(block.Text))
 		case "tool_use":
-			if block.ID == confirmedBlockID {
+			if confirmed[block.ID] {
 				inputBytes, _ := json.Marshal(block.Input)
 				blocks = append(blocks, core.NewToolUseBlock(block.ID, block.Name, inputBytes))
 			}
@@ -927,36 +1452,20 @@ func categorizeError(errMsg string) string {
 	}
 }
 
-// generatePrevention suggests how to avoid this error in the future
-func generatePrevention(action, errorType string) string {
-	preventionMap := map[string]string{
-		"send_money:insufficient_balance":          "Check balance with get_balance before attempting transfer",
-		"send_money:not_found":                     "Verify recipient exists with search_users before transfer",
-		"send_money:invalid_input":                 "Validate amount is positive and recipient ID format is correct",
-		"deposit_savings:insufficient_balance":     "Check wallet balance before depositing to savings",
-		"withdraw_savings:insufficient_balance":    "Check savings balance with get_savings_balance before withdrawal",
-	}
-
-	key := action + ":" + errorType
-	if prevention, ok := preventionMap[key]; ok {
+// generatePrevention suggests how to avoid this error in the future, in the
+// run's language (see WithLocalizer)
+func generatePrevention(ctx context.Context, action, errorType string) string {
+	key := core.PreventionKey(action, errorType)
+	if prevention := core.Localize(ctx, key, nil); prevention != key {
 		return prevention
 	}
 
 	// Generic prevention by error type
-	switch errorType {
-	case "insufficient_balance":
-		return "Check balance before attempting operation"
-	case "not_found":
-		return "Verify the entity exists before referencing it"
-	case "invalid_input":
-		return "Validate input parameters before submission"
-	case "rate_limit":
-		return "Implement retry with backoff"
-	case "timeout":
-		return "Retry operation with timeout handling"
-	default:
-		return "Review error message and adjust approach accordingly"
+	key = core.PreventionKey("", errorType)
+	if prevention := core.Localize(ctx, key, nil); prevention != key {
+		return prevention
 	}
+	return core.Localize(ctx, core.MsgPreventionDefault, nil)
 }
 
 // RunAgent executes an Agent using the engine.
@@ -1001,6 +1510,7 @@ func (e *Engine) RunAgent(ctx context.Context, agent core.Agent, input *core.Inp
 		Type:           core.OutputType(output.Type),
 		Text:           output.Text,
 		PendingAction:  output.PendingAction,
+		PendingBatch:   output.PendingBatch,
 		ToolsUsed:      output.ToolsUsed,
 		ResponseBlocks: output.ResponseBlocks,
 		TokensUsed:     output.TokensUsed,
@@ -0,0 +1,559 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/memory"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+func TestRunPassesContextValuesToTools(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "whoami", map[string]interface{}{}),
+		textResponse("You are on tenant acme."),
+	)
+
+	var gotTenant string
+	var gotOK bool
+	registry := NewToolRegistry()
+	registry.Register(testTool("whoami", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		gotTenant, gotOK = core.ContextValue(ctx, "tenant")
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"tenant": gotTenant}}, nil
+	}))
+
+	input := testInput("which tenant am I on?")
+	input.Context.Values = map[string]string{"tenant": "acme"}
+
+	output, err := NewEngine(client, registry).Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Type != OutputComplete {
+		t.Fatalf("Run() type = %v, want OutputComplete (error: %v)", output.Type, output.Error)
+	}
+	if !gotOK || gotTenant != "acme" {
+		t.Errorf("ContextValue(tenant) = (%q, %v), want (\"acme\", true)", gotTenant, gotOK)
+	}
+}
+
+func TestRunConfirmedActionPassesContextValuesToTools(t *testing.T) {
+	_, client := newFakeClaude(t, textResponse("Sent $10."))
+
+	var gotTenant string
+	var gotOK bool
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		gotTenant, gotOK = core.ContextValue(ctx, "tenant")
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	input := testInput("")
+	input.Context.Values = map[string]string{"tenant": "acme"}
+	action := &core.PendingAction{
+		ID:      "action-1",
+		UserID:  "user-1",
+		Tool:    "send_money",
+		Input:   []byte(`{"amount":"10"}`),
+		BlockID: "toolu_1",
+	}
+
+	if _, err := NewEngine(client, registry).RunConfirmedAction(context.Background(), input, action); err != nil {
+		t.Fatalf("RunConfirmedAction() error = %v", err)
+	}
+	if !gotOK || gotTenant != "acme" {
+		t.Errorf("ContextValue(tenant) = (%q, %v), want (\"acme\", true)", gotTenant, gotOK)
+	}
+}
+
+func TestRunConfirmedActionIdempotent(t *testing.T) {
+	_, client := newFakeClaude(t,
+		textResponse("Sent $10."),
+		textResponse("Sent $10."),
+		textResponse("Sent $10."),
+	)
+
+	executions := 0
+	fail := true
+	var keys []string
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executions++
+		keys = append(keys, params.IdempotencyKey)
+		if fail {
+			return &core.ToolResult{Success: false, Error: "provider unavailable"}, nil
+		}
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"tx": "tx-1"}}, nil
+	}))
+	eng := NewEngine(client, registry, WithIdempotency(store.NewMemoryIdempotency(), 0))
+
+	action := &core.PendingAction{
+		ID:             "action-1",
+		UserID:         "user-1",
+		Tool:           "send_money",
+		Input:          []byte(`{"amount":"10"}`),
+		BlockID:        "toolu_1",
+		IdempotencyKey: "key-1",
+	}
+	confirm := func() *Output {
+		t.Helper()
+		output, err := eng.RunConfirmedAction(context.Background(), testInput(""), action)
+		if err != nil {
+			t.Fatalf("RunConfirmedAction() error = %v", err)
+		}
+		return output
+	}
+
+	// A failed execution doesn't claim the key.
+	confirm()
+	fail = false
+	confirm()
+	output := confirm()
+
+	if executions != 2 {
+		t.Errorf("tool executed %d times, want 2", executions)
+	}
+	// Both attempts carry the action's ID so the backend can deduplicate too.
+	for _, key := range keys {
+		if key != "action-1" {
+			t.Errorf("tool got IdempotencyKey %q, want action-1", key)
+		}
+	}
+	got := output.ToolsUsed[0].Result.(map[string]interface{})
+	if got["tx"] != "tx-1" {
+		t.Errorf("replayed result = %v, want tx-1", got)
+	}
+}
+
+func TestRunConfirmedActionRepeatedIdenticalSends(t *testing.T) {
+	send := map[string]interface{}{"recipient": "@alice", "amount": "20", "thought": "User asked to send $20 to Alice"}
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", send),
+		textResponse("Sent $20."),
+		toolUseResponse("toolu_2", "send_money", send),
+		textResponse("Sent another $20."),
+	)
+
+	var keys []string
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		keys = append(keys, params.IdempotencyKey)
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"tx": fmt.Sprintf("tx-%d", len(keys))}}, nil
+	}))
+	eng := NewEngine(client, registry,
+		WithConversationStore(store.NewMemoryConversationStore()),
+		WithIdempotency(store.NewMemoryIdempotency(), 0),
+	)
+
+	// The user sends $20 to @alice twice on purpose: two actions with the
+	// same content, each confirmed once
+	for i := 0; i < 2; i++ {
+		output, err := eng.Run(context.Background(), testInput("send $20 to alice"))
+		if err != nil || output.PendingAction == nil {
+			t.Fatalf("Run() #%d = (%v, %v), want a pending send_money", i+1, output.Type, err)
+		}
+		output, err = eng.RunConfirmedAction(context.Background(), testInput(""), output.PendingAction)
+		if err != nil || output.Type != OutputComplete {
+			t.Fatalf("RunConfirmedAction() #%d = (%v, %v), want OutputComplete", i+1, output.Type, err)
+		}
+		if got := output.ToolsUsed[0].Result.(map[string]interface{})["tx"]; got != fmt.Sprintf("tx-%d", i+1) {
+			t.Errorf("confirmation #%d result tx = %v, want a new transfer", i+1, got)
+		}
+	}
+	if len(keys) != 2 {
+		t.Fatalf("send_money executed %d times, want 2", len(keys))
+	}
+	// The gateway sees two writes too
+	if keys[0] == "" || keys[0] == keys[1] {
+		t.Errorf("IdempotencyKeys = %q, want a different key per action", keys)
+	}
+}
+
+func TestToolIdempotencyKeyUsesIdempotencyFields(t *testing.T) {
+	keyed := core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		RequiresUserConfirmation: true,
+		IdempotencyFields:        []string{"recipient", "amount", "currency"},
+	}, nil)
+	plain := testTool("send_money", true, nil)
+
+	first := json.RawMessage(`{"recipient":"@alice","amount":"10","currency":"USDC","client_ts":"2026-01-01T10:00:00Z","thought":"User asked"}`)
+	retry := json.RawMessage(`{"thought":"Retrying the user's request","client_ts":"2026-01-01T10:00:05Z","currency":"USDC","amount":"10","recipient":"@alice"}`)
+	other := json.RawMessage(`{"recipient":"@alice","amount":"20","currency":"USDC","client_ts":"2026-01-01T10:00:00Z","thought":"User asked"}`)
+
+	if toolIdempotencyKey("user-1", keyed, first) != toolIdempotencyKey("user-1", keyed, retry) {
+		t.Error("inputs differing only in volatile fields got different keys")
+	}
+	if toolIdempotencyKey("user-1", keyed, first) == toolIdempotencyKey("user-1", keyed, other) {
+		t.Error("inputs with different amounts got the same key")
+	}
+	if toolIdempotencyKey("user-1", keyed, first) == toolIdempotencyKey("user-2", keyed, first) {
+		t.Error("different users got the same key")
+	}
+	if toolIdempotencyKey("user-1", plain, first) == toolIdempotencyKey("user-1", plain, retry) {
+		t.Error("tool without IdempotencyFields ignored volatile fields")
+	}
+}
+
+func TestConversationStoreResumesConfirmation(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "10", "thought": "User asked to send $10"}),
+		textResponse("Sent $10."),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"tx": "tx-1"}}, nil
+	}))
+
+	history, err := store.NewSQLiteConversationStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteConversationStore() error = %v", err)
+	}
+	defer history.Close()
+	eng := NewEngine(client, registry, WithConversationStore(history))
+
+	output, err := eng.Run(context.Background(), testInput("send $10"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+
+	// No History passed: the tool_use block comes from the store.
+	input := testInput("")
+	output, err = eng.RunConfirmedAction(context.Background(), input, output.PendingAction)
+	if err != nil || output.Type != OutputComplete {
+		t.Fatalf("RunConfirmedAction() = (%v, %v), want OutputComplete", output.Type, err)
+	}
+
+	// The follow-up request carried user, tool_use and tool_result messages.
+	reqs := fake.Requests()
+	if got := len(reqs[1]["messages"].([]interface{})); got != 3 {
+		t.Errorf("follow-up request has %d messages, want 3", got)
+	}
+
+	stored, _ := history.Load(context.Background(), "conv-1")
+	want := []core.Role{core.RoleUser, core.RoleAssistant, core.RoleUser, core.RoleAssistant}
+	if len(stored) != len(want) {
+		t.Fatalf("stored %d messages, want %d", len(stored), len(want))
+	}
+	for i, msg := range stored {
+		if msg.Role != want[i] {
+			t.Errorf("stored[%d].Role = %q, want %q", i, msg.Role, want[i])
+		}
+	}
+	if block := stored[2].ContentBlocks; len(block) != 1 || block[0].ToolResult == nil || block[0].ToolResult.ToolUseID != "toolu_1" {
+		t.Errorf("stored[2] = %+v, want tool_result for toolu_1", stored[2])
+	}
+	if stored[3].Content != "Sent $10." {
+		t.Errorf("stored[3].Content = %q, want %q", stored[3].Content, "Sent $10.")
+	}
+}
+
+func TestRunRequiresThoughtForOptedInReadTool(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "analyze_spending", map[string]interface{}{}),
+		toolUseResponse("toolu_2", "analyze_spending", map[string]interface{}{"thought": "User asked where their money goes"}),
+		textResponse("Mostly coffee."),
+	)
+
+	calls := 0
+	registry := NewToolRegistry()
+	registry.Register(core.NewBaseTool(core.ToolDefinition{
+		ToolName:       "analyze_spending",
+		RequireThought: true,
+		InputSchema:    map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		calls++
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"top": "coffee"}}, nil
+	}))
+
+	output, err := NewEngine(client, registry).Run(context.Background(), testInput("where does my money go?"))
+	if err != nil || output.Type != OutputComplete {
+		t.Fatalf("Run() = (%v, %v), want OutputComplete", output.Type, err)
+	}
+	if calls != 1 {
+		t.Errorf("tool executed %d times, want 1 (call without thought rejected)", calls)
+	}
+
+	// The rejected call came back to Claude as an error tool_result.
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	result := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if result["is_error"] != true {
+		t.Errorf("first tool_result = %v, want is_error", result)
+	}
+}
+
+// usageMemory is a memory.Manager that records MarkUsed calls.
+type usageMemory struct {
+	used []string
+}
+
+func (m *usageMemory) Retrieve(ctx context.Context, userID, userMessage string) (string, error) {
+	return "", nil
+}
+
+func (m *usageMemory) Record(ctx context.Context, userID string, interaction *memory.Interaction) error {
+	return nil
+}
+
+func (m *usageMemory) MarkUsed(ctx context.Context, userID, action string) error {
+	m.used = append(m.used, userID+":"+action)
+	return nil
+}
+
+func TestRunMarksMemoryUsedOnSuccess(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{}),
+		toolUseResponse("toolu_2", "get_savings", map[string]interface{}{}),
+		textResponse("Done."),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	}))
+	registry.Register(testTool("get_savings", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: false, Error: "unavailable"}, nil
+	}))
+
+	mem := &usageMemory{}
+	if _, err := NewEngine(client, registry, WithMemory(mem)).Run(context.Background(), testInput("balance?")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(mem.used) != 1 || mem.used[0] != "user-1:get_balance" {
+		t.Errorf("MarkUsed calls = %q, want [\"user-1:get_balance\"]", mem.used)
+	}
+}
+
+// slowMemory is a memory manager whose Retrieve blocks until released,
+// ignoring context cancellation.
+type slowMemory struct {
+	release chan struct{}
+}
+
+func (m slowMemory) Retrieve(ctx context.Context, userID, userMessage string) (string, error) {
+	<-m.release
+	return "=== MEMORIES ===", nil
+}
+
+func (m slowMemory) Record(ctx context.Context, userID string, interaction *memory.Interaction) error {
+	return nil
+}
+
+func TestRunMemoryTimeout(t *testing.T) {
+	fake, client := newFakeClaude(t, textResponse("Hi."))
+	mem := slowMemory{release: make(chan struct{})}
+	defer close(mem.release)
+
+	eng := NewEngine(client, NewToolRegistry(), WithMemory(mem), WithMemoryTimeout(50*time.Millisecond))
+	start := time.Now()
+	output, err := eng.Run(context.Background(), testInput("hello"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run() took %v with a 50ms memory timeout", elapsed)
+	}
+	if output.Type != OutputComplete || output.Text != "Hi." {
+		t.Errorf("output = (%v, %q), want the answer without memories", output.Type, output.Text)
+	}
+	if system := fake.Requests()[0]["system"].([]interface{}); len(system) != 1 {
+		t.Errorf("system has %d blocks, want only the prompt", len(system))
+	}
+}
+
+func TestRunStopsAtTokenBudget(t *testing.T) {
+	first := toolUseResponse("toolu_1", "get_balance", map[string]interface{}{})
+	first["content"] = append([]map[string]interface{}{{"type": "text", "text": "Checking your balance."}},
+		first["content"].([]map[string]interface{})...)
+	fake, client := newFakeClaude(t, first, textResponse("unreachable"))
+
+	executed := false
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executed = true
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	input := testInput("what's my balance?")
+	input.Context.Limits.MaxTotalTokens = 10 // The first turn uses 15
+
+	output, err := NewEngine(client, registry).Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Type != OutputError || !errors.Is(output.Error, ErrTokenBudgetExceeded) {
+		t.Fatalf("output = (%v, %v), want OutputError with ErrTokenBudgetExceeded", output.Type, output.Error)
+	}
+	if !executed {
+		t.Error("the turn's tool call did not finish before stopping")
+	}
+	if output.Text != "Checking your balance." {
+		t.Errorf("output text = %q, want the partial text", output.Text)
+	}
+	if got := len(fake.Requests()); got != 1 {
+		t.Errorf("Claude called %d times, want 1", got)
+	}
+}
+
+func TestRunStopsBetweenToolsWhenCancelled(t *testing.T) {
+	resp := toolUseResponse("toolu_1", "get_balance", map[string]interface{}{})
+	resp["content"] = []map[string]interface{}{
+		{"type": "text", "text": "Let me look."},
+		{"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": map[string]interface{}{}},
+		{"type": "tool_use", "id": "toolu_2", "name": "get_transactions", "input": map[string]interface{}{}},
+		{"type": "tool_use", "id": "toolu_3", "name": "get_balance", "input": map[string]interface{}{}},
+	}
+	fake, client := newFakeClaude(t, resp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls []string
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		calls = append(calls, "get_balance")
+		cancel() // The client disconnects while the first tool runs
+		return &core.ToolResult{Success: true}, nil
+	}))
+	registry.Register(testTool("get_transactions", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		calls = append(calls, "get_transactions")
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	output, err := NewEngine(client, registry).Run(ctx, testInput("balance and transactions?"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Type != OutputError || !errors.Is(output.Error, context.Canceled) {
+		t.Fatalf("output = (%v, %v), want OutputError with context.Canceled", output.Type, output.Error)
+	}
+	if len(calls) != 1 {
+		t.Errorf("tools called = %q, want only the first", calls)
+	}
+	if len(output.ToolsUsed) != 1 || output.ToolsUsed[0].Tool != "get_balance" {
+		t.Errorf("ToolsUsed = %+v, want the first tool", output.ToolsUsed)
+	}
+	if output.Text != "Let me look." {
+		t.Errorf("output text = %q, want the partial text", output.Text)
+	}
+	if output.TokensUsed.InputTokens != 10 || output.TokensUsed.OutputTokens != 5 {
+		t.Errorf("TokensUsed = %+v, want the first turn's usage", output.TokensUsed)
+	}
+	if got := len(fake.Requests()); got != 1 {
+		t.Errorf("Claude called %d times, want 1", got)
+	}
+}
+
+// warningTool is a write tool with a per-user confirmation summary.
+type warningTool struct {
+	core.Tool
+}
+
+func (w warningTool) GetSummaryContext(ctx context.Context, userID string, input json.RawMessage) string {
+	return w.GetSummary(input) + " (first payment from " + userID + ")"
+}
+
+func TestRunUsesContextSummarizer(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "10", "thought": "User asked to send $10"}),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(warningTool{testTool("send_money", true, nil)})
+	eng := NewEngine(client, registry)
+
+	output, err := eng.Run(context.Background(), testInput("send $10"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+	if got, want := output.PendingAction.Summary, "send_money (first payment from user-1)"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+// capLimiter is a SpendingLimiter allowing up to limit in total.
+type capLimiter struct {
+	limit, spent float64
+}
+
+func (l *capLimiter) amount(input json.RawMessage) float64 {
+	var params struct {
+		Amount float64 `json:"amount,string"`
+	}
+	json.Unmarshal(input, &params)
+	return params.Amount
+}
+
+func (l *capLimiter) Check(ctx context.Context, userID, tool string, input json.RawMessage) (*SpendingResult, error) {
+	if l.spent+l.amount(input) > l.limit {
+		return &SpendingResult{Allowed: false, Reason: "daily limit reached"}, nil
+	}
+	return &SpendingResult{Allowed: true}, nil
+}
+
+func (l *capLimiter) Record(ctx context.Context, userID, tool string, input json.RawMessage) error {
+	l.spent += l.amount(input)
+	return nil
+}
+
+func TestRunEnforcesSpendingLimits(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "80", "thought": "User asked to send $80"}),
+		textResponse("That's over your daily limit."),
+		toolUseResponse("toolu_2", "send_money", map[string]interface{}{"amount": "30", "thought": "User asked to send $30"}),
+		textResponse("You've hit your daily limit."),
+		textResponse("Sent $30."),
+	)
+
+	executed := 0
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executed++
+		return &core.ToolResult{Success: true}, nil
+	}))
+	limiter := &capLimiter{limit: 50}
+	eng := NewEngine(client, registry, WithSpendingLimits(limiter))
+
+	// Over the limit: no confirmation, and Claude gets the reason
+	output, err := eng.Run(context.Background(), testInput("send $80"))
+	if err != nil || output.Type != OutputComplete {
+		t.Fatalf("Run() = (%v, %v), want OutputComplete", output.Type, err)
+	}
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	result := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if result["is_error"] != true {
+		t.Errorf("tool_result = %v, want is_error", result)
+	}
+
+	// Within the limit: confirmation is requested...
+	output, err = eng.Run(context.Background(), testInput("send $30"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+	action := output.PendingAction
+
+	// ...but another transfer lands before the user confirms
+	limiter.spent = 40
+	if _, err := eng.RunConfirmedAction(context.Background(), testInput(""), action); err != nil {
+		t.Fatalf("RunConfirmedAction() error = %v", err)
+	}
+	if executed != 0 {
+		t.Fatalf("send_money executed %d times, want 0 (blocked at confirmation)", executed)
+	}
+
+	limiter.spent = 0
+	if _, err := eng.RunConfirmedAction(context.Background(), testInput(""), action); err != nil {
+		t.Fatalf("RunConfirmedAction() error = %v", err)
+	}
+	if executed != 1 || limiter.spent != 30 {
+		t.Errorf("executed = %d, spent = %v; want 1 and 30 recorded", executed, limiter.spent)
+	}
+}
@@ -0,0 +1,175 @@
+package enginetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// Claude is a scripted fake of the Claude Messages API. Each request is
+// answered with the next queued response; a request with nothing queued
+// fails the test. Streaming requests aren't supported, so runs driven
+// against it shouldn't set Input.StreamCallback.
+type Claude struct {
+	t      testing.TB
+	client *anthropic.Client
+
+	mu        sync.Mutex
+	responses []anthropic.Message
+	requests  []anthropic.MessageNewParams
+}
+
+// NewClaude starts a fake Claude server, closed when the test ends, with
+// responses queued.
+func NewClaude(t testing.TB, responses ...anthropic.Message) *Claude {
+	t.Helper()
+	c := &Claude{t: t, responses: responses}
+	srv := httptest.NewServer(http.HandlerFunc(c.handle))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithBaseURL(srv.URL),
+		option.WithAPIKey("test-key"),
+		option.WithMaxRetries(0),
+	)
+	c.client = &client
+	return c
+}
+
+// Client returns an Anthropic client that talks to the fake.
+func (c *Claude) Client() *anthropic.Client {
+	return c.client
+}
+
+// Queue adds responses to the end of the queue.
+func (c *Claude) Queue(responses ...anthropic.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses = append(c.responses, responses...)
+}
+
+// Pending returns how many queued responses haven't been requested yet.
+func (c *Claude) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.responses)
+}
+
+// Requests returns the requests received so far, in order.
+func (c *Claude) Requests() []anthropic.MessageNewParams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]anthropic.MessageNewParams(nil), c.requests...)
+}
+
+func (c *Claude) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req anthropic.MessageNewParams
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.t.Errorf("enginetest: invalid Messages API request: %v", err)
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	n := len(c.requests)
+	if len(c.responses) == 0 {
+		c.mu.Unlock()
+		c.t.Errorf("enginetest: unexpected Messages API call #%d: no response queued", n)
+		http.Error(w, `{"type":"error","error":{"type":"api_error","message":"no response queued"}}`, http.StatusInternalServerError)
+		return
+	}
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	c.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wireMessage(resp, n))
+}
+
+// wireMessage encodes msg as the Messages API would, filling in the ID,
+// model and stop reason if unset. n numbers the response.
+func wireMessage(msg anthropic.Message, n int) map[string]interface{} {
+	content := make([]map[string]interface{}, 0, len(msg.Content))
+	stopReason := anthropic.StopReasonEndTurn
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "tool_use":
+			input := block.Input
+			if len(input) == 0 {
+				input = json.RawMessage(`{}`)
+			}
+			content = append(content, map[string]interface{}{"type": "tool_use", "id": block.ID, "name": block.Name, "input": input})
+			stopReason = anthropic.StopReasonToolUse
+		case "thinking":
+			content = append(content, map[string]interface{}{"type": "thinking", "thinking": block.Thinking, "signature": block.Signature})
+		default:
+			content = append(content, map[string]interface{}{"type": "text", "text": block.Text})
+		}
+	}
+	if msg.StopReason != "" {
+		stopReason = msg.StopReason
+	}
+
+	id := msg.ID
+	if id == "" {
+		id = fmt.Sprintf("msg_%d", n)
+	}
+	model := msg.Model
+	if model == "" {
+		model = "claude-test"
+	}
+	return map[string]interface{}{
+		"id":          id,
+		"type":        "message",
+		"role":        "assistant",
+		"model":       model,
+		"stop_reason": stopReason,
+		"content":     content,
+		"usage": map[string]interface{}{
+			"input_tokens":  msg.Usage.InputTokens,
+			"output_tokens": msg.Usage.OutputTokens,
+		},
+	}
+}
+
+// Reply builds a response with the given content blocks. It stops for tool
+// use if any block is a tool_use.
+func Reply(blocks ...anthropic.ContentBlockUnion) anthropic.Message {
+	return anthropic.Message{Content: blocks}
+}
+
+// Text builds a response that ends the turn with text.
+func Text(text string) anthropic.Message {
+	return Reply(TextBlock(text))
+}
+
+// ToolUse builds a response calling one tool. input is marshaled to JSON
+// unless it's already a json.RawMessage.
+func ToolUse(id, name string, input interface{}) anthropic.Message {
+	return Reply(ToolUseBlock(id, name, input))
+}
+
+// TextBlock builds a text content block.
+func TextBlock(text string) anthropic.ContentBlockUnion {
+	return anthropic.ContentBlockUnion{Type: "text", Text: text}
+}
+
+// ToolUseBlock builds a tool_use content block. input is marshaled to JSON
+// unless it's already a json.RawMessage.
+func ToolUseBlock(id, name string, input interface{}) anthropic.ContentBlockUnion {
+	raw, ok := input.(json.RawMessage)
+	if !ok && input != nil {
+		var err error
+		if raw, err = json.Marshal(input); err != nil {
+			panic(fmt.Sprintf("enginetest: tool %s input: %v", name, err))
+		}
+	}
+	return anthropic.ContentBlockUnion{Type: "tool_use", ID: id, Name: name, Input: raw}
+}
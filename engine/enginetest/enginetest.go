@@ -0,0 +1,264 @@
+// Package enginetest drives agent conversations end to end against a
+// scripted fake of Claude, for deterministic integration tests:
+//
+//	h := enginetest.New(t)
+//	h.Register(tools.LiminalTools(mock)...)
+//	h.Claude.Queue(
+//		enginetest.ToolUse("toolu_1", "send_money", map[string]interface{}{
+//			"recipient": "@alice", "amount": "10", "currency": "USD",
+//			"thought": "User asked to send $10 to Alice",
+//		}),
+//		enginetest.Text("Sent $10 to @alice."),
+//	)
+//
+//	out := h.Run("send $10 to alice")
+//	h.AssertPending(out, "send_money")
+//	out = h.Confirm()
+//	h.AssertComplete(out)
+//	h.AssertToolsUsed(out, "send_money")
+//
+// The harness keeps the conversation's history between runs, as a server
+// would, so a confirmation resumes where the run that requested it stopped.
+package enginetest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+// Harness runs one conversation through an engine backed by a fake Claude.
+// Runs that return an error fail the test.
+type Harness struct {
+	T        testing.TB
+	Claude   *Claude
+	Engine   *engine.Engine
+	Registry *engine.ToolRegistry
+
+	// Context identifies the user and conversation for every run. Tests can
+	// change it, e.g. its Values or Limits, between runs.
+	Context *core.Context
+
+	// Model is sent to the fake Claude. Default: "claude-test".
+	Model string
+
+	// Last is the output of the most recent run.
+	Last *engine.Output
+
+	history  *historyStore
+	recorder *traceRecorder
+}
+
+// New creates a harness with an empty tool registry and a fake Claude with
+// nothing queued. opts configure the engine; the harness keeps history with
+// engine.WithConversationStore and records traces with engine.WithMemory,
+// so passing either option replaces the harness's.
+func New(t testing.TB, opts ...engine.Option) *Harness {
+	t.Helper()
+	claude := NewClaude(t)
+	registry := engine.NewToolRegistry()
+	history := &historyStore{}
+	recorder := &traceRecorder{}
+
+	opts = append([]engine.Option{
+		engine.WithConversationStore(history),
+		engine.WithMemory(recorder),
+	}, opts...)
+
+	return &Harness{
+		T:        t,
+		Claude:   claude,
+		Engine:   engine.NewEngine(claude.Client(), registry, opts...),
+		Registry: registry,
+		Context:  core.NewContext("user-1", "session-1", "conv-1", "req-1"),
+		Model:    "claude-test",
+		history:  history,
+		recorder: recorder,
+	}
+}
+
+// Register adds tools to the registry, failing the test on a duplicate.
+func (h *Harness) Register(tools ...core.Tool) *Harness {
+	h.T.Helper()
+	if err := h.Registry.RegisterAll(tools...); err != nil {
+		h.T.Fatalf("enginetest: register tools: %v", err)
+	}
+	return h
+}
+
+// Run sends a user message and returns the engine's output.
+func (h *Harness) Run(message string) *engine.Output {
+	h.T.Helper()
+	return h.RunInput(&engine.Input{UserMessage: message})
+}
+
+// RunInput runs input, defaulting its Context and Model to the harness's.
+// Use it to set callbacks or per-run options.
+func (h *Harness) RunInput(input *engine.Input) *engine.Output {
+	h.T.Helper()
+	h.fill(input)
+	out, err := h.Engine.Run(context.Background(), input)
+	if err != nil {
+		h.T.Fatalf("enginetest: Run(%q) error = %v", input.UserMessage, err)
+	}
+	h.Last = out
+	return out
+}
+
+// Confirm confirms the action or batch the last run is waiting on and
+// returns the output of resuming the conversation.
+func (h *Harness) Confirm() *engine.Output {
+	h.T.Helper()
+	if h.Last == nil {
+		h.T.Fatalf("enginetest: Confirm() before any run")
+	}
+
+	input := &engine.Input{}
+	h.fill(input)
+	var out *engine.Output
+	var err error
+	switch {
+	case h.Last.PendingAction != nil:
+		out, err = h.Engine.RunConfirmedAction(context.Background(), input, h.Last.PendingAction)
+	case h.Last.PendingBatch != nil:
+		out, err = h.Engine.RunConfirmedBatch(context.Background(), input, h.Last.PendingBatch)
+	default:
+		h.T.Fatalf("enginetest: Confirm() with nothing pending; last output type = %v", h.Last.Type)
+	}
+	if err != nil {
+		h.T.Fatalf("enginetest: Confirm() error = %v", err)
+	}
+	h.Last = out
+	return out
+}
+
+func (h *Harness) fill(input *engine.Input) {
+	if input.Context == nil {
+		input.Context = h.Context
+	}
+	if input.Model == "" {
+		input.Model = h.Model
+	}
+}
+
+// History returns the conversation's messages so far.
+func (h *Harness) History() []core.Message {
+	messages, _ := h.history.Load(context.Background(), h.Context.ConversationID)
+	return messages
+}
+
+// Traces returns the ReAct traces the engine has recorded so far, in order.
+// The engine records a run's traces when it completes, so a run that ends
+// waiting for confirmation contributes none.
+func (h *Harness) Traces() []*core.Trace {
+	return h.recorder.Traces()
+}
+
+// AssertComplete checks that out finished without needing confirmation.
+func (h *Harness) AssertComplete(out *engine.Output) {
+	h.T.Helper()
+	if out.Type != engine.OutputComplete {
+		h.T.Errorf("output type = %v (error: %v), want OutputComplete", out.Type, out.Error)
+	}
+}
+
+// AssertText checks that out's text contains substr.
+func (h *Harness) AssertText(out *engine.Output, substr string) {
+	h.T.Helper()
+	if !strings.Contains(out.Text, substr) {
+		h.T.Errorf("output text = %q, want it to contain %q", out.Text, substr)
+	}
+}
+
+// AssertPending checks that out is waiting for confirmation of a single
+// call to tool and returns the pending action.
+func (h *Harness) AssertPending(out *engine.Output, tool string) *core.PendingAction {
+	h.T.Helper()
+	if out.Type != engine.OutputConfirmationNeeded || out.PendingAction == nil {
+		h.T.Fatalf("output type = %v (error: %v), want OutputConfirmationNeeded for %s", out.Type, out.Error, tool)
+	}
+	if out.PendingAction.Tool != tool {
+		h.T.Errorf("pending action tool = %s, want %s", out.PendingAction.Tool, tool)
+	}
+	return out.PendingAction
+}
+
+// AssertToolsUsed checks that out's tool executions are of tools, in order.
+func (h *Harness) AssertToolsUsed(out *engine.Output, tools ...string) {
+	h.T.Helper()
+	got := make([]string, len(out.ToolsUsed))
+	for i, execution := range out.ToolsUsed {
+		got[i] = execution.Tool
+	}
+	if strings.Join(got, ",") != strings.Join(tools, ",") {
+		h.T.Errorf("tools used = %v, want %v", got, tools)
+	}
+}
+
+// AssertTraces checks that the recorded traces are of actions, in order,
+// and all succeeded.
+func (h *Harness) AssertTraces(actions ...string) {
+	h.T.Helper()
+	traces := h.Traces()
+	got := make([]string, len(traces))
+	for i, trace := range traces {
+		got[i] = trace.Action
+		if !trace.Success {
+			h.T.Errorf("trace %d (%s) failed: %s", i, trace.Action, trace.Observation)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(actions, ",") {
+		h.T.Errorf("traces = %v, want %v", got, actions)
+	}
+}
+
+// historyStore is an in-memory engine.ConversationStore.
+type historyStore struct {
+	mu       sync.Mutex
+	messages map[string][]core.Message
+}
+
+func (s *historyStore) Load(ctx context.Context, conversationID string) ([]core.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]core.Message{}, s.messages[conversationID]...), nil
+}
+
+func (s *historyStore) Append(ctx context.Context, conversationID string, messages []core.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.messages == nil {
+		s.messages = make(map[string][]core.Message)
+	}
+	s.messages[conversationID] = append(s.messages[conversationID], messages...)
+	return nil
+}
+
+// traceRecorder is a memory.Manager that keeps the recorded traces and
+// retrieves nothing.
+type traceRecorder struct {
+	mu     sync.Mutex
+	traces []*core.Trace
+}
+
+func (r *traceRecorder) Retrieve(ctx context.Context, userID, userMessage string) (string, error) {
+	return "", nil
+}
+
+func (r *traceRecorder) Record(ctx context.Context, userID string, interaction *memory.Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.traces = append(r.traces, interaction.Traces...)
+	return nil
+}
+
+func (r *traceRecorder) Traces() []*core.Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*core.Trace(nil), r.traces...)
+}
@@ -0,0 +1,73 @@
+package enginetest_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/engine/enginetest"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestConfirmationFlow(t *testing.T) {
+	mock := executor.NewMock()
+	mock.On("get_balance").Return(map[string]interface{}{"totalUsd": "250.00"})
+	mock.On("get_transactions").Return(map[string]interface{}{"transactions": []interface{}{}})
+	mock.On("send_money").Return(map[string]interface{}{"status": "sent", "transactionId": "tx_1"})
+
+	h := enginetest.New(t)
+	h.Register(tools.LiminalTools(mock)...)
+	h.Claude.Queue(
+		enginetest.ToolUse("toolu_1", "get_balance", map[string]interface{}{}),
+		enginetest.ToolUse("toolu_2", "send_money", map[string]interface{}{
+			"recipient": "@alice",
+			"amount":    "10",
+			"currency":  "USD",
+			"thought":   "Balance is $250, enough for the $10 the user asked to send to Alice",
+		}),
+		enginetest.Text("Sent $10 to @alice."),
+	)
+
+	// The agent checks the balance, then asks to confirm the transfer
+	out := h.Run("send $10 to alice")
+	action := h.AssertPending(out, "send_money")
+	h.AssertToolsUsed(out, "get_balance")
+	if !strings.Contains(action.Summary, "@alice") {
+		t.Errorf("confirmation summary = %q, want the recipient", action.Summary)
+	}
+	if len(mock.Calls("send_money")) != 0 {
+		t.Fatal("send_money executed before confirmation")
+	}
+
+	// Confirming executes it and lets the agent finish
+	out = h.Confirm()
+	h.AssertComplete(out)
+	h.AssertText(out, "Sent $10")
+	h.AssertToolsUsed(out, "send_money")
+	h.AssertTraces("send_money")
+	if h.Claude.Pending() != 0 {
+		t.Errorf("%d Claude responses unused", h.Claude.Pending())
+	}
+
+	calls := mock.Calls("send_money")
+	if len(calls) != 1 || calls[0].UserID != h.Context.UserID {
+		t.Fatalf("send_money calls = %+v, want one for %s", calls, h.Context.UserID)
+	}
+	var input map[string]interface{}
+	json.Unmarshal(calls[0].Input, &input)
+	if input["recipient"] != "@alice" || input["amount"] != "10" {
+		t.Errorf("send_money input = %v, want $10 to @alice", input)
+	}
+
+	// Claude saw the balance result and, after confirming, the transfer's
+	requests := h.Claude.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("Claude received %d requests, want 3", len(requests))
+	}
+	last := requests[2].Messages
+	result := last[len(last)-1].Content[0].OfToolResult
+	if result == nil || result.ToolUseID != "toolu_2" {
+		t.Errorf("last message = %+v, want the send_money tool_result", last[len(last)-1])
+	}
+}
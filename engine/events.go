@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// EventSink receives events as the engine runs, e.g. to export metrics.
+// HandleEvent is called synchronously on the run's goroutine, possibly from
+// several runs at once, so implementations must be fast and safe for
+// concurrent use.
+type EventSink interface {
+	HandleEvent(ctx context.Context, event Event)
+}
+
+// EventType identifies the kind of engine event.
+type EventType string
+
+const (
+	// EventRunCompleted is emitted when Run or RunConfirmedAction returns.
+	// Output, Tokens and Duration describe the whole run.
+	EventRunCompleted EventType = "run_completed"
+
+	// EventToolExecuted is emitted after a tool executes. Tool, Duration and
+	// Err describe the execution.
+	EventToolExecuted EventType = "tool_executed"
+
+	// EventConfirmationRequested is emitted when a write tool is paused for
+	// user confirmation.
+	EventConfirmationRequested EventType = "confirmation_requested"
+
+	// EventMemoryRetrieved is emitted after memory retrieval. Hit reports
+	// whether any memories were injected into the prompt.
+	EventMemoryRetrieved EventType = "memory_retrieved"
+
+	// EventMemoryRecorded is emitted after an interaction is recorded to
+	// memory.
+	EventMemoryRecorded EventType = "memory_recorded"
+
+	// EventStreamError is emitted when a streamed response event can't be
+	// accumulated into the message. Err describes the failure.
+	EventStreamError EventType = "stream_error"
+)
+
+// Event describes something that happened during a run. Fields not
+// relevant to the event's Type are zero.
+type Event struct {
+	Type      EventType
+	UserID    string
+	AgentName string
+
+	Tool     string        // Tool events
+	Duration time.Duration // Run and tool events
+
+	Output OutputType      // EventRunCompleted
+	Tokens core.TokenUsage // EventRunCompleted
+
+	Hit bool // EventMemoryRetrieved
+
+	Err error // Failure, if any
+}
+
+// WithEventSink sends engine events to sink.
+func WithEventSink(sink EventSink) Option {
+	return func(e *Engine) {
+		e.events = sink
+	}
+}
+
+// emit sends an event to the sink, if configured.
+func (e *Engine) emit(ctx context.Context, event Event) {
+	if e.events != nil {
+		e.events.HandleEvent(ctx, event)
+	}
+}
+
+// emitRunCompleted sends the EventRunCompleted for a run started at start.
+func (e *Engine) emitRunCompleted(ctx context.Context, input *Input, start time.Time, output *Output, err error) {
+	if e.events == nil {
+		return
+	}
+	agentName := input.AgentName
+	if agentName == "" {
+		agentName = "default"
+	}
+	event := Event{
+		Type:      EventRunCompleted,
+		AgentName: agentName,
+		Duration:  time.Since(start),
+		Output:    OutputError,
+		Err:       err,
+	}
+	if input.Context != nil {
+		event.UserID = input.Context.UserID
+	}
+	if output != nil {
+		event.Output = output.Type
+		event.Tokens = output.TokensUsed
+		if event.Err == nil {
+			event.Err = output.Error
+		}
+	}
+	e.emit(ctx, event)
+}
+
+// emitToolExecuted sends an EventToolExecuted. A result that reports
+// failure counts as an error.
+func (e *Engine) emitToolExecuted(ctx context.Context, userID, agentName, tool string, duration time.Duration, result *core.ToolResult, err error) {
+	if e.events == nil {
+		return
+	}
+	if err == nil && result != nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	e.emit(ctx, Event{
+		Type:      EventToolExecuted,
+		UserID:    userID,
+		AgentName: agentName,
+		Tool:      tool,
+		Duration:  duration,
+		Err:       err,
+	})
+}
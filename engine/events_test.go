@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) HandleEvent(ctx context.Context, event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func TestEventSink(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{}),
+		toolUseResponse("toolu_2", "send_money", map[string]interface{}{"thought": "User asked to pay Bob"}),
+		textResponse("Sent."),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: false, Error: "account locked"}, nil
+	}))
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	sink := &recordingSink{}
+	eng := NewEngine(client, registry, WithEventSink(sink))
+
+	input := testInput("pay bob")
+	output, err := eng.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := eng.RunConfirmedAction(context.Background(), testInput(""), output.PendingAction); err != nil {
+		t.Fatalf("RunConfirmedAction() error = %v", err)
+	}
+
+	want := []struct {
+		typ    EventType
+		tool   string
+		output OutputType
+		failed bool
+	}{
+		{EventToolExecuted, "get_balance", 0, true},
+		{EventConfirmationRequested, "send_money", 0, false},
+		{EventRunCompleted, "", OutputConfirmationNeeded, false},
+		{EventToolExecuted, "send_money", 0, false},
+		{EventRunCompleted, "", OutputComplete, false},
+	}
+	if len(sink.events) != len(want) {
+		t.Fatalf("got %d events %+v, want %d", len(sink.events), sink.events, len(want))
+	}
+	for i, w := range want {
+		got := sink.events[i]
+		if got.Type != w.typ || got.Tool != w.tool || got.Output != w.output || (got.Err != nil) != w.failed {
+			t.Errorf("event %d = {%s %q %v err=%v}, want {%s %q %v failed=%v}",
+				i, got.Type, got.Tool, got.Output, got.Err, w.typ, w.tool, w.output, w.failed)
+		}
+		if got.UserID != "user-1" {
+			t.Errorf("event %d UserID = %q, want user-1", i, got.UserID)
+		}
+	}
+	if tokens := sink.events[2].Tokens; tokens.InputTokens != 20 || tokens.OutputTokens != 10 {
+		t.Errorf("first run tokens = %+v, want 20 in / 10 out", tokens)
+	}
+}
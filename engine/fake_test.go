@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// fakeClaude is a scripted Messages API server for engine tests.
+// Each request pops the next canned response from the queue.
+type fakeClaude struct {
+	t         *testing.T
+	mu        sync.Mutex
+	responses []map[string]interface{}
+	requests  []map[string]interface{}
+}
+
+func newFakeClaude(t *testing.T, responses ...map[string]interface{}) (*fakeClaude, *anthropic.Client) {
+	t.Helper()
+	f := &fakeClaude{t: t, responses: responses}
+	srv := httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(srv.Close)
+
+	client := anthropic.NewClient(
+		option.WithBaseURL(srv.URL),
+		option.WithAPIKey("test-key"),
+		option.WithMaxRetries(0),
+	)
+	return f, &client
+}
+
+func (f *fakeClaude) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req map[string]interface{}
+	json.Unmarshal(body, &req)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	if len(f.responses) == 0 {
+		f.mu.Unlock()
+		f.t.Errorf("unexpected Messages API call #%d", len(f.requests))
+		http.Error(w, `{"type":"error","error":{"type":"api_error","message":"no response queued"}}`, http.StatusInternalServerError)
+		return
+	}
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	f.mu.Unlock()
+
+	if events, ok := resp["_stream"].([]map[string]interface{}); ok {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], data)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Requests returns the decoded request bodies received so far.
+func (f *fakeClaude) Requests() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]interface{}(nil), f.requests...)
+}
+
+// textResponse builds a canned end_turn message.
+func textResponse(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "msg_text",
+		"type":        "message",
+		"role":        "assistant",
+		"model":       "claude-test",
+		"stop_reason": "end_turn",
+		"content": []map[string]interface{}{
+			{"type": "text", "text": text},
+		},
+		"usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 5},
+	}
+}
+
+// toolUseResponse builds a canned tool_use message.
+func toolUseResponse(id, name string, input map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "msg_" + id,
+		"type":        "message",
+		"role":        "assistant",
+		"model":       "claude-test",
+		"stop_reason": "tool_use",
+		"content": []map[string]interface{}{
+			{"type": "tool_use", "id": id, "name": name, "input": input},
+		},
+		"usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 5},
+	}
+}
+
+// streamResponse builds a canned streaming response from raw SSE events.
+func streamResponse(events ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"_stream": events}
+}
+
+// testTool builds a simple tool backed by a handler.
+func testTool(name string, write bool, handler func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error)) core.Tool {
+	return core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 name,
+		ToolDescription:          "test tool " + name,
+		RequiresUserConfirmation: write,
+		SummaryTemplate:          name,
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}, handler)
+}
+
+func testInput(message string) *Input {
+	return &Input{
+		UserMessage: message,
+		Context:     core.NewContext("user-1", "session-1", "conv-1", "req-1"),
+		Model:       "claude-test",
+	}
+}
@@ -1,11 +1,14 @@
 package engine
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
 )
 
 // IdempotencyBucketDuration is the time window for idempotency key generation.
@@ -13,6 +16,29 @@ import (
 // the same idempotency key.
 const IdempotencyBucketDuration = 10 * time.Minute
 
+// DefaultIdempotencyTTL is how long executed actions are remembered when
+// WithIdempotency is given a zero TTL.
+const DefaultIdempotencyTTL = time.Hour
+
+// IdempotencyStore records which confirmed actions have executed, so a
+// repeated confirmation (double-click, client retry) returns the prior result
+// instead of executing the action again.
+// The store package provides in-memory and SQLite implementations.
+type IdempotencyStore interface {
+	// Begin claims key before a confirmed action executes. started is true if
+	// the caller should execute the action. Otherwise key was already claimed
+	// within its TTL, and result is the recorded result, or nil if that
+	// execution is still in progress.
+	Begin(ctx context.Context, key string, ttl time.Duration) (result *core.ToolResult, started bool, err error)
+
+	// Complete records the result of an action started with Begin.
+	Complete(ctx context.Context, key string, result *core.ToolResult) error
+
+	// Release forgets key so the action can be executed again.
+	// Called when an execution fails.
+	Release(ctx context.Context, key string) error
+}
+
 // GenerateIdempotencyKey creates a unique key for deduplicating confirmations.
 // Keys are deterministic based on userID, tool name, canonicalized input, and
 // a 10-minute time bucket. This prevents duplicate confirmations for the same
@@ -50,3 +76,29 @@ func GenerateIdempotencyKeyWithTime(userID, tool string, input json.RawMessage,
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
+
+// toolIdempotencyKey generates the idempotency key for a call to tool. Tools
+// that implement core.IdempotencyKeyer choose which parts of the input are
+// hashed.
+func toolIdempotencyKey(userID string, tool core.Tool, input json.RawMessage) string {
+	if keyer, ok := tool.(core.IdempotencyKeyer); ok {
+		input = keyer.IdempotencyInput(input)
+	}
+	return GenerateIdempotencyKey(userID, tool.Name(), input)
+}
+
+// actionExecutionKey returns the IdempotencyStore key for a confirmed
+// action. It's the action's ID, not its content hash, so a repeated
+// confirmation of the same action is deduplicated but a second, identical
+// action (say, sending $20 to @alice twice on purpose) still executes.
+func actionExecutionKey(action *core.PendingAction) string {
+	return "action:" + action.ID
+}
+
+// actionIdempotencyKey returns the key passed to the tool for a confirmed
+// action, and on to the gateway's Idempotency-Key header. Like
+// actionExecutionKey it's the action's ID, so the gateway deduplicates a
+// retried confirmation but applies a second, identical action too.
+func actionIdempotencyKey(action *core.PendingAction) string {
+	return action.ID
+}
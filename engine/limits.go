@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// SpendingLimiter enforces money limits on write tools, such as a cap on a
+// single transfer or on a user's total outbound per day. The engine checks it
+// before asking the user to confirm a write and again before executing the
+// confirmed write (totals may have changed in between), and records the
+// write once it succeeds. Blocked writes fail with the result's Reason.
+// Limiters that also implement SpendingReserver make the last check and the
+// record atomic.
+//
+// Implementations decide which tools move money and how much, typically
+// from the tool input; Check and Record should ignore other tools.
+type SpendingLimiter interface {
+	// Check reports whether userID may call tool with input.
+	Check(ctx context.Context, userID, tool string, input json.RawMessage) (*SpendingResult, error)
+
+	// Record adds a successfully executed call to userID's running totals.
+	Record(ctx context.Context, userID, tool string, input json.RawMessage) error
+}
+
+// SpendingReserver is an optional interface for SpendingLimiters that can
+// check a call and hold its amount in one atomic step. Without it, two
+// confirmed writes executing at once can both pass Check against the same
+// total and together exceed a cap. With it, the engine reserves a confirmed
+// write's amount before executing it instead of calling Check, and commits
+// or releases the reservation instead of calling Record.
+type SpendingReserver interface {
+	// Reserve checks the call like Check and, if it's allowed, holds its
+	// amount against userID's totals. reservation is nil if the call isn't
+	// allowed.
+	Reserve(ctx context.Context, userID, tool string, input json.RawMessage) (result *SpendingResult, reservation SpendingReservation, err error)
+}
+
+// SpendingReservation is an amount held by SpendingReserver.Reserve until
+// the write it was reserved for finishes.
+type SpendingReservation interface {
+	// Commit keeps the amount, counting it as spent. Called when the write
+	// succeeds.
+	Commit(ctx context.Context) error
+
+	// Release gives the amount back. Called when the write fails.
+	Release(ctx context.Context) error
+}
+
+// SpendingResult is the outcome of a spending limit check.
+type SpendingResult struct {
+	// Allowed indicates whether the write may proceed.
+	Allowed bool
+
+	// Reason explains why the write was blocked, for the user and Claude,
+	// e.g. "Transfers are limited to 500.00 per day; 450.00 already sent today".
+	Reason string
+}
+
+// WithSpendingLimits sets the spending limiter consulted before write tools.
+func WithSpendingLimits(l SpendingLimiter) Option {
+	return func(e *Engine) {
+		e.spending = l
+	}
+}
+
+// checkSpending runs the spending limiter, if configured. It returns a
+// non-empty reason if the call is blocked. A failed check blocks the call,
+// since a limit that can't be verified mustn't be bypassed.
+func (e *Engine) checkSpending(ctx context.Context, userID, tool string, input json.RawMessage) string {
+	if e.spending == nil {
+		return ""
+	}
+	result, err := e.spending.Check(ctx, userID, tool, input)
+	if err != nil {
+		e.logger.ErrorContext(ctx, "spending check failed", "user_id", userID, "tool", tool, "error", err)
+		return "Spending limits couldn't be checked, so this action was not performed. Please try again later."
+	}
+	if !result.Allowed {
+		return result.Reason
+	}
+	return ""
+}
+
+// reserveSpending checks a write that is about to execute against the
+// spending limiter, reserving its amount if the limiter is a
+// SpendingReserver. It returns a non-empty reason if the write is blocked,
+// and otherwise settle, which the caller must call with whether the write
+// succeeded: it commits or releases the reservation, or records a
+// successful write with a plain SpendingLimiter.
+func (e *Engine) reserveSpending(ctx context.Context, userID, tool string, input json.RawMessage) (reason string, settle func(succeeded bool)) {
+	if e.spending == nil {
+		return "", func(bool) {}
+	}
+	reserver, ok := e.spending.(SpendingReserver)
+	if !ok {
+		if reason := e.checkSpending(ctx, userID, tool, input); reason != "" {
+			return reason, nil
+		}
+		return "", func(succeeded bool) {
+			if !succeeded {
+				return
+			}
+			if err := e.spending.Record(ctx, userID, tool, input); err != nil {
+				e.logger.ErrorContext(ctx, "failed to record spending", "user_id", userID, "tool", tool, "error", err)
+			}
+		}
+	}
+
+	result, reservation, err := reserver.Reserve(ctx, userID, tool, input)
+	if err != nil {
+		e.logger.ErrorContext(ctx, "spending check failed", "user_id", userID, "tool", tool, "error", err)
+		return "Spending limits couldn't be checked, so this action was not performed. Please try again later.", nil
+	}
+	if !result.Allowed {
+		return result.Reason, nil
+	}
+	return "", func(succeeded bool) {
+		if reservation == nil {
+			return
+		}
+		settle, action := reservation.Release, "release"
+		if succeeded {
+			settle, action = reservation.Commit, "commit"
+		}
+		if err := settle(ctx); err != nil {
+			e.logger.ErrorContext(ctx, "failed to "+action+" spending reservation", "user_id", userID, "tool", tool, "error", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"context"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// WithLocalizer translates confirmation summaries (for tools with a summary
+// key, e.g. the Liminal write tools) and the engine's built-in messages,
+// such as "this operation requires user confirmation" and the prevention
+// advice recorded for failed actions. Messages it doesn't translate fall
+// back to core.English. Tools can localize their own messages with
+// core.Localize, which uses the run's localizer.
+func WithLocalizer(l core.Localizer) Option {
+	return func(e *Engine) {
+		e.localizer = l
+	}
+}
+
+// WithLocalizerFor uses l instead of the WithLocalizer default for runs
+// whose Context.Preferences.Locale is locale, compared case-insensitively.
+// A language-only locale such as "pt" also matches its regional variants
+// ("pt-BR", "pt_PT") unless they have their own localizer.
+func WithLocalizerFor(locale string, l core.Localizer) Option {
+	return func(e *Engine) {
+		if e.localizers == nil {
+			e.localizers = make(map[string]core.Localizer)
+		}
+		e.localizers[normalizeLocale(locale)] = l
+	}
+}
+
+// withLocalizer returns ctx carrying the localizer for the run's locale.
+func (e *Engine) withLocalizer(ctx context.Context, c *core.Context) context.Context {
+	return core.WithLocalizer(ctx, e.localizerFor(c))
+}
+
+// localizerFor returns the localizer for c's locale, or the default.
+func (e *Engine) localizerFor(c *core.Context) core.Localizer {
+	if len(e.localizers) == 0 || c == nil || c.Preferences == nil {
+		return e.localizer
+	}
+	locale := normalizeLocale(c.Preferences.Locale)
+	if l, ok := e.localizers[locale]; ok {
+		return l
+	}
+	if lang, _, ok := strings.Cut(locale, "-"); ok {
+		if l, ok := e.localizers[lang]; ok {
+			return l
+		}
+	}
+	return e.localizer
+}
+
+// normalizeLocale lowercases locale and uses "-" as the region separator.
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestLocalizedConfirmationSummary(t *testing.T) {
+	english := core.Catalog{"summary.send_money": "Send {{money .amount}} to {{.recipient}}"}
+	portuguese := core.Catalog{"summary.send_money": "Enviar {{money .amount}} para {{.recipient}}"}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en-US", "Send 30.00 to @ana"},
+		{"pt-BR", "Enviar 30.00 para @ana"},
+		{"pt_PT", "Enviar 30.00 para @ana"},
+		{"", "Send 30.00 to @ana"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			_, client := newFakeClaude(t,
+				toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "30", "recipient": "@ana", "thought": "User asked"}),
+			)
+			registry := NewToolRegistry()
+			registry.Register(core.NewBaseTool(core.ToolDefinition{
+				ToolName:                 "send_money",
+				RequiresUserConfirmation: true,
+				SummaryTemplate:          "send_money",
+				SummaryKey:               "summary.send_money",
+				InputSchema:              map[string]interface{}{"type": "object"},
+			}, nil))
+			eng := NewEngine(client, registry, WithLocalizer(english), WithLocalizerFor("pt", portuguese))
+
+			input := testInput("send @ana $30")
+			input.Context.Preferences.Locale = tt.locale
+			output, err := eng.Run(context.Background(), input)
+			if err != nil || output.Type != OutputConfirmationNeeded {
+				t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+			}
+			if output.PendingAction.Summary != tt.want {
+				t.Errorf("Summary = %q, want %q", output.PendingAction.Summary, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizedEngineMessages(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_weather", map[string]interface{}{}),
+		textResponse("No puedo."),
+	)
+	eng := NewEngine(client, NewToolRegistry(), WithLocalizer(core.Catalog{
+		core.MsgUnknownTool: "herramienta desconocida: {{.tool}}",
+	}))
+
+	if _, err := eng.Run(context.Background(), testInput("¿qué tiempo hace?")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	result := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if text != "herramienta desconocida: get_weather" {
+		t.Errorf("tool_result = %q, want the localized unknown tool message", text)
+	}
+}
+
+func TestGeneratePreventionFallsBack(t *testing.T) {
+	ctx := core.WithLocalizer(context.Background(), core.Catalog{
+		core.PreventionKey("", "not_found"): "Verifique que existe",
+	})
+
+	tests := []struct {
+		action, errorType, want string
+	}{
+		{"send_money", "not_found", "Verify recipient exists with search_users before transfer"},
+		{"get_balance", "not_found", "Verifique que existe"},
+		{"get_balance", "timeout", "Retry operation with timeout handling"},
+		{"get_balance", "unknown", "Review error message and adjust approach accordingly"},
+	}
+	for _, tt := range tests {
+		if got := generatePrevention(ctx, tt.action, tt.errorType); got != tt.want {
+			t.Errorf("generatePrevention(%s, %s) = %q, want %q", tt.action, tt.errorType, got, tt.want)
+		}
+	}
+}
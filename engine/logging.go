@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// WithLogger sets the structured logger for engine logs. Records carry
+// attributes such as user_id, tool, trace_id and duration_ms, so they can be
+// rendered as JSON and filtered by level. Defaults to slog.Default(); use a
+// logger with a discarding handler to silence the engine.
+func WithLogger(l *slog.Logger) Option {
+	return func(e *Engine) {
+		e.logger = l
+	}
+}
+
+// logTrace logs a ReAct trace. Failed steps are logged at warn level.
+// durationMs is omitted if negative (the tool didn't run).
+func (e *Engine) logTrace(ctx context.Context, userID string, trace *core.Trace, durationMs int64) {
+	level := slog.LevelInfo
+	if !trace.Success {
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("trace_id", trace.ID),
+		slog.String("session_id", trace.SessionID),
+		slog.String("user_id", userID),
+		slog.String("tool", trace.Action),
+		slog.Bool("success", trace.Success),
+		slog.String("thought", truncateLog(trace.Thought, 200)),
+		slog.String("observation", truncateLog(trace.Observation, 200)),
+	}
+	if durationMs >= 0 {
+		attrs = append(attrs, slog.Int64("duration_ms", durationMs))
+	}
+	if errMsg, ok := trace.Metadata["error"]; ok {
+		attrs = append(attrs, slog.String("error", errMsg))
+	}
+	if status, ok := trace.Metadata["status"]; ok {
+		attrs = append(attrs, slog.String("status", status))
+	}
+	e.logger.LogAttrs(ctx, level, "react trace", attrs...)
+}
+
+// truncateLog shortens s to at most n bytes for logging.
+func truncateLog(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestWithLoggerLogsStructuredTraces(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{}),
+		textResponse("You have $10."),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "10"}}, nil
+	}))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	if _, err := NewEngine(client, registry, WithLogger(logger)).Run(context.Background(), testInput("balance?")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var trace map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		if record["msg"] == "react trace" {
+			trace = record
+		}
+	}
+	if trace == nil {
+		t.Fatalf("no react trace logged: %s", buf.String())
+	}
+	if trace["user_id"] != "user-1" || trace["tool"] != "get_balance" || trace["success"] != true {
+		t.Errorf("trace record = %v", trace)
+	}
+	for _, key := range []string{"trace_id", "duration_ms"} {
+		if _, ok := trace[key]; !ok {
+			t.Errorf("trace record missing %s: %v", key, trace)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ErrorTypePanic is the trace error_type of a tool that panicked.
+const ErrorTypePanic = "panic"
+
+// ToolPanicError is the error for a tool whose Execute panicked. The panic
+// is recovered so one broken tool can't crash the server; Claude gets the
+// error as the tool's result and the run continues.
+type ToolPanicError struct {
+	Tool  string
+	Value interface{} // The value passed to panic
+	Stack []byte      // The panicking goroutine's stack
+}
+
+func (e *ToolPanicError) Error() string {
+	return fmt.Sprintf("tool %s failed unexpectedly: %v", e.Tool, e.Value)
+}
+
+// executeTool runs tool, converting a panic into a *ToolPanicError.
+func (e *Engine) executeTool(ctx context.Context, tool core.Tool, params *core.ToolParams) (result *core.ToolResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &ToolPanicError{Tool: tool.Name(), Value: r, Stack: debug.Stack()}
+			e.logger.ErrorContext(ctx, "tool panicked", "user_id", params.UserID, "tool", tool.Name(), "panic", fmt.Sprint(r), "stack", string(panicErr.Stack))
+			result, err = nil, panicErr
+		}
+	}()
+	return tool.Execute(ctx, params)
+}
+
+// failureType classifies a failed call for its trace's error_type. A panic
+// also records its stack in the trace's metadata.
+func failureType(trace *core.Trace, err error) string {
+	var panicErr *ToolPanicError
+	if errors.As(err, &panicErr) {
+		trace.Metadata["stack"] = string(panicErr.Stack)
+		return ErrorTypePanic
+	}
+	return categorizeError(trace.Metadata["error"])
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/memory"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// traceMemory is a memory.Manager that keeps the recorded traces.
+type traceMemory struct {
+	traces []*core.Trace
+}
+
+func (m *traceMemory) Retrieve(ctx context.Context, userID, userMessage string) (string, error) {
+	return "", nil
+}
+
+func (m *traceMemory) Record(ctx context.Context, userID string, interaction *memory.Interaction) error {
+	m.traces = append(m.traces, interaction.Traces...)
+	return nil
+}
+
+func panickingTool(name string, write bool) core.Tool {
+	return testTool(name, write, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		var rates map[string]float64
+		rates["USD"] = 1 // assignment to entry in nil map
+		return &core.ToolResult{Success: true}, nil
+	})
+}
+
+func assertPanicTrace(t *testing.T, traces []*core.Trace, tool string) {
+	t.Helper()
+	if len(traces) != 1 {
+		t.Fatalf("recorded %d traces, want one for %s", len(traces), tool)
+	}
+	trace := traces[0]
+	if trace.Action != tool || trace.Success {
+		t.Errorf("trace = %s (success %v), want the failed %s call", trace.Action, trace.Success, tool)
+	}
+	if trace.Metadata["error_type"] != ErrorTypePanic {
+		t.Errorf("error_type = %q, want %q", trace.Metadata["error_type"], ErrorTypePanic)
+	}
+	if !strings.Contains(trace.Metadata["stack"], "panickingTool") {
+		t.Errorf("stack = %q, want the panicking tool's frames", trace.Metadata["stack"])
+	}
+}
+
+func TestRunRecoversToolPanic(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_rates", map[string]interface{}{}),
+		textResponse("Sorry, I couldn't fetch exchange rates."),
+	)
+	registry := NewToolRegistry()
+	registry.Register(panickingTool("get_rates", false))
+	mem := &traceMemory{}
+	eng := NewEngine(client, registry, WithMemory(mem))
+
+	output, err := eng.Run(context.Background(), testInput("what's the rate?"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Type != OutputComplete || output.Text != "Sorry, I couldn't fetch exchange rates." {
+		t.Errorf("output = (%v, %q), want the run to finish after the panic", output.Type, output.Text)
+	}
+
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	result := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if result["is_error"] != true || !strings.Contains(text, "get_rates failed unexpectedly") {
+		t.Errorf("tool_result = %v, want the panic as an error", result)
+	}
+	if strings.Contains(text, "goroutine") {
+		t.Errorf("tool_result = %q, want the stack kept out of Claude's context", text)
+	}
+	assertPanicTrace(t, mem.traces, "get_rates")
+}
+
+func TestRunConfirmedActionRecoversToolPanic(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "10", "thought": "User asked to send $10"}),
+		textResponse("The transfer failed; nothing was sent."),
+	)
+	registry := NewToolRegistry()
+	registry.Register(panickingTool("send_money", true))
+	mem := &traceMemory{}
+	eng := NewEngine(client, registry, WithConversationStore(store.NewMemoryConversationStore()), WithMemory(mem))
+
+	output, err := eng.Run(context.Background(), testInput("send $10"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+	output, err = eng.RunConfirmedAction(context.Background(), testInput(""), output.PendingAction)
+	if err != nil {
+		t.Fatalf("RunConfirmedAction() error = %v", err)
+	}
+	if output.Type != OutputComplete || output.Text != "The transfer failed; nothing was sent." {
+		t.Errorf("output = (%v, %q), want the run to finish after the panic", output.Type, output.Text)
+	}
+	assertPanicTrace(t, mem.traces, "send_money")
+}
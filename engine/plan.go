@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// planToolName is the name of the synthetic tool used to capture a plan.
+// It is never registered and never executed.
+const planToolName = "submit_plan"
+
+// planPrompt is appended to the system prompt for the planning call.
+const planPrompt = `PLANNING:
+Before taking any action, outline the steps you intend to take to handle the user's request.
+Call the submit_plan tool with a short summary and an ordered list of steps.
+Each step names the tool you expect to use (or leave it empty for a plain reply) and what it is for.
+Do not call any other tools yet.`
+
+// Plan is the agent's intended sequence of steps for a request.
+// It is produced before any tools run and is informational only: the user
+// does not need to approve it, and the agent may deviate from it.
+type Plan struct {
+	// Summary is a one-line description of the overall approach.
+	Summary string `json:"summary"`
+
+	// Steps are the intended steps in execution order.
+	Steps []PlanStep `json:"steps"`
+}
+
+// PlanStep is a single step in a Plan.
+type PlanStep struct {
+	// Tool is the tool the agent expects to call. Empty for a text-only step.
+	Tool string `json:"tool,omitempty"`
+
+	// Description explains what the step does.
+	Description string `json:"description"`
+
+	// RequiresConfirmation is true when Tool is a write operation.
+	// Filled in from the registry, not by the model.
+	RequiresConfirmation bool `json:"requires_confirmation,omitempty"`
+}
+
+// WithPlanPreview enables the plan preview mode. When enabled, Run asks
+// Claude for a structured plan before the first turn and returns it via
+// Input.PlanCallback and Output.Plan before any tools are executed.
+func WithPlanPreview() Option {
+	return func(e *Engine) {
+		e.planPreview = true
+	}
+}
+
+// planToolParam returns the tool definition used to capture the plan.
+func planToolParam() anthropic.ToolUnionParam {
+	return anthropic.ToolUnionParam{
+		OfTool: &anthropic.ToolParam{
+			Name:        planToolName,
+			Description: anthropic.String("Submit the ordered plan of steps you intend to take for the user's request."),
+			InputSchema: anthropic.ToolInputSchemaParam{
+				Properties: map[string]interface{}{
+					"summary": map[string]interface{}{
+						"type":        "string",
+						"description": "One-line summary of the approach",
+					},
+					"steps": map[string]interface{}{
+						"type":        "array",
+						"description": "Ordered steps",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"tool": map[string]interface{}{
+									"type":        "string",
+									"description": "Tool to call for this step, if any",
+								},
+								"description": map[string]interface{}{
+									"type":        "string",
+									"description": "What this step does",
+								},
+							},
+							"required": []string{"description"},
+						},
+					},
+				},
+				Required: []string{"summary", "steps"},
+			},
+		},
+	}
+}
+
+// generatePlan makes a single planning call to Claude with the session's
+// current messages. The plan is not added to the session history.
+func (e *Engine) generatePlan(ctx context.Context, session *Session, cfg *loopConfig) (*Plan, core.TokenUsage, error) {
+	var usage core.TokenUsage
+
+	tools := make([]anthropic.ToolUnionParam, 0, len(cfg.apiTools)+1)
+	tools = append(tools, cfg.apiTools...)
+	tools = append(tools, planToolParam())
+
+	system := make([]PromptSegment, 0, len(cfg.system)+1)
+	system = append(system, cfg.system...)
+	system = append(system, PromptSegment{Text: planPrompt})
+
+	resp, err := e.client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:      anthropic.Model(cfg.model),
+		MaxTokens:  cfg.maxTokens,
+		Messages:   session.Messages(),
+		System:     systemBlocks(system),
+		Tools:      tools,
+		ToolChoice: anthropic.ToolChoiceParamOfTool(planToolName),
+	})
+	if err != nil {
+		return nil, usage, fmt.Errorf("plan request failed: %w", err)
+	}
+
+	usage.InputTokens = int(resp.Usage.InputTokens)
+	usage.OutputTokens = int(resp.Usage.OutputTokens)
+
+	for _, block := range resp.Content {
+		if block.Type != "tool_use" || block.Name != planToolName {
+			continue
+		}
+
+		var plan Plan
+		if err := json.Unmarshal(block.Input, &plan); err != nil {
+			return nil, usage, fmt.Errorf("invalid plan: %w", err)
+		}
+		for i := range plan.Steps {
+			if tool, ok := e.registry.Get(plan.Steps[i].Tool); ok {
+				plan.Steps[i].RequiresConfirmation = tool.RequiresConfirmation()
+			}
+		}
+		return &plan, usage, nil
+	}
+
+	return nil, usage, fmt.Errorf("no plan in response")
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestRunPlanPreview(t *testing.T) {
+	planResp := toolUseResponse("toolu_plan", planToolName, map[string]interface{}{
+		"summary": "Check balance then send money",
+		"steps": []map[string]interface{}{
+			{"tool": "get_balance", "description": "Check the wallet balance"},
+			{"tool": "send_money", "description": "Send $10 to Alice"},
+		},
+	})
+	fake, client := newFakeClaude(t,
+		planResp,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{}),
+		textResponse("Your balance is $100."),
+	)
+
+	var events []string
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		events = append(events, "execute:get_balance")
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+	registry.Register(testTool("send_money", true, nil))
+
+	eng := NewEngine(client, registry, WithPlanPreview())
+
+	input := testInput("check my balance and send alice $10")
+	var captured *Plan
+	input.PlanCallback = func(plan *Plan) {
+		events = append(events, "plan")
+		captured = plan
+	}
+
+	output, err := eng.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Type != OutputComplete {
+		t.Fatalf("Run() type = %v, want OutputComplete (error: %v)", output.Type, output.Error)
+	}
+
+	if len(events) != 2 || events[0] != "plan" || events[1] != "execute:get_balance" {
+		t.Fatalf("events = %v, want [plan execute:get_balance]", events)
+	}
+
+	if captured == nil {
+		t.Fatal("PlanCallback was not called")
+	}
+	if output.Plan != captured {
+		t.Error("Output.Plan does not match the plan passed to PlanCallback")
+	}
+	if captured.Summary != "Check balance then send money" {
+		t.Errorf("Plan.Summary = %q", captured.Summary)
+	}
+	if len(captured.Steps) != 2 {
+		t.Fatalf("len(Plan.Steps) = %d, want 2", len(captured.Steps))
+	}
+	if captured.Steps[0].Tool != "get_balance" || captured.Steps[0].RequiresConfirmation {
+		t.Errorf("Steps[0] = %+v, want read step get_balance", captured.Steps[0])
+	}
+	if captured.Steps[1].Tool != "send_money" || !captured.Steps[1].RequiresConfirmation {
+		t.Errorf("Steps[1] = %+v, want write step send_money", captured.Steps[1])
+	}
+
+	// The planning call forces submit_plan; the plan is not part of the loop history.
+	reqs := fake.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("API calls = %d, want 3", len(reqs))
+	}
+	choice, _ := reqs[0]["tool_choice"].(map[string]interface{})
+	if choice["name"] != planToolName {
+		t.Errorf("plan request tool_choice = %v, want %s", reqs[0]["tool_choice"], planToolName)
+	}
+	if msgs, _ := reqs[1]["messages"].([]interface{}); len(msgs) != 1 {
+		t.Errorf("first loop request has %d messages, want 1", len(msgs))
+	}
+
+	if output.TokensUsed.InputTokens != 30 {
+		t.Errorf("TokensUsed.InputTokens = %d, want 30", output.TokensUsed.InputTokens)
+	}
+}
+
+func TestRunPlanCountsTowardTokenBudget(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_plan", planToolName, map[string]interface{}{
+			"summary": "Check balance",
+			"steps":   []map[string]interface{}{{"tool": "get_balance", "description": "Check the wallet balance"}},
+		}),
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{}),
+		textResponse("unreachable"),
+	)
+
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, nil))
+	input := testInput("check my balance")
+	input.Context.Limits.MaxTotalTokens = 20 // The plan and first turn use 15 each
+
+	output, err := NewEngine(client, registry, WithPlanPreview()).Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Type != OutputError || !errors.Is(output.Error, ErrTokenBudgetExceeded) {
+		t.Fatalf("output = (%v, %v), want OutputError with ErrTokenBudgetExceeded", output.Type, output.Error)
+	}
+	if got := len(fake.Requests()); got != 2 {
+		t.Errorf("Claude called %d times, want the plan and one turn", got)
+	}
+	if output.TokensUsed.InputTokens != 20 {
+		t.Errorf("TokensUsed.InputTokens = %d, want 20", output.TokensUsed.InputTokens)
+	}
+}
+
+func TestRunWithoutPlanPreview(t *testing.T) {
+	fake, client := newFakeClaude(t, textResponse("Hello!"))
+
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, nil))
+	eng := NewEngine(client, registry)
+
+	input := testInput("hi")
+	input.PlanCallback = func(plan *Plan) {
+		t.Error("PlanCallback called without WithPlanPreview")
+	}
+
+	output, err := eng.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Plan != nil {
+		t.Errorf("Output.Plan = %+v, want nil", output.Plan)
+	}
+	if len(fake.Requests()) != 1 {
+		t.Errorf("API calls = %d, want 1", len(fake.Requests()))
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// PromptSegment is one block of a layered system prompt, such as a base
+// persona, a per-tenant policy or per-request context. Segments are sent to
+// Claude as separate system blocks, in order.
+type PromptSegment struct {
+	// Text is the segment's content. Empty segments are skipped.
+	Text string
+
+	// Cache marks the end of a cacheable prefix: Claude caches the system
+	// prompt up to and including this segment (prompt caching), so later
+	// requests with the same prefix cost less. Set it on stable segments
+	// only. The API allows at most four cache breakpoints per request.
+	Cache bool
+}
+
+// systemSegments returns the segments of input's system prompt: SystemPrompt,
+// if set, followed by SystemPromptSegments. Defaults to DefaultSystemPrompt.
+func systemSegments(input *Input) []PromptSegment {
+	var segments []PromptSegment
+	if input.SystemPrompt != "" {
+		segments = append(segments, PromptSegment{Text: input.SystemPrompt})
+	}
+	segments = append(segments, input.SystemPromptSegments...)
+	if len(segments) == 0 {
+		segments = []PromptSegment{{Text: DefaultSystemPrompt}}
+	}
+	return segments
+}
+
+// systemBlocks converts segments to system blocks for the API.
+func systemBlocks(segments []PromptSegment) []anthropic.TextBlockParam {
+	blocks := make([]anthropic.TextBlockParam, 0, len(segments))
+	for _, segment := range segments {
+		if segment.Text == "" {
+			continue
+		}
+		block := anthropic.TextBlockParam{Text: segment.Text}
+		if segment.Cache {
+			block.CacheControl = anthropic.NewCacheControlEphemeralParam()
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+// staticMemory retrieves the same enrichment for every message.
+type staticMemory struct {
+	enrichment string
+}
+
+func (m staticMemory) Retrieve(ctx context.Context, userID, userMessage string) (string, error) {
+	return m.enrichment, nil
+}
+
+func (m staticMemory) Record(ctx context.Context, userID string, interaction *memory.Interaction) error {
+	return nil
+}
+
+func TestRunSendsSystemPromptSegments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input func(*Input)
+		want  []map[string]interface{}
+	}{
+		{
+			name:  "default",
+			input: func(in *Input) {},
+			want: []map[string]interface{}{
+				{"type": "text", "text": DefaultSystemPrompt},
+				{"type": "text", "text": "=== MEMORIES ==="},
+			},
+		},
+		{
+			name:  "single prompt",
+			input: func(in *Input) { in.SystemPrompt = "You are Nim." },
+			want: []map[string]interface{}{
+				{"type": "text", "text": "You are Nim."},
+				{"type": "text", "text": "=== MEMORIES ==="},
+			},
+		},
+		{
+			name: "layered",
+			input: func(in *Input) {
+				in.SystemPrompt = "You are Nim."
+				in.SystemPromptSegments = []PromptSegment{
+					{Text: "Tenant acme: never discuss crypto.", Cache: true},
+					{Text: ""},
+					{Text: "Today is Friday."},
+				}
+			},
+			want: []map[string]interface{}{
+				{"type": "text", "text": "You are Nim."},
+				{"type": "text", "text": "Tenant acme: never discuss crypto.", "cache_control": map[string]interface{}{"type": "ephemeral"}},
+				{"type": "text", "text": "Today is Friday."},
+				{"type": "text", "text": "=== MEMORIES ==="},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeClaude(t, textResponse("Hi."))
+			input := testInput("hello")
+			tt.input(input)
+
+			eng := NewEngine(client, NewToolRegistry(), WithMemory(staticMemory{enrichment: "=== MEMORIES ==="}))
+			if _, err := eng.Run(context.Background(), input); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var got []map[string]interface{}
+			for _, block := range fake.Requests()[0]["system"].([]interface{}) {
+				got = append(got, block.(map[string]interface{}))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("system = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,20 @@
 package engine
 
 import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
 	"sync"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/becomeliminal/nim-go-sdk/core"
 )
 
+// ErrToolExists is returned when registering a tool whose name is already
+// taken. Use Override to replace a tool deliberately.
+var ErrToolExists = errors.New("tool already registered")
+
 // ToolRegistry manages available tools for an agent.
 type ToolRegistry struct {
 	mu    sync.RWMutex
@@ -21,18 +29,36 @@ func NewToolRegistry() *ToolRegistry {
 	}
 }
 
-// Register adds a tool to the registry.
-func (r *ToolRegistry) Register(tool core.Tool) {
+// Register adds a tool to the registry. If a tool with the same name is
+// already registered, it is kept and ErrToolExists is returned.
+func (r *ToolRegistry) Register(tool core.Tool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, ok := r.tools[tool.Name()]; ok {
+		return fmt.Errorf("%w: %s", ErrToolExists, tool.Name())
+	}
 	r.tools[tool.Name()] = tool
+	return nil
 }
 
-// RegisterAll adds multiple tools to the registry.
-func (r *ToolRegistry) RegisterAll(tools ...core.Tool) {
+// RegisterAll adds multiple tools to the registry. Tools whose names are
+// already taken are skipped, and their errors are returned joined.
+func (r *ToolRegistry) RegisterAll(tools ...core.Tool) error {
+	var errs []error
 	for _, tool := range tools {
-		r.Register(tool)
+		if err := r.Register(tool); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
+}
+
+// Override adds a tool to the registry, replacing any tool with the same
+// name, e.g. to swap a built-in Liminal tool for a custom implementation.
+func (r *ToolRegistry) Override(tool core.Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[tool.Name()] = tool
 }
 
 // Get retrieves a tool by name.
@@ -43,92 +69,113 @@ func (r *ToolRegistry) Get(name string) (core.Tool, bool) {
 	return tool, ok
 }
 
-// List returns all registered tool names.
+// List returns all registered tool names, sorted.
 func (r *ToolRegistry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	names := make([]string, 0, len(r.tools))
-	for name := range r.tools {
-		names = append(names, name)
-	}
-	return names
+	return core.SortedKeys(r.tools)
 }
 
-// ToAPITools converts registered tools to Claude API format.
+// ToAPITools converts registered tools to Claude API format, sorted by name
+// so every request lists them in the same order.
 func (r *ToolRegistry) ToAPITools() []anthropic.ToolUnionParam {
+	return r.ToAPIToolsFiltered(func(core.Tool) bool { return true })
+}
+
+// ToAPIToolsFiltered returns tools matching the filter, sorted by name.
+func (r *ToolRegistry) ToAPIToolsFiltered(filter func(core.Tool) bool) []anthropic.ToolUnionParam {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	tools := make([]anthropic.ToolUnionParam, 0, len(r.tools))
-	for _, tool := range r.tools {
-		schema := tool.Schema()
-		properties, _ := schema["properties"].(map[string]interface{})
-		required := []string{}
-		if reqField, ok := schema["required"].([]interface{}); ok {
-			for _, r := range reqField {
-				if str, ok := r.(string); ok {
-					required = append(required, str)
-				}
-			}
+	for _, name := range core.SortedKeys(r.tools) {
+		tool := r.tools[name]
+		if filter(tool) {
+			tools = append(tools, apiTool(tool))
 		}
-
-		tools = append(tools, anthropic.ToolUnionParam{
-			OfTool: &anthropic.ToolParam{
-				Name:        tool.Name(),
-				Description: anthropic.String(tool.Description()),
-				InputSchema: anthropic.ToolInputSchemaParam{
-					Properties: properties,
-					Required:   required,
-				},
-			},
-		})
 	}
 	return tools
 }
 
-// ToAPIToolsFiltered returns tools matching the filter.
-func (r *ToolRegistry) ToAPIToolsFiltered(filter func(core.Tool) bool) []anthropic.ToolUnionParam {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var tools []anthropic.ToolUnionParam
-	for _, tool := range r.tools {
-		if filter(tool) {
-			schema := tool.Schema()
-			properties, _ := schema["properties"].(map[string]interface{})
-			required := []string{}
-			if reqField, ok := schema["required"].([]interface{}); ok {
-				for _, r := range reqField {
-					if str, ok := r.(string); ok {
-						required = append(required, str)
-					}
-				}
+// apiTool converts a tool to Claude API format.
+func apiTool(tool core.Tool) anthropic.ToolUnionParam {
+	schema := tool.Schema()
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := []string{}
+	if reqField, ok := schema["required"].([]interface{}); ok {
+		for _, r := range reqField {
+			if str, ok := r.(string); ok {
+				required = append(required, str)
 			}
-
-			tools = append(tools, anthropic.ToolUnionParam{
-				OfTool: &anthropic.ToolParam{
-					Name:        tool.Name(),
-					Description: anthropic.String(tool.Description()),
-					InputSchema: anthropic.ToolInputSchemaParam{
-						Properties: properties,
-						Required:   required,
-					},
-				},
-			})
 		}
 	}
-	return tools
+
+	return anthropic.ToolUnionParam{
+		OfTool: &anthropic.ToolParam{
+			Name:        tool.Name(),
+			Description: anthropic.String(tool.Description()),
+			InputSchema: anthropic.ToolInputSchemaParam{
+				Properties: properties,
+				Required:   required,
+			},
+		},
+	}
 }
 
-// FilterByNames returns a filter that matches tools by name.
+// ReadOnlyTools is a FilterByNames selector matching every tool that does
+// not require confirmation.
+const ReadOnlyTools = "@read-only"
+
+// FilterByNames returns a filter that matches tools by selector. A selector
+// is one of:
+//
+//   - an exact tool name: "get_balance"
+//   - a glob: "liminal_*" (see path.Match)
+//   - ReadOnlyTools: tools where RequiresConfirmation is false
+//
+// A selector prefixed with "-" excludes matching tools, and exclusions win.
+// If only exclusions are given, all other tools match, so
+// FilterByNames("-send_money") is every tool except send_money.
 func FilterByNames(names ...string) func(core.Tool) bool {
-	nameSet := make(map[string]bool)
+	var include, exclude []string
 	for _, name := range names {
-		nameSet[name] = true
+		if strings.HasPrefix(name, "-") {
+			exclude = append(exclude, strings.TrimPrefix(name, "-"))
+		} else {
+			include = append(include, name)
+		}
 	}
 	return func(t core.Tool) bool {
-		return nameSet[t.Name()]
+		for _, selector := range exclude {
+			if matchesSelector(selector, t) {
+				return false
+			}
+		}
+		if len(include) == 0 {
+			return true
+		}
+		for _, selector := range include {
+			if matchesSelector(selector, t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchesSelector reports whether a single FilterByNames selector matches t.
+func matchesSelector(selector string, t core.Tool) bool {
+	if selector == ReadOnlyTools {
+		return !t.RequiresConfirmation()
+	}
+	if selector == t.Name() {
+		return true
+	}
+	if strings.ContainsAny(selector, "*?[") {
+		matched, _ := path.Match(selector, t.Name()) // Malformed patterns match nothing
+		return matched
 	}
+	return false
 }
 
 // Count returns the number of registered tools.
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestRegisterDuplicate(t *testing.T) {
+	original := testTool("send_money", true, nil)
+	duplicate := testTool("send_money", false, nil)
+
+	registry := NewToolRegistry()
+	if err := registry.Register(original); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register(duplicate); !errors.Is(err, ErrToolExists) {
+		t.Errorf("Register() duplicate error = %v, want ErrToolExists", err)
+	}
+	if got, _ := registry.Get("send_money"); got != original {
+		t.Error("duplicate Register() replaced the original tool")
+	}
+
+	registry.Override(duplicate)
+	if got, _ := registry.Get("send_money"); got != duplicate {
+		t.Error("Override() didn't replace the tool")
+	}
+}
+
+func TestRegisterAllDuplicate(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, nil))
+
+	err := registry.RegisterAll(
+		testTool("get_balance", false, nil),
+		testTool("get_transactions", false, nil),
+	)
+	if !errors.Is(err, ErrToolExists) {
+		t.Errorf("RegisterAll() error = %v, want ErrToolExists", err)
+	}
+	if registry.Count() != 2 {
+		t.Errorf("Count() = %d, want 2 (non-colliding tools still registered)", registry.Count())
+	}
+}
+
+func TestFilterByNames(t *testing.T) {
+	tools := []core.Tool{
+		testTool("get_balance", false, nil),
+		testTool("liminal_get_savings", false, nil),
+		testTool("liminal_deposit", true, nil),
+		testTool("send_money", true, nil),
+	}
+
+	tests := []struct {
+		selectors []string
+		want      []string
+	}{
+		{[]string{"get_balance", "send_money"}, []string{"get_balance", "send_money"}},
+		{[]string{"liminal_*"}, []string{"liminal_get_savings", "liminal_deposit"}},
+		{[]string{"-send_money"}, []string{"get_balance", "liminal_get_savings", "liminal_deposit"}},
+		{[]string{ReadOnlyTools}, []string{"get_balance", "liminal_get_savings"}},
+		{[]string{"liminal_*", "send_money", "-liminal_deposit"}, []string{"liminal_get_savings", "send_money"}},
+		{[]string{"-" + ReadOnlyTools}, []string{"liminal_deposit", "send_money"}},
+		{[]string{"[bad"}, nil},
+	}
+	for _, tt := range tests {
+		filter := FilterByNames(tt.selectors...)
+		var got []string
+		for _, tool := range tools {
+			if filter(tool) {
+				got = append(got, tool.Name())
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FilterByNames(%q) matched %v, want %v", tt.selectors, got, tt.want)
+		}
+	}
+}
+
+func TestToolOrderIsStable(t *testing.T) {
+	registry := NewToolRegistry()
+	for _, name := range []string{"send_money", "get_balance", "search_users", "deposit_savings", "get_transactions"} {
+		registry.Register(testTool(name, false, nil))
+	}
+	want := []string{"deposit_savings", "get_balance", "get_transactions", "search_users", "send_money"}
+
+	// Map iteration order varies between runs, so check repeatedly
+	for i := 0; i < 20; i++ {
+		if got := registry.List(); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("List() = %v, want %v", got, want)
+		}
+		var names []string
+		for _, tool := range registry.ToAPIToolsFiltered(FilterByNames("-search_users")) {
+			names = append(names, tool.OfTool.Name)
+		}
+		if got := strings.Join(names, ","); got != "deposit_savings,get_balance,get_transactions,send_money" {
+			t.Fatalf("ToAPIToolsFiltered() = %s, want sorted names", got)
+		}
+	}
+}
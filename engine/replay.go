@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ReplayStatus is the outcome of replaying one trace.
+type ReplayStatus string
+
+const (
+	// ReplayMatched means the tool produced the stored observation again.
+	ReplayMatched ReplayStatus = "matched"
+
+	// ReplayDiverged means the observation or success changed.
+	ReplayDiverged ReplayStatus = "diverged"
+
+	// ReplayMissingTool means the trace's tool is no longer registered.
+	ReplayMissingTool ReplayStatus = "missing_tool"
+
+	// ReplaySkipped means the trace wasn't replayed, e.g. because its tool
+	// is a write.
+	ReplaySkipped ReplayStatus = "skipped"
+)
+
+// ReplayResult compares one trace's stored observation with the current
+// tool's.
+type ReplayResult struct {
+	TraceID string
+	Tool    string
+	Status  ReplayStatus
+	Reason  string // Why the trace was skipped
+
+	StoredObservation string
+	StoredSuccess     bool
+	Observation       string // Empty unless the trace was replayed
+	Success           bool
+
+	// Diff is a line diff from the stored to the new observation, with
+	// "- " and "+ " prefixes, when the observation changed.
+	Diff string
+}
+
+// ReplayReport is the result of Engine.Replay, in trace order.
+type ReplayReport struct {
+	Results []ReplayResult
+}
+
+// Divergences returns the results whose tool diverged or no longer exists.
+func (r *ReplayReport) Divergences() []ReplayResult {
+	var diverged []ReplayResult
+	for _, result := range r.Results {
+		if result.Status == ReplayDiverged || result.Status == ReplayMissingTool {
+			diverged = append(diverged, result)
+		}
+	}
+	return diverged
+}
+
+// Replay re-executes recorded traces against the current tool
+// implementations, without calling Claude, and reports where the new
+// observations diverge from the stored ones. This turns captured traces
+// (e.g. from memory.SimpleManager.Export) into a regression corpus.
+//
+// Tools are looked up in tools, or the engine's registry if nil, so a replay
+// can run against mocked backends. Write tools are never executed: their
+// traces are reported as skipped, as are traces without a recorded
+// execution (e.g. blocked or pending confirmations).
+func (e *Engine) Replay(ctx context.Context, userID string, traces []*core.Trace, tools *ToolRegistry) (*ReplayReport, error) {
+	if tools == nil {
+		tools = e.registry
+	}
+
+	report := &ReplayReport{Results: make([]ReplayResult, 0, len(traces))}
+	for _, trace := range traces {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		result := ReplayResult{
+			TraceID:           trace.ID,
+			Tool:              trace.Action,
+			StoredObservation: trace.Observation,
+			StoredSuccess:     trace.Success,
+		}
+
+		tool, ok := tools.Get(trace.Action)
+		switch {
+		case !ok:
+			result.Status = ReplayMissingTool
+		case tool.RequiresConfirmation():
+			result.Status = ReplaySkipped
+			result.Reason = "write tools are not replayed"
+		case trace.Metadata["status"] == "pending_confirmation" || trace.Metadata["error"] == "confirmation_disabled" || trace.Metadata["error"] == "spending_limit":
+			result.Status = ReplaySkipped
+			result.Reason = "tool was not executed"
+		default:
+			toolResult, err := e.executeTool(ctx, tool, &core.ToolParams{
+				UserID:    userID,
+				Input:     trace.ActionInput,
+				RequestID: trace.SessionID,
+			})
+			result.Observation = formatObservation(tool, toolResult, err)
+			result.Success = err == nil && toolResult != nil && toolResult.Success
+
+			result.Status = ReplayMatched
+			if result.Observation != result.StoredObservation || result.Success != result.StoredSuccess {
+				result.Status = ReplayDiverged
+			}
+			if result.Observation != result.StoredObservation {
+				result.Diff = diffLines(result.StoredObservation, result.Observation)
+			}
+		}
+
+		if result.Status == ReplayDiverged || result.Status == ReplayMissingTool {
+			e.logger.InfoContext(ctx, "replay diverged", "user_id", userID, "trace_id", trace.ID, "tool", trace.Action, "status", string(result.Status))
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// diffLines returns a minimal line diff from a to b: common lines prefixed
+// with "  ", removed lines with "- " and added lines with "+ ".
+func diffLines(a, b string) string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			fmt.Fprintf(&sb, "  %s\n", x[i])
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] > lcs[i+1][j]):
+			fmt.Fprintf(&sb, "+ %s\n", y[j])
+			j++
+		default:
+			fmt.Fprintf(&sb, "- %s\n", x[i])
+			i++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestReplay(t *testing.T) {
+	_, client := newFakeClaude(t) // Replay never calls Claude
+
+	var sent bool
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: "Balance: $100"}, nil
+	}))
+	registry.Register(testTool("get_profile", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		var input struct {
+			Tag string `json:"tag"`
+		}
+		json.Unmarshal(params.Input, &input)
+		return &core.ToolResult{Success: true, Data: "Name: Alice\nTag: " + input.Tag}, nil
+	}))
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		sent = true
+		return &core.ToolResult{Success: true}, nil
+	}))
+	eng := NewEngine(client, registry)
+
+	traces := []*core.Trace{
+		{ID: "t1", Action: "get_balance", ActionInput: json.RawMessage(`{}`), Observation: "Balance: $100", Success: true},
+		{ID: "t2", Action: "get_profile", ActionInput: json.RawMessage(`{"tag":"@alice"}`), Observation: "Name: Alice\nTag: alice", Success: true},
+		{ID: "t3", Action: "send_money", ActionInput: json.RawMessage(`{"amount":"10"}`), Observation: "Success: sent", Success: true},
+		{ID: "t4", Action: "get_savings", ActionInput: json.RawMessage(`{}`), Observation: "$50 saved", Success: true},
+	}
+
+	report, err := eng.Replay(context.Background(), "user-1", traces, nil)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if sent {
+		t.Error("Replay() executed a write tool")
+	}
+
+	want := []ReplayStatus{ReplayMatched, ReplayDiverged, ReplaySkipped, ReplayMissingTool}
+	if len(report.Results) != len(want) {
+		t.Fatalf("Replay() returned %d results, want %d", len(report.Results), len(want))
+	}
+	for i, status := range want {
+		if got := report.Results[i].Status; got != status {
+			t.Errorf("Results[%d].Status = %q, want %q", i, got, status)
+		}
+	}
+
+	if wantDiff := "  Name: Alice\n- Tag: alice\n+ Tag: @alice\n"; report.Results[1].Diff != wantDiff {
+		t.Errorf("Results[1].Diff = %q, want %q", report.Results[1].Diff, wantDiff)
+	}
+	if got := len(report.Divergences()); got != 2 {
+		t.Errorf("Divergences() returned %d results, want 2", got)
+	}
+}
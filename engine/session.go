@@ -80,6 +80,19 @@ func (s *Session) Messages() []anthropic.MessageParam {
 	return s.messages
 }
 
+// MessagesSince returns the messages after the first n as core.Messages,
+// e.g. the messages added during a run for persistence.
+func (s *Session) MessagesSince(n int) []core.Message {
+	if n >= len(s.messages) {
+		return nil
+	}
+	messages := make([]core.Message, 0, len(s.messages)-n)
+	for _, msg := range s.messages[n:] {
+		messages = append(messages, convertAPIMessageToCore(msg))
+	}
+	return messages
+}
+
 // IncrementTurnCount increments and returns the turn count.
 func (s *Session) IncrementTurnCount() int {
 	s.TurnCount++
@@ -137,10 +150,10 @@ func convertCoreBlocksToAPI(blocks []core.ContentBlock) []anthropic.ContentBlock
 		case core.ToolResultBlockType:
 			if block.ToolResult != nil {
 				content := block.ToolResult.Content
-				if content == "" {
+				if content == "" && len(block.ToolResult.Blocks) == 0 {
 					content = "No output"
 				}
-				result = append(result, anthropic.NewToolResultBlock(block.ToolResult.ToolUseID, content, block.ToolResult.IsError))
+				result = append(result, toolResultBlock(block.ToolResult.ToolUseID, content, block.ToolResult.Blocks, block.ToolResult.IsError))
 			}
 		}
 	}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ToolInputDelta is a fragment of a tool's input as Claude streams it, so
+// clients can show long arguments (e.g. contract calldata) as they arrive.
+type ToolInputDelta struct {
+	// BlockID is the tool_use block ID.
+	BlockID string
+
+	// Tool is the tool name.
+	Tool string
+
+	// PartialJSON is the next fragment of the input JSON. Concatenating the
+	// fragments for a block yields its full input. Empty when Done.
+	PartialJSON string
+
+	// Done reports that the block's input is complete.
+	Done bool
+}
+
+// toolInputStream accumulates streamed tool_use input by content block
+// index, independently of message.Accumulate, so the final input is correct
+// even if deltas for different blocks interleave.
+type toolInputStream struct {
+	blocks   map[int64]*streamedToolInput
+	callback func(ToolInputDelta)
+}
+
+type streamedToolInput struct {
+	id, name string
+	input    strings.Builder
+	done     bool // content_block_stop received
+}
+
+func newToolInputStream(callback func(ToolInputDelta)) *toolInputStream {
+	return &toolInputStream{blocks: make(map[int64]*streamedToolInput), callback: callback}
+}
+
+// handle processes a stream event.
+func (s *toolInputStream) handle(event anthropic.MessageStreamEventUnion) {
+	switch evt := event.AsAny().(type) {
+	case anthropic.ContentBlockStartEvent:
+		if evt.ContentBlock.Type == "tool_use" {
+			s.blocks[evt.Index] = &streamedToolInput{id: evt.ContentBlock.ID, name: evt.ContentBlock.Name}
+		}
+	case anthropic.ContentBlockDeltaEvent:
+		delta, ok := evt.Delta.AsAny().(anthropic.InputJSONDelta)
+		block := s.blocks[evt.Index]
+		if !ok || block == nil || delta.PartialJSON == "" {
+			return
+		}
+		block.input.WriteString(delta.PartialJSON)
+		if s.callback != nil {
+			s.callback(ToolInputDelta{BlockID: block.id, Tool: block.name, PartialJSON: delta.PartialJSON})
+		}
+	case anthropic.ContentBlockStopEvent:
+		block := s.blocks[evt.Index]
+		if block == nil {
+			return
+		}
+		block.done = true
+		if s.callback != nil {
+			s.callback(ToolInputDelta{BlockID: block.id, Tool: block.name, Done: true})
+		}
+	}
+}
+
+// apply replaces the input of each tool_use block in message with the
+// streamed input, matching blocks by ID. A block with no input deltas gets
+// "{}", as in a non-streaming response. It fails if any streamed tool_use
+// block is unfinished, has invalid input, or is missing from message.
+func (s *toolInputStream) apply(message *anthropic.Message) error {
+	indexes := make([]int64, 0, len(s.blocks))
+	for index := range s.blocks {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	for _, index := range indexes {
+		block := s.blocks[index]
+		if !block.done {
+			return fmt.Errorf("tool %s (%s): input stream ended before the block finished", block.name, block.id)
+		}
+
+		input := block.input.String()
+		if input == "" {
+			input = "{}"
+		}
+		if !json.Valid([]byte(input)) {
+			return fmt.Errorf("tool %s (%s): streamed input is not valid JSON", block.name, block.id)
+		}
+
+		cb := findToolUse(message, block.id)
+		if cb == nil {
+			return fmt.Errorf("tool %s (%s): block missing from accumulated message", block.name, block.id)
+		}
+
+		// Re-decode the block so both Input and the raw JSON used by
+		// ToParam reflect the streamed input.
+		raw, err := json.Marshal(map[string]interface{}{
+			"type":  "tool_use",
+			"id":    block.id,
+			"name":  block.name,
+			"input": json.RawMessage(input),
+		})
+		if err != nil {
+			return fmt.Errorf("tool %s (%s): %w", block.name, block.id, err)
+		}
+		if err := cb.UnmarshalJSON(raw); err != nil {
+			return fmt.Errorf("tool %s (%s): %w", block.name, block.id, err)
+		}
+	}
+	return nil
+}
+
+// findToolUse returns the tool_use block with the given ID, or nil.
+func findToolUse(message *anthropic.Message, id string) *anthropic.ContentBlockUnion {
+	for i := range message.Content {
+		if cb := &message.Content[i]; cb.Type == "tool_use" && cb.ID == id {
+			return cb
+		}
+	}
+	return nil
+}
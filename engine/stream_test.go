@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// toolUseStream is the streamed equivalent of toolUseResponse, with the
+// input split across input_json_delta events.
+func toolUseStream(id, name string, fragments ...string) map[string]interface{} {
+	events := []map[string]interface{}{
+		{"type": "message_start", "message": map[string]interface{}{
+			"id": "msg_" + id, "type": "message", "role": "assistant", "model": "claude-test",
+			"content": []interface{}{}, "usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 1},
+		}},
+		{"type": "content_block_start", "index": 0, "content_block": map[string]interface{}{"type": "text", "text": ""}},
+		{"type": "content_block_delta", "index": 0, "delta": map[string]interface{}{"type": "text_delta", "text": "Preparing."}},
+		{"type": "content_block_stop", "index": 0},
+		{"type": "content_block_start", "index": 1, "content_block": map[string]interface{}{
+			"type": "tool_use", "id": id, "name": name, "input": map[string]interface{}{},
+		}},
+	}
+	for _, fragment := range fragments {
+		events = append(events, map[string]interface{}{
+			"type": "content_block_delta", "index": 1,
+			"delta": map[string]interface{}{"type": "input_json_delta", "partial_json": fragment},
+		})
+	}
+	events = append(events,
+		map[string]interface{}{"type": "content_block_stop", "index": 1},
+		map[string]interface{}{"type": "message_delta", "delta": map[string]interface{}{"stop_reason": "tool_use"}, "usage": map[string]interface{}{"output_tokens": 5}},
+		map[string]interface{}{"type": "message_stop"},
+	)
+	return streamResponse(events...)
+}
+
+func textStream(text string) map[string]interface{} {
+	return streamResponse(
+		map[string]interface{}{"type": "message_start", "message": map[string]interface{}{
+			"id": "msg_text", "type": "message", "role": "assistant", "model": "claude-test",
+			"content": []interface{}{}, "usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 1},
+		}},
+		map[string]interface{}{"type": "content_block_start", "index": 0, "content_block": map[string]interface{}{"type": "text", "text": ""}},
+		map[string]interface{}{"type": "content_block_delta", "index": 0, "delta": map[string]interface{}{"type": "text_delta", "text": text}},
+		map[string]interface{}{"type": "content_block_stop", "index": 0},
+		map[string]interface{}{"type": "message_delta", "delta": map[string]interface{}{"stop_reason": "end_turn"}, "usage": map[string]interface{}{"output_tokens": 5}},
+		map[string]interface{}{"type": "message_stop"},
+	)
+}
+
+func TestStreamingToolInputMatchesNonStreaming(t *testing.T) {
+	input := map[string]interface{}{"to": "0xabc", "calldata": "0xa9059cbb" + strings.Repeat("0", 64), "thought": "User asked"}
+	full, _ := json.Marshal(input)
+	fragments := []string{string(full[:7]), string(full[7:40]), string(full[40:])}
+
+	run := func(stream bool, responses ...map[string]interface{}) (json.RawMessage, []ToolInputDelta, *fakeClaude) {
+		fake, client := newFakeClaude(t, responses...)
+		var got json.RawMessage
+		registry := NewToolRegistry()
+		registry.Register(testTool("execute_contract_call", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			got = params.Input
+			return &core.ToolResult{Success: true}, nil
+		}))
+		var deltas []ToolInputDelta
+		in := testInput("call the contract")
+		if stream {
+			in.StreamCallback = func(string, bool) {}
+			in.ToolInputCallback = func(d ToolInputDelta) { deltas = append(deltas, d) }
+		}
+		if _, err := NewEngine(client, registry).Run(context.Background(), in); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return got, deltas, fake
+	}
+
+	want, _, _ := run(false, toolUseResponse("toolu_1", "execute_contract_call", input), textResponse("Done."))
+	got, deltas, fake := run(true, toolUseStream("toolu_1", "execute_contract_call", fragments...), textStream("Done."))
+
+	if string(got) != string(want) {
+		t.Errorf("streamed tool input = %s, want %s", got, want)
+	}
+
+	var partial strings.Builder
+	for _, d := range deltas[:len(deltas)-1] {
+		if d.BlockID != "toolu_1" || d.Tool != "execute_contract_call" || d.Done {
+			t.Errorf("delta = %+v, want a toolu_1 fragment", d)
+		}
+		partial.WriteString(d.PartialJSON)
+	}
+	if partial.String() != string(full) {
+		t.Errorf("concatenated deltas = %s, want %s", partial.String(), full)
+	}
+	if last := deltas[len(deltas)-1]; !last.Done {
+		t.Errorf("last delta = %+v, want Done", last)
+	}
+
+	// The history sent back to Claude carries the full input too.
+	reqs := fake.Requests()
+	messages := reqs[len(reqs)-1]["messages"].([]interface{})
+	blocks := messages[1].(map[string]interface{})["content"].([]interface{})
+	toolUse := blocks[1].(map[string]interface{})
+	if history, _ := json.Marshal(toolUse["input"]); string(history) != string(full) {
+		t.Errorf("history tool_use input = %s, want %s", history, full)
+	}
+}
+
+func TestStreamingIncompleteToolUseFailsRun(t *testing.T) {
+	_, client := newFakeClaude(t, streamResponse(
+		map[string]interface{}{"type": "message_start", "message": map[string]interface{}{
+			"id": "msg_bad", "type": "message", "role": "assistant", "model": "claude-test",
+			"content": []interface{}{}, "usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 1},
+		}},
+		// A delta with no content block fails to accumulate.
+		map[string]interface{}{"type": "content_block_delta", "index": 0, "delta": map[string]interface{}{"type": "text_delta", "text": "lost"}},
+		map[string]interface{}{"type": "content_block_start", "index": 0, "content_block": map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "send_money", "input": map[string]interface{}{},
+		}},
+		map[string]interface{}{"type": "content_block_delta", "index": 0, "delta": map[string]interface{}{"type": "input_json_delta", "partial_json": `{"amount": "1`}},
+		// No content_block_stop: the tool_use block is truncated.
+		map[string]interface{}{"type": "message_delta", "delta": map[string]interface{}{"stop_reason": "tool_use"}, "usage": map[string]interface{}{"output_tokens": 5}},
+		map[string]interface{}{"type": "message_stop"},
+	))
+
+	executed := false
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executed = true
+		return &core.ToolResult{Success: true}, nil
+	}))
+	sink := &recordingSink{}
+	in := testInput("send $10 to bob")
+	in.StreamCallback = func(string, bool) {}
+
+	output, err := NewEngine(client, registry, WithEventSink(sink)).Run(context.Background(), in)
+	if err == nil || !strings.Contains(err.Error(), "send_money (toolu_1)") {
+		t.Fatalf("Run() error = %v, want an incomplete tool_use error", err)
+	}
+	if output.Type != OutputError {
+		t.Errorf("output type = %v, want OutputError", output.Type)
+	}
+	if executed {
+		t.Error("truncated tool_use was executed")
+	}
+	if len(sink.events) == 0 || sink.events[0].Type != EventStreamError || sink.events[0].Err == nil {
+		t.Errorf("events = %+v, want a stream_error first", sink.events)
+	}
+}
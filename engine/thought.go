@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// DefaultMinThoughtLength is the minimum thought length, in characters,
+// suggested for MinThoughtLength.
+const DefaultMinThoughtLength = 20
+
+// ThoughtValidator checks the thought of a call to tool that requires one,
+// after the engine has checked it isn't empty. A non-nil error rejects the
+// call; its message tells Claude what to fix.
+type ThoughtValidator func(thought, tool string) error
+
+// WithThoughtValidator runs v on the thought of every call that requires
+// one (writes, and read tools that opt in), e.g. to require a minimum
+// length, verification language, or to reject placeholders like "ok".
+// Rejected calls get a corrective tool_result, like a missing thought.
+func WithThoughtValidator(v ThoughtValidator) Option {
+	return func(e *Engine) {
+		e.thoughtValidator = v
+	}
+}
+
+// MinThoughtLength returns a ThoughtValidator that rejects thoughts shorter
+// than n characters, ignoring surrounding whitespace. Zero or negative n
+// means DefaultMinThoughtLength.
+func MinThoughtLength(n int) ThoughtValidator {
+	if n <= 0 {
+		n = DefaultMinThoughtLength
+	}
+	return func(thought, tool string) error {
+		if utf8.RuneCountInString(strings.TrimSpace(thought)) < n {
+			return fmt.Errorf("thought %q is too short to show your reasoning (at least %d characters)", thought, n)
+		}
+		return nil
+	}
+}
+
+// thoughtRequired reports whether calls to tool must include a thought.
+func thoughtRequired(tool core.Tool) bool {
+	if tool.RequiresConfirmation() {
+		return true
+	}
+	tr, ok := tool.(core.ThoughtRequirer)
+	return ok && tr.RequiresThought()
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestThoughtValidatorRejectsPlaceholder(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "30", "thought": "ok"}),
+		toolUseResponse("toolu_2", "send_money", map[string]interface{}{"amount": "30", "thought": "Balance is $120, enough for the $30 the user asked to send"}),
+	)
+	registry := NewToolRegistry()
+	registry.Register(testTool("send_money", true, nil))
+	eng := NewEngine(client, registry, WithThoughtValidator(MinThoughtLength(DefaultMinThoughtLength)))
+
+	output, err := eng.Run(context.Background(), testInput("send $30"))
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded after a better thought", output.Type, err)
+	}
+	if output.PendingAction.Thought == "ok" {
+		t.Error("pending action kept the placeholder thought")
+	}
+
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	result := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if result["is_error"] != true || !strings.Contains(text, "too short") || !strings.Contains(text, "call send_money again") {
+		t.Errorf("tool_result = %q (is_error %v), want a corrective error", text, result["is_error"])
+	}
+}
+
+func TestThoughtValidatorSkipsOptionalThoughts(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{"thought": "ok"}),
+		textResponse("You have $120."),
+	)
+	registry := NewToolRegistry()
+	executed := false
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executed = true
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "120"}}, nil
+	}))
+	eng := NewEngine(client, registry, WithThoughtValidator(MinThoughtLength(0)))
+
+	if _, err := eng.Run(context.Background(), testInput("balance?")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !executed {
+		t.Error("read tool without a required thought was rejected")
+	}
+}
+
+func TestMinThoughtLength(t *testing.T) {
+	validate := MinThoughtLength(10)
+	if err := validate("  sending  ", "send_money"); err == nil {
+		t.Error("MinThoughtLength(10) accepted a 7-character thought")
+	}
+	if err := validate("User asked to pay rent", "send_money"); err != nil {
+		t.Errorf("MinThoughtLength(10) error = %v", err)
+	}
+}
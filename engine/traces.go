@@ -0,0 +1,55 @@
+package engine
+
+import "github.com/becomeliminal/nim-go-sdk/core"
+
+// TraceStep is a ReAct trace prepared for display, so a UI can show how the
+// agent reached its answer. Unlike core.Trace it leaves out the tool input
+// and internal metadata.
+type TraceStep struct {
+	// Thought is the agent's reasoning for the call, if it gave one.
+	Thought string `json:"thought,omitempty"`
+
+	// Action is the tool called.
+	Action string `json:"action"`
+
+	// Observation is what the agent learned from the call.
+	Observation string `json:"observation"`
+
+	// Success reports whether the call succeeded.
+	Success bool `json:"success"`
+
+	// Status is "pending_confirmation" for a write awaiting the user's
+	// confirmation. The write's trace from the confirmed run replaces it.
+	Status string `json:"status,omitempty"`
+}
+
+// TraceRedactor sanitizes a step before it's returned in Output.Traces,
+// e.g. masking account numbers in observations.
+type TraceRedactor func(step TraceStep) TraceStep
+
+// WithTraceRedactor sets a TraceRedactor applied to every step returned in
+// Output.Traces. By default steps are returned as recorded.
+func WithTraceRedactor(r TraceRedactor) Option {
+	return func(e *Engine) {
+		e.traceRedactor = r
+	}
+}
+
+// traceSteps converts a run's traces for Output.Traces.
+func (e *Engine) traceSteps(traces []*core.Trace) []TraceStep {
+	steps := make([]TraceStep, 0, len(traces))
+	for _, trace := range traces {
+		step := TraceStep{
+			Thought:     trace.Thought,
+			Action:      trace.Action,
+			Observation: trace.Observation,
+			Success:     trace.Success,
+			Status:      trace.Metadata["status"],
+		}
+		if e.traceRedactor != nil {
+			step = e.traceRedactor(step)
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
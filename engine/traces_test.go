@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+func TestIncludeTraces(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{"thought": "Check funds first"}),
+		toolUseResponse("toolu_2", "send_money", map[string]interface{}{"amount": "10", "thought": "User asked to send $10"}),
+		textResponse("Sent $10."),
+	)
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"account": "GB33BUKB20201555555555", "balance": "100"}}, nil
+	}))
+	registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"tx": "tx-1"}}, nil
+	}))
+	mask := func(step TraceStep) TraceStep {
+		step.Observation = strings.ReplaceAll(step.Observation, "GB33BUKB20201555555555", "GB33…5555")
+		return step
+	}
+	eng := NewEngine(client, registry, WithConversationStore(store.NewMemoryConversationStore()), WithTraceRedactor(mask))
+
+	input := testInput("send $10")
+	input.IncludeTraces = true
+	output, err := eng.Run(context.Background(), input)
+	if err != nil || output.Type != OutputConfirmationNeeded {
+		t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+	}
+	if len(output.Traces) != 2 {
+		t.Fatalf("Traces = %+v, want the read and the pending write", output.Traces)
+	}
+	read, write := output.Traces[0], output.Traces[1]
+	if read.Action != "get_balance" || read.Thought != "Check funds first" || !read.Success {
+		t.Errorf("Traces[0] = %+v, want the successful get_balance call", read)
+	}
+	if strings.Contains(read.Observation, "GB33BUKB20201555555555") || !strings.Contains(read.Observation, "GB33…5555") {
+		t.Errorf("Traces[0].Observation = %q, want the account number masked", read.Observation)
+	}
+	if write.Action != "send_money" || write.Status != "pending_confirmation" || write.Success {
+		t.Errorf("Traces[1] = %+v, want send_money pending confirmation", write)
+	}
+
+	confirm := testInput("")
+	confirm.IncludeTraces = true
+	output, err = eng.RunConfirmedAction(context.Background(), confirm, output.PendingAction)
+	if err != nil || output.Type != OutputComplete {
+		t.Fatalf("RunConfirmedAction() = (%v, %v), want OutputComplete", output.Type, err)
+	}
+	if len(output.Traces) != 1 || output.Traces[0].Action != "send_money" || !output.Traces[0].Success || output.Traces[0].Status != "" {
+		t.Errorf("confirmed Traces = %+v, want the executed send_money", output.Traces)
+	}
+}
+
+func TestTracesOmittedByDefault(t *testing.T) {
+	_, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_balance", map[string]interface{}{}),
+		textResponse("You have $100."),
+	)
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_balance", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true}, nil
+	}))
+	eng := NewEngine(client, registry)
+
+	output, err := eng.Run(context.Background(), testInput("balance?"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Traces != nil {
+		t.Errorf("Traces = %+v, want none without IncludeTraces", output.Traces)
+	}
+}
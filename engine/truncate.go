@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// WithMaxToolResultBytes limits the size of tool results sent back to Claude.
+// Larger results are truncated to at most n bytes of valid JSON: arrays keep
+// their first items and end with an "N more items omitted" note. The trace
+// metadata records truncated=true. Zero or negative means no limit.
+func WithMaxToolResultBytes(n int) Option {
+	return func(e *Engine) {
+		e.maxToolResultBytes = n
+	}
+}
+
+// toolResultContent formats a successful tool result for Claude, applying
+// the configured size limit. Tools that implement core.ClaudeResultFormatter
+// format their own results; others are marshaled as JSON.
+func (e *Engine) toolResultContent(ctx context.Context, tool core.Tool, result *core.ToolResult, trace *core.Trace) string {
+	var data []byte
+	if formatter, ok := tool.(core.ClaudeResultFormatter); ok && result != nil {
+		formatted, err := formatter.FormatForModel(result)
+		if err != nil {
+			e.logger.WarnContext(ctx, "failed to format tool result for Claude", "tool", tool.Name(), "trace_id", trace.ID, "error", err)
+		} else {
+			data = []byte(formatted)
+		}
+	}
+	if data == nil {
+		data, _ = json.Marshal(resultData(result))
+	}
+	if e.maxToolResultBytes <= 0 || len(data) <= e.maxToolResultBytes {
+		return string(data)
+	}
+
+	truncated := truncateJSON(data, e.maxToolResultBytes)
+	trace.Metadata["truncated"] = "true"
+	trace.Metadata["original_bytes"] = fmt.Sprintf("%d", len(data))
+	return string(truncated)
+}
+
+// resultData returns the result's data, or nil for a nil result.
+func resultData(result *core.ToolResult) interface{} {
+	if result == nil {
+		return nil
+	}
+	return result.Data
+}
+
+// truncateJSON shrinks data to at most limit bytes of valid JSON. Arrays are
+// cut to their first items (the same count for every array, halved until the
+// result fits) with a trailing note of how many were omitted. If that isn't
+// enough, e.g. because of one huge string, a preview of the raw JSON is
+// returned instead.
+func truncateJSON(data []byte, limit int) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err == nil {
+		for keep := longestArray(v) / 2; keep >= 0; keep /= 2 {
+			out, err := json.Marshal(trimArrays(v, keep))
+			if err == nil && len(out) <= limit {
+				return out
+			}
+			if keep == 0 {
+				break
+			}
+		}
+	}
+
+	// Fall back to a string preview, leaving room for the wrapper
+	preview := map[string]interface{}{
+		"truncated":      true,
+		"original_bytes": len(data),
+		"preview":        "",
+	}
+	overhead, _ := json.Marshal(preview)
+	room := limit - len(overhead)
+	for room > 0 {
+		preview["preview"] = string(data[:min(room, len(data))])
+		out, _ := json.Marshal(preview)
+		if len(out) <= limit {
+			return out
+		}
+		// Escaping made it longer; shrink by the excess and retry
+		room -= len(out) - limit
+	}
+	out, _ := json.Marshal(map[string]bool{"truncated": true})
+	return out
+}
+
+// longestArray returns the length of the longest array anywhere in v.
+func longestArray(v interface{}) int {
+	longest := 0
+	switch t := v.(type) {
+	case []interface{}:
+		longest = len(t)
+		for _, item := range t {
+			longest = max(longest, longestArray(item))
+		}
+	case map[string]interface{}:
+		for _, item := range t {
+			longest = max(longest, longestArray(item))
+		}
+	}
+	return longest
+}
+
+// trimArrays returns a copy of v with every array cut to its first keep
+// items, followed by a note of how many were omitted.
+func trimArrays(v interface{}, keep int) interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		n := min(keep, len(t))
+		out := make([]interface{}, 0, n+1)
+		for _, item := range t[:n] {
+			out = append(out, trimArrays(item, keep))
+		}
+		if omitted := len(t) - n; omitted > 0 {
+			out = append(out, fmt.Sprintf("... %d more items omitted", omitted))
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			out[k] = trimArrays(item, keep)
+		}
+		return out
+	default:
+		return v
+	}
+}
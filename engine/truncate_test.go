@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestMaxToolResultBytes(t *testing.T) {
+	fake, client := newFakeClaude(t,
+		toolUseResponse("toolu_1", "get_transactions", map[string]interface{}{}),
+		textResponse("Here are your transactions."),
+	)
+
+	txs := make([]interface{}, 100)
+	for i := range txs {
+		txs[i] = map[string]interface{}{"id": fmt.Sprintf("tx-%03d", i), "amount": "12.50", "note": "coffee"}
+	}
+	registry := NewToolRegistry()
+	registry.Register(testTool("get_transactions", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"transactions": txs, "count": 100}}, nil
+	}))
+
+	const limit = 1000
+	output, err := NewEngine(client, registry, WithMaxToolResultBytes(limit)).Run(context.Background(), testInput("show transactions"))
+	if err != nil || output.Type != OutputComplete {
+		t.Fatalf("Run() = (%v, %v), want OutputComplete", output.Type, err)
+	}
+
+	// messages: user, assistant tool_use, user tool_result
+	messages := fake.Requests()[1]["messages"].([]interface{})
+	block := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	content := block["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+
+	if len(content) > limit {
+		t.Errorf("tool result is %d bytes, want <= %d", len(content), limit)
+	}
+	var got struct {
+		Transactions []interface{} `json:"transactions"`
+		Count        int           `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(content), &got); err != nil {
+		t.Fatalf("truncated tool result is not valid JSON: %v\n%s", err, content)
+	}
+	if got.Count != 100 || len(got.Transactions) < 2 {
+		t.Fatalf("truncated result = %s, want count and leading transactions kept", content)
+	}
+	note, _ := got.Transactions[len(got.Transactions)-1].(string)
+	if want := fmt.Sprintf("... %d more items omitted", 100-(len(got.Transactions)-1)); note != want {
+		t.Errorf("last item = %q, want %q", note, want)
+	}
+}
+
+func TestTruncateJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"nested arrays", `{"a":[[1,2,3,4,5,6,7,8],[1,2,3,4,5,6,7,8]],"b":[1,2,3,4,5,6,7,8,9,10]}`},
+		{"long string", `{"memo":"` + strings.Repeat("x\"y", 200) + `"}`},
+		{"invalid JSON", strings.Repeat("{", 300)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := truncateJSON([]byte(tt.data), 60)
+			if len(out) > 60 {
+				t.Errorf("truncateJSON() = %d bytes, want <= 60", len(out))
+			}
+			if !json.Valid(out) {
+				t.Errorf("truncateJSON() = %s, not valid JSON", out)
+			}
+		})
+	}
+}
+
+// compactTool sends Claude a summary of its result instead of the data.
+type compactTool struct {
+	core.Tool
+	err error
+}
+
+func (c compactTool) FormatForModel(result *core.ToolResult) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	txs, _ := result.Data.(map[string]interface{})["transactions"].([]interface{})
+	return fmt.Sprintf("%d transactions, all coffee", len(txs)), nil
+}
+
+func TestClaudeResultFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"formatted", nil, "2 transactions, all coffee"},
+		{"falls back to JSON", errors.New("boom"), `{"transactions":[{"id":"tx-1"},{"id":"tx-2"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeClaude(t,
+				toolUseResponse("toolu_1", "get_transactions", map[string]interface{}{}),
+				textResponse("You bought coffee twice."),
+			)
+			data := map[string]interface{}{"transactions": []interface{}{
+				map[string]interface{}{"id": "tx-1"},
+				map[string]interface{}{"id": "tx-2"},
+			}}
+			registry := NewToolRegistry()
+			registry.Register(compactTool{
+				Tool: testTool("get_transactions", false, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					return &core.ToolResult{Success: true, Data: data}, nil
+				}),
+				err: tt.err,
+			})
+
+			output, err := NewEngine(client, registry).Run(context.Background(), testInput("show transactions"))
+			if err != nil || output.Type != OutputComplete {
+				t.Fatalf("Run() = (%v, %v), want OutputComplete", output.Type, err)
+			}
+
+			messages := fake.Requests()[1]["messages"].([]interface{})
+			block := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+			content := block["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+			if content != tt.want {
+				t.Errorf("tool result sent to Claude = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
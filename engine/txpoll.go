@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// TransactionPoller follows up a confirmed write whose result only says a
+// transaction was submitted, waiting for it to settle.
+// tools.TxPoller implements it for Liminal and on-chain transactions.
+type TransactionPoller interface {
+	// PollTransaction waits for the transaction referenced by a successful
+	// write result to settle, until ctx is done. It returns false if the
+	// result doesn't reference a transaction.
+	PollTransaction(ctx context.Context, userID, tool string, result *core.ToolResult) (core.TxStatus, bool, error)
+}
+
+// WithTransactionPolling polls the transaction behind each successfully
+// executed confirmed write for up to timeout, and includes the outcome in
+// what Claude sees. A failed or reverted transaction turns the write into a
+// failure, so Claude doesn't report a transfer that never landed; one still
+// pending at the timeout is reported as pending.
+func WithTransactionPolling(p TransactionPoller, timeout time.Duration) Option {
+	return func(e *Engine) {
+		e.txPoller = p
+		e.txPollTimeout = timeout
+	}
+}
+
+// pollTransaction waits for the transaction behind a confirmed write's
+// result, if polling is configured, and records its status in the trace.
+// It returns the result to report: result itself, or a failure if the
+// transaction didn't land.
+func (e *Engine) pollTransaction(ctx context.Context, action *core.PendingAction, result *core.ToolResult, trace *core.Trace) *core.ToolResult {
+	if e.txPoller == nil {
+		return result
+	}
+	if e.txPollTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.txPollTimeout)
+		defer cancel()
+	}
+
+	status, ok, err := e.txPoller.PollTransaction(ctx, action.UserID, action.Tool, result)
+	if !ok {
+		return result
+	}
+	if err != nil && !status.Final() {
+		e.logger.WarnContext(ctx, "transaction status unknown", "user_id", action.UserID, "tool", action.Tool, "confirmation_id", action.ID, "error", err)
+		status = core.TxPending
+	}
+	trace.Metadata["transaction_status"] = string(status)
+
+	switch status {
+	case core.TxFailed, core.TxReverted:
+		return &core.ToolResult{
+			Success:  false,
+			Error:    fmt.Sprintf("The transaction was submitted but %s, so it did not go through.", status),
+			Metadata: result.Metadata,
+		}
+	default:
+		return result
+	}
+}
+
+// transactionStatusNote describes a polled transaction's status for Claude,
+// or returns "" if it wasn't polled.
+func transactionStatusNote(trace *core.Trace) string {
+	switch core.TxStatus(trace.Metadata["transaction_status"]) {
+	case core.TxConfirmed:
+		return "\n\nTransaction status: confirmed."
+	case core.TxPending:
+		return "\n\nTransaction status: still pending. Don't tell the user it has landed yet; check again with poll_transaction_status if available."
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// fixedPoller reports status for results with a transactionId.
+type fixedPoller struct {
+	status core.TxStatus
+}
+
+func (p fixedPoller) PollTransaction(ctx context.Context, userID, tool string, result *core.ToolResult) (core.TxStatus, bool, error) {
+	data, _ := result.Data.(map[string]interface{})
+	if data["transactionId"] == nil {
+		return "", false, nil
+	}
+	return p.status, true, nil
+}
+
+func TestRunConfirmedActionPollsTransaction(t *testing.T) {
+	tests := []struct {
+		status    core.TxStatus
+		wantError bool
+		wantText  string
+	}{
+		{core.TxConfirmed, false, "Transaction status: confirmed"},
+		{core.TxPending, false, "still pending"},
+		{core.TxReverted, true, "submitted but reverted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			fake, client := newFakeClaude(t,
+				toolUseResponse("toolu_1", "send_money", map[string]interface{}{"amount": "30", "thought": "User asked to send $30"}),
+				textResponse("Done."),
+			)
+			registry := NewToolRegistry()
+			registry.Register(testTool("send_money", true, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+				return &core.ToolResult{Success: true, Data: map[string]interface{}{"transactionId": "tx-1"}}, nil
+			}))
+			eng := NewEngine(client, registry, WithTransactionPolling(fixedPoller{tt.status}, time.Second))
+
+			output, err := eng.Run(context.Background(), testInput("send $30"))
+			if err != nil || output.Type != OutputConfirmationNeeded {
+				t.Fatalf("Run() = (%v, %v), want OutputConfirmationNeeded", output.Type, err)
+			}
+			if _, err := eng.RunConfirmedAction(context.Background(), testInput(""), output.PendingAction); err != nil {
+				t.Fatalf("RunConfirmedAction() error = %v", err)
+			}
+
+			messages := fake.Requests()[1]["messages"].([]interface{})
+			result := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+			text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+			if (result["is_error"] == true) != tt.wantError || !strings.Contains(text, tt.wantText) {
+				t.Errorf("tool_result = %q (is_error %v), want %q (is_error %v)", text, result["is_error"], tt.wantText, tt.wantError)
+			}
+		})
+	}
+}
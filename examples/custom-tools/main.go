@@ -76,10 +76,11 @@ func (s *TaskStore) Add(task *Task) {
 	s.tasks[task.ID] = task
 }
 
+// List returns the tasks in ID order, so list_tasks is stable across calls.
 func (s *TaskStore) List() []*Task {
 	result := make([]*Task, 0, len(s.tasks))
-	for _, t := range s.tasks {
-		result = append(result, t)
+	for _, id := range core.SortedKeys(s.tasks) {
+		result = append(result, s.tasks[id])
 	}
 	return result
 }
@@ -70,8 +70,9 @@ func main() {
 }
 
 // authenticateRequest validates the request and returns a user ID.
-// In production, this would validate a JWT or session token.
-func authenticateRequest(r *http.Request) (string, error) {
+// In production, this would validate a JWT or session token and could return
+// extra values (e.g., a tenant ID) for tools to read with core.ContextValue.
+func authenticateRequest(r *http.Request) (string, map[string]string, error) {
 	// Check for token in query param or Authorization header
 	token := r.URL.Query().Get("token")
 	if token == "" {
@@ -85,7 +86,7 @@ func authenticateRequest(r *http.Request) (string, error) {
 		token = "demo-user"
 	}
 
-	return token, nil
+	return token, nil, nil
 }
 
 // createThinkTool creates a reasoning tool for the agent.
@@ -7,11 +7,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/becomeliminal/nim-go-sdk/analytics"
 	"github.com/becomeliminal/nim-go-sdk/core"
 	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/goals"
 	"github.com/becomeliminal/nim-go-sdk/server"
 	"github.com/becomeliminal/nim-go-sdk/tools"
 	"github.com/joho/godotenv"
@@ -94,6 +100,9 @@ func main() {
 	srv.AddTools(tools.LiminalTools(liminalExecutor)...)
 	log.Println("✅ Added 9 Liminal banking tools")
 
+	// Warn before the first payment to a new recipient
+	srv.OverrideTool(tools.FirstTimeRecipientGuard(liminalExecutor))
+
 	// ============================================================================
 	// ADD CUSTOM TOOLS
 	// ============================================================================
@@ -103,9 +112,27 @@ func main() {
 	srv.AddTool(createSpendingAnalyzerTool(liminalExecutor))
 	log.Println("✅ Added custom spending analyzer tool")
 
+	// Subscription finder built on analytics.DetectRecurring
+	srv.AddTool(tools.NewFindSubscriptionsTool(liminalExecutor))
+	log.Println("✅ Added subscription finder tool")
+
+	// Currency conversion for "$50 (about €46)" style answers, using a
+	// public rates endpoint (cached for 10 minutes)
+	srv.AddTool(tools.NewConvertCurrencyTool(tools.NewHTTPFXClient(tools.HTTPFXConfig{})))
+	log.Println("✅ Added currency conversion tool")
+
+	// Savings goals, kept in a local SQLite database, with progress measured
+	// against the user's savings balance
+	goalStore, err := goals.NewSQLiteStore("goals.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer goalStore.Close()
+	srv.AddTools(goals.Tools(goalStore, liminalExecutor)...)
+	log.Println("✅ Added savings goal tools")
+
 	// TODO: Add more custom tools here!
 	// Examples:
-	//   - Savings goal tracker
 	//   - Budget alerts
 	//   - Spending category analyzer
 	//   - Bill payment predictor
@@ -124,7 +151,8 @@ func main() {
 	log.Println("Ready for connections! Start your frontend with: cd ../frontend && npm run dev")
 	log.Println()
 
-	if err := srv.Run(":" + port); err != nil {
+	// Ctrl+C / SIGTERM lets in-flight transfers finish before exiting
+	if err := srv.Run(":"+port, server.WithSignalShutdown(30*time.Second)); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -178,6 +206,7 @@ AVAILABLE BANKING TOOLS:
 
 CUSTOM ANALYTICAL TOOLS:
 - Analyze spending patterns (analyze_spending)
+- Set savings goals and track progress towards them (set_savings_goal, get_savings_progress, list_goals)
 
 TIPS FOR GREAT INTERACTIONS:
 - Proactively suggest relevant actions ("Want me to move some to savings?")
@@ -201,7 +230,7 @@ Remember: You're here to make banking delightful and help users build better fin
 
 func createSpendingAnalyzerTool(liminalExecutor core.ToolExecutor) core.Tool {
 	return tools.New("analyze_spending").
-		Description("Analyze the user's spending patterns over a specified time period. Returns insights about spending velocity, categories, and trends.").
+		Description("Analyze the user's spending over a specified time period. Returns totals, spending by counterparty with percentages, recurring payments with their next expected date, and the week-over-week trend.").
 		Schema(tools.ObjectSchema(map[string]interface{}{
 			"days": tools.IntegerProperty("Number of days to analyze (default: 30)"),
 		})).
@@ -223,57 +252,26 @@ func createSpendingAnalyzerTool(liminalExecutor core.ToolExecutor) core.Tool {
 			}
 
 			// STEP 1: Fetch transaction history
-			// We'll call the Liminal get_transactions tool through the executor
-			txRequest := map[string]interface{}{
-				"limit": 100, // Get up to 100 transactions
-			}
-			txRequestJSON, _ := json.Marshal(txRequest)
-
-			txResponse, err := liminalExecutor.Execute(ctx, &core.ExecuteRequest{
-				UserID:    toolParams.UserID,
-				Tool:      "get_transactions",
-				Input:     txRequestJSON,
-				RequestID: toolParams.RequestID,
-			})
+			// FetchAllTransactions pages through the Liminal get_transactions
+			// tool until it has covered the whole period
+			now := time.Now()
+			transactions, err := tools.FetchAllTransactions(ctx, liminalExecutor, toolParams.UserID, now.AddDate(0, 0, -params.Days))
 			if err != nil {
 				return &core.ToolResult{
 					Success: false,
-					Error:   fmt.Sprintf("failed to fetch transactions: %v", err),
+					Error:   err.Error(),
 				}, nil
 			}
 
-			if !txResponse.Success {
-				return &core.ToolResult{
-					Success: false,
-					Error:   fmt.Sprintf("transaction fetch failed: %s", txResponse.Error),
-				}, nil
-			}
+			// STEP 2: Analyze the data
+			analysis := analyzeTransactions(transactions, params.Days, now)
 
-			// STEP 2: Parse transaction data
-			// In a real implementation, you'd parse the actual response structure
-			// For now, we'll create a structured analysis
-
-			var transactions []map[string]interface{}
-			var txData map[string]interface{}
-			if err := json.Unmarshal(txResponse.Data, &txData); err == nil {
-				if txArray, ok := txData["transactions"].([]interface{}); ok {
-					for _, tx := range txArray {
-						if txMap, ok := tx.(map[string]interface{}); ok {
-							transactions = append(transactions, txMap)
-						}
-					}
-				}
-			}
-
-			// STEP 3: Analyze the data
-			analysis := analyzeTransactions(transactions, params.Days)
-
-			// STEP 4: Return insights
+			// STEP 3: Return insights
 			result := map[string]interface{}{
 				"period_days":        params.Days,
 				"total_transactions": len(transactions),
 				"analysis":           analysis,
-				"generated_at":       time.Now().Format(time.RFC3339),
+				"generated_at":       now.Format(time.RFC3339),
 			}
 
 			return &core.ToolResult{
@@ -284,42 +282,131 @@ func createSpendingAnalyzerTool(liminalExecutor core.ToolExecutor) core.Tool {
 		Build()
 }
 
-// analyzeTransactions processes transaction data and returns insights
-func analyzeTransactions(transactions []map[string]interface{}, days int) map[string]interface{} {
-	if len(transactions) == 0 {
+// spendingCategory is the spending with one counterparty over the period.
+type spendingCategory struct {
+	Name    string  `json:"name"`
+	Total   string  `json:"total"`
+	Percent float64 `json:"percent"`
+	Count   int     `json:"count"`
+
+	total float64
+}
+
+// recurringPayment is a payment repeated to the same counterparty on a
+// regular cadence.
+type recurringPayment struct {
+	Counterparty string  `json:"counterparty"`
+	Amount       string  `json:"amount"`
+	Cadence      string  `json:"cadence"` // "weekly", "biweekly" or "monthly"
+	Occurrences  int     `json:"occurrences"`
+	NextExpected string  `json:"next_expected"`
+	Confidence   float64 `json:"confidence"`
+}
+
+// spendingTx is a transaction normalized for analysis.
+type spendingTx struct {
+	counterparty string
+	amount       float64 // USD value, always positive
+	outflow      bool
+	at           time.Time
+}
+
+// analyzeTransactions categorizes the transactions in the last days by
+// counterparty, finds recurring payments, and compares this week's spending
+// to last week's. Amounts use the transaction's USD value when present.
+func analyzeTransactions(transactions []executor.Transaction, days int, now time.Time) map[string]interface{} {
+	since := now.AddDate(0, 0, -days)
+	var txs []spendingTx
+	var windowed []executor.Transaction
+	for _, tx := range transactions {
+		at, err := time.Parse(time.RFC3339, tx.CreatedAt)
+		if err != nil || at.Before(since) || at.After(now) {
+			continue
+		}
+		if status := strings.ToLower(tx.Status); status == "failed" || status == "cancelled" {
+			continue
+		}
+		amount := parseAmount(tx.USDValue)
+		if amount == 0 {
+			amount = parseAmount(tx.Amount)
+		}
+		counterparty := tx.Counterparty
+		if counterparty == "" {
+			counterparty = "Other"
+		}
+		windowed = append(windowed, tx)
+		txs = append(txs, spendingTx{
+			counterparty: counterparty,
+			amount:       math.Abs(amount),
+			outflow:      isOutflow(tx),
+			at:           at,
+		})
+	}
+
+	if len(txs) == 0 {
 		return map[string]interface{}{
 			"summary": "No transactions found in the specified period",
 		}
 	}
 
-	// Calculate basic metrics
+	// Totals and per-counterparty categories
 	var totalSpent, totalReceived float64
 	var spendCount, receiveCount int
-
-	// This is a simplified example - you'd do real analysis here:
-	// - Group by category/merchant
-	// - Calculate daily/weekly averages
-	// - Identify spending spikes
-	// - Compare to previous periods
-	// - Detect recurring payments
-
-	for _, tx := range transactions {
-		// Example analysis logic
-		txType, _ := tx["type"].(string)
-		amount, _ := tx["amount"].(float64)
-
-		switch txType {
-		case "send":
-			totalSpent += amount
-			spendCount++
-		case "receive":
-			totalReceived += amount
+	byCounterparty := make(map[string]*spendingCategory)
+	for _, tx := range txs {
+		if !tx.outflow {
+			totalReceived += tx.amount
 			receiveCount++
+			continue
+		}
+		totalSpent += tx.amount
+		spendCount++
+		c, ok := byCounterparty[tx.counterparty]
+		if !ok {
+			c = &spendingCategory{Name: tx.counterparty}
+			byCounterparty[tx.counterparty] = c
+		}
+		c.total += tx.amount
+		c.Count++
+	}
+
+	categories := make([]spendingCategory, 0, len(byCounterparty))
+	for _, c := range byCounterparty {
+		c.Total = fmt.Sprintf("%.2f", c.total)
+		if totalSpent > 0 {
+			c.Percent = math.Round(c.total/totalSpent*1000) / 10
 		}
+		categories = append(categories, *c)
 	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].total != categories[j].total {
+			return categories[i].total > categories[j].total
+		}
+		return categories[i].Name < categories[j].Name
+	})
 
+	recurring := findRecurring(windowed)
+	trend := weekOverWeek(txs, now)
 	avgDailySpend := totalSpent / float64(days)
 
+	insights := []string{
+		fmt.Sprintf("You made %d payments totalling $%.2f over %d days", spendCount, totalSpent, days),
+	}
+	if len(categories) > 0 {
+		top := categories[0]
+		insights = append(insights, fmt.Sprintf("Your biggest spend was %s: $%s (%.0f%% of spending)", top.Name, top.Total, top.Percent))
+	}
+	if len(recurring) > 0 {
+		insights = append(insights, fmt.Sprintf("%d recurring payment(s) detected", len(recurring)))
+	}
+	if change, ok := trend["change_percent"].(float64); ok {
+		if trend["direction"] == "flat" {
+			insights = append(insights, "Spending this week is about the same as last week")
+		} else {
+			insights = append(insights, fmt.Sprintf("Spending this week is %s %.0f%% versus last week", trend["direction"], math.Abs(change)))
+		}
+	}
+
 	return map[string]interface{}{
 		"total_spent":     fmt.Sprintf("%.2f", totalSpent),
 		"total_received":  fmt.Sprintf("%.2f", totalReceived),
@@ -327,12 +414,84 @@ func analyzeTransactions(transactions []map[string]interface{}, days int) map[st
 		"receive_count":   receiveCount,
 		"avg_daily_spend": fmt.Sprintf("%.2f", avgDailySpend),
 		"velocity":        calculateVelocity(spendCount, days),
-		"insights": []string{
-			fmt.Sprintf("You made %d spending transactions over %d days", spendCount, days),
-			fmt.Sprintf("Average daily spend: $%.2f", avgDailySpend),
-			"Consider setting up savings goals to build financial cushion",
-		},
+		"categories":      categories,
+		"recurring":       recurring,
+		"week_over_week":  trend,
+		"insights":        insights,
+	}
+}
+
+// isOutflow reports whether money left the user's wallet, using the
+// transaction's direction, or its type if no direction is set.
+func isOutflow(tx executor.Transaction) bool {
+	switch strings.ToLower(tx.Direction) {
+	case "outgoing", "out", "debit", "sent":
+		return true
+	case "incoming", "in", "credit", "received":
+		return false
+	}
+	switch strings.ToLower(tx.Type) {
+	case "send", "payment", "withdrawal", "transfer_out", "deposit_savings":
+		return true
+	}
+	return strings.HasPrefix(tx.Amount, "-")
+}
+
+func parseAmount(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v
+}
+
+// findRecurring finds recurring outgoing payments with
+// analytics.DetectRecurring.
+func findRecurring(transactions []executor.Transaction) []recurringPayment {
+	recurring := []recurringPayment{}
+	for _, s := range analytics.DetectRecurring(analytics.FromLiminal(transactions)) {
+		if s.Amount >= 0 {
+			continue
+		}
+		recurring = append(recurring, recurringPayment{
+			Counterparty: s.Counterparty,
+			Amount:       fmt.Sprintf("%.2f", -s.Amount),
+			Cadence:      string(s.Period),
+			Occurrences:  len(s.Occurrences),
+			NextExpected: s.NextExpected.Format("2006-01-02"),
+			Confidence:   s.Confidence,
+		})
+	}
+	return recurring
+}
+
+// weekOverWeek compares spending in the last 7 days with the 7 days before.
+func weekOverWeek(txs []spendingTx, now time.Time) map[string]interface{} {
+	weekAgo, twoWeeksAgo := now.AddDate(0, 0, -7), now.AddDate(0, 0, -14)
+	var thisWeek, lastWeek float64
+	for _, tx := range txs {
+		switch {
+		case !tx.outflow:
+		case !tx.at.Before(weekAgo):
+			thisWeek += tx.amount
+		case !tx.at.Before(twoWeeksAgo):
+			lastWeek += tx.amount
+		}
+	}
+
+	trend := map[string]interface{}{
+		"this_week": fmt.Sprintf("%.2f", thisWeek),
+		"last_week": fmt.Sprintf("%.2f", lastWeek),
+	}
+	if lastWeek > 0 {
+		change := math.Round((thisWeek-lastWeek)/lastWeek*1000) / 10
+		direction := "flat"
+		if change >= 10 {
+			direction = "up"
+		} else if change <= -10 {
+			direction = "down"
+		}
+		trend["change_percent"] = change
+		trend["direction"] = direction
 	}
+	return trend
 }
 
 // calculateVelocity determines spending frequency
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+)
+
+// WithDecodedCalldata wraps the execute_contract_call tool among tools so its
+// confirmation summary says what the calldata does (e.g. "Approve unlimited
+// USDC spending by Aave V3 Pool") instead of only naming the chain and
+// contract. Other tools are returned unchanged.
+func WithDecodedCalldata(tools []core.Tool) []core.Tool {
+	wrapped := make([]core.Tool, len(tools))
+	for i, tool := range tools {
+		if tool.Name() == "execute_contract_call" {
+			tool = &contractCallTool{toolWrapper{tool}}
+		}
+		wrapped[i] = tool
+	}
+	return wrapped
+}
+
+// contractCallTool is execute_contract_call with a confirmation summary
+// decoded from its calldata.
+type contractCallTool struct {
+	toolWrapper
+}
+
+// GetSummaryContext describes the call. Calls it can't decode keep the base
+// summary, flagged so the user knows to check the call before confirming.
+func (t *contractCallTool) GetSummaryContext(ctx context.Context, userID string, input json.RawMessage) string {
+	var params struct {
+		ChainID json.Number `json:"chain_id"`
+		To      string      `json:"to"`
+		Data    string      `json:"data"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return t.baseSummary(ctx, userID, input)
+	}
+	data, err := defi.DecodeHexCalldata(params.Data)
+	if err != nil || len(data) < 4 {
+		return t.baseSummary(ctx, userID, input)
+	}
+
+	description, ok := defi.DescribeContractCall(params.To, data)
+	if !ok {
+		return fmt.Sprintf("%s — unrecognized function 0x%x, check it before confirming", t.baseSummary(ctx, userID, input), data[:4])
+	}
+	return fmt.Sprintf("%s (contract %s on chain %s)", description, params.To, params.ChainID)
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+func TestWithDecodedCalldata(t *testing.T) {
+	contractCall := -1
+	wrapped := WithDecodedCalldata(tools.LiminalTools(nil))
+	for i, tool := range wrapped {
+		if tool.Name() == "execute_contract_call" {
+			contractCall = i
+		}
+	}
+	if contractCall < 0 {
+		t.Fatal("execute_contract_call missing from wrapped tools")
+	}
+	tool, ok := wrapped[contractCall].(core.ContextSummarizer)
+	if !ok {
+		t.Fatal("wrapped execute_contract_call doesn't implement core.ContextSummarizer")
+	}
+
+	input := json.RawMessage(fmt.Sprintf(`{"chain_id":42161,"to":%q,"data":%q}`,
+		defi.USDC, defi.HexEncode(defi.EncodeApprove(defi.AaveV3Pool, defi.MaxUint256))))
+	want := "Approve unlimited USDC spending by Aave V3 Pool (contract " + defi.USDC + " on chain 42161)"
+	if got := tool.GetSummaryContext(context.Background(), "alice", input); got != want {
+		t.Errorf("GetSummaryContext() = %q, want %q", got, want)
+	}
+
+	unknown := json.RawMessage(fmt.Sprintf(`{"chain_id":42161,"to":%q,"data":"0xdeadbeef"}`, defi.USDC))
+	if got := tool.GetSummaryContext(context.Background(), "alice", unknown); !strings.Contains(got, "unrecognized function 0xdeadbeef") {
+		t.Errorf("GetSummaryContext() for unknown calldata = %q, want it flagged", got)
+	}
+}
+
+func TestWithDecodedCalldataKeepsToolInterfaces(t *testing.T) {
+	var inner, wrapped core.Tool
+	for i, tool := range WithDecodedCalldata(tools.LiminalTools(nil)) {
+		if tool.Name() == "execute_contract_call" {
+			inner, wrapped = tools.LiminalTools(nil)[i], tool
+		}
+	}
+
+	input := json.RawMessage(`{"chain_id":42161,"to":"0xabc","data":"0x","thought":"Approve USDC"}`)
+	innerKeyer, ok := inner.(core.IdempotencyKeyer)
+	if !ok {
+		t.Fatal("execute_contract_call doesn't implement core.IdempotencyKeyer")
+	}
+	keyer, ok := wrapped.(core.IdempotencyKeyer)
+	if !ok {
+		t.Fatal("wrapping hides core.IdempotencyKeyer")
+	}
+	if got, want := string(keyer.IdempotencyInput(input)), string(innerKeyer.IdempotencyInput(input)); got != want {
+		t.Errorf("IdempotencyInput() = %s, want %s", got, want)
+	}
+	if sk, ok := wrapped.(core.SummaryKeyer); !ok || sk.SummaryKey() != inner.(core.SummaryKeyer).SummaryKey() {
+		t.Error("wrapping hides core.SummaryKeyer")
+	}
+}
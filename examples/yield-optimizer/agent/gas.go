@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+)
+
+// gasEstimateTimeout bounds how long a confirmation summary waits on the RPC.
+const gasEstimateTimeout = 5 * time.Second
+
+// txPlan builds the transactions a write tool will submit for the given input.
+// note is appended to the gas estimate (e.g., when some steps can't be estimated yet).
+type txPlan func(ctx context.Context, input json.RawMessage) (txs []defi.Call, note string, err error)
+
+// gasSummaryTool wraps a write tool so its confirmation summary includes an
+// estimated gas cost, or the revert reason if the transaction would fail.
+type gasSummaryTool struct {
+	toolWrapper
+	deps *ToolDeps
+	plan txPlan
+}
+
+func withGasSummary(tool core.Tool, deps *ToolDeps, plan txPlan) core.Tool {
+	return &gasSummaryTool{toolWrapper: toolWrapper{tool}, deps: deps, plan: plan}
+}
+
+// GetSummaryContext returns the base summary with the gas estimate appended.
+// The estimate is bounded by gasEstimateTimeout and cancelled with ctx.
+func (t *gasSummaryTool) GetSummaryContext(ctx context.Context, userID string, input json.RawMessage) string {
+	summary := t.baseSummary(ctx, userID, input)
+
+	ctx, cancel := context.WithTimeout(ctx, gasEstimateTimeout)
+	defer cancel()
+
+	return summary + t.deps.gasNote(ctx, t.plan, input)
+}
+
+// gasNote estimates gas for the planned transactions and formats it for a summary.
+// Returns an empty string if no estimate is available.
+func (d *ToolDeps) gasNote(ctx context.Context, plan txPlan, input json.RawMessage) string {
+	if d.RPC == nil || d.WalletAddress == "" {
+		return ""
+	}
+
+	txs, note, err := plan(ctx, input)
+	if err != nil || len(txs) == 0 {
+		return ""
+	}
+
+	cost, err := d.estimateTxCost(ctx, txs)
+	if err != nil {
+		var revertErr *defi.RPCRevertError
+		if errors.As(err, &revertErr) {
+			return fmt.Sprintf(" — WARNING: this transaction would fail (%s)", revertErr.Error())
+		}
+		var rpcErr *defi.RPCError
+		if errors.As(err, &rpcErr) && rpcErr.IsRevert() {
+			return fmt.Sprintf(" — WARNING: this transaction would fail (%s)", rpcErr.Message)
+		}
+		return ""
+	}
+
+	suffix := fmt.Sprintf(" (est. gas: %s ETH", formatETH(cost))
+	if note != "" {
+		suffix += ", " + note
+	}
+	return suffix + ")"
+}
+
+// estimateTxCost estimates the total cost in wei of submitting txs from the
+// wallet at the current gas price. A transaction that would revert returns
+// the RPC's revert error.
+func (d *ToolDeps) estimateTxCost(ctx context.Context, txs []defi.Call) (*big.Int, error) {
+	var totalGas uint64
+	for _, tx := range txs {
+		gas, err := d.RPC.EstimateGas(ctx, d.WalletAddress, tx.Target, tx.CallData, nil)
+		if err != nil {
+			return nil, err
+		}
+		totalGas += gas
+	}
+
+	gasPrice, err := d.RPC.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(totalGas), gasPrice), nil
+}
+
+// depositTxPlan returns the transactions deposit_aave will submit.
+// If an approval is needed first, only the approval can be estimated: the
+// supply call would revert until the allowance is in place.
+func (d *ToolDeps) depositTxPlan(ctx context.Context, input json.RawMessage) ([]defi.Call, string, error) {
+	var params struct {
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return nil, "", err
+	}
+	amountWei, err := defi.ParseUSDCAmount(params.Amount)
+	if err != nil {
+		return nil, "", err
+	}
+
+	allowance, err := d.Aave.GetAllowance(ctx, d.WalletAddress, defi.AaveV3Pool)
+	if err == nil && allowance.Cmp(amountWei) < 0 {
+		return []defi.Call{
+			{Target: defi.USDC, CallData: defi.EncodeApprove(defi.AaveV3Pool, defi.MaxUint256)},
+		}, "approval only, supply gas not included", nil
+	}
+
+	return []defi.Call{
+		{Target: defi.AaveV3Pool, CallData: defi.EncodeAaveSupply(defi.USDC, amountWei, d.WalletAddress)},
+	}, "", nil
+}
+
+// withdrawTxPlan returns the transaction withdraw_aave will submit.
+func (d *ToolDeps) withdrawTxPlan(ctx context.Context, input json.RawMessage) ([]defi.Call, string, error) {
+	var params struct {
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return nil, "", err
+	}
+	amountWei, err := parseWithdrawAmount(params.Amount)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []defi.Call{
+		{Target: defi.AaveV3Pool, CallData: defi.EncodeAaveWithdraw(defi.USDC, amountWei, d.WalletAddress)},
+	}, "", nil
+}
+
+// parseWithdrawAmount parses a withdrawal amount, mapping "max"/"all" to MaxUint256.
+func parseWithdrawAmount(amount string) (*big.Int, error) {
+	if amount == "max" || amount == "all" {
+		return defi.MaxUint256, nil
+	}
+	return defi.ParseUSDCAmount(amount)
+}
+
+// formatETH formats a wei amount as ETH with 6 decimal places.
+func formatETH(wei *big.Int) string {
+	eth := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+	return eth.Text('f', 6)
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+)
+
+// gasRPCServer answers eth_estimateGas with 21000 and eth_gasPrice with 1 gwei.
+func gasRPCServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := "0x5208"
+		if req.Method == "eth_gasPrice" {
+			result = "0x3b9aca00"
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%q}`, result)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGasSummaryTool(t *testing.T) {
+	rpc := gasRPCServer(t)
+	deps := &ToolDeps{RPC: defi.NewRPCClient(rpc.URL), WalletAddress: "0x0000000000000000000000000000000000000001"}
+	tool := withGasSummary(createWithdrawAaveTool(deps), deps, deps.withdrawTxPlan)
+	input := json.RawMessage(`{"amount":"100","thought":"Moving to a better rate"}`)
+
+	cs, ok := tool.(core.ContextSummarizer)
+	if !ok {
+		t.Fatal("gas summary tool doesn't implement core.ContextSummarizer")
+	}
+	want := "Withdraw 100 USDC from Aave V3 (est. gas: 0.000021 ETH)"
+	if got := cs.GetSummaryContext(context.Background(), "alice", input); got != want {
+		t.Errorf("GetSummaryContext() = %q, want %q", got, want)
+	}
+
+	// The estimate follows the caller's context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := cs.GetSummaryContext(ctx, "alice", input); got != "Withdraw 100 USDC from Aave V3" {
+		t.Errorf("GetSummaryContext() with a cancelled context = %q, want no estimate", got)
+	}
+
+	// Wrapping keeps the tool's optional interfaces.
+	if _, ok := tool.(core.IdempotencyKeyer); !ok {
+		t.Error("gas summary tool hides core.IdempotencyKeyer")
+	}
+	if _, ok := tool.(core.SummaryKeyer); !ok {
+		t.Error("gas summary tool hides core.SummaryKeyer")
+	}
+}
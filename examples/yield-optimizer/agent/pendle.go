@@ -0,0 +1,384 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// pendleExpiryWarning is how close to maturity a market must be for the
+// confirmation summary to warn that the fixed rate ends soon.
+const pendleExpiryWarning = 7 * 24 * time.Hour
+
+// pendleTrade is a resolved buy or redeem: the market, the exact input amount
+// and the minimum output, all in base units.
+type pendleTrade struct {
+	market     *defi.PendleMarket
+	amountIn   *big.Int
+	minOut     *big.Int
+	ptDecimals int
+}
+
+// pendleSummaryTool gives a Pendle trade tool a confirmation summary computed
+// from the trade resolved against the live market: its expiry and estimated
+// gas. The trade is resolved once per summary and shared by both. Falls back
+// to the base summary if the trade can't be resolved.
+type pendleSummaryTool struct {
+	toolWrapper
+	deps    *ToolDeps
+	prepare func(ctx context.Context, input json.RawMessage) (*pendleTrade, error)
+	summary func(trade *pendleTrade, now time.Time) string
+	plan    func(trade *pendleTrade) txPlan
+}
+
+func withPendleSummary(tool core.Tool, deps *ToolDeps, prepare func(ctx context.Context, input json.RawMessage) (*pendleTrade, error), summary func(trade *pendleTrade, now time.Time) string, plan func(trade *pendleTrade) txPlan) core.Tool {
+	return &pendleSummaryTool{toolWrapper: toolWrapper{tool}, deps: deps, prepare: prepare, summary: summary, plan: plan}
+}
+
+// GetSummaryContext returns the trade's summary with the gas estimate appended.
+func (t *pendleSummaryTool) GetSummaryContext(ctx context.Context, userID string, input json.RawMessage) string {
+	summaryCtx, cancel := context.WithTimeout(ctx, gasEstimateTimeout)
+	defer cancel()
+
+	trade, err := t.prepare(summaryCtx, input)
+	if err != nil {
+		return t.baseSummary(ctx, userID, input)
+	}
+	return t.summary(trade, time.Now()) + t.deps.gasNote(summaryCtx, t.plan(trade), input)
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// buy_pendle_pt
+// ────────────────────────────────────────────────────────────────────────────
+
+func createBuyPendlePTTool(deps *ToolDeps) core.Tool {
+	return tools.New("buy_pendle_pt").
+		Description("Buy Pendle PT with USDC to lock in a fixed rate until the market's expiry. Funds stay locked until expiry (selling early is at market price). Handles USDC approval if needed. Requires confirmation.").
+		Schema(tools.BuildSchemaWithThought(map[string]interface{}{
+			"market": tools.StringProperty("Pendle market address from scan_yields"),
+			"amount": tools.StringProperty("USDC amount to spend (e.g., '100.00')"),
+		}, true, "market", "amount")).
+		RequiresConfirmation().
+		SummaryTemplate("Buy Pendle PT with {{.amount}} USDC (funds locked until expiry)").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Thought string `json:"thought"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: "invalid input"}, nil
+			}
+
+			walletAddr := deps.WalletAddress
+			if walletAddr == "" {
+				return &core.ToolResult{Success: false, Error: "wallet address not configured"}, nil
+			}
+
+			trade, err := deps.preparePendleBuy(ctx, params.Input)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			// Check allowance, approve if needed
+			allowance, err := deps.Aave.GetAllowance(ctx, walletAddr, defi.PendleRouterV4)
+			if err == nil && allowance.Cmp(trade.amountIn) < 0 {
+				if err := deps.approve(ctx, params, defi.USDC, defi.PendleRouterV4, "Approving USDC for Pendle Router"); err != nil {
+					return &core.ToolResult{Success: false, Error: "USDC approval failed"}, nil
+				}
+			}
+
+			buyData := defi.EncodePendleBuyPT(walletAddr, trade.market.Address, defi.USDC, trade.amountIn, trade.minOut)
+			buyReq, _ := json.Marshal(map[string]interface{}{
+				"chain_id": defi.ChainIDArbitrum,
+				"to":       defi.PendleRouterV4,
+				"data":     defi.HexEncode(buyData),
+				"value":    "0",
+				"gas_tier": "standard",
+				"thought":  input.Thought,
+			})
+
+			resp, err := deps.Executor.ExecuteWrite(ctx, &core.ExecuteRequest{
+				UserID: params.UserID, Tool: "execute_contract_call",
+				Input: buyReq, RequestID: params.RequestID,
+			})
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			if resp.RequiresConfirmation {
+				return &core.ToolResult{Success: true, Data: map[string]interface{}{
+					"status":     "pending_confirmation",
+					"summary":    pendleBuySummary(trade, time.Now()) + deps.gasNote(ctx, deps.pendleBuyTxPlan(trade), params.Input),
+					"pt_address": trade.market.PTAddress,
+					"expiry":     trade.market.Expiry,
+				}}, nil
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{
+				"status":     "submitted",
+				"pt_address": trade.market.PTAddress,
+				"expiry":     trade.market.Expiry,
+			}}, nil
+		}).
+		Build()
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// redeem_pendle_pt
+// ────────────────────────────────────────────────────────────────────────────
+
+func createRedeemPendlePTTool(deps *ToolDeps) core.Tool {
+	return tools.New("redeem_pendle_pt").
+		Description("Redeem Pendle PT back to USDC. After expiry PT redeems 1:1; before expiry it is sold at the current market price. Use 'max' to redeem the full PT balance. Requires confirmation.").
+		Schema(tools.BuildSchemaWithThought(map[string]interface{}{
+			"market": tools.StringProperty("Pendle market address the PT belongs to"),
+			"amount": tools.StringProperty("PT amount to redeem (e.g., '100.00' or 'max')"),
+		}, true, "market", "amount")).
+		RequiresConfirmation().
+		SummaryTemplate("Redeem {{.amount}} Pendle PT for USDC").
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Thought string `json:"thought"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: "invalid input"}, nil
+			}
+
+			walletAddr := deps.WalletAddress
+			if walletAddr == "" {
+				return &core.ToolResult{Success: false, Error: "wallet address not configured"}, nil
+			}
+
+			trade, err := deps.preparePendleRedeem(ctx, params.Input)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+
+			// Check PT allowance, approve if needed
+			allowance, err := deps.RPC.TokenAllowance(ctx, trade.market.PTAddress, walletAddr, defi.PendleRouterV4)
+			if err == nil && allowance.Cmp(trade.amountIn) < 0 {
+				if err := deps.approve(ctx, params, trade.market.PTAddress, defi.PendleRouterV4, "Approving PT for Pendle Router"); err != nil {
+					return &core.ToolResult{Success: false, Error: "PT approval failed"}, nil
+				}
+			}
+
+			sellData := defi.EncodePendleSellPT(walletAddr, trade.market.Address, trade.amountIn, defi.USDC, trade.minOut)
+			sellReq, _ := json.Marshal(map[string]interface{}{
+				"chain_id": defi.ChainIDArbitrum,
+				"to":       defi.PendleRouterV4,
+				"data":     defi.HexEncode(sellData),
+				"value":    "0",
+				"gas_tier": "standard",
+				"thought":  input.Thought,
+			})
+
+			resp, err := deps.Executor.ExecuteWrite(ctx, &core.ExecuteRequest{
+				UserID: params.UserID, Tool: "execute_contract_call",
+				Input: sellReq, RequestID: params.RequestID,
+			})
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			if resp.RequiresConfirmation {
+				return &core.ToolResult{Success: true, Data: map[string]interface{}{
+					"status":  "pending_confirmation",
+					"summary": pendleRedeemSummary(trade, time.Now()) + deps.gasNote(ctx, deps.pendleRedeemTxPlan(trade), params.Input),
+				}}, nil
+			}
+			return &core.ToolResult{Success: true, Data: map[string]interface{}{
+				"status": "submitted",
+			}}, nil
+		}).
+		Build()
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// helpers
+// ────────────────────────────────────────────────────────────────────────────
+
+// preparePendleBuy resolves buy_pendle_pt input against the live market.
+func (d *ToolDeps) preparePendleBuy(ctx context.Context, input json.RawMessage) (*pendleTrade, error) {
+	var params struct {
+		Market string `json:"market"`
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return nil, err
+	}
+	if d.Pendle == nil || d.RPC == nil {
+		return nil, errors.New("Pendle trading not configured")
+	}
+
+	amountIn, err := defi.ParseUSDCAmount(params.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	if amountIn.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	market, err := d.Pendle.GetMarket(ctx, params.Market)
+	if err != nil {
+		return nil, fmt.Errorf("Pendle market lookup failed: %w", err)
+	}
+	now := time.Now()
+	if !market.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("market %s expired on %s; PT can only be redeemed", market.Underlying, market.Expiry)
+	}
+
+	ptDecimals, err := d.RPC.TokenDecimals(ctx, market.PTAddress)
+	if err != nil {
+		return nil, fmt.Errorf("read PT decimals: %w", err)
+	}
+
+	return &pendleTrade{
+		market:     market,
+		amountIn:   amountIn,
+		minOut:     market.MinPTOut(amountIn, defi.USDCDecimals, ptDecimals, now),
+		ptDecimals: ptDecimals,
+	}, nil
+}
+
+// preparePendleRedeem resolves redeem_pendle_pt input against the live market
+// and the wallet's PT balance.
+func (d *ToolDeps) preparePendleRedeem(ctx context.Context, input json.RawMessage) (*pendleTrade, error) {
+	var params struct {
+		Market string `json:"market"`
+		Amount string `json:"amount"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return nil, err
+	}
+	if d.Pendle == nil || d.RPC == nil {
+		return nil, errors.New("Pendle trading not configured")
+	}
+
+	market, err := d.Pendle.GetMarket(ctx, params.Market)
+	if err != nil {
+		return nil, fmt.Errorf("Pendle market lookup failed: %w", err)
+	}
+
+	ptDecimals, err := d.RPC.TokenDecimals(ctx, market.PTAddress)
+	if err != nil {
+		return nil, fmt.Errorf("read PT decimals: %w", err)
+	}
+
+	balance, err := d.RPC.TokenBalance(ctx, market.PTAddress, d.WalletAddress)
+	if err != nil {
+		return nil, fmt.Errorf("read PT balance: %w", err)
+	}
+
+	amountIn := balance
+	if params.Amount != "max" && params.Amount != "all" {
+		amountIn, err = defi.ParseTokenAmount(params.Amount, ptDecimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount: %w", err)
+		}
+	}
+	if amountIn.Sign() <= 0 {
+		return nil, errors.New("no PT to redeem")
+	}
+	if amountIn.Cmp(balance) > 0 {
+		return nil, fmt.Errorf("amount exceeds PT balance of %s", defi.FormatTokenAmount(balance, ptDecimals))
+	}
+
+	return &pendleTrade{
+		market:     market,
+		amountIn:   amountIn,
+		minOut:     market.MinTokenOut(amountIn, ptDecimals, defi.USDCDecimals, time.Now()),
+		ptDecimals: ptDecimals,
+	}, nil
+}
+
+// approve submits an unlimited ERC20 approval of token to spender.
+func (d *ToolDeps) approve(ctx context.Context, params *core.ToolParams, token, spender, thought string) error {
+	approveReq, _ := json.Marshal(map[string]interface{}{
+		"chain_id": defi.ChainIDArbitrum,
+		"to":       token,
+		"data":     defi.HexEncode(defi.EncodeApprove(spender, defi.MaxUint256)),
+		"value":    "0",
+		"gas_tier": "standard",
+		"thought":  thought,
+	})
+	resp, err := d.Executor.ExecuteWrite(ctx, &core.ExecuteRequest{
+		UserID: params.UserID, Tool: "execute_contract_call",
+		Input: approveReq, RequestID: params.RequestID,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// pendleBuySummary describes a PT purchase, including the lock-up and a
+// warning for markets close to expiry.
+func pendleBuySummary(trade *pendleTrade, now time.Time) string {
+	m := trade.market
+	summary := fmt.Sprintf("Buy PT %s with %s USDC at %.2f%% fixed — funds locked until %s (PT %s, min %s PT)",
+		m.Underlying, defi.FormatUSDCAmount(trade.amountIn), m.ImpliedAPY, m.Expiry, m.PTAddress, defi.FormatTokenAmount(trade.minOut, trade.ptDecimals))
+	if left := m.ExpiresAt.Sub(now); left < pendleExpiryWarning {
+		summary += fmt.Sprintf(" — WARNING: market expires in %s; the fixed rate only applies until then", formatDuration(left))
+	}
+	return summary
+}
+
+// pendleRedeemSummary describes a PT redemption: 1:1 after expiry, or an early
+// sale at market price before it.
+func pendleRedeemSummary(trade *pendleTrade, now time.Time) string {
+	m := trade.market
+	amount := defi.FormatTokenAmount(trade.amountIn, trade.ptDecimals)
+	if !m.ExpiresAt.After(now) {
+		return fmt.Sprintf("Redeem %s PT %s for USDC (matured %s, PT %s)", amount, m.Underlying, m.Expiry, m.PTAddress)
+	}
+	return fmt.Sprintf("Sell %s PT %s for at least %s USDC before maturity — early exit at market price, funds otherwise locked until %s (PT %s)",
+		amount, m.Underlying, defi.FormatUSDCAmount(trade.minOut), m.Expiry, m.PTAddress)
+}
+
+// pendleBuyTxPlan returns the plan for the transactions buy_pendle_pt will
+// submit for a resolved trade.
+func (d *ToolDeps) pendleBuyTxPlan(trade *pendleTrade) txPlan {
+	return func(ctx context.Context, _ json.RawMessage) ([]defi.Call, string, error) {
+		allowance, err := d.Aave.GetAllowance(ctx, d.WalletAddress, defi.PendleRouterV4)
+		if err == nil && allowance.Cmp(trade.amountIn) < 0 {
+			return []defi.Call{
+				{Target: defi.USDC, CallData: defi.EncodeApprove(defi.PendleRouterV4, defi.MaxUint256)},
+			}, "approval only, swap gas not included", nil
+		}
+
+		return []defi.Call{
+			{Target: defi.PendleRouterV4, CallData: defi.EncodePendleBuyPT(d.WalletAddress, trade.market.Address, defi.USDC, trade.amountIn, trade.minOut)},
+		}, "", nil
+	}
+}
+
+// pendleRedeemTxPlan returns the plan for the transactions redeem_pendle_pt
+// will submit for a resolved trade.
+func (d *ToolDeps) pendleRedeemTxPlan(trade *pendleTrade) txPlan {
+	return func(ctx context.Context, _ json.RawMessage) ([]defi.Call, string, error) {
+		allowance, err := d.RPC.TokenAllowance(ctx, trade.market.PTAddress, d.WalletAddress, defi.PendleRouterV4)
+		if err == nil && allowance.Cmp(trade.amountIn) < 0 {
+			return []defi.Call{
+				{Target: trade.market.PTAddress, CallData: defi.EncodeApprove(defi.PendleRouterV4, defi.MaxUint256)},
+			}, "approval only, redeem gas not included", nil
+		}
+
+		return []defi.Call{
+			{Target: defi.PendleRouterV4, CallData: defi.EncodePendleSellPT(d.WalletAddress, trade.market.Address, trade.amountIn, defi.USDC, trade.minOut)},
+		}, "", nil
+	}
+}
+
+// formatDuration formats a time until expiry in days, or hours under a day.
+func formatDuration(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%d hours", int(d.Hours()))
+}
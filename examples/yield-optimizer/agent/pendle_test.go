@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+)
+
+func TestPendleSummaryToolPreparesOnce(t *testing.T) {
+	rpc := gasRPCServer(t)
+	deps := &ToolDeps{RPC: defi.NewRPCClient(rpc.URL), WalletAddress: "0x0000000000000000000000000000000000000001"}
+	expiry := time.Now().Add(90 * 24 * time.Hour)
+	trade := &pendleTrade{
+		market: &defi.PendleMarket{
+			Address:    "0x0000000000000000000000000000000000000002",
+			PTAddress:  "0x0000000000000000000000000000000000000003",
+			Underlying: "USDC",
+			Expiry:     expiry.Format("2006-01-02"),
+			ExpiresAt:  expiry,
+		},
+		amountIn:   big.NewInt(100_000_000),
+		minOut:     big.NewInt(99_000_000),
+		ptDecimals: 6,
+	}
+
+	prepared := 0
+	fail := false
+	prepare := func(ctx context.Context, input json.RawMessage) (*pendleTrade, error) {
+		prepared++
+		if fail {
+			return nil, errors.New("market not found")
+		}
+		return trade, nil
+	}
+	tool := withPendleSummary(createRedeemPendlePTTool(deps), deps, prepare, pendleRedeemSummary, deps.pendleRedeemTxPlan)
+	cs := tool.(core.ContextSummarizer)
+	input := json.RawMessage(`{"market":"0x0000000000000000000000000000000000000002","amount":"100","thought":"Rate dropped"}`)
+
+	got := cs.GetSummaryContext(context.Background(), "alice", input)
+	if !strings.HasPrefix(got, "Sell 100") || !strings.Contains(got, "(est. gas: 0.000021 ETH") {
+		t.Errorf("GetSummaryContext() = %q, want the trade summary with a gas estimate", got)
+	}
+	if prepared != 1 {
+		t.Errorf("trade prepared %d times, want once for the summary and gas estimate", prepared)
+	}
+
+	// Without a trade, the template summary is used.
+	fail = true
+	if got := cs.GetSummaryContext(context.Background(), "alice", input); got != "Redeem 100 Pendle PT for USDC" {
+		t.Errorf("GetSummaryContext() without a trade = %q, want the template summary", got)
+	}
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// minRebalanceAPYGain is the APY improvement, in percentage points, below
+// which rebalancing isn't worth the gas and effort (see SystemPrompt).
+const minRebalanceAPYGain = 0.5
+
+// currentEarnings returns the deposited total across positions, its blended
+// APY, and the projected yearly earnings at that APY.
+func currentEarnings(p *defiPositions) (deposited, apy, yearly float64) {
+	for _, pos := range p.Positions {
+		b, _ := pos["balance"].(string)
+		a, _ := pos["apy"].(string)
+		balance, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			continue
+		}
+		posAPY, _ := strconv.ParseFloat(strings.TrimSuffix(a, "%"), 64)
+		deposited += balance
+		yearly += balance * posAPY / 100
+	}
+	if deposited > 0 {
+		apy = yearly / deposited * 100
+	}
+	return deposited, apy, yearly
+}
+
+// compareWithCurrent compares the suggested allocation of total against the
+// user's current positions: the APY gain, what it is worth per year, and
+// whether it clears minRebalanceAPYGain. The gas for the Aave leg is
+// included when it can be estimated.
+func (d *ToolDeps) compareWithCurrent(ctx context.Context, current *defiPositions, slots []Slot, total float64) map[string]interface{} {
+	deposited, currentAPY, currentYearly := currentEarnings(current)
+	suggestedAPY := 0.0
+	for _, s := range slots {
+		suggestedAPY += s.APY * s.Weight
+	}
+	gain := suggestedAPY - currentAPY
+
+	comparison := map[string]interface{}{
+		"current_deposited":        fmt.Sprintf("%.2f", deposited),
+		"current_apy":              fmt.Sprintf("%.2f", currentAPY),
+		"current_projected_yearly": fmt.Sprintf("%.2f", currentYearly),
+		"suggested_apy":            fmt.Sprintf("%.2f", suggestedAPY),
+		"apy_gain":                 fmt.Sprintf("%+.2f", gain),
+		"yearly_gain":              fmt.Sprintf("%+.2f", total*gain/100),
+	}
+
+	switch {
+	case deposited == 0:
+		comparison["worth_rebalancing"] = false
+		comparison["note"] = "No current positions to rebalance — this is a fresh allocation"
+		return comparison
+	case gain > minRebalanceAPYGain:
+		comparison["worth_rebalancing"] = true
+	default:
+		comparison["worth_rebalancing"] = false
+		comparison["note"] = fmt.Sprintf("APY gain is below %.1f%% — not worth rebalancing", minRebalanceAPYGain)
+	}
+
+	if gas := d.rebalanceGasNote(ctx, current, slots, total); gas != "" {
+		comparison["estimated_gas"] = gas
+	}
+	return comparison
+}
+
+// rebalanceGasNote estimates the gas to move the user's Aave V3 balance to
+// its suggested share. Morpho moves go through Liminal and cost no gas, and
+// Pendle trades are estimated when confirmed. Returns an empty string if no
+// estimate is available or no Aave transaction is needed.
+func (d *ToolDeps) rebalanceGasNote(ctx context.Context, current *defiPositions, slots []Slot, total float64) string {
+	if d.RPC == nil || d.WalletAddress == "" {
+		return ""
+	}
+
+	currentAave := 0.0
+	for _, pos := range current.Positions {
+		if pos["protocol"] == "Aave V3" {
+			b, _ := pos["balance"].(string)
+			currentAave, _ = strconv.ParseFloat(b, 64)
+		}
+	}
+	targetAave := 0.0
+	if i := slotIndex(slots, "Aave V3"); i >= 0 {
+		targetAave = total * slots[i].Weight
+	}
+
+	delta := math.Round((targetAave-currentAave)*100) / 100
+	if delta == 0 {
+		return ""
+	}
+	plan := d.depositTxPlan
+	if delta < 0 {
+		plan = d.withdrawTxPlan
+	}
+	input, _ := json.Marshal(map[string]string{"amount": fmt.Sprintf("%.2f", math.Abs(delta))})
+
+	ctx, cancel := context.WithTimeout(ctx, gasEstimateTimeout)
+	defer cancel()
+	txs, note, err := plan(ctx, input)
+	if err != nil || len(txs) == 0 {
+		return ""
+	}
+	cost, err := d.estimateTxCost(ctx, txs)
+	if err != nil {
+		return ""
+	}
+	if note != "" {
+		return fmt.Sprintf("%s ETH (%s)", formatETH(cost), note)
+	}
+	return formatETH(cost) + " ETH"
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompareWithCurrent(t *testing.T) {
+	current := &defiPositions{Positions: []map[string]interface{}{
+		{"protocol": "Aave V3", "balance": "600.00", "apy": "4.00%"},
+		{"protocol": "Morpho", "balance": "400.00", "apy": "5.00%"},
+	}}
+	deps := &ToolDeps{} // No RPC: no gas estimate
+
+	tests := []struct {
+		name      string
+		slots     []Slot
+		wantGain  string
+		wantYear  string
+		wantWorth bool
+	}{
+		{
+			name:      "clears the threshold",
+			slots:     []Slot{{Protocol: "Pendle PT-USDC", APY: 9, Weight: 0.4}, {Protocol: "Morpho", APY: 5, Weight: 0.6}},
+			wantGain:  "+2.20",
+			wantYear:  "+22.00",
+			wantWorth: true,
+		},
+		{
+			name:      "below the threshold",
+			slots:     []Slot{{Protocol: "Morpho", APY: 5, Weight: 0.6}, {Protocol: "Aave V3", APY: 4, Weight: 0.4}},
+			wantGain:  "+0.20",
+			wantYear:  "+2.00",
+			wantWorth: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := deps.compareWithCurrent(context.Background(), current, tt.slots, 1000)
+			if got["current_apy"] != "4.40" || got["current_projected_yearly"] != "44.00" {
+				t.Errorf("current = %v / %v, want 4.40 / 44.00", got["current_apy"], got["current_projected_yearly"])
+			}
+			if got["apy_gain"] != tt.wantGain || got["yearly_gain"] != tt.wantYear {
+				t.Errorf("gain = %v / %v, want %s / %s", got["apy_gain"], got["yearly_gain"], tt.wantGain, tt.wantYear)
+			}
+			if got["worth_rebalancing"] != tt.wantWorth {
+				t.Errorf("worth_rebalancing = %v, want %v", got["worth_rebalancing"], tt.wantWorth)
+			}
+		})
+	}
+}
+
+func TestCompareWithCurrentNoPositions(t *testing.T) {
+	got := (&ToolDeps{}).compareWithCurrent(context.Background(), &defiPositions{}, []Slot{{Protocol: "Morpho", APY: 5, Weight: 1}}, 500)
+	if got["worth_rebalancing"] != false || got["note"] == nil {
+		t.Errorf("comparison = %v, want a fresh-allocation note", got)
+	}
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"math"
+	"sort"
+)
+
+// Rates holds the current protocol rates a Strategy allocates across, as
+// percentages (e.g. 4.23).
+type Rates struct {
+	Aave       float64
+	Morpho     float64
+	Compound   float64 // 0 when unavailable
+	Pendle     float64 // Best Pendle fixed rate
+	PendleName string  // Market of the best Pendle rate; empty when none
+}
+
+// Slot is one protocol's share of an allocation.
+type Slot struct {
+	Protocol   string
+	APY        float64
+	Weight     float64 // Fraction of the total, 0–1
+	Kind       string  // "variable" or "fixed"
+	Actionable bool    // Whether the agent can deposit into it
+}
+
+// Strategy decides how to split funds across protocols. Allocate returns
+// weighted slots for a risk preference ("conservative", "balanced" or
+// "aggressive"); weights that sum to less than 1 leave the rest unallocated.
+type Strategy interface {
+	Name() string
+	Allocate(rates Rates, risk string) []Slot
+}
+
+// DefaultStrategy is the built-in allocation:
+//   - conservative: 60/40 across the two best variable-rate markets
+//   - balanced: 65/35 across them, or 40% Pendle plus 35/25 when Pendle
+//     pays over 1.5x Aave
+//   - aggressive: all-in on the highest rate, including Pendle
+type DefaultStrategy struct{}
+
+// Name implements Strategy.
+func (DefaultStrategy) Name() string { return "default" }
+
+// Allocate implements Strategy.
+func (DefaultStrategy) Allocate(rates Rates, risk string) []Slot {
+	variable := variableSlots(rates)
+	first, second := variable[0], variable[1]
+	withWeight := func(s Slot, weight float64) Slot {
+		s.Weight = weight
+		return s
+	}
+
+	switch risk {
+	case "conservative":
+		// Split between the two best variable-rate markets, skip Pendle
+		return []Slot{withWeight(first, 0.60), withWeight(second, 0.40)}
+	case "aggressive":
+		// All-in on highest yield (including Pendle)
+		if rates.Pendle > first.APY && rates.PendleName != "" {
+			return []Slot{withWeight(pendleSlot(rates), 1.0)}
+		}
+		return []Slot{withWeight(first, 1.0)}
+	default: // balanced
+		// Mix variable + fixed if Pendle offers significantly more
+		if rates.Pendle > rates.Aave*1.5 && rates.PendleName != "" {
+			return []Slot{withWeight(pendleSlot(rates), 0.40), withWeight(first, 0.35), withWeight(second, 0.25)}
+		}
+		return []Slot{withWeight(first, 0.65), withWeight(second, 0.35)}
+	}
+}
+
+// variableSlots returns the variable-rate lending markets, best first. Ties
+// keep the order Aave, Morpho, Compound.
+func variableSlots(rates Rates) []Slot {
+	variable := []Slot{
+		{Protocol: "Aave V3", APY: rates.Aave, Kind: "variable", Actionable: true},
+		{Protocol: "Morpho", APY: rates.Morpho, Kind: "variable", Actionable: true},
+	}
+	if rates.Compound > 0 {
+		variable = append(variable, Slot{Protocol: "Compound V3", APY: rates.Compound, Kind: "variable"})
+	}
+	sort.SliceStable(variable, func(i, j int) bool { return variable[i].APY > variable[j].APY })
+	return variable
+}
+
+// pendleSlot returns the slot for the best Pendle market.
+func pendleSlot(rates Rates) Slot {
+	return Slot{Protocol: "Pendle " + rates.PendleName, APY: rates.Pendle, Kind: "fixed", Actionable: true}
+}
+
+// Constraints limit an allocation's weights. Zero fields are unlimited.
+type Constraints struct {
+	MaxFixedRate   float64 // Max weight in any fixed-rate (Pendle) market
+	MaxPerProtocol float64 // Max weight in any single protocol
+}
+
+// IsZero reports whether c has no limits.
+func (c Constraints) IsZero() bool {
+	return c.MaxFixedRate <= 0 && c.MaxPerProtocol <= 0
+}
+
+// limit returns the max weight allowed for s.
+func (c Constraints) limit(s Slot) float64 {
+	limit := 1.0
+	if c.MaxPerProtocol > 0 {
+		limit = math.Min(limit, c.MaxPerProtocol)
+	}
+	if s.Kind == "fixed" && c.MaxFixedRate > 0 {
+		limit = math.Min(limit, c.MaxFixedRate)
+	}
+	return limit
+}
+
+// Apply caps each slot's weight and moves the excess into the best
+// variable-rate markets with room left, adding them to the allocation if
+// needed. Weight that fits nowhere is left unallocated.
+func (c Constraints) Apply(slots []Slot, rates Rates) []Slot {
+	capped := make([]Slot, len(slots))
+	copy(capped, slots)
+
+	excess := 0.0
+	for i := range capped {
+		if limit := c.limit(capped[i]); capped[i].Weight > limit {
+			excess += capped[i].Weight - limit
+			capped[i].Weight = limit
+		}
+	}
+
+	for _, candidate := range variableSlots(rates) {
+		if excess < 1e-9 {
+			break
+		}
+		i := slotIndex(capped, candidate.Protocol)
+		if i < 0 {
+			capped = append(capped, candidate)
+			i = len(capped) - 1
+		}
+		add := math.Min(c.limit(capped[i])-capped[i].Weight, excess)
+		if add > 0 {
+			capped[i].Weight += add
+			excess -= add
+		}
+	}
+
+	result := capped[:0]
+	for _, s := range capped {
+		if s.Weight > 0 {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// slotIndex returns the index of protocol in slots, or -1.
+func slotIndex(slots []Slot, protocol string) int {
+	for i, s := range slots {
+		if s.Protocol == protocol {
+			return i
+		}
+	}
+	return -1
+}
+
+// ConstrainedStrategy applies Constraints to another strategy's allocation.
+type ConstrainedStrategy struct {
+	Base        Strategy
+	Constraints Constraints
+}
+
+// Name implements Strategy.
+func (s ConstrainedStrategy) Name() string { return s.Base.Name() }
+
+// Allocate implements Strategy.
+func (s ConstrainedStrategy) Allocate(rates Rates, risk string) []Slot {
+	return s.Constraints.Apply(s.Base.Allocate(rates, risk), rates)
+}
@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// formatSlots renders slots as "Protocol:weight%" for comparison.
+func formatSlots(slots []Slot) string {
+	parts := make([]string, len(slots))
+	for i, s := range slots {
+		parts[i] = fmt.Sprintf("%s:%.0f", s.Protocol, math.Round(s.Weight*100))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestStrategies(t *testing.T) {
+	pendleRich := Rates{Aave: 4, Morpho: 5, Pendle: 9, PendleName: "PT-USDC"}
+	withCompound := Rates{Aave: 4, Morpho: 5, Compound: 3}
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		rates    Rates
+		risk     string
+		want     string
+	}{
+		{"default conservative", DefaultStrategy{}, pendleRich, "conservative", "Morpho:60 Aave V3:40"},
+		{"default balanced with Pendle", DefaultStrategy{}, pendleRich, "balanced", "Pendle PT-USDC:40 Morpho:35 Aave V3:25"},
+		{"default balanced without Pendle", DefaultStrategy{}, withCompound, "balanced", "Morpho:65 Aave V3:35"},
+		{"default aggressive", DefaultStrategy{}, pendleRich, "aggressive", "Pendle PT-USDC:100"},
+		{
+			name:     "Pendle cap moves the excess to the best variable market",
+			strategy: ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxFixedRate: 0.25}},
+			rates:    pendleRich,
+			risk:     "aggressive",
+			want:     "Pendle PT-USDC:25 Morpho:75",
+		},
+		{
+			name:     "Pendle cap below the balanced split",
+			strategy: ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxFixedRate: 0.25}},
+			rates:    pendleRich,
+			risk:     "balanced",
+			want:     "Pendle PT-USDC:25 Morpho:50 Aave V3:25",
+		},
+		{
+			name:     "single-protocol limit spreads an all-in allocation",
+			strategy: ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxPerProtocol: 0.5}},
+			rates:    pendleRich,
+			risk:     "aggressive",
+			want:     "Pendle PT-USDC:50 Morpho:50",
+		},
+		{
+			name:     "single-protocol limit adds Compound when needed",
+			strategy: ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxPerProtocol: 0.4}},
+			rates:    withCompound,
+			risk:     "balanced",
+			want:     "Morpho:40 Aave V3:40 Compound V3:20",
+		},
+		{
+			name:     "single-protocol limit with nowhere to put the rest",
+			strategy: ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxPerProtocol: 0.3}},
+			rates:    Rates{Aave: 4, Morpho: 5},
+			risk:     "conservative",
+			want:     "Morpho:30 Aave V3:30",
+		},
+		{
+			name:     "both limits",
+			strategy: ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxFixedRate: 0.2, MaxPerProtocol: 0.5}},
+			rates:    pendleRich,
+			risk:     "aggressive",
+			want:     "Pendle PT-USDC:20 Morpho:50 Aave V3:30",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSlots(tt.strategy.Allocate(tt.rates, tt.risk)); got != tt.want {
+				t.Errorf("Allocate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAllocationUnallocated(t *testing.T) {
+	strategy := ConstrainedStrategy{Base: DefaultStrategy{}, Constraints: Constraints{MaxPerProtocol: 0.3}}
+	slots := strategy.Allocate(Rates{Aave: 4, Morpho: 5}, "conservative")
+	result := buildAllocation(strategy.Name(), slots, 1000, "conservative")
+	if got := result["unallocated"]; got != "40%" {
+		t.Errorf("unallocated = %v, want 40%%", got)
+	}
+	if got := result["strategy"]; got != "default" {
+		t.Errorf("strategy = %v, want default", got)
+	}
+}
+
+func TestToolDepsStrategy(t *testing.T) {
+	deps := &ToolDeps{}
+	if s, err := deps.strategy(""); err != nil || s.Name() != "default" {
+		t.Errorf("strategy(\"\") = (%v, %v), want DefaultStrategy", s, err)
+	}
+	if _, err := deps.strategy("yolo"); err == nil || !strings.Contains(err.Error(), "available: default") {
+		t.Errorf("strategy(yolo) error = %v, want unknown strategy listing default", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"math/big"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/becomeliminal/nim-go-sdk/core"
@@ -17,10 +18,30 @@ import (
 // ToolDeps holds shared dependencies for all custom tools.
 type ToolDeps struct {
 	Aave          *defi.AaveClient
+	RPC           *defi.RPCClient // Optional: gas estimates in confirmation summaries; required for Pendle trades
 	DefiLlama     *defi.DefiLlamaClient
 	Pendle        *defi.PendleClient
+	Compound      *defi.CompoundClient // Optional: Compound V3 rates (view only)
 	Executor      core.ToolExecutor
 	WalletAddress string
+	Strategies    []Strategy // Optional: extra allocation strategies suggest_allocation can use by name
+}
+
+// strategy returns the allocation strategy named name, or DefaultStrategy
+// for an empty name.
+func (d *ToolDeps) strategy(name string) (Strategy, error) {
+	strategies := append([]Strategy{DefaultStrategy{}}, d.Strategies...)
+	if name == "" {
+		return strategies[0], nil
+	}
+	names := make([]string, len(strategies))
+	for i, s := range strategies {
+		if s.Name() == name {
+			return s, nil
+		}
+		names[i] = s.Name()
+	}
+	return nil, fmt.Errorf("unknown strategy %q (available: %s)", name, strings.Join(names, ", "))
 }
 
 // CreateTools returns all custom yield optimizer tools.
@@ -29,8 +50,10 @@ func CreateTools(deps *ToolDeps) []core.Tool {
 		createScanYieldsTool(deps),
 		createGetDefiPositionsTool(deps),
 		createSuggestAllocationTool(deps),
-		createDepositAaveTool(deps),
-		createWithdrawAaveTool(deps),
+		withGasSummary(createDepositAaveTool(deps), deps, deps.depositTxPlan),
+		withGasSummary(createWithdrawAaveTool(deps), deps, deps.withdrawTxPlan),
+		withPendleSummary(createBuyPendlePTTool(deps), deps, deps.preparePendleBuy, pendleBuySummary, deps.pendleBuyTxPlan),
+		withPendleSummary(createRedeemPendlePTTool(deps), deps, deps.preparePendleRedeem, pendleRedeemSummary, deps.pendleRedeemTxPlan),
 	}
 }
 
@@ -40,7 +63,7 @@ func CreateTools(deps *ToolDeps) []core.Tool {
 
 func createScanYieldsTool(deps *ToolDeps) core.Tool {
 	return tools.New("scan_yields").
-		Description("Scan current USDC yield rates across Aave V3, Liminal/Morpho, and Pendle fixed-rate markets on Arbitrum.").
+		Description("Scan current USDC yield rates across Aave V3, Liminal/Morpho, Compound V3, and Pendle fixed-rate markets on Arbitrum.").
 		Schema(tools.ObjectSchema(map[string]interface{}{
 			"token": tools.StringEnumProperty("Token to scan yields for", "USDC"),
 		})).
@@ -101,7 +124,28 @@ func createScanYieldsTool(deps *ToolDeps) core.Tool {
 				}
 			}
 
-			// 3. Pendle — fixed-rate markets
+			// 3. Compound V3 — on-chain rate, DefiLlama TVL (view only, no deposit flow yet)
+			if deps.Compound != nil {
+				if apy, err := deps.Compound.GetSupplyAPY(ctx); err == nil {
+					compoundTVL := 0.0
+					if deps.DefiLlama != nil {
+						if _, t, err := deps.DefiLlama.CompoundArbitrumUSDCYield(ctx); err == nil {
+							compoundTVL = t
+						}
+					}
+					protocols = append(protocols, map[string]interface{}{
+						"name":       "Compound V3",
+						"chain":      "Arbitrum",
+						"apy":        fmt.Sprintf("%.2f", apy),
+						"type":       "variable",
+						"risk":       "low",
+						"tvl":        formatTVL(compoundTVL),
+						"actionable": false,
+					})
+				}
+			}
+
+			// 4. Pendle — fixed-rate markets
 			if deps.Pendle != nil {
 				markets, err := deps.Pendle.GetStablecoinMarkets(ctx)
 				if err == nil {
@@ -113,7 +157,9 @@ func createScanYieldsTool(deps *ToolDeps) core.Tool {
 							"type":      "fixed",
 							"risk":      "medium",
 							"expiry":    m.Expiry,
-							"actionable": false,
+							"market":    m.Address,
+							"pt_address": m.PTAddress,
+							"actionable": deps.RPC != nil,
 						})
 					}
 				}
@@ -151,108 +197,145 @@ func createGetDefiPositionsTool(deps *ToolDeps) core.Tool {
 		Description("Get user's USDC positions across all protocols: wallet balance, Aave V3, and Morpho savings.").
 		Schema(tools.ObjectSchema(map[string]interface{}{})).
 		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
-			positions := []map[string]interface{}{}
-			walletUSDC := "0.00"
-
-			// 1. Wallet balance
-			balReq, _ := json.Marshal(map[string]interface{}{"currency": "USDC"})
-			balResp, err := deps.Executor.Execute(ctx, &core.ExecuteRequest{
-				UserID: params.UserID, Tool: "get_balance",
-				Input: balReq, RequestID: params.RequestID,
-			})
-			if err == nil && balResp.Success {
-				var balData struct {
-					Balances []struct {
-						Currency string `json:"currency"`
-						Amount   string `json:"amount"`
-					} `json:"balances"`
-					TotalUSD string `json:"totalUsd"`
-				}
-				if json.Unmarshal(balResp.Data, &balData) == nil {
-					for _, b := range balData.Balances {
-						if b.Currency == "USDC" || b.Currency == "usdc" {
-							walletUSDC = b.Amount
-						}
-					}
-					if walletUSDC == "0.00" && balData.TotalUSD != "" {
-						walletUSDC = balData.TotalUSD
-					}
-				}
+			snapshot := deps.fetchPositions(ctx, params.UserID, params.RequestID)
+			totalDeposited := snapshot.totalDeposited()
+			walletVal, _ := strconv.ParseFloat(snapshot.WalletUSDC, 64)
+
+			data := map[string]interface{}{
+				"wallet_usdc":     snapshot.WalletUSDC,
+				"positions":       snapshot.Positions,
+				"total_deposited": fmt.Sprintf("%.2f", totalDeposited),
+				"total_portfolio": fmt.Sprintf("%.2f", totalDeposited+walletVal),
+				"idle_funds":      snapshot.WalletUSDC,
+			}
+			if snapshot.AaveAccount != nil {
+				data["aave_account"] = snapshot.AaveAccount
 			}
+			return &core.ToolResult{Success: true, Data: data}, nil
+		}).
+		Build()
+}
 
-			// 2. Aave V3 (on-chain read)
-			if deps.WalletAddress != "" {
-				aaveBal, _, err := deps.Aave.GetUserBalance(ctx, deps.WalletAddress)
-				if err == nil && aaveBal != "0.00" {
-					// Get correct APY from DefiLlama
-					aaveAPY := 0.0
-					if deps.DefiLlama != nil {
-						a, _, _ := deps.DefiLlama.AaveArbitrumUSDCYield(ctx)
-						aaveAPY = math.Round(a*100) / 100
-					}
-					positions = append(positions, map[string]interface{}{
-						"protocol": "Aave V3",
-						"token":    "USDC",
-						"balance":  aaveBal,
-						"apy":      fmt.Sprintf("%.2f%%", aaveAPY),
-						"type":     "variable",
-					})
-				}
+// defiPositions is a snapshot of the user's USDC across protocols.
+type defiPositions struct {
+	WalletUSDC  string
+	Positions   []map[string]interface{} // protocol, token, balance, apy ("4.23%"), type
+	AaveAccount map[string]interface{}   // nil unless the user has Aave collateral or debt
+}
+
+// totalDeposited returns the sum of the position balances.
+func (p *defiPositions) totalDeposited() float64 {
+	total := 0.0
+	for _, pos := range p.Positions {
+		if b, ok := pos["balance"].(string); ok {
+			if v, err := strconv.ParseFloat(b, 64); err == nil {
+				total += v
 			}
+		}
+	}
+	return total
+}
 
-			// 3. Morpho savings
-			savReq, _ := json.Marshal(map[string]interface{}{})
-			savResp, err := deps.Executor.Execute(ctx, &core.ExecuteRequest{
-				UserID: params.UserID, Tool: "get_savings_balance",
-				Input: savReq, RequestID: params.RequestID,
-			})
-			if err == nil && savResp.Success {
-				var savData struct {
-					Positions []struct {
-						Currency     string `json:"currency"`
-						Deposited    string `json:"deposited"`
-						CurrentValue string `json:"currentValue"`
-						APY          string `json:"apy"`
-						Earnings     string `json:"earnings"`
-					} `json:"positions"`
-				}
-				if json.Unmarshal(savResp.Data, &savData) == nil {
-					for _, p := range savData.Positions {
-						pos := map[string]interface{}{
-							"protocol": "Morpho",
-							"token":    p.Currency,
-							"balance":  p.CurrentValue,
-							"apy":      p.APY + "%",
-							"type":     "variable",
-						}
-						if p.Earnings != "" && p.Earnings != "0" {
-							pos["earnings"] = p.Earnings
-						}
-						positions = append(positions, pos)
-					}
+// fetchPositions reads the user's wallet balance, Aave V3 position and
+// account health, and Morpho savings. Sources that fail are left out.
+func (d *ToolDeps) fetchPositions(ctx context.Context, userID, requestID string) *defiPositions {
+	positions := []map[string]interface{}{}
+	walletUSDC := "0.00"
+
+	// 1. Wallet balance
+	balReq, _ := json.Marshal(map[string]interface{}{"currency": "USDC"})
+	balResp, err := d.Executor.Execute(ctx, &core.ExecuteRequest{
+		UserID: userID, Tool: "get_balance",
+		Input: balReq, RequestID: requestID,
+	})
+	if err == nil && balResp.Success {
+		if usdc, ok := tools.ParseBalance(balResp.Data, "USDC"); ok {
+			walletUSDC = fmt.Sprintf("%.2f", usdc)
+		} else if total, ok := tools.ParseBalance(balResp.Data, "USD"); ok {
+			walletUSDC = fmt.Sprintf("%.2f", total)
+		}
+	}
+
+	// Pin the on-chain reads below to one block so balances and rates
+	// come from the same snapshot; on failure they read at latest
+	chainCtx := ctx
+	if d.WalletAddress != "" && d.RPC != nil {
+		if pinned, _, err := d.RPC.PinBlock(ctx); err == nil {
+			chainCtx = pinned
+		}
+	}
+
+	// 2. Aave V3 (on-chain read, batched via Multicall3)
+	if d.WalletAddress != "" {
+		snapshot, err := d.Aave.GetSnapshot(chainCtx, d.WalletAddress)
+		if err == nil && snapshot.Balance != "0.00" {
+			// Prefer DefiLlama APY, fall back to the on-chain rate
+			aaveAPY := snapshot.SupplyAPY
+			if d.DefiLlama != nil {
+				if a, _, err := d.DefiLlama.AaveArbitrumUSDCYield(ctx); err == nil {
+					aaveAPY = math.Round(a*100) / 100
 				}
 			}
+			positions = append(positions, map[string]interface{}{
+				"protocol": "Aave V3",
+				"token":    "USDC",
+				"balance":  snapshot.Balance,
+				"apy":      fmt.Sprintf("%.2f%%", aaveAPY),
+				"type":     "variable",
+			})
+		}
+	}
 
-			// Totals
-			totalDeposited := 0.0
-			for _, p := range positions {
-				if b, ok := p["balance"].(string); ok {
-					if v, err := strconv.ParseFloat(b, 64); err == nil {
-						totalDeposited += v
-					}
+	// Aave account health (relevant when the user also borrows)
+	var aaveAccount map[string]interface{}
+	if d.WalletAddress != "" {
+		account, err := d.Aave.GetUserAccountData(chainCtx, d.WalletAddress)
+		if err == nil && (account.HasDebt() || account.TotalCollateralUSD > 0) {
+			aaveAccount = map[string]interface{}{
+				"total_collateral_usd": fmt.Sprintf("%.2f", account.TotalCollateralUSD),
+				"total_debt_usd":       fmt.Sprintf("%.2f", account.TotalDebtUSD),
+				"health_factor":        formatHealthFactor(account.HealthFactor),
+			}
+			if account.HasDebt() && account.HealthFactor < minWithdrawHealthFactor {
+				aaveAccount["warning"] = fmt.Sprintf("Health factor is below %.1f — withdrawing collateral risks liquidation", minWithdrawHealthFactor)
+			}
+		}
+	}
+
+	// 3. Morpho savings
+	savReq, _ := json.Marshal(map[string]interface{}{})
+	savResp, err := d.Executor.Execute(ctx, &core.ExecuteRequest{
+		UserID: userID, Tool: "get_savings_balance",
+		Input: savReq, RequestID: requestID,
+	})
+	if err == nil && savResp.Success {
+		var savData struct {
+			Positions []struct {
+				Currency     string `json:"currency"`
+				Deposited    string `json:"deposited"`
+				CurrentValue string `json:"currentValue"`
+				APY          string `json:"apy"`
+				Earnings     string `json:"earnings"`
+			} `json:"positions"`
+		}
+		if json.Unmarshal(savResp.Data, &savData) == nil {
+			for _, p := range savData.Positions {
+				pos := map[string]interface{}{
+					"protocol": "Morpho",
+					"token":    p.Currency,
+					"balance":  p.CurrentValue,
+					"apy":      p.APY + "%",
+					"type":     "variable",
+				}
+				if p.Earnings != "" && p.Earnings != "0" {
+					pos["earnings"] = p.Earnings
 				}
+				positions = append(positions, pos)
 			}
-			walletVal, _ := strconv.ParseFloat(walletUSDC, 64)
+		}
+	}
 
-			return &core.ToolResult{Success: true, Data: map[string]interface{}{
-				"wallet_usdc":     walletUSDC,
-				"positions":       positions,
-				"total_deposited": fmt.Sprintf("%.2f", totalDeposited),
-				"total_portfolio": fmt.Sprintf("%.2f", totalDeposited+walletVal),
-				"idle_funds":      walletUSDC,
-			}}, nil
-		}).
-		Build()
+	return &defiPositions{WalletUSDC: walletUSDC, Positions: positions, AaveAccount: aaveAccount}
 }
 
 // ────────────────────────────────────────────────────────────────────────────
@@ -261,19 +344,39 @@ func createGetDefiPositionsTool(deps *ToolDeps) core.Tool {
 
 func createSuggestAllocationTool(deps *ToolDeps) core.Tool {
 	return tools.New("suggest_allocation").
-		Description("Suggest optimal USDC allocation across protocols based on current rates and risk preference.").
+		Description("Suggest optimal USDC allocation across protocols based on current rates and risk preference. Optionally use a named strategy or cap how much goes into fixed-rate markets or any one protocol.").
 		Schema(tools.ObjectSchema(map[string]interface{}{
-			"amount":          tools.StringProperty("Total USDC amount to allocate (e.g., '1000'). If empty, analyzes existing positions."),
-			"risk_preference": tools.StringEnumProperty("Risk tolerance", "conservative", "balanced", "aggressive"),
+			"amount":               tools.StringProperty("Total USDC amount to allocate (e.g., '1000'). If empty with compare_current, reallocates the currently deposited total."),
+			"risk_preference":      tools.StringEnumProperty("Risk tolerance", "conservative", "balanced", "aggressive"),
+			"strategy":             tools.StringProperty("Allocation strategy name (default: 'default')"),
+			"max_fixed_rate_pct":   tools.NumberProperty("Max percentage in any fixed-rate (Pendle) market, e.g. 25"),
+			"max_per_protocol_pct": tools.NumberProperty("Max percentage in any single protocol, e.g. 50"),
+			"compare_current":      tools.BooleanProperty("Compare against the user's current positions: current APY, yearly gain from rebalancing, and whether it clears the 0.5% threshold"),
 		})).
-		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
-			var params struct {
-				Amount         string `json:"amount"`
-				RiskPreference string `json:"risk_preference"`
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Amount            string  `json:"amount"`
+				RiskPreference    string  `json:"risk_preference"`
+				Strategy          string  `json:"strategy"`
+				MaxFixedRatePct   float64 `json:"max_fixed_rate_pct"`
+				MaxPerProtocolPct float64 `json:"max_per_protocol_pct"`
+				CompareCurrent    bool    `json:"compare_current"`
 			}
-			json.Unmarshal(input, &params)
-			if params.RiskPreference == "" {
-				params.RiskPreference = "balanced"
+			json.Unmarshal(params.Input, &input)
+			if input.RiskPreference == "" {
+				input.RiskPreference = "balanced"
+			}
+
+			strategy, err := deps.strategy(input.Strategy)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: err.Error()}, nil
+			}
+			constraints := Constraints{
+				MaxFixedRate:   input.MaxFixedRatePct / 100,
+				MaxPerProtocol: input.MaxPerProtocolPct / 100,
+			}
+			if !constraints.IsZero() {
+				strategy = ConstrainedStrategy{Base: strategy, Constraints: constraints}
 			}
 
 			// Get rates from DefiLlama (reliable)
@@ -304,6 +407,11 @@ func createSuggestAllocationTool(deps *ToolDeps) core.Tool {
 				}
 			}
 
+			compoundAPY := 0.0
+			if deps.Compound != nil {
+				compoundAPY, _ = deps.Compound.GetSupplyAPY(ctx)
+			}
+
 			// Get Pendle best fixed rate
 			pendleAPY := 0.0
 			pendleName := ""
@@ -319,95 +427,64 @@ func createSuggestAllocationTool(deps *ToolDeps) core.Tool {
 				}
 			}
 
-			totalAmount, _ := strconv.ParseFloat(params.Amount, 64)
+			totalAmount, _ := strconv.ParseFloat(input.Amount, 64)
+
+			var current *defiPositions
+			if input.CompareCurrent {
+				current = deps.fetchPositions(ctx, params.UserID, params.RequestID)
+				if totalAmount == 0 {
+					totalAmount = current.totalDeposited()
+				}
+			}
 
-			return buildAllocation(aaveAPY, morphoAPY, pendleAPY, pendleName, totalAmount, params.RiskPreference), nil
+			rates := Rates{Aave: aaveAPY, Morpho: morphoAPY, Compound: compoundAPY, Pendle: pendleAPY, PendleName: pendleName}
+			slots := strategy.Allocate(rates, input.RiskPreference)
+			result := buildAllocation(strategy.Name(), slots, totalAmount, input.RiskPreference)
+			if current != nil {
+				result["comparison"] = deps.compareWithCurrent(ctx, current, slots, totalAmount)
+			}
+			return &core.ToolResult{Success: true, Data: result}, nil
 		}).
 		Build()
 }
 
-func buildAllocation(aaveAPY, morphoAPY, pendleAPY float64, pendleName string, total float64, risk string) map[string]interface{} {
-	type slot struct {
-		name string
-		apy  float64
-		pct  float64
-		kind string // "variable" or "fixed"
-	}
-
-	var slots []slot
-
-	switch risk {
-	case "conservative":
-		// Split between Aave + Morpho, skip Pendle
-		if morphoAPY > aaveAPY {
-			slots = append(slots, slot{"Morpho", morphoAPY, 0.60, "variable"})
-			slots = append(slots, slot{"Aave V3", aaveAPY, 0.40, "variable"})
-		} else {
-			slots = append(slots, slot{"Aave V3", aaveAPY, 0.60, "variable"})
-			slots = append(slots, slot{"Morpho", morphoAPY, 0.40, "variable"})
-		}
-	case "aggressive":
-		// All-in on highest yield (including Pendle)
-		best := aaveAPY
-		bestName := "Aave V3"
-		bestKind := "variable"
-		if morphoAPY > best {
-			best = morphoAPY
-			bestName = "Morpho"
-		}
-		if pendleAPY > best && pendleName != "" {
-			best = pendleAPY
-			bestName = "Pendle " + pendleName
-			bestKind = "fixed"
-		}
-		slots = append(slots, slot{bestName, best, 1.0, bestKind})
-	default: // balanced
-		// Mix variable + fixed if Pendle offers significantly more
-		if pendleAPY > aaveAPY*1.5 && pendleName != "" {
-			slots = append(slots, slot{"Pendle " + pendleName, pendleAPY, 0.40, "fixed"})
-			if morphoAPY > aaveAPY {
-				slots = append(slots, slot{"Morpho", morphoAPY, 0.35, "variable"})
-				slots = append(slots, slot{"Aave V3", aaveAPY, 0.25, "variable"})
-			} else {
-				slots = append(slots, slot{"Aave V3", aaveAPY, 0.35, "variable"})
-				slots = append(slots, slot{"Morpho", morphoAPY, 0.25, "variable"})
-			}
-		} else if morphoAPY > aaveAPY {
-			slots = append(slots, slot{"Morpho", morphoAPY, 0.65, "variable"})
-			slots = append(slots, slot{"Aave V3", aaveAPY, 0.35, "variable"})
-		} else {
-			slots = append(slots, slot{"Aave V3", aaveAPY, 0.65, "variable"})
-			slots = append(slots, slot{"Morpho", morphoAPY, 0.35, "variable"})
-		}
-	}
-
+// buildAllocation formats a strategy's allocation of total.
+func buildAllocation(strategy string, slots []Slot, total float64, risk string) map[string]interface{} {
 	suggestions := []map[string]interface{}{}
 	blendedAPY := 0.0
 	totalProjected := 0.0
+	allocated := 0.0
 
 	for _, s := range slots {
 		entry := map[string]interface{}{
-			"protocol":   s.name,
-			"apy":        fmt.Sprintf("%.2f", s.apy),
-			"allocation": fmt.Sprintf("%.0f%%", s.pct*100),
-			"type":       s.kind,
+			"protocol":   s.Protocol,
+			"apy":        fmt.Sprintf("%.2f", s.APY),
+			"allocation": fmt.Sprintf("%.0f%%", s.Weight*100),
+			"type":       s.Kind,
+			"actionable": s.Actionable,
 		}
 		if total > 0 {
-			amt := total * s.pct
-			yearly := amt * s.apy / 100
+			amt := total * s.Weight
+			yearly := amt * s.APY / 100
 			entry["amount"] = fmt.Sprintf("%.2f", amt)
 			entry["projected_yearly"] = fmt.Sprintf("%.2f", yearly)
 			totalProjected += yearly
 		}
-		blendedAPY += s.apy * s.pct
+		blendedAPY += s.APY * s.Weight
+		allocated += s.Weight
 		suggestions = append(suggestions, entry)
 	}
 
 	result := map[string]interface{}{
 		"risk":        risk,
+		"strategy":    strategy,
 		"suggestions": suggestions,
 		"blended_apy": fmt.Sprintf("%.2f", blendedAPY),
 	}
+	// Constraints can leave part of the funds with nowhere to go
+	if unallocated := 1 - allocated; unallocated > 0.005 {
+		result["unallocated"] = fmt.Sprintf("%.0f%%", unallocated*100)
+	}
 	if total > 0 {
 		result["total_amount"] = fmt.Sprintf("%.2f", total)
 		result["projected_yearly"] = fmt.Sprintf("%.2f", totalProjected)
@@ -489,7 +566,7 @@ func createDepositAaveTool(deps *ToolDeps) core.Tool {
 			if resp.RequiresConfirmation {
 				return &core.ToolResult{Success: true, Data: map[string]interface{}{
 					"status":  "pending_confirmation",
-					"summary": fmt.Sprintf("Deposit %s USDC into Aave V3", input.Amount),
+					"summary": fmt.Sprintf("Deposit %s USDC into Aave V3", input.Amount) + deps.gasNote(ctx, deps.depositTxPlan, params.Input),
 				}}, nil
 			}
 			return &core.ToolResult{Success: true, Data: map[string]interface{}{
@@ -505,16 +582,18 @@ func createDepositAaveTool(deps *ToolDeps) core.Tool {
 
 func createWithdrawAaveTool(deps *ToolDeps) core.Tool {
 	return tools.New("withdraw_aave").
-		Description("Withdraw USDC from Aave V3 on Arbitrum. Use 'max' to withdraw everything. Requires confirmation.").
+		Description("Withdraw USDC from Aave V3 on Arbitrum. Use 'max' to withdraw everything. Requires confirmation. Refuses withdrawals that would push a borrowing user's health factor too low.").
 		Schema(tools.BuildSchemaWithThought(map[string]interface{}{
-			"amount": tools.StringProperty("USDC amount to withdraw (e.g., '100.00' or 'max')"),
+			"amount":                   tools.StringProperty("USDC amount to withdraw (e.g., '100.00' or 'max')"),
+			"accept_low_health_factor": tools.BooleanProperty("Set only after the user explicitly accepts a health factor below the safe threshold"),
 		}, true, "amount")).
 		RequiresConfirmation().
 		SummaryTemplate("Withdraw {{.amount}} USDC from Aave V3").
 		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
 			var input struct {
-				Amount  string `json:"amount"`
-				Thought string `json:"thought"`
+				Amount                string `json:"amount"`
+				AcceptLowHealthFactor bool   `json:"accept_low_health_factor"`
+				Thought               string `json:"thought"`
 			}
 			if err := json.Unmarshal(params.Input, &input); err != nil {
 				return &core.ToolResult{Success: false, Error: "invalid input"}, nil
@@ -525,15 +604,14 @@ func createWithdrawAaveTool(deps *ToolDeps) core.Tool {
 				return &core.ToolResult{Success: false, Error: "wallet address not configured"}, nil
 			}
 
-			var amountWei *big.Int
-			if input.Amount == "max" || input.Amount == "all" {
-				amountWei = defi.MaxUint256
-			} else {
-				var err error
-				amountWei, err = defi.ParseUSDCAmount(input.Amount)
-				if err != nil {
-					return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid amount: %v", err)}, nil
-				}
+			amountWei, err := parseWithdrawAmount(input.Amount)
+			if err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid amount: %v", err)}, nil
+			}
+
+			// Health factor check for users borrowing against their deposit
+			if msg := deps.checkWithdrawHealth(ctx, amountWei, input.AcceptLowHealthFactor); msg != "" {
+				return &core.ToolResult{Success: false, Error: msg}, nil
 			}
 
 			withdrawData := defi.EncodeAaveWithdraw(defi.USDC, amountWei, walletAddr)
@@ -556,7 +634,7 @@ func createWithdrawAaveTool(deps *ToolDeps) core.Tool {
 			if resp.RequiresConfirmation {
 				return &core.ToolResult{Success: true, Data: map[string]interface{}{
 					"status":  "pending_confirmation",
-					"summary": fmt.Sprintf("Withdraw %s USDC from Aave V3", input.Amount),
+					"summary": fmt.Sprintf("Withdraw %s USDC from Aave V3", input.Amount) + deps.gasNote(ctx, deps.withdrawTxPlan, params.Input),
 				}}, nil
 			}
 			return &core.ToolResult{Success: true, Data: map[string]interface{}{
@@ -570,6 +648,48 @@ func createWithdrawAaveTool(deps *ToolDeps) core.Tool {
 // helpers
 // ────────────────────────────────────────────────────────────────────────────
 
+// minWithdrawHealthFactor is the health factor below which withdrawals need
+// explicit user acceptance. Below 1.0 the position can be liquidated.
+const minWithdrawHealthFactor = 1.5
+
+// checkWithdrawHealth returns a refusal message if withdrawing amountWei would
+// leave a borrowing user's health factor too low, or "" if it is safe.
+// If account data can't be read the withdrawal proceeds; Aave itself rejects
+// withdrawals that would make the position liquidatable.
+func (d *ToolDeps) checkWithdrawHealth(ctx context.Context, amountWei *big.Int, accepted bool) string {
+	account, err := d.Aave.GetUserAccountData(ctx, d.WalletAddress)
+	if err != nil || !account.HasDebt() {
+		return ""
+	}
+
+	if amountWei.Cmp(defi.MaxUint256) == 0 {
+		_, balance, err := d.Aave.GetUserBalance(ctx, d.WalletAddress)
+		if err != nil {
+			return ""
+		}
+		amountWei = balance
+	}
+	amountUSD, _ := strconv.ParseFloat(defi.FormatUSDCAmount(amountWei), 64)
+
+	projected := account.HealthFactorAfterWithdraw(amountUSD)
+	switch {
+	case projected < 1.0:
+		return fmt.Sprintf("withdrawal refused: health factor would drop from %s to %s, making the position liquidatable. Repay debt or withdraw less.",
+			formatHealthFactor(account.HealthFactor), formatHealthFactor(projected))
+	case projected < minWithdrawHealthFactor && !accepted:
+		return fmt.Sprintf("withdrawal needs explicit approval: health factor would drop from %s to %s (below %.1f). Explain the liquidation risk to the user and retry with accept_low_health_factor only if they agree.",
+			formatHealthFactor(account.HealthFactor), formatHealthFactor(projected), minWithdrawHealthFactor)
+	}
+	return ""
+}
+
+func formatHealthFactor(hf float64) string {
+	if math.IsInf(hf, 1) {
+		return "∞"
+	}
+	return fmt.Sprintf("%.2f", hf)
+}
+
 func formatTVL(tvl float64) string {
 	if tvl >= 1e9 {
 		return fmt.Sprintf("$%.1fB", tvl/1e9)
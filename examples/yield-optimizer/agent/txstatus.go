@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/examples/yield-optimizer/defi"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// chainTxStatus checks on-chain transactions by their receipt.
+type chainTxStatus struct {
+	rpc *defi.RPCClient
+}
+
+// NewChainTxStatus returns a tools.TxStatusChecker that reads the receipts of
+// transactions with a hash from the chain, so a reverted contract call is
+// reported as reverted. References without a hash return tools.ErrTxNotFound.
+func NewChainTxStatus(rpc *defi.RPCClient) tools.TxStatusChecker {
+	return &chainTxStatus{rpc: rpc}
+}
+
+// TxStatus implements tools.TxStatusChecker.
+func (c *chainTxStatus) TxStatus(ctx context.Context, userID string, ref tools.TxRef) (core.TxStatus, error) {
+	if ref.TxHash == "" {
+		return "", tools.ErrTxNotFound
+	}
+	mined, succeeded, err := c.rpc.TransactionReceiptStatus(ctx, ref.TxHash)
+	switch {
+	case err != nil:
+		return "", err
+	case !mined:
+		return core.TxPending, nil
+	case succeeded:
+		return core.TxConfirmed, nil
+	default:
+		return core.TxReverted, nil
+	}
+}
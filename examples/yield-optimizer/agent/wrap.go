@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// toolWrapper embeds a tool so a wrapper can override some of its methods.
+// Embedding core.Tool alone would hide the optional interfaces the engine
+// checks for, so toolWrapper forwards them to the wrapped tool.
+type toolWrapper struct {
+	core.Tool
+}
+
+// RequiresThought forwards core.ThoughtRequirer.
+func (w toolWrapper) RequiresThought() bool {
+	tr, ok := w.Tool.(core.ThoughtRequirer)
+	return ok && tr.RequiresThought()
+}
+
+// SummaryKey forwards core.SummaryKeyer.
+func (w toolWrapper) SummaryKey() string {
+	if sk, ok := w.Tool.(core.SummaryKeyer); ok {
+		return sk.SummaryKey()
+	}
+	return ""
+}
+
+// IdempotencyInput forwards core.IdempotencyKeyer. Tools without one are
+// keyed on their full input, as the engine does.
+func (w toolWrapper) IdempotencyInput(input json.RawMessage) json.RawMessage {
+	if ik, ok := w.Tool.(core.IdempotencyKeyer); ok {
+		return ik.IdempotencyInput(input)
+	}
+	return input
+}
+
+// baseSummary returns the wrapped tool's confirmation summary as the engine
+// would build it: context-aware if the tool supports it, else localized.
+func (w toolWrapper) baseSummary(ctx context.Context, userID string, input json.RawMessage) string {
+	if cs, ok := w.Tool.(core.ContextSummarizer); ok {
+		return cs.GetSummaryContext(ctx, userID, input)
+	}
+	return core.LocalizedSummary(ctx, w.Tool, input)
+}
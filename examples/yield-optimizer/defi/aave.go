@@ -25,6 +25,11 @@ func (a *AaveClient) GetSupplyAPY(ctx context.Context) (float64, error) {
 		return 0, fmt.Errorf("getReserveData call failed: %w", err)
 	}
 
+	return decodeReserveAPY(result)
+}
+
+// decodeReserveAPY extracts the supply APY from getReserveData output.
+func decodeReserveAPY(result []byte) (float64, error) {
 	// getReserveData returns a ReserveData struct. The fields are ABI-encoded as:
 	// [0]  (32 bytes) configuration (ReserveConfigurationMap)
 	// [1]  (32 bytes) liquidityIndex (uint128)
@@ -76,6 +81,113 @@ func (a *AaveClient) GetAllowance(ctx context.Context, owner, spender string) (*
 	return decodeUint256(result[:32]), nil
 }
 
+// AaveAccountData is a user's aggregate Aave V3 position across all reserves.
+type AaveAccountData struct {
+	TotalCollateralUSD   float64
+	TotalDebtUSD         float64
+	AvailableBorrowsUSD  float64
+	LiquidationThreshold float64 // Weighted average, as a percentage (e.g., 78.5)
+	LTV                  float64 // Weighted average, as a percentage
+	HealthFactor         float64 // +Inf when the user has no debt
+}
+
+// HasDebt reports whether the user is borrowing.
+func (d *AaveAccountData) HasDebt() bool {
+	return d.TotalDebtUSD > 0
+}
+
+// HealthFactorAfterWithdraw estimates the health factor after removing
+// amountUSD of collateral. Returns +Inf when the user has no debt.
+func (d *AaveAccountData) HealthFactorAfterWithdraw(amountUSD float64) float64 {
+	if !d.HasDebt() {
+		return math.Inf(1)
+	}
+	remaining := math.Max(d.TotalCollateralUSD-amountUSD, 0)
+	return remaining * (d.LiquidationThreshold / 100) / d.TotalDebtUSD
+}
+
+// GetUserAccountData reads the user's collateral, debt and health factor from the Pool.
+func (a *AaveClient) GetUserAccountData(ctx context.Context, userAddress string) (*AaveAccountData, error) {
+	result, err := a.rpc.EthCall(ctx, AaveV3Pool, EncodeGetUserAccountData(userAddress))
+	if err != nil {
+		return nil, fmt.Errorf("getUserAccountData call failed: %w", err)
+	}
+	return decodeUserAccountData(result)
+}
+
+// decodeUserAccountData decodes getUserAccountData output:
+// [0] totalCollateralBase, [1] totalDebtBase, [2] availableBorrowsBase
+// (base currency, 8 decimals), [3] currentLiquidationThreshold, [4] ltv
+// (basis points), [5] healthFactor (WAD; max uint256 when there is no debt).
+func decodeUserAccountData(result []byte) (*AaveAccountData, error) {
+	if len(result) < 192 {
+		return nil, fmt.Errorf("unexpected response length: %d bytes (need at least 192)", len(result))
+	}
+
+	word := func(i int) *big.Int { return decodeUint256(result[i*32 : (i+1)*32]) }
+
+	data := &AaveAccountData{
+		TotalCollateralUSD:   scaleDown(word(0), AaveBaseCurrencyDecimals),
+		TotalDebtUSD:         scaleDown(word(1), AaveBaseCurrencyDecimals),
+		AvailableBorrowsUSD:  scaleDown(word(2), AaveBaseCurrencyDecimals),
+		LiquidationThreshold: scaleDown(word(3), 2),
+		LTV:                  scaleDown(word(4), 2),
+	}
+
+	if data.HasDebt() {
+		data.HealthFactor = scaleDown(word(5), WadDecimals)
+	} else {
+		data.HealthFactor = math.Inf(1)
+	}
+	return data, nil
+}
+
+// scaleDown converts a fixed-point integer with the given decimals to a float.
+func scaleDown(n *big.Int, decimals int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(n), new(big.Float).SetFloat64(math.Pow10(decimals))).Float64()
+	return f
+}
+
+// AaveSnapshot is a consistent view of a user's Aave V3 USDC state.
+type AaveSnapshot struct {
+	SupplyAPY  float64  // Current USDC supply APY as a percentage
+	Balance    string   // aUSDC balance, formatted (e.g., "1234.56")
+	BalanceRaw *big.Int // aUSDC balance in base units
+	Allowance  *big.Int // USDC allowance granted to the Aave V3 Pool
+}
+
+// GetSnapshot reads the USDC reserve data, the user's aUSDC balance, and the
+// user's USDC allowance to the Pool in a single Multicall3 round trip.
+func (a *AaveClient) GetSnapshot(ctx context.Context, userAddress string) (*AaveSnapshot, error) {
+	results, err := a.rpc.MulticallEthCall(ctx, []Call{
+		{Target: AaveV3Pool, CallData: EncodeGetReserveData(USDC)},
+		{Target: AaveAUSDC, CallData: EncodeBalanceOf(userAddress)},
+		{Target: USDC, CallData: EncodeAllowance(userAddress, AaveV3Pool)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aave snapshot failed: %w", err)
+	}
+
+	apy, err := decodeReserveAPY(results[0])
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &AaveSnapshot{
+		SupplyAPY:  apy,
+		BalanceRaw: big.NewInt(0),
+		Allowance:  big.NewInt(0),
+	}
+	if len(results[1]) >= 32 {
+		snapshot.BalanceRaw = decodeUint256(results[1][:32])
+	}
+	if len(results[2]) >= 32 {
+		snapshot.Allowance = decodeUint256(results[2][:32])
+	}
+	snapshot.Balance = FormatUSDCAmount(snapshot.BalanceRaw)
+	return snapshot, nil
+}
+
 // rayToAPY converts an Aave RAY rate (1e27) to an annual percentage yield.
 // The liquidityRate is a per-second rate in RAY, compounded over a year.
 // For simplicity we use the linear approximation: APY ≈ rate * SECONDS_PER_YEAR / 1e27 * 100
@@ -0,0 +1,104 @@
+package defi
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// accountDataResult builds getUserAccountData returndata from its six words.
+func accountDataResult(words ...*big.Int) []byte {
+	var out []byte
+	for _, w := range words {
+		out = append(out, encodeUint256(w)...)
+	}
+	return out
+}
+
+func base(usd int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(usd), big.NewInt(1e8))
+}
+
+func TestDecodeUserAccountData(t *testing.T) {
+	hf156, _ := new(big.Int).SetString("1560000000000000000", 10)
+
+	tests := []struct {
+		name    string
+		result  []byte
+		want    AaveAccountData
+		wantErr bool
+	}{
+		{
+			name:   "borrowing user",
+			result: accountDataResult(base(10000), base(5000), base(2500), big.NewInt(7800), big.NewInt(7500), hf156),
+			want: AaveAccountData{
+				TotalCollateralUSD:   10000,
+				TotalDebtUSD:         5000,
+				AvailableBorrowsUSD:  2500,
+				LiquidationThreshold: 78,
+				LTV:                  75,
+				HealthFactor:         1.56,
+			},
+		},
+		{
+			name:   "supply only",
+			result: accountDataResult(base(1234), big.NewInt(0), base(925), big.NewInt(7800), big.NewInt(7500), MaxUint256),
+			want: AaveAccountData{
+				TotalCollateralUSD:   1234,
+				AvailableBorrowsUSD:  925,
+				LiquidationThreshold: 78,
+				LTV:                  75,
+				HealthFactor:         math.Inf(1),
+			},
+		},
+		{
+			name:    "short response",
+			result:  accountDataResult(base(1), base(1)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeUserAccountData(tt.result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeUserAccountData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got != tt.want {
+				t.Errorf("decodeUserAccountData() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthFactorAfterWithdraw(t *testing.T) {
+	account := &AaveAccountData{
+		TotalCollateralUSD:   10000,
+		TotalDebtUSD:         5000,
+		LiquidationThreshold: 80,
+		HealthFactor:         1.6,
+	}
+
+	tests := []struct {
+		amount float64
+		want   float64
+	}{
+		{0, 1.6},
+		{2500, 1.2},
+		{5000, 0.8},
+		{20000, 0},
+	}
+	for _, tt := range tests {
+		if got := account.HealthFactorAfterWithdraw(tt.amount); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("HealthFactorAfterWithdraw(%v) = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+
+	noDebt := &AaveAccountData{TotalCollateralUSD: 100, LiquidationThreshold: 80}
+	if got := noDebt.HealthFactorAfterWithdraw(100); !math.IsInf(got, 1) {
+		t.Errorf("HealthFactorAfterWithdraw() without debt = %v, want +Inf", got)
+	}
+}
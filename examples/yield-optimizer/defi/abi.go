@@ -14,10 +14,17 @@ var (
 	SelectorSupply         = mustDecodeHex("617ba037") // supply(address,uint256,address,uint16)
 	SelectorWithdraw       = mustDecodeHex("69328dec") // withdraw(address,uint256,address)
 
+	SelectorGetUserAccountData = mustDecodeHex("bf92857c") // getUserAccountData(address)
+
+	// Compound V3 (Comet)
+	SelectorGetUtilization = mustDecodeHex("7eb71131") // getUtilization()
+	SelectorGetSupplyRate  = mustDecodeHex("d955759d") // getSupplyRate(uint256)
+
 	// ERC20
 	SelectorBalanceOf = mustDecodeHex("70a08231") // balanceOf(address)
 	SelectorApprove   = mustDecodeHex("095ea7b3") // approve(address,uint256)
 	SelectorAllowance = mustDecodeHex("dd62ed3e") // allowance(address,address)
+	SelectorDecimals  = mustDecodeHex("313ce567") // decimals()
 
 	// MaxUint256 for unlimited approval
 	MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
@@ -87,6 +94,11 @@ func EncodeApprove(spender string, amount *big.Int) []byte {
 	return data
 }
 
+// EncodeDecimals encodes an ERC20 decimals() call.
+func EncodeDecimals() []byte {
+	return append([]byte{}, SelectorDecimals...)
+}
+
 // EncodeGetReserveData builds calldata for Pool.getReserveData(address asset).
 func EncodeGetReserveData(asset string) []byte {
 	data := make([]byte, 0, 4+32)
@@ -95,6 +107,27 @@ func EncodeGetReserveData(asset string) []byte {
 	return data
 }
 
+// EncodeGetUserAccountData builds calldata for Pool.getUserAccountData(address user).
+func EncodeGetUserAccountData(user string) []byte {
+	data := make([]byte, 0, 4+32)
+	data = append(data, SelectorGetUserAccountData...)
+	data = append(data, encodeAddress(user)...)
+	return data
+}
+
+// EncodeGetUtilization builds calldata for Comet.getUtilization().
+func EncodeGetUtilization() []byte {
+	return append([]byte{}, SelectorGetUtilization...)
+}
+
+// EncodeGetSupplyRate builds calldata for Comet.getSupplyRate(uint256 utilization).
+func EncodeGetSupplyRate(utilization *big.Int) []byte {
+	data := make([]byte, 0, 4+32)
+	data = append(data, SelectorGetSupplyRate...)
+	data = append(data, encodeUint256(utilization)...)
+	return data
+}
+
 // EncodeAaveSupply builds calldata for Pool.supply(asset, amount, onBehalfOf, referralCode).
 func EncodeAaveSupply(asset string, amount *big.Int, onBehalfOf string) []byte {
 	data := make([]byte, 0, 4+128)
@@ -120,43 +153,3 @@ func EncodeAaveWithdraw(asset string, amount *big.Int, to string) []byte {
 func HexEncode(data []byte) string {
 	return "0x" + hex.EncodeToString(data)
 }
-
-// ParseUSDCAmount converts a human-readable USDC amount (e.g., "100.50") to its
-// on-chain representation (6 decimals). Returns the value in base units.
-func ParseUSDCAmount(amount string) (*big.Int, error) {
-	// Handle the decimal by splitting and reconstructing
-	parts := strings.Split(amount, ".")
-	whole := parts[0]
-	frac := ""
-	if len(parts) == 2 {
-		frac = parts[1]
-	}
-
-	// Pad or truncate fractional part to 6 decimals
-	for len(frac) < USDCDecimals {
-		frac += "0"
-	}
-	frac = frac[:USDCDecimals]
-
-	combined := whole + frac
-	result, ok := new(big.Int).SetString(combined, 10)
-	if !ok {
-		return nil, fmt.Errorf("invalid USDC amount: %s", amount)
-	}
-	return result, nil
-}
-
-// FormatUSDCAmount converts on-chain USDC units (6 decimals) to human-readable format.
-func FormatUSDCAmount(amount *big.Int) string {
-	if amount == nil {
-		return "0.00"
-	}
-
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(USDCDecimals), nil)
-	whole := new(big.Int).Div(amount, divisor)
-	remainder := new(big.Int).Mod(amount, divisor)
-
-	// Pad remainder to 6 digits, then take first 2 for display
-	fracStr := fmt.Sprintf("%06d", remainder.Int64())
-	return fmt.Sprintf("%s.%s", whole.String(), fracStr[:2])
-}
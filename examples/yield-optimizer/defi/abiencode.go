@@ -0,0 +1,127 @@
+package defi
+
+import (
+	"math/big"
+)
+
+// ABIArg is a single argument for the general ABI encoder.
+// Use the ABI* constructors to build arguments.
+type ABIArg interface {
+	// dynamic reports whether the type is encoded in the tail with an offset in the head.
+	dynamic() bool
+	// encode returns the argument's ABI encoding (the tail for dynamic types).
+	encode() []byte
+}
+
+// EncodeCall builds calldata for a function call: the 4-byte selector followed
+// by the ABI encoding of args. It supports dynamic types (bytes, string, T[],
+// tuples containing them) with standard head/tail offset encoding.
+//
+// The hand-rolled Encode* helpers remain the fast path for fixed calls.
+func EncodeCall(selector []byte, args ...ABIArg) []byte {
+	data := make([]byte, 0, 4+32*len(args))
+	data = append(data, selector...)
+	return append(data, encodeArgs(args)...)
+}
+
+// encodeArgs encodes a sequence of values as a tuple: static values inline in
+// the head, dynamic values as offsets (relative to the start of the head)
+// into the tail.
+func encodeArgs(args []ABIArg) []byte {
+	headSize := 0
+	for _, a := range args {
+		if a.dynamic() {
+			headSize += 32
+		} else {
+			headSize += len(a.encode())
+		}
+	}
+
+	head := make([]byte, 0, headSize)
+	var tail []byte
+	for _, a := range args {
+		if a.dynamic() {
+			head = append(head, encodeUint256(big.NewInt(int64(headSize+len(tail))))...)
+			tail = append(tail, a.encode()...)
+		} else {
+			head = append(head, a.encode()...)
+		}
+	}
+	return append(head, tail...)
+}
+
+type abiStatic []byte
+
+func (a abiStatic) dynamic() bool  { return false }
+func (a abiStatic) encode() []byte { return a }
+
+// ABIAddress encodes an address.
+func ABIAddress(addr string) ABIArg {
+	return abiStatic(encodeAddress(addr))
+}
+
+// ABIUint256 encodes an unsigned integer of any width up to 256 bits.
+func ABIUint256(n *big.Int) ABIArg {
+	return abiStatic(encodeUint256(n))
+}
+
+// ABIUint encodes a small unsigned integer (uint8 through uint64).
+func ABIUint(n uint64) ABIArg {
+	return abiStatic(encodeUint256(new(big.Int).SetUint64(n)))
+}
+
+// ABIBool encodes a bool.
+func ABIBool(b bool) ABIArg {
+	return abiStatic(encodeBool(b))
+}
+
+// ABIFixedBytes encodes a bytesN value (N <= 32), right-padded.
+func ABIFixedBytes(b []byte) ABIArg {
+	padded := make([]byte, 32)
+	copy(padded, b)
+	return abiStatic(padded)
+}
+
+type abiBytes []byte
+
+func (a abiBytes) dynamic() bool  { return true }
+func (a abiBytes) encode() []byte { return encodeBytes(a) }
+
+// ABIBytes encodes a dynamic bytes value.
+func ABIBytes(b []byte) ABIArg {
+	return abiBytes(b)
+}
+
+// ABIString encodes a string.
+func ABIString(s string) ABIArg {
+	return abiBytes(s)
+}
+
+type abiArray []ABIArg
+
+func (a abiArray) dynamic() bool { return true }
+func (a abiArray) encode() []byte {
+	return append(encodeUint256(big.NewInt(int64(len(a)))), encodeArgs(a)...)
+}
+
+// ABIArray encodes a dynamic array T[]. All elements should have the same type.
+func ABIArray(elems ...ABIArg) ABIArg {
+	return abiArray(elems)
+}
+
+type abiTuple []ABIArg
+
+func (t abiTuple) dynamic() bool {
+	for _, f := range t {
+		if f.dynamic() {
+			return true
+		}
+	}
+	return false
+}
+func (t abiTuple) encode() []byte { return encodeArgs(t) }
+
+// ABITuple encodes a tuple (struct). It is dynamic if any field is dynamic.
+func ABITuple(fields ...ABIArg) ABIArg {
+	return abiTuple(fields)
+}
@@ -0,0 +1,122 @@
+package defi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	s = strings.Join(strings.Fields(s), "")
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		t.Fatalf("bad hex: %v", err)
+	}
+	return b
+}
+
+func TestEncodeCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		args     []ABIArg
+		want     string
+	}{
+		{
+			// f(uint256,uint32[],bytes10,bytes) from the Solidity ABI specification
+			name:     "static and dynamic arguments",
+			selector: "8be65246",
+			args: []ABIArg{
+				ABIUint(0x123),
+				ABIArray(ABIUint(0x456), ABIUint(0x789)),
+				ABIFixedBytes([]byte("1234567890")),
+				ABIString("Hello, world!"),
+			},
+			want: `0x8be65246
+				0000000000000000000000000000000000000000000000000000000000000123
+				0000000000000000000000000000000000000000000000000000000000000080
+				3132333435363738393000000000000000000000000000000000000000000000
+				00000000000000000000000000000000000000000000000000000000000000e0
+				0000000000000000000000000000000000000000000000000000000000000002
+				0000000000000000000000000000000000000000000000000000000000000456
+				0000000000000000000000000000000000000000000000000000000000000789
+				000000000000000000000000000000000000000000000000000000000000000d
+				48656c6c6f2c20776f726c642100000000000000000000000000000000000000`,
+		},
+		{
+			// g(uint256[][],string[]) from the Solidity ABI specification
+			name:     "nested dynamic arrays",
+			selector: "2289b18c",
+			args: []ABIArg{
+				ABIArray(
+					ABIArray(ABIUint(1), ABIUint(2)),
+					ABIArray(ABIUint(3)),
+				),
+				ABIArray(ABIString("one"), ABIString("two"), ABIString("three")),
+			},
+			want: `0x2289b18c
+				0000000000000000000000000000000000000000000000000000000000000040
+				0000000000000000000000000000000000000000000000000000000000000140
+				0000000000000000000000000000000000000000000000000000000000000002
+				0000000000000000000000000000000000000000000000000000000000000040
+				00000000000000000000000000000000000000000000000000000000000000a0
+				0000000000000000000000000000000000000000000000000000000000000002
+				0000000000000000000000000000000000000000000000000000000000000001
+				0000000000000000000000000000000000000000000000000000000000000002
+				0000000000000000000000000000000000000000000000000000000000000001
+				0000000000000000000000000000000000000000000000000000000000000003
+				0000000000000000000000000000000000000000000000000000000000000003
+				0000000000000000000000000000000000000000000000000000000000000060
+				00000000000000000000000000000000000000000000000000000000000000a0
+				00000000000000000000000000000000000000000000000000000000000000e0
+				0000000000000000000000000000000000000000000000000000000000000003
+				6f6e650000000000000000000000000000000000000000000000000000000000
+				0000000000000000000000000000000000000000000000000000000000000003
+				74776f0000000000000000000000000000000000000000000000000000000000
+				0000000000000000000000000000000000000000000000000000000000000005
+				7468726565000000000000000000000000000000000000000000000000000000`,
+		},
+		{
+			name:     "static arguments match hand-rolled encoder",
+			selector: "617ba037",
+			args: []ABIArg{
+				ABIAddress(USDC),
+				ABIUint256(big.NewInt(100_000_000)),
+				ABIAddress(AaveV3Pool),
+				ABIUint(0),
+			},
+			want: HexEncode(EncodeAaveSupply(USDC, big.NewInt(100_000_000), AaveV3Pool)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodeCall(mustHex(t, tt.selector), tt.args...)
+			want := mustHex(t, tt.want)
+			if !bytes.Equal(got, want) {
+				t.Errorf("EncodeCall() =\n%x\nwant\n%x", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeCallMatchesAggregate3(t *testing.T) {
+	calls := []Call{
+		{Target: AaveV3Pool, CallData: EncodeGetReserveData(USDC)},
+		{Target: USDC, CallData: EncodeAllowance(AaveV3Pool, AaveAUSDC), AllowFailure: true},
+	}
+
+	var tuples []ABIArg
+	for _, c := range calls {
+		tuples = append(tuples, ABITuple(ABIAddress(c.Target), ABIBool(c.AllowFailure), ABIBytes(c.CallData)))
+	}
+
+	got := EncodeCall(SelectorAggregate3, ABIArray(tuples...))
+	want := EncodeAggregate3(calls)
+	if !bytes.Equal(got, want) {
+		t.Errorf("EncodeCall() =\n%x\nwant\n%x", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package defi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// tokenDecimals maps token symbols to their on-chain decimals.
+var (
+	tokenDecimalsMu sync.RWMutex
+	tokenDecimals   = map[string]int{
+		"USDC": USDCDecimals,
+		"EURC": 6,
+	}
+)
+
+// RegisterToken records the decimals of a token symbol, e.g. for a token the
+// agent starts supporting. Symbols are case-insensitive.
+func RegisterToken(symbol string, decimals int) {
+	tokenDecimalsMu.Lock()
+	defer tokenDecimalsMu.Unlock()
+	tokenDecimals[strings.ToUpper(symbol)] = decimals
+}
+
+// TokenDecimals returns the decimals of a registered token symbol.
+func TokenDecimals(symbol string) (int, bool) {
+	tokenDecimalsMu.RLock()
+	defer tokenDecimalsMu.RUnlock()
+	decimals, ok := tokenDecimals[strings.ToUpper(symbol)]
+	return decimals, ok
+}
+
+// ParseTokenAmount converts a human-readable amount (e.g. "100.50") to base
+// units of a token with the given decimals. The amount must be a
+// non-negative decimal number: digits with an optional fractional part, and
+// no more significant fractional digits than the token has.
+func ParseTokenAmount(amount string, decimals int) (*big.Int, error) {
+	if decimals < 0 {
+		return nil, fmt.Errorf("invalid decimals: %d", decimals)
+	}
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return nil, errors.New("empty amount")
+	}
+	if strings.HasPrefix(amount, "-") {
+		return nil, fmt.Errorf("negative amount: %s", amount)
+	}
+
+	whole, frac, hasDot := strings.Cut(amount, ".")
+	switch {
+	case whole == "":
+		return nil, fmt.Errorf("missing whole part: %s", amount)
+	case hasDot && frac == "":
+		return nil, fmt.Errorf("missing fractional part: %s", amount)
+	case !isDigits(whole) || !isDigits(frac):
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+
+	// Extra fractional digits may only be zeros, so no value is lost
+	if len(frac) > decimals {
+		if strings.Trim(frac[decimals:], "0") != "" {
+			return nil, fmt.Errorf("amount %s has more than %d decimal places", amount, decimals)
+		}
+		frac = frac[:decimals]
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	result, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+	return result, nil
+}
+
+// isDigits reports whether s contains only ASCII digits.
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatTokenAmount converts base units of a token with the given decimals
+// to a human-readable amount with 2 decimal places (truncated).
+func FormatTokenAmount(amount *big.Int, decimals int) string {
+	if amount == nil {
+		return "0.00"
+	}
+
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount = new(big.Int).Neg(amount)
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, remainder := new(big.Int).QuoRem(amount, divisor, new(big.Int))
+
+	// Left-pad the remainder to the full decimals, then take the first 2 for display
+	fracStr := "00"
+	if decimals > 0 {
+		fracStr = remainder.String()
+		fracStr = strings.Repeat("0", decimals-len(fracStr)) + fracStr + "00"
+	}
+	return fmt.Sprintf("%s%s.%s", sign, whole.String(), fracStr[:2])
+}
+
+// ParseUSDCAmount converts a human-readable USDC amount (e.g., "100.50") to its
+// on-chain representation (6 decimals). Returns the value in base units.
+func ParseUSDCAmount(amount string) (*big.Int, error) {
+	result, err := ParseTokenAmount(amount, USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid USDC amount: %w", err)
+	}
+	return result, nil
+}
+
+// FormatUSDCAmount converts on-chain USDC units (6 decimals) to human-readable format.
+func FormatUSDCAmount(amount *big.Int) string {
+	return FormatTokenAmount(amount, USDCDecimals)
+}
@@ -0,0 +1,92 @@
+package defi
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseTokenAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string // Base units; empty means an error
+	}{
+		{"100", 6, "100000000"},
+		{"100.50", 6, "100500000"},
+		{"0.000001", 6, "1"},
+		{" 42.5 ", 6, "42500000"},
+		{"1.500000000", 6, "1500000"}, // Extra trailing zeros are fine
+		{"7", 0, "7"},
+		{"1.25", 18, "1250000000000000000"},
+		{"123456789012345678901234567890", 6, "123456789012345678901234567890000000"},
+
+		{"", 6, ""},
+		{"-5", 6, ""},
+		{"+5", 6, ""},
+		{"1.2.3", 6, ""},
+		{".5", 6, ""},
+		{"5.", 6, ""},
+		{"1e6", 6, ""},
+		{"12a", 6, ""},
+		{"1,000", 6, ""},
+		{"$10", 6, ""},
+		{"0.0000001", 6, ""}, // More precision than the token has
+		{"1.5", 0, ""},
+		{"1", -1, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTokenAmount(tt.amount, tt.decimals)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseTokenAmount(%q, %d) = %s, want error", tt.amount, tt.decimals, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTokenAmount(%q, %d) error = %v", tt.amount, tt.decimals, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseTokenAmount(%q, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTokenAmount(t *testing.T) {
+	tests := []struct {
+		amount   *big.Int
+		decimals int
+		want     string
+	}{
+		{nil, 6, "0.00"},
+		{big.NewInt(0), 6, "0.00"},
+		{big.NewInt(100500000), 6, "100.50"},
+		{big.NewInt(1), 6, "0.00"}, // Truncated, not rounded
+		{big.NewInt(1999999), 6, "1.99"},
+		{big.NewInt(-1500000), 6, "-1.50"},
+		{big.NewInt(7), 0, "7.00"},
+		{big.NewInt(5), 1, "0.50"},
+		{new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil), 18, "1000000.00"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTokenAmount(tt.amount, tt.decimals); got != tt.want {
+			t.Errorf("FormatTokenAmount(%v, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestTokenDecimals(t *testing.T) {
+	if got, ok := TokenDecimals("usdc"); !ok || got != 6 {
+		t.Errorf("TokenDecimals(usdc) = (%d, %v), want (6, true)", got, ok)
+	}
+	if _, ok := TokenDecimals("LIL"); ok {
+		t.Error("TokenDecimals(LIL) found an unregistered token")
+	}
+
+	RegisterToken("lil", 18)
+	if got, ok := TokenDecimals("LIL"); !ok || got != 18 {
+		t.Errorf("TokenDecimals(LIL) after RegisterToken = (%d, %v), want (18, true)", got, ok)
+	}
+}
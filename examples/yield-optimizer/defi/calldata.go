@@ -0,0 +1,178 @@
+package defi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// CalldataDecoder describes a call from its ABI-encoded arguments (the
+// calldata after the 4-byte selector). to is the called contract, or empty
+// if unknown. It returns false if the arguments can't be decoded.
+type CalldataDecoder func(to string, args []byte) (string, bool)
+
+var (
+	calldataMu       sync.RWMutex
+	calldataDecoders = map[string]CalldataDecoder{
+		string(SelectorApprove):  decodeApprove,
+		string(SelectorSupply):   decodeAaveSupply,
+		string(SelectorWithdraw): decodeAaveWithdraw,
+	}
+
+	// contractNames maps known contract addresses (lowercase) to display names.
+	contractNames = map[string]string{
+		strings.ToLower(USDC):            "USDC",
+		strings.ToLower(AaveV3Pool):      "Aave V3 Pool",
+		strings.ToLower(AaveAUSDC):       "aUSDC",
+		strings.ToLower(CompoundCUSDCv3): "Compound V3 USDC",
+		strings.ToLower(PendleRouterV4):  "Pendle Router",
+		strings.ToLower(Multicall3):      "Multicall3",
+	}
+)
+
+// RegisterCalldataDecoder adds or replaces the decoder for a function selector.
+func RegisterCalldataDecoder(selector []byte, decoder CalldataDecoder) {
+	calldataMu.Lock()
+	defer calldataMu.Unlock()
+	calldataDecoders[string(selector)] = decoder
+}
+
+// RegisterContractName records a display name for a contract address, used
+// when describing calls to or about it.
+func RegisterContractName(address, name string) {
+	calldataMu.Lock()
+	defer calldataMu.Unlock()
+	contractNames[strings.ToLower(address)] = name
+}
+
+// ContractName returns the display name of a known contract, or the address
+// itself, so an unknown contract is never hidden behind a guess.
+func ContractName(address string) string {
+	calldataMu.RLock()
+	defer calldataMu.RUnlock()
+	if name, ok := contractNames[strings.ToLower(address)]; ok {
+		return name
+	}
+	return address
+}
+
+// DecodeCalldata describes calldata in plain language, e.g. "Approve
+// unlimited spending by Aave V3 Pool". It returns false for unknown
+// selectors or malformed arguments. Use DescribeContractCall when the called
+// contract is known, so token amounts and approvals can name the token.
+func DecodeCalldata(data []byte) (string, bool) {
+	return DescribeContractCall("", data)
+}
+
+// DescribeContractCall describes a call of data to the contract at to, e.g.
+// "Approve unlimited USDC spending by Aave V3 Pool".
+func DescribeContractCall(to string, data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	calldataMu.RLock()
+	decoder, ok := calldataDecoders[string(data[:4])]
+	calldataMu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	return decoder(to, data[4:])
+}
+
+// DecodeHexCalldata decodes 0x-prefixed hex calldata.
+func DecodeHexCalldata(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+// decodeApprove describes approve(address spender, uint256 amount).
+func decodeApprove(to string, args []byte) (string, bool) {
+	words, ok := abiWords(args, 2)
+	if !ok {
+		return "", false
+	}
+	spender := ContractName(decodeAddress(words[0]))
+	amount := decodeUint256(words[1])
+
+	token := ""
+	if to != "" {
+		token = ContractName(to) + " "
+	}
+	switch {
+	case amount.Sign() == 0:
+		return fmt.Sprintf("Revoke %sspending by %s", token, spender), true
+	case isUnlimited(amount):
+		return fmt.Sprintf("Approve unlimited %sspending by %s", token, spender), true
+	case to == "":
+		return fmt.Sprintf("Approve spending of %s base units by %s", amount, spender), true
+	default:
+		return fmt.Sprintf("Approve %s spending by %s", formatTokenAmountOf(to, amount), spender), true
+	}
+}
+
+// decodeAaveSupply describes supply(address asset, uint256 amount, address onBehalfOf, uint16 referralCode).
+func decodeAaveSupply(to string, args []byte) (string, bool) {
+	words, ok := abiWords(args, 4)
+	if !ok {
+		return "", false
+	}
+	asset := decodeAddress(words[0])
+	return fmt.Sprintf("Supply %s to %s", formatTokenAmountOf(asset, decodeUint256(words[1])), poolName(to)), true
+}
+
+// decodeAaveWithdraw describes withdraw(address asset, uint256 amount, address to).
+func decodeAaveWithdraw(to string, args []byte) (string, bool) {
+	words, ok := abiWords(args, 3)
+	if !ok {
+		return "", false
+	}
+	asset := decodeAddress(words[0])
+	amount := decodeUint256(words[1])
+	if isUnlimited(amount) {
+		return fmt.Sprintf("Withdraw all %s from %s", ContractName(asset), poolName(to)), true
+	}
+	return fmt.Sprintf("Withdraw %s from %s", formatTokenAmountOf(asset, amount), poolName(to)), true
+}
+
+// poolName names the pool a supply or withdraw goes to. The selectors are
+// Aave's, so an unknown target is still described as Aave.
+func poolName(to string) string {
+	if to == "" || strings.EqualFold(to, AaveV3Pool) {
+		return "Aave V3"
+	}
+	return "Aave-style pool " + ContractName(to)
+}
+
+// formatTokenAmountOf formats amount of the token at address, e.g.
+// "100.00 USDC", or in base units if the token's decimals are unknown.
+func formatTokenAmountOf(token string, amount *big.Int) string {
+	name := ContractName(token)
+	if decimals, ok := TokenDecimals(name); ok {
+		return FormatTokenAmount(amount, decimals) + " " + name
+	}
+	return fmt.Sprintf("%s base units of %s", amount, name)
+}
+
+// isUnlimited reports whether amount is an "unlimited" sentinel: anything at
+// or above 2^255, which covers MaxUint256 and its common variants.
+func isUnlimited(amount *big.Int) bool {
+	return amount.BitLen() >= 256
+}
+
+// abiWords splits ABI-encoded static arguments into n 32-byte words.
+func abiWords(args []byte, n int) ([][]byte, bool) {
+	if len(args) < n*32 {
+		return nil, false
+	}
+	words := make([][]byte, n)
+	for i := range words {
+		words[i] = args[i*32 : (i+1)*32]
+	}
+	return words, true
+}
+
+// decodeAddress returns the address in a 32-byte ABI word.
+func decodeAddress(word []byte) string {
+	return "0x" + hex.EncodeToString(word[12:32])
+}
@@ -0,0 +1,47 @@
+package defi
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDescribeContractCall(t *testing.T) {
+	const wallet = "0x1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name   string
+		to     string
+		data   []byte
+		want   string
+		wantOK bool
+	}{
+		{"unlimited approval", USDC, EncodeApprove(AaveV3Pool, MaxUint256), "Approve unlimited USDC spending by Aave V3 Pool", true},
+		{"limited approval", USDC, EncodeApprove(PendleRouterV4, big.NewInt(250_000_000)), "Approve 250.00 USDC spending by Pendle Router", true},
+		{"revoke", USDC, EncodeApprove(AaveV3Pool, big.NewInt(0)), "Revoke USDC spending by Aave V3 Pool", true},
+		{"approval to unknown spender", USDC, EncodeApprove(wallet, MaxUint256), "Approve unlimited USDC spending by " + wallet, true},
+		{"approval without target", "", EncodeApprove(AaveV3Pool, MaxUint256), "Approve unlimited spending by Aave V3 Pool", true},
+		{"supply", AaveV3Pool, EncodeAaveSupply(USDC, big.NewInt(100_000_000), wallet), "Supply 100.00 USDC to Aave V3", true},
+		{"withdraw", AaveV3Pool, EncodeAaveWithdraw(USDC, big.NewInt(12_340_000), wallet), "Withdraw 12.34 USDC from Aave V3", true},
+		{"withdraw all", AaveV3Pool, EncodeAaveWithdraw(USDC, MaxUint256, wallet), "Withdraw all USDC from Aave V3", true},
+		{"supply of unknown token", AaveV3Pool, EncodeAaveSupply(wallet, big.NewInt(5), wallet), "Supply 5 base units of " + wallet + " to Aave V3", true},
+		{"unknown selector", USDC, EncodeBalanceOf(wallet), "", false},
+		{"truncated arguments", USDC, EncodeApprove(AaveV3Pool, MaxUint256)[:36], "", false},
+		{"too short", USDC, []byte{0x09, 0x5e}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DescribeContractCall(tt.to, tt.data)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("DescribeContractCall() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	got, ok := DecodeCalldata(EncodeAaveSupply(USDC, big.NewInt(1_000_000), AaveV3Pool))
+	if !ok || got != "Supply 1.00 USDC to Aave V3" {
+		t.Errorf("DecodeCalldata() = (%q, %v)", got, ok)
+	}
+}
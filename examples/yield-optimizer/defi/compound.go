@@ -0,0 +1,56 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// CompoundClient reads Compound V3 (Comet) on-chain data via RPC.
+type CompoundClient struct {
+	rpc *RPCClient
+}
+
+// NewCompoundClient creates a new Compound V3 client using the given RPC client.
+func NewCompoundClient(rpc *RPCClient) *CompoundClient {
+	return &CompoundClient{rpc: rpc}
+}
+
+// GetSupplyAPY returns the current USDC supply APY on Compound V3 as a percentage (e.g., 4.23).
+func (c *CompoundClient) GetSupplyAPY(ctx context.Context) (float64, error) {
+	result, err := c.rpc.EthCall(ctx, CompoundCUSDCv3, EncodeGetUtilization())
+	if err != nil {
+		return 0, fmt.Errorf("getUtilization call failed: %w", err)
+	}
+	if len(result) < 32 {
+		return 0, fmt.Errorf("unexpected response length: %d bytes (need at least 32)", len(result))
+	}
+	utilization := decodeUint256(result[:32])
+
+	result, err = c.rpc.EthCall(ctx, CompoundCUSDCv3, EncodeGetSupplyRate(utilization))
+	if err != nil {
+		return 0, fmt.Errorf("getSupplyRate call failed: %w", err)
+	}
+	if len(result) < 32 {
+		return 0, fmt.Errorf("unexpected response length: %d bytes (need at least 32)", len(result))
+	}
+
+	return cometRateToAPY(decodeUint256(result[:32])), nil
+}
+
+// cometRateToAPY converts a Comet per-second rate (1e18 scale) to an annual
+// percentage yield, using the same linear approximation as rayToAPY.
+func cometRateToAPY(rate *big.Int) float64 {
+	if rate == nil || rate.Sign() == 0 {
+		return 0
+	}
+
+	const secondsPerYear = 365.25 * 24 * 3600
+
+	ratePerSecond := scaleDown(rate, WadDecimals)
+	apy := ratePerSecond * secondsPerYear * 100
+
+	// Round to 2 decimal places
+	return math.Round(apy*100) / 100
+}
@@ -0,0 +1,62 @@
+package defi
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// cometServer answers getUtilization with utilization and getSupplyRate
+// with rate, failing getSupplyRate calls made with any other utilization.
+func cometServer(t *testing.T, utilization, rate *big.Int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var call struct {
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		json.Unmarshal(req.Params[0], &call)
+		data, _ := hex.DecodeString(strings.TrimPrefix(call.Data, "0x"))
+
+		var result []byte
+		switch {
+		case !strings.EqualFold(call.To, CompoundCUSDCv3):
+		case bytes.Equal(data, EncodeGetUtilization()):
+			result = encodeUint256(utilization)
+		case bytes.Equal(data, EncodeGetSupplyRate(utilization)):
+			result = encodeUint256(rate)
+		}
+		if result == nil {
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%q}`, HexEncode(result))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCompoundGetSupplyAPY(t *testing.T) {
+	// 80% utilization; 1e-9 per second is 3.15576% a year.
+	utilization := new(big.Int).Mul(big.NewInt(8), big.NewInt(1e17))
+	srv := cometServer(t, utilization, big.NewInt(1e9))
+
+	apy, err := NewCompoundClient(NewRPCClient(srv.URL)).GetSupplyAPY(context.Background())
+	if err != nil {
+		t.Fatalf("GetSupplyAPY() error = %v", err)
+	}
+	if apy != 3.16 {
+		t.Errorf("GetSupplyAPY() = %v, want 3.16", apy)
+	}
+}
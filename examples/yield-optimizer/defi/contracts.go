@@ -7,18 +7,32 @@ const (
 	// Aave V3 on Arbitrum
 	AaveV3Pool = "0x794a61358D6845594F94dc1DB02A252b5b4814aD"
 
+	// Compound V3 (Comet) native USDC market on Arbitrum
+	CompoundCUSDCv3 = "0x9c4ec768c28520B50860ea7a15bd7213a9fF58bf"
+
+	// Pendle Router V4 (same address on all supported chains)
+	PendleRouterV4 = "0x888888888889758F76e7103c6CbF23ABbF58F946"
+
 	// Tokens on Arbitrum
 	USDC = "0xaf88d065e77c8cC2239327C5EDb3A432268e5831" // Native USDC (Circle)
 
 	// Aave aTokens (interest-bearing receipt tokens)
 	AaveAUSDC = "0x724dc807b04555b71ed48a6896b6F41593b8C637" // aArbUSDCn
 
+	// Multicall3 (same address on all major chains)
+	Multicall3 = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
 	// USDC has 6 decimals
 	USDCDecimals = 6
 
 	// Aave uses RAY units (1e27) for rates
 	RayDecimals = 27
 
+	// Aave V3 reports account values in the base currency (USD, 8 decimals)
+	// and the health factor in WAD units (1e18)
+	AaveBaseCurrencyDecimals = 8
+	WadDecimals              = 18
+
 	// Public Arbitrum RPC endpoints
 	ArbitrumRPC         = "https://arb1.arbitrum.io/rpc"
 	ArbitrumRPCFallback = "https://rpc.ankr.com/arbitrum"
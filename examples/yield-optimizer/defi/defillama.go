@@ -6,23 +6,65 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
 const defiLlamaYieldsURL = "https://yields.llama.fi/pools"
 
+// DefaultDefiLlamaCacheTTL is how long a fetched pools list is reused.
+const DefaultDefiLlamaCacheTTL = 60 * time.Second
+
 // DefiLlamaClient fetches yield data from the DefiLlama Yields API.
+// The pools list is several megabytes, so responses are cached for a short
+// TTL and concurrent lookups share a single in-flight request.
 type DefiLlamaClient struct {
 	httpClient *http.Client
+	url        string
+	cacheTTL   time.Duration
+
+	mu       sync.Mutex
+	cache    map[string]*poolsEntry
+	inflight map[string]*poolsCall
+}
+
+// DefiLlamaOption configures a DefiLlamaClient.
+type DefiLlamaOption func(*DefiLlamaClient)
+
+// WithCacheTTL sets how long the pools list is cached. Zero disables caching;
+// concurrent callers still share one in-flight request.
+func WithCacheTTL(ttl time.Duration) DefiLlamaOption {
+	return func(c *DefiLlamaClient) {
+		c.cacheTTL = ttl
+	}
+}
+
+type poolsEntry struct {
+	pools     []defiLlamaPool
+	fetchedAt time.Time
+}
+
+type poolsCall struct {
+	done  chan struct{}
+	pools []defiLlamaPool
+	err   error
 }
 
 // NewDefiLlamaClient creates a new DefiLlama client.
-func NewDefiLlamaClient() *DefiLlamaClient {
-	return &DefiLlamaClient{
+func NewDefiLlamaClient(opts ...DefiLlamaOption) *DefiLlamaClient {
+	c := &DefiLlamaClient{
 		httpClient: &http.Client{
 			Timeout: 15 * time.Second,
 		},
+		url:      defiLlamaYieldsURL,
+		cacheTTL: DefaultDefiLlamaCacheTTL,
+		cache:    make(map[string]*poolsEntry),
+		inflight: make(map[string]*poolsCall),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type defiLlamaResponse struct {
@@ -52,6 +94,15 @@ func (c *DefiLlamaClient) AaveArbitrumUSDCYield(ctx context.Context) (apy float6
 	return pool.APY, pool.TVLUsd, nil
 }
 
+// CompoundArbitrumUSDCYield fetches the Compound V3 USDC yield on Arbitrum from DefiLlama.
+func (c *DefiLlamaClient) CompoundArbitrumUSDCYield(ctx context.Context) (apy float64, tvl float64, err error) {
+	pool, err := c.findPool(ctx, "compound-v3", "Arbitrum", "USDC")
+	if err != nil {
+		return 0, 0, err
+	}
+	return pool.APY, pool.TVLUsd, nil
+}
+
 // MorphoArbitrumUSDCYield fetches Morpho USDC yield data from DefiLlama if available.
 func (c *DefiLlamaClient) MorphoArbitrumUSDCYield(ctx context.Context) (apy float64, tvl float64, err error) {
 	pool, err := c.findPool(ctx, "morpho", "Arbitrum", "USDC")
@@ -63,7 +114,61 @@ func (c *DefiLlamaClient) MorphoArbitrumUSDCYield(ctx context.Context) (apy floa
 }
 
 func (c *DefiLlamaClient) findPool(ctx context.Context, project, chain, symbol string) (*defiLlamaPool, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", defiLlamaYieldsURL, nil)
+	pools, err := c.getPools(ctx, c.url)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		if pool.Project == project && pool.Chain == chain {
+			// Match symbol — DefiLlama uses compound symbols like "USDC" or "USDC.e"
+			if pool.Symbol == symbol || pool.Symbol == symbol+".e" {
+				return &pool, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("pool not found: %s/%s/%s", project, chain, symbol)
+}
+
+// getPools returns the pools list for url, from cache if fresh. Concurrent
+// callers for the same url wait on a single fetch.
+func (c *DefiLlamaClient) getPools(ctx context.Context, url string) ([]defiLlamaPool, error) {
+	c.mu.Lock()
+	if entry, ok := c.cache[url]; ok && time.Since(entry.fetchedAt) < c.cacheTTL {
+		c.mu.Unlock()
+		return entry.pools, nil
+	}
+	if call, ok := c.inflight[url]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.pools, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &poolsCall{done: make(chan struct{})}
+	c.inflight[url] = call
+	c.mu.Unlock()
+
+	// Other callers share this fetch, so don't let one caller's cancellation
+	// abort it; the HTTP client timeout still bounds it.
+	call.pools, call.err = c.fetchPools(context.WithoutCancel(ctx), url)
+
+	c.mu.Lock()
+	if call.err == nil && c.cacheTTL > 0 {
+		c.cache[url] = &poolsEntry{pools: call.pools, fetchedAt: time.Now()}
+	}
+	delete(c.inflight, url)
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.pools, call.err
+}
+
+func (c *DefiLlamaClient) fetchPools(ctx context.Context, url string) ([]defiLlamaPool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -84,14 +189,5 @@ func (c *DefiLlamaClient) findPool(ctx context.Context, project, chain, symbol s
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
-	for _, pool := range result.Data {
-		if pool.Project == project && pool.Chain == chain {
-			// Match symbol — DefiLlama uses compound symbols like "USDC" or "USDC.e"
-			if pool.Symbol == symbol || pool.Symbol == symbol+".e" {
-				return &pool, nil
-			}
-		}
-	}
-
-	return nil, fmt.Errorf("pool not found: %s/%s/%s", project, chain, symbol)
+	return result.Data, nil
 }
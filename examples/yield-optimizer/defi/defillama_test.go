@@ -0,0 +1,100 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const fakePoolsJSON = `{"status":"success","data":[
+	{"pool":"a","chain":"Arbitrum","project":"aave-v3","symbol":"USDC","tvlUsd":1000000,"apy":4.5},
+	{"pool":"b","chain":"Arbitrum","project":"morpho","symbol":"USDC","tvlUsd":500000,"apy":5.1}
+]}`
+
+// newCountingPoolsServer returns a fake /pools endpoint and its request counter.
+// Each response is delayed so concurrent callers overlap.
+func newCountingPoolsServer(t *testing.T, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		time.Sleep(delay)
+		fmt.Fprint(w, fakePoolsJSON)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func TestDefiLlamaCachesPools(t *testing.T) {
+	srv, count := newCountingPoolsServer(t, 0)
+	client := NewDefiLlamaClient()
+	client.url = srv.URL
+
+	ctx := context.Background()
+	apy, tvl, err := client.AaveArbitrumUSDCYield(ctx)
+	if err != nil {
+		t.Fatalf("AaveArbitrumUSDCYield() error = %v", err)
+	}
+	if apy != 4.5 || tvl != 1000000 {
+		t.Errorf("AaveArbitrumUSDCYield() = (%v, %v), want (4.5, 1000000)", apy, tvl)
+	}
+
+	if _, _, err := client.AaveArbitrumUSDCYield(ctx); err != nil {
+		t.Fatalf("second call error = %v", err)
+	}
+	if apy, _, _ := client.MorphoArbitrumUSDCYield(ctx); apy != 5.1 {
+		t.Errorf("MorphoArbitrumUSDCYield() apy = %v, want 5.1", apy)
+	}
+
+	if n := count.Load(); n != 1 {
+		t.Errorf("server requests = %d, want 1", n)
+	}
+}
+
+func TestDefiLlamaCacheExpires(t *testing.T) {
+	srv, count := newCountingPoolsServer(t, 0)
+	client := NewDefiLlamaClient(WithCacheTTL(20 * time.Millisecond))
+	client.url = srv.URL
+
+	ctx := context.Background()
+	client.AaveArbitrumUSDCYield(ctx)
+	time.Sleep(40 * time.Millisecond)
+	client.AaveArbitrumUSDCYield(ctx)
+
+	if n := count.Load(); n != 2 {
+		t.Errorf("server requests = %d, want 2", n)
+	}
+}
+
+func TestDefiLlamaSharesInflightRequest(t *testing.T) {
+	srv, count := newCountingPoolsServer(t, 50*time.Millisecond)
+	client := NewDefiLlamaClient(WithCacheTTL(0))
+	client.url = srv.URL
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := client.AaveArbitrumUSDCYield(context.Background()); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("AaveArbitrumUSDCYield() error = %v", err)
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("server requests = %d, want 1", n)
+	}
+}
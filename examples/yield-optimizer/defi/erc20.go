@@ -0,0 +1,47 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// TokenBalance returns owner's balance of an arbitrary ERC20 token in base units.
+func (c *RPCClient) TokenBalance(ctx context.Context, token, owner string) (*big.Int, error) {
+	result, err := c.EthCall(ctx, token, EncodeBalanceOf(owner))
+	if err != nil {
+		return nil, fmt.Errorf("balanceOf call failed: %w", err)
+	}
+	if len(result) < 32 {
+		return big.NewInt(0), nil
+	}
+	return decodeUint256(result[:32]), nil
+}
+
+// TokenAllowance returns the allowance of an arbitrary ERC20 token granted by owner to spender.
+func (c *RPCClient) TokenAllowance(ctx context.Context, token, owner, spender string) (*big.Int, error) {
+	result, err := c.EthCall(ctx, token, EncodeAllowance(owner, spender))
+	if err != nil {
+		return nil, fmt.Errorf("allowance call failed: %w", err)
+	}
+	if len(result) < 32 {
+		return big.NewInt(0), nil
+	}
+	return decodeUint256(result[:32]), nil
+}
+
+// TokenDecimals returns the decimals of an ERC20 token.
+func (c *RPCClient) TokenDecimals(ctx context.Context, token string) (int, error) {
+	result, err := c.EthCall(ctx, token, EncodeDecimals())
+	if err != nil {
+		return 0, fmt.Errorf("decimals call failed: %w", err)
+	}
+	if len(result) < 32 {
+		return 0, fmt.Errorf("unexpected response length: %d bytes (need at least 32)", len(result))
+	}
+	decimals := decodeUint256(result[:32])
+	if !decimals.IsUint64() || decimals.Uint64() > 77 {
+		return 0, fmt.Errorf("invalid decimals: %s", decimals)
+	}
+	return int(decimals.Uint64()), nil
+}
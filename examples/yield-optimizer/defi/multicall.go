@@ -0,0 +1,172 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// SelectorAggregate3 is Multicall3.aggregate3((address,bool,bytes)[]).
+var SelectorAggregate3 = mustDecodeHex("82ad56cb")
+
+// Call is a single contract call to batch through Multicall3.
+type Call struct {
+	// Target is the contract address to call.
+	Target string
+
+	// CallData is the ABI-encoded function call.
+	CallData []byte
+
+	// AllowFailure lets the batch succeed even if this call reverts.
+	// The call's result is nil when it fails.
+	AllowFailure bool
+}
+
+// EncodeAggregate3 builds calldata for Multicall3.aggregate3(Call3[] calls).
+func EncodeAggregate3(calls []Call) []byte {
+	// Each Call3 tuple is dynamic (it contains bytes), so the array body is a
+	// head of per-element offsets followed by the encoded tuples.
+	var tuples [][]byte
+	for _, c := range calls {
+		tuple := make([]byte, 0, 128+len(c.CallData)+32)
+		tuple = append(tuple, encodeAddress(c.Target)...)
+		tuple = append(tuple, encodeBool(c.AllowFailure)...)
+		tuple = append(tuple, encodeUint256(big.NewInt(96))...) // offset of bytes within the tuple
+		tuple = append(tuple, encodeBytes(c.CallData)...)
+		tuples = append(tuples, tuple)
+	}
+
+	data := make([]byte, 0, 4+64+32*len(calls))
+	data = append(data, SelectorAggregate3...)
+	data = append(data, encodeUint256(big.NewInt(32))...) // offset of the array
+	data = append(data, encodeUint256(big.NewInt(int64(len(calls))))...)
+
+	offset := 32 * len(calls)
+	for _, t := range tuples {
+		data = append(data, encodeUint256(big.NewInt(int64(offset)))...)
+		offset += len(t)
+	}
+	for _, t := range tuples {
+		data = append(data, t...)
+	}
+	return data
+}
+
+// DecodeAggregate3 decodes the Result[] returned by aggregate3.
+// Failed calls have a nil entry in the returned slice.
+func DecodeAggregate3(data []byte, n int) ([][]byte, error) {
+	arrayOffset, err := readOffset(data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("array offset: %w", err)
+	}
+	length, err := readOffset(data, arrayOffset)
+	if err != nil {
+		return nil, fmt.Errorf("array length: %w", err)
+	}
+	if length != n {
+		return nil, fmt.Errorf("expected %d results, got %d", n, length)
+	}
+
+	body := arrayOffset + 32
+	results := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		rel, err := readOffset(data, body+32*i)
+		if err != nil {
+			return nil, fmt.Errorf("result %d offset: %w", i, err)
+		}
+		tuple := body + rel
+		if tuple+64 > len(data) {
+			return nil, fmt.Errorf("result %d out of range", i)
+		}
+
+		success := decodeUint256(data[tuple:tuple+32]).Sign() != 0
+		bytesRel, err := readOffset(data, tuple+32)
+		if err != nil {
+			return nil, fmt.Errorf("result %d data offset: %w", i, err)
+		}
+		ret, err := readBytes(data, tuple+bytesRel)
+		if err != nil {
+			return nil, fmt.Errorf("result %d data: %w", i, err)
+		}
+		if success {
+			results[i] = ret
+		}
+	}
+	return results, nil
+}
+
+// MulticallEthCall executes calls in a single eth_call through Multicall3 and
+// returns each call's raw result in order. If the batched call fails (for
+// example, Multicall3 is not deployed on the endpoint's chain), the calls are
+// retried one by one. Both read at the block pinned in ctx (see WithBlockTag),
+// so the fallback stays consistent.
+func (c *RPCClient) MulticallEthCall(ctx context.Context, calls []Call) ([][]byte, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	result, err := c.EthCall(ctx, Multicall3, EncodeAggregate3(calls))
+	if err == nil {
+		var results [][]byte
+		results, err = DecodeAggregate3(result, len(calls))
+		if err == nil {
+			return results, nil
+		}
+	}
+
+	// Sequential fallback
+	results := make([][]byte, len(calls))
+	for i, call := range calls {
+		res, callErr := c.EthCall(ctx, call.Target, call.CallData)
+		if callErr != nil {
+			if call.AllowFailure {
+				continue
+			}
+			return nil, fmt.Errorf("call %d to %s failed: %w", i, call.Target, callErr)
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// encodeBool encodes a bool as a 32-byte value.
+func encodeBool(b bool) []byte {
+	padded := make([]byte, 32)
+	if b {
+		padded[31] = 1
+	}
+	return padded
+}
+
+// encodeBytes encodes dynamic bytes as length followed by right-padded data.
+func encodeBytes(b []byte) []byte {
+	padded := (len(b) + 31) / 32 * 32
+	out := make([]byte, 32+padded)
+	copy(out, encodeUint256(big.NewInt(int64(len(b)))))
+	copy(out[32:], b)
+	return out
+}
+
+// readOffset reads a 32-byte word at pos as a non-negative int.
+func readOffset(data []byte, pos int) (int, error) {
+	if pos < 0 || pos+32 > len(data) {
+		return 0, fmt.Errorf("read at %d beyond %d bytes", pos, len(data))
+	}
+	v := decodeUint256(data[pos : pos+32])
+	if !v.IsInt64() || v.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("value at %d out of range", pos)
+	}
+	return int(v.Int64()), nil
+}
+
+// readBytes reads a length-prefixed dynamic bytes value at pos.
+func readBytes(data []byte, pos int) ([]byte, error) {
+	length, err := readOffset(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	if pos+32+length > len(data) {
+		return nil, fmt.Errorf("bytes of length %d at %d beyond %d bytes", length, pos, len(data))
+	}
+	return data[pos+32 : pos+32+length], nil
+}
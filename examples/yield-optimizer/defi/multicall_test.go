@@ -0,0 +1,192 @@
+package defi
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// encodeAggregate3Results ABI-encodes a Result[] as returned by aggregate3.
+// A nil entry is a failed call.
+func encodeAggregate3Results(results [][]byte) []byte {
+	var tuples [][]byte
+	for _, r := range results {
+		tuple := encodeBool(r != nil)
+		tuple = append(tuple, encodeUint256(big.NewInt(64))...)
+		tuple = append(tuple, encodeBytes(r)...)
+		tuples = append(tuples, tuple)
+	}
+
+	data := encodeUint256(big.NewInt(32))
+	data = append(data, encodeUint256(big.NewInt(int64(len(results))))...)
+	offset := 32 * len(results)
+	for _, t := range tuples {
+		data = append(data, encodeUint256(big.NewInt(int64(offset)))...)
+		offset += len(t)
+	}
+	for _, t := range tuples {
+		data = append(data, t...)
+	}
+	return data
+}
+
+func TestEncodeAggregate3(t *testing.T) {
+	calls := []Call{
+		{Target: USDC, CallData: EncodeBalanceOf(AaveV3Pool)},
+		{Target: AaveV3Pool, CallData: []byte{0xde, 0xad, 0xbe, 0xef}, AllowFailure: true},
+	}
+	data := EncodeAggregate3(calls)
+	if !bytes.Equal(data[:4], SelectorAggregate3) {
+		t.Fatalf("selector = %x, want %x", data[:4], SelectorAggregate3)
+	}
+
+	// Walk the encoding back into calls.
+	args := data[4:]
+	arrayOffset, err := readOffset(args, 0)
+	if err != nil {
+		t.Fatalf("array offset: %v", err)
+	}
+	length, err := readOffset(args, arrayOffset)
+	if err != nil || length != len(calls) {
+		t.Fatalf("array length = %d (err %v), want %d", length, err, len(calls))
+	}
+	body := arrayOffset + 32
+	for i, want := range calls {
+		rel, err := readOffset(args, body+32*i)
+		if err != nil {
+			t.Fatalf("call %d offset: %v", i, err)
+		}
+		tuple := body + rel
+		target := "0x" + hex.EncodeToString(args[tuple+12:tuple+32])
+		allowFailure := decodeUint256(args[tuple+32:tuple+64]).Sign() != 0
+		dataRel, err := readOffset(args, tuple+64)
+		if err != nil {
+			t.Fatalf("call %d data offset: %v", i, err)
+		}
+		callData, err := readBytes(args, tuple+dataRel)
+		if err != nil {
+			t.Fatalf("call %d data: %v", i, err)
+		}
+
+		if !strings.EqualFold(target, want.Target) || allowFailure != want.AllowFailure || !bytes.Equal(callData, want.CallData) {
+			t.Errorf("call %d = (%s, %v, %x), want (%s, %v, %x)", i, target, allowFailure, callData, want.Target, want.AllowFailure, want.CallData)
+		}
+	}
+}
+
+func TestDecodeAggregate3(t *testing.T) {
+	balance := encodeUint256(big.NewInt(1_000_000))
+	short := []byte{0x01, 0x02, 0x03}
+	data := encodeAggregate3Results([][]byte{balance, nil, short})
+
+	results, err := DecodeAggregate3(data, 3)
+	if err != nil {
+		t.Fatalf("DecodeAggregate3() error = %v", err)
+	}
+	if !bytes.Equal(results[0], balance) || results[1] != nil || !bytes.Equal(results[2], short) {
+		t.Errorf("DecodeAggregate3() = %x, want [%x nil %x]", results, balance, short)
+	}
+
+	if _, err := DecodeAggregate3(data, 2); err == nil {
+		t.Error("DecodeAggregate3() with the wrong result count error = nil, want error")
+	}
+	if _, err := DecodeAggregate3(data[:len(data)-40], 3); err == nil {
+		t.Error("DecodeAggregate3() of truncated data error = nil, want error")
+	}
+}
+
+// multicallServer answers eth_call: calls to Multicall3 get multicall (hex,
+// without 0x), other targets get their entry in results, and targets
+// missing from results revert.
+func multicallServer(t *testing.T, multicall string, results map[string][]byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var call struct {
+			To string `json:"to"`
+		}
+		json.Unmarshal(req.Params[0], &call)
+
+		if strings.EqualFold(call.To, Multicall3) {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"0x%s"}`, multicall)
+			return
+		}
+		for target, result := range results {
+			if strings.EqualFold(call.To, target) {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%q}`, HexEncode(result))
+				return
+			}
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestMulticallEthCall(t *testing.T) {
+	balance := encodeUint256(big.NewInt(1_000_000))
+	rate := encodeUint256(big.NewInt(42))
+	calls := []Call{
+		{Target: USDC, CallData: EncodeBalanceOf(AaveV3Pool)},
+		{Target: AaveV3Pool, CallData: []byte{0x01, 0x02, 0x03, 0x04}},
+	}
+
+	srv, requests := multicallServer(t, hex.EncodeToString(encodeAggregate3Results([][]byte{balance, rate})), nil)
+	results, err := NewRPCClient(srv.URL).MulticallEthCall(context.Background(), calls)
+	if err != nil {
+		t.Fatalf("MulticallEthCall() error = %v", err)
+	}
+	if !bytes.Equal(results[0], balance) || !bytes.Equal(results[1], rate) {
+		t.Errorf("MulticallEthCall() = %x, want [%x %x]", results, balance, rate)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d RPC requests, want one batched call", n)
+	}
+}
+
+func TestMulticallEthCallSequentialFallback(t *testing.T) {
+	balance := encodeUint256(big.NewInt(1_000_000))
+	rate := encodeUint256(big.NewInt(42))
+	missing := "0x0000000000000000000000000000000000000bad"
+
+	// Multicall3 isn't deployed: the call returns no data.
+	srv, requests := multicallServer(t, "", map[string][]byte{USDC: balance, AaveV3Pool: rate})
+	client := NewRPCClient(srv.URL)
+
+	results, err := client.MulticallEthCall(context.Background(), []Call{
+		{Target: USDC, CallData: EncodeBalanceOf(AaveV3Pool)},
+		{Target: missing, CallData: []byte{0x01, 0x02, 0x03, 0x04}, AllowFailure: true},
+		{Target: AaveV3Pool, CallData: []byte{0x01, 0x02, 0x03, 0x04}},
+	})
+	if err != nil {
+		t.Fatalf("MulticallEthCall() error = %v", err)
+	}
+	if !bytes.Equal(results[0], balance) || results[1] != nil || !bytes.Equal(results[2], rate) {
+		t.Errorf("MulticallEthCall() = %x, want [%x nil %x]", results, balance, rate)
+	}
+	if n := requests.Load(); n != 4 {
+		t.Errorf("made %d RPC requests, want the batch then 3 single calls", n)
+	}
+
+	// A call that must succeed fails the whole fallback.
+	_, err = client.MulticallEthCall(context.Background(), []Call{
+		{Target: USDC, CallData: EncodeBalanceOf(AaveV3Pool)},
+		{Target: missing, CallData: []byte{0x01, 0x02, 0x03, 0x04}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "call 1") {
+		t.Errorf("MulticallEthCall() error = %v, want call 1 to fail", err)
+	}
+}
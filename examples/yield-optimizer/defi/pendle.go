@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -25,12 +26,13 @@ func NewPendleClient() *PendleClient {
 
 // PendleMarket represents a Pendle market with fixed yield.
 type PendleMarket struct {
-	Name       string  `json:"name"`
-	Address    string  `json:"address"`
-	Expiry     string  `json:"expiry"`
-	ImpliedAPY float64 `json:"implied_apy"` // As percentage (e.g., 7.42)
-	PTAddress  string  `json:"pt_address"`
-	Underlying string  `json:"underlying"`
+	Name       string    `json:"name"`
+	Address    string    `json:"address"`
+	Expiry     string    `json:"expiry"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ImpliedAPY float64   `json:"implied_apy"` // As percentage (e.g., 7.42)
+	PTAddress  string    `json:"pt_address"`
+	Underlying string    `json:"underlying"`
 }
 
 type pendleAPIResponse struct {
@@ -48,6 +50,60 @@ type pendleMarketRaw struct {
 
 // GetStablecoinMarkets returns active Pendle markets for stablecoin-adjacent assets on Arbitrum.
 func (c *PendleClient) GetStablecoinMarkets(ctx context.Context) ([]PendleMarket, error) {
+	results, err := c.fetchMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var markets []PendleMarket
+	now := time.Now()
+
+	for _, m := range results {
+		// Skip expired or zero-APY markets
+		if m.ImpliedAPY <= 0 {
+			continue
+		}
+
+		market, ok := m.toMarket(now)
+		if !ok || market.ExpiresAt.Before(now) {
+			continue
+		}
+
+		// Filter to stablecoin-adjacent markets
+		lowName := toLowerCase(market.Underlying)
+		if !containsAny(lowName, "usd", "dai", "gusdc", "usdc", "usdt", "usde", "fxusd") {
+			continue
+		}
+
+		markets = append(markets, market)
+	}
+
+	return markets, nil
+}
+
+// GetMarket returns the Arbitrum market with the given address, including
+// expired markets (needed to redeem PT after maturity).
+func (c *PendleClient) GetMarket(ctx context.Context, address string) (*PendleMarket, error) {
+	results, err := c.fetchMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, m := range results {
+		if !strings.EqualFold(m.Address, address) {
+			continue
+		}
+		market, ok := m.toMarket(now)
+		if !ok {
+			return nil, fmt.Errorf("market %s has invalid expiry %q", address, m.Expiry)
+		}
+		return &market, nil
+	}
+	return nil, fmt.Errorf("market %s not found", address)
+}
+
+func (c *PendleClient) fetchMarkets(ctx context.Context) ([]pendleMarketRaw, error) {
 	url := fmt.Sprintf("%s/%d/markets?order_by=name:1&skip=0&limit=100", pendleAPIBase, ChainIDArbitrum)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -70,54 +126,38 @@ func (c *PendleClient) GetStablecoinMarkets(ctx context.Context) ([]PendleMarket
 	if err := json.Unmarshal(body, &apiResp); err != nil {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
+	return apiResp.Results, nil
+}
 
-	var markets []PendleMarket
-	now := time.Now()
-
-	for _, m := range apiResp.Results {
-		// Skip expired or zero-APY markets
-		if m.ImpliedAPY <= 0 {
-			continue
-		}
-
-		expiry, err := time.Parse(time.RFC3339, m.Expiry)
-		if err != nil {
-			continue
-		}
-		if expiry.Before(now) {
-			continue
-		}
-
-		name := m.ProName
-		if name == "" {
-			name = m.Name
-		}
-
-		// Filter to stablecoin-adjacent markets
-		lowName := toLowerCase(name)
-		if !containsAny(lowName, "usd", "dai", "gusdc", "usdc", "usdt", "usde", "fxusd") {
-			continue
-		}
-
-		// Extract PT address
-		var ptData struct {
-			Address string `json:"address"`
-		}
-		json.Unmarshal(m.PT, &ptData)
-
-		daysToExpiry := int(expiry.Sub(now).Hours() / 24)
+// toMarket converts an API result. Returns false if the expiry can't be parsed.
+func (m pendleMarketRaw) toMarket(now time.Time) (PendleMarket, bool) {
+	expiry, err := time.Parse(time.RFC3339, m.Expiry)
+	if err != nil {
+		return PendleMarket{}, false
+	}
 
-		markets = append(markets, PendleMarket{
-			Name:       fmt.Sprintf("%s (%dd)", name, daysToExpiry),
-			Address:    m.Address,
-			Expiry:     expiry.Format("2006-01-02"),
-			ImpliedAPY: m.ImpliedAPY * 100, // Convert to percentage
-			PTAddress:  ptData.Address,
-			Underlying: name,
-		})
+	name := m.ProName
+	if name == "" {
+		name = m.Name
 	}
 
-	return markets, nil
+	// Extract PT address
+	var ptData struct {
+		Address string `json:"address"`
+	}
+	json.Unmarshal(m.PT, &ptData)
+
+	daysToExpiry := int(expiry.Sub(now).Hours() / 24)
+
+	return PendleMarket{
+		Name:       fmt.Sprintf("%s (%dd)", name, daysToExpiry),
+		Address:    m.Address,
+		Expiry:     expiry.Format("2006-01-02"),
+		ExpiresAt:  expiry,
+		ImpliedAPY: m.ImpliedAPY * 100, // Convert to percentage
+		PTAddress:  ptData.Address,
+		Underlying: name,
+	}, true
 }
 
 func toLowerCase(s string) string {
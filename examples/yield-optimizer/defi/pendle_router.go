@@ -0,0 +1,125 @@
+package defi
+
+import (
+	"math"
+	"math/big"
+	"time"
+)
+
+// Pendle Router V4 selectors. The full signatures expand every struct:
+//
+//	ApproxParams   (uint256 guessMin, uint256 guessMax, uint256 guessOffchain, uint256 maxIteration, uint256 eps)
+//	TokenInput     (address tokenIn, uint256 netTokenIn, address tokenMintSy, address pendleSwap, SwapData swapData)
+//	TokenOutput    (address tokenOut, uint256 minTokenOut, address tokenRedeemSy, address pendleSwap, SwapData swapData)
+//	SwapData       (uint8 swapType, address extRouter, bytes extCalldata, bool needScale)
+//	LimitOrderData (address limitRouter, uint256 epsSkipMarket, FillOrderParams[] normalFills, FillOrderParams[] flashFills, bytes optData)
+var (
+	// swapExactTokenForPt(address receiver, address market, uint256 minPtOut, ApproxParams, TokenInput, LimitOrderData)
+	SelectorSwapExactTokenForPt = mustDecodeHex("c81f847a")
+
+	// swapExactPtForToken(address receiver, address market, uint256 exactPtIn, TokenOutput, LimitOrderData)
+	// After expiry the router redeems the PT instead of swapping.
+	SelectorSwapExactPtForToken = mustDecodeHex("594a88cc")
+)
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// PendleSlippage is the tolerance applied to the implied-APY price when
+// computing minimum outputs for router swaps.
+const PendleSlippage = 0.01
+
+// EncodePendleBuyPT builds calldata for Router.swapExactTokenForPt, buying PT
+// with amountIn of tokenIn. tokenIn must be accepted directly by the market's SY
+// (no aggregator swap is encoded). The router runs its own on-chain binary
+// search for the PT amount, bounded by minPtOut.
+func EncodePendleBuyPT(receiver, market, tokenIn string, amountIn, minPtOut *big.Int) []byte {
+	return EncodeCall(SelectorSwapExactTokenForPt,
+		ABIAddress(receiver),
+		ABIAddress(market),
+		ABIUint256(minPtOut),
+		pendleDefaultApprox(),
+		ABITuple(
+			ABIAddress(tokenIn),
+			ABIUint256(amountIn),
+			ABIAddress(tokenIn), // tokenMintSy
+			ABIAddress(zeroAddress),
+			pendleNoSwap(),
+		),
+		pendleNoLimitOrders(),
+	)
+}
+
+// EncodePendleSellPT builds calldata for Router.swapExactPtForToken, selling
+// ptIn PT for tokenOut before expiry, or redeeming it after expiry.
+// tokenOut must be redeemable directly from the market's SY.
+func EncodePendleSellPT(receiver, market string, ptIn *big.Int, tokenOut string, minTokenOut *big.Int) []byte {
+	return EncodeCall(SelectorSwapExactPtForToken,
+		ABIAddress(receiver),
+		ABIAddress(market),
+		ABIUint256(ptIn),
+		ABITuple(
+			ABIAddress(tokenOut),
+			ABIUint256(minTokenOut),
+			ABIAddress(tokenOut), // tokenRedeemSy
+			ABIAddress(zeroAddress),
+			pendleNoSwap(),
+		),
+		pendleNoLimitOrders(),
+	)
+}
+
+// pendleDefaultApprox is Pendle's recommended ApproxParams when no off-chain
+// guess is available: search the full range with 0.01% precision.
+func pendleDefaultApprox() ABIArg {
+	return ABITuple(
+		ABIUint(0),             // guessMin
+		ABIUint256(MaxUint256), // guessMax
+		ABIUint(0),             // guessOffchain
+		ABIUint(256),           // maxIteration
+		ABIUint(1e14),          // eps (1e18 = 100%)
+	)
+}
+
+// pendleNoSwap is an empty SwapData (swapType NONE).
+func pendleNoSwap() ABIArg {
+	return ABITuple(ABIUint(0), ABIAddress(zeroAddress), ABIBytes(nil), ABIBool(false))
+}
+
+// pendleNoLimitOrders is an empty LimitOrderData: fill entirely from the AMM.
+func pendleNoLimitOrders() ABIArg {
+	return ABITuple(ABIAddress(zeroAddress), ABIUint(0), ABIArray(), ABIArray(), ABIBytes(nil))
+}
+
+// PTGrowthFactor returns how many PT one unit of the underlying buys at the
+// market's implied APY: (1 + APY)^yearsToExpiry. Returns 1 at or after expiry,
+// when PT redeems 1:1.
+func (m *PendleMarket) PTGrowthFactor(now time.Time) float64 {
+	years := m.ExpiresAt.Sub(now).Hours() / (24 * 365)
+	if years <= 0 {
+		return 1
+	}
+	return math.Pow(1+m.ImpliedAPY/100, years)
+}
+
+// MinPTOut returns the minimum PT (in PT base units) to accept when buying
+// with amountIn base units of a token with inDecimals, at the implied APY
+// less PendleSlippage.
+func (m *PendleMarket) MinPTOut(amountIn *big.Int, inDecimals, ptDecimals int, now time.Time) *big.Int {
+	return scaleAmount(amountIn, inDecimals, ptDecimals, m.PTGrowthFactor(now)*(1-PendleSlippage))
+}
+
+// MinTokenOut returns the minimum output (in base units of a token with
+// outDecimals) to accept when selling or redeeming ptIn PT base units.
+func (m *PendleMarket) MinTokenOut(ptIn *big.Int, ptDecimals, outDecimals int, now time.Time) *big.Int {
+	return scaleAmount(ptIn, ptDecimals, outDecimals, (1-PendleSlippage)/m.PTGrowthFactor(now))
+}
+
+// scaleAmount converts amount between decimal bases and multiplies it by
+// factor, rounding down.
+func scaleAmount(amount *big.Int, fromDecimals, toDecimals int, factor float64) *big.Int {
+	f := new(big.Float).SetInt(amount)
+	f.Mul(f, big.NewFloat(factor))
+	f.Mul(f, big.NewFloat(math.Pow10(toDecimals-fromDecimals)))
+	result, _ := f.Int(nil)
+	return result
+}
@@ -0,0 +1,113 @@
+package defi
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const testMarket = "0x0000000000000000000000000000000000001234"
+
+func TestEncodePendleBuyPT(t *testing.T) {
+	receiver := "0x00000000000000000000000000000000000000aa"
+	amount := big.NewInt(100_000000)
+	minOut := big.NewInt(104_000000)
+
+	data := EncodePendleBuyPT(receiver, testMarket, USDC, amount, minOut)
+
+	if !bytes.Equal(data[:4], SelectorSwapExactTokenForPt) {
+		t.Fatalf("selector = %x, want %x", data[:4], SelectorSwapExactTokenForPt)
+	}
+	args := data[4:]
+	word := func(i int) []byte { return args[i*32 : (i+1)*32] }
+
+	// Head: receiver, market, minPtOut, ApproxParams (5 static words inline),
+	// TokenInput offset, LimitOrderData offset.
+	if !bytes.Equal(word(0), encodeAddress(receiver)) {
+		t.Errorf("receiver = %x", word(0))
+	}
+	if !bytes.Equal(word(1), encodeAddress(testMarket)) {
+		t.Errorf("market = %x", word(1))
+	}
+	if got := decodeUint256(word(2)); got.Cmp(minOut) != 0 {
+		t.Errorf("minPtOut = %s, want %s", got, minOut)
+	}
+	if got := decodeUint256(word(4)); got.Cmp(MaxUint256) != 0 {
+		t.Errorf("guessMax = %s, want MaxUint256", got)
+	}
+	if got := decodeUint256(word(6)).Int64(); got != 256 {
+		t.Errorf("maxIteration = %d, want 256", got)
+	}
+
+	inputOffset := int(decodeUint256(word(8)).Int64())
+	if inputOffset != 10*32 {
+		t.Fatalf("TokenInput offset = %d, want %d", inputOffset, 10*32)
+	}
+	input := args[inputOffset:]
+	if !bytes.Equal(input[:32], encodeAddress(USDC)) {
+		t.Errorf("tokenIn = %x, want USDC", input[:32])
+	}
+	if got := decodeUint256(input[32:64]); got.Cmp(amount) != 0 {
+		t.Errorf("netTokenIn = %s, want %s", got, amount)
+	}
+	if !bytes.Equal(input[64:96], encodeAddress(USDC)) {
+		t.Errorf("tokenMintSy = %x, want USDC", input[64:96])
+	}
+}
+
+func TestEncodePendleSellPT(t *testing.T) {
+	receiver := "0x00000000000000000000000000000000000000aa"
+	ptIn := big.NewInt(50_000000)
+	minOut := big.NewInt(48_000000)
+
+	data := EncodePendleSellPT(receiver, testMarket, ptIn, USDC, minOut)
+
+	if !bytes.Equal(data[:4], SelectorSwapExactPtForToken) {
+		t.Fatalf("selector = %x, want %x", data[:4], SelectorSwapExactPtForToken)
+	}
+	args := data[4:]
+	if got := decodeUint256(args[64:96]); got.Cmp(ptIn) != 0 {
+		t.Errorf("exactPtIn = %s, want %s", got, ptIn)
+	}
+	outputOffset := int(decodeUint256(args[96:128]).Int64())
+	output := args[outputOffset:]
+	if !bytes.Equal(output[:32], encodeAddress(USDC)) {
+		t.Errorf("tokenOut = %x, want USDC", output[:32])
+	}
+	if got := decodeUint256(output[32:64]); got.Cmp(minOut) != 0 {
+		t.Errorf("minTokenOut = %s, want %s", got, minOut)
+	}
+}
+
+func TestPendleMinOut(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	market := &PendleMarket{ImpliedAPY: 10, ExpiresAt: now.Add(365 * 24 * time.Hour)}
+	amount := big.NewInt(100_000000) // 100 USDC
+
+	// Amounts are float-derived, so allow one base unit of rounding.
+	near := func(got, want *big.Int, tol int64) bool {
+		return new(big.Int).Sub(got, want).CmpAbs(big.NewInt(tol)) <= 0
+	}
+
+	// 100 USDC at 10% for one year buys ~110 PT; less 1% slippage = 108.9.
+	if got := market.MinPTOut(amount, 6, 6, now); !near(got, big.NewInt(108_900000), 1) {
+		t.Errorf("MinPTOut() = %s, want ~108900000", got)
+	}
+	// 18-decimal PT scales accordingly.
+	want18, _ := new(big.Int).SetString("108900000000000000000", 10)
+	if got := market.MinPTOut(amount, 6, 18, now); !near(got, want18, 1e6) {
+		t.Errorf("MinPTOut() 18 decimals = %s, want ~%s", got, want18)
+	}
+
+	// 110 PT a year before expiry sells for ~100 USDC; less 1% = 99.
+	if got := market.MinTokenOut(big.NewInt(110_000000), 6, 6, now); !near(got, big.NewInt(99_000000), 1) {
+		t.Errorf("MinTokenOut() = %s, want ~99000000", got)
+	}
+
+	// After expiry PT redeems 1:1.
+	expired := &PendleMarket{ImpliedAPY: 10, ExpiresAt: now.Add(-time.Hour)}
+	if got := expired.MinTokenOut(big.NewInt(100_000000), 6, 6, now); !near(got, big.NewInt(99_000000), 1) {
+		t.Errorf("MinTokenOut() after expiry = %s, want ~99000000", got)
+	}
+}
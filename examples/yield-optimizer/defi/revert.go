@@ -0,0 +1,138 @@
+package defi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Standard Solidity revert payload selectors.
+var (
+	SelectorErrorString = mustDecodeHex("08c379a0") // Error(string)
+	SelectorPanic       = mustDecodeHex("4e487b71") // Panic(uint256)
+)
+
+// panicReasons maps Solidity Panic(uint256) codes to descriptions.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized function",
+}
+
+// RPCRevertError is returned when a call reverts with decodable revert data.
+type RPCRevertError struct {
+	// Reason is the human-readable revert reason, e.g. "insufficient allowance"
+	// or "panic: division or modulo by zero".
+	Reason string
+
+	// Data is the raw revert payload.
+	Data []byte
+
+	// Err is the underlying JSON-RPC error.
+	Err *RPCError
+}
+
+func (e *RPCRevertError) Error() string {
+	return "revert: " + e.Reason
+}
+
+func (e *RPCRevertError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeRevertReason decodes a revert payload into a human-readable reason.
+// Returns false if data is empty or not a recognizable payload.
+func DecodeRevertReason(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	selector, payload := data[:4], data[4:]
+
+	switch {
+	case bytes.Equal(selector, SelectorErrorString):
+		offset, err := readOffset(payload, 0)
+		if err != nil {
+			return "", false
+		}
+		msg, err := readBytes(payload, offset)
+		if err != nil {
+			return "", false
+		}
+		return string(msg), true
+
+	case bytes.Equal(selector, SelectorPanic):
+		if len(payload) < 32 {
+			return "", false
+		}
+		code := decodeUint256(payload[:32])
+		desc := "unknown panic"
+		if code.IsUint64() {
+			if d, ok := panicReasons[code.Uint64()]; ok {
+				desc = d
+			}
+		}
+		return fmt.Sprintf("panic: %s (0x%s)", desc, code.Text(16)), true
+
+	default:
+		return fmt.Sprintf("custom error 0x%s", hex.EncodeToString(selector)), true
+	}
+}
+
+// revertError converts a JSON-RPC revert into an *RPCRevertError with a
+// decoded reason. Returns the original error if it is not a revert or there
+// is nothing to decode.
+func revertError(rpcErr *RPCError) error {
+	data, fromMessage := revertData(rpcErr)
+	if len(data) >= 4 {
+		// Only trust unknown selectors from the data field of an actual revert;
+		// hex found in a message may be an address rather than a payload.
+		known := bytes.Equal(data[:4], SelectorErrorString) || bytes.Equal(data[:4], SelectorPanic)
+		if known || (!fromMessage && rpcErr.IsRevert()) {
+			if reason, ok := DecodeRevertReason(data); ok {
+				return &RPCRevertError{Reason: reason, Data: data, Err: rpcErr}
+			}
+		}
+	}
+
+	// Some nodes decode the reason themselves: "execution reverted: <reason>"
+	if _, reason, ok := strings.Cut(rpcErr.Message, "execution reverted: "); ok && rpcErr.IsRevert() {
+		return &RPCRevertError{Reason: reason, Err: rpcErr}
+	}
+	return rpcErr
+}
+
+// revertData extracts the revert payload from an error. Nodes report it in
+// the data field as a hex string, or nested as {"data": "0x..."}; some only
+// include it in the message, in which case fromMessage is true.
+func revertData(rpcErr *RPCError) (data []byte, fromMessage bool) {
+	var s string
+	if err := json.Unmarshal(rpcErr.Data, &s); err != nil {
+		var nested struct {
+			Data string `json:"data"`
+		}
+		if json.Unmarshal(rpcErr.Data, &nested) == nil {
+			s = nested.Data
+		}
+	}
+	if s == "" {
+		if i := strings.Index(rpcErr.Message, "0x"); i >= 0 {
+			s = strings.Fields(rpcErr.Message[i:])[0]
+			fromMessage = true
+		}
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, false
+	}
+	return data, fromMessage
+}
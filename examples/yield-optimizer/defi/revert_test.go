@@ -0,0 +1,153 @@
+package defi
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// Error(string) payload for "insufficient allowance".
+const revertInsufficientAllowance = "0x08c379a0" +
+	"0000000000000000000000000000000000000000000000000000000000000020" +
+	"0000000000000000000000000000000000000000000000000000000000000016" +
+	"696e73756666696369656e7420616c6c6f77616e636500000000000000000000"
+
+// Panic(0x11) payload.
+const revertPanicOverflow = "0x4e487b71" +
+	"0000000000000000000000000000000000000000000000000000000000000011"
+
+func TestDecodeRevertReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOK bool
+	}{
+		{"error string", revertInsufficientAllowance, "insufficient allowance", true},
+		{"panic overflow", revertPanicOverflow, "panic: arithmetic overflow or underflow (0x11)", true},
+		{"panic division by zero", "0x4e487b71" + "0000000000000000000000000000000000000000000000000000000000000012", "panic: division or modulo by zero (0x12)", true},
+		{"panic unknown code", "0x4e487b71" + "00000000000000000000000000000000000000000000000000000000000000ff", "panic: unknown panic (0xff)", true},
+		{"custom error", "0xe450d38c", "custom error 0xe450d38c", true},
+		{"truncated error string", "0x08c379a0" + "0000000000000000000000000000000000000000000000000000000000000020", "", false},
+		{"truncated panic", "0x4e487b71", "", false},
+		{"empty", "0x", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.data[2:])
+			if err != nil {
+				t.Fatalf("bad test data: %v", err)
+			}
+			got, ok := DecodeRevertReason(data)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("DecodeRevertReason() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEthCallRevert(t *testing.T) {
+	tests := []struct {
+		name       string
+		error      string
+		wantReason string
+		wantRevert bool
+	}{
+		{
+			name:       "error string in data",
+			error:      fmt.Sprintf(`{"code":3,"message":"execution reverted","data":%q}`, revertInsufficientAllowance),
+			wantReason: "insufficient allowance",
+			wantRevert: true,
+		},
+		{
+			name:       "panic in data",
+			error:      fmt.Sprintf(`{"code":3,"message":"execution reverted","data":%q}`, revertPanicOverflow),
+			wantReason: "panic: arithmetic overflow or underflow (0x11)",
+			wantRevert: true,
+		},
+		{
+			name:       "nested data object",
+			error:      fmt.Sprintf(`{"code":-32000,"message":"execution reverted","data":{"data":%q}}`, revertInsufficientAllowance),
+			wantReason: "insufficient allowance",
+			wantRevert: true,
+		},
+		{
+			name:       "payload in message",
+			error:      fmt.Sprintf(`{"code":-32015,"message":"VM execution error: Reverted %s"}`, revertInsufficientAllowance),
+			wantReason: "insufficient allowance",
+			wantRevert: true,
+		},
+		{
+			name:       "reason already decoded by node",
+			error:      `{"code":3,"message":"execution reverted: ERC20: transfer amount exceeds balance"}`,
+			wantReason: "ERC20: transfer amount exceeds balance",
+			wantRevert: true,
+		},
+		{
+			name:       "not a revert",
+			error:      `{"code":-32005,"message":"rate limited, retry 0x1 later"}`,
+			wantRevert: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":%s}`, tt.error)
+			}))
+			defer srv.Close()
+
+			_, err := NewRPCClient(srv.URL).EthCall(context.Background(), USDC, EncodeBalanceOf(AaveV3Pool))
+			if err == nil {
+				t.Fatal("EthCall() error = nil, want error")
+			}
+
+			var revertErr *RPCRevertError
+			if got := errors.As(err, &revertErr); got != tt.wantRevert {
+				t.Fatalf("errors.As(*RPCRevertError) = %v, want %v (err: %v)", got, tt.wantRevert, err)
+			}
+			if !tt.wantRevert {
+				return
+			}
+			if revertErr.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", revertErr.Reason, tt.wantReason)
+			}
+			if revertErr.Error() != "revert: "+tt.wantReason {
+				t.Errorf("Error() = %q", revertErr.Error())
+			}
+
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) {
+				t.Error("revert error does not unwrap to *RPCError")
+			}
+		})
+	}
+}
+
+func TestEthCallRevertSkipsFallback(t *testing.T) {
+	var fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted","data":%q}}`, revertInsufficientAllowance)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x"}`)
+	}))
+	defer fallback.Close()
+
+	_, err := NewRPCClient(primary.URL, fallback.URL).EthCall(context.Background(), USDC, nil)
+	var revertErr *RPCRevertError
+	if !errors.As(err, &revertErr) {
+		t.Fatalf("EthCall() error = %v, want *RPCRevertError", err)
+	}
+	if n := fallbackCalls.Load(); n != 0 {
+		t.Errorf("fallback called %d times, want 0", n)
+	}
+}
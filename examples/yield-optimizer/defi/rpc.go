@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -18,17 +21,114 @@ type RPCClient struct {
 	urls       []string
 	httpClient *http.Client
 	requestID  atomic.Int64
+
+	retries           int           // Extra attempts per endpoint on transient errors
+	retryBackoff      time.Duration // Wait before the first retry, doubled after each
+	failoverThreshold int           // Consecutive preferred-endpoint failures before rotating; 0 = never
+
+	preferred         atomic.Int32 // Index of the endpoint tried first
+	preferredFailures atomic.Int32 // Consecutive failures of the preferred endpoint
+
+	requests        atomic.Int64
+	retried         atomic.Int64
+	primaryFailures atomic.Int64
+	fallbackUsed    atomic.Int64
+	rotations       atomic.Int64
+}
+
+// RPCOption configures an RPCClient.
+type RPCOption func(*RPCClient)
+
+// WithRPCRetry retries a failed request to the same endpoint up to retries
+// times before falling back to the next one, waiting backoff before the first
+// retry and doubling it after each. Execution reverts are never retried.
+func WithRPCRetry(retries int, backoff time.Duration) RPCOption {
+	return func(c *RPCClient) {
+		c.retries = retries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithRPCFailover makes the next endpoint preferred once the preferred one
+// has failed threshold calls in a row, so a flaky primary stops adding
+// latency to every call. CheckHealth moves back to the first healthy
+// endpoint in the configured order.
+func WithRPCFailover(threshold int) RPCOption {
+	return func(c *RPCClient) {
+		c.failoverThreshold = threshold
+	}
 }
 
 // NewRPCClient creates a new RPC client with the given endpoint URLs.
 // The first URL is primary; others are fallbacks.
 func NewRPCClient(urls ...string) *RPCClient {
-	return &RPCClient{
+	return NewRPCClientWithOptions(urls)
+}
+
+// NewRPCClientWithOptions creates a new RPC client with the given endpoint
+// URLs, in order of preference, and options.
+func NewRPCClientWithOptions(urls []string, opts ...RPCOption) *RPCClient {
+	c := &RPCClient{
 		urls: urls,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RPCStats counts how the client's calls were served.
+type RPCStats struct {
+	Requests        int64  // Calls made
+	Retries         int64  // Requests retried on the same endpoint
+	PrimaryFailures int64  // Calls the preferred endpoint failed
+	FallbackUsed    int64  // Calls answered by a fallback endpoint
+	Rotations       int64  // Times the preferred endpoint changed
+	Preferred       string // The endpoint currently tried first
+}
+
+// Stats returns the client's counters since it was created.
+func (c *RPCClient) Stats() RPCStats {
+	stats := RPCStats{
+		Requests:        c.requests.Load(),
+		Retries:         c.retried.Load(),
+		PrimaryFailures: c.primaryFailures.Load(),
+		FallbackUsed:    c.fallbackUsed.Load(),
+		Rotations:       c.rotations.Load(),
+	}
+	if len(c.urls) > 0 {
+		stats.Preferred = c.urls[c.preferred.Load()]
+	}
+	return stats
+}
+
+// CheckHealth probes the endpoints in their configured order with
+// eth_chainId and makes the first one that answers preferred, so the client
+// returns to the primary once it recovers. It returns an error if no
+// endpoint answers. Use it as a server.HealthCheck or call it periodically.
+func (c *RPCClient) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for i, url := range c.urls {
+		_, err := c.doRequest(ctx, url, rpcRequest{
+			JSONRPC: "2.0",
+			Method:  "eth_chainId",
+			Params:  []interface{}{},
+			ID:      c.requestID.Add(1),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			continue
+		}
+		if old := c.preferred.Swap(int32(i)); old != int32(i) {
+			c.rotations.Add(1)
+			c.preferredFailures.Store(0)
+		}
+		return nil
+	}
+	return fmt.Errorf("no healthy RPC endpoint: %w", errors.Join(errs...))
 }
 
 type rpcRequest struct {
@@ -42,44 +142,236 @@ type rpcResponse struct {
 	JSONRPC string          `json:"jsonrpc"`
 	ID      int64           `json:"id"`
 	Result  json.RawMessage `json:"result"`
-	Error   *rpcError       `json:"error,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
 }
 
-type rpcError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+// RPCError is an error object returned by the node in a JSON-RPC response.
+// Execution reverts are reported this way (typically code 3 with revert data).
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
-// EthCall executes a read-only contract call (eth_call) and returns the raw result bytes.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// IsRevert reports whether the error is an execution revert.
+func (e *RPCError) IsRevert() bool {
+	return e.Code == 3 || strings.Contains(strings.ToLower(e.Message), "revert")
+}
+
+// BlockLatest is the block tag for reads against the latest block.
+const BlockLatest = "latest"
+
+// blockTagKey is the context key for a block tag pinned with WithBlockTag.
+type blockTagKey struct{}
+
+// WithBlockTag pins EthCall (and the reads built on it) to blockTag for every
+// call made with the returned context, so a group of reads sees one
+// consistent state even if a block lands between them.
+func WithBlockTag(ctx context.Context, blockTag string) context.Context {
+	return context.WithValue(ctx, blockTagKey{}, blockTag)
+}
+
+// BlockTagFromContext returns the block tag pinned with WithBlockTag, or
+// BlockLatest if none is.
+func BlockTagFromContext(ctx context.Context) string {
+	if tag, ok := ctx.Value(blockTagKey{}).(string); ok && tag != "" {
+		return tag
+	}
+	return BlockLatest
+}
+
+// BlockTag returns the hex block tag for a block number (e.g. "0x10").
+func BlockTag(number uint64) string {
+	return "0x" + strconv.FormatUint(number, 16)
+}
+
+// BlockNumber returns the number of the most recent block (eth_blockNumber).
+func (c *RPCClient) BlockNumber(ctx context.Context) (uint64, error) {
+	result, err := c.call(ctx, "eth_blockNumber", []interface{}{})
+	if err != nil {
+		return 0, err
+	}
+	number, err := decodeHexQuantity(result)
+	if err != nil {
+		return 0, err
+	}
+	if !number.IsUint64() {
+		return 0, fmt.Errorf("block number out of range: %s", number)
+	}
+	return number.Uint64(), nil
+}
+
+// PinBlock fetches the current block number and returns a context that pins
+// reads to it (see WithBlockTag), along with its block tag.
+func (c *RPCClient) PinBlock(ctx context.Context) (context.Context, string, error) {
+	number, err := c.BlockNumber(ctx)
+	if err != nil {
+		return ctx, "", fmt.Errorf("pin block: %w", err)
+	}
+	tag := BlockTag(number)
+	return WithBlockTag(ctx, tag), tag, nil
+}
+
+// EthCall executes a read-only contract call (eth_call) and returns the raw
+// result bytes. It reads at the block pinned in ctx, or the latest block.
 func (c *RPCClient) EthCall(ctx context.Context, to string, calldata []byte) ([]byte, error) {
+	return c.EthCallAt(ctx, to, calldata, BlockTagFromContext(ctx))
+}
+
+// EthCallAt executes a read-only contract call (eth_call) at blockTag: a hex
+// block number from BlockTag, or a named tag such as BlockLatest.
+func (c *RPCClient) EthCallAt(ctx context.Context, to string, calldata []byte, blockTag string) ([]byte, error) {
 	params := []interface{}{
 		map[string]string{
 			"to":   to,
 			"data": "0x" + hex.EncodeToString(calldata),
 		},
-		"latest",
+		blockTag,
 	}
 
+	result, err := c.call(ctx, "eth_call", params)
+	if err != nil {
+		return nil, err
+	}
+	return decodeHexData(result)
+}
+
+// EstimateGas estimates the gas needed to execute a transaction (eth_estimateGas).
+// If the transaction would revert, the returned error is an *RPCError.
+func (c *RPCClient) EstimateGas(ctx context.Context, from, to string, data []byte, value *big.Int) (uint64, error) {
+	tx := map[string]string{
+		"from": from,
+		"to":   to,
+		"data": "0x" + hex.EncodeToString(data),
+	}
+	if value != nil && value.Sign() > 0 {
+		tx["value"] = "0x" + value.Text(16)
+	}
+
+	result, err := c.call(ctx, "eth_estimateGas", []interface{}{tx})
+	if err != nil {
+		return 0, err
+	}
+	gas, err := decodeHexQuantity(result)
+	if err != nil {
+		return 0, err
+	}
+	if !gas.IsUint64() {
+		return 0, fmt.Errorf("gas estimate out of range: %s", gas)
+	}
+	return gas.Uint64(), nil
+}
+
+// GasPrice returns the current gas price in wei (eth_gasPrice).
+func (c *RPCClient) GasPrice(ctx context.Context) (*big.Int, error) {
+	result, err := c.call(ctx, "eth_gasPrice", []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	return decodeHexQuantity(result)
+}
+
+// TransactionReceiptStatus reports whether the transaction with txHash has
+// been mined (eth_getTransactionReceipt) and, if so, whether it succeeded
+// or reverted.
+func (c *RPCClient) TransactionReceiptStatus(ctx context.Context, txHash string) (mined, succeeded bool, err error) {
+	result, err := c.call(ctx, "eth_getTransactionReceipt", []interface{}{txHash})
+	if err != nil {
+		return false, false, err
+	}
+	var receipt *struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(result, &receipt); err != nil {
+		return false, false, fmt.Errorf("invalid receipt: %w", err)
+	}
+	if receipt == nil {
+		return false, false, nil // Not mined yet
+	}
+	return true, receipt.Status == "0x1", nil
+}
+
+// call sends a JSON-RPC request, trying each endpoint in order, starting
+// with the preferred one. Execution reverts are deterministic, so they are
+// returned without retrying or trying fallbacks.
+func (c *RPCClient) call(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
 	req := rpcRequest{
 		JSONRPC: "2.0",
-		Method:  "eth_call",
+		Method:  method,
 		Params:  params,
 		ID:      c.requestID.Add(1),
 	}
+	c.requests.Add(1)
 
+	preferred := int(c.preferred.Load())
 	var lastErr error
-	for _, url := range c.urls {
-		result, err := c.doRequest(ctx, url, req)
-		if err != nil {
+	for i := range c.urls {
+		result, err := c.requestWithRetry(ctx, c.urls[(preferred+i)%len(c.urls)], req)
+		if err != nil && !isRevert(err) {
+			if i == 0 {
+				c.preferredFailed(preferred)
+			}
 			lastErr = err
 			continue
 		}
-		return result, nil
+
+		if i == 0 {
+			c.preferredFailures.Store(0)
+		} else if err == nil {
+			c.fallbackUsed.Add(1)
+		}
+		return result, err
 	}
 	return nil, fmt.Errorf("all RPC endpoints failed: %w", lastErr)
 }
 
-func (c *RPCClient) doRequest(ctx context.Context, url string, req rpcRequest) ([]byte, error) {
+// requestWithRetry sends a request to one endpoint, retrying transient
+// errors with backoff as configured by WithRPCRetry.
+func (c *RPCClient) requestWithRetry(ctx context.Context, url string, req rpcRequest) (json.RawMessage, error) {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		result, err := c.doRequest(ctx, url, req)
+		if err == nil || attempt >= c.retries || isRevert(err) {
+			return result, err
+		}
+
+		c.retried.Add(1)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// preferredFailed records a call the preferred endpoint (at index) failed,
+// and makes the next endpoint preferred once the failover threshold is hit.
+func (c *RPCClient) preferredFailed(index int) {
+	c.primaryFailures.Add(1)
+	if c.failoverThreshold <= 0 || len(c.urls) < 2 {
+		return
+	}
+	if int(c.preferredFailures.Add(1)) < c.failoverThreshold {
+		return
+	}
+	if c.preferred.CompareAndSwap(int32(index), int32((index+1)%len(c.urls))) {
+		c.preferredFailures.Store(0)
+		c.rotations.Add(1)
+	}
+}
+
+// isRevert reports whether err is an execution revert returned by the node.
+func isRevert(err error) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr) && rpcErr.IsRevert()
+}
+
+func (c *RPCClient) doRequest(ctx context.Context, url string, req rpcRequest) (json.RawMessage, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
@@ -108,15 +400,33 @@ func (c *RPCClient) doRequest(ctx context.Context, url string, req rpcRequest) (
 	}
 
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("rpc error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return nil, revertError(rpcResp.Error)
 	}
 
-	// Result is a hex string like "0x..."
+	return rpcResp.Result, nil
+}
+
+// decodeHexData decodes a JSON hex string result like "0x..." into bytes.
+func decodeHexData(result json.RawMessage) ([]byte, error) {
 	var hexResult string
-	if err := json.Unmarshal(rpcResp.Result, &hexResult); err != nil {
+	if err := json.Unmarshal(result, &hexResult); err != nil {
 		return nil, fmt.Errorf("unmarshal result: %w", err)
 	}
 
 	hexResult = strings.TrimPrefix(hexResult, "0x")
 	return hex.DecodeString(hexResult)
 }
+
+// decodeHexQuantity decodes a JSON hex quantity result like "0x5208" into a big.Int.
+func decodeHexQuantity(result json.RawMessage) (*big.Int, error) {
+	var hexResult string
+	if err := json.Unmarshal(result, &hexResult); err != nil {
+		return nil, fmt.Errorf("unmarshal result: %w", err)
+	}
+
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(hexResult, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity: %s", hexResult)
+	}
+	return n, nil
+}
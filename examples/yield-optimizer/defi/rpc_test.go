@@ -0,0 +1,171 @@
+package defi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rpcServer serves JSON-RPC results, failing with a 502 while fail returns true.
+func rpcServer(t *testing.T, fail func() bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail() {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRPCClientRetriesBeforeFallback(t *testing.T) {
+	var primaryFailing atomic.Bool
+	primaryFailing.Store(true)
+	primary, primaryCalls := rpcServer(t, func() bool { return primaryFailing.Swap(false) })
+	fallback, fallbackCalls := rpcServer(t, func() bool { return false })
+
+	client := NewRPCClientWithOptions([]string{primary.URL, fallback.URL}, WithRPCRetry(2, time.Millisecond))
+	if _, err := client.EthCall(context.Background(), USDC, nil); err != nil {
+		t.Fatalf("EthCall() error = %v", err)
+	}
+
+	if n := primaryCalls.Load(); n != 2 {
+		t.Errorf("primary called %d times, want 2", n)
+	}
+	if n := fallbackCalls.Load(); n != 0 {
+		t.Errorf("fallback called %d times, want 0", n)
+	}
+	if stats := client.Stats(); stats.Retries != 1 || stats.PrimaryFailures != 0 || stats.FallbackUsed != 0 {
+		t.Errorf("Stats() = %+v, want 1 retry and no failures", stats)
+	}
+}
+
+func TestRPCClientFailover(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	primary, _ := rpcServer(t, primaryDown.Load)
+	fallback, _ := rpcServer(t, func() bool { return false })
+
+	client := NewRPCClientWithOptions([]string{primary.URL, fallback.URL}, WithRPCFailover(2))
+	for i := 0; i < 3; i++ {
+		if _, err := client.EthCall(context.Background(), USDC, nil); err != nil {
+			t.Fatalf("EthCall() #%d error = %v", i, err)
+		}
+	}
+
+	// Two primary failures rotate to the fallback, which then answers directly
+	stats := client.Stats()
+	if stats.Requests != 3 || stats.PrimaryFailures != 2 || stats.FallbackUsed != 2 || stats.Rotations != 1 {
+		t.Errorf("Stats() = %+v, want 3 requests, 2 primary failures, 2 fallbacks, 1 rotation", stats)
+	}
+	if stats.Preferred != fallback.URL {
+		t.Errorf("Preferred = %q, want fallback %q", stats.Preferred, fallback.URL)
+	}
+
+	// Once the primary recovers, the health check moves back to it
+	primaryDown.Store(false)
+	if err := client.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth() error = %v", err)
+	}
+	if got := client.Stats().Preferred; got != primary.URL {
+		t.Errorf("Preferred after CheckHealth = %q, want primary %q", got, primary.URL)
+	}
+}
+
+func TestRPCClientCheckHealthAllDown(t *testing.T) {
+	primary, _ := rpcServer(t, func() bool { return true })
+	fallback, _ := rpcServer(t, func() bool { return true })
+
+	if err := NewRPCClient(primary.URL, fallback.URL).CheckHealth(context.Background()); err == nil {
+		t.Error("CheckHealth() error = nil with every endpoint down")
+	}
+}
+
+func TestPinBlockUsesOneBlockTag(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		tags []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "eth_blockNumber":
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x1b4"}`)
+		case "eth_call":
+			var tag string
+			json.Unmarshal(req.Params[1], &tag)
+			mu.Lock()
+			tags = append(tags, tag)
+			mu.Unlock()
+			// Fail the Multicall3 batch so the sequential fallback runs too
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x"}`)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewRPCClient(srv.URL)
+	ctx, tag, err := client.PinBlock(context.Background())
+	if err != nil {
+		t.Fatalf("PinBlock() error = %v", err)
+	}
+	if tag != "0x1b4" {
+		t.Errorf("PinBlock() tag = %q, want 0x1b4", tag)
+	}
+
+	aave := NewAaveClient(client)
+	aave.GetSnapshot(ctx, AaveV3Pool)
+	aave.GetUserAccountData(ctx, AaveV3Pool)
+
+	if len(tags) < 3 {
+		t.Fatalf("made %d eth_calls, want at least 3", len(tags))
+	}
+	for i, got := range tags {
+		if got != tag {
+			t.Errorf("eth_call %d block tag = %q, want %q", i, got, tag)
+		}
+	}
+
+	// Without a pinned block, reads go to latest
+	tags = nil
+	client.EthCall(context.Background(), USDC, nil)
+	if len(tags) != 1 || tags[0] != BlockLatest {
+		t.Errorf("unpinned eth_call block tags = %v, want [latest]", tags)
+	}
+}
+
+func TestTransactionReceiptStatus(t *testing.T) {
+	tests := []struct {
+		result        string
+		wantMined     bool
+		wantSucceeded bool
+	}{
+		{`null`, false, false},
+		{`{"status":"0x1"}`, true, true},
+		{`{"status":"0x0"}`, true, false},
+	}
+
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, tt.result)
+		}))
+		mined, succeeded, err := NewRPCClient(srv.URL).TransactionReceiptStatus(context.Background(), "0xabc")
+		srv.Close()
+		if err != nil || mined != tt.wantMined || succeeded != tt.wantSucceeded {
+			t.Errorf("TransactionReceiptStatus() with %s = (%v, %v, %v), want (%v, %v)", tt.result, mined, succeeded, err, tt.wantMined, tt.wantSucceeded)
+		}
+	}
+}
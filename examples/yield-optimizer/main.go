@@ -6,7 +6,9 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
+	"github.com/becomeliminal/nim-go-sdk/engine"
 	"github.com/becomeliminal/nim-go-sdk/executor"
 	"github.com/becomeliminal/nim-go-sdk/server"
 	"github.com/becomeliminal/nim-go-sdk/tools"
@@ -45,18 +47,32 @@ func main() {
 		BaseURL: liminalBaseURL,
 	})
 
-	// Arbitrum RPC client for on-chain reads
-	rpcClient := defi.NewRPCClient(defi.ArbitrumRPC, defi.ArbitrumRPCFallback)
+	// Arbitrum RPC client for on-chain reads. Public RPCs are flaky: retry
+	// transient errors, and prefer the fallback while the primary keeps failing
+	rpcClient := defi.NewRPCClientWithOptions(
+		[]string{defi.ArbitrumRPC, defi.ArbitrumRPCFallback},
+		defi.WithRPCRetry(2, 200*time.Millisecond),
+		defi.WithRPCFailover(3),
+	)
 
 	// Aave V3 client for reading supply rates and balances
 	aaveClient := defi.NewAaveClient(rpcClient)
 
+	// Compound V3 client for reading Comet supply rates
+	compoundClient := defi.NewCompoundClient(rpcClient)
+
 	// DefiLlama client for yield enrichment (TVL, metadata)
 	defiLlamaClient := defi.NewDefiLlamaClient()
 
 	// Pendle client for fixed-rate stablecoin markets
 	pendleClient := defi.NewPendleClient()
 
+	// Transaction status: on-chain receipts for tx hashes, Liminal history otherwise
+	txStatus := tools.TxStatusCheckers{
+		agent.NewChainTxStatus(rpcClient),
+		&tools.LiminalTxStatus{Executor: liminalExecutor},
+	}
+
 	// Create server with Claude
 	srv, err := server.New(server.Config{
 		AnthropicKey:    anthropicKey,
@@ -64,20 +80,39 @@ func main() {
 		Model:           "claude-sonnet-4-20250514",
 		MaxTokens:       4096,
 		LiminalExecutor: liminalExecutor,
+		// Rebalancing withdraws and deposits in one turn; confirm both at once
+		BatchConfirmations: true,
+		// Claude may only call the contracts this agent knows via
+		// execute_contract_call. The yield tools build their own calls.
+		ContractAllowlist: []engine.ContractTarget{
+			{ChainID: defi.ChainIDArbitrum, Address: defi.USDC},
+			{ChainID: defi.ChainIDArbitrum, Address: defi.AaveV3Pool},
+			{ChainID: defi.ChainIDArbitrum, Address: defi.PendleRouterV4},
+		},
+		// Wait for each confirmed write's transaction to land (or revert)
+		TransactionPoller: &tools.TxPoller{Checker: txStatus},
+		// /health probes the RPC endpoints and moves back to the primary once it recovers
+		HealthChecks: []server.HealthCheck{
+			{Name: "arbitrum_rpc", Check: rpcClient.CheckHealth},
+		},
 	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Register Liminal banking tools (balance, savings, send, etc.)
-	srv.AddTools(tools.LiminalTools(liminalExecutor)...)
-	log.Println("Added 10 Liminal banking tools")
+	// execute_contract_call confirmations describe the decoded calldata
+	srv.AddTools(agent.WithDecodedCalldata(tools.LiminalTools(liminalExecutor))...)
+	srv.AddTools(tools.NewPollTransactionStatusTool(txStatus))
+	log.Println("Added 10 Liminal banking tools + poll_transaction_status")
 
 	// Register custom yield optimizer tools
 	deps := &agent.ToolDeps{
 		Aave:          aaveClient,
+		RPC:           rpcClient,
 		DefiLlama:     defiLlamaClient,
 		Pendle:        pendleClient,
+		Compound:      compoundClient,
 		Executor:      liminalExecutor,
 		WalletAddress: walletAddress,
 	}
@@ -90,7 +125,7 @@ func main() {
 	log.Printf("WebSocket endpoint: ws://localhost:%s/ws", port)
 	log.Printf("Health check: http://localhost:%s/health", port)
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	log.Println("Protocols: Aave V3 + Morpho + Pendle on Arbitrum")
+	log.Println("Protocols: Aave V3 + Morpho + Compound V3 + Pendle on Arbitrum")
 	log.Printf("Arbitrum RPC: %s", defi.ArbitrumRPC)
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("Start the frontend with: cd frontend && npm run dev")
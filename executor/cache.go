@@ -0,0 +1,233 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// InvalidationRules maps a write tool to the read tools whose cached results
+// it makes stale, e.g. "send_money" → ["get_balance", "get_transactions"].
+type InvalidationRules map[string][]string
+
+// DefaultInvalidationRules covers the built-in Liminal tools.
+var DefaultInvalidationRules = InvalidationRules{
+	"send_money":            {"get_balance", "get_transactions"},
+	"deposit_savings":       {"get_balance", "get_savings_balance", "get_transactions"},
+	"withdraw_savings":      {"get_balance", "get_savings_balance", "get_transactions"},
+	"execute_contract_call": {"get_balance", "get_transactions"},
+}
+
+// pendingTTL is how long a confirmation's tool is remembered between
+// StorePending and Confirm. Pending actions expire sooner, so a confirmation
+// older than this is never confirmed.
+const pendingTTL = 15 * time.Minute
+
+// CachingExecutor wraps a ToolExecutor and caches successful read-only
+// (Execute) responses per user, tool and input for a short TTL.
+// Writes always go to the inner executor; when a write executes, the read
+// tools listed for it in the invalidation rules are dropped from that user's
+// cache.
+type CachingExecutor struct {
+	inner core.ToolExecutor
+	ttl   time.Duration
+	rules InvalidationRules
+
+	mu           sync.Mutex
+	entries      map[string]map[cacheKey]cacheEntry // userID -> cached responses
+	confirmTools map[string]pendingTool             // confirmationID -> tool, from StorePending
+	sweptAt      time.Time                          // Last removal of expired entries
+}
+
+// pendingTool is the tool of a write awaiting confirmation.
+type pendingTool struct {
+	tool     string
+	storedAt time.Time
+}
+
+// dropStalePending removes confirmations stored more than pendingTTL ago.
+func dropStalePending(pending map[string]pendingTool, now time.Time) {
+	for id, p := range pending {
+		if now.Sub(p.storedAt) > pendingTTL {
+			delete(pending, id)
+		}
+	}
+}
+
+type cacheKey struct {
+	tool  string
+	input string // Canonical JSON input
+}
+
+type cacheEntry struct {
+	resp      *core.ExecuteResponse
+	expiresAt time.Time
+}
+
+// NewCaching wraps inner with a read cache. rules may be nil, in which case
+// writes don't invalidate anything and entries only expire; pass
+// DefaultInvalidationRules for the Liminal tools.
+func NewCaching(inner core.ToolExecutor, ttl time.Duration, rules InvalidationRules) *CachingExecutor {
+	return &CachingExecutor{
+		inner:        inner,
+		ttl:          ttl,
+		rules:        rules,
+		entries:      make(map[string]map[cacheKey]cacheEntry),
+		confirmTools: make(map[string]pendingTool),
+	}
+}
+
+// Execute returns a cached response if one is fresh, otherwise runs the
+// read-only tool and caches a successful response. Each caller gets its own
+// copy, so changing a response doesn't change the cached one.
+func (c *CachingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	key := cacheKey{tool: req.Tool, input: canonicalInput(req.Input)}
+
+	c.mu.Lock()
+	entry, ok := c.entries[req.UserID][key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return cloneResponse(entry.resp), nil
+	}
+
+	resp, err := c.inner.Execute(ctx, req)
+	if err != nil || resp == nil || !resp.Success {
+		return resp, err
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	c.sweepLocked(now)
+	user, ok := c.entries[req.UserID]
+	if !ok {
+		user = make(map[cacheKey]cacheEntry)
+		c.entries[req.UserID] = user
+	}
+	user[key] = cacheEntry{resp: cloneResponse(resp), expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return resp, nil
+}
+
+// ExecuteWrite runs a write tool and invalidates the reads it affects.
+func (c *CachingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	resp, err := c.inner.ExecuteWrite(ctx, req)
+	c.invalidateFor(req.UserID, req.Tool)
+	return resp, err
+}
+
+// Confirm executes a confirmed write and invalidates the reads it affects.
+// If the write's tool isn't known (no StorePending call), the user's whole
+// cache is dropped.
+func (c *CachingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	c.mu.Lock()
+	pending, ok := c.confirmTools[confirmationID]
+	delete(c.confirmTools, confirmationID)
+	c.mu.Unlock()
+
+	resp, err := c.inner.Confirm(ctx, userID, confirmationID)
+	if ok {
+		c.invalidateFor(userID, pending.tool)
+	} else {
+		c.Invalidate(userID)
+	}
+	return resp, err
+}
+
+// Cancel cancels a pending confirmation.
+func (c *CachingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	c.mu.Lock()
+	delete(c.confirmTools, confirmationID)
+	c.mu.Unlock()
+	return c.inner.Cancel(ctx, userID, confirmationID)
+}
+
+// StorePending records the confirmation's tool and passes the request on if
+// the inner executor caches pending writes (see core.PendingStore).
+func (c *CachingExecutor) StorePending(confirmationID string, req *core.ExecuteRequest) {
+	c.mu.Lock()
+	now := time.Now()
+	c.sweepLocked(now)
+	c.confirmTools[confirmationID] = pendingTool{tool: req.Tool, storedAt: now}
+	c.mu.Unlock()
+
+	if ps, ok := c.inner.(core.PendingStore); ok {
+		ps.StorePending(confirmationID, req)
+	}
+}
+
+// Invalidate drops a user's cached responses for the given tools, or all of
+// the user's cached responses if no tools are given.
+func (c *CachingExecutor) Invalidate(userID string, tools ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(tools) == 0 {
+		delete(c.entries, userID)
+		return
+	}
+	drop := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		drop[tool] = true
+	}
+	for key := range c.entries[userID] {
+		if drop[key.tool] {
+			delete(c.entries[userID], key)
+		}
+	}
+}
+
+// sweepLocked removes expired responses, and confirmations stored more than
+// pendingTTL ago, at most once per cache TTL. c.mu must be held.
+func (c *CachingExecutor) sweepLocked(now time.Time) {
+	if now.Sub(c.sweptAt) < c.ttl {
+		return
+	}
+	c.sweptAt = now
+	for userID, user := range c.entries {
+		for key, entry := range user {
+			if !now.Before(entry.expiresAt) {
+				delete(user, key)
+			}
+		}
+		if len(user) == 0 {
+			delete(c.entries, userID)
+		}
+	}
+	dropStalePending(c.confirmTools, now)
+}
+
+// invalidateFor drops the reads made stale by a write tool.
+func (c *CachingExecutor) invalidateFor(userID, writeTool string) {
+	if tools := c.rules[writeTool]; len(tools) > 0 {
+		c.Invalidate(userID, tools...)
+	}
+}
+
+// cloneResponse returns a copy of resp that shares no memory with it.
+func cloneResponse(resp *core.ExecuteResponse) *core.ExecuteResponse {
+	clone := *resp
+	clone.Data = bytes.Clone(resp.Data)
+	if resp.Confirmation != nil {
+		confirmation := *resp.Confirmation
+		clone.Confirmation = &confirmation
+	}
+	return &clone
+}
+
+// canonicalInput normalizes tool input for cache keys: object keys are
+// sorted by re-marshaling, and the ReAct "thought" field, which differs on
+// every call, is ignored.
+func canonicalInput(input json.RawMessage) string {
+	var params map[string]interface{}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return string(input)
+	}
+	delete(params, "thought")
+	canonical, _ := json.Marshal(params)
+	return string(canonical)
+}
@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// countingExecutor succeeds on every call and counts them by tool.
+type countingExecutor struct {
+	calls map[string]int
+}
+
+func (c *countingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	c.calls[req.Tool]++
+	return &core.ExecuteResponse{Success: true, Data: json.RawMessage(`{}`)}, nil
+}
+
+func (c *countingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	c.calls[req.Tool]++
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (c *countingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (c *countingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return nil
+}
+
+func TestCachingExecutor(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingExecutor{calls: map[string]int{}}
+	c := NewCaching(inner, time.Minute, DefaultInvalidationRules)
+
+	read := func(userID, tool, input string) {
+		t.Helper()
+		if _, err := c.Execute(ctx, &core.ExecuteRequest{UserID: userID, Tool: tool, Input: json.RawMessage(input)}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	read("alice", "get_balance", `{"currency":"USD","thought":"check funds"}`)
+	read("alice", "get_balance", `{"thought":"check again","currency":"USD"}`)
+	read("alice", "get_vault_rates", `{}`)
+	if inner.calls["get_balance"] != 1 {
+		t.Errorf("get_balance executed %d times, want 1 (cached, thought ignored)", inner.calls["get_balance"])
+	}
+
+	read("alice", "get_balance", `{"currency":"EUR"}`)
+	read("bob", "get_balance", `{"currency":"USD"}`)
+	if inner.calls["get_balance"] != 3 {
+		t.Errorf("get_balance executed %d times, want 3 (different input and user)", inner.calls["get_balance"])
+	}
+
+	// A confirmed send drops alice's balance but not vault rates.
+	c.StorePending("conf-1", &core.ExecuteRequest{UserID: "alice", Tool: "send_money"})
+	c.Confirm(ctx, "alice", "conf-1")
+	read("alice", "get_balance", `{"currency":"USD"}`)
+	read("alice", "get_vault_rates", `{}`)
+	read("bob", "get_balance", `{"currency":"USD"}`)
+	if inner.calls["get_balance"] != 4 || inner.calls["get_vault_rates"] != 1 {
+		t.Errorf("after send_money: get_balance %d, get_vault_rates %d executions, want 4 and 1",
+			inner.calls["get_balance"], inner.calls["get_vault_rates"])
+	}
+}
+
+func TestCachingExecutorCopiesResponses(t *testing.T) {
+	ctx := context.Background()
+	c := NewCaching(&countingExecutor{calls: map[string]int{}}, time.Minute, nil)
+	req := &core.ExecuteRequest{UserID: "alice", Tool: "get_balance", Input: json.RawMessage(`{}`)}
+
+	// Callers scribble over both the fresh and the cached response.
+	for i := 0; i < 2; i++ {
+		resp, err := c.Execute(ctx, req)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		resp.Data[0] = 'x'
+		resp.Success = false
+	}
+
+	resp, _ := c.Execute(ctx, req)
+	if !resp.Success || string(resp.Data) != `{}` {
+		t.Errorf("cached response = (%v, %s), want the original (true, {})", resp.Success, resp.Data)
+	}
+}
+
+func TestCachingExecutorSweepsExpired(t *testing.T) {
+	ctx := context.Background()
+	c := NewCaching(&countingExecutor{calls: map[string]int{}}, time.Minute, DefaultInvalidationRules)
+
+	c.Execute(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "get_balance", Input: json.RawMessage(`{}`)})
+	c.StorePending("conf-1", &core.ExecuteRequest{UserID: "alice", Tool: "send_money"})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepLocked(time.Now().Add(2 * time.Minute))
+	if len(c.entries) != 0 {
+		t.Errorf("%d users still cached after their responses expired", len(c.entries))
+	}
+	if len(c.confirmTools) != 1 {
+		t.Error("confirmation dropped before pendingTTL")
+	}
+	c.sweepLocked(time.Now().Add(pendingTTL + time.Minute))
+	if len(c.confirmTools) != 0 {
+		t.Errorf("%d confirmations kept after pendingTTL", len(c.confirmTools))
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
@@ -15,6 +17,16 @@ import (
 	"github.com/becomeliminal/nim-go-sdk/core"
 )
 
+// ErrNotSent marks a failed request that never reached the server (e.g. the
+// connection couldn't be established), so retrying it can't apply a write twice.
+// Check with errors.Is.
+var ErrNotSent = errors.New("request not sent")
+
+// IdempotencyKeyHeader is the header carrying a confirmed write's
+// idempotency key (core.ExecuteRequest.IdempotencyKey), so the gateway can
+// reject a write it has already applied.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // pendingWrite stores the details of a write operation awaiting confirmation.
 type pendingWrite struct {
 	req       *core.ExecuteRequest
@@ -27,6 +39,8 @@ type HTTPExecutor struct {
 	baseURL    string
 	jwtToken   string  // JWT for Bearer authentication
 	httpClient *http.Client
+	headers    map[string]string // Sent with every request, see HTTPExecutorConfig.DefaultHeaders
+	forwarded  map[string]string // Metadata key -> header name, see HTTPExecutorConfig.MetadataHeaders
 
 	// pending stores write operations awaiting confirmation, keyed by confirmation ID.
 	pending   map[string]*pendingWrite
@@ -41,8 +55,25 @@ type HTTPExecutorConfig struct {
 	// JWTToken is the JWT token for Bearer authentication.
 	JWTToken string
 
-	// Timeout is the HTTP request timeout.
+	// Timeout is the HTTP request timeout. Defaults to 30s.
 	Timeout time.Duration
+
+	// HTTPClient is the client used for requests, e.g. to set a proxy, TLS
+	// config or a tracing transport. Its own Timeout is kept if set;
+	// otherwise Timeout applies. If nil, a default client is used.
+	HTTPClient *http.Client
+
+	// DefaultHeaders are added to every request (e.g. tracing or API gateway
+	// headers). Headers the executor sets itself take precedence:
+	// Authorization when a JWT is configured (see UpdateJWT), and
+	// Content-Type on requests with a body.
+	DefaultHeaders map[string]string
+
+	// MetadataHeaders forwards request metadata (core.ExecuteRequest.Metadata)
+	// as headers, mapping metadata keys to header names, e.g.
+	// {"trace_id": "X-Trace-Id"}. Keys not listed are not sent. Metadata
+	// headers override DefaultHeaders but not the executor's own headers.
+	MetadataHeaders map[string]string
 }
 
 // NewHTTPExecutor creates a new HTTP-based tool executor.
@@ -52,13 +83,31 @@ func NewHTTPExecutor(cfg HTTPExecutorConfig) *HTTPExecutor {
 		timeout = 30 * time.Second
 	}
 
+	client := &http.Client{Timeout: timeout}
+	if cfg.HTTPClient != nil {
+		c := *cfg.HTTPClient
+		if c.Timeout == 0 {
+			c.Timeout = timeout
+		}
+		client = &c
+	}
+
+	headers := make(map[string]string, len(cfg.DefaultHeaders))
+	for k, v := range cfg.DefaultHeaders {
+		headers[k] = v
+	}
+	forwarded := make(map[string]string, len(cfg.MetadataHeaders))
+	for k, v := range cfg.MetadataHeaders {
+		forwarded[k] = v
+	}
+
 	return &HTTPExecutor{
-		baseURL:  cfg.BaseURL,
-		jwtToken: cfg.JWTToken,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		pending: make(map[string]*pendingWrite),
+		baseURL:    cfg.BaseURL,
+		jwtToken:   cfg.JWTToken,
+		httpClient: client,
+		headers:    headers,
+		forwarded:  forwarded,
+		pending:    make(map[string]*pendingWrite),
 	}
 }
 
@@ -107,7 +156,14 @@ func (e *HTTPExecutor) Confirm(ctx context.Context, userID, confirmationID strin
 	// Execute the actual write operation
 	fmt.Printf("[HTTP] Executing confirmed write: tool=%s\n", pw.req.Tool)
 	endpoint := e.endpointForTool(pw.req.Tool)
-	return e.doRequest(ctx, "POST", endpoint, pw.req, pw.req.Tool)
+	resp, err := e.doRequest(ctx, "POST", endpoint, pw.req, pw.req.Tool)
+	if errors.Is(err, ErrNotSent) {
+		// Nothing was applied; keep the write so Confirm can be retried
+		e.pendingMu.Lock()
+		e.pending[confirmationID] = pw
+		e.pendingMu.Unlock()
+	}
+	return resp, err
 }
 
 // Cancel removes a pending confirmation.
@@ -215,6 +271,19 @@ func (e *HTTPExecutor) doRequest(ctx context.Context, method, endpoint string, b
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	if execReq, ok := body.(*core.ExecuteRequest); ok {
+		for key, header := range e.forwarded {
+			if v, ok := execReq.Metadata[key]; ok {
+				req.Header.Set(header, v)
+			}
+		}
+	}
+	if execReq, ok := body.(*core.ExecuteRequest); ok && method != "GET" && execReq.IdempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, execReq.IdempotencyKey)
+	}
 	if method != "GET" {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -230,6 +299,9 @@ func (e *HTTPExecutor) doRequest(ctx context.Context, method, endpoint string, b
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
 		fmt.Printf("[HTTP] Request failed: %v\n", err)
+		if notSent(err) {
+			return nil, fmt.Errorf("request failed: %w: %w", ErrNotSent, err)
+		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
@@ -269,8 +341,38 @@ func (e *HTTPExecutor) doRequest(ctx context.Context, method, endpoint string, b
 	}, nil
 }
 
+// notSent reports whether an HTTP client error happened before the request
+// could be sent: a failed dial or DNS lookup.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
 // UpdateJWT updates the JWT token used for authentication.
 // This should be called when the token is refreshed.
 func (e *HTTPExecutor) UpdateJWT(jwt string) {
 	e.jwtToken = jwt
 }
+
+// Ping checks the gateway is reachable. Any HTTP response counts as
+// reachable; only transport errors (DNS, connection refused, timeout)
+// are reported.
+func (e *HTTPExecutor) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, e.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gateway unreachable: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
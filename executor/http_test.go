@@ -0,0 +1,184 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine/enginetest"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestHTTPExecutorHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{
+		BaseURL:  srv.URL,
+		JWTToken: "jwt-token-for-alice-0123456789",
+		DefaultHeaders: map[string]string{
+			"X-Trace-Source": "nim",
+			"Authorization":  "Basic ignored",
+		},
+	})
+	if _, err := exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{UserID: "alice", Tool: "send_money", Input: []byte(`{"amount":"10"}`)}); err != nil {
+		t.Fatalf("ExecuteWrite() error = %v", err)
+	}
+
+	if v := got.Get("X-Trace-Source"); v != "nim" {
+		t.Errorf("X-Trace-Source = %q, want nim", v)
+	}
+	if v := got.Get("Authorization"); v != "Bearer jwt-token-for-alice-0123456789" {
+		t.Errorf("Authorization = %q, want the JWT to take precedence over the default header", v)
+	}
+	if v := got.Get("Content-Type"); v != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", v)
+	}
+}
+
+func TestHTTPExecutorForwardsMetadata(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{
+		BaseURL:         srv.URL,
+		DefaultHeaders:  map[string]string{"X-Trace-Id": "default"},
+		MetadataHeaders: map[string]string{"trace_id": "X-Trace-Id"},
+	})
+	tool := core.NewExecutorTool(core.ToolDefinition{ToolName: "get_balance"}, exec)
+
+	// The engine puts core.Context.Values on the context passed to tools.
+	ctx := core.WithValues(context.Background(), map[string]string{"trace_id": "trace-123", "tenant": "acme"})
+	if _, err := tool.Execute(ctx, &core.ToolParams{UserID: "alice", Input: []byte(`{}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if v := got.Get("X-Trace-Id"); v != "trace-123" {
+		t.Errorf("X-Trace-Id = %q, want trace-123 from metadata", v)
+	}
+	if v := got.Get("Tenant"); v != "" {
+		t.Errorf("unlisted metadata key was forwarded as %q", v)
+	}
+}
+
+func TestHTTPExecutorForwardsMetadataOnConfirm(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{
+		BaseURL:         srv.URL,
+		MetadataHeaders: map[string]string{"trace_id": "X-Trace-Id"},
+	})
+	h := enginetest.New(t)
+	h.Register(core.NewExecutorTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, exec))
+	h.Context.Values = map[string]string{"trace_id": "trace-123"}
+	h.Claude.Queue(
+		enginetest.ToolUse("toolu_1", "send_money", map[string]interface{}{
+			"recipient": "@bob",
+			"amount":    "10",
+			"thought":   "User asked to send $10 to Bob",
+		}),
+		enginetest.Text("Sent $10 to @bob."),
+	)
+
+	h.AssertPending(h.Run("send $10 to bob"), "send_money")
+	if got != nil {
+		t.Fatal("send_money reached the executor before confirmation")
+	}
+	h.AssertComplete(h.Confirm())
+
+	if v := got.Get("X-Trace-Id"); v != "trace-123" {
+		t.Errorf("X-Trace-Id = %q, want trace-123 from the confirming request's values", v)
+	}
+}
+
+func TestHTTPExecutorClient(t *testing.T) {
+	called := false
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		called = true
+		return httptest.NewRecorder().Result(), nil
+	})}
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: "http://liminal.invalid", HTTPClient: client, Timeout: 5 * time.Second})
+	if err := exec.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if !called {
+		t.Error("custom HTTPClient transport was not used")
+	}
+	if exec.httpClient.Timeout != 5*time.Second {
+		t.Errorf("client timeout = %v, want Timeout applied to a client without one", exec.httpClient.Timeout)
+	}
+	if client.Timeout != 0 {
+		t.Error("the caller's HTTPClient was modified")
+	}
+}
+
+func TestHTTPExecutorForwardsIdempotencyKey(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	exec := NewHTTPExecutor(HTTPExecutorConfig{BaseURL: srv.URL})
+	tool := core.NewExecutorTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, exec)
+
+	// A confirmed write, as the engine runs it after the user approves
+	_, err := tool.Execute(context.Background(), &core.ToolParams{
+		UserID:         "alice",
+		Input:          []byte(`{"recipient":"@bob","amount":"10"}`),
+		ConfirmationID: "conf-1",
+		IdempotencyKey: "key-123",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if v := got.Get(IdempotencyKeyHeader); v != "key-123" {
+		t.Errorf("%s = %q, want key-123", IdempotencyKeyHeader, v)
+	}
+}
+
+func TestHTTPExecutorKeysIdenticalActionsSeparately(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	h := enginetest.New(t)
+	h.Register(core.NewExecutorTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, NewHTTPExecutor(HTTPExecutorConfig{BaseURL: srv.URL})))
+	send := map[string]interface{}{"recipient": "@bob", "amount": "10", "thought": "User asked to send $10 to Bob"}
+
+	// The user sends @bob $10 twice on purpose.
+	for i := 0; i < 2; i++ {
+		h.Claude.Queue(enginetest.ToolUse(fmt.Sprintf("toolu_%d", i+1), "send_money", send), enginetest.Text("Sent $10 to @bob."))
+		h.AssertPending(h.Run("send $10 to bob"), "send_money")
+		h.AssertComplete(h.Confirm())
+	}
+
+	if len(keys) != 2 || keys[0] == "" || keys[0] == keys[1] {
+		t.Errorf("%s headers = %q, want a different key per action", IdempotencyKeyHeader, keys)
+	}
+}
@@ -0,0 +1,272 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MockExecutor is an in-memory ToolExecutor for testing agents without the
+// Liminal API. Register canned responses per tool with On, then assert on
+// the recorded requests:
+//
+//	mock := executor.NewMock()
+//	mock.On("get_balance").Return(map[string]interface{}{"totalUsd": "100.00"})
+//	mock.On("send_money").RequireConfirmation().Return(map[string]interface{}{"status": "sent"})
+//	mock.On("search_users").Fail(errors.New("search unavailable"))
+//
+//	srv.AddTools(tools.LiminalTools(mock)...)
+//	// ... run the agent ...
+//	if len(mock.Calls("send_money")) != 1 { ... }
+type MockExecutor struct {
+	mu       sync.Mutex
+	tools    map[string]*MockTool
+	requests []MockRequest
+	pending  map[string]*core.ExecuteRequest // confirmationID -> write awaiting Confirm
+	nextID   int
+}
+
+// MockTool is the canned behavior for one tool. Configure it with its
+// chainable methods.
+type MockTool struct {
+	mu                  sync.Mutex
+	data                json.RawMessage
+	err                 error
+	requireConfirmation bool
+	summary             string
+}
+
+// MockRequest is a call received by a MockExecutor.
+type MockRequest struct {
+	// Method is "execute", "execute_write", "confirm" or "cancel".
+	Method string
+
+	UserID         string
+	Tool           string
+	Input          json.RawMessage
+	ConfirmationID string
+}
+
+// NewMock creates a mock executor with no tools registered.
+// Calls to unregistered tools fail.
+func NewMock() *MockExecutor {
+	return &MockExecutor{
+		tools:   make(map[string]*MockTool),
+		pending: make(map[string]*core.ExecuteRequest),
+	}
+}
+
+// On returns the canned behavior for tool, registering it if needed.
+// By default the tool succeeds with an empty object.
+func (m *MockExecutor) On(tool string) *MockTool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tools[tool]
+	if !ok {
+		t = &MockTool{data: json.RawMessage(`{}`)}
+		m.tools[tool] = t
+	}
+	return t
+}
+
+// Return sets the tool's result data. data is marshaled to JSON unless it's
+// already a json.RawMessage. Clears any error set with Fail.
+func (t *MockTool) Return(data interface{}) *MockTool {
+	raw, ok := data.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			panic(fmt.Sprintf("mock: can't marshal return value: %v", err))
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.data = raw
+	t.err = nil
+	return t
+}
+
+// Fail makes the tool return err.
+func (t *MockTool) Fail(err error) *MockTool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.err = err
+	return t
+}
+
+// RequireConfirmation makes ExecuteWrite return a pending confirmation
+// instead of executing; the result is returned by Confirm.
+func (t *MockTool) RequireConfirmation() *MockTool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requireConfirmation = true
+	return t
+}
+
+// Summary sets the confirmation summary (default "Confirm <tool>").
+func (t *MockTool) Summary(summary string) *MockTool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.summary = summary
+	return t
+}
+
+// respond returns the tool's canned response.
+func (t *MockTool) respond() (*core.ExecuteResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return nil, t.err
+	}
+	return &core.ExecuteResponse{Success: true, Data: t.data}, nil
+}
+
+// Execute returns the canned response for a read-only tool.
+func (m *MockExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	t, err := m.record(MockRequest{Method: "execute", UserID: req.UserID, Tool: req.Tool, Input: req.Input})
+	if err != nil {
+		return nil, err
+	}
+	return t.respond()
+}
+
+// ExecuteWrite returns a pending confirmation if the tool requires one,
+// otherwise the canned response.
+func (m *MockExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	t, err := m.record(MockRequest{Method: "execute_write", UserID: req.UserID, Tool: req.Tool, Input: req.Input})
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	requireConfirmation, summary := t.requireConfirmation, t.summary
+	t.mu.Unlock()
+	if !requireConfirmation {
+		return t.respond()
+	}
+
+	if summary == "" {
+		summary = "Confirm " + req.Tool
+	}
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("mock-confirmation-%d", m.nextID)
+	m.pending[id] = req
+	m.mu.Unlock()
+
+	return &core.ExecuteResponse{
+		Success:              true,
+		RequiresConfirmation: true,
+		Confirmation: &core.ConfirmationDetails{
+			ID:        id,
+			Summary:   summary,
+			ExpiresAt: time.Now().Add(10 * time.Minute).Unix(),
+		},
+	}, nil
+}
+
+// StorePending registers a write under confirmationID so Confirm can execute
+// it. Called by core.ExecutorTool when the engine manages confirmations.
+func (m *MockExecutor) StorePending(confirmationID string, req *core.ExecuteRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending[confirmationID] = req
+}
+
+// Confirm executes a pending write and returns its canned response.
+func (m *MockExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	m.mu.Lock()
+	req, ok := m.pending[confirmationID]
+	if ok && req.UserID == userID {
+		delete(m.pending, confirmationID)
+	}
+	m.mu.Unlock()
+
+	if !ok || req.UserID != userID {
+		m.record(MockRequest{Method: "confirm", UserID: userID, ConfirmationID: confirmationID})
+		return &core.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("confirmation %s not found or expired", confirmationID),
+		}, nil
+	}
+
+	t, err := m.record(MockRequest{Method: "confirm", UserID: userID, Tool: req.Tool, Input: req.Input, ConfirmationID: confirmationID})
+	if err != nil {
+		return nil, err
+	}
+	return t.respond()
+}
+
+// Cancel removes a pending write.
+func (m *MockExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	m.mu.Lock()
+	req, ok := m.pending[confirmationID]
+	if ok && req.UserID == userID {
+		delete(m.pending, confirmationID)
+	}
+	m.mu.Unlock()
+
+	call := MockRequest{Method: "cancel", UserID: userID, ConfirmationID: confirmationID}
+	if ok {
+		call.Tool = req.Tool
+	}
+	m.record(call)
+	return nil
+}
+
+// Requests returns all calls received so far, in order.
+func (m *MockExecutor) Requests() []MockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockRequest(nil), m.requests...)
+}
+
+// Calls returns the calls received for tool, in order.
+func (m *MockExecutor) Calls(tool string) []MockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []MockRequest
+	for _, r := range m.requests {
+		if r.Tool == tool {
+			calls = append(calls, r)
+		}
+	}
+	return calls
+}
+
+// Pending returns the IDs of writes awaiting Confirm.
+func (m *MockExecutor) Pending() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.pending))
+	for id := range m.pending {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// record appends the request and returns its tool's canned behavior.
+func (m *MockExecutor) record(r MockRequest) (*MockTool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, r)
+	if r.Tool == "" {
+		return nil, nil
+	}
+	t, ok := m.tools[r.Tool]
+	if !ok {
+		return nil, fmt.Errorf("mock: no response registered for tool %q", r.Tool)
+	}
+	return t, nil
+}
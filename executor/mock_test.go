@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestMockExecutor(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMock()
+	mock.On("get_balance").Return(map[string]string{"totalUsd": "100.00"})
+	mock.On("send_money").RequireConfirmation().Summary("Send $10 to bob").Return(map[string]string{"status": "sent"})
+	mock.On("search_users").Fail(errors.New("search unavailable"))
+
+	resp, err := mock.Execute(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "get_balance"})
+	if err != nil || string(resp.Data) != `{"totalUsd":"100.00"}` {
+		t.Errorf("Execute(get_balance) = (%s, %v), want canned balance", resp.Data, err)
+	}
+	if _, err := mock.Execute(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "search_users"}); err == nil {
+		t.Error("Execute(search_users) error = nil, want error")
+	}
+	if _, err := mock.Execute(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "unknown"}); err == nil {
+		t.Error("Execute(unknown) error = nil, want error")
+	}
+
+	input := json.RawMessage(`{"amount":"10","recipient":"@bob"}`)
+	resp, err = mock.ExecuteWrite(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "send_money", Input: input})
+	if err != nil || !resp.RequiresConfirmation || resp.Confirmation.Summary != "Send $10 to bob" {
+		t.Fatalf("ExecuteWrite(send_money) = (%+v, %v), want confirmation", resp, err)
+	}
+
+	// Only the owner can confirm, and only once.
+	if resp, _ := mock.Confirm(ctx, "mallory", resp.Confirmation.ID); resp.Success {
+		t.Error("Confirm() by another user succeeded")
+	}
+	confirmed, err := mock.Confirm(ctx, "alice", resp.Confirmation.ID)
+	if err != nil || !confirmed.Success || string(confirmed.Data) != `{"status":"sent"}` {
+		t.Errorf("Confirm() = (%+v, %v), want canned result", confirmed, err)
+	}
+	if resp, _ := mock.Confirm(ctx, "alice", resp.Confirmation.ID); resp.Success {
+		t.Error("second Confirm() succeeded")
+	}
+
+	calls := mock.Calls("send_money")
+	if len(calls) != 2 || calls[0].Method != "execute_write" || calls[1].Method != "confirm" {
+		t.Fatalf("Calls(send_money) = %+v, want execute_write then confirm", calls)
+	}
+	if string(calls[1].Input) != string(input) {
+		t.Errorf("confirm call input = %s, want %s", calls[1].Input, input)
+	}
+}
+
+func TestMockExecutorWithExecutorTool(t *testing.T) {
+	mock := NewMock()
+	mock.On("send_money").Return(map[string]string{"status": "sent"})
+	tool := core.NewExecutorTool(core.ToolDefinition{ToolName: "send_money", RequiresUserConfirmation: true}, mock)
+
+	// The engine's confirmation path: StorePending, then Confirm.
+	result, err := tool.Execute(context.Background(), &core.ToolParams{
+		UserID:         "alice",
+		Input:          json.RawMessage(`{"amount":"10"}`),
+		ConfirmationID: "action-1",
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() = (%+v, %v), want success", result, err)
+	}
+	if got := mock.Requests(); len(got) != 1 || got[0].Method != "confirm" || got[0].ConfirmationID != "action-1" {
+		t.Errorf("Requests() = %+v, want one confirm for action-1", got)
+	}
+}
@@ -0,0 +1,242 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/guardrails"
+)
+
+// ErrCircuitOpen is returned when a tool's circuit breaker is open and the
+// call was rejected without reaching the inner executor.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// RetryingExecutor wraps a ToolExecutor with retries and a per-tool circuit
+// breaker.
+//
+// Read operations (Execute, Cancel) are retried on errors and on HTTP 429 and
+// 5xx responses. Write operations (ExecuteWrite, Confirm) are only retried
+// when the inner executor reports the request was never sent (ErrNotSent),
+// so a retry can't move money twice.
+//
+// After repeated failed calls to a tool, its circuit opens and further calls
+// fail fast with ErrCircuitOpen until the cooldown passes.
+type RetryingExecutor struct {
+	inner      core.ToolExecutor
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	breaker    *guardrails.CircuitBreaker
+
+	// confirmTools maps confirmation IDs to tool names so Confirm calls
+	// count against the right circuit. Filled in by StorePending; entries
+	// never confirmed are dropped after pendingTTL.
+	mu           sync.Mutex
+	confirmTools map[string]pendingTool
+}
+
+// RetryOption configures a RetryingExecutor.
+type RetryOption func(*RetryingExecutor)
+
+// WithMaxRetries sets how many times a failed call is retried (default 2).
+func WithMaxRetries(n int) RetryOption {
+	return func(r *RetryingExecutor) {
+		r.maxRetries = n
+	}
+}
+
+// WithBackoff sets the exponential backoff between retries: base doubles
+// after each attempt, capped at max, with jitter (default 200ms to 2s).
+func WithBackoff(base, max time.Duration) RetryOption {
+	return func(r *RetryingExecutor) {
+		r.baseDelay = base
+		r.maxDelay = max
+	}
+}
+
+// WithCircuitBreaker opens a tool's circuit after failureThreshold
+// consecutive failed calls, for cooldown (default 5 failures, 30s).
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) RetryOption {
+	return func(r *RetryingExecutor) {
+		r.breaker = guardrails.NewCircuitBreaker(failureThreshold, cooldown)
+	}
+}
+
+// NewRetrying wraps inner with retries and circuit breaking.
+func NewRetrying(inner core.ToolExecutor, opts ...RetryOption) *RetryingExecutor {
+	r := &RetryingExecutor{
+		inner:        inner,
+		maxRetries:   2,
+		baseDelay:    200 * time.Millisecond,
+		maxDelay:     2 * time.Second,
+		breaker:      guardrails.NewCircuitBreaker(5, 30*time.Second),
+		confirmTools: make(map[string]pendingTool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Execute runs a read-only tool, retrying transient failures.
+func (r *RetryingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return r.do(ctx, req.Tool, true, func() (*core.ExecuteResponse, error) {
+		return r.inner.Execute(ctx, req)
+	})
+}
+
+// ExecuteWrite runs a write tool, retrying only requests that were never sent.
+func (r *RetryingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return r.do(ctx, req.Tool, false, func() (*core.ExecuteResponse, error) {
+		return r.inner.ExecuteWrite(ctx, req)
+	})
+}
+
+// Confirm executes a confirmed write, retrying only requests that were never sent.
+func (r *RetryingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	r.mu.Lock()
+	pending, ok := r.confirmTools[confirmationID]
+	delete(r.confirmTools, confirmationID)
+	r.mu.Unlock()
+	tool := pending.tool
+	if !ok {
+		tool = "confirm"
+	}
+
+	return r.do(ctx, tool, false, func() (*core.ExecuteResponse, error) {
+		return r.inner.Confirm(ctx, userID, confirmationID)
+	})
+}
+
+// Cancel cancels a pending confirmation, retrying on errors.
+func (r *RetryingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	r.mu.Lock()
+	delete(r.confirmTools, confirmationID)
+	r.mu.Unlock()
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = r.inner.Cancel(ctx, userID, confirmationID)
+		if err == nil || ctx.Err() != nil || attempt >= r.maxRetries {
+			return err
+		}
+		if r.wait(ctx, attempt) != nil {
+			return err
+		}
+	}
+}
+
+// StorePending records the confirmation's tool and passes the request on if
+// the inner executor caches pending writes (see core.PendingStore).
+func (r *RetryingExecutor) StorePending(confirmationID string, req *core.ExecuteRequest) {
+	r.mu.Lock()
+	now := time.Now()
+	dropStalePending(r.confirmTools, now)
+	r.confirmTools[confirmationID] = pendingTool{tool: req.Tool, storedAt: now}
+	r.mu.Unlock()
+
+	if ps, ok := r.inner.(core.PendingStore); ok {
+		ps.StorePending(confirmationID, req)
+	}
+}
+
+// do runs call with retries, guarded by the tool's circuit breaker.
+// read is true if the call is safe to repeat.
+func (r *RetryingExecutor) do(ctx context.Context, tool string, read bool, call func() (*core.ExecuteResponse, error)) (*core.ExecuteResponse, error) {
+	if check, _ := r.breaker.Check(ctx, tool); check != nil && !check.Allowed {
+		return nil, fmt.Errorf("%s: %w: %s", tool, ErrCircuitOpen, check.Warning)
+	}
+
+	var resp *core.ExecuteResponse
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = call()
+		if attempt >= r.maxRetries || !r.retryable(ctx, resp, err, read) {
+			break
+		}
+		log.Printf("[RETRY] %s attempt %d failed, retrying: %s", tool, attempt+1, failureReason(resp, err))
+		if r.wait(ctx, attempt) != nil {
+			break
+		}
+	}
+
+	if ctx.Err() == nil && transient(resp, err) {
+		r.breaker.RecordFailure(ctx, tool)
+	} else {
+		r.breaker.RecordSuccess(ctx, tool)
+	}
+	return resp, err
+}
+
+// retryable reports whether a failed call should be retried.
+func (r *RetryingExecutor) retryable(ctx context.Context, resp *core.ExecuteResponse, err error, read bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if !read {
+		return errors.Is(err, ErrNotSent)
+	}
+	return transient(resp, err)
+}
+
+// wait sleeps before the retry following attempt, or returns early if ctx is done.
+func (r *RetryingExecutor) wait(ctx context.Context, attempt int) error {
+	delay := r.baseDelay << attempt
+	if delay > r.maxDelay || delay <= 0 {
+		delay = r.maxDelay
+	}
+	// Jitter: between half and the full delay
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int63n(half+1))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// transient reports whether a call failed in a way that may succeed if
+// repeated: an error (other than cancellation), or an HTTP 429 or 5xx
+// response.
+func transient(resp *core.ExecuteResponse, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	if resp == nil || resp.Success {
+		return false
+	}
+	status := httpStatus(resp.Error)
+	return status == 429 || status >= 500
+}
+
+// httpStatus extracts the status code from an HTTPExecutor error response
+// ("HTTP 503: ..."), or returns 0.
+func httpStatus(msg string) int {
+	rest, ok := strings.CutPrefix(msg, "HTTP ")
+	if !ok {
+		return 0
+	}
+	code, _, _ := strings.Cut(rest, ":")
+	status, _ := strconv.Atoi(code)
+	return status
+}
+
+func failureReason(resp *core.ExecuteResponse, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Error
+}
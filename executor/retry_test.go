@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// flakyExecutor fails the first failures calls with resp/err, then succeeds.
+type flakyExecutor struct {
+	failures int
+	resp     *core.ExecuteResponse
+	err      error
+	calls    int
+}
+
+func (f *flakyExecutor) call() (*core.ExecuteResponse, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.resp, f.err
+	}
+	return &core.ExecuteResponse{Success: true}, nil
+}
+
+func (f *flakyExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return f.call()
+}
+
+func (f *flakyExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return f.call()
+}
+
+func (f *flakyExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return f.call()
+}
+
+func (f *flakyExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	_, err := f.call()
+	return err
+}
+
+func TestRetryingExecutor(t *testing.T) {
+	unavailable := &core.ExecuteResponse{Success: false, Error: "HTTP 503: unavailable"}
+	badRequest := &core.ExecuteResponse{Success: false, Error: "HTTP 400: bad amount"}
+	timeout := errors.New("request failed: timeout")
+	refused := fmt.Errorf("request failed: %w: connection refused", ErrNotSent)
+
+	tests := []struct {
+		name      string
+		write     bool
+		inner     *flakyExecutor
+		wantCalls int
+		wantOK    bool
+	}{
+		{"read retries 503", false, &flakyExecutor{failures: 2, resp: unavailable}, 3, true},
+		{"read retries error", false, &flakyExecutor{failures: 1, err: timeout}, 2, true},
+		{"read gives up", false, &flakyExecutor{failures: 5, err: timeout}, 3, false},
+		{"read doesn't retry 400", false, &flakyExecutor{failures: 1, resp: badRequest}, 1, false},
+		{"write doesn't retry timeout", true, &flakyExecutor{failures: 1, err: timeout}, 1, false},
+		{"write doesn't retry 503", true, &flakyExecutor{failures: 1, resp: unavailable}, 1, false},
+		{"write retries unsent", true, &flakyExecutor{failures: 1, err: refused}, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRetrying(tt.inner, WithBackoff(time.Millisecond, time.Millisecond))
+			req := &core.ExecuteRequest{UserID: "alice", Tool: "send_money"}
+
+			var resp *core.ExecuteResponse
+			var err error
+			if tt.write {
+				resp, err = r.ExecuteWrite(context.Background(), req)
+			} else {
+				resp, err = r.Execute(context.Background(), req)
+			}
+
+			if tt.inner.calls != tt.wantCalls {
+				t.Errorf("inner called %d times, want %d", tt.inner.calls, tt.wantCalls)
+			}
+			if ok := err == nil && resp.Success; ok != tt.wantOK {
+				t.Errorf("succeeded = %v (resp %+v, err %v), want %v", ok, resp, err, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryingExecutorCircuitBreaker(t *testing.T) {
+	inner := &flakyExecutor{failures: 100, err: errors.New("request failed: timeout")}
+	r := NewRetrying(inner, WithMaxRetries(0), WithCircuitBreaker(2, time.Minute))
+	ctx := context.Background()
+
+	r.Execute(ctx, &core.ExecuteRequest{Tool: "get_balance"})
+	r.Execute(ctx, &core.ExecuteRequest{Tool: "get_balance"})
+	_, err := r.Execute(ctx, &core.ExecuteRequest{Tool: "get_balance"})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Execute() after 2 failures error = %v, want ErrCircuitOpen", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("inner called %d times, want 2", inner.calls)
+	}
+
+	// Other tools have their own circuit.
+	if _, err := r.Execute(ctx, &core.ExecuteRequest{Tool: "get_vault_rates"}); errors.Is(err, ErrCircuitOpen) {
+		t.Error("get_vault_rates blocked by get_balance's circuit")
+	}
+}
+
+func TestRetryingExecutorDropsStaleConfirmations(t *testing.T) {
+	r := NewRetrying(&flakyExecutor{})
+	r.StorePending("conf-old", &core.ExecuteRequest{Tool: "send_money"})
+	r.StorePending("conf-kept", &core.ExecuteRequest{Tool: "send_money"})
+
+	r.mu.Lock()
+	old := r.confirmTools["conf-old"]
+	old.storedAt = time.Now().Add(-pendingTTL - time.Minute)
+	r.confirmTools["conf-old"] = old
+	r.mu.Unlock()
+
+	r.StorePending("conf-new", &core.ExecuteRequest{Tool: "send_money"})
+	if _, ok := r.confirmTools["conf-old"]; ok {
+		t.Error("confirmation older than pendingTTL was kept")
+	}
+	if len(r.confirmTools) != 2 {
+		t.Errorf("%d confirmations tracked, want the 2 recent ones", len(r.confirmTools))
+	}
+}
@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Router is a ToolExecutor that dispatches each call to a backend chosen by
+// tool name, so tools served by different APIs can share one executor:
+//
+//	router := executor.NewRouter(liminal).
+//		RoutePrefix("internal_", internalAPI).
+//		Route("lookup_customer", crm)
+//
+// An exact Route wins over a prefix, and the longest matching prefix wins
+// over shorter ones. Tools with no route go to the fallback.
+//
+// Confirm and Cancel only receive a confirmation ID, so the Router remembers
+// which backend each pending write belongs to (from ExecuteWrite responses
+// and StorePending). Unknown confirmation IDs go to the fallback.
+type Router struct {
+	fallback core.ToolExecutor
+
+	mu       sync.RWMutex
+	exact    map[string]core.ToolExecutor
+	prefixes []prefixRoute
+	pending  map[string]core.ToolExecutor // confirmationID -> backend
+}
+
+type prefixRoute struct {
+	prefix   string
+	executor core.ToolExecutor
+}
+
+// NewRouter creates a router that sends unrouted tools to fallback. If
+// fallback is nil, calls for unrouted tools fail.
+func NewRouter(fallback core.ToolExecutor) *Router {
+	return &Router{
+		fallback: fallback,
+		exact:    make(map[string]core.ToolExecutor),
+		pending:  make(map[string]core.ToolExecutor),
+	}
+}
+
+// Route sends calls for the named tool to executor.
+func (r *Router) Route(tool string, executor core.ToolExecutor) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exact[tool] = executor
+	return r
+}
+
+// RoutePrefix sends calls for tools whose name starts with prefix to executor.
+func (r *Router) RoutePrefix(prefix string, executor core.ToolExecutor) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefixes = append(r.prefixes, prefixRoute{prefix: prefix, executor: executor})
+	return r
+}
+
+// Execute runs a read-only tool on its backend.
+func (r *Router) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	backend, err := r.backend(req.Tool)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Execute(ctx, req)
+}
+
+// ExecuteWrite runs a write tool on its backend, remembering the backend for
+// any confirmation it returns.
+func (r *Router) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	backend, err := r.backend(req.Tool)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := backend.ExecuteWrite(ctx, req)
+	if err == nil && resp != nil && resp.RequiresConfirmation && resp.Confirmation != nil {
+		r.mu.Lock()
+		r.pending[resp.Confirmation.ID] = backend
+		r.mu.Unlock()
+	}
+	return resp, err
+}
+
+// Confirm executes a confirmed write on the backend that owns it.
+func (r *Router) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	backend, err := r.takePending(confirmationID)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Confirm(ctx, userID, confirmationID)
+}
+
+// Cancel cancels a pending write on the backend that owns it.
+func (r *Router) Cancel(ctx context.Context, userID, confirmationID string) error {
+	backend, err := r.takePending(confirmationID)
+	if err != nil {
+		return err
+	}
+	return backend.Cancel(ctx, userID, confirmationID)
+}
+
+// StorePending records the confirmation's backend and passes the request on
+// if that backend caches pending writes (see core.PendingStore).
+func (r *Router) StorePending(confirmationID string, req *core.ExecuteRequest) {
+	backend, err := r.backend(req.Tool)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	r.pending[confirmationID] = backend
+	r.mu.Unlock()
+
+	if ps, ok := backend.(core.PendingStore); ok {
+		ps.StorePending(confirmationID, req)
+	}
+}
+
+// backend returns the executor for tool.
+func (r *Router) backend(tool string) (core.ToolExecutor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if executor, ok := r.exact[tool]; ok {
+		return executor, nil
+	}
+	var match *prefixRoute
+	for i, route := range r.prefixes {
+		if strings.HasPrefix(tool, route.prefix) && (match == nil || len(route.prefix) > len(match.prefix)) {
+			match = &r.prefixes[i]
+		}
+	}
+	if match != nil {
+		return match.executor, nil
+	}
+	if r.fallback == nil {
+		return nil, fmt.Errorf("no executor routed for tool %q", tool)
+	}
+	return r.fallback, nil
+}
+
+// takePending removes and returns the backend for a confirmation.
+func (r *Router) takePending(confirmationID string) (core.ToolExecutor, error) {
+	r.mu.Lock()
+	backend, ok := r.pending[confirmationID]
+	delete(r.pending, confirmationID)
+	r.mu.Unlock()
+
+	if ok {
+		return backend, nil
+	}
+	if r.fallback == nil {
+		return nil, fmt.Errorf("no executor known for confirmation %q", confirmationID)
+	}
+	return r.fallback, nil
+}
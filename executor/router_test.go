@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	liminal := NewMock()
+	liminal.On("get_balance").Return(map[string]string{"totalUsd": "100.00"})
+	internal := NewMock()
+	internal.On("internal_lookup").Return(map[string]string{"tier": "gold"})
+	internal.On("internal_refund").RequireConfirmation().Return(map[string]string{"status": "refunded"})
+
+	router := NewRouter(liminal).RoutePrefix("internal_", internal)
+
+	if resp, err := router.Execute(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "get_balance"}); err != nil || !resp.Success {
+		t.Errorf("Execute(get_balance) = (%+v, %v), want success", resp, err)
+	}
+	if resp, err := router.Execute(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "internal_lookup"}); err != nil || string(resp.Data) != `{"tier":"gold"}` {
+		t.Errorf("Execute(internal_lookup) = (%+v, %v), want the internal backend's response", resp, err)
+	}
+	if len(liminal.Calls("get_balance")) != 1 || len(internal.Calls("get_balance")) != 0 {
+		t.Error("get_balance was not routed to the fallback only")
+	}
+	if len(internal.Calls("internal_lookup")) != 1 || len(liminal.Calls("internal_lookup")) != 0 {
+		t.Error("internal_lookup was not routed to the internal backend only")
+	}
+
+	// Confirmations go back to the backend that issued them.
+	resp, err := router.ExecuteWrite(ctx, &core.ExecuteRequest{UserID: "alice", Tool: "internal_refund"})
+	if err != nil || !resp.RequiresConfirmation {
+		t.Fatalf("ExecuteWrite(internal_refund) = (%+v, %v), want confirmation", resp, err)
+	}
+	if confirmed, err := router.Confirm(ctx, "alice", resp.Confirmation.ID); err != nil || !confirmed.Success {
+		t.Errorf("Confirm() = (%+v, %v), want success", confirmed, err)
+	}
+
+	// Engine-managed confirmations are registered with StorePending.
+	router.StorePending("action-1", &core.ExecuteRequest{UserID: "alice", Tool: "internal_refund"})
+	if confirmed, err := router.Confirm(ctx, "alice", "action-1"); err != nil || !confirmed.Success {
+		t.Errorf("Confirm(action-1) = (%+v, %v), want success", confirmed, err)
+	}
+	if got := len(internal.Calls("internal_refund")); got != 3 {
+		t.Errorf("internal_refund calls = %d, want 3 (execute_write and two confirms)", got)
+	}
+}
+
+func TestRouterPrecedence(t *testing.T) {
+	short, long, exact := NewMock(), NewMock(), NewMock()
+	for _, m := range []*MockExecutor{short, long, exact} {
+		m.On("liminal_send").Return(nil)
+		m.On("liminal_send_money").Return(nil)
+		m.On("liminal_get").Return(nil)
+	}
+	router := NewRouter(nil).
+		RoutePrefix("liminal_", short).
+		RoutePrefix("liminal_send", long).
+		Route("liminal_send_money", exact)
+
+	for tool, want := range map[string]*MockExecutor{
+		"liminal_get":        short,
+		"liminal_send":       long,
+		"liminal_send_money": exact,
+	} {
+		router.Execute(context.Background(), &core.ExecuteRequest{Tool: tool})
+		if len(want.Calls(tool)) != 1 {
+			t.Errorf("%s was not routed to the expected backend", tool)
+		}
+	}
+	if _, err := router.Execute(context.Background(), &core.ExecuteRequest{Tool: "other"}); err == nil {
+		t.Error("Execute(other) with no fallback error = nil, want error")
+	}
+}
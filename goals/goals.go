@@ -0,0 +1,123 @@
+// Package goals tracks users' savings goals and their progress, computed
+// from the user's Liminal savings balance (get_savings_balance).
+//
+// Goals live in a Store; SQLiteStore keeps them in a local database. Tools
+// returns the agent's tools for them:
+//
+//	store, err := goals.NewSQLiteStore("goals.db")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.AddTools(goals.Tools(store, liminalExecutor)...)
+package goals
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// ErrNotFound is returned for a goal the user doesn't have.
+var ErrNotFound = errors.New("savings goal not found")
+
+// Goal is a user's target for their savings in one currency.
+type Goal struct {
+	ID       string     `json:"id"`
+	UserID   string     `json:"user_id"`
+	Name     string     `json:"name"`     // e.g. "Emergency fund"; unique per user
+	Target   float64    `json:"target"`   // Amount to save
+	Currency string     `json:"currency"` // "USDC" or "EURC"
+	Deadline *time.Time `json:"deadline,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store persists savings goals.
+type Store interface {
+	// Set saves goal, replacing the user's goal of the same name if any.
+	// It fills in the goal's ID and timestamps.
+	Set(ctx context.Context, goal *Goal) error
+
+	// Get returns the user's goal called name, or ErrNotFound. Names match
+	// case-insensitively.
+	Get(ctx context.Context, userID, name string) (*Goal, error)
+
+	// List returns the user's goals, oldest first.
+	List(ctx context.Context, userID string) ([]*Goal, error)
+}
+
+// Progress is how far a goal's currency's savings are towards its target.
+type Progress struct {
+	Goal      *Goal   `json:"goal"`
+	Saved     float64 `json:"saved"`     // Current value of the savings in Goal.Currency
+	Remaining float64 `json:"remaining"` // Still to save; 0 once reached
+	Percent   float64 `json:"percent"`   // Saved as a percentage of the target, capped at 100
+	Reached   bool    `json:"reached"`
+
+	// DaysLeft and MonthlyNeeded are set for goals with a deadline.
+	// MonthlyNeeded is what saving evenly until the deadline takes, or all
+	// of Remaining once the deadline has passed.
+	DaysLeft      *int     `json:"days_left,omitempty"`
+	MonthlyNeeded *float64 `json:"monthly_needed,omitempty"`
+}
+
+// ComputeProgress measures goal against savings, the user's savings
+// balance. Every position in the goal's currency counts towards it, so
+// goals in the same currency share the same savings.
+func ComputeProgress(goal *Goal, savings *executor.GetSavingsBalanceResponse, now time.Time) *Progress {
+	p := &Progress{Goal: goal}
+	for _, pos := range savings.Positions {
+		if NormalizeCurrency(pos.Currency) != NormalizeCurrency(goal.Currency) {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(pos.CurrentValue), 64)
+		if err != nil {
+			value, _ = strconv.ParseFloat(strings.TrimSpace(pos.Deposited), 64)
+		}
+		p.Saved += value
+	}
+	p.Saved = round2(p.Saved)
+
+	p.Remaining = round2(math.Max(goal.Target-p.Saved, 0))
+	p.Reached = goal.Target > 0 && p.Saved >= goal.Target
+	if goal.Target > 0 {
+		p.Percent = round2(math.Min(p.Saved/goal.Target*100, 100))
+	}
+
+	if goal.Deadline != nil {
+		days := int(math.Ceil(goal.Deadline.Sub(now).Hours() / 24))
+		if days < 0 {
+			days = 0
+		}
+		monthly := p.Remaining
+		if months := float64(days) / 30.44; months > 1 {
+			monthly = round2(p.Remaining / months)
+		}
+		p.DaysLeft = &days
+		p.MonthlyNeeded = &monthly
+	}
+	return p
+}
+
+// NormalizeCurrency maps the ways users name a currency to the Liminal
+// stablecoin: "USD" and "dollars" to "USDC", "EUR" and "euros" to "EURC".
+func NormalizeCurrency(currency string) string {
+	switch c := strings.ToUpper(strings.TrimSpace(currency)); c {
+	case "USD", "DOLLARS", "$":
+		return "USDC"
+	case "EUR", "EUROS", "€":
+		return "EURC"
+	default:
+		return c
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
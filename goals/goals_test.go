@@ -0,0 +1,144 @@
+package goals
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestComputeProgress(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	deadline := now.AddDate(0, 6, 0)
+	passed := now.AddDate(0, 0, -3)
+	savings := &executor.GetSavingsBalanceResponse{Positions: []executor.SavingsPosition{
+		{Currency: "EURC", Deposited: "1100", CurrentValue: "1150.50"},
+		{Currency: "EURC", Deposited: "50", CurrentValue: "49.50"},
+		{Currency: "USDC", Deposited: "300", CurrentValue: "310"},
+	}}
+
+	tests := []struct {
+		name          string
+		goal          Goal
+		wantSaved     float64
+		wantPercent   float64
+		wantRemaining float64
+		wantReached   bool
+		wantDays      int
+		wantMonthly   float64
+	}{
+		{"sums positions in the currency", Goal{Target: 2000, Currency: "EURC"}, 1200, 60, 800, false, 0, 0},
+		{"matches EUR to EURC", Goal{Target: 2000, Currency: "eur"}, 1200, 60, 800, false, 0, 0},
+		{"reached caps at 100%", Goal{Target: 250, Currency: "USD"}, 310, 100, 0, true, 0, 0},
+		{"nothing saved", Goal{Target: 500, Currency: "GBP"}, 0, 0, 500, false, 0, 0},
+		{"deadline spreads the remainder", Goal{Target: 2000, Currency: "EURC", Deadline: &deadline}, 1200, 60, 800, false, 184, 132.35},
+		{"passed deadline needs it all now", Goal{Target: 2000, Currency: "EURC", Deadline: &passed}, 1200, 60, 800, false, 0, 800},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ComputeProgress(&tt.goal, savings, now)
+			if p.Saved != tt.wantSaved || p.Percent != tt.wantPercent || p.Remaining != tt.wantRemaining || p.Reached != tt.wantReached {
+				t.Errorf("progress = saved %v, %v%%, remaining %v, reached %v; want %v, %v%%, %v, %v",
+					p.Saved, p.Percent, p.Remaining, p.Reached, tt.wantSaved, tt.wantPercent, tt.wantRemaining, tt.wantReached)
+			}
+			if tt.goal.Deadline == nil {
+				if p.DaysLeft != nil || p.MonthlyNeeded != nil {
+					t.Error("progress without a deadline has days left or a monthly amount")
+				}
+				return
+			}
+			if p.DaysLeft == nil || p.MonthlyNeeded == nil {
+				t.Fatal("progress with a deadline has no days left or monthly amount")
+			}
+			if *p.DaysLeft != tt.wantDays || *p.MonthlyNeeded != tt.wantMonthly {
+				t.Errorf("progress = %d days left, %v a month; want %d, %v", *p.DaysLeft, *p.MonthlyNeeded, tt.wantDays, tt.wantMonthly)
+			}
+		})
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "goals.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+
+	deadline := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	fund := &Goal{UserID: "user-1", Name: "Emergency fund", Target: 1000, Currency: "EURC"}
+	if err := store.Set(ctx, fund); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	store.Set(ctx, &Goal{UserID: "user-2", Name: "Emergency fund", Target: 50, Currency: "USDC"})
+
+	// Setting a goal of the same name replaces it
+	update := &Goal{UserID: "user-1", Name: "emergency FUND", Target: 2000, Currency: "EURC", Deadline: &deadline}
+	if err := store.Set(ctx, update); err != nil {
+		t.Fatalf("Set() update error = %v", err)
+	}
+	if update.ID != fund.ID {
+		t.Errorf("updated goal ID = %s, want the original %s", update.ID, fund.ID)
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	goals, err := store.List(ctx, "user-1")
+	if err != nil || len(goals) != 1 {
+		t.Fatalf("List() = (%v, %v), want the one goal", goals, err)
+	}
+	got := goals[0]
+	if got.Target != 2000 || got.Deadline == nil || !got.Deadline.Equal(deadline) || got.Name != "emergency FUND" {
+		t.Errorf("List()[0] = %+v, want the updated goal", got)
+	}
+	if _, err := store.Get(ctx, "user-1", "Holiday"); err != ErrNotFound {
+		t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGoalTools(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "goals.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	defer store.Close()
+	mock := executor.NewMock()
+	mock.On("get_savings_balance").Return(executor.GetSavingsBalanceResponse{Positions: []executor.SavingsPosition{
+		{Currency: "EURC", Deposited: "1200", CurrentValue: "1200"},
+	}})
+	tools := Tools(store, mock)
+
+	call := func(tool core.Tool, input string) map[string]interface{} {
+		t.Helper()
+		result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(input)})
+		if err != nil || !result.Success {
+			t.Fatalf("%s(%s) = (%+v, %v), want success", tool.Name(), input, result, err)
+		}
+		data, _ := json.Marshal(result.Data)
+		var out map[string]interface{}
+		json.Unmarshal(data, &out)
+		return out
+	}
+
+	call(tools[0], `{"name": "Emergency fund", "target_amount": "2000", "currency": "EUR"}`)
+	progress := call(tools[1], `{"name": "emergency fund"}`)["goals"].([]interface{})[0].(map[string]interface{})
+	if progress["percent"] != 60.0 || progress["saved"] != "1200.00" || progress["currency"] != "EURC" {
+		t.Errorf("get_savings_progress = %v, want 60%% of the EURC goal", progress)
+	}
+	if list := call(tools[2], `{}`); list["count"] != 1.0 {
+		t.Errorf("list_goals = %v, want the one goal", list)
+	}
+
+	result, _ := tools[0].Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"name": "Car", "target_amount": "-5", "currency": "USDC"}`)})
+	if result.Success {
+		t.Error("set_savings_goal with a negative target succeeded")
+	}
+}
@@ -0,0 +1,142 @@
+package goals
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver, no cgo required
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS savings_goals (
+	id         TEXT PRIMARY KEY,
+	user_id    TEXT NOT NULL,
+	name       TEXT NOT NULL COLLATE NOCASE,
+	target     REAL NOT NULL,
+	currency   TEXT NOT NULL,
+	deadline   INTEGER,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL,
+	UNIQUE (user_id, name)
+);
+`
+
+// SQLiteStore is a Store backed by a SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create goals schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Set implements Store.
+func (s *SQLiteStore) Set(ctx context.Context, goal *Goal) error {
+	now := time.Now().UTC()
+	id := uuid.New().String()
+	var deadline sql.NullInt64
+	if goal.Deadline != nil {
+		deadline = sql.NullInt64{Int64: goal.Deadline.Unix(), Valid: true}
+	}
+
+	// Replacing a goal keeps its ID and creation time
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO savings_goals (id, user_id, name, target, currency, deadline, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, name) DO UPDATE SET
+			name = excluded.name,
+			target = excluded.target,
+			currency = excluded.currency,
+			deadline = excluded.deadline,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at`,
+		id, goal.UserID, strings.TrimSpace(goal.Name), goal.Target, goal.Currency, deadline, now.UnixMilli(), now.UnixMilli(),
+	)
+	var createdAt int64
+	if err := row.Scan(&goal.ID, &createdAt); err != nil {
+		return fmt.Errorf("failed to save goal: %w", err)
+	}
+	goal.Name = strings.TrimSpace(goal.Name)
+	goal.CreatedAt = time.UnixMilli(createdAt).UTC()
+	goal.UpdatedAt = now
+	return nil
+}
+
+// Get implements Store.
+func (s *SQLiteStore) Get(ctx context.Context, userID, name string) (*Goal, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, name, target, currency, deadline, created_at, updated_at
+		FROM savings_goals WHERE user_id = ? AND name = ?`,
+		userID, strings.TrimSpace(name),
+	)
+	goal, err := scanGoal(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return goal, err
+}
+
+// List implements Store.
+func (s *SQLiteStore) List(ctx context.Context, userID string) ([]*Goal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, name, target, currency, deadline, created_at, updated_at
+		FROM savings_goals WHERE user_id = ? ORDER BY created_at, name`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func scanGoal(row interface{ Scan(...interface{}) error }) (*Goal, error) {
+	var goal Goal
+	var deadline sql.NullInt64
+	var createdAt, updatedAt int64
+	err := row.Scan(&goal.ID, &goal.UserID, &goal.Name, &goal.Target, &goal.Currency, &deadline, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if deadline.Valid {
+		t := time.Unix(deadline.Int64, 0).UTC()
+		goal.Deadline = &t
+	}
+	goal.CreatedAt = time.UnixMilli(createdAt).UTC()
+	goal.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	return &goal, nil
+}
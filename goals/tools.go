@@ -0,0 +1,204 @@
+package goals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// Names of the savings goal tools.
+const (
+	SetGoalToolName      = "set_savings_goal"
+	GoalProgressToolName = "get_savings_progress"
+	ListGoalsToolName    = "list_goals"
+)
+
+// Tools returns the savings goal tools, storing goals in store and reading
+// savings balances through exec.
+func Tools(store Store, exec core.ToolExecutor) []core.Tool {
+	return []core.Tool{
+		NewSetGoalTool(store),
+		NewGoalProgressTool(store, exec),
+		NewListGoalsTool(store),
+	}
+}
+
+// NewSetGoalTool creates the set_savings_goal tool, which creates a savings
+// goal or updates the user's goal of the same name.
+func NewSetGoalTool(store Store) core.Tool {
+	return tools.New(SetGoalToolName).
+		Description("Create or update a savings goal, such as an emergency fund or a holiday. Setting a goal with an existing name replaces its target, currency and deadline. Progress is measured against the user's savings balance in the goal's currency.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"name":          tools.StringProperty("Short name for the goal (e.g., 'Emergency fund')"),
+			"target_amount": tools.StringProperty("Amount to save (e.g., '2000')"),
+			"currency":      tools.StringEnumProperty("Currency of the savings that count towards the goal. When users say 'USD' or 'dollars', use 'USDC'. When users say 'EUR' or 'euros', use 'EURC'.", "USDC", "EURC"),
+			"deadline":      tools.StringProperty("Optional: date to reach the goal by, as YYYY-MM-DD"),
+		}, "name", "target_amount", "currency")).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Name         string `json:"name"`
+				TargetAmount string `json:"target_amount"`
+				Currency     string `json:"currency"`
+				Deadline     string `json:"deadline"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+
+			goal := &Goal{
+				UserID:   params.UserID,
+				Name:     strings.TrimSpace(input.Name),
+				Currency: NormalizeCurrency(input.Currency),
+			}
+			if goal.Name == "" {
+				return &core.ToolResult{Success: false, Error: "name is required"}, nil
+			}
+			target, err := strconv.ParseFloat(strings.TrimSpace(input.TargetAmount), 64)
+			if err != nil || target <= 0 {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("target_amount must be a positive number, got %q", input.TargetAmount)}, nil
+			}
+			goal.Target = target
+			if input.Deadline != "" {
+				deadline, err := time.Parse("2006-01-02", input.Deadline)
+				if err != nil {
+					return &core.ToolResult{Success: false, Error: fmt.Sprintf("deadline must be a date as YYYY-MM-DD, got %q", input.Deadline)}, nil
+				}
+				goal.Deadline = &deadline
+			}
+
+			if err := store.Set(ctx, goal); err != nil {
+				return nil, err
+			}
+			return &core.ToolResult{Success: true, Data: goalData(goal)}, nil
+		}).
+		Build()
+}
+
+// NewGoalProgressTool creates the get_savings_progress tool, which reports
+// progress towards one or all of the user's goals from their savings
+// balance, fetched through exec (get_savings_balance).
+func NewGoalProgressTool(store Store, exec core.ToolExecutor) core.Tool {
+	return tools.New(GoalProgressToolName).
+		Description("Get the user's progress towards their savings goals: amount saved, amount remaining, percent complete and, for goals with a deadline, days left and the monthly saving needed to make it.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"name": tools.StringProperty("Optional: the goal to check; omit for all goals"),
+		})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: fmt.Sprintf("invalid input: %v", err)}, nil
+			}
+
+			var goals []*Goal
+			if input.Name != "" {
+				goal, err := store.Get(ctx, params.UserID, input.Name)
+				if errors.Is(err, ErrNotFound) {
+					return &core.ToolResult{Success: false, Error: fmt.Sprintf("no savings goal named %q", input.Name)}, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				goals = []*Goal{goal}
+			} else {
+				var err error
+				if goals, err = store.List(ctx, params.UserID); err != nil {
+					return nil, err
+				}
+			}
+			if len(goals) == 0 {
+				return &core.ToolResult{Success: true, Data: map[string]interface{}{"goals": []interface{}{}, "count": 0}}, nil
+			}
+
+			resp, err := exec.Execute(ctx, &core.ExecuteRequest{
+				UserID:    params.UserID,
+				Tool:      "get_savings_balance",
+				Input:     json.RawMessage(`{}`),
+				RequestID: params.RequestID,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch savings balance: %w", err)
+			}
+			if !resp.Success {
+				return &core.ToolResult{Success: false, Error: "failed to fetch savings balance: " + resp.Error}, nil
+			}
+			var savings executor.GetSavingsBalanceResponse
+			if err := json.Unmarshal(resp.Data, &savings); err != nil {
+				return nil, fmt.Errorf("failed to parse savings balance: %w", err)
+			}
+
+			now := time.Now()
+			progress := make([]map[string]interface{}, len(goals))
+			for i, goal := range goals {
+				progress[i] = progressData(ComputeProgress(goal, &savings, now))
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data:    map[string]interface{}{"goals": progress, "count": len(progress)},
+			}, nil
+		}).
+		Build()
+}
+
+// NewListGoalsTool creates the list_goals tool, which lists the user's
+// savings goals without their progress.
+func NewListGoalsTool(store Store) core.Tool {
+	return tools.New(ListGoalsToolName).
+		Description("List the user's savings goals with their targets and deadlines. Use get_savings_progress to see how close they are.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			goals, err := store.List(ctx, params.UserID)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]map[string]interface{}, len(goals))
+			for i, goal := range goals {
+				list[i] = goalData(goal)
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data:    map[string]interface{}{"goals": list, "count": len(list)},
+			}, nil
+		}).
+		Build()
+}
+
+// goalData formats goal for Claude, with amounts as strings like the
+// Liminal tools'.
+func goalData(goal *Goal) map[string]interface{} {
+	data := map[string]interface{}{
+		"name":     goal.Name,
+		"target":   formatAmount(goal.Target),
+		"currency": goal.Currency,
+	}
+	if goal.Deadline != nil {
+		data["deadline"] = goal.Deadline.Format("2006-01-02")
+	}
+	return data
+}
+
+func progressData(p *Progress) map[string]interface{} {
+	data := goalData(p.Goal)
+	data["saved"] = formatAmount(p.Saved)
+	data["remaining"] = formatAmount(p.Remaining)
+	data["percent"] = p.Percent
+	data["reached"] = p.Reached
+	if p.DaysLeft != nil {
+		data["days_left"] = *p.DaysLeft
+		data["monthly_needed"] = formatAmount(*p.MonthlyNeeded)
+	}
+	return data
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,102 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// Circuit breaker states, as reported in engine.GuardrailResult.CircuitState.
+const (
+	StateClosed   = "closed"    // Normal operation
+	StateOpen     = "open"      // Blocking requests until the cooldown passes
+	StateHalfOpen = "half-open" // Cooldown passed; the next result decides
+)
+
+// CircuitBreaker blocks a user's requests after repeated consecutive
+// failures. Once tripped, requests are blocked for the cooldown period;
+// after that the circuit is half-open and requests are let through again.
+// A success closes the circuit; a failure while half-open trips it again.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit // userID -> state; absent means closed
+	now      func() time.Time
+}
+
+type circuit struct {
+	failures int
+	state    string
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker that trips after
+// failureThreshold consecutive failures and stays open for cooldown.
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: failureThreshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+		now:       time.Now,
+	}
+}
+
+// Check blocks the request if the user's circuit is open.
+func (cb *CircuitBreaker) Check(ctx context.Context, userID string) (*engine.GuardrailResult, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[userID]
+	if !ok {
+		return &engine.GuardrailResult{Allowed: true, CircuitState: StateClosed, RemainingRequests: -1}, nil
+	}
+
+	if c.state == StateOpen {
+		retryAt := c.openedAt.Add(cb.cooldown)
+		if now := cb.now(); now.Before(retryAt) {
+			return &engine.GuardrailResult{
+				Allowed:      false,
+				Warning:      fmt.Sprintf("Too many recent failures. Please try again in %s.", roundUp(retryAt.Sub(now))),
+				CircuitState: StateOpen,
+				RetryAfter:   retryAt.Unix(),
+			}, nil
+		}
+		c.state = StateHalfOpen
+	}
+
+	return &engine.GuardrailResult{Allowed: true, CircuitState: c.state, RemainingRequests: -1}, nil
+}
+
+// RecordSuccess closes the user's circuit and resets the failure count.
+func (cb *CircuitBreaker) RecordSuccess(ctx context.Context, userID string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.circuits, userID)
+}
+
+// RecordFailure counts a failure, tripping the circuit at the threshold or
+// on any failure while half-open.
+func (cb *CircuitBreaker) RecordFailure(ctx context.Context, userID string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[userID]
+	if !ok {
+		c = &circuit{state: StateClosed}
+		cb.circuits[userID] = c
+	}
+	c.failures++
+
+	if c.state == StateHalfOpen || c.failures >= cb.threshold {
+		c.state = StateOpen
+		c.openedAt = cb.now()
+	}
+}
@@ -0,0 +1,13 @@
+// Package guardrails provides ready-made engine.Guardrails implementations:
+// a per-user token bucket rate limiter and a per-user circuit breaker, and
+// SpendingLimits, an engine.SpendingLimiter with per-transfer and daily caps.
+//
+// The rate limiter and circuit breaker keep state in memory, so limits apply
+// per process. For limits shared across instances, implement
+// engine.Guardrails against a shared store (e.g., Redis), or give
+// SpendingLimits a shared SpendingStore.
+//
+//	srv, _ := server.New(server.Config{
+//		Guardrails: guardrails.NewTokenBucket(rate.Every(6*time.Second), 5),
+//	})
+package guardrails
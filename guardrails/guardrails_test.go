@@ -0,0 +1,251 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/engine/enginetest"
+)
+
+func TestTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	b := NewTokenBucket(rate.Every(time.Minute), 2)
+	b.now = func() time.Time { return now }
+
+	for i, wantRemaining := range []int{1, 0} {
+		res, err := b.Check(ctx, "alice")
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if !res.Allowed || res.RemainingRequests != wantRemaining {
+			t.Errorf("Check() #%d = (allowed %v, remaining %d), want (true, %d)", i+1, res.Allowed, res.RemainingRequests, wantRemaining)
+		}
+	}
+
+	res, _ := b.Check(ctx, "alice")
+	if res.Allowed {
+		t.Fatal("Check() allowed request with empty bucket")
+	}
+	if want := now.Add(time.Minute).Unix(); res.RetryAfter != want {
+		t.Errorf("RetryAfter = %d, want %d", res.RetryAfter, want)
+	}
+	if res.Warning == "" {
+		t.Error("blocked result has no Warning")
+	}
+
+	// Other users have their own bucket.
+	if res, _ := b.Check(ctx, "bob"); !res.Allowed {
+		t.Error("Check(bob) blocked by alice's usage")
+	}
+
+	// A blocked request doesn't consume a token.
+	now = now.Add(time.Minute)
+	if res, _ := b.Check(ctx, "alice"); !res.Allowed {
+		t.Error("Check() blocked after refill")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	cb := NewCircuitBreaker(2, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	check := func(wantAllowed bool, wantState string) {
+		t.Helper()
+		res, err := cb.Check(ctx, "alice")
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if res.Allowed != wantAllowed || res.CircuitState != wantState {
+			t.Errorf("Check() = (%v, %q), want (%v, %q)", res.Allowed, res.CircuitState, wantAllowed, wantState)
+		}
+	}
+
+	cb.RecordFailure(ctx, "alice")
+	check(true, StateClosed)
+
+	cb.RecordFailure(ctx, "alice")
+	check(false, StateOpen)
+	if res, _ := cb.Check(ctx, "bob"); !res.Allowed {
+		t.Error("Check(bob) blocked by alice's circuit")
+	}
+
+	// After the cooldown one failure re-trips it.
+	now = now.Add(time.Minute)
+	check(true, StateHalfOpen)
+	cb.RecordFailure(ctx, "alice")
+	check(false, StateOpen)
+
+	// A success closes it.
+	now = now.Add(time.Minute)
+	check(true, StateHalfOpen)
+	cb.RecordSuccess(ctx, "alice")
+	check(true, StateClosed)
+	cb.RecordFailure(ctx, "alice")
+	check(true, StateClosed)
+}
+
+func TestSpendingLimits(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	l := NewSpendingLimits(SpendingConfig{
+		PerTransfer: map[string]float64{"send_money": 500},
+		Daily:       1000,
+	})
+	l.now = func() time.Time { return now }
+
+	send := func(amount string) json.RawMessage {
+		return json.RawMessage(`{"recipient":"@alice","amount":` + amount + `,"currency":"USDC"}`)
+	}
+	check := func(input json.RawMessage) *engine.SpendingResult {
+		t.Helper()
+		res, err := l.Check(ctx, "alice", "send_money", input)
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		return res
+	}
+
+	if res := check(send(`"600.00"`)); res.Allowed || res.Reason == "" {
+		t.Errorf("Check(600) = %+v, want blocked by per-transfer limit", res)
+	}
+	for _, amount := range []string{`"450"`, `450`} {
+		if res := check(send(amount)); !res.Allowed {
+			t.Fatalf("Check(%s) = %+v, want allowed", amount, res)
+		}
+		if err := l.Record(ctx, "alice", "send_money", send(amount)); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	// 900 sent today: 150 more would pass the daily cap
+	res := check(send(`"150"`))
+	if res.Allowed || !strings.Contains(res.Reason, "at most 100.00 more") {
+		t.Errorf("Check(150) = %+v, want blocked by daily limit", res)
+	}
+	if res, _ := l.Check(ctx, "bob", "send_money", send(`"150"`)); !res.Allowed {
+		t.Error("Check(bob) blocked by alice's spending")
+	}
+	if res, _ := l.Check(ctx, "alice", "get_balance", json.RawMessage(`{}`)); !res.Allowed {
+		t.Error("Check(get_balance) blocked; read tools don't move money")
+	}
+	if res := check(json.RawMessage(`{"amount":"lots"}`)); res.Allowed {
+		t.Error("Check() allowed an unparseable amount")
+	}
+
+	// The daily total resets at midnight UTC
+	now = now.Add(3 * time.Hour)
+	if res := check(send(`"150"`)); !res.Allowed {
+		t.Errorf("Check(150) next day = %+v, want allowed", res)
+	}
+}
+
+// plainSpendingStore hides MemorySpendingStore's AddWithin.
+type plainSpendingStore struct {
+	SpendingStore
+}
+
+func TestSpendingLimitsReserve(t *testing.T) {
+	ctx := context.Background()
+	send := json.RawMessage(`{"recipient":"@alice","amount":"30"}`)
+
+	for _, store := range []SpendingStore{NewMemorySpendingStore(), plainSpendingStore{NewMemorySpendingStore()}} {
+		l := NewSpendingLimits(SpendingConfig{Daily: 100, Store: store})
+
+		// Concurrent reservations can't together pass the daily cap
+		var wg sync.WaitGroup
+		var allowed atomic.Int32
+		var reservations sync.Map
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				res, reservation, err := l.Reserve(ctx, "alice", "send_money", send)
+				if err != nil {
+					t.Errorf("Reserve() error = %v", err)
+					return
+				}
+				if res.Allowed {
+					allowed.Add(1)
+					reservations.Store(i, reservation)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if allowed.Load() != 3 {
+			t.Errorf("%T: %d of 10 concurrent $30 reservations allowed, want 3 under a $100 cap", store, allowed.Load())
+		}
+
+		// Releasing a reservation gives its amount back
+		reservations.Range(func(_, reservation interface{}) bool {
+			reservation.(engine.SpendingReservation).Release(ctx)
+			return false
+		})
+		if spent, _ := store.Spent(ctx, "alice", time.Now()); spent != 60 {
+			t.Errorf("%T: spent after one release = %v, want 60", store, spent)
+		}
+	}
+}
+
+func TestSpendingLimitsConcurrentConfirmations(t *testing.T) {
+	limits := NewSpendingLimits(SpendingConfig{Daily: 100})
+	h := enginetest.New(t, engine.WithSpendingLimits(limits))
+	h.Claude.Queue(enginetest.Text("Done."), enginetest.Text("Done."))
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var executed atomic.Int32
+	h.Register(core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		ToolDescription:          "Send money",
+		InputSchema:              map[string]interface{}{"type": "object"},
+		RequiresUserConfirmation: true,
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		// Hold the first transfer in flight while the second is confirmed
+		if executed.Add(1) == 1 {
+			started <- struct{}{}
+			<-release
+		}
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	// Two $60 transfers confirmed at once, e.g. over a WebSocket and REST
+	confirm := func(id string) (*engine.Output, error) {
+		return h.Engine.RunConfirmedAction(context.Background(), &engine.Input{
+			Context: core.NewContext("user-1", "session-"+id, "conv-"+id, "req-"+id),
+			Model:   h.Model,
+		}, &core.PendingAction{ID: id, UserID: "user-1", Tool: "send_money", Input: json.RawMessage(`{"amount":"60"}`), BlockID: "toolu_" + id})
+	}
+	first := make(chan error, 1)
+	go func() {
+		_, err := confirm("1")
+		first <- err
+	}()
+	<-started
+
+	if _, err := confirm("2"); err != nil {
+		t.Fatalf("second RunConfirmedAction() error = %v", err)
+	}
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("first RunConfirmedAction() error = %v", err)
+	}
+
+	if executed.Load() != 1 {
+		t.Errorf("send_money executed %d times, want 1 under a $100 daily cap", executed.Load())
+	}
+	if spent, _ := limits.store.Spent(context.Background(), "user-1", time.Now()); spent != 60 {
+		t.Errorf("spent = %v, want 60", spent)
+	}
+}
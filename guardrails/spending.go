@@ -0,0 +1,316 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// SpendingStore holds each user's running spending totals per day.
+type SpendingStore interface {
+	// Spent returns the amount userID has spent on day.
+	Spent(ctx context.Context, userID string, day time.Time) (float64, error)
+
+	// Add adds amount to userID's total for day.
+	Add(ctx context.Context, userID string, day time.Time, amount float64) error
+}
+
+// AtomicSpendingStore is an optional interface for SpendingStores that can
+// check and add to a total in one step, e.g. with a conditional update in a
+// shared database. SpendingLimits uses it to reserve amounts, so a daily cap
+// holds across instances. With other stores, reservations are serialized
+// within the process only.
+type AtomicSpendingStore interface {
+	SpendingStore
+
+	// AddWithin adds amount to userID's total for day unless that would take
+	// it over limit. It returns the total before the add and whether amount
+	// was added.
+	AddWithin(ctx context.Context, userID string, day time.Time, amount, limit float64) (spent float64, added bool, err error)
+}
+
+// SpendingConfig configures SpendingLimits.
+type SpendingConfig struct {
+	// PerTransfer caps the amount of a single call, by tool name, e.g.
+	// {"send_money": 500}. Tools listed here count as moving money.
+	PerTransfer map[string]float64
+
+	// Daily caps the total a user moves per day (UTC) across all tools that
+	// move money. Zero means no daily cap.
+	Daily float64
+
+	// Tools are the tools that move money and count towards Daily, along
+	// with those in PerTransfer. Defaults to send_money. The amount is read
+	// from the tool input's "amount" field (a number or numeric string).
+	Tools []string
+
+	// Store holds running totals. Defaults to an in-memory store, which
+	// applies limits per process.
+	Store SpendingStore
+}
+
+// SpendingLimits is an engine.SpendingLimiter with per-transfer and per-day
+// caps. Amounts are compared as given, without currency conversion, so
+// limits suit dollar-pegged balances (USDC) or single-currency apps.
+//
+// It's also an engine.SpendingReserver: the engine reserves a confirmed
+// write's amount before executing it, so concurrent confirmations can't
+// together exceed the daily cap, and gives it back if the write fails.
+type SpendingLimits struct {
+	perTransfer map[string]float64
+	daily       float64
+	tools       map[string]bool
+	store       SpendingStore
+	now         func() time.Time
+
+	mu sync.Mutex // Serializes reservations against stores that aren't atomic
+}
+
+// NewSpendingLimits creates a spending limiter from cfg.
+//
+//	srv, _ := server.New(server.Config{
+//		SpendingLimits: guardrails.NewSpendingLimits(guardrails.SpendingConfig{
+//			PerTransfer: map[string]float64{"send_money": 500},
+//			Daily:       1000,
+//		}),
+//	})
+func NewSpendingLimits(cfg SpendingConfig) *SpendingLimits {
+	l := &SpendingLimits{
+		perTransfer: cfg.PerTransfer,
+		daily:       cfg.Daily,
+		tools:       make(map[string]bool),
+		store:       cfg.Store,
+		now:         time.Now,
+	}
+	if len(cfg.Tools) == 0 {
+		cfg.Tools = []string{"send_money"}
+	}
+	for _, tool := range cfg.Tools {
+		l.tools[tool] = true
+	}
+	for tool := range cfg.PerTransfer {
+		l.tools[tool] = true
+	}
+	if l.store == nil {
+		l.store = NewMemorySpendingStore()
+	}
+	return l
+}
+
+// Check blocks a call that exceeds the tool's per-transfer cap or would take
+// the user over the daily cap. Calls to tools that don't move money are
+// always allowed.
+func (l *SpendingLimits) Check(ctx context.Context, userID, tool string, input json.RawMessage) (*engine.SpendingResult, error) {
+	if !l.tools[tool] {
+		return &engine.SpendingResult{Allowed: true}, nil
+	}
+	amount, blocked := l.checkTransfer(tool, input)
+	if blocked != nil {
+		return blocked, nil
+	}
+
+	if l.daily > 0 {
+		spent, err := l.store.Spent(ctx, userID, l.now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load spending: %w", err)
+		}
+		if spent+amount > l.daily {
+			return l.overDaily(spent), nil
+		}
+	}
+	return &engine.SpendingResult{Allowed: true}, nil
+}
+
+// Reserve checks a call like Check and, if it's allowed, adds its amount to
+// the user's total for today in the same step. Releasing the reservation
+// takes the amount off again.
+func (l *SpendingLimits) Reserve(ctx context.Context, userID, tool string, input json.RawMessage) (*engine.SpendingResult, engine.SpendingReservation, error) {
+	if !l.tools[tool] {
+		return &engine.SpendingResult{Allowed: true}, nil, nil
+	}
+	amount, blocked := l.checkTransfer(tool, input)
+	if blocked != nil {
+		return blocked, nil, nil
+	}
+
+	limit := l.daily
+	if limit <= 0 {
+		limit = math.Inf(1)
+	}
+	day := l.now()
+	var spent float64
+	var added bool
+	var err error
+	if atomic, ok := l.store.(AtomicSpendingStore); ok {
+		spent, added, err = atomic.AddWithin(ctx, userID, day, amount, limit)
+	} else {
+		l.mu.Lock()
+		spent, err = l.store.Spent(ctx, userID, day)
+		if err == nil && spent+amount <= limit {
+			err = l.store.Add(ctx, userID, day, amount)
+			added = err == nil
+		}
+		l.mu.Unlock()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reserve spending: %w", err)
+	}
+	if !added {
+		return l.overDaily(spent), nil, nil
+	}
+	return &engine.SpendingResult{Allowed: true}, &spendingReservation{store: l.store, userID: userID, day: day, amount: amount}, nil
+}
+
+// checkTransfer reads the call's amount and checks it against the tool's
+// per-transfer cap. blocked is the result for a call that isn't allowed.
+func (l *SpendingLimits) checkTransfer(tool string, input json.RawMessage) (amount float64, blocked *engine.SpendingResult) {
+	amount, err := spendAmount(input)
+	if err != nil {
+		return 0, &engine.SpendingResult{Allowed: false, Reason: fmt.Sprintf("Can't check spending limits: %v.", err)}
+	}
+	if limit, ok := l.perTransfer[tool]; ok && amount > limit {
+		return 0, &engine.SpendingResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("%.2f is over the limit of %.2f per transfer.", amount, limit),
+		}
+	}
+	return amount, nil
+}
+
+func (l *SpendingLimits) overDaily(spent float64) *engine.SpendingResult {
+	return &engine.SpendingResult{
+		Allowed: false,
+		Reason:  fmt.Sprintf("Transfers are limited to %.2f per day and %.2f has already been sent today, so at most %.2f more can be sent.", l.daily, spent, max0(l.daily-spent)),
+	}
+}
+
+// Record adds a money-moving call to the user's total for today.
+func (l *SpendingLimits) Record(ctx context.Context, userID, tool string, input json.RawMessage) error {
+	if !l.tools[tool] {
+		return nil
+	}
+	amount, err := spendAmount(input)
+	if err != nil {
+		return err
+	}
+	return l.store.Add(ctx, userID, l.now(), amount)
+}
+
+// spendingReservation is an amount SpendingLimits.Reserve added to a day's
+// total.
+type spendingReservation struct {
+	store  SpendingStore
+	userID string
+	day    time.Time
+	amount float64
+}
+
+// Commit keeps the reserved amount, which already counts as spent.
+func (r *spendingReservation) Commit(ctx context.Context) error {
+	return nil
+}
+
+// Release takes the reserved amount off the day's total.
+func (r *spendingReservation) Release(ctx context.Context) error {
+	return r.store.Add(ctx, r.userID, r.day, -r.amount)
+}
+
+// spendAmount reads the "amount" field of a tool input.
+func spendAmount(input json.RawMessage) (float64, error) {
+	var params struct {
+		Amount json.Number `json:"amount"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return 0, errors.New("invalid input")
+	}
+	amount, err := strconv.ParseFloat(strings.TrimSpace(params.Amount.String()), 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid amount %q", params.Amount)
+	}
+	return amount, nil
+}
+
+func max0(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// MemorySpendingStore is an in-memory SpendingStore. It keeps only each
+// user's current day.
+type MemorySpendingStore struct {
+	mu     sync.Mutex
+	totals map[string]dailyTotal // userID -> total
+}
+
+type dailyTotal struct {
+	day   string // YYYY-MM-DD (UTC)
+	total float64
+}
+
+// NewMemorySpendingStore creates an empty in-memory spending store.
+func NewMemorySpendingStore() *MemorySpendingStore {
+	return &MemorySpendingStore{totals: make(map[string]dailyTotal)}
+}
+
+// Spent returns the user's total for day.
+func (s *MemorySpendingStore) Spent(ctx context.Context, userID string, day time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.totals[userID]
+	if t.day != dayKey(day) {
+		return 0, nil
+	}
+	return t.total, nil
+}
+
+// Add adds amount to the user's total for day, resetting it on a new day.
+// Amounts for days before the one kept are ignored.
+func (s *MemorySpendingStore) Add(ctx context.Context, userID string, day time.Time, amount float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(userID, dayKey(day), amount)
+	return nil
+}
+
+// AddWithin adds amount to the user's total for day unless that would take
+// it over limit.
+func (s *MemorySpendingStore) AddWithin(ctx context.Context, userID string, day time.Time, amount, limit float64) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := dayKey(day)
+	var spent float64
+	if t := s.totals[userID]; t.day == key {
+		spent = t.total
+	}
+	if spent+amount > limit {
+		return spent, false, nil
+	}
+	s.add(userID, key, amount)
+	return spent, true, nil
+}
+
+func (s *MemorySpendingStore) add(userID, key string, amount float64) {
+	t := s.totals[userID]
+	if t.day != key {
+		if key < t.day {
+			return
+		}
+		t = dailyTotal{day: key}
+	}
+	t.total += amount
+	s.totals[userID] = t
+}
+
+func dayKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,96 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// TokenBucket rate limits each user with their own token bucket.
+// Each request takes one token; tokens refill at the configured rate
+// up to burst.
+type TokenBucket struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter // userID -> bucket
+	now      func() time.Time
+}
+
+// NewTokenBucket creates a rate limiter allowing each user perUserRate
+// requests per second, with bursts of up to burst requests.
+// For example, rate.Every(6*time.Second) with a burst of 5 allows 10
+// requests a minute after an initial burst of 5.
+func NewTokenBucket(perUserRate rate.Limit, burst int) *TokenBucket {
+	return &TokenBucket{
+		limit:    perUserRate,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+		now:      time.Now,
+	}
+}
+
+// Check takes a token from the user's bucket, or blocks the request if the
+// bucket is empty.
+func (b *TokenBucket) Check(ctx context.Context, userID string) (*engine.GuardrailResult, error) {
+	lim := b.limiter(userID)
+	now := b.now()
+
+	r := lim.ReserveN(now, 1)
+	if !r.OK() {
+		return &engine.GuardrailResult{
+			Allowed:      false,
+			Warning:      "You've reached the request limit. Please try again later.",
+			CircuitState: StateClosed,
+		}, nil
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return &engine.GuardrailResult{
+			Allowed:      false,
+			Warning:      fmt.Sprintf("You're sending requests too quickly. Please try again in %s.", roundUp(delay)),
+			CircuitState: StateClosed,
+			RetryAfter:   now.Add(delay).Unix(),
+		}, nil
+	}
+
+	result := &engine.GuardrailResult{
+		Allowed:           true,
+		CircuitState:      StateClosed,
+		RemainingRequests: int(lim.TokensAt(now)),
+	}
+	if result.RemainingRequests == 0 {
+		result.Warning = "You're approaching the request limit."
+	}
+	return result, nil
+}
+
+// RecordSuccess is a no-op; every checked request counts against the limit.
+func (b *TokenBucket) RecordSuccess(ctx context.Context, userID string) {}
+
+// RecordFailure is a no-op; every checked request counts against the limit.
+func (b *TokenBucket) RecordFailure(ctx context.Context, userID string) {}
+
+// limiter returns the user's bucket, creating a full one on first use.
+func (b *TokenBucket) limiter(userID string) *rate.Limiter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lim, ok := b.limiters[userID]
+	if !ok {
+		lim = rate.NewLimiter(b.limit, b.burst)
+		b.limiters[userID] = lim
+	}
+	return lim
+}
+
+// roundUp rounds d up to the next whole second for display.
+func roundUp(d time.Duration) time.Duration {
+	return ((d + time.Second - 1) / time.Second) * time.Second
+}
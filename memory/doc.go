@@ -19,7 +19,7 @@
 //   - Focus on interface definitions for production swap
 //
 // Production Implementation (nim/agent):
-//   - pgvector store (PostgreSQL)
+//   - pgvector store (PostgreSQL, see memory/store/pgvector)
 //   - Voyage AI embedder (API-based)
 //   - Background jobs for decay and promotion
 package memory
@@ -0,0 +1,90 @@
+// Package fakesemantic provides a deterministic embedder with word-overlap
+// semantics, for tests that need to assert what memory retrieval returns.
+//
+// Each vocabulary word is an orthogonal basis vector; a text embeds to the
+// normalized sum of its words' vectors. Texts sharing words therefore have
+// high cosine similarity, and texts sharing none have zero similarity:
+//
+//	e := fakesemantic.New()
+//	a, _ := e.Embed(ctx, "send money to Alice")
+//	b, _ := e.Embed(ctx, "Action: send_money")    // similarity ~0.82 with a
+//	c, _ := e.Embed(ctx, "check savings balance") // similarity 0 with a
+//
+// Not for production use.
+package fakesemantic
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultVocabulary covers the words used by the Liminal tools and typical
+// banking requests.
+var DefaultVocabulary = []string{
+	"send", "money", "transfer", "pay", "payment", "recipient",
+	"balance", "wallet", "savings", "deposit", "withdraw", "yield",
+	"transactions", "spending", "search", "users", "profile",
+	"alice", "bob", "charlie",
+	"usd", "usdc", "eur", "eurc",
+	"failed", "insufficient", "funds", "successful", "confirmed",
+}
+
+// Embedder maps words to orthogonal basis vectors.
+type Embedder struct {
+	index map[string]int // word -> dimension
+}
+
+// New creates an embedder over vocabulary, or DefaultVocabulary if none is
+// given. Words are matched case-insensitively; other words are ignored.
+func New(vocabulary ...string) *Embedder {
+	if len(vocabulary) == 0 {
+		vocabulary = DefaultVocabulary
+	}
+	index := make(map[string]int, len(vocabulary))
+	for _, word := range vocabulary {
+		word = strings.ToLower(word)
+		if _, ok := index[word]; !ok {
+			index[word] = len(index)
+		}
+	}
+	return &Embedder{index: index}
+}
+
+// Embed returns the normalized sum of the basis vectors of the vocabulary
+// words in text. Text with no vocabulary words embeds to a reserved
+// dimension, so it is only similar to other such text.
+func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embedding := make([]float32, e.Dimensions())
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	matched := false
+	for _, word := range words {
+		if i, ok := e.index[word]; ok {
+			embedding[i]++
+			matched = true
+		}
+	}
+	if !matched {
+		embedding[len(e.index)] = 1
+		return embedding, nil
+	}
+
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	norm = math.Sqrt(norm)
+	for i := range embedding {
+		embedding[i] = float32(float64(embedding[i]) / norm)
+	}
+	return embedding, nil
+}
+
+// Dimensions returns the vocabulary size plus one reserved dimension.
+func (e *Embedder) Dimensions() int {
+	return len(e.index) + 1
+}
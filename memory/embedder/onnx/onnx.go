@@ -6,7 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"os"
 	"strings"
@@ -34,6 +34,16 @@ type Config struct {
 
 	// Dimensions is the embedding vector size (default: 384 for all-MiniLM-L6-v2).
 	Dimensions int
+
+	// QueryPrefix and DocumentPrefix are prepended to queries (EmbedQuery)
+	// and documents (Embed) for models trained with asymmetric prefixes,
+	// e.g. "query: " and "passage: " for E5. Leave empty for symmetric
+	// models like all-MiniLM-L6-v2.
+	QueryPrefix    string
+	DocumentPrefix string
+
+	// Logger receives the embedder's structured logs (default slog.Default()).
+	Logger *slog.Logger
 }
 
 // ONNXEmbedder generates embeddings using ONNX Runtime.
@@ -41,6 +51,11 @@ type ONNXEmbedder struct {
 	session    *ort.DynamicAdvancedSession
 	tokenizer  *BERTTokenizer
 	dimensions int
+
+	queryPrefix    string
+	documentPrefix string
+
+	logger *slog.Logger
 }
 
 // New creates a new ONNX embedder.
@@ -52,6 +67,9 @@ func New(cfg Config) (*ONNXEmbedder, error) {
 	if cfg.Dimensions == 0 {
 		cfg.Dimensions = 384 // Default for all-MiniLM-L6-v2
 	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
 
 	// Initialize ONNX Runtime
 	ort.SetSharedLibraryPath("/home/jack/.local/lib/onnxruntime/libonnxruntime.so")
@@ -82,9 +100,7 @@ func New(cfg Config) (*ONNXEmbedder, error) {
 
 	producer, _ := metadata.GetProducerName()
 	version, _ := metadata.GetVersion()
-	log.Printf("[ONNX] Model metadata:")
-	log.Printf("[ONNX]   Producer: %s", producer)
-	log.Printf("[ONNX]   Version: %d", version)
+	cfg.Logger.Info("loaded ONNX model", "model", cfg.ModelPath, "producer", producer, "version", version)
 
 	// Clean up temp session and metadata
 	metadata.Destroy()
@@ -107,11 +123,26 @@ func New(cfg Config) (*ONNXEmbedder, error) {
 		session:    session,
 		tokenizer:  tokenizer,
 		dimensions: cfg.Dimensions,
+
+		queryPrefix:    cfg.QueryPrefix,
+		documentPrefix: cfg.DocumentPrefix,
+
+		logger: cfg.Logger,
 	}, nil
 }
 
-// Embed converts text to embedding vector.
+// Embed converts a document to embedding vector.
 func (e *ONNXEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.embed(ctx, e.documentPrefix+text)
+}
+
+// EmbedQuery converts a search query to embedding vector.
+func (e *ONNXEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return e.embed(ctx, e.queryPrefix+text)
+}
+
+// embed runs the model on text.
+func (e *ONNXEmbedder) embed(ctx context.Context, text string) ([]float32, error) {
 	// Tokenize text using BERT tokenizer
 	tokens := e.tokenizer.Tokenize(text)
 
@@ -173,7 +204,6 @@ func (e *ONNXEmbedder) Embed(ctx context.Context, text string) ([]float32, error
 		return nil, fmt.Errorf("ONNX inference failed: %w", err)
 	}
 
-	log.Printf("[ONNX] Inference successful, got %d outputs", len(outputTensors))
 	defer func() {
 		for _, output := range outputTensors {
 			if output != nil {
@@ -194,7 +224,7 @@ func (e *ONNXEmbedder) Embed(ctx context.Context, text string) ([]float32, error
 
 	outputData := outputTensor.GetData()
 	outputShape := outputTensor.GetShape()
-	log.Printf("[ONNX] Output shape: %v, data length: %d", outputShape, len(outputData))
+	e.logger.DebugContext(ctx, "ONNX inference", "outputs", len(outputTensors), "shape", outputShape, "values", len(outputData))
 
 	// Check if output is already pooled (shape: [1, 384]) or needs pooling (shape: [1, 128, 384])
 	var embedding []float32
@@ -0,0 +1,260 @@
+// Package openai provides an embedder for any server implementing the
+// OpenAI embeddings API (POST /v1/embeddings), such as OpenAI itself,
+// Ollama, LM Studio or text-embeddings-inference. It needs no native
+// libraries, unlike the ONNX embedder:
+//
+//	embedder, err := openai.New(openai.Config{
+//		BaseURL:    "http://localhost:11434/v1",
+//		Model:      "nomic-embed-text",
+//		Dimensions: 768,
+//	})
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+// Config configures the embedder.
+type Config struct {
+	// BaseURL is the API's base URL, up to but not including /embeddings
+	// (default: "https://api.openai.com/v1").
+	BaseURL string
+
+	// APIKey is sent as a bearer token. Local servers usually need none.
+	APIKey string
+
+	// Model is the embedding model to request. Required.
+	Model string
+
+	// Dimensions is the size of the model's vectors. Required. Every
+	// response is checked against it; it isn't sent, as not every server
+	// can shorten vectors.
+	Dimensions int
+
+	// QueryPrefix and DocumentPrefix are prepended to queries (EmbedQuery)
+	// and documents (Embed, EmbedBatch) for models trained with asymmetric
+	// prefixes, e.g. "query: " and "passage: " for E5.
+	QueryPrefix    string
+	DocumentPrefix string
+
+	// MaxRetries is how many times a rate-limited (429) or failed (5xx)
+	// request is retried (default: 3; negative for none). Retries honor
+	// Retry-After and otherwise back off exponentially from one second.
+	MaxRetries int
+
+	// HTTPClient sends the requests (default: a client with a 30s timeout).
+	HTTPClient *http.Client
+
+	// Logger receives the embedder's structured logs (default slog.Default()).
+	Logger *slog.Logger
+}
+
+// Embedder embeds text through an OpenAI-compatible embeddings endpoint.
+type Embedder struct {
+	url        string
+	apiKey     string
+	model      string
+	dimensions int
+
+	queryPrefix    string
+	documentPrefix string
+
+	maxRetries int
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+// New creates an embedder.
+func New(cfg Config) (*Embedder, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("Model is required")
+	}
+	if cfg.Dimensions <= 0 {
+		return nil, fmt.Errorf("Dimensions is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.openai.com/v1"
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Embedder{
+		url:        strings.TrimRight(cfg.BaseURL, "/") + "/embeddings",
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		dimensions: cfg.Dimensions,
+
+		queryPrefix:    cfg.QueryPrefix,
+		documentPrefix: cfg.DocumentPrefix,
+
+		maxRetries: max(cfg.MaxRetries, 0),
+		client:     cfg.HTTPClient,
+		logger:     cfg.Logger,
+	}, nil
+}
+
+// Embed converts a document to embedding vector.
+func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{e.documentPrefix + text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedQuery converts a search query to embedding vector.
+func (e *Embedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{e.queryPrefix + text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch converts documents to embedding vectors in one request. The
+// vectors are in the same order as texts.
+func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	inputs := make([]string, len(texts))
+	for i, text := range texts {
+		inputs[i] = e.documentPrefix + text
+	}
+	return e.embed(ctx, inputs)
+}
+
+// Dimensions returns the embedding size.
+func (e *Embedder) Dimensions() int {
+	return e.dimensions
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embed requests embeddings for inputs, retrying rate limits and server
+// errors, and checks each vector's size.
+func (e *Embedder) embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{"model": e.model, "input": inputs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var data []byte
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		data, retryAfter, err = e.post(ctx, body)
+		if err == nil {
+			break
+		}
+		if retryAfter < 0 || attempt >= e.maxRetries {
+			return nil, err
+		}
+		if retryAfter == 0 {
+			retryAfter = time.Second << attempt
+		}
+		e.logger.WarnContext(ctx, "embeddings request failed, retrying", "model", e.model, "attempt", attempt+1, "retry_in", retryAfter, "error", err)
+		select {
+		case <-time.After(retryAfter):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	var resp embeddingsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings response: %w", err)
+	}
+	if len(resp.Data) != len(inputs) {
+		return nil, fmt.Errorf("embeddings response has %d vectors for %d inputs", len(resp.Data), len(inputs))
+	}
+	sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+
+	vectors := make([][]float32, len(resp.Data))
+	for i, d := range resp.Data {
+		if len(d.Embedding) != e.dimensions {
+			return nil, fmt.Errorf("%w: model %s returned %d dimensions, configured for %d",
+				memory.ErrDimensionMismatch, e.model, len(d.Embedding), e.dimensions)
+		}
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// post sends one embeddings request. On failure, retryAfter is how long to
+// wait before retrying (0 for the default backoff), or negative if the
+// request shouldn't be retried.
+func (e *Embedder) post(ctx context.Context, body []byte) (data []byte, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, -1, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		return nil, 0, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read embeddings response: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return data, 0, nil
+	}
+
+	err = fmt.Errorf("embeddings request failed (status %d): %s", resp.StatusCode, errorMessage(data))
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return nil, -1, err
+	}
+	if seconds, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && seconds >= 0 {
+		// A zero Retry-After retries at once rather than with the backoff
+		return nil, max(time.Duration(seconds)*time.Second, time.Nanosecond), err
+	}
+	return nil, 0, err
+}
+
+// errorMessage extracts the message from an OpenAI-style error body, or
+// returns the body itself.
+func errorMessage(data []byte) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		return body.Error.Message
+	}
+	return strings.TrimSpace(string(data))
+}
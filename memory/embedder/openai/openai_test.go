@@ -0,0 +1,144 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+// fakeServer is an OpenAI-compatible embeddings endpoint. It embeds each
+// input as [len(input), index, ...] padded to dims, listing the vectors in
+// reverse order, and first answers with each of the queued failure statuses.
+type fakeServer struct {
+	dims     int
+	failures []int
+
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	auth     []string
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&req)
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	var status int
+	if len(f.failures) > 0 {
+		status, f.failures = f.failures[0], f.failures[1:]
+	}
+	f.mu.Unlock()
+
+	if r.URL.Path != "/v1/embeddings" {
+		http.NotFound(w, r)
+		return
+	}
+	if status != 0 {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error": {"message": "slow down", "type": "rate_limit"}}`))
+		return
+	}
+
+	inputs := req["input"].([]interface{})
+	data := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		vector := make([]float32, f.dims)
+		vector[0] = float32(len(input.(string)))
+		vector[1] = float32(i)
+		data[len(inputs)-1-i] = map[string]interface{}{"object": "embedding", "index": i, "embedding": vector}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": data, "model": req["model"]})
+}
+
+func newTestEmbedder(t *testing.T, fake *fakeServer, cfg Config) *Embedder {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL + "/v1/"
+	if cfg.Model == "" {
+		cfg.Model = "nomic-embed-text"
+	}
+	if cfg.Dimensions == 0 {
+		cfg.Dimensions = 4
+	}
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return e
+}
+
+func TestEmbedBatch(t *testing.T) {
+	fake := &fakeServer{dims: 4}
+	e := newTestEmbedder(t, fake, Config{APIKey: "sk-test", DocumentPrefix: "passage: ", QueryPrefix: "query: "})
+
+	vectors, err := e.EmbedBatch(context.Background(), []string{"a", "bbb", "cc"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	for i, want := range []float32{10, 12, 11} { // len("passage: ") + len(text)
+		if len(vectors[i]) != 4 || vectors[i][0] != want || vectors[i][1] != float32(i) {
+			t.Errorf("vectors[%d] = %v, want the vector for input %d", i, vectors[i], i)
+		}
+	}
+
+	query, err := e.EmbedQuery(context.Background(), "send money")
+	if err != nil || query[0] != float32(len("query: send money")) {
+		t.Errorf("EmbedQuery() = (%v, %v), want the prefixed query's vector", query, err)
+	}
+
+	if len(fake.requests) != 2 || fake.requests[0]["model"] != "nomic-embed-text" || fake.auth[0] != "Bearer sk-test" {
+		t.Errorf("requests = %v with auth %v, want 2 for the model with the API key", fake.requests, fake.auth)
+	}
+	if e.Dimensions() != 4 {
+		t.Errorf("Dimensions() = %d, want 4", e.Dimensions())
+	}
+}
+
+func TestEmbedRetriesRateLimits(t *testing.T) {
+	fake := &fakeServer{dims: 4, failures: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}}
+	e := newTestEmbedder(t, fake, Config{})
+
+	if _, err := e.Embed(context.Background(), "hello"); err != nil {
+		t.Fatalf("Embed() error = %v, want success after retries", err)
+	}
+	if len(fake.requests) != 3 {
+		t.Errorf("sent %d requests, want 3", len(fake.requests))
+	}
+
+	// Out of retries, the API's error surfaces
+	fake.failures = []int{http.StatusTooManyRequests, http.StatusTooManyRequests}
+	e = newTestEmbedder(t, fake, Config{MaxRetries: 1})
+	_, err := e.Embed(context.Background(), "hello")
+	if err == nil || !strings.Contains(err.Error(), "429") || !strings.Contains(err.Error(), "slow down") {
+		t.Errorf("Embed() error = %v, want the 429 and its message", err)
+	}
+}
+
+func TestEmbedErrors(t *testing.T) {
+	// Client errors aren't retried
+	fake := &fakeServer{dims: 4, failures: []int{http.StatusBadRequest}}
+	e := newTestEmbedder(t, fake, Config{})
+	if _, err := e.Embed(context.Background(), "hello"); err == nil || len(fake.requests) != 1 {
+		t.Errorf("Embed() after a 400 = %v in %d requests, want an error without retrying", err, len(fake.requests))
+	}
+
+	// A model with other dimensions than configured
+	e = newTestEmbedder(t, &fakeServer{dims: 768}, Config{Dimensions: 384})
+	if _, err := e.Embed(context.Background(), "hello"); !errors.Is(err, memory.ErrDimensionMismatch) {
+		t.Errorf("Embed() error = %v, want ErrDimensionMismatch", err)
+	}
+
+	if _, err := New(Config{Model: "m"}); err == nil {
+		t.Error("New() without Dimensions succeeded")
+	}
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Export returns all of the user's stored traces, oldest first, e.g. for
+// offline analysis of failure patterns or for backups. Each trace's ID is the
+// ID of the memory it was stored as, and its Metadata holds the stored
+// metadata (error_type, prevention, ...). Memories of other types are
+// skipped. Requires a store that implements Lister.
+func (m *SimpleManager) Export(ctx context.Context, userID string) ([]*core.Trace, error) {
+	lister, ok := m.store.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("store %T does not support listing memories", m.store)
+	}
+	memories, err := lister.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	var traces []*core.Trace
+	for _, mem := range memories {
+		if tm, ok := mem.(*TraceMemory); ok {
+			traces = append(traces, tm.Trace())
+		}
+	}
+	m.logger.DebugContext(ctx, "exported traces", "user_id", userID, "memories", len(memories), "traces", len(traces))
+	return traces, nil
+}
+
+// Import embeds and stores traces as the user's memories, e.g. to restore a
+// backup made with Export or to move memories to another store. Unlike Record,
+// every trace is stored, without filtering. Traces keep their ID and
+// Timestamp when set, so importing into a store that replaces memories by ID
+// (such as pgvector) is idempotent. Traces that fail to embed or store are
+// skipped and their errors returned together.
+func (m *SimpleManager) Import(ctx context.Context, userID string, traces []*core.Trace) error {
+	if m.dimErr != nil {
+		return m.dimErr
+	}
+	var errs []error
+	imported := 0
+	for _, trace := range traces {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		mem := NewTraceMemory(userID, trace.SessionID, trace)
+		if trace.ID != "" {
+			mem.id = trace.ID
+		}
+		if trace.Timestamp > 0 {
+			mem.createdAt = time.Unix(trace.Timestamp, 0)
+		}
+
+		embedding, err := m.embedder.Embed(ctx, mem.FormatForEmbedding())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("embed trace %s: %w", mem.ID(), err))
+			continue
+		}
+		mem.SetEmbedding(embedding)
+
+		if err := m.store.Store(ctx, mem); err != nil {
+			errs = append(errs, fmt.Errorf("store trace %s: %w", mem.ID(), err))
+			continue
+		}
+		imported++
+	}
+	m.logger.DebugContext(ctx, "imported traces", "user_id", userID, "traces", len(traces), "imported", imported)
+	return errors.Join(errs...)
+}
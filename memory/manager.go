@@ -2,9 +2,13 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/becomeliminal/nim-go-sdk/core"
 )
@@ -27,18 +31,42 @@ type SimpleManager struct {
 	store    Store
 	embedder Embedder // Internal: Engine never sees this
 	config   *Config
+	logger   *slog.Logger
+	dimErr   error // Set if the embedder doesn't match the store's dimensions
+
+	mu        sync.Mutex
+	retrieved map[string][]Memory // userID -> memories from the last Retrieve, for MarkUsed
 }
 
 // NewSimpleManager creates a new SimpleManager.
+//
+// If the store reports its dimensions (see DimensionReporter) and they differ
+// from embedder.Dimensions(), the mismatch is logged and Retrieve, Record and
+// Import fail with ErrDimensionMismatch rather than mixing incompatible
+// vectors.
 func NewSimpleManager(store Store, embedder Embedder, config *Config) *SimpleManager {
 	if config == nil {
 		config = DefaultConfig
 	}
-	return &SimpleManager{
-		store:    store,
-		embedder: embedder,
-		config:   config,
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
+	m := &SimpleManager{
+		store:     store,
+		embedder:  embedder,
+		config:    config,
+		logger:    logger,
+		retrieved: make(map[string][]Memory),
+	}
+	if dr, ok := store.(DimensionReporter); ok {
+		if want := dr.Dimensions(); want > 0 && embedder.Dimensions() != want {
+			m.dimErr = fmt.Errorf("%w: embedder produces %d dimensions, store expects %d",
+				ErrDimensionMismatch, embedder.Dimensions(), want)
+			logger.Error("memory embedder doesn't match store", "error", m.dimErr)
+		}
+	}
+	return m
 }
 
 // Retrieve finds relevant memories and returns formatted string.
@@ -46,28 +74,122 @@ func (m *SimpleManager) Retrieve(ctx context.Context, userID string, userMessage
 	if !m.config.Enabled {
 		return "", nil // Memory disabled
 	}
+	if m.dimErr != nil {
+		return "", m.dimErr
+	}
+
+	// Skip the round trip for users with no memories yet
+	if checker, ok := m.store.(MemoryChecker); ok {
+		has, err := checker.HasMemories(ctx, userID)
+		if err != nil {
+			return "", fmt.Errorf("check memories: %w", err)
+		}
+		if !has {
+			m.logger.DebugContext(ctx, "no memories stored, skipping retrieval", "user_id", userID)
+			return "", nil
+		}
+	}
 
 	// Embed query
-	embedding, err := m.embedder.Embed(ctx, userMessage)
+	embedding, err := m.embedQuery(ctx, userMessage)
 	if err != nil {
 		return "", fmt.Errorf("embed query: %w", err)
 	}
 
-	// Query store for top 10 memories
-	memories, err := m.store.Query(ctx, userID, embedding, 10)
+	// Over-fetch candidates, then keep the best by similarity and importance
+	candidates, err := m.store.Query(ctx, userID, embedding, m.config.candidates())
 	if err != nil {
 		return "", fmt.Errorf("query store: %w", err)
 	}
+	selected := selectTopK(candidates, embedding, m.config.topK())
+	memories := make([]Memory, len(selected))
+	for i, s := range selected {
+		memories[i] = s.mem
+	}
+
+	m.mu.Lock()
+	m.retrieved[userID] = memories
+	m.mu.Unlock()
 
 	// Log retrieval
-	log.Printf("[MEMORY] Retrieved %d memories for query: %q", len(memories), truncateLog(userMessage, 50))
+	m.logger.DebugContext(ctx, "retrieved memories", "user_id", userID, "memories", len(memories), "candidates", len(candidates), "query", truncateLog(userMessage, 50))
 	if len(memories) == 0 {
-		log.Printf("[MEMORY]   No memories found")
 		return "", nil
 	}
 
 	// Format memories
-	return m.formatMemories(memories, userID, userMessage), nil
+	return m.formatMemories(selected, userID, userMessage), nil
+}
+
+// embedQuery embeds a retrieval query, using the embedder's query mode if
+// it has one (see QueryEmbedder).
+func (m *SimpleManager) embedQuery(ctx context.Context, text string) ([]float32, error) {
+	if qe, ok := m.embedder.(QueryEmbedder); ok {
+		return qe.EmbedQuery(ctx, text)
+	}
+	return m.embedder.Embed(ctx, text)
+}
+
+// Promote adds delta to a memory's importance (negative to demote), so
+// useful memories rank higher and survive decay. Requires a store that
+// implements ImportanceUpdater.
+func (m *SimpleManager) Promote(ctx context.Context, userID string, memoryID string, delta float64) error {
+	updater, ok := m.store.(ImportanceUpdater)
+	if !ok {
+		return fmt.Errorf("store %T does not support importance updates", m.store)
+	}
+	importance, err := updater.UpdateImportance(ctx, userID, memoryID, delta)
+	if err != nil {
+		return fmt.Errorf("promote memory %s: %w", memoryID, err)
+	}
+	m.logger.DebugContext(ctx, "promoted memory", "user_id", userID, "memory_id", memoryID, "delta", delta, "importance", importance)
+	return nil
+}
+
+// MarkUsed promotes the memories of action from the user's last retrieval
+// by Config.UsagePromotion. Each retrieved memory is promoted at most once.
+func (m *SimpleManager) MarkUsed(ctx context.Context, userID string, action string) error {
+	delta := m.config.usagePromotion()
+	if !m.config.Enabled || delta <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	var used, rest []Memory
+	for _, mem := range m.retrieved[userID] {
+		if memoryAction(mem) == action {
+			used = append(used, mem)
+		} else {
+			rest = append(rest, mem)
+		}
+	}
+	m.retrieved[userID] = rest
+	m.mu.Unlock()
+
+	var errs []error
+	for _, mem := range used {
+		if err := m.Promote(ctx, userID, mem.ID(), delta); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Ping checks the store is reachable, if it supports Ping (see Pinger).
+func (m *SimpleManager) Ping(ctx context.Context) error {
+	if p, ok := m.store.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// memoryAction returns the tool action a memory records, if any.
+func memoryAction(mem Memory) string {
+	if trace, ok := mem.(*TraceMemory); ok {
+		return trace.Action
+	}
+	action, _ := mem.Metadata()["action"].(string)
+	return action
 }
 
 // Record stores a complete interaction as memory.
@@ -77,18 +199,21 @@ func (m *SimpleManager) Record(ctx context.Context, userID string, interaction *
 	if !m.config.Enabled {
 		return nil // Memory disabled
 	}
+	if m.dimErr != nil {
+		return m.dimErr
+	}
 
 	// Filter traces worth storing
 	storableTraces := m.filterStorableTraces(interaction.Traces)
 	if len(storableTraces) == 0 {
-		log.Printf("[MEMORY] No traces worth storing (filtered out)")
+		m.logger.DebugContext(ctx, "no traces worth storing", "user_id", userID, "traces", len(interaction.Traces))
 		return nil
 	}
 
-	log.Printf("[MEMORY] Recording %d traces (filtered from %d)", len(storableTraces), len(interaction.Traces))
+	m.logger.DebugContext(ctx, "recording traces", "user_id", userID, "storable", len(storableTraces), "traces", len(interaction.Traces))
 
 	// Convert traces to memories and embed them
-	for i, trace := range storableTraces {
+	for _, trace := range storableTraces {
 		// Create TraceMemory
 		mem := NewTraceMemory(userID, trace.SessionID, trace)
 
@@ -98,25 +223,25 @@ func (m *SimpleManager) Record(ctx context.Context, userID string, interaction *
 		// Generate embedding
 		embedding, err := m.embedder.Embed(ctx, text)
 		if err != nil {
-			log.Printf("[MEMORY] Failed to embed trace #%d: %v", i+1, err)
+			m.logger.WarnContext(ctx, "failed to embed trace", "user_id", userID, "trace_id", trace.ID, "tool", trace.Action, "error", err)
 			continue
 		}
 		mem.SetEmbedding(embedding)
 
 		// Store
 		if err := m.store.Store(ctx, mem); err != nil {
-			log.Printf("[MEMORY] Failed to store trace #%d: %v", i+1, err)
+			m.logger.WarnContext(ctx, "failed to store trace", "user_id", userID, "trace_id", trace.ID, "tool", trace.Action, "error", err)
 			continue
 		}
 
-		log.Printf("[MEMORY]   Stored trace #%d: action=%s", i+1, trace.Action)
+		m.logger.DebugContext(ctx, "stored trace", "user_id", userID, "trace_id", trace.ID, "tool", trace.Action)
 	}
 
 	return nil
 }
 
 // formatMemories formats retrieved memories into a structured string.
-func (m *SimpleManager) formatMemories(memories []Memory, userID string, query string) string {
+func (m *SimpleManager) formatMemories(memories []scoredMemory, userID string, query string) string {
 	if len(memories) == 0 {
 		return ""
 	}
@@ -124,18 +249,17 @@ func (m *SimpleManager) formatMemories(memories []Memory, userID string, query s
 	var parts []string
 	parts = append(parts, "=== RELEVANT PAST ACTIONS ===\n")
 
-	// Calculate max length per memory
-	maxLengthPerMemory := 2000 / len(memories)
-	if maxLengthPerMemory < 100 {
-		maxLengthPerMemory = 100 // Minimum reasonable length
-	}
+	// Split the prompt budget across memories by importance
+	maxLengths := allocateBudget(memories, m.config.promptBudget(), m.config.minCharsPerMemory())
 
 	// Format each memory
-	for i, mem := range memories {
-		formatted := mem.Format(FormatContext{
+	for i, s := range memories {
+		formatted := s.mem.Format(FormatContext{
 			UserID:    userID,
 			Query:     query,
-			MaxLength: maxLengthPerMemory,
+			MaxLength: maxLengths[i],
+			Score:     s.similarity,
+			ShowScore: m.config.ShowScores,
 		})
 		parts = append(parts, fmt.Sprintf("%d. %s\n", i+1, formatted))
 	}
@@ -143,9 +267,35 @@ func (m *SimpleManager) formatMemories(memories []Memory, userID string, query s
 	return strings.Join(parts, "\n")
 }
 
-// filterStorableTraces selects traces worth storing.
-// SimpleManager's filtering logic - user implementations can define their own.
+// allocateBudget splits budget characters across memories in proportion to
+// their importance, giving each at least minChars.
+func allocateBudget(memories []scoredMemory, budget, minChars int) []int {
+	var total float64
+	for _, s := range memories {
+		total += memoryImportance(s.mem)
+	}
+
+	lengths := make([]int, len(memories))
+	for i, s := range memories {
+		share := 1 / float64(len(memories))
+		if total > 0 {
+			share = memoryImportance(s.mem) / total
+		}
+		lengths[i] = int(float64(budget) * share)
+		if lengths[i] < minChars {
+			lengths[i] = minChars
+		}
+	}
+	return lengths
+}
+
+// filterStorableTraces selects traces worth storing: Config.TraceFilter if
+// set, otherwise SimpleManager's rules, tuned by the Config thresholds.
 func (m *SimpleManager) filterStorableTraces(traces []*core.Trace) []*core.Trace {
+	if m.config.TraceFilter != nil {
+		return m.config.TraceFilter(traces)
+	}
+
 	// Store multi-step traces (both successes and failures)
 	if len(traces) > 1 {
 		return traces
@@ -156,7 +306,7 @@ func (m *SimpleManager) filterStorableTraces(traces []*core.Trace) []*core.Trace
 		trace := traces[0]
 
 		// Store failures (for learning)
-		if !trace.Success {
+		if !trace.Success && !m.config.SkipFailures {
 			return traces
 		}
 
@@ -168,20 +318,14 @@ func (m *SimpleManager) filterStorableTraces(traces []*core.Trace) []*core.Trace
 		}
 
 		// Store contextually valuable actions
-		contextualActions := []string{
-			"search_users",     // User relationships
-			"get_profile",      // User preferences/info
-			"get_transactions", // Spending patterns
-			"analyze_spending", // Financial insights
-		}
-		for _, action := range contextualActions {
+		for _, action := range m.config.contextualActions() {
 			if trace.Action == action {
 				return traces
 			}
 		}
 
-		// Store traces with substantive thoughts (>30 chars indicates reasoning)
-		if len(trace.Thought) > 30 {
+		// Store traces with substantive thoughts (a long thought indicates reasoning)
+		if n := m.config.minThoughtLength(); n >= 0 && len(trace.Thought) > n {
 			return traces
 		}
 
@@ -191,6 +335,85 @@ func (m *SimpleManager) filterStorableTraces(traces []*core.Trace) []*core.Trace
 	return nil
 }
 
+const (
+	// importanceWeight is the share of a candidate's retrieval score that
+	// comes from its importance; the rest is query similarity.
+	importanceWeight = 0.3
+
+	// duplicateSimilarity is the similarity between two candidates above
+	// which the lower-scoring one is dropped as a near-duplicate.
+	duplicateSimilarity = 0.95
+)
+
+type scoredMemory struct {
+	mem        Memory
+	similarity float64 // Cosine similarity to the query
+	score      float64 // Blend of similarity and importance, for ranking
+}
+
+// selectTopK ranks candidates by a blend of similarity to the query and
+// importance, drops near-duplicates of better-ranked memories, and returns
+// the best k.
+func selectTopK(candidates []Memory, query []float32, k int) []scoredMemory {
+	scored := make([]scoredMemory, len(candidates))
+	for i, mem := range candidates {
+		similarity := cosineSimilarity(query, mem.Embedding())
+		scored[i] = scoredMemory{
+			mem:        mem,
+			similarity: similarity,
+			score:      (1-importanceWeight)*similarity + importanceWeight*memoryImportance(mem),
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	var selected []scoredMemory
+	for _, c := range scored {
+		if len(selected) == k {
+			break
+		}
+		duplicate := false
+		for _, kept := range selected {
+			if cosineSimilarity(c.mem.Embedding(), kept.mem.Embedding()) > duplicateSimilarity {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// memoryImportance returns a memory's importance, or 0.5 for memory types
+// without one.
+func memoryImportance(mem Memory) float64 {
+	if imp, ok := mem.(interface{ Importance() float64 }); ok {
+		return imp.Importance()
+	}
+	return 0.5
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 if they
+// differ in length or either is zero.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
 // truncateLog truncates text for logging.
 func truncateLog(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -218,6 +441,133 @@ type Config struct {
 	// DecayEnabled toggles Ebbinghaus forgetting curve.
 	// Default: false (not implemented in local version).
 	DecayEnabled bool
+
+	// RetrieveCandidates is how many memories are fetched from the store
+	// per retrieval before ranking by similarity and importance.
+	// Default: 30
+	RetrieveCandidates int
+
+	// RetrieveTopK is how many of the ranked candidates are injected into
+	// the prompt.
+	// Default: 10
+	RetrieveTopK int
+
+	// UsagePromotion is the importance added to a retrieved memory when its
+	// action is subsequently used successfully (see MarkUsed). Negative
+	// disables it.
+	// Default: 0.05
+	UsagePromotion float64
+
+	// ShowScores renders each injected memory's similarity to the query,
+	// e.g. "(relevance 0.82)", to debug retrieval. Leave off in production
+	// so scores don't end up in prompts.
+	// Default: false
+	ShowScores bool
+
+	// MemoryPromptBudget is the total characters of formatted memories
+	// injected into the prompt, split across memories by importance.
+	// Default: 2000
+	MemoryPromptBudget int
+
+	// MinCharsPerMemory is the least budget any injected memory gets, so
+	// many memories can together exceed MemoryPromptBudget.
+	// Default: 100
+	MinCharsPerMemory int
+
+	// TraceFilter selects which of a run's traces are recorded, replacing
+	// the default rules below. Default: nil (store multi-step runs,
+	// failures, confirmed actions, ContextualActions and traces with a
+	// thought longer than MinThoughtLength)
+	TraceFilter func(traces []*core.Trace) []*core.Trace
+
+	// MinThoughtLength is the thought length, in bytes, above which a
+	// single trace is stored for its reasoning. Negative disables it.
+	// Default: 30
+	MinThoughtLength int
+
+	// ContextualActions are tools whose single traces are always stored
+	// because they reveal context, e.g. relationships or spending patterns.
+	// Set an empty, non-nil slice to store none.
+	// Default: DefaultContextualActions
+	ContextualActions []string
+
+	// SkipFailures stops storing single failed traces. Failures in
+	// multi-step runs are still stored with the rest of the run.
+	// Default: false (failures are stored for learning)
+	SkipFailures bool
+
+	// Logger receives the manager's structured logs (user_id, trace_id,
+	// tool, ...). Default: slog.Default()
+	Logger *slog.Logger
+}
+
+// DefaultContextualActions are the tools whose traces are stored by default
+// (see Config.ContextualActions).
+var DefaultContextualActions = []string{
+	"search_users",     // User relationships
+	"get_profile",      // User preferences/info
+	"get_transactions", // Spending patterns
+	"analyze_spending", // Financial insights
+}
+
+// minThoughtLength returns MinThoughtLength, defaulted.
+func (c *Config) minThoughtLength() int {
+	if c.MinThoughtLength == 0 {
+		return 30
+	}
+	return c.MinThoughtLength
+}
+
+// contextualActions returns ContextualActions, defaulted.
+func (c *Config) contextualActions() []string {
+	if c.ContextualActions == nil {
+		return DefaultContextualActions
+	}
+	return c.ContextualActions
+}
+
+// candidates returns RetrieveCandidates, defaulted and at least RetrieveTopK.
+func (c *Config) candidates() int {
+	n := c.RetrieveCandidates
+	if n <= 0 {
+		n = 30
+	}
+	if k := c.topK(); n < k {
+		n = k
+	}
+	return n
+}
+
+// usagePromotion returns UsagePromotion, defaulted.
+func (c *Config) usagePromotion() float64 {
+	if c.UsagePromotion == 0 {
+		return 0.05
+	}
+	return c.UsagePromotion
+}
+
+// promptBudget returns MemoryPromptBudget, defaulted.
+func (c *Config) promptBudget() int {
+	if c.MemoryPromptBudget <= 0 {
+		return 2000
+	}
+	return c.MemoryPromptBudget
+}
+
+// minCharsPerMemory returns MinCharsPerMemory, defaulted.
+func (c *Config) minCharsPerMemory() int {
+	if c.MinCharsPerMemory <= 0 {
+		return 100
+	}
+	return c.MinCharsPerMemory
+}
+
+// topK returns RetrieveTopK, defaulted.
+func (c *Config) topK() int {
+	if c.RetrieveTopK <= 0 {
+		return 10
+	}
+	return c.RetrieveTopK
 }
 
 // DefaultConfig returns sensible defaults for local SDK.
@@ -226,4 +576,9 @@ var DefaultConfig = &Config{
 	MinSimilarity:      0.5,   // Reasonable for most embedders
 	MaxMemoriesPerUser: 1000,
 	DecayEnabled:       false, // Skip decay for local version
+	RetrieveCandidates: 30,
+	RetrieveTopK:       10,
+	UsagePromotion:     0.05,
+	MemoryPromptBudget: 2000,
+	MinCharsPerMemory:  100,
 }
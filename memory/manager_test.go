@@ -2,12 +2,15 @@ package memory_test
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/becomeliminal/nim-go-sdk/core"
 	"github.com/becomeliminal/nim-go-sdk/memory"
+	"github.com/becomeliminal/nim-go-sdk/memory/embedder/fakesemantic"
 	"github.com/becomeliminal/nim-go-sdk/memory/store/chromem"
 )
 
@@ -37,17 +40,16 @@ func (m *MockEmbedder) Dimensions() int {
 func TestSimpleManager_RecordAndRetrieve(t *testing.T) {
 	ctx := context.Background()
 
-	// Setup with mock embedder
 	store, err := chromem.New()
 	if err != nil {
 		t.Fatalf("Failed to create store: %v", err)
 	}
 
-	embedder := NewMockEmbedder(384)
+	embedder := fakesemantic.New()
 
 	config := &memory.Config{
-		Enabled:       true,
-		MinSimilarity: 0.0, // Low threshold for mock embeddings
+		Enabled:      true,
+		RetrieveTopK: 1,
 	}
 	manager := memory.NewSimpleManager(store, embedder, config)
 
@@ -84,16 +86,72 @@ func TestSimpleManager_RecordAndRetrieve(t *testing.T) {
 		t.Fatalf("Failed to retrieve memories: %v", err)
 	}
 
-	// Verify formatted output contains relevant information
-	if formatted == "" {
-		t.Log("No memories retrieved. This is expected with mock embedder.")
-		t.Skip("Skipping test - mock embedder doesn't provide real semantic similarity")
-	}
-
 	// Check that formatted output looks reasonable
 	if !strings.Contains(formatted, "RELEVANT PAST ACTIONS") {
 		t.Errorf("Expected formatted output to contain header")
 	}
+
+	// The related trace is retrieved, the unrelated one isn't
+	if !strings.Contains(formatted, "send_money") {
+		t.Errorf("Retrieve() = %q, want the send_money trace", formatted)
+	}
+	if strings.Contains(formatted, "get_balance") {
+		t.Errorf("Retrieve() = %q, want only the most relevant trace", formatted)
+	}
+}
+
+func TestSimpleManager_RecordTwiceDoesNotDuplicate(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	manager := memory.NewSimpleManager(store, NewMockEmbedder(8), &memory.Config{Enabled: true})
+
+	traces := []*core.Trace{
+		{
+			SessionID:   "session1",
+			TurnNumber:  1,
+			Thought:     "Check the balance first",
+			Action:      "get_balance",
+			Observation: "Balance is $100",
+			Success:     true,
+		},
+		{
+			SessionID:   "session1",
+			TurnNumber:  2,
+			Thought:     "Send $50 to Alice",
+			Action:      "send_money",
+			ActionInput: []byte(`{"recipient":"@alice","amount":"50"}`),
+			Observation: "Transfer successful",
+			Success:     true,
+		},
+		{
+			SessionID:   "session1",
+			TurnNumber:  2,
+			Thought:     "Send $20 to Bob",
+			Action:      "send_money",
+			ActionInput: []byte(`{"recipient":"@bob","amount":"20"}`),
+			Observation: "Transfer successful",
+			Success:     true,
+		},
+	}
+
+	// Recording the same traces again, e.g. on a retry, overwrites them
+	for i := 0; i < 2; i++ {
+		if err := manager.Record(ctx, "user1", &memory.Interaction{Traces: traces}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	stored, err := store.List(ctx, "user1")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(stored) != len(traces) {
+		t.Errorf("stored %d memories after recording twice, want %d", len(stored), len(traces))
+	}
 }
 
 func TestSimpleManager_UserNamespacing(t *testing.T) {
@@ -104,7 +162,7 @@ func TestSimpleManager_UserNamespacing(t *testing.T) {
 		t.Fatalf("Failed to create store: %v", err)
 	}
 
-	embedder := NewMockEmbedder(384)
+	embedder := fakesemantic.New()
 
 	config := &memory.Config{
 		Enabled:       true,
@@ -154,12 +212,12 @@ func TestSimpleManager_UserNamespacing(t *testing.T) {
 		t.Fatalf("Failed to retrieve user2 memories: %v", err)
 	}
 
-	// Verify isolation (if we got results)
-	if formatted1 != "" && strings.Contains(formatted1, "user2") {
-		t.Error("User1 should not see user2's memories")
+	// Each user sees only their own trace
+	if !strings.Contains(formatted1, "send_money") || strings.Contains(formatted1, "get_balance") {
+		t.Errorf("user1 Retrieve() = %q, want only user1's send_money trace", formatted1)
 	}
-	if formatted2 != "" && strings.Contains(formatted2, "user1") {
-		t.Error("User2 should not see user1's memories")
+	if !strings.Contains(formatted2, "get_balance") || strings.Contains(formatted2, "send_money") {
+		t.Errorf("user2 Retrieve() = %q, want only user2's get_balance trace", formatted2)
 	}
 }
 
@@ -237,6 +295,57 @@ func TestSimpleManager_FilterStorableTraces(t *testing.T) {
 	t.Logf("Multi-step trace retrieve result: %s", formatted)
 }
 
+func TestSimpleManager_TraceFilterConfig(t *testing.T) {
+	failure := &core.Trace{SessionID: "s", Action: "send_money", Observation: "Insufficient funds", Success: false}
+	lookup := &core.Trace{SessionID: "s", Thought: "Find Alice", Action: "search_users", Observation: "Found @alice", Success: true}
+	reasoned := &core.Trace{SessionID: "s", Thought: "Checking the balance before the rent payment", Action: "get_balance", Observation: "$100", Success: true}
+	trivial := &core.Trace{SessionID: "s", Thought: "Check balance", Action: "get_balance", Observation: "$100", Success: true}
+
+	tests := []struct {
+		name   string
+		config memory.Config
+		trace  *core.Trace
+		stored bool
+	}{
+		{"default stores failures", memory.Config{}, failure, true},
+		{"SkipFailures", memory.Config{SkipFailures: true}, failure, false},
+		{"default contextual action", memory.Config{}, lookup, true},
+		{"no contextual actions", memory.Config{ContextualActions: []string{}}, lookup, false},
+		{"custom contextual action", memory.Config{ContextualActions: []string{"get_balance"}}, trivial, true},
+		{"default thought length", memory.Config{}, reasoned, true},
+		{"longer MinThoughtLength", memory.Config{MinThoughtLength: 100}, reasoned, false},
+		{"shorter MinThoughtLength", memory.Config{MinThoughtLength: 5}, trivial, true},
+		{"MinThoughtLength disabled", memory.Config{MinThoughtLength: -1}, reasoned, false},
+		{"TraceFilter replaces the rules", memory.Config{TraceFilter: func(traces []*core.Trace) []*core.Trace {
+			return nil
+		}}, failure, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := chromem.New()
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			config := tt.config
+			config.Enabled = true
+			manager := memory.NewSimpleManager(store, NewMockEmbedder(8), &config)
+
+			if err := manager.Record(ctx, "user1", &memory.Interaction{Traces: []*core.Trace{tt.trace}}); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			stored, err := store.List(ctx, "user1")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := len(stored) == 1; got != tt.stored {
+				t.Errorf("stored = %v, want %v", got, tt.stored)
+			}
+		})
+	}
+}
+
 func TestSimpleManager_DisabledConfig(t *testing.T) {
 	ctx := context.Background()
 
@@ -358,3 +467,413 @@ func TestSimpleManager_ConfirmationStorage(t *testing.T) {
 
 	t.Logf("Confirmation trace retrieve result: %s", formatted)
 }
+
+// queryEmbedder records which embedding mode was used for each text.
+type queryEmbedder struct {
+	*MockEmbedder
+	queries   []string
+	documents []string
+}
+
+func (e *queryEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.documents = append(e.documents, text)
+	return e.MockEmbedder.Embed(ctx, text)
+}
+
+func (e *queryEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	e.queries = append(e.queries, text)
+	return e.MockEmbedder.Embed(ctx, "query: "+text)
+}
+
+func TestSimpleManager_UsesEmbedQuery(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	embedder := &queryEmbedder{MockEmbedder: NewMockEmbedder(384)}
+	manager := memory.NewSimpleManager(store, embedder, &memory.Config{Enabled: true})
+
+	err = manager.Record(ctx, "user1", &memory.Interaction{Traces: []*core.Trace{{
+		SessionID:   "session1",
+		Thought:     "Try to send money",
+		Action:      "send_money",
+		Observation: "Insufficient funds",
+		Success:     false,
+	}}})
+	if err != nil {
+		t.Fatalf("Failed to record traces: %v", err)
+	}
+	if _, err := manager.Retrieve(ctx, "user1", "send money to alice"); err != nil {
+		t.Fatalf("Failed to retrieve: %v", err)
+	}
+
+	if len(embedder.documents) != 1 {
+		t.Errorf("Embed called %d times, want 1 (recorded trace)", len(embedder.documents))
+	}
+	if len(embedder.queries) != 1 || embedder.queries[0] != "send money to alice" {
+		t.Errorf("EmbedQuery calls = %q, want [\"send money to alice\"]", embedder.queries)
+	}
+}
+
+// keywordEmbedder returns a fixed vector for the first keyword found in the
+// text, so tests control similarities exactly.
+type keywordEmbedder struct {
+	vectors map[string][]float32
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	for keyword, v := range e.vectors {
+		if strings.Contains(text, keyword) {
+			return v, nil
+		}
+	}
+	return []float32{0, 0, 1}, nil
+}
+
+func (e *keywordEmbedder) Dimensions() int {
+	return 3
+}
+
+func TestSimpleManager_RetrievePrefersImportantMemories(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Against the query (1,0,0): the trivial read scores 0.95 similarity,
+	// the failure 0.90. The failure's higher importance should outweigh that.
+	embedder := &keywordEmbedder{vectors: map[string][]float32{
+		"QUERY":       {1, 0, 0},
+		"get_balance": {0.95, 0.3122, 0},
+		"send_money":  {0.90, 0, 0.4359},
+	}}
+	manager := memory.NewSimpleManager(store, embedder, &memory.Config{
+		Enabled:      true,
+		RetrieveTopK: 1,
+	})
+
+	err = manager.Record(ctx, "user1", &memory.Interaction{Traces: []*core.Trace{
+		{
+			SessionID:   "session1",
+			Thought:     "Check balance",
+			Action:      "get_balance",
+			Observation: "Balance is $100",
+			Success:     true,
+		},
+		{
+			SessionID:   "session1",
+			Thought:     "Send rent",
+			Action:      "send_money",
+			Observation: "Insufficient funds",
+			Success:     false,
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to record traces: %v", err)
+	}
+
+	formatted, err := manager.Retrieve(ctx, "user1", "QUERY")
+	if err != nil {
+		t.Fatalf("Failed to retrieve: %v", err)
+	}
+	if !strings.Contains(formatted, "send_money") {
+		t.Errorf("Retrieve() = %q, want the send_money failure", formatted)
+	}
+	if strings.Contains(formatted, "get_balance") {
+		t.Errorf("Retrieve() = %q, want only the top memory", formatted)
+	}
+}
+
+func TestSimpleManager_MarkUsedPromotesRetrievedMemories(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Both traces are equally similar to the query; importance decides.
+	embedder := &keywordEmbedder{vectors: map[string][]float32{
+		"get_transactions": {0.8, 0.6, 0},
+		"search_users":     {0.8, 0, 0.6},
+		"QUERY":            {1, 0, 0},
+	}}
+	manager := memory.NewSimpleManager(store, embedder, &memory.Config{
+		Enabled:        true,
+		RetrieveTopK:   1,
+		UsagePromotion: 0.2,
+	})
+
+	err = manager.Record(ctx, "user1", &memory.Interaction{Traces: []*core.Trace{
+		{SessionID: "s1", Thought: "Look up", Action: "search_users", Observation: "Found @alice", Success: true},
+		{SessionID: "s1", Thought: "List", Action: "get_transactions", Observation: "3 transactions", Success: true},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to record traces: %v", err)
+	}
+
+	// Retrieve with room for both, then use get_transactions.
+	wide := memory.NewSimpleManager(store, embedder, &memory.Config{Enabled: true, UsagePromotion: 0.2})
+	if _, err := wide.Retrieve(ctx, "user1", "QUERY"); err != nil {
+		t.Fatalf("Failed to retrieve: %v", err)
+	}
+	if err := wide.MarkUsed(ctx, "user1", "get_transactions"); err != nil {
+		t.Fatalf("MarkUsed() error = %v", err)
+	}
+
+	// The promoted memory now wins the single slot.
+	formatted, err := manager.Retrieve(ctx, "user1", "QUERY")
+	if err != nil {
+		t.Fatalf("Failed to retrieve: %v", err)
+	}
+	if !strings.Contains(formatted, "get_transactions") {
+		t.Errorf("Retrieve() = %q, want the promoted get_transactions memory", formatted)
+	}
+}
+
+func TestSimpleManager_PromoteUnsupportedStore(t *testing.T) {
+	manager := memory.NewSimpleManager(noUpdateStore{}, NewMockEmbedder(3), &memory.Config{Enabled: true})
+	if err := manager.Promote(context.Background(), "user1", "mem1", 0.1); err == nil {
+		t.Error("Promote() error = nil, want error for store without UpdateImportance")
+	}
+}
+
+// noUpdateStore is a Store without ImportanceUpdater.
+type noUpdateStore struct{ baseStore }
+
+type baseStore interface{ memory.Store }
+
+func TestSimpleManager_SkipsRetrievalForNewUsers(t *testing.T) {
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	embedder := &queryEmbedder{MockEmbedder: NewMockEmbedder(384)}
+	manager := memory.NewSimpleManager(store, embedder, &memory.Config{Enabled: true})
+
+	formatted, err := manager.Retrieve(context.Background(), "new-user", "send money")
+	if err != nil {
+		t.Fatalf("Failed to retrieve: %v", err)
+	}
+	if formatted != "" {
+		t.Errorf("Retrieve() = %q, want empty", formatted)
+	}
+	if len(embedder.queries) != 0 {
+		t.Errorf("EmbedQuery called %d times, want 0 for a user with no memories", len(embedder.queries))
+	}
+}
+
+func TestSimpleManager_ShowScores(t *testing.T) {
+	tests := []struct {
+		name       string
+		showScores bool
+		want       string
+	}{
+		{"shown", true, "[Failed] send_money (relevance 0.80)"},
+		{"hidden", false, "[Failed] send_money\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store, err := chromem.New()
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			embedder := &keywordEmbedder{vectors: map[string][]float32{
+				"QUERY":      {1, 0, 0},
+				"send_money": {0.8, 0.6, 0},
+			}}
+			manager := memory.NewSimpleManager(store, embedder, &memory.Config{
+				Enabled:    true,
+				ShowScores: tt.showScores,
+			})
+
+			err = manager.Record(ctx, "user1", &memory.Interaction{Traces: []*core.Trace{{
+				SessionID:   "session1",
+				Thought:     "Send rent",
+				Action:      "send_money",
+				Observation: "Insufficient funds",
+				Success:     false,
+			}}})
+			if err != nil {
+				t.Fatalf("Failed to record trace: %v", err)
+			}
+
+			formatted, err := manager.Retrieve(ctx, "user1", "QUERY")
+			if err != nil {
+				t.Fatalf("Failed to retrieve: %v", err)
+			}
+			if !strings.Contains(formatted, tt.want) {
+				t.Errorf("Retrieve() = %q, want it to contain %q", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimpleManager_MemoryPromptBudget(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	embedder := &keywordEmbedder{vectors: map[string][]float32{
+		"QUERY":       {1, 0, 0},
+		"send_money":  {0.7, 0.7, 0},
+		"get_balance": {0.7, 0, 0.7},
+		"withdraw":    {0.7, 0.5, 0.5},
+	}}
+	const budget = 300
+	manager := memory.NewSimpleManager(store, embedder, &memory.Config{
+		Enabled:            true,
+		MemoryPromptBudget: budget,
+		MinCharsPerMemory:  50,
+	})
+
+	long := strings.Repeat("lorem ipsum ", 100)
+	err = manager.Record(ctx, "user1", &memory.Interaction{Traces: []*core.Trace{
+		{SessionID: "session1", Thought: long, Action: "send_money", Observation: long, Success: false},
+		{SessionID: "session1", Thought: long, Action: "get_balance", Observation: long, Success: true},
+		{SessionID: "session1", Thought: long, Action: "withdraw", Observation: long, Success: false},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to record traces: %v", err)
+	}
+
+	formatted, err := manager.Retrieve(ctx, "user1", "QUERY")
+	if err != nil {
+		t.Fatalf("Failed to retrieve: %v", err)
+	}
+	if strings.Count(formatted, "lorem") == 0 {
+		t.Fatalf("Retrieve() = %q, want the recorded traces", formatted)
+	}
+
+	// Header plus "N. " and newlines around each of the three memories
+	overhead := len("=== RELEVANT PAST ACTIONS ===\n") + 3*6
+	if len(formatted) > budget+overhead {
+		t.Errorf("len(Retrieve()) = %d, want <= %d", len(formatted), budget+overhead)
+	}
+}
+
+func TestSimpleManager_ExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	src, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	manager := memory.NewSimpleManager(src, NewMockEmbedder(8), &memory.Config{Enabled: true})
+
+	traces := []*core.Trace{
+		{
+			SessionID:   "session1",
+			Thought:     "Check the balance first",
+			Action:      "get_balance",
+			Observation: "Balance is $100",
+			Success:     true,
+		},
+		{
+			SessionID:   "session1",
+			Thought:     "Send $500 to Alice",
+			Action:      "send_money",
+			ActionInput: []byte(`{"recipient":"@alice","amount":"500"}`),
+			Observation: "Insufficient funds",
+			Success:     false,
+			Metadata:    map[string]string{"error_type": "insufficient_funds", "prevention": "Check the balance before sending"},
+		},
+	}
+	if err := manager.Record(ctx, "user1", &memory.Interaction{Traces: traces}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	exported, err := manager.Export(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(exported) != 2 {
+		t.Fatalf("Export() returned %d traces, want 2", len(exported))
+	}
+	var failed *core.Trace
+	for _, trace := range exported {
+		if trace.ID == "" || trace.SessionID != "session1" || trace.Timestamp == 0 {
+			t.Errorf("exported trace = %+v, want ID, session and timestamp", trace)
+		}
+		if trace.Action == "send_money" {
+			failed = trace
+		}
+	}
+	if failed == nil || failed.Success || string(failed.ActionInput) != `{"recipient":"@alice","amount":"500"}` {
+		t.Fatalf("exported send_money trace = %+v", failed)
+	}
+	if failed.Metadata["error_type"] != "insufficient_funds" || failed.Metadata["prevention"] != "Check the balance before sending" {
+		t.Errorf("exported metadata = %v, want error_type and prevention", failed.Metadata)
+	}
+	if _, ok := failed.Metadata["success"]; ok {
+		t.Errorf("exported metadata = %v, want no success key", failed.Metadata)
+	}
+
+	dst, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	restored := memory.NewSimpleManager(dst, NewMockEmbedder(8), &memory.Config{Enabled: true})
+	if err := restored.Import(ctx, "user1", exported); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	reexported, err := restored.Export(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Export() after Import error = %v", err)
+	}
+	if !reflect.DeepEqual(reexported, exported) {
+		t.Errorf("round trip = %+v, want %+v", reexported, exported)
+	}
+
+	formatted, err := restored.Retrieve(ctx, "user1", "send money")
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if !strings.Contains(formatted, "Prevention: Check the balance before sending") {
+		t.Errorf("Retrieve() after Import = %q, want the prevention", formatted)
+	}
+}
+
+func TestSimpleManager_ExportUnsupportedStore(t *testing.T) {
+	manager := memory.NewSimpleManager(noUpdateStore{}, NewMockEmbedder(3), &memory.Config{Enabled: true})
+	if _, err := manager.Export(context.Background(), "user1"); err == nil {
+		t.Error("Export() error = nil, want error for store without List")
+	}
+}
+
+func TestSimpleManager_DimensionMismatch(t *testing.T) {
+	ctx := context.Background()
+	store, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	traces := []*core.Trace{{SessionID: "s1", Action: "send_money", Observation: "Insufficient funds"}}
+
+	// Record with a 384-dim embedder, then swap to a 512-dim one
+	old := memory.NewSimpleManager(store, NewMockEmbedder(384), &memory.Config{Enabled: true})
+	if err := old.Record(ctx, "user1", &memory.Interaction{Traces: traces}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	swapped := memory.NewSimpleManager(store, NewMockEmbedder(512), &memory.Config{Enabled: true})
+	if _, err := swapped.Retrieve(ctx, "user1", "send money"); !errors.Is(err, memory.ErrDimensionMismatch) {
+		t.Errorf("Retrieve() error = %v, want ErrDimensionMismatch", err)
+	}
+	if err := swapped.Record(ctx, "user1", &memory.Interaction{Traces: traces}); !errors.Is(err, memory.ErrDimensionMismatch) {
+		t.Errorf("Record() error = %v, want ErrDimensionMismatch", err)
+	}
+
+	if _, err := old.Retrieve(ctx, "user1", "send money"); err != nil {
+		t.Errorf("Retrieve() with matching embedder error = %v", err)
+	}
+}
@@ -46,10 +46,13 @@ type Memory interface {
 //   - Truncate based on available space (MaxLength)
 //   - Customize output based on user context (UserID)
 //   - Emphasize query-relevant parts (Query)
+//   - Show how relevant the memory was (Score, when ShowScore is set)
 type FormatContext struct {
-	UserID    string // Current user
-	Query     string // Current query being answered
-	MaxLength int    // Max characters for this memory's output
+	UserID    string  // Current user
+	Query     string  // Current query being answered
+	MaxLength int     // Max characters for this memory's output
+	Score     float64 // Cosine similarity to the query [-1.0-1.0], 0 if unknown
+	ShowScore bool    // Whether to render Score (for debugging, see Config.ShowScores)
 }
 
 // Interaction represents a complete user-agent interaction: the user's message,
@@ -121,7 +124,8 @@ type Store interface {
 }
 
 // Embedder converts text to vector embeddings.
-// Implementations: MockEmbedder (testing), ONNXEmbedder (local SDK), VoyageEmbedder (production).
+// Implementations: MockEmbedder (testing), ONNXEmbedder (local SDK),
+// openai.Embedder (OpenAI-compatible servers), VoyageEmbedder (production).
 //
 // Note: Embedder is an implementation detail of Manager.
 // The Engine does not interact with Embedder directly.
@@ -132,3 +136,62 @@ type Embedder interface {
 	// Dimensions returns embedding vector size.
 	Dimensions() int
 }
+
+// QueryEmbedder is an optional interface for embedders whose models embed
+// search queries differently from stored documents (asymmetric retrieval,
+// e.g. Voyage input_type "query"/"document" or E5's "query: " prefix).
+// SimpleManager uses EmbedQuery for retrieval when the embedder implements
+// it, and Embed for stored memories.
+type QueryEmbedder interface {
+	// EmbedQuery converts a search query to an embedding vector comparable
+	// with vectors from Embed.
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// ImportanceUpdater is an optional interface for stores that can adjust a
+// stored memory's importance, used by SimpleManager.Promote.
+type ImportanceUpdater interface {
+	// UpdateImportance adds delta to a memory's importance, clamped to
+	// [0.0-1.0], and returns the new importance.
+	UpdateImportance(ctx context.Context, ownerID string, memoryID string, delta float64) (float64, error)
+}
+
+// Lister is an optional interface for stores that can enumerate a user's
+// memories, used by SimpleManager.Export.
+type Lister interface {
+	// List returns all of the user's memories, oldest first.
+	List(ctx context.Context, ownerID string) ([]Memory, error)
+}
+
+// DimensionReporter is an optional interface for stores that hold vectors of
+// a fixed size, used to catch embedder/store mismatches.
+type DimensionReporter interface {
+	// Dimensions returns the embedding size the store expects, or 0 if it
+	// isn't known yet.
+	Dimensions() int
+}
+
+// MemoryChecker is an optional interface for stores that can cheaply tell
+// whether a user has any memories. SimpleManager uses it to skip embedding
+// and querying for users with none.
+type MemoryChecker interface {
+	// HasMemories reports whether the user has any stored memories.
+	HasMemories(ctx context.Context, userID string) (bool, error)
+}
+
+// UsageRecorder is an optional interface for managers that learn which
+// retrieved memories turned out to be useful. The engine calls MarkUsed
+// after each successful tool execution.
+type UsageRecorder interface {
+	// MarkUsed signals that action succeeded for the user, so memories of
+	// that action retrieved for the current request were relevant.
+	MarkUsed(ctx context.Context, userID string, action string) error
+}
+
+// Pinger is an optional interface for managers and stores that can check
+// their backend is reachable, used by server health checks. SimpleManager
+// forwards Ping to its store when the store implements it.
+type Pinger interface {
+	// Ping returns an error if the backend is unreachable.
+	Ping(ctx context.Context) error
+}
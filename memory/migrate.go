@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDimensionMismatch is returned when an embedding's size doesn't match
+// the size a store or embedder expects.
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// Migrate copies all memories of userIDs from src to dst, e.g. from a local
+// chromem store to pgvector. src must implement Lister. Memories keep their
+// IDs, so a failed migration can be re-run.
+//
+// If dst reports its dimensions (see DimensionReporter) and they differ from
+// a memory's embedding, the memory is re-embedded with embedder, which must
+// produce vectors of dst's size. Memories are re-embedded from
+// FormatForEmbedding, which TraceMemory implements. Without an embedder, a
+// mismatch fails with ErrDimensionMismatch rather than storing incompatible
+// vectors. embedder may be nil if the dimensions match.
+//
+// Embeddings are otherwise copied as is. When switching to an embedder with
+// the same dimensions, use SimpleManager.Export and Import instead, which
+// re-embed every trace.
+func Migrate(ctx context.Context, src Store, dst Store, userIDs []string, embedder Embedder) error {
+	lister, ok := src.(Lister)
+	if !ok {
+		return fmt.Errorf("store %T does not support listing memories", src)
+	}
+	dims := 0
+	if dr, ok := dst.(DimensionReporter); ok {
+		dims = dr.Dimensions()
+	}
+	if dims > 0 && embedder != nil && embedder.Dimensions() != dims {
+		return fmt.Errorf("%w: embedder produces %d dimensions, destination store expects %d",
+			ErrDimensionMismatch, embedder.Dimensions(), dims)
+	}
+
+	for _, userID := range userIDs {
+		memories, err := lister.List(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("list memories of %s: %w", userID, err)
+		}
+		for _, mem := range memories {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if dims > 0 && len(mem.Embedding()) != dims {
+				if err := reembed(ctx, mem, embedder, dims); err != nil {
+					return fmt.Errorf("migrate memory %s of %s: %w", mem.ID(), userID, err)
+				}
+			}
+			if err := dst.Store(ctx, mem); err != nil {
+				return fmt.Errorf("migrate memory %s of %s: %w", mem.ID(), userID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reembed replaces mem's embedding with one of dims dimensions.
+func reembed(ctx context.Context, mem Memory, embedder Embedder, dims int) error {
+	if embedder == nil {
+		return fmt.Errorf("%w: embedding has %d dimensions, destination store expects %d; pass an embedder to re-embed",
+			ErrDimensionMismatch, len(mem.Embedding()), dims)
+	}
+	formatter, ok := mem.(interface{ FormatForEmbedding() string })
+	if !ok {
+		return fmt.Errorf("can't re-embed %s memory: no FormatForEmbedding method", mem.Type())
+	}
+	embedding, err := embedder.Embed(ctx, formatter.FormatForEmbedding())
+	if err != nil {
+		return fmt.Errorf("embed: %w", err)
+	}
+	if len(embedding) != dims {
+		return fmt.Errorf("%w: embedder returned %d dimensions, destination store expects %d",
+			ErrDimensionMismatch, len(embedding), dims)
+	}
+	mem.SetEmbedding(embedding)
+	return nil
+}
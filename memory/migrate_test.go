@@ -0,0 +1,94 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/memory"
+	"github.com/becomeliminal/nim-go-sdk/memory/store/chromem"
+)
+
+// sizedStore is a Store that expects embeddings of a fixed size.
+type sizedStore struct {
+	*chromem.ChromemStore
+	dims int
+}
+
+func (s sizedStore) Dimensions() int { return s.dims }
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+
+	src, err := chromem.New()
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	manager := memory.NewSimpleManager(src, NewMockEmbedder(4), &memory.Config{Enabled: true})
+	traces := []*core.Trace{
+		{SessionID: "s1", Thought: "Check balance", Action: "get_balance", Observation: "$100", Success: true},
+		{SessionID: "s1", Thought: "Send to Alice", Action: "send_money", Observation: "Insufficient funds",
+			Metadata: map[string]string{"prevention": "Check the balance first"}},
+	}
+	for _, userID := range []string{"user1", "user2"} {
+		if err := manager.Record(ctx, userID, &memory.Interaction{Traces: traces}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		dims     int
+		embedder memory.Embedder
+		wantErr  error
+	}{
+		{"same dimensions", 4, nil, nil},
+		{"unknown dimensions", 0, nil, nil},
+		{"re-embed", 8, NewMockEmbedder(8), nil},
+		{"mismatch without embedder", 8, nil, memory.ErrDimensionMismatch},
+		{"embedder of wrong size", 8, NewMockEmbedder(6), memory.ErrDimensionMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chromemDst, err := chromem.New()
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			dst := sizedStore{ChromemStore: chromemDst, dims: tt.dims}
+
+			err = memory.Migrate(ctx, src, dst, []string{"user1", "user2"}, tt.embedder)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Migrate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantSize := tt.dims
+			if wantSize == 0 {
+				wantSize = 4
+			}
+			for _, userID := range []string{"user1", "user2"} {
+				migrated, err := chromemDst.List(ctx, userID)
+				if err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+				if tt.wantErr != nil {
+					if len(migrated) != 0 {
+						t.Errorf("%s: %d memories stored despite the mismatch", userID, len(migrated))
+					}
+					continue
+				}
+				if len(migrated) != len(traces) {
+					t.Fatalf("%s: %d memories migrated, want %d", userID, len(migrated), len(traces))
+				}
+				for _, mem := range migrated {
+					if len(mem.Embedding()) != wantSize {
+						t.Errorf("%s: embedding has %d dimensions, want %d", mem.ID(), len(mem.Embedding()), wantSize)
+					}
+					if mem.OwnerID() != userID {
+						t.Errorf("%s: owner = %q, want %q", mem.ID(), mem.OwnerID(), userID)
+					}
+				}
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,17 +21,41 @@ import (
 type ChromemStore struct {
 	db          *chromem.DB
 	collections map[string]*chromem.Collection // Per-user collections
+	docIDs      map[string]map[string]bool     // userID -> stored document IDs, for List
+	dims        map[string]int                 // userID -> embedding size, set by the first Store
 	mu          sync.RWMutex
+	updateMu    sync.Mutex // Serializes read-modify-write updates
+	logger      *slog.Logger
+}
+
+// Option configures a ChromemStore.
+type Option func(*ChromemStore)
+
+// WithLogger sets the structured logger for store logs. Defaults to
+// slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(s *ChromemStore) {
+		s.logger = l
+	}
 }
 
 // New creates a new chromem-based store.
-func New() (*ChromemStore, error) {
+func New(opts ...Option) (*ChromemStore, error) {
 	db := chromem.NewDB()
 
-	return &ChromemStore{
+	s := &ChromemStore{
 		db:          db,
 		collections: make(map[string]*chromem.Collection),
-	}, nil
+		docIDs:      make(map[string]map[string]bool),
+		dims:        make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	return s, nil
 }
 
 // getOrCreateCollection returns the collection for a user.
@@ -69,15 +96,77 @@ func (s *ChromemStore) getOrCreateCollection(userID string) (*chromem.Collection
 	return col, nil
 }
 
-// Store saves a memory with its embedding.
+// PreloadUsers creates the collections of the given users up front, so their
+// first Store or Query doesn't pay for collection creation.
+func (s *ChromemStore) PreloadUsers(ctx context.Context, userIDs []string) error {
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := s.getOrCreateCollection(userID); err != nil {
+			return fmt.Errorf("preload %s: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+// HasMemories reports whether the user has any stored memories.
+func (s *ChromemStore) HasMemories(ctx context.Context, userID string) (bool, error) {
+	s.mu.RLock()
+	col, exists := s.collections[userID]
+	s.mu.RUnlock()
+	return exists && col.Count() > 0, nil
+}
+
+// Dimensions returns the embedding size of the stored memories, or 0 if
+// nothing has been stored yet or users' collections differ.
+func (s *ChromemStore) Dimensions() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dims := 0
+	for _, n := range s.dims {
+		if dims != 0 && n != dims {
+			return 0
+		}
+		dims = n
+	}
+	return dims
+}
+
+// checkDimensions returns memory.ErrDimensionMismatch if n differs from the
+// embedding size of the user's collection. If record is set and the size
+// isn't known yet, n becomes the collection's size.
+func (s *ChromemStore) checkDimensions(userID string, n int, record bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	want, ok := s.dims[userID]
+	if !ok {
+		if record && n > 0 {
+			s.dims[userID] = n
+		}
+		return nil
+	}
+	if n != want {
+		return fmt.Errorf("%w: embedding has %d dimensions, collection of %q has %d",
+			memory.ErrDimensionMismatch, n, userID, want)
+	}
+	return nil
+}
+
+// Store saves a memory with its embedding. The first memory stored for a
+// user sets the embedding size of their collection; memories of a different
+// size fail with memory.ErrDimensionMismatch, since cosine similarity
+// between them is meaningless.
 func (s *ChromemStore) Store(ctx context.Context, mem memory.Memory) error {
 	col, err := s.getOrCreateCollection(mem.OwnerID())
 	if err != nil {
 		return err
 	}
+	if err := s.checkDimensions(mem.OwnerID(), len(mem.Embedding()), true); err != nil {
+		return err
+	}
 
-	log.Printf("[CHROMEM] Storing memory: id=%s, owner=%s, type=%s",
-		mem.ID(), mem.OwnerID(), mem.Type())
+	s.logger.DebugContext(ctx, "storing memory", "memory_id", mem.ID(), "user_id", mem.OwnerID(), "type", mem.Type())
 
 	// Serialize memory for storage
 	stored, err := serializeMemory(mem)
@@ -98,64 +187,104 @@ func (s *ChromemStore) Store(ctx context.Context, mem memory.Memory) error {
 		return fmt.Errorf("add document: %w", err)
 	}
 
+	s.mu.Lock()
+	if s.docIDs[mem.OwnerID()] == nil {
+		s.docIDs[mem.OwnerID()] = make(map[string]bool)
+	}
+	s.docIDs[mem.OwnerID()][mem.ID()] = true
+	s.mu.Unlock()
+
 	return nil
 }
 
-// Query retrieves memories by vector similarity.
+// List returns all of the user's memories, oldest first.
+func (s *ChromemStore) List(ctx context.Context, ownerID string) ([]memory.Memory, error) {
+	// chromem-go can't enumerate a collection, so List reads back the
+	// documents recorded by Store
+	s.mu.RLock()
+	col := s.collections[ownerID]
+	ids := make([]string, 0, len(s.docIDs[ownerID]))
+	for id := range s.docIDs[ownerID] {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+	if col == nil {
+		return nil, nil
+	}
+
+	var memories []memory.Memory
+	for _, id := range ids {
+		doc, err := col.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("get document %s: %w", id, err)
+		}
+		mem, err := deserializeMemory(chromem.Result{
+			ID:        doc.ID,
+			Metadata:  doc.Metadata,
+			Embedding: doc.Embedding,
+			Content:   doc.Content,
+		})
+		if err != nil {
+			s.logger.WarnContext(ctx, "skipping unreadable memory", "memory_id", id, "user_id", ownerID, "error", err)
+			continue
+		}
+		memories = append(memories, mem)
+	}
+
+	sort.Slice(memories, func(i, j int) bool {
+		if !memories[i].CreatedAt().Equal(memories[j].CreatedAt()) {
+			return memories[i].CreatedAt().Before(memories[j].CreatedAt())
+		}
+		return memories[i].ID() < memories[j].ID()
+	})
+	return memories, nil
+}
+
+// Query retrieves memories by vector similarity. It fails with
+// memory.ErrDimensionMismatch if embedding's size differs from the stored
+// memories'.
 func (s *ChromemStore) Query(ctx context.Context, userID string, embedding []float32, limit int) ([]memory.Memory, error) {
 	col, err := s.getOrCreateCollection(userID)
 	if err != nil {
 		return nil, err
 	}
-
-	log.Printf("[CHROMEM] Querying collection for owner=%s, limit=%d", userID, limit)
+	if err := s.checkDimensions(userID, len(embedding), false); err != nil {
+		return nil, err
+	}
 
 	// Build where clause for filtering
 	where := map[string]string{
 		"owner_id": userID,
 	}
 
-	// Query chromem with embedding
 	// chromem-go requires nResults <= collection size
-	// Retry with smaller limits if necessary
-	var results []chromem.Result
-	for currentLimit := limit; currentLimit >= 1; currentLimit-- {
-		var err error
-		results, err = col.QueryEmbedding(ctx, embedding, currentLimit, where, nil)
-		if err == nil {
-			break
-		}
-
-		// Check if error is due to insufficient documents
-		if isInsufficientDocsError(err) {
-			if currentLimit == 1 {
-				// Collection is empty
-				log.Printf("[CHROMEM] Collection is empty")
-				return nil, nil
-			}
-			continue
-		}
+	count := col.Count()
+	if count == 0 {
+		return nil, nil
+	}
+	if limit > count {
+		limit = count
+	}
 
-		// Some other error
+	results, err := col.QueryEmbedding(ctx, embedding, limit, where, nil)
+	if err != nil {
 		return nil, fmt.Errorf("chromem query: %w", err)
 	}
 
-	log.Printf("[CHROMEM] Retrieved %d raw results", len(results))
-
 	// Convert and filter results
 	var memories []memory.Memory
-	for i, result := range results {
+	for _, result := range results {
 		// Deserialize memory
 		mem, err := deserializeMemory(result)
 		if err != nil {
-			log.Printf("[CHROMEM] Skipping result #%d: %v", i+1, err)
+			s.logger.WarnContext(ctx, "skipping unreadable memory", "memory_id", result.ID, "user_id", userID, "error", err)
 			continue
 		}
 
 		memories = append(memories, mem)
 	}
 
-	log.Printf("[CHROMEM] Returning %d memories", len(memories))
+	s.logger.DebugContext(ctx, "queried memories", "user_id", userID, "limit", limit, "results", len(results), "memories", len(memories))
 	return memories, nil
 }
 
@@ -171,10 +300,38 @@ func (s *ChromemStore) Get(ctx context.Context, ownerID string, memoryID string)
 func (s *ChromemStore) Delete(ctx context.Context, ownerID string, memoryID string) error {
 	// Note: chromem-go doesn't expose direct delete by ID in current API
 	// For local version, this is acceptable (memories decay naturally)
-	log.Printf("[CHROMEM] Delete not supported (chromem-go limitation)")
+	s.logger.DebugContext(ctx, "delete not supported (chromem-go limitation)", "memory_id", memoryID, "user_id", ownerID)
 	return nil
 }
 
+// UpdateImportance adds delta to a memory's importance, clamped to
+// [0.0-1.0], and returns the new importance.
+func (s *ChromemStore) UpdateImportance(ctx context.Context, ownerID string, memoryID string, delta float64) (float64, error) {
+	col, err := s.getOrCreateCollection(ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	doc, err := col.GetByID(ctx, memoryID)
+	if err != nil {
+		return 0, err
+	}
+	importance, err := strconv.ParseFloat(doc.Metadata["importance"], 64)
+	if err != nil {
+		importance = 0.5
+	}
+	importance = math.Max(0, math.Min(1, importance+delta))
+	doc.Metadata["importance"] = strconv.FormatFloat(importance, 'f', -1, 64)
+
+	if err := col.AddDocument(ctx, doc); err != nil {
+		return 0, fmt.Errorf("update document: %w", err)
+	}
+	return importance, nil
+}
+
 // Close releases resources.
 func (s *ChromemStore) Close() error {
 	// chromem-go keeps everything in memory, nothing to close
@@ -203,6 +360,9 @@ func serializeMemory(mem memory.Memory) (*StoredMemory, error) {
 		"conversation_id": mem.ConversationID(),
 		"created_at":      mem.CreatedAt().Format(time.RFC3339),
 	}
+	if imp, ok := mem.(interface{ Importance() float64 }); ok {
+		metadata["importance"] = strconv.FormatFloat(imp.Importance(), 'f', -1, 64)
+	}
 
 	// Add custom metadata
 	for k, v := range mem.Metadata() {
@@ -239,64 +399,35 @@ func deserializeMemory(result chromem.Result) (memory.Memory, error) {
 
 // deserializeTraceMemory deserializes a TraceMemory from chromem result.
 func deserializeTraceMemory(result chromem.Result) (*memory.TraceMemory, error) {
-	// Parse content
-	var content map[string]interface{}
+	// Parse content (documents stored before TraceContent have the same keys)
+	var content memory.TraceContent
 	if err := json.Unmarshal([]byte(result.Content), &content); err != nil {
 		return nil, fmt.Errorf("unmarshal content: %w", err)
 	}
 
-	// Extract fields
-	thought, _ := content["thought"].(string)
-	action, _ := content["action"].(string)
-	observation, _ := content["observation"].(string)
-	success, _ := content["success"].(bool)
-
 	// Parse timestamps
 	createdAt, _ := time.Parse(time.RFC3339, result.Metadata["created_at"])
 
 	// Parse metadata
 	metadata := make(map[string]interface{})
 	for k, v := range result.Metadata {
-		if k != "type" && k != "owner_id" && k != "conversation_id" && k != "created_at" {
+		if k != "type" && k != "owner_id" && k != "conversation_id" && k != "created_at" && k != "importance" {
 			metadata[k] = v
 		}
 	}
 
 	// Create TraceMemory using storage constructor
-	return memory.NewTraceMemoryFromStorage(
+	mem := memory.NewTraceMemoryFromContent(
 		result.ID,
 		result.Metadata["owner_id"],
 		result.Metadata["conversation_id"],
 		createdAt,
 		result.Embedding,
-		thought,
-		action,
-		observation,
-		success,
+		content,
 		metadata,
-	), nil
-}
-
-// isInsufficientDocsError checks if error is due to insufficient documents.
-func isInsufficientDocsError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := err.Error()
-	return contains(errStr, "nResults must be") || contains(errStr, "number of documents")
-}
-
-// contains checks if a string contains a substring.
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
-		(len(s) > 0 && len(substr) > 0 && containsHelper(s, substr)))
-}
-
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+	)
+	if importance, err := strconv.ParseFloat(result.Metadata["importance"], 64); err == nil {
+		mem.SetImportance(importance)
 	}
-	return false
+	return mem, nil
 }
@@ -0,0 +1,177 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	chromem "github.com/philippgille/chromem-go"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+func TestHasMemories(t *testing.T) {
+	ctx := context.Background()
+	store, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := store.PreloadUsers(ctx, []string{"user1", "user2"}); err != nil {
+		t.Fatalf("PreloadUsers() error = %v", err)
+	}
+
+	mem := memory.NewTraceMemory("user1", "conv1", &core.Trace{Action: "get_balance", Success: true})
+	mem.SetEmbedding([]float32{1, 0, 0})
+	if err := store.Store(ctx, mem); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	tests := []struct {
+		userID string
+		want   bool
+	}{
+		{"user1", true},  // Preloaded, has a memory
+		{"user2", false}, // Preloaded, empty
+		{"user3", false}, // Unknown
+	}
+	for _, tt := range tests {
+		got, err := store.HasMemories(ctx, tt.userID)
+		if err != nil {
+			t.Fatalf("HasMemories(%q) error = %v", tt.userID, err)
+		}
+		if got != tt.want {
+			t.Errorf("HasMemories(%q) = %v, want %v", tt.userID, got, tt.want)
+		}
+	}
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	ctx := context.Background()
+	store, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := store.Dimensions(); got != 0 {
+		t.Errorf("Dimensions() of empty store = %d, want 0", got)
+	}
+
+	storeTrace := func(userID string, n int) error {
+		mem := memory.NewTraceMemory(userID, "conv1", &core.Trace{Action: "get_balance", Success: true})
+		mem.SetEmbedding(make([]float32, n))
+		mem.Embedding()[0] = 1
+		return store.Store(ctx, mem)
+	}
+	if err := storeTrace("user1", 384); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if got := store.Dimensions(); got != 384 {
+		t.Errorf("Dimensions() = %d, want 384", got)
+	}
+
+	if err := storeTrace("user1", 512); !errors.Is(err, memory.ErrDimensionMismatch) {
+		t.Errorf("Store() of 512 dimensions error = %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := store.Query(ctx, "user1", make([]float32, 512), 5); !errors.Is(err, memory.ErrDimensionMismatch) {
+		t.Errorf("Query() with 512 dimensions error = %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := store.Query(ctx, "user1", make([]float32, 384), 5); err != nil {
+		t.Errorf("Query() with 384 dimensions error = %v", err)
+	}
+
+	// Collections are checked separately
+	if err := storeTrace("user2", 512); err != nil {
+		t.Errorf("Store() of 512 dimensions for another user error = %v", err)
+	}
+	if got := store.Dimensions(); got != 0 {
+		t.Errorf("Dimensions() with mixed collections = %d, want 0", got)
+	}
+}
+
+// BenchmarkQuerySmallCollection queries a collection smaller than the limit,
+// which needs a single QueryEmbedding call rather than one per limit step.
+func BenchmarkQuerySmallCollection(b *testing.B) {
+	ctx := context.Background()
+	store, err := New()
+	if err != nil {
+		b.Fatalf("New() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		mem := memory.NewTraceMemory("user1", "conv1", &core.Trace{Action: "get_balance", Success: true})
+		mem.SetEmbedding([]float32{1, float32(i), 0})
+		if err := store.Store(ctx, mem); err != nil {
+			b.Fatalf("Store() error = %v", err)
+		}
+	}
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.Query(ctx, "user1", []float32{1, 0, 0}, 30); err != nil {
+			b.Fatalf("Query() error = %v", err)
+		}
+	}
+}
+
+func TestTraceContentRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	input := `{"amount":"12345678.123456789","recipient":{"tag":"@alice"}}`
+	mem := memory.NewTraceMemory("user1", "conv1", &core.Trace{
+		Thought:     "Pay Alice back",
+		Action:      "send_money",
+		ActionInput: []byte(input),
+		Observation: "Sent",
+		Success:     true,
+	})
+	mem.SetEmbedding([]float32{1, 0, 0})
+	if err := store.Store(ctx, mem); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	results, err := store.Query(ctx, "user1", []float32{1, 0, 0}, 1)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Query() = %v, %v, want 1 result", results, err)
+	}
+	got, ok := results[0].(*memory.TraceMemory)
+	if !ok {
+		t.Fatalf("Query() returned %T, want *memory.TraceMemory", results[0])
+	}
+	if string(got.ActionInput) != input {
+		t.Errorf("ActionInput = %s, want %s", got.ActionInput, input)
+	}
+	if got.Thought != "Pay Alice back" || got.Action != "send_money" || got.Observation != "Sent" || !got.Success {
+		t.Errorf("round-tripped trace = %+v, want the stored fields", got)
+	}
+}
+
+func TestDeserializeLegacyTraceContent(t *testing.T) {
+	// Documents stored before TraceContent: a plain map, no action_input
+	mem, err := deserializeTraceMemory(chromem.Result{
+		ID:      "mem1",
+		Content: `{"action":"get_balance","observation":"Balance is $100","success":true,"thought":"Check"}`,
+		Metadata: map[string]string{
+			"type":       "trace",
+			"owner_id":   "user1",
+			"created_at": "2025-01-02T03:04:05Z",
+		},
+	})
+	if err != nil {
+		t.Fatalf("deserializeTraceMemory() error = %v", err)
+	}
+	if mem.Action != "get_balance" || mem.Observation != "Balance is $100" || !mem.Success || mem.Thought != "Check" {
+		t.Errorf("deserializeTraceMemory() = %+v, want the legacy fields", mem)
+	}
+	if mem.ActionInput != nil {
+		t.Errorf("ActionInput = %s, want nil", mem.ActionInput)
+	}
+}
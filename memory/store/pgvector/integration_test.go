@@ -0,0 +1,122 @@
+package pgvector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+// newIntegrationStore returns a store on a fresh table in the database named
+// by PGVECTOR_TEST_DSN, skipping the test if it's unset. The database needs
+// the pgvector extension available.
+func newIntegrationStore(t *testing.T) *PgVectorStore {
+	t.Helper()
+	dsn := os.Getenv("PGVECTOR_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PGVECTOR_TEST_DSN not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	table := fmt.Sprintf("memories_test_%d", time.Now().UnixNano())
+	t.Cleanup(func() { db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table) })
+
+	cfg := Config{Dimensions: 3, Table: table}
+	if _, err := New(ctx, db, cfg); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// Migrating an existing schema is a no-op.
+	store, err := New(ctx, db, cfg)
+	if err != nil {
+		t.Fatalf("New() on an existing table error = %v", err)
+	}
+	return store
+}
+
+func traceMemory(ownerID, action string, embedding ...float32) *memory.TraceMemory {
+	mem := memory.NewTraceMemory(ownerID, "conv-1", &core.Trace{Action: action, Observation: "ok", Success: true})
+	mem.SetEmbedding(embedding)
+	return mem
+}
+
+func TestStoreIntegration(t *testing.T) {
+	store := newIntegrationStore(t)
+	ctx := context.Background()
+
+	balance := traceMemory("alice", "get_balance", 1, 0, 0)
+	send := traceMemory("alice", "send_money", 0, 1, 0)
+	other := traceMemory("bob", "get_balance", 1, 0, 0)
+	for _, mem := range []memory.Memory{balance, send, other} {
+		if err := store.Store(ctx, mem); err != nil {
+			t.Fatalf("Store(%s) error = %v", mem.ID(), err)
+		}
+	}
+
+	got, err := store.Query(ctx, "alice", []float32{0.9, 0.1, 0}, 5)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID() != balance.ID() || got[1].ID() != send.ID() {
+		t.Errorf("Query() = %v, want alice's memories nearest first", got)
+	}
+
+	mem, err := store.Get(ctx, "alice", send.ID())
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if trace := mem.(*memory.TraceMemory); trace.Action != "send_money" || trace.ConversationID() != "conv-1" {
+		t.Errorf("Get() = %+v, want the stored send_money memory", trace)
+	}
+	if _, err := store.Get(ctx, "bob", send.ID()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of another owner's memory error = %v, want ErrNotFound", err)
+	}
+
+	// Another owner can't overwrite the memory by reusing its ID.
+	hijack := memory.NewTraceMemoryFromStorage(send.ID(), "bob", "conv-2", time.Now(), []float32{0, 0, 1}, "", "hijack", "", true, nil)
+	if err := store.Store(ctx, hijack); !errors.Is(err, ErrIDTaken) {
+		t.Errorf("Store() with another owner's ID error = %v, want ErrIDTaken", err)
+	}
+	// The owner can.
+	send.SetEmbedding([]float32{0, 0, 1})
+	if err := store.Store(ctx, send); err != nil {
+		t.Errorf("Store() replacing own memory error = %v", err)
+	}
+
+	if n, err := store.Count(ctx, "alice"); err != nil || n != 2 {
+		t.Errorf("Count() = (%d, %v), want 2", n, err)
+	}
+	if has, err := store.HasMemories(ctx, "carol"); err != nil || has {
+		t.Errorf("HasMemories() for a new user = (%v, %v), want false", has, err)
+	}
+
+	if imp, err := store.UpdateImportance(ctx, "alice", balance.ID(), 5); err != nil || imp != 1 {
+		t.Errorf("UpdateImportance() = (%v, %v), want clamped to 1", imp, err)
+	}
+	if _, err := store.UpdateImportance(ctx, "bob", balance.ID(), 0.1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateImportance() of another owner's memory error = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, "alice", balance.ID()); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(ctx, "alice", balance.ID()); err != nil {
+		t.Errorf("Delete() of a missing memory error = %v", err)
+	}
+	list, err := store.List(ctx, "alice")
+	if err != nil || len(list) != 1 || list[0].ID() != send.ID() {
+		t.Errorf("List() after Delete() = (%v, %v), want only send_money", list, err)
+	}
+}
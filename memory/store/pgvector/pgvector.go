@@ -0,0 +1,419 @@
+// Package pgvector provides a memory.Store backed by PostgreSQL with the
+// pgvector extension.
+//
+// The store takes a *sql.DB, so any Postgres driver works:
+//
+//	import _ "github.com/jackc/pgx/v5/stdlib"
+//
+//	db, err := sql.Open("pgx", os.Getenv("DATABASE_URL"))
+//	store, err := pgvector.New(ctx, db, pgvector.Config{Dimensions: embedder.Dimensions()})
+package pgvector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+// ErrNotFound is returned by Get when no memory matches the ID and owner.
+var ErrNotFound = errors.New("memory not found")
+
+// ErrIDTaken is returned by Store when the memory's ID is already used by
+// another owner's memory.
+var ErrIDTaken = errors.New("memory ID belongs to another owner")
+
+// IndexType selects the approximate nearest neighbor index on embeddings.
+type IndexType string
+
+const (
+	// IndexHNSW builds an HNSW index: better recall and query speed, slower
+	// builds. The default.
+	IndexHNSW IndexType = "hnsw"
+
+	// IndexIVFFlat builds an IVFFlat index: faster builds and less memory.
+	// Create it after loading data, as its lists are computed at build time.
+	IndexIVFFlat IndexType = "ivfflat"
+
+	// IndexNone skips the vector index (exact search).
+	IndexNone IndexType = "none"
+)
+
+// Config configures a PgVectorStore.
+type Config struct {
+	// Dimensions is the embedding vector size. Required; it must match the
+	// embedder's Dimensions().
+	Dimensions int
+
+	// Table is the table name (default "memories").
+	Table string
+
+	// Index is the vector index type (default IndexHNSW).
+	Index IndexType
+
+	// Lists is the number of IVFFlat lists (default 100).
+	Lists int
+
+	// Logger receives the store's structured logs (default slog.Default()).
+	Logger *slog.Logger
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PgVectorStore stores memories in a Postgres table, one row per memory,
+// and queries them by cosine distance filtered by owner.
+type PgVectorStore struct {
+	db     *sql.DB
+	table  string
+	config Config
+}
+
+// New creates a store on db, creating the vector extension, table and
+// indexes if they don't exist.
+func New(ctx context.Context, db *sql.DB, cfg Config) (*PgVectorStore, error) {
+	if cfg.Dimensions <= 0 {
+		return nil, errors.New("pgvector: Dimensions is required")
+	}
+	if cfg.Table == "" {
+		cfg.Table = "memories"
+	}
+	if !identifier.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("pgvector: invalid table name %q", cfg.Table)
+	}
+	if cfg.Index == "" {
+		cfg.Index = IndexHNSW
+	}
+	if cfg.Lists <= 0 {
+		cfg.Lists = 100
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	s := &PgVectorStore{db: db, table: cfg.Table, config: cfg}
+	if err := s.migrate(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate creates the schema.
+func (s *PgVectorStore) migrate(ctx context.Context) error {
+	stmts := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			owner_id TEXT NOT NULL,
+			conversation_id TEXT NOT NULL DEFAULT '',
+			type TEXT NOT NULL,
+			content JSONB NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}',
+			embedding vector(%d) NOT NULL,
+			importance DOUBLE PRECISION NOT NULL DEFAULT 0.5,
+			created_at TIMESTAMPTZ NOT NULL
+		)`, s.table, s.config.Dimensions),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_owner_idx ON %[1]s (owner_id, created_at)`, s.table),
+	}
+
+	switch s.config.Index {
+	case IndexHNSW:
+		stmts = append(stmts, fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS %[1]s_embedding_idx ON %[1]s USING hnsw (embedding vector_cosine_ops)`,
+			s.table))
+	case IndexIVFFlat:
+		stmts = append(stmts, fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS %[1]s_embedding_idx ON %[1]s USING ivfflat (embedding vector_cosine_ops) WITH (lists = %[2]d)`,
+			s.table, s.config.Lists))
+	case IndexNone:
+	default:
+		return fmt.Errorf("pgvector: unknown index type %q", s.config.Index)
+	}
+
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("pgvector migrate: %w", err)
+		}
+	}
+	return nil
+}
+
+// Store saves a memory with its embedding, replacing the owner's memory
+// with the same ID. Returns ErrIDTaken if another owner has a memory with
+// that ID.
+func (s *PgVectorStore) Store(ctx context.Context, mem memory.Memory) error {
+	embedding := mem.Embedding()
+	if len(embedding) != s.config.Dimensions {
+		return fmt.Errorf("pgvector: %w: embedding has %d dimensions, want %d", memory.ErrDimensionMismatch, len(embedding), s.config.Dimensions)
+	}
+
+	content, err := json.Marshal(mem.Content())
+	if err != nil {
+		return fmt.Errorf("marshal content: %w", err)
+	}
+	metadata, err := json.Marshal(mem.Metadata())
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+	importance := 0.5
+	if imp, ok := mem.(interface{ Importance() float64 }); ok {
+		importance = imp.Importance()
+	}
+
+	s.config.Logger.DebugContext(ctx, "storing memory", "memory_id", mem.ID(), "user_id", mem.OwnerID(), "type", mem.Type())
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %[1]s (id, owner_id, conversation_id, type, content, metadata, embedding, importance, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::vector, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			content = EXCLUDED.content,
+			metadata = EXCLUDED.metadata,
+			embedding = EXCLUDED.embedding,
+			importance = EXCLUDED.importance
+		WHERE %[1]s.owner_id = EXCLUDED.owner_id`, s.table),
+		mem.ID(), mem.OwnerID(), mem.ConversationID(), mem.Type(),
+		string(content), string(metadata), formatVector(embedding), importance, mem.CreatedAt())
+	if err != nil {
+		return fmt.Errorf("insert memory: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("insert memory: %w", err)
+	}
+	if n == 0 {
+		// The ON CONFLICT update was skipped by its owner check
+		return fmt.Errorf("pgvector: %w: %s", ErrIDTaken, mem.ID())
+	}
+	return nil
+}
+
+// Query retrieves the user's memories nearest to embedding by cosine
+// distance, most similar first.
+func (s *PgVectorStore) Query(ctx context.Context, userID string, embedding []float32, limit int) ([]memory.Memory, error) {
+	if len(embedding) != s.config.Dimensions {
+		return nil, fmt.Errorf("pgvector: %w: query embedding has %d dimensions, want %d", memory.ErrDimensionMismatch, len(embedding), s.config.Dimensions)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE owner_id = $1
+		ORDER BY embedding <=> $2::vector
+		LIMIT $3`, columns, s.table),
+		userID, formatVector(embedding), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query memories: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []memory.Memory
+	for rows.Next() {
+		mem, err := scanMemory(rows)
+		if err != nil {
+			s.config.Logger.WarnContext(ctx, "skipping unreadable memory", "user_id", userID, "error", err)
+			continue
+		}
+		memories = append(memories, mem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query memories: %w", err)
+	}
+
+	s.config.Logger.DebugContext(ctx, "queried memories", "user_id", userID, "limit", limit, "memories", len(memories))
+	return memories, nil
+}
+
+// List returns all of the user's memories, oldest first.
+func (s *PgVectorStore) List(ctx context.Context, ownerID string) ([]memory.Memory, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE owner_id = $1
+		ORDER BY created_at, id`, columns, s.table),
+		ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []memory.Memory
+	for rows.Next() {
+		mem, err := scanMemory(rows)
+		if err != nil {
+			s.config.Logger.WarnContext(ctx, "skipping unreadable memory", "user_id", ownerID, "error", err)
+			continue
+		}
+		memories = append(memories, mem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+	return memories, nil
+}
+
+// Get retrieves a specific memory by ID and owner.
+// Returns ErrNotFound if it doesn't exist.
+func (s *PgVectorStore) Get(ctx context.Context, ownerID string, memoryID string) (memory.Memory, error) {
+	row := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s WHERE owner_id = $1 AND id = $2`, columns, s.table),
+		ownerID, memoryID)
+	mem, err := scanMemory(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return mem, err
+}
+
+// Delete removes a memory permanently. Deleting a memory that doesn't exist
+// is not an error.
+func (s *PgVectorStore) Delete(ctx context.Context, ownerID string, memoryID string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE owner_id = $1 AND id = $2`, s.table),
+		ownerID, memoryID)
+	if err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
+	return nil
+}
+
+// UpdateImportance adds delta to a memory's importance, clamped to
+// [0.0-1.0], and returns the new importance.
+// Returns ErrNotFound if the memory doesn't exist.
+func (s *PgVectorStore) UpdateImportance(ctx context.Context, ownerID string, memoryID string, delta float64) (float64, error) {
+	var importance float64
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET importance = LEAST(1, GREATEST(0, importance + $3))
+		WHERE owner_id = $1 AND id = $2
+		RETURNING importance`, s.table),
+		ownerID, memoryID, delta).Scan(&importance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("update importance: %w", err)
+	}
+	return importance, nil
+}
+
+// HasMemories reports whether the user has any stored memories.
+func (s *PgVectorStore) HasMemories(ctx context.Context, ownerID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE owner_id = $1)`, s.table), ownerID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("check memories: %w", err)
+	}
+	return exists, nil
+}
+
+// Count returns the number of memories stored for a user.
+func (s *PgVectorStore) Count(ctx context.Context, ownerID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT count(*) FROM %s WHERE owner_id = $1`, s.table), ownerID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count memories: %w", err)
+	}
+	return n, nil
+}
+
+// Dimensions returns the configured embedding size.
+func (s *PgVectorStore) Dimensions() int {
+	return s.config.Dimensions
+}
+
+// Ping checks the database is reachable.
+func (s *PgVectorStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close releases resources. The *sql.DB belongs to the caller and is not
+// closed.
+func (s *PgVectorStore) Close() error {
+	return nil
+}
+
+// columns are the columns read by scanMemory, in order.
+const columns = `id, owner_id, conversation_id, type, content, metadata, embedding::text, importance, created_at`
+
+// scanner is a *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMemory reads a memory row selected with columns.
+func scanMemory(row scanner) (memory.Memory, error) {
+	var (
+		id, ownerID, conversationID, memType string
+		content, metadata, embedding         string
+		importance                           float64
+		createdAt                            time.Time
+	)
+	if err := row.Scan(&id, &ownerID, &conversationID, &memType, &content, &metadata, &embedding, &importance, &createdAt); err != nil {
+		return nil, err
+	}
+
+	vector, err := parseVector(embedding)
+	if err != nil {
+		return nil, fmt.Errorf("parse embedding of %s: %w", id, err)
+	}
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &meta); err != nil {
+		return nil, fmt.Errorf("unmarshal metadata of %s: %w", id, err)
+	}
+
+	switch memType {
+	case "trace":
+		var c memory.TraceContent
+		if err := json.Unmarshal([]byte(content), &c); err != nil {
+			return nil, fmt.Errorf("unmarshal content of %s: %w", id, err)
+		}
+		mem := memory.NewTraceMemoryFromContent(id, ownerID, conversationID, createdAt, vector, c, meta)
+		mem.SetImportance(importance)
+		return mem, nil
+	default:
+		return nil, fmt.Errorf("unknown memory type: %s", memType)
+	}
+}
+
+// formatVector encodes v as a pgvector text literal, e.g. "[0.1,0.2]".
+func formatVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseVector decodes a pgvector text literal.
+func parseVector(s string) ([]float32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	v := make([]float32, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector element %q: %w", p, err)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
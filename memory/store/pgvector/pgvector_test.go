@@ -0,0 +1,60 @@
+package pgvector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestVectorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		v    []float32
+		want string
+	}{
+		{"values", []float32{0.1, -2, 3.25}, "[0.1,-2,3.25]"},
+		{"empty", nil, "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatVector(tt.v)
+			if got != tt.want {
+				t.Errorf("formatVector() = %q, want %q", got, tt.want)
+			}
+			parsed, err := parseVector(got)
+			if err != nil {
+				t.Fatalf("parseVector(%q) error = %v", got, err)
+			}
+			if !reflect.DeepEqual(parsed, tt.v) {
+				t.Errorf("parseVector(%q) = %v, want %v", got, parsed, tt.v)
+			}
+		})
+	}
+}
+
+func TestParseVectorInvalid(t *testing.T) {
+	for _, s := range []string{"", "0.1,0.2", "[0.1,x]"} {
+		if _, err := parseVector(s); err == nil {
+			t.Errorf("parseVector(%q) error = nil, want error", s)
+		}
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing dimensions", Config{}},
+		{"invalid table", Config{Dimensions: 384, Table: "memories; DROP TABLE users"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(context.Background(), nil, tt.cfg); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package memory
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,10 +28,21 @@ type TraceMemory struct {
 	// Trace-specific fields
 	Thought     string
 	Action      string
+	ActionInput json.RawMessage
 	Observation string
 	Success     bool
 }
 
+// TraceContent is the stored content of a TraceMemory, returned by Content.
+// Stores marshal it to JSON and unmarshal it back with NewTraceMemoryFromContent.
+type TraceContent struct {
+	Thought     string          `json:"thought"`
+	Action      string          `json:"action"`
+	ActionInput json.RawMessage `json:"action_input,omitempty"`
+	Observation string          `json:"observation"`
+	Success     bool            `json:"success"`
+}
+
 // NewTraceMemory creates a TraceMemory from a core.Trace.
 func NewTraceMemory(ownerID string, conversationID string, trace *core.Trace) *TraceMemory {
 	// Assess importance
@@ -45,7 +58,7 @@ func NewTraceMemory(ownerID string, conversationID string, trace *core.Trace) *T
 	}
 
 	return &TraceMemory{
-		id:             uuid.New().String(),
+		id:             traceMemoryID(ownerID, trace),
 		ownerID:        ownerID,
 		conversationID: conversationID,
 		createdAt:      time.Now(),
@@ -53,11 +66,34 @@ func NewTraceMemory(ownerID string, conversationID string, trace *core.Trace) *T
 		metadata:       metadata,
 		Thought:        trace.Thought,
 		Action:         trace.Action,
+		ActionInput:    trace.ActionInput,
 		Observation:    trace.Observation,
 		Success:        trace.Success,
 	}
 }
 
+// traceMemoryNamespace is the UUID namespace of trace memory IDs.
+var traceMemoryNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/becomeliminal/nim-go-sdk/memory/trace"))
+
+// traceMemoryID derives a stable memory ID from the trace's user, session,
+// turn and action, so recording the same trace twice (e.g. when a response
+// is retried) overwrites the stored memory instead of duplicating it. The
+// input is included because one turn can call the same tool more than once,
+// e.g. a batch of transfers. Traces without a session get a random ID.
+func traceMemoryID(ownerID string, trace *core.Trace) string {
+	if trace.SessionID == "" {
+		return uuid.New().String()
+	}
+	key := strings.Join([]string{
+		ownerID,
+		trace.SessionID,
+		strconv.Itoa(trace.TurnNumber),
+		trace.Action,
+		string(trace.ActionInput),
+	}, "\x00")
+	return uuid.NewSHA1(traceMemoryNamespace, []byte(key)).String()
+}
+
 // NewTraceMemoryFromStorage creates a TraceMemory from stored data.
 // This is used by Store implementations when deserializing.
 func NewTraceMemoryFromStorage(
@@ -87,6 +123,24 @@ func NewTraceMemoryFromStorage(
 	}
 }
 
+// NewTraceMemoryFromContent creates a TraceMemory from stored data and its
+// decoded TraceContent. This is used by Store implementations when
+// deserializing.
+func NewTraceMemoryFromContent(
+	id string,
+	ownerID string,
+	conversationID string,
+	createdAt time.Time,
+	embedding []float32,
+	content TraceContent,
+	metadata map[string]interface{},
+) *TraceMemory {
+	mem := NewTraceMemoryFromStorage(id, ownerID, conversationID, createdAt, embedding,
+		content.Thought, content.Action, content.Observation, content.Success, metadata)
+	mem.ActionInput = content.ActionInput
+	return mem
+}
+
 // Memory interface implementation
 
 func (t *TraceMemory) ID() string {
@@ -105,12 +159,14 @@ func (t *TraceMemory) Type() string {
 	return "trace"
 }
 
+// Content returns the trace as a TraceContent.
 func (t *TraceMemory) Content() interface{} {
-	return map[string]interface{}{
-		"thought":     t.Thought,
-		"action":      t.Action,
-		"observation": t.Observation,
-		"success":     t.Success,
+	return TraceContent{
+		Thought:     t.Thought,
+		Action:      t.Action,
+		ActionInput: t.ActionInput,
+		Observation: t.Observation,
+		Success:     t.Success,
 	}
 }
 
@@ -142,7 +198,11 @@ func (t *TraceMemory) Format(ctx FormatContext) string {
 	}
 
 	// Action line
-	parts = append(parts, fmt.Sprintf("[%s] %s", status, t.Action))
+	action := fmt.Sprintf("[%s] %s", status, t.Action)
+	if ctx.ShowScore {
+		action += fmt.Sprintf(" (relevance %.2f)", ctx.Score)
+	}
+	parts = append(parts, action)
 
 	// Thought (if meaningful)
 	if len(t.Thought) > 0 {
@@ -163,7 +223,46 @@ func (t *TraceMemory) Format(ctx FormatContext) string {
 		}
 	}
 
-	return strings.Join(parts, "\n")
+	formatted := strings.Join(parts, "\n")
+	if ctx.MaxLength > 0 {
+		formatted = truncate(formatted, ctx.MaxLength)
+	}
+	return formatted
+}
+
+// Trace converts the memory back to a core.Trace. The trace's ID is the
+// memory's ID and its Metadata is the memory's metadata, without the action
+// and success keys added by NewTraceMemory. Non-string metadata values are
+// formatted with fmt.Sprint.
+func (t *TraceMemory) Trace() *core.Trace {
+	var metadata map[string]string
+	for k, v := range t.metadata {
+		if k == "action" || k == "success" {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		if s, ok := v.(string); ok {
+			metadata[k] = s
+		} else {
+			metadata[k] = fmt.Sprint(v)
+		}
+	}
+	trace := &core.Trace{
+		ID:          t.id,
+		SessionID:   t.conversationID,
+		Thought:     t.Thought,
+		Action:      t.Action,
+		ActionInput: t.ActionInput,
+		Observation: t.Observation,
+		Success:     t.Success,
+		Metadata:    metadata,
+	}
+	if !t.createdAt.IsZero() {
+		trace.Timestamp = t.createdAt.Unix()
+	}
+	return trace
 }
 
 // FormatForEmbedding returns text representation for embedding.
@@ -178,6 +277,12 @@ func (t *TraceMemory) Importance() float64 {
 	return t.importance
 }
 
+// SetImportance overrides the importance score, e.g. when restoring a
+// stored trace.
+func (t *TraceMemory) SetImportance(importance float64) {
+	t.importance = importance
+}
+
 // Helper functions
 
 // assessTraceImportance scores trace importance [0.0-1.0].
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errTooManyRuns is returned for agent runs over a user's concurrency limit.
+var errTooManyRuns = errors.New("too many requests in progress; wait for your current request to finish and try again")
+
+// userLimiter bounds the agent runs each user has in flight, across all of
+// their WebSockets and REST requests.
+type userLimiter struct {
+	max  int
+	wait time.Duration // How long an excess run waits for a slot
+
+	mu    sync.Mutex
+	users map[string]*userSlots
+}
+
+// userSlots is one user's run semaphore. refs counts runs holding or
+// waiting for a slot, so idle users are removed from the map.
+type userSlots struct {
+	sem  chan struct{}
+	refs int
+}
+
+// newUserLimiter returns a limiter allowing max runs per user, or nil for
+// no limit.
+func newUserLimiter(max int, wait time.Duration) *userLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &userLimiter{max: max, wait: wait, users: make(map[string]*userSlots)}
+}
+
+// acquire reserves a run slot for userID, waiting up to l.wait for one to
+// free up. It returns errTooManyRuns if none does, or ctx's error if ctx is
+// done first. On success the caller must call release. A nil limiter
+// allows every run.
+func (l *userLimiter) acquire(ctx context.Context, userID string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	slots, ok := l.users[userID]
+	if !ok {
+		slots = &userSlots{sem: make(chan struct{}, l.max)}
+		l.users[userID] = slots
+	}
+	slots.refs++
+	l.mu.Unlock()
+
+	select {
+	case slots.sem <- struct{}{}:
+		return nil
+	default:
+	}
+
+	err := errTooManyRuns
+	if l.wait > 0 {
+		timer := time.NewTimer(l.wait)
+		defer timer.Stop()
+		select {
+		case slots.sem <- struct{}{}:
+			return nil
+		case <-timer.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	l.unref(userID, slots)
+	return err
+}
+
+// release frees a slot reserved by acquire.
+func (l *userLimiter) release(userID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	slots := l.users[userID]
+	l.mu.Unlock()
+	<-slots.sem
+	l.unref(userID, slots)
+}
+
+func (l *userLimiter) unref(userID string, slots *userSlots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots.refs--
+	if slots.refs == 0 {
+		delete(l.users, userID)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestPerUserConcurrencyLimit(t *testing.T) {
+	done := fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Done."})
+	srv := newTestServer(t, Config{MaxConcurrentRunsPerUser: 1},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": map[string]interface{}{},
+		}),
+		// Replies and conversation titles, in whichever order they're requested
+		done, done, done, done,
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:        "get_balance",
+		ToolDescription: "Get balance",
+		InputSchema:     map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		close(started)
+		<-release
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+
+	first := make(chan int, 1)
+	go func() {
+		rec, _ := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "balance"})
+		first <- rec.Code
+	}()
+	<-started
+
+	// A second conversation from the same user is rejected while the first
+	// run is in flight...
+	rec, resp := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "balance again"})
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("concurrent POST /chat status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if resp.Error != errTooManyRuns.Error() {
+		t.Errorf("concurrent POST /chat error = %q, want %q", resp.Error, errTooManyRuns.Error())
+	}
+
+	// ...but other users aren't affected.
+	rec, _ = postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "bob", Message: "hi"})
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST /chat from another user status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first POST /chat status = %d, want %d", code, http.StatusOK)
+	}
+	if len(srv.userRuns.users) != 0 {
+		t.Errorf("limiter still tracks %d users after all runs finished", len(srv.userRuns.users))
+	}
+}
+
+func TestUserLimiterWait(t *testing.T) {
+	l := newUserLimiter(1, time.Second)
+	ctx := context.Background()
+	if err := l.acquire(ctx, "alice"); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- l.acquire(ctx, "alice") }()
+	select {
+	case err := <-acquired:
+		t.Fatalf("queued acquire() returned %v while the slot was held", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.release("alice")
+	if err := <-acquired; err != nil {
+		t.Fatalf("queued acquire() error = %v, want the freed slot", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.acquire(cancelled, "alice"); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+	l.release("alice")
+}
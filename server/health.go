@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/packages/param"
+
+	"github.com/becomeliminal/nim-go-sdk/memory"
+)
+
+const (
+	// healthCheckTimeout bounds each dependency check.
+	healthCheckTimeout = 5 * time.Second
+
+	// anthropicHealthTTL is how long an Anthropic check result is reused, so
+	// frequent readiness probes don't each cost an API call.
+	anthropicHealthTTL = 30 * time.Second
+)
+
+// HealthCheck is a named dependency check run by the /ready endpoint.
+// Check should be cheap and return an error if the dependency is unusable.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthResponse is the /ready response body.
+type HealthResponse struct {
+	Status string                  `json:"status"` // "ok" or "unavailable"
+	Checks map[string]HealthStatus `json:"checks"`
+}
+
+// HealthStatus is the result of a single dependency check.
+type HealthStatus struct {
+	Status     string `json:"status"` // "ok" or "error"
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// healthCache holds the last Anthropic check result.
+type healthCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// HealthHandler returns an HTTP handler for GET /ready. It runs the built-in
+// checks (Anthropic API, Liminal executor if configured, memory store if it
+// implements memory.Pinger) plus Config.HealthChecks concurrently, and
+// responds 200 if all pass or 503 otherwise. Run mounts it automatically.
+func (s *Server) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := s.checkHealth(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if resp.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// healthChecks returns the built-in checks followed by Config.HealthChecks.
+func (s *Server) healthChecks() []HealthCheck {
+	checks := []HealthCheck{{Name: "anthropic", Check: s.checkAnthropic}}
+	if s.config.LiminalExecutor != nil {
+		checks = append(checks, HealthCheck{Name: "liminal", Check: s.config.LiminalExecutor.Ping})
+	}
+	if p, ok := s.config.Memory.(memory.Pinger); ok {
+		checks = append(checks, HealthCheck{Name: "memory", Check: p.Ping})
+	}
+	return append(checks, s.config.HealthChecks...)
+}
+
+// checkHealth runs all checks concurrently.
+func (s *Server) checkHealth(ctx context.Context) HealthResponse {
+	checks := s.healthChecks()
+	statuses := make([]HealthStatus, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			statuses[i] = HealthStatus{Status: "ok", DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				statuses[i].Status = "error"
+				statuses[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	resp := HealthResponse{Status: "ok", Checks: make(map[string]HealthStatus, len(checks))}
+	for i, check := range checks {
+		resp.Checks[check.Name] = statuses[i]
+		if statuses[i].Status != "ok" {
+			resp.Status = "unavailable"
+		}
+	}
+	return resp
+}
+
+// checkAnthropic lists a single model to verify the API key and
+// connectivity. Results are cached for anthropicHealthTTL.
+func (s *Server) checkAnthropic(ctx context.Context) error {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	if !s.health.checkedAt.IsZero() && time.Since(s.health.checkedAt) < anthropicHealthTTL {
+		return s.health.err
+	}
+
+	_, err := s.client.Models.List(ctx, anthropic.ModelListParams{Limit: param.NewOpt(int64(1))})
+	s.health.checkedAt = time.Now()
+	s.health.err = err
+	return err
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	modelList := map[string]interface{}{
+		"data": []map[string]interface{}{{
+			"id": "claude-test", "type": "model", "display_name": "Claude Test", "created_at": "2025-01-01T00:00:00Z",
+		}},
+		"has_more": false, "first_id": "claude-test", "last_id": "claude-test",
+	}
+	healthy := true
+	srv := newTestServer(t, Config{HealthChecks: []HealthCheck{{
+		Name: "ledger",
+		Check: func(ctx context.Context) error {
+			if !healthy {
+				return errors.New("ledger down")
+			}
+			return nil
+		},
+	}}}, modelList)
+
+	get := func() (int, HealthResponse) {
+		rec := httptest.NewRecorder()
+		srv.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var resp HealthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v (body %s)", err, rec.Body.String())
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := get()
+	if code != http.StatusOK || resp.Status != "ok" {
+		t.Fatalf("GET /ready = %d %+v, want 200 ok", code, resp)
+	}
+	for _, name := range []string{"anthropic", "ledger"} {
+		if resp.Checks[name].Status != "ok" {
+			t.Errorf("check %q = %+v, want ok", name, resp.Checks[name])
+		}
+	}
+
+	// The Anthropic result is cached, so no second models call is made.
+	healthy = false
+	code, resp = get()
+	if code != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+		t.Errorf("GET /ready = %d %q, want 503 unavailable", code, resp.Status)
+	}
+	if got := resp.Checks["ledger"]; got.Status != "error" || got.Error != "ledger down" {
+		t.Errorf("ledger check = %+v, want error %q", got, "ledger down")
+	}
+	if resp.Checks["anthropic"].Status != "ok" {
+		t.Errorf("anthropic check = %+v, want cached ok", resp.Checks["anthropic"])
+	}
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// durationBuckets are the histogram upper bounds, in seconds, for run and
+// tool durations.
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metrics aggregates engine events and serves them in the Prometheus text
+// exposition format. It is the engine's EventSink when Config.MetricsEnabled
+// is set.
+type metrics struct {
+	mu sync.Mutex
+
+	runs          counterVec   // outcome
+	runDuration   histogramVec // outcome
+	toolCalls     counterVec   // tool, status
+	toolDuration  histogramVec // tool
+	confirmations counterVec   // tool
+	tokens        counterVec   // type
+	memory        counterVec   // operation, result
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		runs:          newCounterVec("nim_agent_runs_total", "Agent runs by outcome.", "outcome"),
+		runDuration:   newHistogramVec("nim_agent_run_duration_seconds", "Agent run duration.", "outcome"),
+		toolCalls:     newCounterVec("nim_tool_executions_total", "Tool executions by tool and status.", "tool", "status"),
+		toolDuration:  newHistogramVec("nim_tool_duration_seconds", "Tool execution duration.", "tool"),
+		confirmations: newCounterVec("nim_confirmations_requested_total", "Write operations paused for user confirmation.", "tool"),
+		tokens:        newCounterVec("nim_claude_tokens_total", "Claude API tokens used.", "type"),
+		memory:        newCounterVec("nim_memory_operations_total", "Memory retrievals and records by result.", "operation", "result"),
+	}
+}
+
+// HandleEvent implements engine.EventSink.
+func (m *metrics) HandleEvent(ctx context.Context, event engine.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch event.Type {
+	case engine.EventRunCompleted:
+		outcome := runOutcome(event.Output)
+		m.runs.inc(1, outcome)
+		m.runDuration.observe(event.Duration.Seconds(), outcome)
+		m.tokens.inc(float64(event.Tokens.InputTokens), "input")
+		m.tokens.inc(float64(event.Tokens.OutputTokens), "output")
+
+	case engine.EventToolExecuted:
+		status := "success"
+		if event.Err != nil {
+			status = "error"
+		}
+		m.toolCalls.inc(1, event.Tool, status)
+		m.toolDuration.observe(event.Duration.Seconds(), event.Tool)
+
+	case engine.EventConfirmationRequested:
+		m.confirmations.inc(1, event.Tool)
+
+	case engine.EventMemoryRetrieved:
+		result := "miss"
+		if event.Err != nil {
+			result = "error"
+		} else if event.Hit {
+			result = "hit"
+		}
+		m.memory.inc(1, "retrieve", result)
+
+	case engine.EventMemoryRecorded:
+		result := "success"
+		if event.Err != nil {
+			result = "error"
+		}
+		m.memory.inc(1, "record", result)
+	}
+}
+
+func runOutcome(t engine.OutputType) string {
+	switch t {
+	case engine.OutputComplete:
+		return "complete"
+	case engine.OutputConfirmationNeeded, engine.OutputBatchConfirmationNeeded:
+		return "confirmation_needed"
+	default:
+		return "error"
+	}
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs.write(w)
+	m.runDuration.write(w)
+	m.toolCalls.write(w)
+	m.toolDuration.write(w)
+	m.confirmations.write(w)
+	m.tokens.write(w)
+	m.memory.write(w)
+}
+
+// MetricsHandler returns an HTTP handler for GET /metrics, or nil if
+// Config.MetricsEnabled is false. Run mounts it automatically.
+func (s *Server) MetricsHandler() http.Handler {
+	if s.metrics == nil {
+		return nil
+	}
+	return s.metrics
+}
+
+// counterVec is a Prometheus counter with labels.
+type counterVec struct {
+	name, help string
+	labels     []string
+	values     map[string]float64 // formatted label set -> value
+}
+
+func newCounterVec(name, help string, labels ...string) counterVec {
+	return counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+func (c *counterVec) inc(delta float64, labelValues ...string) {
+	c.values[formatLabels(c.labels, labelValues)] += delta
+}
+
+func (c *counterVec) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	keys := make([]string, 0, len(c.values))
+	for labels := range c.values {
+		keys = append(keys, labels)
+	}
+	sort.Strings(keys)
+	for _, labels := range keys {
+		fmt.Fprintf(w, "%s{%s} %g\n", c.name, labels, c.values[labels])
+	}
+}
+
+// histogramVec is a Prometheus histogram with labels, using durationBuckets.
+type histogramVec struct {
+	name, help string
+	labels     []string
+	series     map[string]*histogram // formatted label set -> histogram
+}
+
+type histogram struct {
+	buckets []uint64 // Cumulative counts per durationBuckets bound
+	count   uint64
+	sum     float64
+}
+
+func newHistogramVec(name, help string, labels ...string) histogramVec {
+	return histogramVec{name: name, help: help, labels: labels, series: make(map[string]*histogram)}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := formatLabels(h.labels, labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{buckets: make([]uint64, len(durationBuckets))}
+		h.series[key] = s
+	}
+	for i, bound := range durationBuckets {
+		if v <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for labels := range h.series {
+		keys = append(keys, labels)
+	}
+	sort.Strings(keys)
+	for _, labels := range keys {
+		s := h.series[labels]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", h.name, labels, bound, s.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, labels, s.count)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", h.name, labels, s.sum)
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, labels, s.count)
+	}
+}
+
+// labelEscaper escapes label values for the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders a label set as `a="x",b="y"`.
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(value))
+	}
+	return strings.Join(pairs, ",")
+}
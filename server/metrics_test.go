@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv := newTestServer(t, Config{MetricsEnabled: true},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": map[string]interface{}{},
+		}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Your balance is $100."}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Balance check"}), // title
+	)
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:        "get_balance",
+		ToolDescription: "Get balance",
+		InputSchema:     map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+
+	rec, _ := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "balance"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /chat status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE nim_agent_runs_total counter\n",
+		`nim_agent_runs_total{outcome="complete"} 1` + "\n",
+		`nim_tool_executions_total{tool="get_balance",status="success"} 1` + "\n",
+		`nim_tool_duration_seconds_count{tool="get_balance"} 1` + "\n",
+		`nim_claude_tokens_total{type="input"} 20` + "\n",
+		`nim_claude_tokens_total{type="output"} 10` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	srv := newTestServer(t, Config{})
+	if h := srv.MetricsHandler(); h != nil {
+		t.Errorf("MetricsHandler() = %v, want nil when metrics are disabled", h)
+	}
+}
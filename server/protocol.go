@@ -1,6 +1,11 @@
-// Package server provides a ready-to-run WebSocket server for the Nim agent.
+// Package server provides a ready-to-run WebSocket and REST server for the Nim agent.
 package server
 
+import (
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
 // ClientMessage is a message from the client.
 type ClientMessage struct {
 	Type           string `json:"type"` // "new_conversation", "resume_conversation", "message", "confirm", "cancel"
@@ -11,15 +16,26 @@ type ClientMessage struct {
 
 // ServerMessage is a message to the client.
 type ServerMessage struct {
-	Type           string      `json:"type"` // "conversation_started", "conversation_resumed", "text", "text_chunk", "confirm_request", "complete", "error"
-	Content        string      `json:"content,omitempty"`
-	ActionID       string      `json:"actionId,omitempty"`
-	Tool           string      `json:"tool,omitempty"`
-	Summary        string      `json:"summary,omitempty"`
-	ExpiresAt      string      `json:"expiresAt,omitempty"`
-	ConversationID string      `json:"conversationId,omitempty"`
-	Messages       interface{} `json:"messages,omitempty"`
-	TokenUsage     *TokenUsage `json:"tokenUsage,omitempty"`
+	Type           string         `json:"type"` // "conversation_started", "conversation_resumed", "text", "text_chunk", "tool_input_chunk", "tool_result", "traces", "plan", "confirm_request", "action_expired", "complete", "error"
+	Content        string         `json:"content,omitempty"`
+	ActionID       string         `json:"actionId,omitempty"`
+	Tool           string         `json:"tool,omitempty"`
+	BlockID        string         `json:"blockId,omitempty"` // tool_use block, for tool_input_chunk
+	Summary        string         `json:"summary,omitempty"`
+	ExpiresAt      string         `json:"expiresAt,omitempty"`
+	ConversationID string         `json:"conversationId,omitempty"`
+	Messages       interface{}    `json:"messages,omitempty"`
+	TokenUsage     *TokenUsage    `json:"tokenUsage,omitempty"`
+	Plan           *engine.Plan   `json:"plan,omitempty"`
+	Actions        []Confirmation `json:"actions,omitempty"` // A batch's actions, for confirm_request
+
+	// Blocks is a tool's rich content (text and image blocks), for
+	// tool_result. Sent for tools that return core.ToolResult.Blocks.
+	Blocks []core.ContentBlock `json:"blocks,omitempty"`
+
+	// Traces are the turn's reasoning steps, for traces. Sent when
+	// Config.IncludeTraces is set.
+	Traces []engine.TraceStep `json:"traces,omitempty"`
 }
 
 // TokenUsage tracks Claude API token consumption.
@@ -31,10 +47,60 @@ type TokenUsage struct {
 	TotalTokens              int `json:"totalTokens"`
 }
 
-// Confirmation contains details about a pending action.
+// Confirmation contains details about a pending action. For a batch of
+// actions confirmed together, ID is the batch's and Actions lists the
+// actions in execution order.
 type Confirmation struct {
-	ID        string `json:"id"`
-	Tool      string `json:"tool"`
-	Summary   string `json:"summary"`
-	ExpiresAt int64  `json:"expiresAt"`
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool,omitempty"`
+	Summary   string         `json:"summary"`
+	ExpiresAt int64          `json:"expiresAt"`
+	Actions   []Confirmation `json:"actions,omitempty"`
+}
+
+// ChatRequest is the body of a POST /chat request.
+type ChatRequest struct {
+	// UserID identifies the user. Only used when the server has no AuthFunc
+	// (and no LiminalExecutor); otherwise the authenticated user ID wins.
+	UserID string `json:"userId,omitempty"`
+
+	// Message is the user's message.
+	Message string `json:"message"`
+
+	// ConversationID continues an existing conversation. If empty, a new
+	// conversation is created and its ID returned in the response.
+	ConversationID string `json:"conversationId,omitempty"`
+
+	// History replaces the server-side history for this turn. If empty, the
+	// server uses the conversation's stored history.
+	History []core.Message `json:"history,omitempty"`
+}
+
+// ConfirmRequest is the body of a POST /confirm request.
+type ConfirmRequest struct {
+	// UserID identifies the user; see ChatRequest.UserID.
+	UserID string `json:"userId,omitempty"`
+
+	// ActionID is the pending action to confirm.
+	ActionID string `json:"actionId"`
+
+	// ConversationID is the conversation the action was created in.
+	ConversationID string `json:"conversationId"`
+
+	// History replaces the server-side history. It must end with the
+	// assistant message containing the action's tool_use block.
+	History []core.Message `json:"history,omitempty"`
+}
+
+// ChatResponse is the result of one agent turn on the REST endpoints.
+type ChatResponse struct {
+	Type           string               `json:"type"` // "complete", "confirm_request", "error"
+	ConversationID string               `json:"conversationId,omitempty"`
+	Text           string               `json:"text,omitempty"`
+	PendingAction  *Confirmation        `json:"pendingAction,omitempty"`
+	ToolsUsed      []core.ToolExecution `json:"toolsUsed,omitempty"`
+	TokenUsage     *TokenUsage          `json:"tokenUsage,omitempty"`
+	Plan           *engine.Plan         `json:"plan,omitempty"`
+	Traces         []engine.TraceStep   `json:"traces,omitempty"` // With Config.IncludeTraces
+	Error          string               `json:"error,omitempty"`
 }
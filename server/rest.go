@@ -0,0 +1,342 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// errConversationNotFound is returned when a REST request names a conversation
+// that doesn't exist or belongs to another user.
+var errConversationNotFound = errors.New("conversation not found")
+
+// ChatHandler returns an HTTP handler for POST /chat.
+//
+// Each request runs one agent turn on the same engine as the WebSocket path
+// and returns a ChatResponse. Responses are not streamed; the full output is
+// returned when the turn completes. If the turn needs confirmation, the
+// response carries the pending action; resume it with POST /confirm.
+func (s *Server) ChatHandler() http.Handler {
+	return http.HandlerFunc(s.handleChat)
+}
+
+// ConfirmHandler returns an HTTP handler for POST /confirm, which executes a
+// pending action from a previous /chat turn and continues the agent loop.
+func (s *Server) ConfirmHandler() http.Handler {
+	return http.HandlerFunc(s.handleRESTConfirm)
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	sess, ok := s.beginChat(w, r, &req)
+	if !ok {
+		return
+	}
+	defer sess.mu.Unlock()
+
+	output, err := s.runChatTurn(r.Context(), sess, req.Message, nil)
+	if errors.Is(err, errShuttingDown) {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if errors.Is(err, errTooManyRuns) {
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &ChatResponse{
+			Type:           "error",
+			ConversationID: sess.ConversationID,
+			Error:          fmt.Sprintf("Agent error: %v", err),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newChatResponse(sess.ConversationID, output))
+}
+
+// beginChat validates and authenticates a chat request and returns its
+// session, locked. On failure it writes the error response and returns false.
+func (s *Server) beginChat(w http.ResponseWriter, r *http.Request, req *ChatRequest) (*session, bool) {
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, "message is required")
+		return nil, false
+	}
+
+	userID, values, ok := s.authenticateREST(w, r, req.UserID)
+	if !ok {
+		return nil, false
+	}
+
+	sess, err := s.restSession(r.Context(), userID, req.ConversationID)
+	if errors.Is(err, errConversationNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	sess.mu.Lock()
+	sess.Values = values
+	if len(req.History) > 0 {
+		sess.History = append([]core.Message{}, req.History...)
+	}
+	return sess, true
+}
+
+// runChatTurn runs one agent turn for a REST session and records the output.
+// configure, if non-nil, can set callbacks on the engine input.
+func (s *Server) runChatTurn(ctx context.Context, sess *session, message string, configure func(*engine.Input)) (*engine.Output, error) {
+	if !s.beginRun() {
+		return nil, errShuttingDown
+	}
+	defer s.runs.Done()
+	if err := s.userRuns.acquire(ctx, sess.UserID); err != nil {
+		return nil, err
+	}
+	defer s.userRuns.release(sess.UserID)
+
+	log.Printf("[CONVERSATION %s] USER (REST): %s", sess.ConversationID, truncate(message, 50))
+
+	messageID := uuid.New().String()
+	sess.History = append(sess.History, core.NewUserMessage(message))
+	sess.TurnCount++
+	s.persistMessageWithID(ctx, sess.ConversationID, "user", message, messageID, 0, 0)
+
+	agentCtx := core.NewContext(sess.UserID, sess.ID, sess.ConversationID, sess.ID)
+	agentCtx.MessageID = messageID
+	agentCtx.Values = sess.Values
+	agentCtx.Preferences = preferences(sess.Values)
+
+	input := &engine.Input{
+		UserMessage:   message,
+		Context:       agentCtx,
+		History:       sess.History[:len(sess.History)-1],
+		SystemPrompt:  s.config.SystemPrompt,
+		Model:         s.config.Model,
+		MaxTokens:     s.config.MaxTokens,
+		IncludeTraces: s.config.IncludeTraces,
+	}
+	if configure != nil {
+		configure(input)
+	}
+
+	output, err := s.engine.Run(ctx, input)
+	if err != nil {
+		log.Printf("Agent error: %v", err)
+		return nil, err
+	}
+
+	s.recordOutput(ctx, sess, output)
+
+	if sess.TurnCount == 1 && output.Type == engine.OutputComplete {
+		go s.generateTitle(sess.ConversationID, message, nil)
+	}
+	return output, nil
+}
+
+func (s *Server) handleRESTConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req ConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ActionID == "" || req.ConversationID == "" {
+		writeError(w, http.StatusBadRequest, "actionId and conversationId are required")
+		return
+	}
+
+	userID, values, ok := s.authenticateREST(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	sess, err := s.restSession(ctx, userID, req.ConversationID)
+	if errors.Is(err, errConversationNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.Values = values
+	if len(req.History) > 0 {
+		sess.History = append([]core.Message{}, req.History...)
+	}
+
+	log.Printf("Processing REST confirmation for action=%s, user=%s", req.ActionID, userID)
+
+	output, err := s.confirmAction(ctx, sess, userID, req.ActionID)
+	if errors.Is(err, errActionExpired) {
+		writeError(w, http.StatusGone, "That action expired. Send a new message to set it up again.")
+		return
+	}
+	if errors.Is(err, errShuttingDown) {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if errors.Is(err, errTooManyRuns) {
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &ChatResponse{
+			Type:           "error",
+			ConversationID: sess.ConversationID,
+			Error:          fmt.Sprintf("action failed: %v", err),
+		})
+		return
+	}
+
+	s.recordOutput(ctx, sess, output)
+	writeJSON(w, http.StatusOK, newChatResponse(sess.ConversationID, output))
+}
+
+// authenticateREST authenticates a REST request, writing a 401 on failure.
+// bodyUserID is only honored when no authentication is configured.
+func (s *Server) authenticateREST(w http.ResponseWriter, r *http.Request, bodyUserID string) (string, map[string]string, bool) {
+	userID, values, err := s.authenticate(r)
+	if err != nil {
+		log.Printf("Authentication failed: %v", err)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return "", nil, false
+	}
+	if bodyUserID != "" && s.config.AuthFunc == nil && s.config.LiminalExecutor == nil {
+		userID = bodyUserID
+	}
+	return userID, values, true
+}
+
+// restSession returns the server-side session for a REST conversation,
+// creating the conversation if conversationID is empty. Sessions keep the full
+// history (including tool_use blocks) between /chat and /confirm calls;
+// a conversation not yet seen by this server, or evicted after
+// Config.SessionTTL, is loaded from the store.
+func (s *Server) restSession(ctx context.Context, userID, conversationID string) (*session, error) {
+	if conversationID == "" {
+		conv, err := s.conversations.Create(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create conversation: %w", err)
+		}
+		sess := &session{
+			ID:             conv.ID,
+			UserID:         userID,
+			ConversationID: conv.ID,
+			History:        []core.Message{},
+		}
+		s.restSessions.Store(conv.ID, sess)
+		log.Printf("Started REST conversation %s for user %s", conv.ID, userID)
+		return sess, nil
+	}
+
+	if sess, ok := s.restSessions.Load(conversationID); ok {
+		if sess.UserID != userID {
+			return nil, errConversationNotFound
+		}
+		return sess, nil
+	}
+
+	conv, err := s.conversations.Get(ctx, conversationID)
+	if err != nil || conv.UserID != userID {
+		return nil, errConversationNotFound
+	}
+
+	// Convert stored messages to core.Message
+	history := make([]core.Message, 0, len(conv.Messages))
+	for _, m := range conv.Messages {
+		history = append(history, core.Message{
+			Role:    core.Role(m.Role),
+			Content: m.Content,
+		})
+	}
+
+	return s.restSessions.LoadOrStore(conversationID, &session{
+		ID:             conversationID,
+		UserID:         userID,
+		ConversationID: conversationID,
+		History:        history,
+	}), nil
+}
+
+// newChatResponse converts an engine output into a REST response.
+func newChatResponse(conversationID string, output *engine.Output) *ChatResponse {
+	resp := &ChatResponse{
+		ConversationID: conversationID,
+		Text:           output.Text,
+		ToolsUsed:      output.ToolsUsed,
+		Plan:           output.Plan,
+		Traces:         output.Traces,
+		TokenUsage: &TokenUsage{
+			InputTokens:  output.TokensUsed.InputTokens,
+			OutputTokens: output.TokensUsed.OutputTokens,
+			TotalTokens:  output.TokensUsed.TotalTokens(),
+		},
+	}
+
+	switch output.Type {
+	case engine.OutputComplete:
+		resp.Type = "complete"
+	case engine.OutputConfirmationNeeded:
+		pending := output.PendingAction
+		resp.Type = "confirm_request"
+		resp.PendingAction = &Confirmation{
+			ID:        pending.ID,
+			Tool:      pending.Tool,
+			Summary:   pending.Summary,
+			ExpiresAt: pending.ExpiresAt,
+		}
+	case engine.OutputBatchConfirmationNeeded:
+		resp.Type = "confirm_request"
+		resp.PendingAction = newBatchConfirmation(output.PendingBatch)
+	case engine.OutputError:
+		resp.Type = "error"
+		if output.Error != nil {
+			resp.Error = output.Error.Error()
+		}
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &ChatResponse{Type: "error", Error: message})
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// newFakeAnthropic serves canned Messages API responses in order.
+func newFakeAnthropic(t *testing.T, responses ...map[string]interface{}) string {
+	t.Helper()
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(responses) == 0 {
+			t.Errorf("unexpected Messages API call")
+			http.Error(w, `{"type":"error","error":{"type":"api_error","message":"no response queued"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(responses[0])
+		responses = responses[1:]
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func fakeMessage(stopReason string, content ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "msg_test",
+		"type":        "message",
+		"role":        "assistant",
+		"model":       "claude-test",
+		"stop_reason": stopReason,
+		"content":     content,
+		"usage":       map[string]interface{}{"input_tokens": 10, "output_tokens": 5},
+	}
+}
+
+func newTestServer(t *testing.T, cfg Config, responses ...map[string]interface{}) *Server {
+	t.Helper()
+	cfg.AnthropicKey = "test-key"
+	cfg.BaseURL = newFakeAnthropic(t, responses...)
+	cfg.Model = "claude-test"
+	cfg.DisableStreaming = true
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return srv
+}
+
+func postJSON(t *testing.T, h http.Handler, body interface{}) (*httptest.ResponseRecorder, *ChatResponse) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(b)))
+
+	var resp ChatResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v (body: %s)", err, rec.Body.String())
+	}
+	return rec, &resp
+}
+
+func TestRESTChatAndConfirm(t *testing.T) {
+	srv := newTestServer(t, Config{},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "send_money",
+			"input": map[string]interface{}{"amount": "10", "thought": "User asked to send $10 to Bob"},
+		}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Sent $10."}),
+	)
+
+	var executedBy string
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		ToolDescription:          "Send money",
+		RequiresUserConfirmation: true,
+		SummaryTemplate:          "Send ${{.amount}}",
+		InputSchema:              map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		executedBy = params.UserID
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"status": "sent"}}, nil
+	}))
+
+	rec, chat := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "send $10 to bob"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /chat status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if chat.Type != "confirm_request" || chat.PendingAction == nil {
+		t.Fatalf("POST /chat type = %q, pendingAction = %v; want confirm_request with action", chat.Type, chat.PendingAction)
+	}
+	if chat.ConversationID == "" {
+		t.Fatal("POST /chat returned no conversationId")
+	}
+	if chat.PendingAction.Summary != "Send $10" {
+		t.Errorf("pendingAction.summary = %q, want %q", chat.PendingAction.Summary, "Send $10")
+	}
+
+	// Another user can't confirm alice's action.
+	rec, _ = postJSON(t, srv.ConfirmHandler(), ConfirmRequest{UserID: "mallory", ActionID: chat.PendingAction.ID, ConversationID: chat.ConversationID})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST /confirm by other user status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec, done := postJSON(t, srv.ConfirmHandler(), ConfirmRequest{UserID: "alice", ActionID: chat.PendingAction.ID, ConversationID: chat.ConversationID})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /confirm status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if done.Type != "complete" || done.Text != "Sent $10." {
+		t.Errorf("POST /confirm = (%q, %q), want (complete, %q)", done.Type, done.Text, "Sent $10.")
+	}
+	if executedBy != "alice" {
+		t.Errorf("tool executed for user %q, want alice", executedBy)
+	}
+
+	// The action can only be confirmed once.
+	rec, _ = postJSON(t, srv.ConfirmHandler(), ConfirmRequest{UserID: "alice", ActionID: chat.PendingAction.ID, ConversationID: chat.ConversationID})
+	if rec.Code != http.StatusGone {
+		t.Errorf("second POST /confirm status = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestRESTConfirmBatch(t *testing.T) {
+	srv := newTestServer(t, Config{BatchConfirmations: true},
+		fakeMessage("tool_use",
+			map[string]interface{}{
+				"type": "tool_use", "id": "toolu_1", "name": "send_money",
+				"input": map[string]interface{}{"amount": "10", "thought": "First of two payments"},
+			},
+			map[string]interface{}{
+				"type": "tool_use", "id": "toolu_2", "name": "send_money",
+				"input": map[string]interface{}{"amount": "20", "thought": "Second of two payments"},
+			},
+		),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Sent both."}),
+	)
+
+	var sent []string
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		ToolDescription:          "Send money",
+		RequiresUserConfirmation: true,
+		SummaryTemplate:          "Send ${{.amount}}",
+		InputSchema:              map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		var input struct {
+			Amount string `json:"amount"`
+		}
+		json.Unmarshal(params.Input, &input)
+		sent = append(sent, input.Amount)
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"status": "sent"}}, nil
+	}))
+
+	rec, chat := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "pay both invoices"})
+	if rec.Code != http.StatusOK || chat.Type != "confirm_request" || chat.PendingAction == nil {
+		t.Fatalf("POST /chat = (%d, %q), want confirm_request with action", rec.Code, chat.Type)
+	}
+	if got := len(chat.PendingAction.Actions); got != 2 {
+		t.Fatalf("pendingAction has %d actions, want 2", got)
+	}
+	if want := "1. Send $10\n2. Send $20"; chat.PendingAction.Summary != want {
+		t.Errorf("pendingAction.summary = %q, want %q", chat.PendingAction.Summary, want)
+	}
+
+	rec, done := postJSON(t, srv.ConfirmHandler(), ConfirmRequest{UserID: "alice", ActionID: chat.PendingAction.ID, ConversationID: chat.ConversationID})
+	if rec.Code != http.StatusOK || done.Type != "complete" {
+		t.Fatalf("POST /confirm = (%d, %q), body = %s", rec.Code, done.Type, rec.Body.String())
+	}
+	if len(sent) != 2 || sent[0] != "10" || sent[1] != "20" {
+		t.Errorf("sent %v, want [10 20]", sent)
+	}
+
+	// The batch can only be confirmed once.
+	rec, _ = postJSON(t, srv.ConfirmHandler(), ConfirmRequest{UserID: "alice", ActionID: chat.PendingAction.ID, ConversationID: chat.ConversationID})
+	if rec.Code != http.StatusGone {
+		t.Errorf("second POST /confirm status = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestRESTConfirmBatchWithExpiredAction(t *testing.T) {
+	srv := newTestServer(t, Config{BatchConfirmations: true},
+		fakeMessage("tool_use",
+			map[string]interface{}{
+				"type": "tool_use", "id": "toolu_1", "name": "send_money",
+				"input": map[string]interface{}{"amount": "10", "thought": "First of two payments"},
+			},
+			map[string]interface{}{
+				"type": "tool_use", "id": "toolu_2", "name": "send_money",
+				"input": map[string]interface{}{"amount": "20", "thought": "Second of two payments"},
+			},
+		),
+	)
+
+	var sent []string
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		ToolDescription:          "Send money",
+		RequiresUserConfirmation: true,
+		SummaryTemplate:          "Send ${{.amount}}",
+		InputSchema:              map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		sent = append(sent, string(params.Input))
+		return &core.ToolResult{Success: true}, nil
+	}))
+
+	_, chat := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "pay both invoices"})
+	if chat.PendingAction == nil || len(chat.PendingAction.Actions) != 2 {
+		t.Fatalf("POST /chat = %+v, want a batch of 2 actions", chat)
+	}
+	first, second := chat.PendingAction.Actions[0], chat.PendingAction.Actions[1]
+	srv.confirmations.Cancel(context.Background(), "alice", second.ID) // expired
+
+	rec, _ := postJSON(t, srv.ConfirmHandler(), ConfirmRequest{UserID: "alice", ActionID: chat.PendingAction.ID, ConversationID: chat.ConversationID})
+	if rec.Code != http.StatusGone {
+		t.Errorf("POST /confirm status = %d, want %d", rec.Code, http.StatusGone)
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent %v, want nothing", sent)
+	}
+	if _, err := srv.confirmations.Get(context.Background(), "alice", first.ID); err == nil {
+		t.Error("the batch's other action is still pending")
+	}
+
+	// Both tool_use blocks are answered, so the conversation can go on.
+	sess, _ := srv.restSessions.Load(chat.ConversationID)
+	last := sess.History[len(sess.History)-1]
+	var answered []string
+	for _, block := range last.ContentBlocks {
+		if block.ToolResult != nil && block.ToolResult.IsError {
+			answered = append(answered, block.ToolResult.ToolUseID)
+		}
+	}
+	if len(answered) != 2 || answered[0] != "toolu_1" || answered[1] != "toolu_2" {
+		t.Errorf("tool_results answer %v, want [toolu_1 toolu_2]", answered)
+	}
+}
+
+func TestRESTChatUnauthorized(t *testing.T) {
+	srv := newTestServer(t, Config{
+		AuthFunc: func(r *http.Request) (string, map[string]string, error) {
+			return "", nil, errors.New("bad token")
+		},
+	})
+
+	rec, resp := postJSON(t, srv.ChatHandler(), ChatRequest{Message: "hi"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if resp.Type != "error" {
+		t.Errorf("type = %q, want error", resp.Type)
+	}
+}
+
+func TestRESTChatMethodNotAllowed(t *testing.T) {
+	srv := newTestServer(t, Config{})
+
+	rec := httptest.NewRecorder()
+	srv.ChatHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chat", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRESTChatStream(t *testing.T) {
+	srv := newTestServer(t, Config{},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": map[string]interface{}{},
+		}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Your balance is $100."}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Balance check"}), // title
+	)
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:        "get_balance",
+		ToolDescription: "Get balance",
+		InputSchema:     map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+
+	ts := httptest.NewServer(srv.ChatStreamHandler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "?userId=alice&message=balance")
+	if err != nil {
+		t.Fatalf("GET /chat/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	toolStart := "event: tool_start\ndata: {\"tool\":\"get_balance\"}\n\n"
+	if !bytes.Contains(body, []byte(toolStart)) {
+		t.Errorf("stream missing tool_start event:\n%s", body)
+	}
+	i := bytes.Index(body, []byte("event: done\ndata: "))
+	if i < 0 {
+		t.Fatalf("stream missing done event:\n%s", body)
+	}
+	var done ChatResponse
+	line := body[i+len("event: done\ndata: "):]
+	line = line[:bytes.IndexByte(line, '\n')]
+	if err := json.Unmarshal(line, &done); err != nil {
+		t.Fatalf("decode done event: %v", err)
+	}
+	if done.Type != "complete" || done.Text != "Your balance is $100." {
+		t.Errorf("done = (%q, %q), want (complete, %q)", done.Type, done.Text, "Your balance is $100.")
+	}
+}
+
+func TestRESTChatIncludeTraces(t *testing.T) {
+	srv := newTestServer(t, Config{IncludeTraces: true},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "get_balance",
+			"input": map[string]interface{}{"thought": "Need the balance"},
+		}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Your balance is $100."}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Balance check"}), // title
+	)
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:        "get_balance",
+		ToolDescription: "Get balance",
+		InputSchema:     map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+
+	rec, chat := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "balance"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /chat status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if len(chat.Traces) != 1 || chat.Traces[0].Action != "get_balance" || chat.Traces[0].Thought != "Need the balance" {
+		t.Errorf("traces = %+v, want the get_balance step", chat.Traces)
+	}
+}
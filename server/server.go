@@ -3,10 +3,14 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -45,10 +49,14 @@ type Config struct {
 	// and forward them to the executor for authenticated API calls.
 	LiminalExecutor *executor.HTTPExecutor
 
-	// AuthFunc validates requests and returns a user ID.
+	// AuthFunc validates each incoming connection and returns the user ID plus
+	// optional request-scoped values (e.g., tenant ID, scopes). The user ID
+	// becomes core.Context.UserID; the values are set on core.Context.Values
+	// and can be read by tools with core.ContextValue.
+	// Returning an error rejects the connection with 401 Unauthorized.
 	// If nil, a default handler is used that extracts JWT tokens for Liminal authentication.
 	// Most users should leave this nil.
-	AuthFunc func(r *http.Request) (userID string, err error)
+	AuthFunc func(r *http.Request) (userID string, ctxValues map[string]string, err error)
 
 	// Conversations persists conversations.
 	// If nil, an in-memory store is used.
@@ -62,14 +70,82 @@ type Config struct {
 	// If nil, no guardrails are applied.
 	Guardrails engine.Guardrails
 
+	// MaxConcurrentRunsPerUser caps the agent runs (messages and
+	// confirmations) each user, as identified by AuthFunc, has in flight
+	// across all their WebSockets and REST requests. A run over the limit
+	// waits up to ConcurrencyWait for another to finish, then is rejected
+	// with an error (429 Too Many Requests over REST). Zero means no limit.
+	MaxConcurrentRunsPerUser int
+	ConcurrencyWait          time.Duration
+
+	// SessionTTL is how long a conversation's in-memory session (its full
+	// history and any pending batch) is kept after its last request. An
+	// evicted conversation is reloaded from Conversations on its next
+	// request. Default: 30 minutes.
+	SessionTTL time.Duration
+
+	// SpendingLimits caps how much money write tools may move, e.g.
+	// guardrails.NewSpendingLimits. If nil, no spending limits are applied.
+	SpendingLimits engine.SpendingLimiter
+
+	// ContractAllowlist restricts execute_contract_call to these contracts;
+	// calls to anything else are rejected before confirmation. If nil, any
+	// contract may be called. Strongly recommended whenever the Liminal
+	// tools are registered (see engine.WithContractAllowlist).
+	ContractAllowlist []engine.ContractTarget
+
+	// TransactionPoller, if set, waits up to TransactionPollTimeout
+	// (default 30s) after each confirmed write for its transaction to
+	// settle, so Claude reports whether it landed, e.g. tools.TxPoller.
+	TransactionPoller      engine.TransactionPoller
+	TransactionPollTimeout time.Duration
+
+	// HistoryCompactionThreshold, if positive, summarizes the oldest turns
+	// of conversations whose history exceeds this many estimated tokens
+	// (see engine.WithHistoryCompaction). Summarizer defaults to
+	// engine.NewClaudeSummarizer.
+	HistoryCompactionThreshold int
+	Summarizer                 engine.Summarizer
+
+	// Localizer translates confirmation summaries and the engine's built-in
+	// messages; Localizers overrides it per locale, e.g. "pt-BR" or "pt",
+	// chosen by the LocaleValue value from AuthFunc (see
+	// engine.WithLocalizer). Untranslated messages are in English.
+	Localizer  core.Localizer
+	Localizers map[string]core.Localizer
+
+	// ThoughtValidator, if set, checks the thought of every call that
+	// requires one beyond being non-empty, e.g.
+	// engine.MinThoughtLength(engine.DefaultMinThoughtLength).
+	ThoughtValidator engine.ThoughtValidator
+
+	// Logger receives the engine's structured logs (ReAct traces with
+	// user_id, tool, trace_id and duration_ms attributes, memory and
+	// confirmation events). If nil, slog.Default() is used.
+	Logger *slog.Logger
+
 	// AuditLogger logs agent actions for compliance.
 	// If nil, no audit logging is performed.
 	AuditLogger engine.AuditLogger
 
+	// AuditRedactor masks sensitive tool input before it's audit logged,
+	// e.g. engine.RedactFields("note"). If nil, input is logged as-is.
+	AuditRedactor engine.Redactor
+
+	// Idempotency records executed confirmations so a repeated confirm
+	// returns the prior result instead of executing the action twice.
+	// If nil, no deduplication is performed beyond the confirmation store.
+	Idempotency engine.IdempotencyStore
+
 	// Memory provides memory system for trace retrieval and storage.
 	// If nil, no memory system is used.
 	Memory memory.Manager
 
+	// MemoryTimeout limits memory retrieval per message; a slower retrieval
+	// is abandoned and the agent answers without memories. Zero means no
+	// limit.
+	MemoryTimeout time.Duration
+
 	// AnthropicOptions are additional options for the Anthropic client.
 	// This can be used to customize the HTTP client for testing.
 	AnthropicOptions []option.RequestOption
@@ -78,26 +154,82 @@ type Config struct {
 	// When true, uses the non-streaming Messages.New() API instead of NewStreaming().
 	// Useful for testing with mock servers that don't support SSE.
 	DisableStreaming bool
+
+	// PlanPreview asks the agent for a structured plan before it runs any tools.
+	// The plan is sent to the client as a "plan" message; no approval is needed.
+	PlanPreview bool
+
+	// BatchConfirmations asks the user to confirm all of a turn's writes
+	// together, e.g. both legs of a rebalance, instead of one at a time.
+	// The batch is sent as one confirm_request listing its actions.
+	BatchConfirmations bool
+
+	// IncludeTraces sends the agent's reasoning steps (engine.TraceStep)
+	// with each reply: a "traces" WebSocket message before the reply, and
+	// "traces" in REST and SSE responses. Use TraceRedactor to mask
+	// sensitive details first.
+	IncludeTraces bool
+	TraceRedactor engine.TraceRedactor
+
+	// CapabilitiesTool adds a list_capabilities tool that returns the
+	// available tools' names and descriptions, so the agent can check what
+	// it can do instead of guessing tool names.
+	CapabilitiesTool bool
+
+	// MetricsEnabled exports Prometheus metrics at /metrics: agent runs,
+	// per-tool durations and errors, confirmations, Claude token usage,
+	// and memory retrievals and records.
+	MetricsEnabled bool
+
+	// HealthChecks are extra dependency checks run by /ready, alongside the
+	// built-in Anthropic, Liminal executor and memory store checks.
+	HealthChecks []HealthCheck
 }
 
-// Server is a WebSocket server for the Nim agent.
+// Server serves the Nim agent over WebSocket and REST.
 type Server struct {
 	config   Config
+	client   *anthropic.Client
 	engine   *engine.Engine
 	registry *engine.ToolRegistry
 	upgrader websocket.Upgrader
+	health   healthCache
 
 	conversations store.Conversations
 	confirmations store.Confirmations
-	sessions      sync.Map // *websocket.Conn -> *session
+	metrics       *metrics     // Nil unless Config.MetricsEnabled
+	userRuns      *userLimiter // Nil unless Config.MaxConcurrentRunsPerUser
+	sessions      sync.Map     // *websocket.Conn -> *session
+	wsSessions    *sessionMap  // conversationID -> *session (WebSocket, kept for resume)
+	restSessions  *sessionMap  // conversationID -> *session (REST endpoints)
+	conns         sync.Map     // *websocket.Conn -> *sync.Mutex, open WebSockets and their write locks
+
+	shutdownMu   sync.Mutex
+	shuttingDown bool
+	httpServer   *http.Server   // Set by Run
+	runs         sync.WaitGroup // In-flight agent runs
+	closers      []io.Closer
 }
 
 type session struct {
 	ID             string
 	UserID         string
+	Values         map[string]string // Request-scoped values from AuthFunc
 	ConversationID string
 	History        []core.Message
 	TurnCount      int
+
+	// PendingActionID is the action awaiting confirmation, if any, so a
+	// reconnecting client can be shown it again.
+	PendingActionID string
+
+	// PendingBatch is the batch awaiting confirmation, if any. Unlike single
+	// actions, batches are only restored while the session is in memory.
+	PendingBatch *core.PendingActionBatch
+
+	mu       sync.Mutex      // Serializes runs and confirmations on the same conversation
+	conn     *websocket.Conn // WebSocket connection the session is attached to; guarded by mu
+	lastUsed atomic.Int64    // Unix nanoseconds of the last request, for eviction
 }
 
 // New creates a new server with the given configuration.
@@ -128,12 +260,70 @@ func New(cfg Config) (*Server, error) {
 	if cfg.Guardrails != nil {
 		engineOpts = append(engineOpts, engine.WithGuardrails(cfg.Guardrails))
 	}
+	if cfg.SpendingLimits != nil {
+		engineOpts = append(engineOpts, engine.WithSpendingLimits(cfg.SpendingLimits))
+	}
+	if cfg.ContractAllowlist != nil {
+		engineOpts = append(engineOpts, engine.WithContractAllowlist(cfg.ContractAllowlist...))
+	}
+	if cfg.TransactionPoller != nil {
+		timeout := cfg.TransactionPollTimeout
+		if timeout == 0 {
+			timeout = 30 * time.Second
+		}
+		engineOpts = append(engineOpts, engine.WithTransactionPolling(cfg.TransactionPoller, timeout))
+	}
+	if cfg.HistoryCompactionThreshold > 0 {
+		summarizer := cfg.Summarizer
+		if summarizer == nil {
+			summarizer = engine.NewClaudeSummarizer(&client)
+		}
+		engineOpts = append(engineOpts, engine.WithHistoryCompaction(cfg.HistoryCompactionThreshold, summarizer))
+	}
+	if cfg.Localizer != nil {
+		engineOpts = append(engineOpts, engine.WithLocalizer(cfg.Localizer))
+	}
+	for locale, l := range cfg.Localizers {
+		engineOpts = append(engineOpts, engine.WithLocalizerFor(locale, l))
+	}
+	if cfg.ThoughtValidator != nil {
+		engineOpts = append(engineOpts, engine.WithThoughtValidator(cfg.ThoughtValidator))
+	}
+	if cfg.Logger != nil {
+		engineOpts = append(engineOpts, engine.WithLogger(cfg.Logger))
+	}
 	if cfg.AuditLogger != nil {
 		engineOpts = append(engineOpts, engine.WithAudit(cfg.AuditLogger))
 	}
+	if cfg.TraceRedactor != nil {
+		engineOpts = append(engineOpts, engine.WithTraceRedactor(cfg.TraceRedactor))
+	}
+	if cfg.AuditRedactor != nil {
+		engineOpts = append(engineOpts, engine.WithAuditRedactor(cfg.AuditRedactor))
+	}
+	if cfg.Idempotency != nil {
+		engineOpts = append(engineOpts, engine.WithIdempotency(cfg.Idempotency, 0))
+	}
 	if cfg.Memory != nil {
 		engineOpts = append(engineOpts, engine.WithMemory(cfg.Memory))
 	}
+	if cfg.MemoryTimeout > 0 {
+		engineOpts = append(engineOpts, engine.WithMemoryTimeout(cfg.MemoryTimeout))
+	}
+	if cfg.PlanPreview {
+		engineOpts = append(engineOpts, engine.WithPlanPreview())
+	}
+	if cfg.BatchConfirmations {
+		engineOpts = append(engineOpts, engine.WithBatchConfirmations())
+	}
+	if cfg.CapabilitiesTool {
+		engineOpts = append(engineOpts, engine.WithCapabilitiesTool())
+	}
+	var m *metrics
+	if cfg.MetricsEnabled {
+		m = newMetrics()
+		engineOpts = append(engineOpts, engine.WithEventSink(m))
+	}
 
 	// Create engine
 	eng := engine.NewEngine(&client, registry, engineOpts...)
@@ -151,10 +341,15 @@ func New(cfg Config) (*Server, error) {
 
 	return &Server{
 		config:        cfg,
+		client:        &client,
 		engine:        eng,
 		registry:      registry,
 		conversations: conversations,
 		confirmations: confirmations,
+		metrics:       m,
+		userRuns:      newUserLimiter(cfg.MaxConcurrentRunsPerUser, cfg.ConcurrencyWait),
+		wsSessions:    newSessionMap(cfg.SessionTTL),
+		restSessions:  newSessionMap(cfg.SessionTTL),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins in development
@@ -163,14 +358,29 @@ func New(cfg Config) (*Server, error) {
 	}, nil
 }
 
-// AddTool registers a custom tool with the server.
-func (s *Server) AddTool(tool core.Tool) {
-	s.registry.Register(tool)
+// AddTool registers a custom tool with the server. If a tool with the same
+// name is already registered, it is kept and an error is returned.
+func (s *Server) AddTool(tool core.Tool) error {
+	err := s.registry.Register(tool)
+	if err != nil {
+		log.Printf("AddTool: %v (use OverrideTool to replace it)", err)
+	}
+	return err
+}
+
+// AddTools registers multiple tools with the server. Tools whose names are
+// already registered are skipped and an error is returned.
+func (s *Server) AddTools(tools ...core.Tool) error {
+	err := s.registry.RegisterAll(tools...)
+	if err != nil {
+		log.Printf("AddTools: %v (use OverrideTool to replace it)", err)
+	}
+	return err
 }
 
-// AddTools registers multiple tools with the server.
-func (s *Server) AddTools(tools ...core.Tool) {
-	s.registry.RegisterAll(tools...)
+// OverrideTool registers a tool, replacing any tool with the same name.
+func (s *Server) OverrideTool(tool core.Tool) {
+	s.registry.Override(tool)
 }
 
 // ToolCount returns the number of registered tools.
@@ -183,22 +393,54 @@ func (s *Server) Handler() http.Handler {
 	return http.HandlerFunc(s.handleWebSocket)
 }
 
-// Run starts the server on the given address.
-func (s *Server) Run(addr string) error {
+// Run starts the server on the given address. It blocks until the server
+// fails or is shut down (see Shutdown and WithSignalShutdown).
+func (s *Server) Run(addr string, opts ...RunOption) error {
+	var o runOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	http.Handle("/ws", s.Handler())
+	http.Handle("/chat", s.ChatHandler())
+	http.Handle("/chat/stream", s.ChatStreamHandler())
+	http.Handle("/confirm", s.ConfirmHandler())
+	if s.metrics != nil {
+		http.Handle("/metrics", s.MetricsHandler())
+	}
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	http.Handle("/ready", s.HealthHandler())
+
+	httpServer := &http.Server{Addr: addr}
+	s.shutdownMu.Lock()
+	s.httpServer = httpServer
+	s.shutdownMu.Unlock()
+
+	var shutdownDone <-chan error
+	if o.handleSignals {
+		var stop func()
+		shutdownDone, stop = s.shutdownOnSignal(o.signalTimeout)
+		defer stop()
+	}
 
 	log.Printf("Starting Nim agent server on %s", addr)
-	return http.ListenAndServe(addr, nil)
+	err := httpServer.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if shutdownDone != nil {
+		return <-shutdownDone
+	}
+	return nil
 }
 
 // defaultLiminalAuthFunc returns a default authentication function for Liminal.
 // It extracts JWT tokens from requests and forwards them to the HTTPExecutor.
-func (s *Server) defaultLiminalAuthFunc() func(r *http.Request) (string, error) {
-	return func(r *http.Request) (string, error) {
+func (s *Server) defaultLiminalAuthFunc() func(r *http.Request) (string, map[string]string, error) {
+	return func(r *http.Request) (string, map[string]string, error) {
 		// Extract JWT from query param (WebSocket) or Authorization header
 		jwt := r.URL.Query().Get("token")
 		if jwt == "" {
@@ -214,13 +456,12 @@ func (s *Server) defaultLiminalAuthFunc() func(r *http.Request) (string, error)
 		}
 
 		// Return placeholder user ID (gateway extracts real user from JWT)
-		return "user", nil
+		return "user", nil, nil
 	}
 }
 
-func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Authenticate
-	userID := "default-user"
+// authenticate resolves the user ID and request-scoped values for a request.
+func (s *Server) authenticate(r *http.Request) (string, map[string]string, error) {
 	authFunc := s.config.AuthFunc
 
 	// Use default Liminal JWT handler if no custom auth provided
@@ -228,13 +469,19 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		authFunc = s.defaultLiminalAuthFunc()
 	}
 
-	if authFunc != nil {
-		var err error
-		userID, err = authFunc(r)
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+	if authFunc == nil {
+		return "default-user", nil, nil
+	}
+	return authFunc(r)
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Authenticate
+	userID, values, err := s.authenticate(r)
+	if err != nil {
+		log.Printf("Authentication failed: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
 	// Upgrade connection
@@ -244,11 +491,12 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer conn.Close()
+	s.conns.Store(conn, &sync.Mutex{})
+	defer s.conns.Delete(conn)
+	defer s.sessions.Delete(conn)
 
 	log.Printf("WebSocket connected for user %s", userID)
 
-	var currentSession *session
-
 	for {
 		_, msgBytes, err := conn.ReadMessage()
 		if err != nil {
@@ -268,31 +516,37 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 		switch msg.Type {
 		case "new_conversation":
-			currentSession = s.handleNewConversation(r.Context(), conn, userID)
+			s.handleNewConversation(r.Context(), conn, userID, values)
 
 		case "resume_conversation":
-			currentSession = s.handleResumeConversation(r.Context(), conn, userID, msg.ConversationID)
+			s.handleResumeConversation(r.Context(), conn, userID, values, msg.ConversationID, msg.ActionID)
 
 		case "message":
-			if currentSession == nil {
+			sess := s.lockSession(conn)
+			if sess == nil {
 				s.sendError(conn, "No active conversation. Send 'new_conversation' first.")
 				continue
 			}
-			s.handleMessage(r.Context(), conn, currentSession, msg.Content)
+			s.handleMessage(r.Context(), conn, sess, msg.Content)
+			sess.mu.Unlock()
 
 		case "confirm":
-			if currentSession == nil {
+			sess := s.lockSession(conn)
+			if sess == nil {
 				s.sendError(conn, "No active conversation")
 				continue
 			}
-			s.handleConfirm(r.Context(), conn, currentSession, userID, msg.ActionID)
+			s.handleConfirm(r.Context(), conn, sess, userID, msg.ActionID)
+			sess.mu.Unlock()
 
 		case "cancel":
-			if currentSession == nil {
+			sess := s.lockSession(conn)
+			if sess == nil {
 				s.sendError(conn, "No active conversation")
 				continue
 			}
-			s.handleCancel(r.Context(), conn, currentSession, userID, msg.ActionID)
+			s.handleCancel(r.Context(), conn, sess, userID, msg.ActionID)
+			sess.mu.Unlock()
 
 		default:
 			s.sendError(conn, fmt.Sprintf("Unknown message type: %s", msg.Type))
@@ -300,20 +554,40 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleNewConversation(ctx context.Context, conn *websocket.Conn, userID string) *session {
+// lockSession returns the session attached to conn, locked, or nil if conn
+// has none. A session taken over by a resuming connection is no longer
+// attached to its previous one.
+func (s *Server) lockSession(conn *websocket.Conn) *session {
+	v, ok := s.sessions.Load(conn)
+	if !ok {
+		return nil
+	}
+	sess := v.(*session)
+	sess.mu.Lock()
+	if sess.conn != conn {
+		sess.mu.Unlock()
+		return nil
+	}
+	return sess
+}
+
+func (s *Server) handleNewConversation(ctx context.Context, conn *websocket.Conn, userID string, values map[string]string) {
 	conv, err := s.conversations.Create(ctx, userID)
 	if err != nil {
 		s.sendError(conn, fmt.Sprintf("Failed to create conversation: %v", err))
-		return nil
+		return
 	}
 
 	sess := &session{
 		ID:             conv.ID,
 		UserID:         userID,
+		Values:         values,
 		ConversationID: conv.ID,
 		History:        []core.Message{},
+		conn:           conn,
 	}
 	s.sessions.Store(conn, sess)
+	s.wsSessions.Store(conv.ID, sess)
 
 	s.send(conn, ServerMessage{
 		Type:           "conversation_started",
@@ -321,31 +595,55 @@ func (s *Server) handleNewConversation(ctx context.Context, conn *websocket.Conn
 	})
 
 	log.Printf("Started conversation %s for user %s", conv.ID, userID)
-	return sess
 }
 
-func (s *Server) handleResumeConversation(ctx context.Context, conn *websocket.Conn, userID, conversationID string) *session {
+// handleResumeConversation reattaches a conversation to a (re)connected
+// client. If the conversation's session is still in memory, e.g. after a
+// dropped connection, its full history and pending action carry over;
+// otherwise the history is loaded from the conversation store. actionID
+// optionally names a pending action the client was shown before
+// disconnecting, for when the server no longer has the session.
+//
+// A cached session is taken over from the connection it was attached to,
+// once any run there finishes; that connection can then start or resume
+// another conversation.
+func (s *Server) handleResumeConversation(ctx context.Context, conn *websocket.Conn, userID string, values map[string]string, conversationID, actionID string) {
 	conv, err := s.conversations.Get(ctx, conversationID)
-	if err != nil {
+	if err != nil || conv.UserID != userID {
 		s.sendError(conn, "Conversation not found")
-		return nil
+		return
 	}
 
-	// Convert stored messages to core.Message
-	history := make([]core.Message, 0, len(conv.Messages))
-	for _, m := range conv.Messages {
-		history = append(history, core.Message{
-			Role:    core.Role(m.Role),
-			Content: m.Content,
-		})
-	}
+	var sess *session
+	if cached, ok := s.wsSessions.Load(conversationID); ok && cached.UserID == userID {
+		sess = cached
+		sess.mu.Lock()
+		if sess.conn != nil && sess.conn != conn {
+			s.sessions.CompareAndDelete(sess.conn, sess)
+		}
+	} else {
+		// Convert stored messages to core.Message
+		history := make([]core.Message, 0, len(conv.Messages))
+		for _, m := range conv.Messages {
+			history = append(history, core.Message{
+				Role:    core.Role(m.Role),
+				Content: m.Content,
+			})
+		}
 
-	sess := &session{
-		ID:             conversationID,
-		UserID:         userID,
-		ConversationID: conversationID,
-		History:        history,
+		sess = &session{
+			ID:             conversationID,
+			UserID:         userID,
+			Values:         values,
+			ConversationID: conversationID,
+			History:        history,
+		}
+		sess.mu.Lock()
+		s.wsSessions.Store(conversationID, sess)
 	}
+	defer sess.mu.Unlock()
+	sess.Values = values
+	sess.conn = conn
 	s.sessions.Store(conn, sess)
 
 	s.send(conn, ServerMessage{
@@ -354,14 +652,119 @@ func (s *Server) handleResumeConversation(ctx context.Context, conn *websocket.C
 		Messages:       conv.Messages,
 	})
 
+	if batch := sess.PendingBatch; batch != nil && (actionID == "" || actionID == batch.ID) {
+		s.restorePendingBatch(conn, sess, batch)
+		actionID = ""
+	} else if actionID == "" {
+		actionID = sess.PendingActionID
+	}
+	if actionID != "" {
+		s.restorePendingAction(ctx, conn, sess, actionID)
+	}
+
 	log.Printf("Resumed conversation %s for user %s", conversationID, userID)
-	return sess
+}
+
+// restorePendingAction re-sends the confirm_request for a pending action on
+// resume. If the action expired while the client was away, the client gets
+// an action_expired message instead and the unanswered tool_use is closed
+// out, so the conversation can continue.
+func (s *Server) restorePendingAction(ctx context.Context, conn *websocket.Conn, sess *session, actionID string) {
+	action, err := s.confirmations.Get(ctx, sess.UserID, actionID)
+	if err != nil {
+		log.Printf("Pending action %s expired during disconnect: %v", actionID, err)
+		sess.PendingActionID = ""
+		closeToolUses(sess, "Action expired before the user confirmed it")
+		s.send(conn, ServerMessage{
+			Type:     "action_expired",
+			ActionID: actionID,
+			Content:  "That action expired. Would you like me to set it up again?",
+		})
+		return
+	}
+
+	// A history loaded from the conversation store has no tool_use blocks;
+	// rebuild the action's so the confirmed result has something to answer.
+	if !hasToolUse(sess.History, action.BlockID) {
+		sess.History = append(sess.History, core.NewAssistantMessageWithBlocks([]core.ContentBlock{
+			core.NewToolUseBlock(action.BlockID, action.Tool, action.Input),
+		}))
+	}
+	sess.PendingActionID = action.ID
+
+	s.send(conn, ServerMessage{
+		Type:      "confirm_request",
+		ActionID:  action.ID,
+		Tool:      action.Tool,
+		Summary:   action.Summary,
+		ExpiresAt: time.Unix(action.ExpiresAt, 0).Format(time.RFC3339),
+	})
+}
+
+// restorePendingBatch re-sends the confirm_request for a pending batch on
+// resume, or closes it out like restorePendingAction if it expired.
+func (s *Server) restorePendingBatch(conn *websocket.Conn, sess *session, batch *core.PendingActionBatch) {
+	if time.Now().Unix() > batch.ExpiresAt {
+		log.Printf("Pending batch %s expired during disconnect", batch.ID)
+		sess.PendingBatch = nil
+		closeToolUses(sess, "Action expired before the user confirmed it")
+		s.send(conn, ServerMessage{
+			Type:     "action_expired",
+			ActionID: batch.ID,
+			Content:  "That action expired. Would you like me to set it up again?",
+		})
+		return
+	}
+	s.send(conn, batchConfirmRequest(batch, ""))
+}
+
+// hasToolUse reports whether history contains the tool_use block with id.
+func hasToolUse(history []core.Message, id string) bool {
+	for _, msg := range history {
+		for _, block := range msg.ContentBlocks {
+			if block.ToolUse != nil && block.ToolUse.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closeToolUses answers any tool_use blocks in the session's last message
+// with an error tool_result, as Claude requires every tool_use to have one.
+func closeToolUses(sess *session, reason string) {
+	if len(sess.History) == 0 {
+		return
+	}
+	last := sess.History[len(sess.History)-1]
+	if last.Role != core.RoleAssistant {
+		return
+	}
+	var results []core.ToolResultContent
+	for _, block := range last.ContentBlocks {
+		if block.ToolUse != nil {
+			results = append(results, core.ToolResultContent{ToolUseID: block.ToolUse.ID, Content: reason, IsError: true})
+		}
+	}
+	if len(results) > 0 {
+		sess.History = append(sess.History, core.NewToolResultMessage(results))
+	}
 }
 
 func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *session, content string) {
 	if content == "" {
 		return
 	}
+	if !s.beginRun() {
+		s.sendError(conn, "Server is shutting down")
+		return
+	}
+	defer s.runs.Done()
+	if err := s.userRuns.acquire(ctx, sess.UserID); err != nil {
+		s.sendError(conn, err.Error())
+		return
+	}
+	defer s.userRuns.release(sess.UserID)
 
 	log.Printf("[CONVERSATION %s] USER: %s", sess.ConversationID, truncate(content, 50))
 
@@ -378,6 +781,8 @@ func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *
 	// Build input
 	agentCtx := core.NewContext(sess.UserID, sess.ID, sess.ConversationID, sess.ID)
 	agentCtx.MessageID = messageID
+	agentCtx.Values = sess.Values
+	agentCtx.Preferences = preferences(sess.Values)
 
 	input := &engine.Input{
 		UserMessage:  content,
@@ -386,6 +791,10 @@ func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *
 		SystemPrompt: s.config.SystemPrompt,
 		Model:        s.config.Model,
 		MaxTokens:    s.config.MaxTokens,
+		PlanCallback: func(plan *engine.Plan) {
+			s.send(conn, ServerMessage{Type: "plan", Content: plan.Summary, Plan: plan})
+		},
+		IncludeTraces: s.config.IncludeTraces,
 	}
 
 	// Only enable streaming if not disabled (streaming requires SSE-compatible server)
@@ -395,6 +804,11 @@ func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *
 				s.send(conn, ServerMessage{Type: "text_chunk", Content: chunk})
 			}
 		}
+		input.ToolInputCallback = func(delta engine.ToolInputDelta) {
+			if !delta.Done {
+				s.send(conn, ServerMessage{Type: "tool_input_chunk", Tool: delta.Tool, BlockID: delta.BlockID, Content: delta.PartialJSON})
+			}
+		}
 	}
 
 	// Run agent
@@ -409,22 +823,34 @@ func (s *Server) handleMessage(ctx context.Context, conn *websocket.Conn, sess *
 
 	// Generate conversation title after first turn completes successfully
 	if sess.TurnCount == 1 && output.Type == engine.OutputComplete {
-		go func() {
-			title, err := s.engine.GenerateTitleFromFirstMessage(context.Background(), content)
-			if err != nil {
-				log.Printf("[TITLE] Failed to generate: %v", err)
-				return
-			}
-			if err := s.conversations.SetTitle(context.Background(), sess.ConversationID, title); err != nil {
-				log.Printf("[TITLE] Failed to save: %v", err)
-				return
-			}
+		go s.generateTitle(sess.ConversationID, content, func(title string) {
 			s.send(conn, ServerMessage{Type: "title_updated", Content: title, ConversationID: sess.ConversationID})
-		}()
+		})
 	}
 }
 
-func (s *Server) handleOutput(ctx context.Context, conn *websocket.Conn, sess *session, output *engine.Output) {
+// generateTitle generates and saves a conversation title from the first
+// message, then calls onSaved (if non-nil). Intended to run in a goroutine.
+func (s *Server) generateTitle(conversationID, firstMessage string, onSaved func(title string)) {
+	title, err := s.engine.GenerateTitleFromFirstMessage(context.Background(), firstMessage)
+	if err != nil {
+		log.Printf("[TITLE] Failed to generate: %v", err)
+		return
+	}
+	if err := s.conversations.SetTitle(context.Background(), conversationID, title); err != nil {
+		log.Printf("[TITLE] Failed to save: %v", err)
+		return
+	}
+	if onSaved != nil {
+		onSaved(title)
+	}
+}
+
+// recordOutput updates the session history and persists state for an agent
+// output: the assistant message on completion, or the pending action when a
+// confirmation is needed.
+func (s *Server) recordOutput(ctx context.Context, sess *session, output *engine.Output) {
+	sess.touch()
 	switch output.Type {
 	case engine.OutputComplete:
 		log.Printf("[CONVERSATION %s] ASSISTANT: %s", sess.ConversationID, truncate(output.Text, 200))
@@ -433,6 +859,46 @@ func (s *Server) handleOutput(ctx context.Context, conn *websocket.Conn, sess *s
 
 		s.persistMessage(ctx, sess.ConversationID, "assistant", output.Text, output.TokensUsed.InputTokens, output.TokensUsed.OutputTokens)
 
+	case engine.OutputConfirmationNeeded:
+		// Store confirmation
+		if err := s.confirmations.Store(ctx, output.PendingAction); err != nil {
+			log.Printf("Failed to store confirmation: %v", err)
+		}
+		sess.PendingActionID = output.PendingAction.ID
+
+		sess.History = append(sess.History, core.NewAssistantMessageWithBlocks(output.ResponseBlocks))
+
+	case engine.OutputBatchConfirmationNeeded:
+		// Store each action, so confirming takes them out of the store
+		for _, action := range output.PendingBatch.Actions {
+			if err := s.confirmations.Store(ctx, action); err != nil {
+				log.Printf("Failed to store confirmation: %v", err)
+			}
+		}
+		sess.PendingBatch = output.PendingBatch
+
+		sess.History = append(sess.History, core.NewAssistantMessageWithBlocks(output.ResponseBlocks))
+
+	case engine.OutputError:
+		log.Printf("Agent error: %v", output.Error)
+	}
+}
+
+func (s *Server) handleOutput(ctx context.Context, conn *websocket.Conn, sess *session, output *engine.Output) {
+	s.recordOutput(ctx, sess, output)
+
+	// Rich tool content (e.g. charts) goes to the UI before the reply
+	for _, execution := range output.ToolsUsed {
+		if len(execution.Blocks) > 0 {
+			s.send(conn, ServerMessage{Type: "tool_result", Tool: execution.Tool, Blocks: execution.Blocks})
+		}
+	}
+	if len(output.Traces) > 0 {
+		s.send(conn, ServerMessage{Type: "traces", Traces: output.Traces})
+	}
+
+	switch output.Type {
+	case engine.OutputComplete:
 		s.send(conn, ServerMessage{Type: "text", Content: output.Text})
 		s.send(conn, ServerMessage{
 			Type: "complete",
@@ -445,14 +911,6 @@ func (s *Server) handleOutput(ctx context.Context, conn *websocket.Conn, sess *s
 
 	case engine.OutputConfirmationNeeded:
 		pending := output.PendingAction
-
-		// Store confirmation
-		if err := s.confirmations.Store(ctx, pending); err != nil {
-			log.Printf("Failed to store confirmation: %v", err)
-		}
-
-		sess.History = append(sess.History, core.NewAssistantMessageWithBlocks(output.ResponseBlocks))
-
 		s.send(conn, ServerMessage{
 			Type:      "confirm_request",
 			ActionID:  pending.ID,
@@ -462,18 +920,51 @@ func (s *Server) handleOutput(ctx context.Context, conn *websocket.Conn, sess *s
 			ExpiresAt: time.Unix(pending.ExpiresAt, 0).Format(time.RFC3339),
 		})
 
+	case engine.OutputBatchConfirmationNeeded:
+		s.send(conn, batchConfirmRequest(output.PendingBatch, output.Text))
+
 	case engine.OutputError:
-		log.Printf("Agent error: %v", output.Error)
 		s.sendError(conn, output.Error.Error())
 	}
 }
 
+// batchConfirmRequest builds the confirm_request for a batch. The batch ID
+// confirms or cancels all of its actions.
+func batchConfirmRequest(batch *core.PendingActionBatch, text string) ServerMessage {
+	confirmation := newBatchConfirmation(batch)
+	return ServerMessage{
+		Type:      "confirm_request",
+		ActionID:  batch.ID,
+		Summary:   confirmation.Summary,
+		Content:   text,
+		ExpiresAt: time.Unix(batch.ExpiresAt, 0).Format(time.RFC3339),
+		Actions:   confirmation.Actions,
+	}
+}
+
+// newBatchConfirmation describes a batch and its actions for the client.
+func newBatchConfirmation(batch *core.PendingActionBatch) *Confirmation {
+	confirmation := &Confirmation{
+		ID:        batch.ID,
+		Summary:   batch.Summary(),
+		ExpiresAt: batch.ExpiresAt,
+	}
+	for _, action := range batch.Actions {
+		confirmation.Actions = append(confirmation.Actions, Confirmation{
+			ID:        action.ID,
+			Tool:      action.Tool,
+			Summary:   action.Summary,
+			ExpiresAt: action.ExpiresAt,
+		})
+	}
+	return confirmation
+}
+
 func (s *Server) handleConfirm(ctx context.Context, conn *websocket.Conn, sess *session, userID, actionID string) {
 	log.Printf("Processing confirmation for action=%s, user=%s", actionID, userID)
 
-	// Get and remove confirmation
-	action, err := s.confirmations.Confirm(ctx, userID, actionID)
-	if err != nil {
+	output, err := s.confirmAction(ctx, sess, userID, actionID)
+	if errors.Is(err, errActionExpired) {
 		s.send(conn, ServerMessage{
 			Type:    "text",
 			Content: "That action expired. Would you like me to set it up again?",
@@ -481,40 +972,64 @@ func (s *Server) handleConfirm(ctx context.Context, conn *websocket.Conn, sess *
 		s.send(conn, ServerMessage{Type: "complete"})
 		return
 	}
+	if errors.Is(err, errTooManyRuns) {
+		// The action is still pending, so the user can confirm it again
+		s.sendError(conn, err.Error())
+		return
+	}
+	if err != nil {
+		s.send(conn, ServerMessage{
+			Type:    "text",
+			Content: fmt.Sprintf("Sorry, the action failed: %v", err),
+		})
+		s.send(conn, ServerMessage{Type: "complete"})
+		return
+	}
 
-	// Resume engine loop with confirmed action
-	// CanConfirm: true enables chained confirmations (e.g., "send to each employee")
-	input := &engine.Input{
-		UserMessage:  "", // No new user message
-		History:      sess.History,
-		SystemPrompt: s.config.SystemPrompt,
-		Model:        s.config.Model,
-		MaxTokens:    s.config.MaxTokens,
-		Context: &core.Context{
-			UserID:         userID,
-			ConversationID: sess.ConversationID,
-			Limits: &core.ExecutionLimits{
-				MaxTurns:   10,
-				MaxTokens:  s.config.MaxTokens,
-				CanConfirm: true, // Allow follow-up confirmations
-			},
-		},
+	// Delegate to handleOutput which handles all output types:
+	// - OutputComplete: sends text + complete
+	// - OutputConfirmationNeeded: stores confirmation + sends confirm_request (chained)
+	// - OutputBatchConfirmationNeeded: stores the batch + sends one confirm_request
+	// - OutputError: sends error
+	s.handleOutput(ctx, conn, sess, output)
+}
+
+// errActionExpired is returned by confirmAction when the pending action is
+// unknown, expired, or belongs to another user.
+var errActionExpired = errors.New("action expired or not found")
+
+// confirmAction executes a confirmed pending action and resumes the agent loop.
+// The tool result (or error) is appended to the session history; the caller
+// records the returned output.
+func (s *Server) confirmAction(ctx context.Context, sess *session, userID, actionID string) (*engine.Output, error) {
+	if !s.beginRun() {
+		return nil, errShuttingDown
+	}
+	defer s.runs.Done()
+	if err := s.userRuns.acquire(ctx, userID); err != nil {
+		return nil, err
 	}
+	defer s.userRuns.release(userID)
+
+	if batch := sess.PendingBatch; batch != nil && batch.ID == actionID {
+		return s.confirmBatch(ctx, sess, userID, batch)
+	}
+
+	// Get and remove confirmation
+	action, err := s.confirmations.Confirm(ctx, userID, actionID)
+	if err != nil {
+		return nil, errActionExpired
+	}
+	sess.PendingActionID = ""
 
 	// Run the confirmed action through the ReAct loop
-	output, err := s.engine.RunConfirmedAction(ctx, input, action)
+	output, err := s.engine.RunConfirmedAction(ctx, s.confirmInput(sess, userID), action)
 	if err != nil {
 		// Add error tool result to history
 		sess.History = append(sess.History, core.NewToolResultMessage([]core.ToolResultContent{
 			{ToolUseID: action.BlockID, Content: err.Error(), IsError: true},
 		}))
-
-		s.send(conn, ServerMessage{
-			Type:    "text",
-			Content: fmt.Sprintf("Sorry, the action failed: %v", err),
-		})
-		s.send(conn, ServerMessage{Type: "complete"})
-		return
+		return nil, err
 	}
 
 	// Add tool_result for the confirmed action to history
@@ -538,18 +1053,125 @@ func (s *Server) handleConfirm(ctx context.Context, conn *websocket.Conn, sess *
 		isError = false
 	}
 
+	var blocks []core.ContentBlock
+	if len(output.ToolsUsed) > 0 && !isError {
+		blocks = output.ToolsUsed[0].Blocks
+	}
 	sess.History = append(sess.History, core.NewToolResultMessage([]core.ToolResultContent{
-		{ToolUseID: action.BlockID, Content: toolResultContent, IsError: isError},
+		{ToolUseID: action.BlockID, Content: toolResultContent, IsError: isError, Blocks: blocks},
 	}))
 
-	// Delegate to handleOutput which handles all output types:
-	// - OutputComplete: sends text + complete
-	// - OutputConfirmationNeeded: stores confirmation + sends confirm_request (chained)
-	// - OutputError: sends error
-	s.handleOutput(ctx, conn, sess, output)
+	return output, nil
+}
+
+// confirmBatch executes a confirmed batch and resumes the agent loop, like
+// confirmAction. Every action's tool_result is appended to the session
+// history, including those skipped after a failure.
+func (s *Server) confirmBatch(ctx context.Context, sess *session, userID string, batch *core.PendingActionBatch) (*engine.Output, error) {
+	sess.PendingBatch = nil
+
+	// Check every action is still pending before consuming any, so an
+	// expired action can't leave the batch half confirmed
+	for _, action := range batch.Actions {
+		if _, err := s.confirmations.Get(ctx, userID, action.ID); err != nil {
+			s.abandonBatch(ctx, sess, userID, batch)
+			return nil, errActionExpired
+		}
+	}
+	for _, action := range batch.Actions {
+		if _, err := s.confirmations.Confirm(ctx, userID, action.ID); err != nil {
+			s.abandonBatch(ctx, sess, userID, batch)
+			return nil, errActionExpired
+		}
+	}
+
+	output, err := s.engine.RunConfirmedBatch(ctx, s.confirmInput(sess, userID), batch)
+
+	results := make([]core.ToolResultContent, len(batch.Actions))
+	for i, action := range batch.Actions {
+		results[i] = core.ToolResultContent{ToolUseID: action.BlockID, Content: "Success"}
+		if output == nil {
+			// The batch failed before any action executed
+			results[i].Content = err.Error()
+			results[i].IsError = true
+			continue
+		}
+		result := output.BatchResults[i]
+		if result.Status != engine.BatchActionSucceeded {
+			results[i].Content = result.Error
+			results[i].IsError = true
+		} else if result.Result != nil {
+			resultBytes, err := json.Marshal(result.Result)
+			if err != nil {
+				log.Printf("[CONFIRMATION] Failed to marshal tool result: %v", err)
+			} else {
+				results[i].Content = string(resultBytes)
+			}
+		}
+		results[i].Blocks = result.Blocks
+	}
+	sess.History = append(sess.History, core.NewToolResultMessage(results))
+
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// abandonBatch cancels a batch that can no longer be confirmed, answering
+// each of its tool_use blocks with an error so the conversation can go on.
+func (s *Server) abandonBatch(ctx context.Context, sess *session, userID string, batch *core.PendingActionBatch) {
+	for _, action := range batch.Actions {
+		s.confirmations.Cancel(ctx, userID, action.ID)
+	}
+	closeToolUses(sess, "Action expired before the user confirmed it")
+}
+
+// confirmInput builds the engine input for resuming a conversation after
+// the user confirms. CanConfirm: true enables chained confirmations (e.g.,
+// "send to each employee").
+func (s *Server) confirmInput(sess *session, userID string) *engine.Input {
+	return &engine.Input{
+		UserMessage:   "", // No new user message
+		History:       sess.History,
+		SystemPrompt:  s.config.SystemPrompt,
+		Model:         s.config.Model,
+		MaxTokens:     s.config.MaxTokens,
+		IncludeTraces: s.config.IncludeTraces,
+		Context: &core.Context{
+			UserID:         userID,
+			ConversationID: sess.ConversationID,
+			Values:         sess.Values,
+			Preferences:    preferences(sess.Values),
+			Limits: &core.ExecutionLimits{
+				MaxTurns:   10,
+				MaxTokens:  s.config.MaxTokens,
+				CanConfirm: true, // Allow follow-up confirmations
+			},
+		},
+	}
+}
+
+// LocaleValue is the request-scoped value (see Config.AuthFunc) holding the
+// user's locale, e.g. "pt-BR", which selects Config.Localizers.
+const LocaleValue = "locale"
+
+// preferences returns the default preferences with the user's locale from
+// the LocaleValue value, if set.
+func preferences(values map[string]string) *core.UserPreferences {
+	prefs := core.DefaultPreferences()
+	if locale := values[LocaleValue]; locale != "" {
+		prefs.Locale = locale
+	}
+	return prefs
 }
 
 func (s *Server) handleCancel(ctx context.Context, conn *websocket.Conn, sess *session, userID, actionID string) {
+	if batch := sess.PendingBatch; batch != nil && batch.ID == actionID {
+		s.cancelBatch(ctx, conn, sess, userID, batch)
+		return
+	}
+
 	// Get action first to have the BlockID for history
 	action, err := s.confirmations.Get(ctx, userID, actionID)
 	if err != nil {
@@ -562,6 +1184,7 @@ func (s *Server) handleCancel(ctx context.Context, conn *websocket.Conn, sess *s
 		s.sendError(conn, "Failed to cancel action")
 		return
 	}
+	sess.PendingActionID = ""
 
 	// Add cancelled tool result to history
 	sess.History = append(sess.History, core.NewToolResultMessage([]core.ToolResultContent{
@@ -572,6 +1195,25 @@ func (s *Server) handleCancel(ctx context.Context, conn *websocket.Conn, sess *s
 	s.send(conn, ServerMessage{Type: "complete"})
 }
 
+// cancelBatch cancels every action of a pending batch.
+func (s *Server) cancelBatch(ctx context.Context, conn *websocket.Conn, sess *session, userID string, batch *core.PendingActionBatch) {
+	sess.PendingBatch = nil
+
+	results := make([]core.ToolResultContent, len(batch.Actions))
+	for i, action := range batch.Actions {
+		if err := s.confirmations.Cancel(ctx, userID, action.ID); err != nil {
+			log.Printf("Failed to cancel action %s: %v", action.ID, err)
+		}
+		results[i] = core.ToolResultContent{ToolUseID: action.BlockID, Content: "Cancelled by user", IsError: true}
+	}
+
+	// Add cancelled tool results to history
+	sess.History = append(sess.History, core.NewToolResultMessage(results))
+
+	s.send(conn, ServerMessage{Type: "text", Content: "Actions cancelled."})
+	s.send(conn, ServerMessage{Type: "complete"})
+}
+
 func (s *Server) persistMessage(ctx context.Context, conversationID string, role, content string, inputTokens, outputTokens int) {
 	s.persistMessageWithID(ctx, conversationID, role, content, "", inputTokens, outputTokens)
 }
@@ -591,6 +1233,13 @@ func (s *Server) persistMessageWithID(ctx context.Context, conversationID string
 }
 
 func (s *Server) send(conn *websocket.Conn, msg ServerMessage) {
+	// A connection allows one writer at a time, and title generation
+	// sends from its own goroutine
+	if v, ok := s.conns.Load(conn); ok {
+		mu := v.(*sync.Mutex)
+		mu.Lock()
+		defer mu.Unlock()
+	}
 	if err := conn.WriteJSON(msg); err != nil {
 		log.Printf("Failed to send message: %v", err)
 	}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func dialWS(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// sendWS sends msg and reads replies until one of type until arrives.
+func sendWS(t *testing.T, conn *websocket.Conn, msg ClientMessage, until string) []ServerMessage {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var replies []ServerMessage
+	for {
+		var reply ServerMessage
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatalf("ReadJSON() error = %v (replies so far: %+v)", err, replies)
+		}
+		replies = append(replies, reply)
+		if reply.Type == until {
+			return replies
+		}
+	}
+}
+
+func newSendMoneyServer(t *testing.T, responses ...map[string]interface{}) *Server {
+	t.Helper()
+	responses = append([]map[string]interface{}{
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "send_money",
+			"input": map[string]interface{}{"amount": "10", "thought": "User asked to send $10 to Bob"},
+		}),
+	}, responses...)
+	srv := newTestServer(t, Config{}, responses...)
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:                 "send_money",
+		ToolDescription:          "Send money",
+		RequiresUserConfirmation: true,
+		SummaryTemplate:          "Send ${{.amount}}",
+		InputSchema:              map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"status": "sent"}}, nil
+	}))
+	return srv
+}
+
+func TestWebSocketResumeRestoresPendingAction(t *testing.T) {
+	srv := newSendMoneyServer(t,
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Sent $10."}),
+	)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	conn := dialWS(t, ts.URL)
+	started := sendWS(t, conn, ClientMessage{Type: "new_conversation"}, "conversation_started")
+	conversationID := started[0].ConversationID
+	replies := sendWS(t, conn, ClientMessage{Type: "message", Content: "send $10 to bob"}, "confirm_request")
+	actionID := replies[len(replies)-1].ActionID
+
+	// The connection drops before the user confirms.
+	conn.Close()
+
+	conn = dialWS(t, ts.URL)
+	replies = sendWS(t, conn, ClientMessage{Type: "resume_conversation", ConversationID: conversationID}, "confirm_request")
+	if replies[0].Type != "conversation_resumed" {
+		t.Errorf("first reply = %q, want conversation_resumed", replies[0].Type)
+	}
+	restored := replies[len(replies)-1]
+	if restored.ActionID != actionID || restored.Summary != "Send $10" {
+		t.Errorf("confirm_request = (%q, %q), want (%q, %q)", restored.ActionID, restored.Summary, actionID, "Send $10")
+	}
+
+	replies = sendWS(t, conn, ClientMessage{Type: "confirm", ActionID: actionID}, "complete")
+	if replies[0].Type != "text" || replies[0].Content != "Sent $10." {
+		t.Errorf("confirm reply = (%q, %q), want (text, %q)", replies[0].Type, replies[0].Content, "Sent $10.")
+	}
+}
+
+func TestWebSocketResumeAfterActionExpired(t *testing.T) {
+	srv := newSendMoneyServer(t)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	conn := dialWS(t, ts.URL)
+	started := sendWS(t, conn, ClientMessage{Type: "new_conversation"}, "conversation_started")
+	conversationID := started[0].ConversationID
+	replies := sendWS(t, conn, ClientMessage{Type: "message", Content: "send $10 to bob"}, "confirm_request")
+	actionID := replies[len(replies)-1].ActionID
+	conn.Close()
+
+	// The action expires while the client is disconnected.
+	if err := srv.confirmations.Cancel(context.Background(), "default-user", actionID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	conn = dialWS(t, ts.URL)
+	replies = sendWS(t, conn, ClientMessage{Type: "resume_conversation", ConversationID: conversationID}, "action_expired")
+	if got := replies[len(replies)-1].ActionID; got != actionID {
+		t.Errorf("action_expired actionId = %q, want %q", got, actionID)
+	}
+
+	// The unanswered tool_use is closed out so the next turn is valid.
+	sess, _ := srv.wsSessions.Load(conversationID)
+	history := sess.History
+	last := history[len(history)-1]
+	if len(last.ContentBlocks) != 1 || last.ContentBlocks[0].ToolResult == nil || last.ContentBlocks[0].ToolResult.ToolUseID != "toolu_1" {
+		t.Errorf("last history message = %+v, want a tool_result for toolu_1", last)
+	}
+}
+
+func TestWebSocketResumeFromStoreRebuildsToolUse(t *testing.T) {
+	srv := newSendMoneyServer(t,
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Sent $10."}),
+	)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	conn := dialWS(t, ts.URL)
+	started := sendWS(t, conn, ClientMessage{Type: "new_conversation"}, "conversation_started")
+	conversationID := started[0].ConversationID
+	replies := sendWS(t, conn, ClientMessage{Type: "message", Content: "send $10 to bob"}, "confirm_request")
+	actionID := replies[len(replies)-1].ActionID
+	conn.Close()
+
+	// Simulate a server restart: only the stores survive.
+	srv.wsSessions.Delete(conversationID)
+
+	conn = dialWS(t, ts.URL)
+	sendWS(t, conn, ClientMessage{Type: "resume_conversation", ConversationID: conversationID, ActionID: actionID}, "confirm_request")
+
+	sess, _ := srv.wsSessions.Load(conversationID)
+	if !hasToolUse(sess.History, "toolu_1") {
+		t.Error("resumed history has no tool_use for the pending action")
+	}
+
+	replies = sendWS(t, conn, ClientMessage{Type: "confirm", ActionID: actionID}, "complete")
+	if replies[0].Content != "Sent $10." {
+		t.Errorf("confirm reply = %q, want %q", replies[0].Content, "Sent $10.")
+	}
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestShutdownDrainsRuns(t *testing.T) {
+	srv := newTestServer(t, Config{},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": map[string]interface{}{},
+		}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Your balance is $100."}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Balance check"}), // title
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:        "get_balance",
+		ToolDescription: "Get balance",
+		InputSchema:     map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		close(started)
+		<-release
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+	closed := false
+	srv.RegisterCloser(closerFunc(func() error {
+		closed = true
+		return nil
+	}))
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	conn := dialWS(t, ts.URL)
+	sendWS(t, conn, ClientMessage{Type: "new_conversation"}, "conversation_started")
+	if err := conn.WriteJSON(ClientMessage{Type: "message", Content: "balance"}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	<-started
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown() returned %v before the run finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// New runs are refused while shutting down.
+	rec, _ := postJSON(t, srv.ChatHandler(), ChatRequest{UserID: "alice", Message: "hi"})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /chat during shutdown status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if !closed {
+		t.Error("registered closer was not closed")
+	}
+
+	// The client got the finished turn, then a close frame.
+	var reply ServerMessage
+	for reply.Type != "complete" {
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatalf("ReadJSON() error = %v before complete", err)
+		}
+	}
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("ReadMessage() error = %v, want close going away", err)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	srv := newTestServer(t, Config{})
+	if !srv.beginRun() {
+		t.Fatal("beginRun() = false before shutdown")
+	}
+	defer srv.runs.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWebSocketResumeDuringRun(t *testing.T) {
+	srv := newTestServer(t, Config{},
+		fakeMessage("tool_use", map[string]interface{}{
+			"type": "tool_use", "id": "toolu_1", "name": "get_balance", "input": map[string]interface{}{},
+		}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Your balance is $100."}),
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Balance check"}), // title
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv.AddTool(core.NewBaseTool(core.ToolDefinition{
+		ToolName:        "get_balance",
+		ToolDescription: "Get balance",
+		InputSchema:     map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	}, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		close(started)
+		<-release
+		return &core.ToolResult{Success: true, Data: map[string]interface{}{"balance": "100"}}, nil
+	}))
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	first := dialWS(t, ts.URL)
+	conversationID := sendWS(t, first, ClientMessage{Type: "new_conversation"}, "conversation_started")[0].ConversationID
+	if err := first.WriteJSON(ClientMessage{Type: "message", Content: "balance"}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	<-started
+
+	// A second connection resumes while the first is mid-run.
+	second := dialWS(t, ts.URL)
+	if err := second.WriteJSON(ClientMessage{Type: "resume_conversation", ConversationID: conversationID}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	var reply ServerMessage
+	for reply.Type != "complete" {
+		if err := first.ReadJSON(&reply); err != nil {
+			t.Fatalf("ReadJSON() error = %v before complete", err)
+		}
+	}
+	for reply.Type != "conversation_resumed" {
+		if err := second.ReadJSON(&reply); err != nil {
+			t.Fatalf("ReadJSON() error = %v before conversation_resumed", err)
+		}
+	}
+	sess, _ := srv.wsSessions.Load(conversationID)
+	sess.mu.Lock()
+	history := sess.History
+	sess.mu.Unlock()
+	if len(history) != 2 || history[1].Content != "Your balance is $100." {
+		t.Errorf("resumed session history = %+v, want the finished turn", history)
+	}
+
+	// The session now belongs to the second connection.
+	replies := sendWS(t, first, ClientMessage{Type: "message", Content: "again"}, "error")
+	if got := replies[len(replies)-1].Content; !strings.Contains(got, "No active conversation") {
+		t.Errorf("message on the old connection got error %q, want no active conversation", got)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSessionTTL is how long an idle session stays in memory when
+// Config.SessionTTL is unset. It outlasts a pending action's 10-minute
+// expiry, so a session isn't evicted while its confirmation is valid.
+const defaultSessionTTL = 30 * time.Minute
+
+// sessionMap holds sessions by conversation ID and evicts those idle for
+// longer than ttl. An evicted conversation is reloaded from the
+// conversation store on its next request.
+type sessionMap struct {
+	ttl      time.Duration
+	sessions sync.Map     // conversationID -> *session
+	sweptAt  atomic.Int64 // Unix nanoseconds of the last sweep
+}
+
+// newSessionMap returns a sessionMap evicting sessions idle for ttl, or
+// defaultSessionTTL if ttl is not positive.
+func newSessionMap(ttl time.Duration) *sessionMap {
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	m := &sessionMap{ttl: ttl}
+	m.sweptAt.Store(time.Now().UnixNano())
+	return m
+}
+
+// Load returns the session for conversationID, marking it used.
+func (m *sessionMap) Load(conversationID string) (*session, bool) {
+	v, ok := m.sessions.Load(conversationID)
+	if !ok {
+		return nil, false
+	}
+	sess := v.(*session)
+	sess.touch()
+	return sess, true
+}
+
+// Store adds sess under conversationID, replacing any session there.
+func (m *sessionMap) Store(conversationID string, sess *session) {
+	sess.touch()
+	m.sessions.Store(conversationID, sess)
+	m.maybeSweep(time.Now())
+}
+
+// LoadOrStore returns the session for conversationID, storing sess if
+// there is none.
+func (m *sessionMap) LoadOrStore(conversationID string, sess *session) *session {
+	sess.touch()
+	v, loaded := m.sessions.LoadOrStore(conversationID, sess)
+	if loaded {
+		v.(*session).touch()
+	} else {
+		m.maybeSweep(time.Now())
+	}
+	return v.(*session)
+}
+
+// Delete removes the session for conversationID.
+func (m *sessionMap) Delete(conversationID string) {
+	m.sessions.Delete(conversationID)
+}
+
+// maybeSweep evicts idle sessions at most once per ttl.
+func (m *sessionMap) maybeSweep(now time.Time) {
+	last := m.sweptAt.Load()
+	if now.UnixNano()-last < int64(m.ttl) || !m.sweptAt.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	m.sweep(now)
+}
+
+// sweep evicts sessions idle for longer than ttl. Sessions serving a
+// request (their mu held) are kept.
+func (m *sessionMap) sweep(now time.Time) {
+	m.sessions.Range(func(key, value interface{}) bool {
+		sess := value.(*session)
+		if !sess.idleSince(now, m.ttl) || !sess.mu.TryLock() {
+			return true
+		}
+		if sess.idleSince(now, m.ttl) {
+			m.sessions.CompareAndDelete(key, sess)
+		}
+		sess.mu.Unlock()
+		return true
+	})
+}
+
+// touch records that the session was just used.
+func (s *session) touch() {
+	s.lastUsed.Store(time.Now().UnixNano())
+}
+
+// idleSince reports whether the session has gone unused for longer than
+// ttl as of now.
+func (s *session) idleSince(now time.Time, ttl time.Duration) bool {
+	return now.UnixNano()-s.lastUsed.Load() > int64(ttl)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionMapSweep(t *testing.T) {
+	m := newSessionMap(time.Minute)
+	idle := &session{ID: "idle"}
+	busy := &session{ID: "busy"}
+	recent := &session{ID: "recent"}
+	m.Store("idle", idle)
+	m.Store("busy", busy)
+	m.Store("recent", recent)
+
+	// A session serving a request is kept even once idle.
+	busy.mu.Lock()
+	defer busy.mu.Unlock()
+	now := time.Now().Add(2 * time.Minute)
+	recent.lastUsed.Store(now.UnixNano())
+	m.sweep(now)
+
+	if _, ok := m.Load("idle"); ok {
+		t.Error("idle session was not evicted")
+	}
+	if _, ok := m.Load("busy"); !ok {
+		t.Error("session serving a request was evicted")
+	}
+	if _, ok := m.Load("recent"); !ok {
+		t.Error("recently used session was evicted")
+	}
+}
+
+func TestRESTSessionReloadsEvictedConversation(t *testing.T) {
+	srv := newTestServer(t, Config{SessionTTL: time.Minute})
+	ctx := context.Background()
+
+	sess, err := srv.restSession(ctx, "alice", "")
+	if err != nil {
+		t.Fatalf("restSession() error = %v", err)
+	}
+	srv.persistMessage(ctx, sess.ConversationID, "user", "hello", 0, 0)
+	srv.restSessions.sweep(time.Now().Add(time.Hour))
+	if _, ok := srv.restSessions.Load(sess.ConversationID); ok {
+		t.Fatal("idle REST session was not evicted")
+	}
+
+	reloaded, err := srv.restSession(ctx, "alice", sess.ConversationID)
+	if err != nil {
+		t.Fatalf("restSession() after eviction error = %v", err)
+	}
+	if reloaded == sess || len(reloaded.History) != 1 {
+		t.Errorf("reloaded session history = %v, want the stored message", reloaded.History)
+	}
+	if _, err := srv.restSession(ctx, "mallory", sess.ConversationID); err == nil {
+		t.Error("another user loaded the evicted conversation")
+	}
+}
+
+func TestWebSocketResumeAfterEviction(t *testing.T) {
+	srv := newSendMoneyServer(t,
+		fakeMessage("end_turn", map[string]interface{}{"type": "text", "text": "Sent $10."}),
+	)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	conn := dialWS(t, ts.URL)
+	started := sendWS(t, conn, ClientMessage{Type: "new_conversation"}, "conversation_started")
+	conversationID := started[0].ConversationID
+	replies := sendWS(t, conn, ClientMessage{Type: "message", Content: "send $10 to bob"}, "confirm_request")
+	actionID := replies[len(replies)-1].ActionID
+	conn.Close()
+
+	srv.wsSessions.sweep(time.Now().Add(time.Hour))
+	if _, ok := srv.wsSessions.Load(conversationID); ok {
+		t.Fatal("idle WebSocket session was not evicted")
+	}
+
+	// The client resumes from the conversation store.
+	conn = dialWS(t, ts.URL)
+	sendWS(t, conn, ClientMessage{Type: "resume_conversation", ConversationID: conversationID, ActionID: actionID}, "confirm_request")
+	replies = sendWS(t, conn, ClientMessage{Type: "confirm", ActionID: actionID}, "complete")
+	if replies[0].Content != "Sent $10." {
+		t.Errorf("confirm reply = %q, want %q", replies[0].Content, "Sent $10.")
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// errShuttingDown is returned for agent runs started after Shutdown.
+var errShuttingDown = errors.New("server is shutting down")
+
+// RunOption configures Server.Run.
+type RunOption func(*runOptions)
+
+type runOptions struct {
+	signalTimeout time.Duration
+	handleSignals bool
+}
+
+// WithSignalShutdown makes Run call Shutdown on SIGINT or SIGTERM, giving
+// in-flight runs up to timeout to finish. Run returns once shutdown is done.
+func WithSignalShutdown(timeout time.Duration) RunOption {
+	return func(o *runOptions) {
+		o.handleSignals = true
+		o.signalTimeout = timeout
+	}
+}
+
+// RegisterCloser adds a background component (e.g. a scheduler) to close
+// on Shutdown, after in-flight runs have drained. Closers run in reverse
+// registration order.
+func (s *Server) RegisterCloser(c io.Closer) {
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+	s.closers = append(s.closers, c)
+}
+
+// Shutdown gracefully stops the server: it stops accepting connections and
+// agent runs, waits for in-flight runs (including pending confirmations
+// being executed) until ctx is done, closes open WebSockets, then closes
+// registered closers. It returns ctx's error if runs were still in flight.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownMu.Lock()
+	s.shuttingDown = true
+	httpServer := s.httpServer
+	closers := s.closers
+	s.shutdownMu.Unlock()
+
+	log.Printf("Shutting down server")
+	var errs []error
+
+	// Stop accepting connections; waits for in-flight REST requests
+	if httpServer != nil {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Drain agent runs, which WebSocket connections don't expose to the
+	// HTTP server once upgraded
+	drained := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Printf("Shutdown deadline reached with agent runs in flight")
+		errs = append(errs, ctx.Err())
+	}
+
+	// Close WebSockets with a close frame so clients know to reconnect
+	s.conns.Range(func(key, _ interface{}) bool {
+		conn := key.(*websocket.Conn)
+		msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+		conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		conn.Close()
+		return true
+	})
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// beginRun registers an agent run for Shutdown to wait on. It returns false
+// once shutdown has started; otherwise the caller must call s.runs.Done.
+func (s *Server) beginRun() bool {
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+	if s.shuttingDown {
+		return false
+	}
+	s.runs.Add(1)
+	return true
+}
+
+// shutdownOnSignal calls Shutdown on SIGINT or SIGTERM and reports its
+// result on the returned channel. stop stops listening for signals.
+func (s *Server) shutdownOnSignal(timeout time.Duration) (done <-chan error, stop func()) {
+	result := make(chan error, 1)
+	sig := make(chan os.Signal, 1)
+	quit := make(chan struct{})
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case received := <-sig:
+			log.Printf("Received %v", received)
+		case <-quit:
+			return
+		}
+		signal.Stop(sig)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		result <- s.Shutdown(ctx)
+	}()
+	return result, func() {
+		signal.Stop(sig)
+		close(quit)
+	}
+}
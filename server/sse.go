@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/engine"
+)
+
+// ChatStreamHandler returns an HTTP handler for GET/POST /chat/stream, which
+// runs one agent turn like POST /chat but streams it as Server-Sent Events:
+//
+//   - unnamed events (data only): text deltas as they are generated
+//   - event: plan            the plan, when plan preview is enabled
+//   - event: tool_input      {"tool", "blockId", "partialJson"} as a tool's
+//     input streams in; concatenate partialJson per blockId
+//   - event: tool_start      {"tool": name} when a tool starts executing
+//   - event: confirm_request the pending action (a Confirmation)
+//   - event: error           {"error": message}
+//   - event: done            the final ChatResponse
+//
+// POST takes a ChatRequest body; GET takes message, conversationId and userId
+// query parameters. If the client disconnects, the agent turn is cancelled.
+// Confirm pending actions with POST /confirm.
+func (s *Server) ChatStreamHandler() http.Handler {
+	return http.HandlerFunc(s.handleChatStream)
+}
+
+func (s *Server) handleChatStream(w http.ResponseWriter, r *http.Request) {
+	var req ChatRequest
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Message = q.Get("message")
+		req.ConversationID = q.Get("conversationId")
+		req.UserID = q.Get("userId")
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	sess, ok := s.beginChat(w, r, &req)
+	if !ok {
+		return
+	}
+	defer sess.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sse := &sseWriter{w: w, flusher: flusher}
+
+	// The request context is cancelled when the client disconnects,
+	// which stops the engine mid-turn.
+	ctx := r.Context()
+	output, err := s.runChatTurn(ctx, sess, req.Message, func(input *engine.Input) {
+		if !s.config.DisableStreaming {
+			input.StreamCallback = func(chunk string, done bool) {
+				if !done && chunk != "" {
+					sse.send("", chunk)
+				}
+			}
+			input.ToolInputCallback = func(delta engine.ToolInputDelta) {
+				if !delta.Done {
+					sse.sendJSON("tool_input", map[string]string{
+						"tool":        delta.Tool,
+						"blockId":     delta.BlockID,
+						"partialJson": delta.PartialJSON,
+					})
+				}
+			}
+		}
+		input.ToolCallback = func(tool string) {
+			sse.sendJSON("tool_start", map[string]string{"tool": tool})
+		}
+		input.PlanCallback = func(plan *engine.Plan) {
+			sse.sendJSON("plan", plan)
+		}
+	})
+	if ctx.Err() != nil {
+		log.Printf("[SSE] Client disconnected from conversation %s", sess.ConversationID)
+		return
+	}
+	if err != nil {
+		sse.sendJSON("error", map[string]string{"error": fmt.Sprintf("Agent error: %v", err)})
+		return
+	}
+
+	resp := newChatResponse(sess.ConversationID, output)
+	switch output.Type {
+	case engine.OutputConfirmationNeeded, engine.OutputBatchConfirmationNeeded:
+		sse.sendJSON("confirm_request", resp.PendingAction)
+	case engine.OutputError:
+		sse.sendJSON("error", map[string]string{"error": resp.Error})
+	}
+	sse.sendJSON("done", resp)
+}
+
+// sseWriter writes Server-Sent Events, flushing after each one.
+// Safe for concurrent use by engine callbacks.
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// send writes one event. Multi-line data is split into one data: line per
+// line, which clients rejoin with "\n".
+func (sw *sseWriter) send(event, data string) {
+	var b strings.Builder
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if _, err := sw.w.Write([]byte(b.String())); err != nil {
+		return // Client gone; the request context cancels the turn
+	}
+	sw.flusher.Flush()
+}
+
+// sendJSON writes one event with a JSON payload.
+func (sw *sseWriter) sendJSON(event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("[SSE] Failed to marshal %s event: %v", event, err)
+		return
+	}
+	sw.send(event, string(data))
+}
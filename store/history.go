@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MemoryConversationStore is an in-memory implementation of
+// engine.ConversationStore. Suitable for development and testing.
+// Not suitable for production as data is lost on restart.
+type MemoryConversationStore struct {
+	mu       sync.RWMutex
+	messages map[string][]core.Message // conversationID -> messages
+}
+
+// NewMemoryConversationStore creates an in-memory conversation history store.
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{
+		messages: make(map[string][]core.Message),
+	}
+}
+
+// Load returns a copy of the conversation's messages.
+func (m *MemoryConversationStore) Load(ctx context.Context, conversationID string) ([]core.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]core.Message{}, m.messages[conversationID]...), nil
+}
+
+// Append adds messages to the conversation.
+func (m *MemoryConversationStore) Append(ctx context.Context, conversationID string, messages []core.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[conversationID] = append(m.messages[conversationID], messages...)
+	return nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// MemoryIdempotency is an in-memory implementation of engine.IdempotencyStore.
+// Suitable for single-instance deployments; keys are lost on restart.
+type MemoryIdempotency struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	result    *core.ToolResult // nil while the action is executing
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotency creates an in-memory idempotency store.
+func NewMemoryIdempotency() *MemoryIdempotency {
+	return &MemoryIdempotency{
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// Begin claims key, or returns the recorded result if key was already claimed
+// and hasn't expired.
+func (m *MemoryIdempotency) Begin(ctx context.Context, key string, ttl time.Duration) (*core.ToolResult, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if entry, ok := m.entries[key]; ok && now.Before(entry.expiresAt) {
+		return entry.result, false, nil
+	}
+	m.entries[key] = &idempotencyEntry{expiresAt: now.Add(ttl)}
+	return nil, true, nil
+}
+
+// Complete records the result for key.
+func (m *MemoryIdempotency) Complete(ctx context.Context, key string, result *core.ToolResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok {
+		entry.result = result
+	}
+	return nil
+}
+
+// Release removes key.
+func (m *MemoryIdempotency) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// Cleanup removes all expired keys. Returns count of removed keys.
+func (m *MemoryIdempotency) Cleanup(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for key, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// SQLiteConversationStore is a SQLite implementation of
+// engine.ConversationStore. Messages are stored as JSON, so tool_use and
+// tool_result blocks survive restarts and pending confirmations can resume.
+type SQLiteConversationStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteConversationStore opens (or creates) the SQLite database at path.
+func NewSQLiteConversationStore(path string) (*SQLiteConversationStore, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_messages (
+			seq             INTEGER PRIMARY KEY AUTOINCREMENT,
+			conversation_id TEXT NOT NULL,
+			message         TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_conversation_messages_conversation
+			ON conversation_messages(conversation_id, seq);`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create conversation schema: %w", err)
+	}
+	return &SQLiteConversationStore{db: db}, nil
+}
+
+// Load returns the conversation's messages in order.
+func (s *SQLiteConversationStore) Load(ctx context.Context, conversationID string) ([]core.Message, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT message FROM conversation_messages WHERE conversation_id = ? ORDER BY seq`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []core.Message{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var msg core.Message
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// Append adds messages to the conversation in one transaction.
+func (s *SQLiteConversationStore) Append(ctx context.Context, conversationID string, messages []core.Message) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO conversation_messages (conversation_id, message) VALUES (?, ?)`,
+			conversationID, string(data),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database.
+func (s *SQLiteConversationStore) Close() error {
+	return s.db.Close()
+}
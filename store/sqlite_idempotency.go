@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// SQLiteIdempotency is a SQLite implementation of engine.IdempotencyStore.
+// Keys survive restarts, so an action confirmed before a crash isn't executed
+// again when the client retries.
+type SQLiteIdempotency struct {
+	db *sql.DB
+}
+
+// NewSQLiteIdempotency opens (or creates) the SQLite database at path.
+func NewSQLiteIdempotency(path string) (*SQLiteIdempotency, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			key        TEXT PRIMARY KEY,
+			result     TEXT,
+			expires_at INTEGER NOT NULL
+		)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create idempotency schema: %w", err)
+	}
+	return &SQLiteIdempotency{db: db}, nil
+}
+
+// Begin claims key, or returns the recorded result if key was already claimed
+// and hasn't expired.
+func (s *SQLiteIdempotency) Begin(ctx context.Context, key string, ttl time.Duration) (*core.ToolResult, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var raw sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT result FROM idempotency_keys WHERE key = ? AND expires_at > ?`,
+		key, now.UnixMilli(),
+	).Scan(&raw)
+	switch {
+	case err == nil:
+		if !raw.Valid {
+			return nil, false, nil // Still executing
+		}
+		var result core.ToolResult
+		if err := json.Unmarshal([]byte(raw.String), &result); err != nil {
+			return nil, false, fmt.Errorf("failed to decode recorded result: %w", err)
+		}
+		return &result, false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, false, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO idempotency_keys (key, result, expires_at) VALUES (?, NULL, ?)`,
+		key, now.Add(ttl).UnixMilli(),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return nil, true, nil
+}
+
+// Complete records the result for key.
+func (s *SQLiteIdempotency) Complete(ctx context.Context, key string, result *core.ToolResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `UPDATE idempotency_keys SET result = ? WHERE key = ?`, string(data), key)
+	return err
+}
+
+// Release removes key.
+func (s *SQLiteIdempotency) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = ?`, key)
+	return err
+}
+
+// Cleanup removes all expired keys. Returns count of removed keys.
+func (s *SQLiteIdempotency) Cleanup(ctx context.Context) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, time.Now().UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Close closes the database.
+func (s *SQLiteIdempotency) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestSQLiteIdempotency(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idem.db")
+	s, err := NewSQLiteIdempotency(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteIdempotency() error = %v", err)
+	}
+
+	if _, started, err := s.Begin(ctx, "k", time.Hour); err != nil || !started {
+		t.Fatalf("Begin() = (started %v, err %v), want (true, nil)", started, err)
+	}
+	if result, started, _ := s.Begin(ctx, "k", time.Hour); started || result != nil {
+		t.Errorf("Begin() while executing = (%v, %v), want (nil, false)", result, started)
+	}
+	if err := s.Complete(ctx, "k", &core.ToolResult{Success: true, Data: "tx-1"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	s.Close()
+
+	// The recorded result survives a restart.
+	s, err = NewSQLiteIdempotency(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer s.Close()
+	result, started, err := s.Begin(ctx, "k", time.Hour)
+	if err != nil || started || result == nil || result.Data != "tx-1" {
+		t.Errorf("Begin() after Complete = (%v, %v, %v), want recorded result", result, started, err)
+	}
+
+	// Released and expired keys can be claimed again.
+	s.Release(ctx, "k")
+	if _, started, _ := s.Begin(ctx, "k", -time.Second); !started {
+		t.Error("Begin() after Release started = false, want true")
+	}
+	if _, started, _ := s.Begin(ctx, "k", time.Hour); !started {
+		t.Error("Begin() after expiry started = false, want true")
+	}
+}
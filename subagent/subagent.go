@@ -31,7 +31,8 @@ type SubAgentConfig struct {
 	// SystemPrompt is the specialized system prompt for this sub-agent.
 	SystemPrompt string
 
-	// AvailableTools lists the tool names this sub-agent can use.
+	// AvailableTools lists the tools this sub-agent can use, by name or by
+	// engine.FilterByNames selector (e.g. "@read-only").
 	AvailableTools []string
 
 	// Model is the Claude model to use. Defaults to claude-sonnet-4-20250514.
@@ -156,7 +157,7 @@ func ToResult(agentName string, output *core.Output) *SubAgentResult {
 		if output.Error != nil {
 			result.Error = output.Error.Error()
 		}
-	case core.OutputConfirmationNeeded:
+	case core.OutputConfirmationNeeded, core.OutputBatchConfirmationNeeded:
 		// Sub-agents should never reach this state
 		result.Success = false
 		result.Error = "sub-agent attempted to request confirmation"
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ParseBalance reads the balance of currency from a get_balance response.
+// It accepts the balance shapes Liminal has returned:
+//
+//	{"balances": [{"currency": "USDC", "amount": "12.50"}], "totalUsd": "12.50"}
+//	{"balance": "12.50", "currency": "USDC"}
+//	{"USDC": "12.50", "EURC": "3.00"}           (also nested under "balances")
+//
+// Currencies compare case-insensitively and amounts may be strings or
+// numbers. For currency "USD", totalUsd is used if there's no USD entry.
+// The flag is false if the response has no balance for currency or can't be
+// parsed.
+func ParseBalance(data json.RawMessage, currency string) (float64, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, false
+	}
+
+	// Balances array, or a currency-keyed object under "balances"
+	if raw, ok := fields["balances"]; ok {
+		var list []map[string]json.RawMessage
+		if json.Unmarshal(raw, &list) == nil {
+			for _, entry := range list {
+				if strings.EqualFold(jsonString(entry["currency"]), currency) {
+					return parseAmount(entry["amount"])
+				}
+			}
+		} else if amount, ok := currencyKeyed(raw, currency); ok {
+			return amount, true
+		}
+	}
+
+	// Flat balance
+	if raw, ok := fields["balance"]; ok {
+		if c := jsonString(fields["currency"]); c == "" || strings.EqualFold(c, currency) {
+			if amount, ok := parseAmount(raw); ok {
+				return amount, true
+			}
+		}
+	}
+
+	// Currency-keyed
+	if amount, ok := currencyKeyed(data, currency); ok {
+		return amount, true
+	}
+
+	if strings.EqualFold(currency, "USD") {
+		return parseAmount(fields["totalUsd"])
+	}
+	return 0, false
+}
+
+// currencyKeyed reads currency's amount from an object keyed by currency.
+func currencyKeyed(data json.RawMessage, currency string) (float64, bool) {
+	var byCurrency map[string]json.RawMessage
+	if err := json.Unmarshal(data, &byCurrency); err != nil {
+		return 0, false
+	}
+	if raw, ok := byCurrency[currency]; ok {
+		return parseAmount(raw)
+	}
+	for _, key := range core.SortedKeys(byCurrency) {
+		if strings.EqualFold(key, currency) {
+			return parseAmount(byCurrency[key])
+		}
+	}
+	return 0, false
+}
+
+// parseAmount reads a JSON number or numeric string.
+func parseAmount(raw json.RawMessage) (float64, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return 0, false
+	}
+	s := string(raw)
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, false
+		}
+	}
+	amount, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// jsonString returns raw as a string, or "" if it isn't one.
+func jsonString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseBalance(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		currency string
+		want     float64
+		wantOK   bool
+	}{
+		{"balances array", `{"balances":[{"currency":"EURC","amount":"3.00"},{"currency":"USDC","amount":"12.50","usdValue":"12.50"}],"totalUsd":"15.75"}`, "USDC", 12.5, true},
+		{"balances array case-insensitive", `{"balances":[{"currency":"usdc","amount":"12.50"}]}`, "USDC", 12.5, true},
+		{"balances array numeric amount", `{"balances":[{"currency":"USDC","amount":12.5}]}`, "USDC", 12.5, true},
+		{"balances array missing currency", `{"balances":[{"currency":"EURC","amount":"3.00"}]}`, "USDC", 0, false},
+		{"total USD", `{"balances":[{"currency":"USDC","amount":"12.50"}],"totalUsd":"15.75"}`, "USD", 15.75, true},
+		{"flat balance", `{"balance":"42.10","currency":"USDC"}`, "USDC", 42.1, true},
+		{"flat balance without currency", `{"balance":42.1}`, "USDC", 42.1, true},
+		{"flat balance other currency", `{"balance":"42.10","currency":"EURC"}`, "USDC", 0, false},
+		{"currency-keyed", `{"USDC":"7.25","EURC":"1"}`, "usdc", 7.25, true},
+		{"currency-keyed under balances", `{"balances":{"USDC":7.25}}`, "USDC", 7.25, true},
+		{"currency-keyed exact match wins", `{"usdc":"1","USDC":"7.25","Usdc":"2"}`, "USDC", 7.25, true},
+		{"currency-keyed fold match is stable", `{"usdc":"1","Usdc":"2"}`, "USDC", 2, true},
+		{"zero balance", `{"balances":[{"currency":"USDC","amount":"0"}]}`, "USDC", 0, true},
+		{"non-numeric amount", `{"balances":[{"currency":"USDC","amount":"lots"}]}`, "USDC", 0, false},
+		{"not an object", `[1,2,3]`, "USDC", 0, false},
+		{"malformed JSON", `{"balances":`, "USDC", 0, false},
+		{"empty", ``, "USDC", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseBalance(json.RawMessage(tt.data), tt.currency)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseBalance(%s, %q) = (%v, %v), want (%v, %v)", tt.data, tt.currency, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
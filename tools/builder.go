@@ -14,7 +14,11 @@ type Builder struct {
 	description          string
 	schema               map[string]interface{}
 	requiresConfirmation bool
+	requireThought       bool
 	summaryTemplate      string
+	summaryFunc          func(input json.RawMessage) string
+	summaryKey           string
+	idempotencyFields    []string
 	handler              core.ToolHandler
 }
 
@@ -49,12 +53,47 @@ func (b *Builder) RequiresConfirmation() *Builder {
 	return b
 }
 
+// RequireThought makes the thought parameter required without requiring
+// confirmation. The engine rejects calls with an empty thought, so the
+// reasoning is always captured in traces for memory and audit. Useful for
+// analytical read tools. Tools that require confirmation already require
+// a thought.
+func (b *Builder) RequireThought() *Builder {
+	b.requireThought = true
+	return b
+}
+
 // SummaryTemplate sets the template for generating action summaries.
 func (b *Builder) SummaryTemplate(template string) *Builder {
 	b.summaryTemplate = template
 	return b
 }
 
+// SummaryFunc sets a function that generates action summaries from the tool
+// input, e.g. to format amounts or add warnings. Takes precedence over
+// SummaryTemplate; use the template for simple field substitution.
+func (b *Builder) SummaryFunc(fn func(input json.RawMessage) string) *Builder {
+	b.summaryFunc = fn
+	return b
+}
+
+// SummaryKey sets the message key of a localized summary, translated with
+// the run's Localizer (see engine.WithLocalizer) against the tool input.
+// SummaryFunc or SummaryTemplate is used when it isn't translated.
+func (b *Builder) SummaryKey(key string) *Builder {
+	b.summaryKey = key
+	return b
+}
+
+// IdempotencyFields sets the input fields that identify a write, e.g.
+// IdempotencyFields("recipient", "amount", "currency"). Only these fields are
+// hashed for the idempotency key of a pending action, so calls differing in
+// other fields (timestamps, request IDs, the thought) are deduplicated.
+func (b *Builder) IdempotencyFields(fields ...string) *Builder {
+	b.idempotencyFields = fields
+	return b
+}
+
 // Handler sets the execution handler for the tool.
 func (b *Builder) Handler(h core.ToolHandler) *Builder {
 	b.handler = h
@@ -75,22 +114,48 @@ func (b *Builder) HandlerFunc(fn func(ctx context.Context, input json.RawMessage
 
 // Build creates the tool.
 func (b *Builder) Build() core.Tool {
+	schema := b.schema
+	if b.requireThought {
+		schema = requireThoughtInSchema(schema)
+	}
+
 	return core.NewBaseTool(core.ToolDefinition{
 		ToolName:                 b.name,
 		ToolDescription:          b.description,
 		RequiresUserConfirmation: b.requiresConfirmation,
+		RequireThought:           b.requireThought,
 		SummaryTemplate:          b.summaryTemplate,
-		InputSchema:              b.schema,
+		SummaryFunc:              b.summaryFunc,
+		SummaryKey:               b.summaryKey,
+		IdempotencyFields:        b.idempotencyFields,
+		InputSchema:              schema,
 	}, b.handler)
 }
 
+// requireThoughtInSchema adds a required thought parameter unless the schema
+// already requires one.
+func requireThoughtInSchema(schema map[string]interface{}) map[string]interface{} {
+	if required, ok := schema["required"].([]string); ok {
+		for _, name := range required {
+			if name == "thought" {
+				return schema
+			}
+		}
+	}
+	return WithThought(schema, true)
+}
+
 // Config provides a declarative way to create a tool.
 type Config struct {
 	Name                 string
 	Description          string
 	Schema               map[string]interface{}
 	RequiresConfirmation bool
+	RequireThought       bool // See Builder.RequireThought
 	SummaryTemplate      string
+	SummaryFunc          func(input json.RawMessage) string // Takes precedence over SummaryTemplate
+	SummaryKey           string                             // See Builder.SummaryKey
+	IdempotencyFields    []string                           // See Builder.IdempotencyFields
 	Handler              func(ctx context.Context, input json.RawMessage) (interface{}, error)
 }
 
@@ -104,11 +169,20 @@ func FromConfig(cfg Config) core.Tool {
 		return &core.ToolResult{Success: true, Data: result}, nil
 	}
 
+	schema := cfg.Schema
+	if cfg.RequireThought {
+		schema = requireThoughtInSchema(schema)
+	}
+
 	return core.NewBaseTool(core.ToolDefinition{
 		ToolName:                 cfg.Name,
 		ToolDescription:          cfg.Description,
 		RequiresUserConfirmation: cfg.RequiresConfirmation,
+		RequireThought:           cfg.RequireThought,
 		SummaryTemplate:          cfg.SummaryTemplate,
-		InputSchema:              cfg.Schema,
+		SummaryFunc:              cfg.SummaryFunc,
+		SummaryKey:               cfg.SummaryKey,
+		IdempotencyFields:        cfg.IdempotencyFields,
+		InputSchema:              schema,
 	}, handler)
 }
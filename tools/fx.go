@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ConvertCurrencyToolName is the name of the currency conversion tool.
+const ConvertCurrencyToolName = "convert_currency"
+
+// ErrUnsupportedPair is returned when no rate is available for a currency pair.
+var ErrUnsupportedPair = errors.New("unsupported currency pair")
+
+// FXClient provides exchange rates.
+type FXClient interface {
+	// Rate returns how many units of to one unit of from buys. Currency codes
+	// are normalized with NormalizeCurrency. Returns an error wrapping
+	// ErrUnsupportedPair if either currency is unknown.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// currencyAliases maps stablecoins and common names to the fiat currency
+// they track, so "USDC" converts like "USD".
+var currencyAliases = map[string]string{
+	"USDC":    "USD",
+	"DOLLAR":  "USD",
+	"DOLLARS": "USD",
+	"EURC":    "EUR",
+	"EURO":    "EUR",
+	"EUROS":   "EUR",
+}
+
+// NormalizeCurrency upper-cases a currency code and maps stablecoins to the
+// fiat currency they track (USDC → USD, EURC → EUR).
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if fiat, ok := currencyAliases[code]; ok {
+		return fiat
+	}
+	return code
+}
+
+// HTTPFXConfig configures an HTTPFXClient.
+type HTTPFXConfig struct {
+	// Endpoint is the rates URL. It is called as GET Endpoint?from=USD and
+	// must return {"base": "USD", "rates": {"EUR": 0.92, ...}}, as
+	// https://api.frankfurter.app/latest does. Defaults to that URL.
+	Endpoint string
+
+	// TTL is how long rates for a base currency are cached. Defaults to 10 minutes.
+	TTL time.Duration
+
+	// HTTPClient is the client used for requests. Defaults to one with a
+	// 10s timeout.
+	HTTPClient *http.Client
+}
+
+// HTTPFXClient fetches exchange rates from a rates endpoint and caches them
+// per base currency.
+type HTTPFXClient struct {
+	endpoint   string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]fxRates // base currency -> rates
+}
+
+type fxRates struct {
+	rates     map[string]float64
+	fetchedAt time.Time
+}
+
+// NewHTTPFXClient creates an FX client for a rates endpoint.
+func NewHTTPFXClient(cfg HTTPFXConfig) *HTTPFXClient {
+	c := &HTTPFXClient{
+		endpoint:   cfg.Endpoint,
+		ttl:        cfg.TTL,
+		httpClient: cfg.HTTPClient,
+		cache:      make(map[string]fxRates),
+	}
+	if c.endpoint == "" {
+		c.endpoint = "https://api.frankfurter.app/latest"
+	}
+	if c.ttl == 0 {
+		c.ttl = 10 * time.Minute
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return c
+}
+
+// Rate returns the exchange rate from one currency to another.
+func (c *HTTPFXClient) Rate(ctx context.Context, from, to string) (float64, error) {
+	from, to = NormalizeCurrency(from), NormalizeCurrency(to)
+	if from == to {
+		return 1, nil
+	}
+
+	rates, err := c.rates(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+	rate, ok := rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s to %s", ErrUnsupportedPair, from, to)
+	}
+	return rate, nil
+}
+
+// rates returns the cached rates for base, fetching them if stale.
+func (c *HTTPFXClient) rates(ctx context.Context, base string) (map[string]float64, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[base]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.ttl {
+		return cached.rates, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?from="+url.QueryEscape(base), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read rates: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity:
+		// Rate providers reject unknown base currencies with 404/422
+		return nil, fmt.Errorf("%w: unknown currency %s", ErrUnsupportedPair, base)
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("fetch rates: HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var parsed struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parse rates: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cache[base] = fxRates{rates: parsed.Rates, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return parsed.Rates, nil
+}
+
+// MockFXClient is an FXClient with fixed rates, for tests.
+type MockFXClient struct {
+	rates map[string]float64 // "FROM/TO" -> rate
+}
+
+// NewMockFX creates a mock FX client from rates keyed "FROM/TO", e.g.
+// {"USD/EUR": 0.92}. Inverse rates are derived, and codes are normalized,
+// so "USDC/EURC" and "EUR/USD" work too.
+func NewMockFX(rates map[string]float64) *MockFXClient {
+	m := &MockFXClient{rates: make(map[string]float64, len(rates)*2)}
+	for pair, rate := range rates {
+		from, to, _ := strings.Cut(pair, "/")
+		from, to = NormalizeCurrency(from), NormalizeCurrency(to)
+		m.rates[from+"/"+to] = rate
+		if _, ok := m.rates[to+"/"+from]; !ok && rate != 0 {
+			m.rates[to+"/"+from] = 1 / rate
+		}
+	}
+	return m
+}
+
+// Rate returns the configured rate for the pair.
+func (m *MockFXClient) Rate(ctx context.Context, from, to string) (float64, error) {
+	from, to = NormalizeCurrency(from), NormalizeCurrency(to)
+	if from == to {
+		return 1, nil
+	}
+	rate, ok := m.rates[from+"/"+to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s to %s", ErrUnsupportedPair, from, to)
+	}
+	return rate, nil
+}
+
+// Convert converts amount between currencies using fx.
+func Convert(ctx context.Context, fx FXClient, amount float64, from, to string) (float64, error) {
+	rate, err := fx.Rate(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// NewConvertCurrencyTool creates the convert_currency tool, which converts an
+// amount between currencies (including USDC and EURC) so the agent can show
+// approximate equivalents like "$50 (about €46)".
+func NewConvertCurrencyTool(fx FXClient) core.Tool {
+	return New(ConvertCurrencyToolName).
+		Description("Convert an amount between currencies using current exchange rates. Use it to show approximate equivalents, e.g. \"$50 (about €46)\". USDC converts as US dollars and EURC as euros. Rates are indicative, not the rate a transfer will get.").
+		Schema(ObjectSchema(map[string]interface{}{
+			"amount": StringProperty("Amount to convert, e.g. '50.00'"),
+			"from":   StringProperty("Currency to convert from (e.g., 'USD', 'USDC', 'EUR', 'EURC', 'GBP')"),
+			"to":     StringProperty("Currency to convert to"),
+		}, "amount", "from", "to")).
+		HandlerFunc(func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+			var params struct {
+				Amount string `json:"amount"`
+				From   string `json:"from"`
+				To     string `json:"to"`
+			}
+			if err := json.Unmarshal(input, &params); err != nil {
+				return nil, fmt.Errorf("invalid input: %w", err)
+			}
+			amount, err := strconv.ParseFloat(strings.TrimSpace(params.Amount), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid amount %q", params.Amount)
+			}
+
+			rate, err := fx.Rate(ctx, params.From, params.To)
+			if errors.Is(err, ErrUnsupportedPair) {
+				return nil, fmt.Errorf("can't convert %s to %s: no exchange rate available", params.From, params.To)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("exchange rates unavailable: %w", err)
+			}
+
+			return map[string]interface{}{
+				"amount":    params.Amount,
+				"from":      strings.ToUpper(params.From),
+				"to":        strings.ToUpper(params.To),
+				"rate":      rate,
+				"converted": strconv.FormatFloat(amount*rate, 'f', 2, 64),
+			}, nil
+		}).
+		Build()
+}
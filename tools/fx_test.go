@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestHTTPFXClient(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("from") != "USD" {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"amount":1.0,"base":"USD","rates":{"EUR":0.92,"GBP":0.79}}`))
+	}))
+	defer srv.Close()
+
+	fx := NewHTTPFXClient(HTTPFXConfig{Endpoint: srv.URL})
+	ctx := context.Background()
+
+	if got, err := Convert(ctx, fx, 50, "USDC", "EURC"); err != nil || got != 46 {
+		t.Errorf("Convert(50 USDC→EURC) = (%v, %v), want 46", got, err)
+	}
+	if _, err := fx.Rate(ctx, "usd", "gbp"); err != nil {
+		t.Errorf("Rate(usd, gbp) error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("rates endpoint called %d times, want 1 (cached)", calls)
+	}
+
+	if _, err := fx.Rate(ctx, "USD", "LIL"); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("Rate(USD, LIL) error = %v, want ErrUnsupportedPair", err)
+	}
+	if _, err := fx.Rate(ctx, "LIL", "USD"); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("Rate(LIL, USD) error = %v, want ErrUnsupportedPair", err)
+	}
+}
+
+func TestConvertCurrencyTool(t *testing.T) {
+	tool := NewConvertCurrencyTool(NewMockFX(map[string]float64{"USD/EUR": 0.8}))
+
+	result, err := tool.Execute(context.Background(), &core.ToolParams{Input: []byte(`{"amount":"46","from":"EURC","to":"USDC"}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() = (%+v, %v), want success", result, err)
+	}
+	if got := result.Data.(map[string]interface{})["converted"]; got != "57.50" {
+		t.Errorf("converted = %v, want 57.50", got)
+	}
+
+	result, _ = tool.Execute(context.Background(), &core.ToolParams{Input: []byte(`{"amount":"10","from":"USD","to":"LIL"}`)})
+	if result.Success || result.Error != "can't convert USD to LIL: no exchange rate available" {
+		t.Errorf("unsupported pair result = %+v, want a friendly error", result)
+	}
+}
@@ -54,7 +54,8 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 			ToolName:                 "send_money",
 			ToolDescription:          "Send money to another user. When users say 'USD' or 'dollars', use 'USDC'. When users say 'EUR' or 'euros', use 'EURC'. Requires confirmation.",
 			RequiresUserConfirmation: true,
-			SummaryTemplate:          "Send {{.amount}} {{.currency}} to {{.recipient}}",
+			SummaryTemplate:          "Send {{money .amount}} {{.currency}} to {{.recipient}}{{with .note}} ({{.}}){{end}}",
+			SummaryKey:               "summary.send_money",
 			InputSchema: BuildSchemaWithThought(map[string]interface{}{
 				"recipient": StringProperty("Recipient's display tag (e.g., @alice) or user ID"),
 				"amount":    StringProperty("Amount to send (e.g., '50.00')"),
@@ -66,7 +67,8 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 			ToolName:                 "deposit_savings",
 			ToolDescription:          "Deposit funds into savings to earn yield. When users say 'USD' or 'dollars', use 'USDC'. When users say 'EUR' or 'euros', use 'EURC'. Requires confirmation.",
 			RequiresUserConfirmation: true,
-			SummaryTemplate:          "Deposit {{.amount}} {{.currency}} into savings",
+			SummaryTemplate:          "Deposit {{money .amount}} {{.currency}} into savings",
+			SummaryKey:               "summary.deposit_savings",
 			InputSchema: BuildSchemaWithThought(map[string]interface{}{
 				"amount":   StringProperty("Amount to deposit"),
 				"currency": StringProperty("Currency to deposit. Use 'USDC' for dollars, 'EURC' for euros"),
@@ -76,7 +78,8 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 			ToolName:                 "withdraw_savings",
 			ToolDescription:          "Withdraw funds from savings back to your wallet. When users say 'USD' or 'dollars', use 'USDC'. When users say 'EUR' or 'euros', use 'EURC'. Requires confirmation.",
 			RequiresUserConfirmation: true,
-			SummaryTemplate:          "Withdraw {{.amount}} {{.currency}} from savings",
+			SummaryTemplate:          "Withdraw {{money .amount}} {{.currency}} from savings",
+			SummaryKey:               "summary.withdraw_savings",
 			InputSchema: BuildSchemaWithThought(map[string]interface{}{
 				"amount":   StringProperty("Amount to withdraw"),
 				"currency": StringProperty("Currency to withdraw. Use 'USDC' for dollars, 'EURC' for euros"),
@@ -87,6 +90,7 @@ func LiminalToolDefinitions() []core.ToolDefinition {
 			ToolDescription:          "Execute an arbitrary smart contract call on any blockchain. Requires confirmation. You must provide pre-encoded calldata as hex.",
 			RequiresUserConfirmation: true,
 			SummaryTemplate:          "Execute contract call on chain {{.chain_id}} to {{.to}}",
+			SummaryKey:               "summary.execute_contract_call",
 			InputSchema: BuildSchemaWithThought(map[string]interface{}{
 				"chain_id": IntegerProperty("Chain ID (42161=Arbitrum, 8453=Base, 1=Ethereum)"),
 				"to":       StringProperty("Contract address (0x...)"),
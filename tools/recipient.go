@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/analytics"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// RecipientGuard is a send_money tool that warns in its confirmation summary
+// when the user is sending to someone they have never paid before, since a
+// mistyped tag on a new recipient is the costliest mistake a payment agent
+// can make. Create it with FirstTimeRecipientGuard.
+type RecipientGuard struct {
+	*core.ExecutorTool
+	exec core.ToolExecutor
+
+	// Logger receives the guard's structured logs (default slog.Default()).
+	Logger *slog.Logger
+}
+
+// FirstTimeRecipientGuard creates the Liminal send_money tool, executed
+// through exec, with a first-time recipient check: before asking for
+// confirmation it looks through the user's recent transactions (via
+// get_transactions) for a previous payment to the recipient, and if there is
+// none, appends a warning to the summary. Register it in place of the
+// standard tool:
+//
+//	srv.AddTools(tools.LiminalTools(liminal)...)
+//	srv.OverrideTool(tools.FirstTimeRecipientGuard(liminal))
+func FirstTimeRecipientGuard(exec core.ToolExecutor) *RecipientGuard {
+	var def core.ToolDefinition
+	for _, d := range LiminalToolDefinitions() {
+		if d.ToolName == "send_money" {
+			def = d
+		}
+	}
+	return &RecipientGuard{ExecutorTool: core.NewExecutorTool(def, exec), exec: exec, Logger: slog.Default()}
+}
+
+// GetSummaryContext returns the send_money summary, with a warning if the
+// user has not paid the recipient before. If their history can't be
+// fetched, the warning says so rather than being silently dropped.
+func (g *RecipientGuard) GetSummaryContext(ctx context.Context, userID string, input json.RawMessage) string {
+	summary := core.LocalizedSummary(ctx, g, input)
+
+	var params struct {
+		Recipient string `json:"recipient"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil || params.Recipient == "" {
+		return summary
+	}
+
+	paid, err := g.hasPaid(ctx, userID, params.Recipient)
+	if err != nil {
+		g.logger().WarnContext(ctx, "recipient history check failed", "user_id", userID, "recipient", params.Recipient, "source", "get_transactions", "error", err)
+		return summary + "\n\n" + core.Localize(ctx, core.MsgRecipientCheckFailed, map[string]interface{}{"recipient": params.Recipient})
+	}
+	if !paid {
+		return summary + "\n\n" + core.Localize(ctx, core.MsgFirstTimeRecipient, map[string]interface{}{"recipient": params.Recipient})
+	}
+	return summary
+}
+
+func (g *RecipientGuard) logger() *slog.Logger {
+	if g.Logger == nil {
+		return slog.Default()
+	}
+	return g.Logger
+}
+
+// hasPaid reports whether the user's recent transactions include a payment
+// to recipient. Tags are compared case-insensitively, with or without "@".
+func (g *RecipientGuard) hasPaid(ctx context.Context, userID, recipient string) (bool, error) {
+	input, _ := json.Marshal(map[string]interface{}{"limit": 100})
+	resp, err := g.exec.Execute(ctx, &core.ExecuteRequest{
+		UserID: userID,
+		Tool:   "get_transactions",
+		Input:  input,
+	})
+	if err != nil {
+		return false, fmt.Errorf("execute get_transactions: %w", err)
+	}
+	if !resp.Success {
+		return false, fmt.Errorf("get_transactions failed: %s", resp.Error)
+	}
+
+	var txns executor.GetTransactionsResponse
+	if err := json.Unmarshal(resp.Data, &txns); err != nil {
+		return false, fmt.Errorf("parse get_transactions response: %w", err)
+	}
+	want := normalizeRecipient(recipient)
+	for _, tx := range analytics.FromLiminal(txns.Transactions) {
+		if tx.Amount < 0 && normalizeRecipient(tx.Counterparty) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func normalizeRecipient(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "@"))
+}
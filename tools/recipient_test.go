@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestFirstTimeRecipientGuard(t *testing.T) {
+	mock := executor.NewMock()
+	mock.On("get_transactions").Return(executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+		{Type: "send", Amount: "20", Currency: "USDC", Counterparty: "@Alice", CreatedAt: "2026-03-01T10:00:00Z"},
+		{Type: "receive", Amount: "50", Currency: "USDC", Counterparty: "@bob", CreatedAt: "2026-03-02T10:00:00Z"},
+	}})
+	guard := FirstTimeRecipientGuard(mock)
+	ctx := context.Background()
+
+	tests := []struct {
+		recipient string
+		warn      bool
+	}{
+		{"@alice", false}, // paid before; tags compare case-insensitively
+		{"alice", false},
+		{"@bob", true}, // only received money from them
+		{"@mallory", true},
+	}
+	for _, tt := range tests {
+		input := []byte(`{"recipient":"` + tt.recipient + `","amount":"10","currency":"USDC"}`)
+		summary := guard.GetSummaryContext(ctx, "user-1", input)
+		if !strings.HasPrefix(summary, guard.GetSummary(input)) {
+			t.Errorf("%s: summary %q doesn't start with the send_money summary", tt.recipient, summary)
+		}
+		if got := strings.Contains(summary, "never sent money to "+tt.recipient); got != tt.warn {
+			t.Errorf("%s: warning = %v, want %v (summary %q)", tt.recipient, got, tt.warn, summary)
+		}
+	}
+	if calls := mock.Calls("get_transactions"); len(calls) != len(tests) || calls[0].UserID != "user-1" {
+		t.Errorf("get_transactions calls = %+v", calls)
+	}
+
+	var logs bytes.Buffer
+	guard.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	mock.On("get_transactions").Fail(errors.New("gateway down"))
+	summary := guard.GetSummaryContext(ctx, "user-1", []byte(`{"recipient":"@alice","amount":"10","currency":"USDC"}`))
+	if !strings.Contains(summary, "Couldn't check whether you've sent money to @alice") {
+		t.Errorf("summary on history failure = %q, want a warning", summary)
+	}
+	if got := logs.String(); !strings.Contains(got, "source=get_transactions") || !strings.Contains(got, "user_id=user-1") || !strings.Contains(got, "gateway down") {
+		t.Errorf("log = %q, want the failed source, user and error", got)
+	}
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/becomeliminal/nim-go-sdk/analytics"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// FindSubscriptionsToolName is the name of the subscription finder tool.
+const FindSubscriptionsToolName = "find_subscriptions"
+
+// NewFindSubscriptionsTool creates the find_subscriptions tool, which fetches
+// the user's recent transactions through exec (get_transactions) and lists
+// recurring outgoing payments found by analytics.DetectRecurring.
+func NewFindSubscriptionsTool(exec core.ToolExecutor) core.Tool {
+	return New(FindSubscriptionsToolName).
+		Description("Find the user's subscriptions and other recurring payments (weekly, biweekly or monthly) from their transaction history. Returns each one's amount, cadence, next expected date and a confidence score between 0 and 1.").
+		Schema(ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			input, _ := json.Marshal(map[string]interface{}{"limit": 100})
+			resp, err := exec.Execute(ctx, &core.ExecuteRequest{
+				UserID:    params.UserID,
+				Tool:      "get_transactions",
+				Input:     input,
+				RequestID: params.RequestID,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+			}
+			if !resp.Success {
+				return &core.ToolResult{Success: false, Error: "failed to fetch transactions: " + resp.Error}, nil
+			}
+
+			var txns executor.GetTransactionsResponse
+			if err := json.Unmarshal(resp.Data, &txns); err != nil {
+				return nil, fmt.Errorf("failed to parse transactions: %w", err)
+			}
+
+			subscriptions := []map[string]interface{}{}
+			for _, s := range analytics.DetectRecurring(analytics.FromLiminal(txns.Transactions)) {
+				if s.Amount >= 0 {
+					continue // recurring income, not a subscription
+				}
+				subscriptions = append(subscriptions, map[string]interface{}{
+					"counterparty":  s.Counterparty,
+					"amount":        strconv.FormatFloat(-s.Amount, 'f', 2, 64),
+					"currency":      s.Currency,
+					"period":        string(s.Period),
+					"occurrences":   len(s.Occurrences),
+					"last_paid":     s.LastSeen.Format("2006-01-02"),
+					"next_expected": s.NextExpected.Format("2006-01-02"),
+					"confidence":    s.Confidence,
+				})
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"subscriptions": subscriptions,
+					"count":         len(subscriptions),
+				},
+			}, nil
+		}).
+		Build()
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+func TestFindSubscriptionsTool(t *testing.T) {
+	mock := executor.NewMock()
+	mock.On("get_transactions").Return(executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+		{Type: "send", Amount: "9.99", Currency: "USDC", Counterparty: "@spotify", CreatedAt: "2026-01-03T10:00:00Z"},
+		{Type: "send", Amount: "9.99", Currency: "USDC", Counterparty: "@spotify", CreatedAt: "2026-02-03T10:00:00Z"},
+		{Type: "send", Amount: "9.99", Currency: "USDC", Counterparty: "@spotify", CreatedAt: "2026-03-03T10:00:00Z"},
+		{Type: "receive", Amount: "2500", Currency: "USDC", Counterparty: "@employer", CreatedAt: "2026-01-28T10:00:00Z"},
+		{Type: "receive", Amount: "2500", Currency: "USDC", Counterparty: "@employer", CreatedAt: "2026-02-28T10:00:00Z"},
+	}})
+
+	result, err := NewFindSubscriptionsTool(mock).Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: []byte(`{}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() = (%+v, %v), want success", result, err)
+	}
+
+	subs := result.Data.(map[string]interface{})["subscriptions"].([]map[string]interface{})
+	if len(subs) != 1 {
+		t.Fatalf("got %d subscriptions, want 1 (salary is not a subscription): %v", len(subs), subs)
+	}
+	if subs[0]["counterparty"] != "@spotify" || subs[0]["amount"] != "9.99" || subs[0]["period"] != "monthly" || subs[0]["next_expected"] != "2026-04-03" {
+		t.Errorf("subscription = %v", subs[0])
+	}
+	if calls := mock.Calls("get_transactions"); len(calls) != 1 || calls[0].UserID != "user-1" {
+		t.Errorf("get_transactions calls = %+v", calls)
+	}
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// TransactionFetcher pages through a user's Liminal transaction history
+// (get_transactions).
+type TransactionFetcher struct {
+	Executor core.ToolExecutor
+
+	// PageSize is the limit requested per page. Default: 100.
+	PageSize int
+
+	// MaxPages caps the pages fetched, bounding the calls made for a user
+	// with a long history. Default: 20.
+	MaxPages int
+}
+
+// FetchAllTransactions returns userID's transactions created since since,
+// paging through get_transactions with the default TransactionFetcher.
+func FetchAllTransactions(ctx context.Context, exec core.ToolExecutor, userID string, since time.Time) ([]executor.Transaction, error) {
+	f := &TransactionFetcher{Executor: exec}
+	return f.FetchSince(ctx, userID, since)
+}
+
+// FetchSince returns userID's transactions created since since, newest
+// first, as get_transactions returns them. It follows the page's nextCursor
+// (or next_cursor) if the API returns one, and otherwise asks for the next
+// page by offset. Paging stops at the first transaction older than since,
+// at a short page, at a page with nothing new (an API that ignores offset)
+// or after MaxPages pages, returning what was fetched. Transactions without
+// a valid createdAt are kept.
+func (f *TransactionFetcher) FetchSince(ctx context.Context, userID string, since time.Time) ([]executor.Transaction, error) {
+	pageSize := f.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	maxPages := f.MaxPages
+	if maxPages <= 0 {
+		maxPages = 20
+	}
+
+	var all []executor.Transaction
+	seen := make(map[string]bool)
+	cursor := ""
+	fetched := 0
+	for page := 0; page < maxPages; page++ {
+		input := map[string]interface{}{"limit": pageSize}
+		switch {
+		case cursor != "":
+			input["cursor"] = cursor
+		case page > 0:
+			input["offset"] = fetched
+		}
+		txns, next, err := f.fetchPage(ctx, userID, input)
+		if err != nil {
+			return nil, err
+		}
+		fetched += len(txns)
+
+		added, done := 0, false
+		for _, tx := range txns {
+			if tx.ID != "" {
+				if seen[tx.ID] {
+					continue
+				}
+				seen[tx.ID] = true
+			}
+			added++
+			if at, err := time.Parse(time.RFC3339, tx.CreatedAt); err == nil && at.Before(since) {
+				done = true
+				continue
+			}
+			all = append(all, tx)
+		}
+
+		switch {
+		case done, added == 0:
+			return all, nil
+		case next != "":
+			cursor = next
+		case cursor != "", len(txns) < pageSize:
+			// The cursor ran out, or a short page ended the history
+			return all, nil
+		}
+	}
+	return all, nil
+}
+
+func (f *TransactionFetcher) fetchPage(ctx context.Context, userID string, input map[string]interface{}) ([]executor.Transaction, string, error) {
+	data, _ := json.Marshal(input)
+	resp, err := f.Executor.Execute(ctx, &core.ExecuteRequest{
+		UserID: userID,
+		Tool:   "get_transactions",
+		Input:  data,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	if !resp.Success {
+		return nil, "", fmt.Errorf("failed to fetch transactions: %s", resp.Error)
+	}
+
+	var page struct {
+		executor.GetTransactionsResponse
+		NextCursorSnake string `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(resp.Data, &page); err != nil {
+		return nil, "", fmt.Errorf("failed to parse transactions: %w", err)
+	}
+	next := page.NextCursor
+	if next == "" {
+		next = page.NextCursorSnake
+	}
+	return page.Transactions, next, nil
+}
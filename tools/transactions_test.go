@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// historyExecutor serves get_transactions from a newest-first history, by
+// cursor or, if offsets is set, by offset.
+type historyExecutor struct {
+	*executor.MockExecutor
+	history []executor.Transaction
+	offsets bool
+	inputs  []map[string]interface{}
+}
+
+func (e *historyExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	var input struct {
+		Limit  int    `json:"limit"`
+		Cursor string `json:"cursor"`
+		Offset int    `json:"offset"`
+	}
+	json.Unmarshal(req.Input, &input)
+	var raw map[string]interface{}
+	json.Unmarshal(req.Input, &raw)
+	e.inputs = append(e.inputs, raw)
+
+	start := input.Offset
+	if !e.offsets {
+		start = 0
+		fmt.Sscan(input.Cursor, &start)
+	}
+	end := min(start+input.Limit, len(e.history))
+	resp := map[string]interface{}{"transactions": e.history[min(start, end):end]}
+	if !e.offsets && end < len(e.history) {
+		resp["next_cursor"] = fmt.Sprint(end)
+	}
+	data, _ := json.Marshal(resp)
+	return &core.ExecuteResponse{Success: true, Data: data}, nil
+}
+
+// dailyHistory returns one transaction a day for days days, newest first.
+func dailyHistory(now time.Time, days int) []executor.Transaction {
+	history := make([]executor.Transaction, days)
+	for i := range history {
+		history[i] = executor.Transaction{
+			ID:        fmt.Sprintf("tx-%d", i),
+			Amount:    "10",
+			CreatedAt: now.AddDate(0, 0, -i).Format(time.RFC3339),
+		}
+	}
+	return history
+}
+
+func TestFetchAllTransactions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	since := now.AddDate(0, 0, -89).Add(-time.Hour)
+
+	for _, offsets := range []bool{false, true} {
+		t.Run(fmt.Sprintf("offsets=%v", offsets), func(t *testing.T) {
+			exec := &historyExecutor{MockExecutor: executor.NewMock(), history: dailyHistory(now, 200), offsets: offsets}
+			fetcher := &TransactionFetcher{Executor: exec, PageSize: 25}
+
+			txns, err := fetcher.FetchSince(context.Background(), "user-1", since)
+			if err != nil {
+				t.Fatalf("FetchSince() error = %v", err)
+			}
+			if len(txns) != 90 || txns[0].ID != "tx-0" || txns[89].ID != "tx-89" {
+				t.Fatalf("FetchSince() returned %d transactions, want the 90 days since %v", len(txns), since)
+			}
+			// Four pages reach the first transaction older than since
+			if len(exec.inputs) != 4 {
+				t.Errorf("fetched %d pages, want 4: %v", len(exec.inputs), exec.inputs)
+			}
+			if _, ok := exec.inputs[0]["offset"]; ok {
+				t.Errorf("first page input = %v, want no offset", exec.inputs[0])
+			}
+		})
+	}
+}
+
+func TestFetchAllTransactionsStops(t *testing.T) {
+	now := time.Now()
+	history := dailyHistory(now, 30)
+
+	tests := []struct {
+		name      string
+		history   []executor.Transaction
+		offsets   bool
+		maxPages  int
+		wantTxns  int
+		wantPages int
+	}{
+		// The history runs out before since
+		{"short page", history, true, 0, 30, 2},
+		{"cursor ends", history, false, 0, 30, 2},
+		{"safety cap", dailyHistory(now, 500), false, 3, 60, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &historyExecutor{MockExecutor: executor.NewMock(), history: tt.history, offsets: tt.offsets}
+			fetcher := &TransactionFetcher{Executor: exec, PageSize: 20, MaxPages: tt.maxPages}
+			txns, err := fetcher.FetchSince(context.Background(), "user-1", now.AddDate(-5, 0, 0))
+			if err != nil {
+				t.Fatalf("FetchSince() error = %v", err)
+			}
+			if len(txns) != tt.wantTxns || len(exec.inputs) != tt.wantPages {
+				t.Errorf("FetchSince() = %d transactions in %d pages, want %d in %d", len(txns), len(exec.inputs), tt.wantTxns, tt.wantPages)
+			}
+		})
+	}
+
+	// An API that ignores offset returns the first page again
+	mock := executor.NewMock()
+	mock.On("get_transactions").Return(executor.GetTransactionsResponse{Transactions: dailyHistory(now, 100)})
+	txns, err := FetchAllTransactions(context.Background(), mock, "user-1", now.AddDate(-1, 0, 0))
+	if err != nil || len(txns) != 100 || len(mock.Calls("get_transactions")) != 2 {
+		t.Errorf("FetchAllTransactions() = (%d, %v) in %d calls, want the 100 unique transactions in 2", len(txns), err, len(mock.Calls("get_transactions")))
+	}
+}
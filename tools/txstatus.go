@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// PollTransactionStatusToolName is the name of the transaction status tool.
+const PollTransactionStatusToolName = "poll_transaction_status"
+
+// ErrTxNotFound is returned by a TxStatusChecker that doesn't know the
+// transaction.
+var ErrTxNotFound = errors.New("transaction not found")
+
+// TxRef identifies a submitted transaction, as returned by send_money or
+// execute_contract_call.
+type TxRef struct {
+	TransactionID string `json:"transactionId,omitempty"`
+	TxHash        string `json:"txHash,omitempty"`
+}
+
+// ParseTxRef extracts the transaction reference from a write tool's result.
+// It returns false if the result doesn't reference a transaction.
+func ParseTxRef(result *core.ToolResult) (TxRef, bool) {
+	var ref TxRef
+	if err := result.UnmarshalData(&ref); err != nil {
+		return TxRef{}, false
+	}
+	return ref, ref.TransactionID != "" || ref.TxHash != ""
+}
+
+// TxStatusChecker looks up the current status of a transaction.
+type TxStatusChecker interface {
+	TxStatus(ctx context.Context, userID string, ref TxRef) (core.TxStatus, error)
+}
+
+// TxStatusCheckers asks each checker in order, moving on when one returns
+// ErrTxNotFound, e.g. the chain for transactions with a hash and then
+// Liminal's history.
+type TxStatusCheckers []TxStatusChecker
+
+// TxStatus implements TxStatusChecker.
+func (c TxStatusCheckers) TxStatus(ctx context.Context, userID string, ref TxRef) (core.TxStatus, error) {
+	for _, checker := range c {
+		status, err := checker.TxStatus(ctx, userID, ref)
+		if !errors.Is(err, ErrTxNotFound) {
+			return status, err
+		}
+	}
+	return "", ErrTxNotFound
+}
+
+// LiminalTxStatus checks transactions against the user's recent Liminal
+// history (get_transactions).
+type LiminalTxStatus struct {
+	Executor core.ToolExecutor
+
+	// Lookback is how many recent transactions to search. Default: 20.
+	Lookback int
+}
+
+// TxStatus implements TxStatusChecker.
+func (l *LiminalTxStatus) TxStatus(ctx context.Context, userID string, ref TxRef) (core.TxStatus, error) {
+	lookback := l.Lookback
+	if lookback <= 0 {
+		lookback = 20
+	}
+	input, _ := json.Marshal(map[string]interface{}{"limit": lookback})
+	resp, err := l.Executor.Execute(ctx, &core.ExecuteRequest{
+		UserID: userID,
+		Tool:   "get_transactions",
+		Input:  input,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	if !resp.Success {
+		return "", fmt.Errorf("failed to fetch transactions: %s", resp.Error)
+	}
+
+	var txns executor.GetTransactionsResponse
+	if err := json.Unmarshal(resp.Data, &txns); err != nil {
+		return "", fmt.Errorf("failed to parse transactions: %w", err)
+	}
+	for _, tx := range txns.Transactions {
+		if (ref.TransactionID != "" && tx.ID == ref.TransactionID) ||
+			(ref.TxHash != "" && strings.EqualFold(tx.TxHash, ref.TxHash)) {
+			return ParseTxStatus(tx.Status), nil
+		}
+	}
+	return "", ErrTxNotFound
+}
+
+// ParseTxStatus maps a backend status string (e.g. "completed", "failed")
+// to a TxStatus. Unrecognised statuses are treated as pending.
+func ParseTxStatus(status string) core.TxStatus {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "confirmed", "completed", "complete", "success", "succeeded", "settled":
+		return core.TxConfirmed
+	case "reverted":
+		return core.TxReverted
+	case "failed", "failure", "rejected", "cancelled", "canceled", "error":
+		return core.TxFailed
+	default:
+		return core.TxPending
+	}
+}
+
+// PollTxStatus checks ref every interval until its status is final or ctx
+// is done, and returns the last status seen. On timeout it returns
+// core.TxPending with ctx's error. A transaction the checker can't find yet
+// counts as pending, since history may lag the write.
+func PollTxStatus(ctx context.Context, checker TxStatusChecker, userID string, ref TxRef, interval time.Duration) (core.TxStatus, error) {
+	for {
+		status, err := checker.TxStatus(ctx, userID, ref)
+		switch {
+		case errors.Is(err, ErrTxNotFound):
+			status = core.TxPending
+		case err != nil:
+			return "", err
+		case status.Final():
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return core.TxPending, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// TxPoller waits for the transaction behind a confirmed write to settle. It
+// implements engine.TransactionPoller.
+type TxPoller struct {
+	Checker TxStatusChecker
+
+	// Interval between checks. Default: 2s.
+	Interval time.Duration
+}
+
+// PollTransaction waits for the transaction referenced by a write's result.
+// It returns false if the result doesn't reference a transaction.
+func (p *TxPoller) PollTransaction(ctx context.Context, userID, tool string, result *core.ToolResult) (core.TxStatus, bool, error) {
+	ref, ok := ParseTxRef(result)
+	if !ok {
+		return "", false, nil
+	}
+	status, err := PollTxStatus(ctx, p.Checker, userID, ref, p.interval())
+	return status, true, err
+}
+
+func (p *TxPoller) interval() time.Duration {
+	if p.Interval <= 0 {
+		return 2 * time.Second
+	}
+	return p.Interval
+}
+
+// NewPollTransactionStatusTool creates the poll_transaction_status tool,
+// which waits up to a timeout for a transaction returned by send_money or
+// execute_contract_call to be confirmed, fail or revert, so the agent can
+// report what actually happened instead of assuming success.
+func NewPollTransactionStatusTool(checker TxStatusChecker) core.Tool {
+	poller := &TxPoller{Checker: checker}
+	return New(PollTransactionStatusToolName).
+		Description("Check whether a submitted transaction has landed. Pass the transactionId and/or txHash returned by send_money or execute_contract_call; waits up to timeout_seconds for it to be confirmed, fail or revert, and returns the status (pending, confirmed, failed or reverted).").
+		Schema(ObjectSchema(map[string]interface{}{
+			"transaction_id":  StringProperty("Transaction ID returned by the write (transactionId)"),
+			"tx_hash":         StringProperty("On-chain transaction hash returned by the write (txHash)"),
+			"timeout_seconds": IntegerProperty("How long to wait for a final status (default: 30, max: 120)"),
+		})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			var input struct {
+				TransactionID  string `json:"transaction_id"`
+				TxHash         string `json:"tx_hash"`
+				TimeoutSeconds int    `json:"timeout_seconds"`
+			}
+			if err := json.Unmarshal(params.Input, &input); err != nil {
+				return &core.ToolResult{Success: false, Error: "invalid input: " + err.Error()}, nil
+			}
+			if input.TransactionID == "" && input.TxHash == "" {
+				return &core.ToolResult{Success: false, Error: "transaction_id or tx_hash is required"}, nil
+			}
+			timeout := 30 * time.Second
+			if input.TimeoutSeconds > 0 {
+				timeout = time.Duration(min(input.TimeoutSeconds, 120)) * time.Second
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			ref := TxRef{TransactionID: input.TransactionID, TxHash: input.TxHash}
+			status, err := PollTxStatus(ctx, checker, params.UserID, ref, poller.interval())
+			if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				return &core.ToolResult{Success: false, Error: "failed to check transaction status: " + err.Error()}, nil
+			}
+
+			data := map[string]interface{}{
+				"status": string(status),
+				"final":  status.Final(),
+			}
+			if ref.TransactionID != "" {
+				data["transaction_id"] = ref.TransactionID
+			}
+			if ref.TxHash != "" {
+				data["tx_hash"] = ref.TxHash
+			}
+			if !status.Final() {
+				data["note"] = fmt.Sprintf("Still pending after %s; check again later", timeout)
+			}
+			return &core.ToolResult{Success: true, Data: data}, nil
+		}).
+		Build()
+}
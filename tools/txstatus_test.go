@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// sequenceChecker returns its statuses in order, repeating the last.
+type sequenceChecker struct {
+	statuses []core.TxStatus
+	calls    int
+}
+
+func (c *sequenceChecker) TxStatus(ctx context.Context, userID string, ref TxRef) (core.TxStatus, error) {
+	status := c.statuses[min(c.calls, len(c.statuses)-1)]
+	c.calls++
+	if status == "" {
+		return "", ErrTxNotFound
+	}
+	return status, nil
+}
+
+func TestLiminalTxStatus(t *testing.T) {
+	mock := executor.NewMock()
+	mock.On("get_transactions").Return(executor.GetTransactionsResponse{Transactions: []executor.Transaction{
+		{ID: "tx-1", Status: "completed"},
+		{ID: "tx-2", Status: "processing", TxHash: "0xABC"},
+		{ID: "tx-3", Status: "failed"},
+	}})
+	checker := &LiminalTxStatus{Executor: mock}
+
+	tests := []struct {
+		ref  TxRef
+		want core.TxStatus
+	}{
+		{TxRef{TransactionID: "tx-1"}, core.TxConfirmed},
+		{TxRef{TxHash: "0xabc"}, core.TxPending},
+		{TxRef{TransactionID: "tx-3"}, core.TxFailed},
+	}
+	for _, tt := range tests {
+		if got, err := checker.TxStatus(context.Background(), "user-1", tt.ref); err != nil || got != tt.want {
+			t.Errorf("TxStatus(%+v) = (%q, %v), want %q", tt.ref, got, err, tt.want)
+		}
+	}
+	if _, err := checker.TxStatus(context.Background(), "user-1", TxRef{TransactionID: "tx-9"}); err != ErrTxNotFound {
+		t.Errorf("TxStatus(unknown) error = %v, want ErrTxNotFound", err)
+	}
+}
+
+func TestPollTxStatus(t *testing.T) {
+	// Not yet in history, then pending, then reverted on-chain
+	checker := &sequenceChecker{statuses: []core.TxStatus{"", core.TxPending, core.TxReverted}}
+	status, err := PollTxStatus(context.Background(), checker, "user-1", TxRef{TxHash: "0x1"}, time.Millisecond)
+	if err != nil || status != core.TxReverted || checker.calls != 3 {
+		t.Errorf("PollTxStatus() = (%q, %v) after %d checks, want reverted after 3", status, err, checker.calls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	status, err = PollTxStatus(ctx, &sequenceChecker{statuses: []core.TxStatus{core.TxPending}}, "user-1", TxRef{TxHash: "0x1"}, time.Millisecond)
+	if status != core.TxPending || err == nil {
+		t.Errorf("PollTxStatus() on timeout = (%q, %v), want pending with an error", status, err)
+	}
+}
+
+func TestTxStatusCheckersFallThrough(t *testing.T) {
+	checkers := TxStatusCheckers{
+		&sequenceChecker{statuses: []core.TxStatus{""}},
+		&sequenceChecker{statuses: []core.TxStatus{core.TxConfirmed}},
+	}
+	if got, err := checkers.TxStatus(context.Background(), "user-1", TxRef{TransactionID: "tx-1"}); err != nil || got != core.TxConfirmed {
+		t.Errorf("TxStatus() = (%q, %v), want confirmed from the second checker", got, err)
+	}
+}
+
+func TestPollTransactionStatusTool(t *testing.T) {
+	tool := NewPollTransactionStatusTool(&sequenceChecker{statuses: []core.TxStatus{core.TxConfirmed}})
+
+	result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{"transaction_id":"tx-1"}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() = (%+v, %v), want success", result, err)
+	}
+	data := result.Data.(map[string]interface{})
+	if data["status"] != "confirmed" || data["final"] != true || data["transaction_id"] != "tx-1" {
+		t.Errorf("Data = %v", data)
+	}
+
+	result, _ = tool.Execute(context.Background(), &core.ToolParams{UserID: "user-1", Input: json.RawMessage(`{}`)})
+	if result.Success {
+		t.Error("Execute() without a reference succeeded")
+	}
+}
+
+func TestParseTxRef(t *testing.T) {
+	ref, ok := ParseTxRef(&core.ToolResult{Success: true, Data: json.RawMessage(`{"success":true,"transactionId":"tx-1","txHash":"0xabc"}`)})
+	if !ok || ref.TransactionID != "tx-1" || ref.TxHash != "0xabc" {
+		t.Errorf("ParseTxRef() = (%+v, %v)", ref, ok)
+	}
+	if _, ok := ParseTxRef(&core.ToolResult{Success: true, Data: map[string]interface{}{"status": "sent"}}); ok {
+		t.Error("ParseTxRef() found a reference in a result without one")
+	}
+}